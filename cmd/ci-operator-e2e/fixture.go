@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const (
+	// fixtureFile holds the expectations of a fixture
+	fixtureFile = "fixture.yaml"
+	// configFile holds the ci-operator configuration of a fixture
+	configFile = "config.yaml"
+)
+
+// fixture is a ci-operator configuration together with the outcome of
+// running it.
+type fixture struct {
+	// Name is the name of the directory holding the fixture.
+	Name string `json:"-"`
+	// Config is the path to the ci-operator configuration.
+	Config string `json:"-"`
+
+	// Targets are passed to ci-operator as --target.
+	Targets []string `json:"targets,omitempty"`
+	// Args are additional arguments for ci-operator.
+	Args []string `json:"args,omitempty"`
+	// JobSpec is passed to ci-operator as $JOB_SPEC.
+	JobSpec string `json:"job_spec,omitempty"`
+
+	// Success is whether ci-operator is expected to succeed.
+	Success bool `json:"success"`
+	// Objects are expected to be created in the test namespace.
+	Objects []expectedObject `json:"objects,omitempty"`
+	// JUnit are test cases expected in the jUnit output of ci-operator.
+	JUnit []expectedTestCase `json:"junit,omitempty"`
+}
+
+type expectedObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+func (o expectedObject) String() string {
+	return fmt.Sprintf("%s %s %s", o.APIVersion, o.Kind, o.Name)
+}
+
+type expectedTestCase struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+}
+
+// loadFixtures loads every directory in the given directory that holds a
+// fixture, ordered by name.
+func loadFixtures(dir string) ([]fixture, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read fixture directory: %w", err)
+	}
+	var fixtures []fixture
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		raw, err := os.ReadFile(filepath.Join(path, fixtureFile))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("could not read fixture %s: %w", entry.Name(), err)
+		}
+		f := fixture{Name: entry.Name(), Config: filepath.Join(path, configFile)}
+		if err := yaml.UnmarshalStrict(raw, &f); err != nil {
+			return nil, fmt.Errorf("could not parse fixture %s: %w", entry.Name(), err)
+		}
+		if _, err := os.Stat(f.Config); err != nil {
+			return nil, fmt.Errorf("fixture %s has no configuration: %w", entry.Name(), err)
+		}
+		fixtures = append(fixtures, f)
+	}
+	sort.Slice(fixtures, func(i, j int) bool {
+		return fixtures[i].Name < fixtures[j].Name
+	})
+	return fixtures, nil
+}
+
+// verifyClusterObjects ensures every expected object exists in the namespace.
+func verifyClusterObjects(ctx context.Context, client ctrlruntimeclient.Reader, namespace string, expected []expectedObject) []error {
+	var errs []error
+	for _, object := range expected {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(object.APIVersion)
+		u.SetKind(object.Kind)
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: object.Name}, u); err != nil {
+			errs = append(errs, fmt.Errorf("expected object %s: %w", object, err))
+		}
+	}
+	return errs
+}
+
+// verifyDryRunObjects ensures every expected object is described in the
+// output of a dry run.
+func verifyDryRunObjects(output []byte, expected []expectedObject) []error {
+	var dryRun []struct {
+		Actions []struct {
+			Object *unstructured.Unstructured `json:"object,omitempty"`
+		} `json:"actions"`
+	}
+	if err := yaml.Unmarshal(output, &dryRun); err != nil {
+		return []error{fmt.Errorf("could not parse dry run output: %w", err)}
+	}
+	described := map[expectedObject]bool{}
+	for _, step := range dryRun {
+		for _, action := range step.Actions {
+			if action.Object == nil {
+				continue
+			}
+			described[expectedObject{APIVersion: action.Object.GetAPIVersion(), Kind: action.Object.GetKind(), Name: action.Object.GetName()}] = true
+		}
+	}
+	var errs []error
+	for _, object := range expected {
+		if !described[object] {
+			errs = append(errs, fmt.Errorf("expected object %s was not described by the dry run", object))
+		}
+	}
+	return errs
+}
+
+// verifyJUnit ensures every expected test case is in a jUnit file in the
+// artifact directory, with the expected result.
+func verifyJUnit(artifactDir string, expected []expectedTestCase) []error {
+	files, err := filepath.Glob(filepath.Join(artifactDir, "junit*.xml"))
+	if err != nil {
+		return []error{fmt.Errorf("could not list jUnit files: %w", err)}
+	}
+	results := map[string]bool{}
+	var record func(suite *junit.TestSuite)
+	record = func(suite *junit.TestSuite) {
+		for _, testCase := range suite.TestCases {
+			results[testCase.Name] = testCase.FailureOutput == nil
+		}
+		for _, child := range suite.Children {
+			record(child)
+		}
+	}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return []error{fmt.Errorf("could not read %s: %w", file, err)}
+		}
+		suites := &junit.TestSuites{}
+		if err := xml.Unmarshal(raw, suites); err != nil {
+			return []error{fmt.Errorf("could not parse %s: %w", file, err)}
+		}
+		for _, suite := range suites.Suites {
+			record(suite)
+		}
+	}
+	var errs []error
+	for _, testCase := range expected {
+		success, ok := results[testCase.Name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("expected test case %q was not reported", testCase.Name))
+		case success != testCase.Success:
+			errs = append(errs, fmt.Errorf("expected test case %q to have success=%t, got %t", testCase.Name, testCase.Success, success))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+const (
+	// backendCluster runs ci-operator against the cluster
+	backendCluster = "cluster"
+	// backendDryRun runs ci-operator with --dry-run, so nothing is created
+	backendDryRun = "dry-run"
+)
+
+type options struct {
+	fixtureDir  string
+	ciOperator  string
+	kubeconfig  string
+	backend     string
+	artifactDir string
+	run         string
+	timeout     time.Duration
+
+	runRegexp *regexp.Regexp
+}
+
+func gatherOptions() options {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.fixtureDir, "fixtures", "", "Directory holding one directory per fixture, each with a config.yaml and a fixture.yaml.")
+	fs.StringVar(&o.ciOperator, "ci-operator", "ci-operator", "Path to the ci-operator binary under test.")
+	fs.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig of the cluster to run against. Defaults to $KUBECONFIG.")
+	fs.StringVar(&o.backend, "backend", backendCluster, fmt.Sprintf("Either %q to run the fixtures in the cluster or %q to only verify what ci-operator would create.", backendCluster, backendDryRun))
+	fs.StringVar(&o.artifactDir, "artifact-dir", "", "Directory to store the artifacts of every fixture and the jUnit results in. Defaults to $ARTIFACTS.")
+	fs.StringVar(&o.run, "run", "", "Only run the fixtures whose names match this regular expression.")
+	fs.DurationVar(&o.timeout, "timeout", 2*time.Hour, "Maximum duration of a single fixture.")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logrus.WithError(err).Fatal("could not parse arguments")
+	}
+	return o
+}
+
+func (o *options) complete() error {
+	if o.fixtureDir == "" {
+		return errors.New("--fixtures is required")
+	}
+	if o.backend != backendCluster && o.backend != backendDryRun {
+		return fmt.Errorf("--backend must be %q or %q", backendCluster, backendDryRun)
+	}
+	if o.kubeconfig == "" {
+		o.kubeconfig = os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	}
+	if o.artifactDir == "" {
+		if dir, set := api.Artifacts(); set {
+			o.artifactDir = dir
+		} else {
+			dir, err := os.MkdirTemp("", "ci-operator-e2e")
+			if err != nil {
+				return fmt.Errorf("could not create artifact directory: %w", err)
+			}
+			o.artifactDir = dir
+		}
+	}
+	var err error
+	if o.runRegexp, err = regexp.Compile(o.run); err != nil {
+		return fmt.Errorf("--run is not a valid regular expression: %w", err)
+	}
+	return nil
+}
+
+func main() {
+	o := gatherOptions()
+	if err := o.complete(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	fixtures, err := loadFixtures(o.fixtureDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not load fixtures")
+	}
+	var client ctrlruntimeclient.Client
+	if o.backend == backendCluster {
+		config, err := util.LoadKubeConfig(o.kubeconfig)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load kubeconfig")
+		}
+		if client, err = ctrlruntimeclient.New(config, ctrlruntimeclient.Options{}); err != nil {
+			logrus.WithError(err).Fatal("could not create client")
+		}
+	}
+
+	suite := &junit.TestSuite{Name: "ci-operator-e2e"}
+	for _, f := range fixtures {
+		if !o.runRegexp.MatchString(f.Name) {
+			continue
+		}
+		start := time.Now()
+		logrus.Infof("Running fixture %s", f.Name)
+		testCase := &junit.TestCase{Name: f.Name}
+		if err := o.runFixture(client, f); err != nil {
+			logrus.WithError(err).Errorf("Fixture %s failed", f.Name)
+			testCase.FailureOutput = &junit.FailureOutput{Message: "fixture failed", Output: err.Error()}
+			suite.NumFailed++
+		} else {
+			logrus.Infof("Fixture %s passed", f.Name)
+		}
+		testCase.Duration = time.Since(start).Seconds()
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.NumTests++
+	}
+	if err := writeJUnit(o.artifactDir, suite); err != nil {
+		logrus.WithError(err).Error("could not write jUnit results")
+	}
+	if suite.NumFailed > 0 {
+		logrus.Fatalf("%d of %d fixtures failed", suite.NumFailed, suite.NumTests)
+	}
+	logrus.Infof("All %d fixtures passed", suite.NumTests)
+}
+
+// runFixture runs ci-operator with the configuration of the fixture and
+// verifies the outcome.
+func (o *options) runFixture(client ctrlruntimeclient.Client, f fixture) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+	artifactDir := filepath.Join(o.artifactDir, f.Name)
+	if err := os.MkdirAll(artifactDir, 0755); err != nil {
+		return fmt.Errorf("could not create artifact directory: %w", err)
+	}
+	namespace := fmt.Sprintf("ci-op-e2e-%d", rand.Int31())
+	if o.backend == backendCluster {
+		defer func() {
+			if err := deleteNamespaces(client, namespace); err != nil {
+				logrus.WithError(err).Warnf("could not delete the namespaces of fixture %s", f.Name)
+			}
+		}()
+	}
+	cmd := exec.CommandContext(ctx, o.ciOperator, o.args(f, namespace)...)
+	cmd.Env = append(os.Environ(), "ARTIFACTS="+artifactDir)
+	if o.kubeconfig != "" {
+		cmd.Env = append(cmd.Env, clientcmd.RecommendedConfigPathEnvVar+"="+o.kubeconfig)
+	}
+	if f.JobSpec != "" {
+		cmd.Env = append(cmd.Env, "JOB_SPEC="+f.JobSpec)
+	}
+	output, err := cmd.CombinedOutput()
+	if writeErr := os.WriteFile(filepath.Join(artifactDir, "ci-operator.output.log"), output, 0644); writeErr != nil {
+		logrus.WithError(writeErr).Warn("could not save the output of ci-operator")
+	}
+	var errs []error
+	if succeeded := err == nil; succeeded != f.Success {
+		errs = append(errs, fmt.Errorf("expected ci-operator to have success=%t, got %t: %v", f.Success, succeeded, err))
+	}
+	if o.backend == backendDryRun {
+		dryRun, err := os.ReadFile(filepath.Join(artifactDir, api.CIOperatorDryRunFilename))
+		if err != nil {
+			return utilerrors.NewAggregate(append(errs, fmt.Errorf("could not read the dry run: %w", err)))
+		}
+		return utilerrors.NewAggregate(append(errs, verifyDryRunObjects(dryRun, f.Objects)...))
+	}
+	errs = append(errs, verifyClusterObjects(ctx, client, namespace, f.Objects)...)
+	errs = append(errs, verifyJUnit(artifactDir, f.JUnit)...)
+	hideJUnit(artifactDir)
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteNamespaces deletes the test namespace of a fixture and the dedicated
+// namespaces of its tests, which are named after it, so that fixtures do not
+// pile up in the cluster. The namespaces are not waited for to terminate.
+func deleteNamespaces(client ctrlruntimeclient.Client, namespace string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	namespaces := &coreapi.NamespaceList{}
+	if err := client.List(ctx, namespaces); err != nil {
+		return fmt.Errorf("could not list namespaces: %w", err)
+	}
+	var errs []error
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if ns.Name != namespace && !strings.HasPrefix(ns.Name, namespace+"-") {
+			continue
+		}
+		if err := client.Delete(ctx, ns); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not delete namespace %s: %w", ns.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// hideJUnit renames the jUnit files of a fixture, as the failures that are
+// expected in them would otherwise show up as failures of the harness.
+func hideJUnit(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "junit*.xml"))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if err := os.Rename(file, filepath.Join(dir, "_"+filepath.Base(file))); err != nil {
+			logrus.WithError(err).Warnf("could not rename %s", file)
+		}
+	}
+}
+
+func (o *options) args(f fixture, namespace string) []string {
+	args := []string{"--config=" + f.Config, "--namespace=" + namespace}
+	for _, target := range f.Targets {
+		args = append(args, "--target="+target)
+	}
+	if o.backend == backendDryRun {
+		args = append(args, "--dry-run")
+	}
+	return append(args, f.Args...)
+}
+
+func writeJUnit(dir string, suite *junit.TestSuite) error {
+	out, err := xml.MarshalIndent(&junit.TestSuites{Suites: []*junit.TestSuite{suite}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal jUnit XML: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "junit_ci-operator-e2e.xml"), out, 0644)
+}
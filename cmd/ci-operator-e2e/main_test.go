@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	fixtures, err := loadFixtures("testdata/fixtures")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []fixture{
+		{
+			Name:    "failure",
+			Config:  "testdata/fixtures/failure/config.yaml",
+			Targets: []string{"failure"},
+			JUnit:   []expectedTestCase{{Name: "Run test failure"}},
+		},
+		{
+			Name:    "success",
+			Config:  "testdata/fixtures/success/config.yaml",
+			Targets: []string{"success"},
+			Success: true,
+			Objects: []expectedObject{{APIVersion: "v1", Kind: "Pod", Name: "success"}},
+			JUnit:   []expectedTestCase{{Name: "Run test success", Success: true}},
+		},
+	}
+	testhelper.Diff(t, "fixtures", fixtures, expected)
+}
+
+func TestArgs(t *testing.T) {
+	f := fixture{Config: "config.yaml", Targets: []string{"a", "b"}, Args: []string{"--promote"}}
+	for _, backend := range []string{backendCluster, backendDryRun} {
+		o := options{backend: backend}
+		expected := []string{"--config=config.yaml", "--namespace=ns", "--target=a", "--target=b"}
+		if backend == backendDryRun {
+			expected = append(expected, "--dry-run")
+		}
+		testhelper.Diff(t, backend, o.args(f, "ns"), append(expected, "--promote"))
+	}
+}
+
+func TestVerifyDryRunObjects(t *testing.T) {
+	output := []byte(`- step: src
+  actions:
+  - description: Create build src to clone the source code
+    object:
+      apiVersion: build.openshift.io/v1
+      kind: Build
+      metadata:
+        name: src
+- step: unit
+  actions:
+  - description: Run test pod unit and wait for it to complete
+    object:
+      apiVersion: v1
+      kind: Pod
+      metadata:
+        name: unit
+- step: "[images]"
+  actions:
+  - description: All images are built and tagged into stable
+`)
+	errs := verifyDryRunObjects(output, []expectedObject{
+		{APIVersion: "v1", Kind: "Pod", Name: "unit"},
+		{APIVersion: "build.openshift.io/v1", Kind: "Build", Name: "src"},
+		{APIVersion: "v1", Kind: "Pod", Name: "e2e"},
+	})
+	testhelper.Diff(t, "errors", fmt.Sprint(errs), "[expected object v1 Pod e2e was not described by the dry run]")
+}
+
+func TestVerifyJUnit(t *testing.T) {
+	errs := verifyJUnit("testdata/junit", []expectedTestCase{
+		{Name: "Run test success", Success: true},
+		{Name: "Run test failure", Success: true},
+		{Name: "Run test missing"},
+	})
+	expected := `[expected test case "Run test failure" to have success=true, got false expected test case "Run test missing" was not reported]`
+	testhelper.Diff(t, "errors", fmt.Sprint(errs), expected)
+}
+
+func TestDeleteNamespaces(t *testing.T) {
+	var objects []ctrlruntimeclient.Object
+	for _, name := range []string{"ci-op-e2e-1", "ci-op-e2e-1-dedicated", "ci-op-e2e-12", "other"} {
+		objects = append(objects, &coreapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(objects...).Build()
+	if err := deleteNamespaces(client, "ci-op-e2e-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	namespaces := &coreapi.NamespaceList{}
+	if err := client.List(context.Background(), namespaces); err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, ns := range namespaces.Items {
+		remaining = append(remaining, ns.Name)
+	}
+	testhelper.Diff(t, "remaining namespaces", remaining, []string{"ci-op-e2e-12", "other"})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// clientWarningCategory groups the warnings the API server sends along with
+// its responses, which client-go would otherwise print for every request.
+type clientWarningCategory string
+
+const (
+	// deprecationWarnings announce that an API version or field the
+	// request used is deprecated
+	deprecationWarnings clientWarningCategory = "deprecation"
+	// otherWarnings are all the other warnings, like those of admission
+	otherWarnings clientWarningCategory = "other"
+)
+
+// suppressedWarnings is the level of the categories that are not logged.
+const suppressedWarnings = "none"
+
+// clientWarningLevels is the level each category of warnings is logged at,
+// set with category=level pairs.
+type clientWarningLevels map[clientWarningCategory]string
+
+func defaultClientWarningLevels() clientWarningLevels {
+	return clientWarningLevels{
+		deprecationWarnings: logrus.DebugLevel.String(),
+		otherWarnings:       logrus.WarnLevel.String(),
+	}
+}
+
+func (l clientWarningLevels) String() string {
+	var pairs []string
+	for category, level := range l {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", category, level))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (l clientWarningLevels) Set(value string) error {
+	category, level, found := strings.Cut(value, "=")
+	if !found {
+		return fmt.Errorf("expected category=level, got %q", value)
+	}
+	switch clientWarningCategory(category) {
+	case deprecationWarnings, otherWarnings:
+	default:
+		return fmt.Errorf("unknown category %q, expected %s or %s", category, deprecationWarnings, otherWarnings)
+	}
+	if level != suppressedWarnings {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			return fmt.Errorf("invalid level for %s warnings: %w", category, err)
+		}
+	}
+	l[clientWarningCategory(category)] = level
+	return nil
+}
+
+// clientWarningHandler logs the warnings client-go receives through our
+// logger, at the level of their category and only once per message, as the
+// same warning is usually sent for every request of a kind.
+type clientWarningHandler struct {
+	levels clientWarningLevels
+	logger *logrus.Logger
+
+	lock sync.Mutex
+	seen sets.Set[string]
+}
+
+func newClientWarningHandler(levels clientWarningLevels, logger *logrus.Logger) *clientWarningHandler {
+	return &clientWarningHandler{levels: levels, logger: logger, seen: sets.New[string]()}
+}
+
+// HandleWarningHeader implements rest.WarningHandler. Like the handler of
+// client-go, only warnings with the 299 code are handled.
+func (h *clientWarningHandler) HandleWarningHeader(code int, _ string, message string) {
+	if code != 299 || message == "" {
+		return
+	}
+	category := otherWarnings
+	if strings.Contains(strings.ToLower(message), "deprecated") {
+		category = deprecationWarnings
+	}
+	level, err := logrus.ParseLevel(h.levels[category])
+	if err != nil {
+		// the category is suppressed
+		return
+	}
+	h.lock.Lock()
+	seen := h.seen.Has(message)
+	h.seen.Insert(message)
+	h.lock.Unlock()
+	if seen {
+		return
+	}
+	h.logger.WithField("category", category).Logf(level, "API server warning: %s", message)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestClientWarningLevelsSet(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		values      []string
+		expected    string
+		expectedErr string
+	}{{
+		name:     "defaults",
+		expected: "deprecation=debug,other=warning",
+	}, {
+		name:     "suppressed deprecations",
+		values:   []string{"deprecation=none", "other=info"},
+		expected: "deprecation=none,other=info",
+	}, {
+		name:        "unknown category",
+		values:      []string{"admission=info"},
+		expectedErr: `unknown category "admission", expected deprecation or other`,
+	}, {
+		name:        "invalid level",
+		values:      []string{"other=loud"},
+		expectedErr: `invalid level for other warnings: not a valid logrus Level: "loud"`,
+	}, {
+		name:        "missing level",
+		values:      []string{"other"},
+		expectedErr: `expected category=level, got "other"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			levels := defaultClientWarningLevels()
+			var actualErr string
+			for _, value := range tc.values {
+				if err := levels.Set(value); err != nil {
+					actualErr = err.Error()
+				}
+			}
+			testhelper.Diff(t, "error", actualErr, tc.expectedErr)
+			if actualErr == "" {
+				testhelper.Diff(t, "levels", levels.String(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestClientWarningHandler(t *testing.T) {
+	logger, hook := logrustest.NewNullLogger()
+	logger.SetLevel(logrus.TraceLevel)
+	levels := defaultClientWarningLevels()
+	if err := levels.Set("other=none"); err != nil {
+		t.Fatal(err)
+	}
+	handler := newClientWarningHandler(levels, logger)
+	handler.HandleWarningHeader(299, "", "v1beta1 CronJob is deprecated in v1.21+, unavailable in v1.25+")
+	handler.HandleWarningHeader(299, "", "v1beta1 CronJob is deprecated in v1.21+, unavailable in v1.25+")
+	handler.HandleWarningHeader(299, "", "would violate PodSecurity")
+	handler.HandleWarningHeader(199, "", "miscellaneous warning is deprecated")
+	type entry struct {
+		Level   logrus.Level
+		Message string
+	}
+	var actual []entry
+	for _, e := range hook.AllEntries() {
+		actual = append(actual, entry{Level: e.Level, Message: e.Message})
+	}
+	testhelper.Diff(t, "entries", actual, []entry{{
+		Level:   logrus.DebugLevel,
+		Message: "API server warning: v1beta1 CronJob is deprecated in v1.21+, unavailable in v1.25+",
+	}})
+}
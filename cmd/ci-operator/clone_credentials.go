@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	coreapi "k8s.io/api/core/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// cloneAuthConfigFor configures cloning with the credentials referenced by
+// clone_credentials. The secret is only read, and named, when the test
+// namespace is set up by resolveCloneCredentials.
+func cloneAuthConfigFor(credentials api.CloneCredentials) *steps.CloneAuthConfig {
+	config := &steps.CloneAuthConfig{
+		Type:   steps.CloneAuthTypeOAuth,
+		Secret: &coreapi.Secret{},
+	}
+	if credentials.Type == api.CloneCredentialsSSH {
+		config.Type = steps.CloneAuthTypeSSH
+	}
+	return config
+}
+
+// resolveCloneCredentials copies the credentials referenced by
+// clone_credentials into the clone secret, in the form the source step
+// expects, and names it after its content like the secrets created from
+// --ssh-key-path and --oauth-token-path. The credentials are added to the
+// censor, so they never show up in the logs or artifacts.
+func (o *options) resolveCloneCredentials(ctx context.Context, client ctrlruntimeclient.Reader) error {
+	credentials := o.configSpec.CloneCredentials
+	if credentials == nil {
+		return nil
+	}
+	source := &coreapi.Secret{}
+	key := ctrlruntimeclient.ObjectKey{Namespace: credentials.Namespace, Name: credentials.Name}
+	if err := client.Get(ctx, key, source); err != nil {
+		return fmt.Errorf("could not get the clone credentials %s: %w", key, err)
+	}
+	secret := o.cloneAuthConfig.Secret
+	secret.Data = map[string][]byte{}
+	switch credentials.Type {
+	case api.CloneCredentialsSSH:
+		key, ok := source.Data[coreapi.SSHAuthPrivateKey]
+		if !ok {
+			return fmt.Errorf("the clone credentials %s/%s have no %s key", credentials.Namespace, credentials.Name, coreapi.SSHAuthPrivateKey)
+		}
+		secret.Name = fmt.Sprintf("ssh-%s", getHashFromBytes(key))
+		secret.Type = coreapi.SecretTypeSSHAuth
+		secret.Data[coreapi.SSHAuthPrivateKey] = bytes.TrimSpace(key)
+	default:
+		token, ok := source.Data[steps.OauthSecretKey]
+		if !ok {
+			return fmt.Errorf("the clone credentials %s/%s have no %s key", credentials.Namespace, credentials.Name, steps.OauthSecretKey)
+		}
+		secret.Name = fmt.Sprintf("oauth-%s", getHashFromBytes(token))
+		token = bytes.TrimSpace(token)
+		secret.Type = coreapi.SecretTypeBasicAuth
+		secret.Data[steps.OauthSecretKey] = token
+		// Those keys will be used in a git source strategy build
+		secret.Data["username"] = token
+		secret.Data["password"] = token
+	}
+	if o.censor != nil {
+		for _, value := range secret.Data {
+			o.censor.AddSecrets(string(value))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/secrets"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestResolveCloneCredentials(t *testing.T) {
+	source := &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "github-token"},
+		Data:       map[string][]byte{steps.OauthSecretKey: []byte("token\n")},
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(source).Build()
+	credentials := &api.CloneCredentials{Namespace: "ci", Name: "github-token", Type: api.CloneCredentialsOAuth}
+	censor := secrets.NewDynamicCensor()
+	o := &options{
+		configSpec:      &api.ReleaseBuildConfiguration{CloneCredentials: credentials},
+		cloneAuthConfig: cloneAuthConfigFor(*credentials),
+		censor:          &censor,
+	}
+	if err := o.resolveCloneCredentials(context.Background(), client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o.cloneAuthConfig.Type != steps.CloneAuthTypeOAuth {
+		t.Errorf("expected OAuth authentication, got %s", o.cloneAuthConfig.Type)
+	}
+	testhelper.Diff(t, "secret data", o.cloneAuthConfig.Secret.Data, map[string][]byte{
+		steps.OauthSecretKey: []byte("token"),
+		"username":           []byte("token"),
+		"password":           []byte("token"),
+	})
+	if o.cloneAuthConfig.Secret.Name == "" {
+		t.Error("expected the secret to be named")
+	}
+	data := []byte("the token is token")
+	censor.Censor(&data)
+	if string(data) == "the token is token" {
+		t.Error("expected the token to be censored")
+	}
+
+	o.configSpec.CloneCredentials.Type = api.CloneCredentialsSSH
+	o.cloneAuthConfig = cloneAuthConfigFor(*o.configSpec.CloneCredentials)
+	if err := o.resolveCloneCredentials(context.Background(), client); err == nil {
+		t.Error("expected an error for a secret without a private key")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// buildRootImageField configures the build root of `from_repository` in the
+// same file, and is not part of the configuration.
+const buildRootImageField = "build_root_image"
+
+// configFromRepo reads the configuration from the .ci-operator.yaml file in
+// the source cloned to --config-in-repo, after making sure the checkout
+// contains the refs under test, so the configuration cannot come from any
+// other revision.
+func (o *options) configFromRepo() ([]byte, error) {
+	if err := verifyCheckout(o.configInRepo, o.jobSpec.Refs); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(o.configInRepo, api.CIOperatorInrepoConfigFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the configuration: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid configuration in file %s: %w", path, err)
+	}
+	if _, set := fields[buildRootImageField]; !set {
+		return data, nil
+	}
+	delete(fields, buildRootImageField)
+	return yaml.Marshal(fields)
+}
+
+// verifyCheckout makes sure that the base and every pull request in the refs
+// are part of the history of the revision checked out in the directory.
+func verifyCheckout(dir string, refs *prowapi.Refs) error {
+	if refs == nil {
+		return nil
+	}
+	revisions := []string{refs.BaseSHA}
+	for _, pull := range refs.Pulls {
+		revisions = append(revisions, pull.SHA)
+	}
+	for _, revision := range revisions {
+		if revision == "" {
+			continue
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", "-C", dir, "merge-base", "--is-ancestor", revision, "HEAD")
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+				return fmt.Errorf("the source in %s is not checked out at the tested revision: %s is not part of its history", dir, revision)
+			}
+			return fmt.Errorf("could not verify the revision of the source in %s: %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestConfigFromRepo(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	config := "build_root_image:\n  namespace: ci\n  name: root\n  tag: latest\nresources:\n  '*':\n    requests:\n      cpu: 10m\n"
+	if err := os.WriteFile(filepath.Join(dir, api.CIOperatorInrepoConfigFileName), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "config")
+	head := git("rev-parse", "HEAD")
+
+	o := &options{configInRepo: dir, jobSpec: &api.JobSpec{}}
+	o.jobSpec.Refs = &prowapi.Refs{BaseSHA: head, Pulls: []prowapi.Pull{{SHA: head}}}
+	data, err := o.configFromRepo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "config", string(data), "resources:\n  '*':\n    requests:\n      cpu: 10m\n")
+
+	o.jobSpec.Refs.Pulls[0].SHA = strings.Repeat("0", len(head))
+	if _, err := o.configFromRepo(); err == nil {
+		t.Error("expected an error when the pull request is not checked out, got none")
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/util/gzip"
+)
+
+// configPaths holds the values of --config, which may be repeated: the first
+// configuration is the base and every following one is merged onto it.
+type configPaths struct {
+	o *options
+}
+
+func (c *configPaths) String() string {
+	if c.o == nil {
+		return ""
+	}
+	return strings.Join(append([]string{c.o.configSpecPath}, c.o.configOverlayPaths...), ",")
+}
+
+func (c *configPaths) Set(value string) error {
+	if c.o.configSpecPath == "" {
+		c.o.configSpecPath = value
+	} else {
+		c.o.configOverlayPaths = append(c.o.configOverlayPaths, value)
+	}
+	return nil
+}
+
+// isConfigFile determines whether a file in a directory passed to --config
+// holds a configuration.
+func isConfigFile(name string) bool {
+	for _, extension := range []string{".yaml", ".yml", ".yaml.gz", ".yml.gz"} {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandConfigPaths replaces the directories passed to --config with the
+// configuration files they hold, in lexical order.
+func expandConfigPaths(paths []string) ([]string, error) {
+	var expanded []string
+	for _, path := range paths {
+		if isConfigURL(path) {
+			expanded = append(expanded, path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			expanded = append(expanded, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the configuration directory %s: %w", path, err)
+		}
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() && isConfigFile(entry.Name()) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("the configuration directory %s holds no configuration file", path)
+		}
+		sort.Strings(files)
+		expanded = append(expanded, files...)
+	}
+	return expanded, nil
+}
+
+// completeConfigPaths expands the directories passed to --config and checks
+// that the options of --config that need a single configuration have one.
+func (o *options) completeConfigPaths() error {
+	if o.configSpecPath == "" {
+		return nil
+	}
+	paths, err := expandConfigPaths(append([]string{o.configSpecPath}, o.configOverlayPaths...))
+	if err != nil {
+		return err
+	}
+	o.configSpecPath, o.configOverlayPaths = paths[0], paths[1:]
+	if len(o.configOverlayPaths) == 0 {
+		return nil
+	}
+	for _, path := range paths {
+		if isConfigURL(path) {
+			return errors.New("--config can only be an http(s) URL when it is given once")
+		}
+	}
+	if o.configSHA256 != "" {
+		return errors.New("--config-sha256 cannot verify a configuration merged from multiple files")
+	}
+	return nil
+}
+
+// configListMergeKeys are the fields identifying the elements of the lists of
+// the configuration that are merged element by element.
+var configListMergeKeys = map[string]string{
+	"images": "to",
+	"tests":  "as",
+}
+
+// mergeConfigs merges the overlay onto the base configuration. Objects are
+// merged recursively and null values remove fields, as in a JSON merge patch.
+// The elements of the images and tests lists are merged with the elements of
+// the base that have the same name, and appended otherwise. Other lists are
+// replaced.
+func mergeConfigs(base, overlay []byte) ([]byte, error) {
+	baseObject, err := configObject(base)
+	if err != nil {
+		return nil, err
+	}
+	overlayObject, err := configObject(overlay)
+	if err != nil {
+		return nil, err
+	}
+	lists := map[string]interface{}{}
+	for field, key := range configListMergeKeys {
+		baseList, isBaseList := baseObject[field].([]interface{})
+		overlayList, isOverlayList := overlayObject[field].([]interface{})
+		if !isBaseList || !isOverlayList {
+			continue
+		}
+		merged, err := mergeConfigList(baseList, overlayList, key)
+		if err != nil {
+			return nil, fmt.Errorf("could not merge %s: %w", field, err)
+		}
+		lists[field] = merged
+		delete(overlayObject, field)
+	}
+	baseJSON, err := json.Marshal(baseObject)
+	if err != nil {
+		return nil, err
+	}
+	overlayJSON, err := json.Marshal(overlayObject)
+	if err != nil {
+		return nil, err
+	}
+	mergedJSON, err := jsonpatch.MergePatch(baseJSON, overlayJSON)
+	if err != nil {
+		return nil, fmt.Errorf("could not merge the configuration: %w", err)
+	}
+	if len(lists) == 0 {
+		return mergedJSON, nil
+	}
+	merged, err := configObject(mergedJSON)
+	if err != nil {
+		return nil, err
+	}
+	for field, list := range lists {
+		merged[field] = list
+	}
+	return json.Marshal(merged)
+}
+
+// configObject parses a configuration, keeping its numbers as they are.
+func configObject(raw []byte) (map[string]interface{}, error) {
+	rawJSON, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the configuration: %w", err)
+	}
+	var object map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(rawJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("could not parse the configuration: %w", err)
+	}
+	return object, nil
+}
+
+func configListElementName(element interface{}, key string) (string, bool) {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	name, ok := object[key].(string)
+	return name, ok && name != ""
+}
+
+// mergeConfigList merges the elements of the overlay onto the elements of
+// the base with the same value of the key, as a JSON merge patch. Elements
+// without a counterpart in the base are appended.
+func mergeConfigList(base, overlay []interface{}, key string) ([]interface{}, error) {
+	merged := append([]interface{}{}, base...)
+	indices := map[string]int{}
+	for i, element := range merged {
+		if name, ok := configListElementName(element, key); ok {
+			indices[name] = i
+		}
+	}
+	for _, element := range overlay {
+		name, ok := configListElementName(element, key)
+		if !ok {
+			merged = append(merged, element)
+			continue
+		}
+		i, exists := indices[name]
+		if !exists {
+			indices[name] = len(merged)
+			merged = append(merged, element)
+			continue
+		}
+		baseJSON, err := json.Marshal(merged[i])
+		if err != nil {
+			return nil, err
+		}
+		overlayJSON, err := json.Marshal(element)
+		if err != nil {
+			return nil, err
+		}
+		elementJSON, err := jsonpatch.MergePatch(baseJSON, overlayJSON)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		var patched interface{}
+		decoder := json.NewDecoder(bytes.NewReader(elementJSON))
+		decoder.UseNumber()
+		if err := decoder.Decode(&patched); err != nil {
+			return nil, err
+		}
+		merged[i] = patched
+	}
+	return merged, nil
+}
+
+// mergeConfigOverlays merges the configurations following the first --config
+// onto it, in order.
+func (o *options) mergeConfigOverlays(raw []byte) ([]byte, error) {
+	for _, path := range o.configOverlayPaths {
+		overlay, err := gzip.ReadFileMaybeGZIP(path)
+		if err != nil {
+			return nil, fmt.Errorf("--config error: %w", err)
+		}
+		if raw, err = mergeConfigs(raw, overlay); err != nil {
+			return nil, fmt.Errorf("--config error: %s: %w", path, err)
+		}
+	}
+	return raw, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestConfigPathsFlag(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	o := bindOptions(flagSet)
+	if err := flagSet.Parse([]string{"--config=base.yaml", "--config=branch.yaml", "--config=local.yaml"}); err != nil {
+		t.Fatalf("could not parse the flags: %v", err)
+	}
+	testhelper.Diff(t, "base", o.configSpecPath, "base.yaml")
+	testhelper.Diff(t, "overlays", o.configOverlayPaths, []string{"branch.yaml", "local.yaml"})
+}
+
+func TestExpandConfigPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20-branch.yaml", "10-base.yaml", "30-local.yml.gz", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	empty := t.TempDir()
+
+	for _, tc := range []struct {
+		name        string
+		paths       []string
+		expected    []string
+		expectedErr bool
+	}{
+		{
+			name:     "files are kept",
+			paths:    []string{"base.yaml", "branch.yaml"},
+			expected: []string{"base.yaml", "branch.yaml"},
+		},
+		{
+			name:     "directory is expanded in order",
+			paths:    []string{dir, "extra.yaml"},
+			expected: []string{filepath.Join(dir, "10-base.yaml"), filepath.Join(dir, "20-branch.yaml"), filepath.Join(dir, "30-local.yml.gz"), "extra.yaml"},
+		},
+		{
+			name:        "directory without configuration",
+			paths:       []string{empty},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := expandConfigPaths(tc.paths)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			testhelper.Diff(t, "paths", actual, tc.expected)
+		})
+	}
+}
+
+func TestCompleteConfigPaths(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		o           options
+		expectedErr bool
+	}{
+		{
+			name: "single URL",
+			o:    options{configSpecPath: "https://config.example.com/c"},
+		},
+		{
+			name:        "URL with overlays",
+			o:           options{configSpecPath: "https://config.example.com/c", configOverlayPaths: []string{"branch.yaml"}},
+			expectedErr: true,
+		},
+		{
+			name:        "checksum with overlays",
+			o:           options{configSpecPath: "base.yaml", configOverlayPaths: []string{"branch.yaml"}, configSHA256: "abc"},
+			expectedErr: true,
+		},
+		{
+			name: "overlays",
+			o:    options{configSpecPath: "base.yaml", configOverlayPaths: []string{"branch.yaml"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.o.completeConfigPaths(); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadMergedConfig(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml": `base_images:
+  os:
+    namespace: ocp
+    name: ubi
+    tag: "8"
+  tools:
+    namespace: ocp
+    name: tools
+    tag: latest
+resources:
+  '*':
+    requests:
+      cpu: 100m
+images:
+- to: component
+  dockerfile_path: Dockerfile
+  context_dir: images/component
+tests:
+- as: unit
+  commands: make test
+  container:
+    from: src
+- as: lint
+  commands: make lint
+  container:
+    from: src
+zz_generated_metadata:
+  org: org
+  repo: repo
+  branch: main
+`,
+		"branch.yaml": `base_images:
+  os:
+    tag: "9"
+  tools: null
+images:
+- to: component
+  dockerfile_path: Dockerfile.rhel
+tests:
+- as: e2e
+  commands: make e2e
+  container:
+    from: src
+- as: unit
+  commands: make test-race
+zz_generated_metadata:
+  branch: release-1.0
+`,
+		"invalid.yaml": "unknown_field: true\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := &options{configSpecPath: filepath.Join(dir, "base.yaml"), configOverlayPaths: []string{filepath.Join(dir, "branch.yaml")}}
+	config, err := o.loadConfig(nil)
+	if err != nil {
+		t.Fatalf("could not load the configuration: %v", err)
+	}
+	expected := &api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BaseImages: map[string]api.ImageStreamTagReference{
+				"os": {Namespace: "ocp", Name: "ubi", Tag: "9"},
+			},
+		},
+		Resources: api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "100m"}}},
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{{
+			To:                               "component",
+			ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{DockerfilePath: "Dockerfile.rhel", ContextDir: "images/component"},
+		}},
+		Tests: []api.TestStepConfiguration{
+			{As: "unit", Commands: "make test-race", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			{As: "lint", Commands: "make lint", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			{As: "e2e", Commands: "make e2e", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+		},
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "release-1.0"},
+	}
+	testhelper.Diff(t, "configuration", config, expected)
+
+	o.configOverlayPaths = append(o.configOverlayPaths, filepath.Join(dir, "invalid.yaml"))
+	if _, err := o.loadConfig(nil); err == nil {
+		t.Error("expected an error for an unknown field in an overlay, got none")
+	}
+}
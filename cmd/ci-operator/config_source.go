@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/util/gzip"
+)
+
+const configFetchTimeout = time.Minute
+
+// isConfigURL determines whether --config points to an HTTP(S) service
+// instead of a local file.
+func isConfigURL(path string) bool {
+	u, err := url.Parse(path)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// configFromURL fetches the configuration from --config, authenticating with
+// the bearer token in --config-token-path when one is given. Every successful
+// fetch is cached in --config-cache-dir next to its checksum, so a retried job
+// can start when the service is unavailable; a cached copy that does not match
+// its checksum is never used.
+func (o *options) configFromURL() ([]byte, error) {
+	data, fetchErr := o.fetchConfig()
+	if fetchErr == nil {
+		if err := o.cacheConfig(data); err != nil {
+			logrus.WithError(err).Warn("Could not cache the configuration.")
+		}
+		return gzip.ReadBytesMaybeGZIP(data)
+	}
+	if o.configCacheDir == "" {
+		return nil, fetchErr
+	}
+	data, err := o.cachedConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%w (no usable cached copy: %v)", fetchErr, err)
+	}
+	logrus.WithError(fetchErr).Warnf("Could not fetch the configuration from %s, using the cached copy.", o.configSpecPath)
+	return gzip.ReadBytesMaybeGZIP(data)
+}
+
+func (o *options) fetchConfig() ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, o.configSpecPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	if o.configTokenPath != "" {
+		token, err := os.ReadFile(o.configTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read --config-token-path: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	client := &http.Client{Timeout: configFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the configuration: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch the configuration: server responded with %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the configuration: %w", err)
+	}
+	return data, nil
+}
+
+// configCachePaths returns the paths of the cached configuration and of its
+// checksum, which are keyed by the URL.
+func (o *options) configCachePaths() (string, string) {
+	key := sha256.Sum256([]byte(o.configSpecPath))
+	base := filepath.Join(o.configCacheDir, hex.EncodeToString(key[:]))
+	return base + ".config", base + ".sha256"
+}
+
+func (o *options) cacheConfig(data []byte) error {
+	if o.configCacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(o.configCacheDir, 0755); err != nil {
+		return fmt.Errorf("could not create the cache directory: %w", err)
+	}
+	configPath, checksumPath := o.configCachePaths()
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("could not write the cached configuration: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if err := os.WriteFile(checksumPath, []byte(hex.EncodeToString(sum[:])), 0644); err != nil {
+		return fmt.Errorf("could not write the checksum of the cached configuration: %w", err)
+	}
+	return nil
+}
+
+func (o *options) cachedConfig() ([]byte, error) {
+	configPath, checksumPath := o.configCachePaths()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the cached configuration: %w", err)
+	}
+	expected, err := os.ReadFile(checksumPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the checksum of the cached configuration: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != strings.TrimSpace(string(expected)) {
+		return nil, fmt.Errorf("checksum mismatch for the cached configuration: expected %s, got %s", strings.TrimSpace(string(expected)), actual)
+	}
+	return data, nil
+}
+
+// verifyConfigDigest returns the digest of the raw configuration and, when an
+// expected SHA-256 was given with --config-sha256, refuses a configuration
+// that does not match it.
+func verifyConfigDigest(raw []byte, expected string) (string, error) {
+	sum := sha256.Sum256(raw)
+	actual := hex.EncodeToString(sum[:])
+	expected = strings.ToLower(strings.TrimPrefix(expected, "sha256:"))
+	if expected != "" && expected != actual {
+		return "", fmt.Errorf("the configuration does not match --config-sha256: expected sha256:%s, got sha256:%s", expected, actual)
+	}
+	return "sha256:" + actual, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsConfigURL(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"config.yaml":                      false,
+		"/etc/config/config.yaml":          false,
+		"https://config.example.com/c":     true,
+		"http://config.example.com/c":      true,
+		"file:///etc/config/config.yaml":   false,
+		"https:///missing-host/config.yml": false,
+	} {
+		if actual := isConfigURL(path); actual != expected {
+			t.Errorf("%s: expected %t, got %t", path, expected, actual)
+		}
+	}
+}
+
+func TestConfigFromURL(t *testing.T) {
+	const config = "resources: {}\n"
+	var fail bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(config))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	o := &options{configSpecPath: server.URL, configTokenPath: tokenPath, configCacheDir: filepath.Join(dir, "cache")}
+
+	data, err := o.configFromURL()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(config, string(data)); diff != "" {
+		t.Errorf("unexpected config: %s", diff)
+	}
+
+	fail = true
+	data, err = o.configFromURL()
+	if err != nil {
+		t.Fatalf("expected the cached copy to be used, got: %v", err)
+	}
+	if diff := cmp.Diff(config, string(data)); diff != "" {
+		t.Errorf("unexpected cached config: %s", diff)
+	}
+
+	configPath, _ := o.configCachePaths()
+	if err := os.WriteFile(configPath, []byte("tampered: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.configFromURL(); err == nil {
+		t.Error("expected a tampered cached copy to be refused")
+	}
+
+	fail = false
+	if _, err := (&options{configSpecPath: server.URL}).configFromURL(); err == nil {
+		t.Error("expected an unauthenticated request to fail")
+	}
+}
+
+func TestVerifyConfigDigest(t *testing.T) {
+	const digest = "7e3d1b7e0b3bd2b7dba0a7e2f2d6ec97b9ee8c8d9f5b0c9b1bc3b5a6b4b3c8b7"
+	raw := []byte("resources: {}\n")
+	actual, err := verifyConfigDigest(raw, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := verifyConfigDigest(raw, actual); err != nil {
+		t.Errorf("expected the digest to match itself, got: %v", err)
+	}
+	if _, err := verifyConfigDigest(raw, digest); err == nil {
+		t.Error("expected a mismatching digest to be refused")
+	}
+}
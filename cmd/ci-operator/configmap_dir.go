@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	coreapi "k8s.io/api/core/v1"
+)
+
+// maxConfigMapSize is the most data a ConfigMap can hold.
+const maxConfigMapSize = 1024 * 1024
+
+// configMapDir describes a ConfigMap created from a directory with
+// --configmap-dir. The flag takes the same values as --secret-dir, except
+// for the type.
+type configMapDir struct {
+	path      string
+	name      string
+	keys      []string
+	recursive bool
+}
+
+func parseConfigMapDir(value string) (configMapDir, error) {
+	dir, err := parseSecretDir(value)
+	if err != nil {
+		return configMapDir{}, err
+	}
+	if dir.secretType != "" {
+		return configMapDir{}, errors.New("the type option is only supported for secrets")
+	}
+	return configMapDir{path: dir.path, name: dir.name, keys: dir.keys, recursive: dir.recursive}, nil
+}
+
+// configMap reads the files of the directory into a ConfigMap. Files that
+// are not valid UTF-8 are stored as binary data.
+func (d configMapDir) configMap() (*coreapi.ConfigMap, error) {
+	// the type keeps the files from being inspected for pull secrets
+	secret, err := secretDir{path: d.path, name: d.name, keys: d.keys, recursive: d.recursive, secretType: coreapi.SecretTypeOpaque}.secret()
+	if err != nil {
+		return nil, err
+	}
+	configMap := &coreapi.ConfigMap{}
+	configMap.Name = d.name
+	var size int
+	for key, value := range secret.Data {
+		size += len(value)
+		if utf8.Valid(value) {
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+			configMap.Data[key] = string(value)
+			continue
+		}
+		if configMap.BinaryData == nil {
+			configMap.BinaryData = map[string][]byte{}
+		}
+		configMap.BinaryData[key] = value
+	}
+	if size > maxConfigMapSize {
+		return nil, fmt.Errorf("%s holds %d bytes, more than the %d a ConfigMap can hold", d.path, size, maxConfigMapSize)
+	}
+	return configMap, nil
+}
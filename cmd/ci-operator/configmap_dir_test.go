@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseConfigMapDir(t *testing.T) {
+	if _, err := parseConfigMapDir("path=/config,type=Opaque"); err == nil {
+		t.Error("expected an error for the type option")
+	}
+	actual, err := parseConfigMapDir("path=/config,name=manifests,key=tests.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := configMapDir{path: "/config", name: "manifests", keys: []string{"tests.txt"}}
+	if diff := cmp.Diff(expected, actual, cmp.AllowUnexported(configMapDir{})); diff != "" {
+		t.Errorf("unexpected ConfigMap dir: %s", diff)
+	}
+}
+
+func TestConfigMapDirConfigMap(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		".dockerconfigjson": "{}",
+		"binary":            "\xff\xfe",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configMap, err := configMapDir{path: dir, name: "config"}.configMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if configMap.Name != "config" {
+		t.Errorf("expected name config, got %s", configMap.Name)
+	}
+	if diff := cmp.Diff(map[string]string{".dockerconfigjson": "{}"}, configMap.Data); diff != "" {
+		t.Errorf("unexpected data: %s", diff)
+	}
+	if diff := cmp.Diff(map[string][]byte{"binary": []byte("\xff\xfe")}, configMap.BinaryData); diff != "" {
+		t.Errorf("unexpected binary data: %s", diff)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "large"), []byte(strings.Repeat("x", maxConfigMapSize)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (configMapDir{path: dir, name: "config"}).configMap(); err == nil {
+		t.Error("expected an error for a directory too large for a ConfigMap")
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/features"
+)
+
+// configureFeatures resolves the feature gates of the execution from their
+// defaults, the --features file and the environment, in that order, and
+// makes them available to every package.
+func (o *options) configureFeatures() error {
+	gates := features.Defaults()
+	if o.featuresPath != "" {
+		if err := gates.SetFromFile(o.featuresPath); err != nil {
+			return err
+		}
+	}
+	if value, set := os.LookupEnv(features.EnvVar); set {
+		if err := gates.SetFromList(value); err != nil {
+			return err
+		}
+	}
+	o.featureGates = gates
+	features.Configure(gates)
+	logrus.Infof("Feature gates: %s", gates)
+	return nil
+}
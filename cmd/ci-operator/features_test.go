@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/features"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestConfigureFeatures(t *testing.T) {
+	defer features.Configure(features.Defaults())
+	path := filepath.Join(t.TempDir(), "features.yaml")
+	if err := os.WriteFile(path, []byte("ImportWatchdog: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name     string
+		path     string
+		env      string
+		expected features.Gates
+	}{{
+		name:     "defaults",
+		expected: features.Defaults(),
+	}, {
+		name:     "file",
+		path:     path,
+		expected: features.Gates{features.ImportWatchdog: false},
+	}, {
+		name:     "environment overrides the file",
+		path:     path,
+		env:      "ImportWatchdog=true",
+		expected: features.Gates{features.ImportWatchdog: true},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.env != "" {
+				t.Setenv(features.EnvVar, tc.env)
+			}
+			o := options{featuresPath: tc.path}
+			if err := o.configureFeatures(); err != nil {
+				t.Fatalf("failed to configure the features: %v", err)
+			}
+			testhelper.Diff(t, "gates", o.featureGates, tc.expected)
+			testhelper.Diff(t, "enabled", features.Enabled(features.ImportWatchdog), tc.expected[features.ImportWatchdog])
+		})
+	}
+}
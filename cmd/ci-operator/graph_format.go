@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// graphFormatDigraph lists one edge per line, the step first and the
+	// step it depends on second, for the golang digraph utility.
+	graphFormatDigraph = "digraph"
+	// graphFormatDot is a Graphviz graph of the steps and post steps.
+	graphFormatDot = "dot"
+	// graphFormatMermaid is a Mermaid flowchart of the steps and post steps.
+	graphFormatMermaid = "mermaid"
+)
+
+var graphFormats = []string{graphFormatDigraph, graphFormatDot, graphFormatMermaid}
+
+func validateGraphFormat(format string) error {
+	for _, valid := range graphFormats {
+		if format == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("--graph-format must be one of %s, got %q", strings.Join(graphFormats, ", "), format)
+}
+
+// graphEdge is a dependency: to runs after from.
+type graphEdge struct {
+	from, to int
+}
+
+// stepGraph is the graph of the steps and post steps, in the order they run.
+type stepGraph struct {
+	steps []api.Step
+	// post are the indices of the post steps
+	post  map[int]bool
+	edges []graphEdge
+}
+
+// newStepGraph resolves the dependencies between the steps. Post steps run
+// once the whole graph succeeded, so they depend on the steps nothing else
+// depends on.
+func newStepGraph(stepList api.OrderedStepList, postSteps []api.Step) *stepGraph {
+	g := &stepGraph{post: map[int]bool{}}
+	hasDependents := map[int]bool{}
+	for i, node := range stepList {
+		g.steps = append(g.steps, node.Step)
+		requires := node.Step.Requires()
+		// Only the first `i` elements can fulfill the requirements since
+		// `OrderedStepList` is a topological order.
+		for j, other := range stepList[:i] {
+			if api.HasAnyLinks(requires, other.Step.Creates()) {
+				g.edges = append(g.edges, graphEdge{from: j, to: i})
+				hasDependents[j] = true
+			}
+		}
+	}
+	for _, step := range postSteps {
+		index := len(g.steps)
+		g.steps = append(g.steps, step)
+		g.post[index] = true
+		for i := range stepList {
+			if !hasDependents[i] {
+				g.edges = append(g.edges, graphEdge{from: i, to: index})
+			}
+		}
+	}
+	return g
+}
+
+// printGraph prints the graph of the steps in the format.
+func printGraph(w io.Writer, format string, stepList api.OrderedStepList, postSteps []api.Step) error {
+	switch format {
+	case graphFormatDot:
+		return newStepGraph(stepList, postSteps).printDot(w)
+	case graphFormatMermaid:
+		return newStepGraph(stepList, postSteps).printMermaid(w)
+	default:
+		return printDigraph(w, stepList)
+	}
+}
+
+func (g *stepGraph) printDot(w io.Writer) error {
+	var out strings.Builder
+	out.WriteString("digraph steps {\n  rankdir=LR;\n")
+	for i, step := range g.steps {
+		shape := "box"
+		if g.post[i] {
+			shape = "doubleoctagon"
+		}
+		fmt.Fprintf(&out, "  %q [label=%q, shape=%s];\n", step.Name(), fmt.Sprintf("%s\n%s", step.Name(), step.Description()), shape)
+	}
+	for _, edge := range g.edges {
+		style := ""
+		if g.post[edge.to] {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&out, "  %q -> %q%s;\n", g.steps[edge.from].Name(), g.steps[edge.to].Name(), style)
+	}
+	out.WriteString("}\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// mermaidLabel escapes the text for a label of a Mermaid node.
+func mermaidLabel(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
+
+func (g *stepGraph) printMermaid(w io.Writer) error {
+	var out strings.Builder
+	out.WriteString("flowchart LR\n")
+	for i, step := range g.steps {
+		label := fmt.Sprintf("%s<br/>%s", mermaidLabel(step.Name()), mermaidLabel(step.Description()))
+		if g.post[i] {
+			fmt.Fprintf(&out, "  s%d{{\"%s\"}}\n", i, label)
+		} else {
+			fmt.Fprintf(&out, "  s%d[\"%s\"]\n", i, label)
+		}
+	}
+	for _, edge := range g.edges {
+		arrow := "-->"
+		if g.post[edge.to] {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&out, "  s%d %s s%d\n", edge.from, arrow, edge.to)
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
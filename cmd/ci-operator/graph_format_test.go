@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type graphStep struct {
+	name, description string
+	requires, creates []api.StepLink
+}
+
+func (*graphStep) Inputs() (api.InputDefinition, error) { return nil, nil }
+func (*graphStep) Run(ctx context.Context) error        { return nil }
+func (s *graphStep) Requires() []api.StepLink           { return s.requires }
+func (s *graphStep) Creates() []api.StepLink            { return s.creates }
+func (s *graphStep) Name() string                       { return s.name }
+func (s *graphStep) Description() string                { return s.description }
+func (*graphStep) Provides() api.ParameterMap           { return nil }
+func (*graphStep) Validate() error                      { return nil }
+func (*graphStep) Objects() []ctrlruntimeclient.Object  { return nil }
+
+func TestPrintGraph(t *testing.T) {
+	src := &graphStep{name: "src", description: "Clone the source", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}
+	unit := &graphStep{name: "unit", description: `Run the "unit" test`, requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}
+	image := &graphStep{name: "component", description: "Build the image", requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}, creates: []api.StepLink{api.InternalImageLink("component")}}
+	promotion := &graphStep{name: "promotion", description: "Promote the images", requires: []api.StepLink{api.AllStepsLink()}}
+	graph := api.BuildGraph([]api.Step{src, unit, image})
+	stepList, errs := graph.TopologicalSort()
+	if errs != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: graphFormatDigraph,
+			expected: `component src
+unit src
+`,
+		},
+		{
+			format: graphFormatDot,
+			expected: `digraph steps {
+  rankdir=LR;
+  "src" [label="src\nClone the source", shape=box];
+  "component" [label="component\nBuild the image", shape=box];
+  "unit" [label="unit\nRun the \"unit\" test", shape=box];
+  "promotion" [label="promotion\nPromote the images", shape=doubleoctagon];
+  "src" -> "component";
+  "src" -> "unit";
+  "component" -> "promotion" [style=dashed];
+  "unit" -> "promotion" [style=dashed];
+}
+`,
+		},
+		{
+			format: graphFormatMermaid,
+			expected: `flowchart LR
+  s0["src<br/>Clone the source"]
+  s1["component<br/>Build the image"]
+  s2["unit<br/>Run the #quot;unit#quot; test"]
+  s3{{"promotion<br/>Promote the images"}}
+  s0 --> s1
+  s0 --> s2
+  s1 -.-> s3
+  s2 -.-> s3
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := printGraph(&out, tc.format, stepList, []api.Step{promotion}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testhelper.Diff(t, "graph", out.String(), tc.expected)
+		})
+	}
+}
+
+func TestValidateGraphFormat(t *testing.T) {
+	for _, format := range graphFormats {
+		if err := validateGraphFormat(format); err != nil {
+			t.Errorf("unexpected error for %s: %v", format, err)
+		}
+	}
+	if err := validateGraphFormat("svg"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	templateapi "github.com/openshift/api/template/v1"
+	"github.com/openshift/imagebuilder"
+	dockercmd "github.com/openshift/imagebuilder/dockerfile/command"
+	"github.com/openshift/imagebuilder/dockerfile/parser"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+)
+
+// imagePolicy restricts the registries the images a job consumes from outside
+// of the cluster can come from. Entries are registries, like quay.io, or
+// repository prefixes in a registry, like quay.io/openshift. Image stream
+// tags are served by the registry of the cluster and are not restricted.
+type imagePolicy struct {
+	// allowed are the only locations images can come from, if set
+	allowed []string
+	// denied are locations images cannot come from, even if allowed
+	denied []string
+}
+
+// matches determines whether the normalized name of an image is in the
+// location, which is either a registry or a repository prefix.
+func matches(name, location string) bool {
+	location = strings.TrimSuffix(location, "/")
+	return name == location || strings.HasPrefix(name, location+"/")
+}
+
+// check returns why the image with the pull spec is not allowed, if it is not.
+func (p imagePolicy) check(pullSpec string) string {
+	named, err := reference.ParseNormalizedNamed(pullSpec)
+	if err != nil {
+		return fmt.Sprintf("invalid pull spec: %v", err)
+	}
+	name := named.Name()
+	for _, location := range p.denied {
+		if matches(name, location) {
+			return fmt.Sprintf("%s is denied", location)
+		}
+	}
+	if len(p.allowed) == 0 {
+		return ""
+	}
+	for _, location := range p.allowed {
+		if matches(name, location) {
+			return ""
+		}
+	}
+	return "the registry is not allowed"
+}
+
+// violations lists the images of base images, test steps, templates and
+// Dockerfiles that the policy does not allow, along with where they are used.
+// Template images are checked with the parameters that are known before the
+// template runs. Dockerfiles in the repository are only checked when the
+// source is checked out in sourceDir.
+func (p imagePolicy) violations(config *api.ReleaseBuildConfiguration, templates []*templateapi.Template, overrides templateParameters, sourceDir string) []string {
+	var ret []string
+	report := func(context, pullSpec string) {
+		if reason := p.check(pullSpec); reason != "" {
+			ret = append(ret, fmt.Sprintf("%s: image %s: %s", context, pullSpec, reason))
+		}
+	}
+	for name, image := range config.BaseImages {
+		if image.PullSpec != "" {
+			report(fmt.Sprintf("base_images[%s]", name), image.PullSpec)
+		}
+	}
+	for _, test := range config.Tests {
+		for _, step := range literalSteps(test) {
+			for _, dependency := range step.Dependencies {
+				if dependency.PullSpec != "" {
+					report(fmt.Sprintf("tests[%s]: step %s: dependency %s", test.As, step.As, dependency.Env), dependency.PullSpec)
+				}
+			}
+		}
+	}
+	for _, template := range templates {
+		for _, image := range steps.TemplateImages(template) {
+			context := fmt.Sprintf("template %s", template.Name)
+			resolved, fromCluster, unresolved := resolveTemplateImage(template, image, overrides[template.Name])
+			switch {
+			case len(unresolved) != 0:
+				ret = append(ret, fmt.Sprintf("%s: image %s: parameter %s is only known when the template runs", context, image, strings.Join(unresolved, ", ")))
+			case !fromCluster:
+				report(context, resolved)
+			}
+		}
+	}
+	for _, image := range config.Images {
+		context := fmt.Sprintf("images[%s]: Dockerfile", image.To)
+		dockerfile, err := readDockerfile(image, sourceDir)
+		if err != nil {
+			ret = append(ret, fmt.Sprintf("%s: %v", context, err))
+			continue
+		}
+		if dockerfile == nil {
+			continue
+		}
+		baseImages, err := dockerfileBaseImages(image, dockerfile)
+		if err != nil {
+			ret = append(ret, fmt.Sprintf("%s: %v", context, err))
+			continue
+		}
+		for _, baseImage := range baseImages {
+			report(context, baseImage)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// templateParameterReference matches the references to parameters in
+// templates, like ${NAME} or ${{NAME}}.
+var templateParameterReference = regexp.MustCompile(`\$\{\{?([A-Za-z_][A-Za-z0-9_]*)\}?\}`)
+
+// resolveTemplateImage substitutes the parameters of the template image with
+// the values given with --template-param or in the environment, or else the
+// default of the template. Parameters that ci-operator sets to images it
+// imports or builds are served by the registry of the cluster, so the image
+// is not restricted when it refers to any. The parameters without a known
+// value are returned.
+func resolveTemplateImage(template *templateapi.Template, image string, overrides map[string]string) (string, bool, []string) {
+	defaults := map[string]string{}
+	for _, parameter := range template.Parameters {
+		defaults[parameter.Name] = parameter.Value
+	}
+	var fromCluster bool
+	var unresolved []string
+	resolved := templateParameterReference.ReplaceAllStringFunc(image, func(match string) string {
+		name := templateParameterReference.FindStringSubmatch(match)[1]
+		if value, ok := overrides[name]; ok {
+			return value
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		if _, ok := utils.LinkForEnv(name); ok {
+			fromCluster = true
+			return match
+		}
+		if value := defaults[name]; value != "" {
+			return value
+		}
+		unresolved = append(unresolved, name)
+		return match
+	})
+	return resolved, fromCluster, unresolved
+}
+
+// readDockerfile returns the Dockerfile the image is built from, or nil when
+// it is in the repository and the source is not available.
+func readDockerfile(image api.ProjectDirectoryImageBuildStepConfiguration, sourceDir string) ([]byte, error) {
+	if image.DockerfileLiteral != nil {
+		return []byte(*image.DockerfileLiteral), nil
+	}
+	if sourceDir == "" {
+		return nil, nil
+	}
+	path := image.DockerfilePath
+	if path == "" {
+		path = "Dockerfile"
+	}
+	dockerfile, err := os.ReadFile(filepath.Join(sourceDir, image.ContextDir, path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return dockerfile, err
+}
+
+// dockerfileBaseImages returns the images the stages of the Dockerfile are
+// built from, except for those ci-operator replaces with images of the
+// pipeline and for earlier stages.
+func dockerfileBaseImages(image api.ProjectDirectoryImageBuildStepConfiguration, dockerfile []byte) ([]string, error) {
+	node, err := imagebuilder.ParseDockerfile(bytes.NewBuffer(dockerfile))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse: %w", err)
+	}
+	replaced := sets.New[string]()
+	for _, input := range image.Inputs {
+		replaced.Insert(input.As...)
+	}
+	args := map[string]string{}
+	for _, arg := range image.BuildArgs {
+		args[arg.Name] = arg.Value
+	}
+	var froms []*parser.Node
+	for _, child := range node.Children {
+		switch child.Value {
+		case dockercmd.Arg:
+			// only arguments declared before the first stage apply to FROM
+			if len(froms) == 0 && child.Next != nil {
+				name, value, _ := strings.Cut(child.Next.Value, "=")
+				if _, set := args[name]; !set {
+					args[name] = value
+				}
+			}
+		case dockercmd.From:
+			froms = append(froms, child)
+		}
+	}
+	stages := sets.New[string]()
+	var images []string
+	for i, from := range froms {
+		if from.Next == nil {
+			continue
+		}
+		name := os.Expand(from.Next.Value, func(arg string) string {
+			if value, ok := args[arg]; ok {
+				return value
+			}
+			return fmt.Sprintf("${%s}", arg)
+		})
+		var alias string
+		if as := from.Next.Next; as != nil && strings.EqualFold(as.Value, "as") && as.Next != nil {
+			alias = as.Next.Value
+		}
+		switch {
+		case i == len(froms)-1 && image.From != "":
+		case replaced.Has(from.Next.Value), replaced.Has(name), alias != "" && replaced.Has(alias):
+		case stages.Has(name), name == "scratch":
+		default:
+			images = append(images, name)
+		}
+		if alias != "" {
+			stages.Insert(alias)
+		}
+	}
+	return images, nil
+}
+
+// validate fails with a report of every image the policy does not allow.
+func (p imagePolicy) validate(config *api.ReleaseBuildConfiguration, templates []*templateapi.Template, overrides templateParameters, sourceDir string) error {
+	violations := p.violations(config, templates, overrides, sourceDir)
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d images violate the image policy:\n  * %s", len(violations), strings.Join(violations, "\n  * "))
+}
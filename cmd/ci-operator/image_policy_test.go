@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilpointer "k8s.io/utils/pointer"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestImagePolicyCheck(t *testing.T) {
+	policy := imagePolicy{
+		allowed: []string{"quay.io/openshift", "registry.redhat.io", "docker.io/library"},
+		denied:  []string{"quay.io/openshift/untrusted"},
+	}
+	for _, tc := range []struct {
+		pullSpec string
+		expected string
+	}{
+		{pullSpec: "quay.io/openshift/origin-cli:4.14"},
+		{pullSpec: "registry.redhat.io/ubi9/ubi@sha256:8d2f0f8c8b4f5c1f5e1c0f6c7d1c4f0e2a0c9b7d3e1f2a3b4c5d6e7f8a9b0c1d"},
+		{pullSpec: "centos:7"},
+		{pullSpec: "quay.io/openshiftx/cli:latest", expected: "the registry is not allowed"},
+		{pullSpec: "quay.io/openshift/untrusted/tool:latest", expected: "quay.io/openshift/untrusted is denied"},
+		{pullSpec: "ghcr.io/org/tool:v1", expected: "the registry is not allowed"},
+	} {
+		t.Run(tc.pullSpec, func(t *testing.T) {
+			testhelper.Diff(t, "reason", policy.check(tc.pullSpec), tc.expected)
+		})
+	}
+}
+
+func TestImagePolicyViolations(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BaseImages: map[string]api.ImageStreamTagReference{
+				"os":    {Namespace: "ocp", Name: "4.14", Tag: "base"},
+				"tools": {PullSpec: "ghcr.io/org/tools:v1"},
+				"cli":   {PullSpec: "quay.io/openshift/origin-cli:4.14"},
+			},
+		},
+		Tests: []api.TestStepConfiguration{{
+			As: "e2e",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Test: []api.LiteralTestStep{{As: "test", Dependencies: []api.StepDependency{
+					{Name: "index", Env: "OO_INDEX", PullSpec: "docker.io/someone/index:latest"},
+					{Name: "bundle", Env: "OO_BUNDLE"},
+				}}},
+			},
+		}},
+	}
+	config.Images = []api.ProjectDirectoryImageBuildStepConfiguration{{
+		To: "literal",
+		ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+			DockerfileLiteral: utilpointer.String("ARG BASE=ghcr.io/org/base:v1\nFROM ${BASE} AS builder\nFROM quay.io/openshift/origin-cli:4.14\nCOPY --from=builder /bin /bin\n"),
+		},
+	}, {
+		To: "repository",
+		ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+			ContextDir: "images/repository",
+			Inputs:     map[string]api.ImageBuildInputs{"os": {As: []string{"registry.ci.openshift.org/ocp/4.14:base"}}},
+		},
+		From: "os",
+	}}
+	sourceDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(sourceDir, "images/repository"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "images/repository/Dockerfile"), []byte("FROM registry.ci.openshift.org/ocp/4.14:base AS base\nFROM docker.io/library/golang:1.20 AS build\nFROM base\nFROM ghcr.io/org/final:v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	templates := []*templateapi.Template{{
+		ObjectMeta: metav1.ObjectMeta{Name: "e2e-aws"},
+		Parameters: []templateapi.Parameter{{Name: "TOOLS_IMAGE", Value: "ghcr.io/org/tools:v1"}, {Name: "CLI_TAG"}, {Name: "JOB_NAME"}},
+		Objects: []runtime.RawExtension{
+			{Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"setup"},"spec":{"initContainers":[{"name":"cp","image":"ghcr.io/org/cp:v1"}],"containers":[{"name":"test","image":"${IMAGE_TESTS}"},{"name":"cli","image":"quay.io/openshift/origin-cli:${CLI_TAG}"},{"name":"tools","image":"${TOOLS_IMAGE}"},{"name":"job","image":"quay.io/openshift/${JOB_NAME}:latest"}]}}`)},
+		},
+	}}
+	overrides := templateParameters{"e2e-aws": {"CLI_TAG": "4.14"}}
+	policy := imagePolicy{allowed: []string{"quay.io/openshift"}}
+	testhelper.Diff(t, "violations", policy.violations(config, templates, overrides, sourceDir), []string{
+		"base_images[tools]: image ghcr.io/org/tools:v1: the registry is not allowed",
+		"images[literal]: Dockerfile: image ghcr.io/org/base:v1: the registry is not allowed",
+		"images[repository]: Dockerfile: image docker.io/library/golang:1.20: the registry is not allowed",
+		"template e2e-aws: image ghcr.io/org/cp:v1: the registry is not allowed",
+		"template e2e-aws: image ghcr.io/org/tools:v1: the registry is not allowed",
+		"template e2e-aws: image quay.io/openshift/${JOB_NAME}:latest: parameter JOB_NAME is only known when the template runs",
+		"tests[e2e]: step test: dependency OO_INDEX: image docker.io/someone/index:latest: the registry is not allowed",
+	})
+	if err := (imagePolicy{denied: []string{"docker.io"}}).validate(config, templates, overrides, ""); err == nil {
+		t.Error("expected the image of the dependency to be denied")
+	}
+	if err := (imagePolicy{allowed: []string{"quay.io", "ghcr.io", "docker.io"}}).validate(config, templates, templateParameters{"e2e-aws": {"CLI_TAG": "4.14", "JOB_NAME": "e2e"}}, sourceDir); err != nil {
+		t.Errorf("expected all images to be allowed, got %v", err)
+	}
+}
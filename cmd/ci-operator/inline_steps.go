@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// registryReferences lists what a multi-stage test uses from the step
+// registry: its workflow, step and chain references and observers.
+func registryReferences(test api.MultiStageTestConfiguration) []string {
+	var references []string
+	if test.Workflow != nil {
+		references = append(references, fmt.Sprintf("workflow %s", *test.Workflow))
+	}
+	for _, phase := range [][]api.TestStep{test.Pre, test.Test, test.Post} {
+		for _, step := range phase {
+			switch {
+			case step.Reference != nil:
+				references = append(references, fmt.Sprintf("step %s", *step.Reference))
+			case step.Chain != nil:
+				references = append(references, fmt.Sprintf("chain %s", *step.Chain))
+			case step.LiteralTestStep != nil:
+				for _, observer := range step.Observers {
+					references = append(references, fmt.Sprintf("observer %s", observer))
+				}
+			}
+		}
+	}
+	if test.Observers != nil {
+		for _, observer := range test.Observers.Enable {
+			references = append(references, fmt.Sprintf("observer %s", observer))
+		}
+	}
+	return references
+}
+
+// resolveInlineSteps resolves the multi-stage tests of a configuration that
+// define all of their steps inline, so they run without a step registry.
+func resolveInlineSteps(config api.ReleaseBuildConfiguration) (api.ReleaseBuildConfiguration, error) {
+	for _, test := range config.Tests {
+		if test.MultiStageTestConfiguration == nil {
+			continue
+		}
+		if references := registryReferences(*test.MultiStageTestConfiguration); len(references) != 0 {
+			return api.ReleaseBuildConfiguration{}, fmt.Errorf("test %s uses %s from the step registry: resolve the configuration with --registry or --unresolved-config, or define the steps inline", test.As, strings.Join(references, ", "))
+		}
+	}
+	return registry.ResolveConfig(registry.NewResolver(nil, nil, nil, nil), config)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/utils/pointer"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestResolveInlineSteps(t *testing.T) {
+	literal := func(as string) api.TestStep {
+		return api.TestStep{LiteralTestStep: &api.LiteralTestStep{As: as, From: "src", Commands: "make " + as}}
+	}
+	var testCases = []struct {
+		name        string
+		test        api.MultiStageTestConfiguration
+		expected    *api.MultiStageTestConfigurationLiteral
+		expectedErr error
+	}{
+		{
+			name: "inline steps",
+			test: api.MultiStageTestConfiguration{
+				Pre:  []api.TestStep{literal("setup")},
+				Test: []api.TestStep{literal("test")},
+				Post: []api.TestStep{literal("teardown")},
+			},
+			expected: &api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{*literal("setup").LiteralTestStep},
+				Test: []api.LiteralTestStep{*literal("test").LiteralTestStep},
+				Post: []api.LiteralTestStep{*literal("teardown").LiteralTestStep},
+			},
+		},
+		{
+			name: "registry references",
+			test: api.MultiStageTestConfiguration{
+				Workflow: pointer.String("e2e"),
+				Pre:      []api.TestStep{{Chain: pointer.String("install")}},
+				Test:     []api.TestStep{literal("test"), {Reference: pointer.String("conformance")}},
+			},
+			expectedErr: errors.New("test e2e uses workflow e2e, chain install, step conformance from the step registry: resolve the configuration with --registry or --unresolved-config, or define the steps inline"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{
+				{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+				{As: "e2e", MultiStageTestConfiguration: &tc.test},
+			}}
+			resolved, err := resolveInlineSteps(config)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err != nil {
+				return
+			}
+			if resolved.Tests[0].ContainerTestConfiguration == nil {
+				t.Error("expected the container test to be kept")
+			}
+			if resolved.Tests[1].MultiStageTestConfiguration != nil {
+				t.Error("expected the multi-stage test to be resolved")
+			}
+			testhelper.Diff(t, "resolved test", resolved.Tests[1].MultiStageTestConfigurationLiteral, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// The refs of the job are resolved with these precedence rules:
+//
+//   - without --git-ref, the refs of JOB_SPEC are used
+//   - without JOB_SPEC, the refs resolved from --git-ref are used
+//   - with both, the rest of JOB_SPEC is used with the refs of --git-ref
+//
+// The refs are expected to be those of the repository the configuration is
+// for. With --strict-job-spec, any disagreement between the sources fails the
+// execution instead of being logged.
+
+// describeRefs describes the refs for the logs.
+func describeRefs(refs *prowapi.Refs) string {
+	if refs == nil {
+		return "no refs"
+	}
+	description := fmt.Sprintf("%s/%s@%s", refs.Org, refs.Repo, refs.BaseRef)
+	if refs.BaseSHA != "" {
+		description = fmt.Sprintf("%s (%s)", description, refs.BaseSHA)
+	}
+	for _, pull := range refs.Pulls {
+		description = fmt.Sprintf("%s, #%d (%s)", description, pull.Number, pull.SHA)
+	}
+	return description
+}
+
+// refsConflicts lists the disagreements between the refs of JOB_SPEC and the
+// ones resolved from --git-ref. A different commit of the same branch is not
+// a conflict, as --git-ref is commonly used to test another commit.
+func refsConflicts(jobSpecRefs, gitRefRefs *prowapi.Refs) []string {
+	if jobSpecRefs == nil || gitRefRefs == nil {
+		return nil
+	}
+	var conflicts []string
+	for _, field := range []struct{ name, jobSpec, gitRef string }{
+		{name: "org", jobSpec: jobSpecRefs.Org, gitRef: gitRefRefs.Org},
+		{name: "repo", jobSpec: jobSpecRefs.Repo, gitRef: gitRefRefs.Repo},
+		{name: "base_ref", jobSpec: jobSpecRefs.BaseRef, gitRef: gitRefRefs.BaseRef},
+	} {
+		if field.jobSpec != field.gitRef {
+			conflicts = append(conflicts, fmt.Sprintf("JOB_SPEC has %s %q but --git-ref has %q", field.name, field.jobSpec, field.gitRef))
+		}
+	}
+	if len(jobSpecRefs.Pulls) != 0 {
+		conflicts = append(conflicts, fmt.Sprintf("JOB_SPEC tests %d pull request(s) which --git-ref drops", len(jobSpecRefs.Pulls)))
+	}
+	return conflicts
+}
+
+// mainRefs are the refs of the repository the job tests.
+func mainRefs(jobSpec *api.JobSpec) *prowapi.Refs {
+	if jobSpec.Refs != nil {
+		return jobSpec.Refs
+	}
+	if len(jobSpec.ExtraRefs) != 0 {
+		return &jobSpec.ExtraRefs[0]
+	}
+	return nil
+}
+
+// metadataConflicts lists the disagreements between the refs of the job and
+// the repository the configuration is for. Fields of the metadata that are
+// not set are not compared.
+func metadataConflicts(refs *prowapi.Refs, metadata api.Metadata) []string {
+	if refs == nil {
+		return nil
+	}
+	var conflicts []string
+	for _, field := range []struct{ name, refs, metadata string }{
+		{name: "org", refs: refs.Org, metadata: metadata.Org},
+		{name: "repo", refs: refs.Repo, metadata: metadata.Repo},
+		{name: "branch", refs: refs.BaseRef, metadata: metadata.Branch},
+	} {
+		if field.metadata != "" && field.refs != field.metadata {
+			conflicts = append(conflicts, fmt.Sprintf("the job has %s %q but the configuration is for %q", field.name, field.refs, field.metadata))
+		}
+	}
+	return conflicts
+}
+
+// checkProvenance logs the conflicts found between the sources of the job,
+// failing on them in the strict mode.
+func checkProvenance(source string, conflicts []string, strict bool) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("%s conflict and --strict-job-spec is set: %s", source, strings.Join(conflicts, "; "))
+	}
+	logrus.Warnf("%s conflict: %s", source, strings.Join(conflicts, "; "))
+	return nil
+}
+
+// verifyExpectedMetadata fails when the refs of the job are not for the
+// repository and branch the configuration declares in its metadata.
+func verifyExpectedMetadata(jobSpec *api.JobSpec, expected *api.ExpectedMetadata) error {
+	if expected == nil {
+		return nil
+	}
+	refs := mainRefs(jobSpec)
+	if refs == nil {
+		return errors.New("the configuration sets metadata to match the refs of the job, but the job has no refs")
+	}
+	if conflicts := metadataConflicts(refs, api.Metadata{Org: expected.Org, Repo: expected.Repo, Branch: expected.Branch}); len(conflicts) != 0 {
+		return fmt.Errorf("the configuration is not for the refs of the job (%s): %s", describeRefs(refs), strings.Join(conflicts, "; "))
+	}
+	return nil
+}
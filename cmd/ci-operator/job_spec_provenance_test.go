@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestRefsConflicts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		jobSpec  *prowapi.Refs
+		gitRef   *prowapi.Refs
+		expected []string
+	}{
+		{
+			name:    "another commit of the same branch",
+			jobSpec: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "abc"},
+			gitRef:  &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "def"},
+		},
+		{
+			name:   "no refs in JOB_SPEC",
+			gitRef: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+		},
+		{
+			name:    "another repository and pull requests",
+			jobSpec: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", Pulls: []prowapi.Pull{{Number: 1}}},
+			gitRef:  &prowapi.Refs{Org: "other", Repo: "repo", BaseRef: "release-4.14"},
+			expected: []string{
+				`JOB_SPEC has org "org" but --git-ref has "other"`,
+				`JOB_SPEC has base_ref "master" but --git-ref has "release-4.14"`,
+				"JOB_SPEC tests 1 pull request(s) which --git-ref drops",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "conflicts", refsConflicts(tc.jobSpec, tc.gitRef), tc.expected)
+		})
+	}
+}
+
+func TestMetadataConflicts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		jobSpec  *api.JobSpec
+		metadata api.Metadata
+		expected []string
+	}{
+		{
+			name:     "matching refs",
+			jobSpec:  &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master"}}},
+			metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master", Variant: "v"},
+		},
+		{
+			name:     "no metadata",
+			jobSpec:  &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master"}}},
+			metadata: api.Metadata{},
+		},
+		{
+			name:     "extra refs of another branch",
+			jobSpec:  &api.JobSpec{JobSpec: downwardapi.JobSpec{ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo", BaseRef: "main"}}}},
+			metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+			expected: []string{`the job has branch "main" but the configuration is for "master"`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "conflicts", metadataConflicts(mainRefs(tc.jobSpec), tc.metadata), tc.expected)
+		})
+	}
+}
+
+func TestCheckProvenance(t *testing.T) {
+	if err := checkProvenance("the sources", []string{"a", "b"}, false); err != nil {
+		t.Errorf("expected conflicts to only be logged, got %v", err)
+	}
+	testhelper.Diff(t, "error", checkProvenance("the sources", []string{"a", "b"}, true), errors.New("the sources conflict and --strict-job-spec is set: a; b"), testhelper.EquateErrorMessage)
+	if err := checkProvenance("the sources", nil, true); err != nil {
+		t.Errorf("expected no error without conflicts, got %v", err)
+	}
+}
+
+func TestVerifyExpectedMetadata(t *testing.T) {
+	refs := &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "abc"}
+	testCases := []struct {
+		name     string
+		jobSpec  *api.JobSpec
+		expected *api.ExpectedMetadata
+		err      error
+	}{
+		{
+			name:    "no metadata",
+			jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: refs}},
+		},
+		{
+			name:     "matching metadata",
+			jobSpec:  &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: refs}},
+			expected: &api.ExpectedMetadata{Org: "org", Repo: "repo", Branch: "master"},
+		},
+		{
+			name:     "any branch",
+			jobSpec:  &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: refs}},
+			expected: &api.ExpectedMetadata{Org: "org", Repo: "repo"},
+		},
+		{
+			name:     "another repository",
+			jobSpec:  &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: refs}},
+			expected: &api.ExpectedMetadata{Org: "org", Repo: "other", Branch: "master"},
+			err:      errors.New(`the configuration is not for the refs of the job (org/repo@master (abc)): the job has repo "repo" but the configuration is for "other"`),
+		},
+		{
+			name:     "no refs",
+			jobSpec:  &api.JobSpec{},
+			expected: &api.ExpectedMetadata{Org: "org"},
+			err:      errors.New("the configuration sets metadata to match the refs of the job, but the job has no refs"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", verifyExpectedMetadata(tc.jobSpec, tc.expected), tc.err, testhelper.EquateErrorMessage)
+		})
+	}
+}
@@ -22,11 +22,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/bombsimon/logrusr/v3"
 	"github.com/go-logr/logr"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
 
 	appsv1 "k8s.io/api/apps/v1"
 	authapi "k8s.io/api/authorization/v1"
@@ -34,26 +37,31 @@ import (
 	policyv1 "k8s.io/api/policy/v1"
 	rbacapi "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	authclientset "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config/secret"
+	"k8s.io/test-infra/prow/github"
+	pkgio "k8s.io/test-infra/prow/io"
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 	"k8s.io/test-infra/prow/version"
 	utilpointer "k8s.io/utils/pointer"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	crcontrollerutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	ctrlruntimelog "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
@@ -72,15 +80,21 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/api/nsttl"
 	"github.com/openshift/ci-tools/pkg/defaults"
+	"github.com/openshift/ci-tools/pkg/features"
 	"github.com/openshift/ci-tools/pkg/interrupt"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/lease"
 	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/metrics"
 	"github.com/openshift/ci-tools/pkg/registry"
 	"github.com/openshift/ci-tools/pkg/registry/server"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/policyclient"
+	releasesteps "github.com/openshift/ci-tools/pkg/steps/release"
+	"github.com/openshift/ci-tools/pkg/steps/scopeclient"
+	"github.com/openshift/ci-tools/pkg/upload"
 	"github.com/openshift/ci-tools/pkg/util"
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 	"github.com/openshift/ci-tools/pkg/validation"
@@ -111,47 +125,14 @@ The standard build steps are designed for simple command-line actions (like invo
 "make test") but can be extended by passing one or more templates via the --template flag.
 The name of the template defines the stage and the template must contain at least one
 pod. The parameters passed to the template are the current process environment and a set
-of dynamic parameters that are inferred from previous steps. These parameters are:
-
-  NAMESPACE
-    The namespace generated by the operator for the given inputs or the value of
-    --namespace.
-
-  IMAGE_FORMAT
-    A string that points to the public image repository URL of the image stream(s)
-    created by the tag step. Example:
-
-      registry.svc.ci.openshift.org/ci-op-9o8bacu/stable:${component}
-
-    Will cause the template to depend on all image builds.
-
-  IMAGE_<component>
-    The public image repository URL for an output image. If specified the template
-    will depend on the image being built.
-
-  LOCAL_IMAGE_<component>
-    The public image repository URL for an image that was built during this run but
-    was not part of the output (such as pipeline cache images). If specified the
-    template will depend on the image being built.
-
-  JOB_NAME
-    The job name from the JOB_SPEC
-
-  JOB_NAME_SAFE
-    The job name in a form safe for use as a Kubernetes resource name.
-
-  JOB_NAME_HASH
-    A short hash of the job name for making tasks unique. This will not account for the target-additional-suffix.
-
-  UNIQUE_HASH
-	A hash for making tasks unique, even when the job name may be the same due to using the target-additional-suffix.
-
-  RPM_REPO_<org>_<repo>
-    If the job creates RPMs this will be the public URL that can be used as the
-		baseurl= value of an RPM repository. The value of org and repo are uppercased
-		and dashes are replaced with underscores.
+of dynamic parameters that are inferred from previous steps. Using a parameter that
+holds the pull spec of an image makes the template depend on the image. The parameters
+of every job are documented in the parameters.json artifact. These parameters are:
+`
 
-Dynamic environment variables are overridden by process environment variables.
+// usageAfterParameters follows the reference of the parameters, which is
+// generated from the steps providing them.
+const usageAfterParameters = `Dynamic environment variables are overridden by process environment variables.
 
 Both test and template jobs can gather artifacts created by pods. Set
 --artifact-dir to define the top level artifact directory, and any test task
@@ -160,15 +141,70 @@ into a container will have artifacts extracted after the container has completed
 Errors in artifact extraction will not cause build failures.
 
 In CI environments the inputs to a job may be different than what a normal
-development workflow would use. The --override file will override fields
-defined in the config file, such as base images and the release tag configuration.
+development workflow would use. Files passed to --config after the first one
+override the fields defined before them, such as base images and the release
+tag configuration: objects are merged, images and tests are merged with the
+ones of the same name, other lists are replaced and null values remove
+fields. A directory passed to --config stands for the configuration files in
+it, in lexical order.
 
 After a successful build the --promote will tag each built image (in "images")
 to the image stream(s) identified by the "promotion" config. You may add
 additional images to promote and their target names via the "additional_images"
 map.
+
+To recover from a promotion that failed, --promote-only skips building and
+testing and promotes the images already in the pipeline image stream of the
+namespace, while --promote-dry-run prints the tags the promotion would create
+or overwrite instead of promoting.
+
+After a successful build the --push-images-to will push the built images (or
+those selected with --push-image) to a repository of your own, tagged by their
+names, and print the resulting pullspecs.
 `
 
+const examples = `  # Build and test everything defined in a configuration file
+  ci-operator --config=config.yaml --git-ref=openshift/origin@master
+
+  # Only run the unit test and the steps it depends on
+  ci-operator --config=config.yaml --git-ref=openshift/origin@master --target=unit
+
+  # Show what would be created in the cluster without running anything
+  ci-operator --config=config.yaml --git-ref=openshift/origin@master --dry-run
+
+  # Show which tags a promotion of the images of a previous execution would change
+  ci-operator --config=config.yaml --git-ref=openshift/origin@master --promote-only --promote-dry-run --image-mirror-push-secret=/secrets/push/.dockerconfigjson
+
+  # Push the built images to a personal repository
+  ci-operator --config=config.yaml --git-ref=openshift/origin@master --push-images-to=quay.io/user/prefix --push-images-secret-dir=/secrets/quay
+`
+
+// usageFor prints the description, examples and flags of the command.
+// It is set as the usage function of the flag set, so that --help and -h are
+// handled by the flag package and the output goes where the flag set writes.
+func usageFor(flagSet *flag.FlagSet) func() {
+	return func() {
+		w := flagSet.Output()
+		fmt.Fprint(w, usage)
+		printParameterReference(w, defaults.ParameterReference())
+		fmt.Fprint(w, usageAfterParameters)
+		fmt.Fprintf(w, "\nUsage:\n  ci-operator [flags]\n\nExamples:\n%s\nFlags:\n", examples)
+		flagSet.PrintDefaults()
+	}
+}
+
+// printParameterReference prints the name and description of the parameters,
+// with an empty line after each.
+func printParameterReference(w io.Writer, parameters []api.Parameter) {
+	for _, parameter := range parameters {
+		fmt.Fprintf(w, "\n  %s\n", parameter.Name)
+		if parameter.Description != "" {
+			fmt.Fprintf(w, "    %s.\n", parameter.Description)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
 const (
 	leaseAcquireTimeout = 120 * time.Minute
 )
@@ -180,11 +216,52 @@ var (
 	configResolverAddress = api.URLForService(api.ServiceConfig)
 )
 
+// configDigestAnnotation records the digest of the configuration on the namespace.
+var configDigestAnnotation = fmt.Sprintf("%s/config-digest", steps.CiAnnotationPrefix)
+
+var (
+	// namespaceBackoff waits for the namespace to be created and for the
+	// cluster to set it up, in about three minutes.
+	namespaceBackoff = wait.Backoff{Duration: time.Second, Factor: 1.2, Jitter: 0.1, Steps: 20}
+	// namespaceTerminationInterval is how often we check whether a previous
+	// namespace with the same name finished terminating. Deleting a namespace
+	// can take a long time, so we wait for as long as it takes.
+	namespaceTerminationInterval = 3 * time.Second
+	// transientBackoff retries requests that failed with transient errors.
+	transientBackoff = wait.Backoff{Duration: 500 * time.Millisecond, Factor: 2, Jitter: 0.1, Steps: 5}
+
+	errNamespaceNotReady = errors.New("the namespace is not ready")
+)
+
+// isNamespaceNotReady determines whether initializing the namespace should
+// be retried.
+func isNamespaceNotReady(err error) bool {
+	return errors.Is(err, errNamespaceNotReady) || util.IsTransientError(err)
+}
+
+// isConflictOrTransientError determines whether reading, modifying and
+// updating an object should be retried, which is also the case when the
+// object changed in the meantime.
+func isConflictOrTransientError(err error) bool {
+	return kerrors.IsConflict(err) || util.IsTransientError(err)
+}
+
+// createWithRetries creates the object in the test namespace, retrying on
+// transient errors. Existing objects are left alone.
+func (o *options) createWithRetries(ctx context.Context, client ctrlruntimeclient.Client, operation string, obj ctrlruntimeclient.Object) error {
+	return o.retrier.Retry(ctx, operation, transientBackoff, util.IsTransientError, func(ctx context.Context) error {
+		if err := client.Create(ctx, obj); err != nil && !kerrors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	})
+}
+
 // CustomProwMetadata the name of the custom prow metadata file that's expected to be found in the artifacts directory.
 const CustomProwMetadata = "custom-prow-metadata.json"
 
 func main() {
-	censor, closer, err := setupLogger()
+	censor, output, closer, err := setupLogger()
 	if err != nil {
 		logrus.WithError(err).Fatal("Could not set up logging.")
 	}
@@ -197,21 +274,26 @@ func main() {
 	}
 	// "i just don't want spam"
 	klog.LogToStderr(false)
-	logrus.Infof("%s version %s", version.Name, version.Version)
-	flagSet := flag.NewFlagSet("", flag.ExitOnError)
+	flagSet := flag.NewFlagSet("ci-operator", flag.ExitOnError)
+	flagSet.Usage = usageFor(flagSet)
 	opt := bindOptions(flagSet)
 	opt.censor = censor
 	if err := flagSet.Parse(os.Args[1:]); err != nil {
 		logrus.WithError(err).Fatal("failed to parse flags")
 	}
+	if err := output.setFormat(opt.logFormat, censor); err != nil {
+		logrus.WithError(err).Fatal("invalid --log-format")
+	}
+	opt.setFlags = setFlagNames(flagSet)
+	logrus.Infof("%s version %s", version.Name, version.Version)
 
 	ctrlruntimelog.SetLogger(logr.New(ctrlruntimelog.NullLogSink{}))
+	rest.SetDefaultWarningHandler(newClientWarningHandler(opt.clientWarningLevels, logrus.StandardLogger()))
 	if opt.verbose {
 		fs := flag.NewFlagSet("", flag.ExitOnError)
 		klog.InitFlags(fs)
-		if err := fs.Set("alsologtostderr", "true"); err != nil {
-			logrus.WithError(err).Fatal("could not set klog alsologtostderr")
-		}
+		// the output of client-go goes through our logger, like our own
+		klog.SetLogger(logrusr.New(logrus.StandardLogger()))
 		if err := fs.Set("v", "10"); err != nil {
 			logrus.WithError(err).Fatal("could not set klog v")
 		}
@@ -224,12 +306,6 @@ func main() {
 		logrus.SetReportCaller(true)
 		controllerruntime.SetLogger(logrusr.New(logrus.StandardLogger()))
 	}
-	if opt.help {
-		fmt.Print(usage)
-		flagSet.SetOutput(os.Stdout)
-		flagSet.Usage()
-		os.Exit(0)
-	}
 	flagSet.Visit(func(f *flag.Flag) {
 		switch f.Name {
 		case "delete-when-idle":
@@ -244,13 +320,21 @@ func main() {
 
 	rand.Seed(time.Now().UnixNano())
 
+	if opt.validateOnly {
+		os.Exit(opt.runValidateOnly(os.Stdout))
+	}
+
 	if err := opt.Complete(); err != nil {
 		logrus.WithError(err).Error("Failed to load arguments.")
-		opt.Report(results.ForReason("loading_args").ForError(err))
-		os.Exit(1)
+		err = results.ForReason("loading_args").ForError(err)
+		opt.closePolicy()
+		opt.Report(err)
+		os.Exit(results.ExitCode(err))
 	}
 
-	if errs := opt.Run(); len(errs) > 0 {
+	errs := opt.Run()
+	opt.closePolicy()
+	if len(errs) > 0 {
 		var defaulted []error
 		for _, err := range errs {
 			defaulted = append(defaulted, results.DefaultReason(err))
@@ -263,18 +347,64 @@ func main() {
 		logrus.Error("Some steps failed:")
 		logrus.Error(message.String())
 		opt.Report(defaulted...)
-		os.Exit(1)
+		opt.uploadArtifacts()
+		os.Exit(results.ExitCode(defaulted...))
 	}
 	opt.Report()
+	opt.uploadArtifacts()
+}
+
+// uploadArtifacts uploads the artifacts of the execution, if requested. Failures
+// are logged but do not fail the execution.
+func (o *options) uploadArtifacts() {
+	if o.uploadArtifactsTo == "" {
+		return
+	}
+	artifactDir, _ := api.Artifacts()
+	ctx := context.Background()
+	var destination upload.Destination
+	if strings.HasPrefix(o.uploadArtifactsTo, "s3://") {
+		opener, err := pkgio.NewOpener(ctx, "", o.s3UploadSecretPath)
+		if err != nil {
+			logrus.WithError(err).Error("Could not create the client to upload artifacts.")
+			return
+		}
+		if destination, err = upload.NewBucket(opener, o.uploadArtifactsTo); err != nil {
+			logrus.WithError(err).Error("Could not upload artifacts.")
+			return
+		}
+	} else {
+		client, err := storage.NewClient(ctx, option.WithCredentialsFile(o.uploadSecretPath))
+		if err != nil {
+			logrus.WithError(err).Error("Could not create the client to upload artifacts.")
+			return
+		}
+		defer client.Close()
+		if destination, err = upload.NewGCS(client, o.uploadArtifactsTo); err != nil {
+			logrus.WithError(err).Error("Could not upload artifacts.")
+			return
+		}
+	}
+	logrus.Infof("Uploading artifacts to %s", o.uploadArtifactsTo)
+	manifest, err := upload.Directory(ctx, destination, artifactDir, upload.Options{
+		Concurrency: o.uploadConcurrency,
+		Retries:     o.uploadRetries,
+		Backoff:     time.Second,
+	})
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not upload %d artifacts.", len(manifest.Failed))
+	}
+	logrus.Infof("Uploaded %d artifacts, see %s/%s", len(manifest.Objects), o.uploadArtifactsTo, upload.ManifestName)
 }
 
-// setupLogger sets up logrus to print all logs to a file and user-friendly logs to stdout
-func setupLogger() (*secrets.DynamicCensor, io.Closer, error) {
+// setupLogger sets up the output of the logs, returning the hook writing to
+// stdout so its format can be chosen once the flags are parsed.
+func setupLogger() (*secrets.DynamicCensor, *formattingHook, io.Closer, error) {
 	logrus.SetLevel(logrus.TraceLevel)
 	censor := secrets.NewDynamicCensor()
 	logrus.SetFormatter(logrusutil.NewFormatterWithCensor(logrus.StandardLogger().Formatter, &censor))
 	logrus.SetOutput(io.Discard)
-	logrus.AddHook(&formattingHook{
+	output := &formattingHook{
 		formatter: logrusutil.NewFormatterWithCensor(&logrus.TextFormatter{
 			ForceColors:     true,
 			DisableQuote:    true,
@@ -289,33 +419,95 @@ func setupLogger() (*secrets.DynamicCensor, io.Closer, error) {
 			logrus.FatalLevel,
 			logrus.PanicLevel,
 		},
-	})
+	}
+	logrus.AddHook(output)
 	artifactDir, set := api.Artifacts()
 	if !set {
-		return &censor, nil, nil
+		return &censor, output, nil, nil
 	}
 	if err := os.MkdirAll(artifactDir, 0777); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	verboseFile, err := os.Create(filepath.Join(artifactDir, "ci-operator.log"))
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	logrus.AddHook(&formattingHook{
 		formatter: logrusutil.NewFormatterWithCensor(&logrus.JSONFormatter{}, &censor),
 		writer:    verboseFile,
 		logLevels: logrus.AllLevels,
+		fields:    jobLogFields,
 	})
-	return &censor, verboseFile, nil
+	return &censor, output, verboseFile, nil
+}
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// setFormat switches the hook to the format, tagging every line with the
+// fields of the job in the json format.
+func (hook *formattingHook) setFormat(format string, censor *secrets.DynamicCensor) error {
+	switch format {
+	case logFormatText:
+		return nil
+	case logFormatJSON:
+		hook.formatter = logrusutil.NewFormatterWithCensor(&logrus.JSONFormatter{TimestampFormat: time.RFC3339}, censor)
+		hook.fields = jobLogFields
+		return nil
+	default:
+		return fmt.Errorf("must be one of %s or %s, got %q", logFormatText, logFormatJSON, format)
+	}
+}
+
+// logFields are fields describing the job, known only as it runs, that are
+// added to the lines logged in the json format.
+type logFields struct {
+	lock   sync.RWMutex
+	fields logrus.Fields
+}
+
+// jobLogFields tags the lines with the job name and the namespace.
+var jobLogFields = &logFields{fields: logrus.Fields{}}
+
+func (f *logFields) set(key string, value interface{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.fields[key] = value
+}
+
+// with returns the entry with the fields it does not set itself.
+func (f *logFields) with(entry *logrus.Entry) *logrus.Entry {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if len(f.fields) == 0 {
+		return entry
+	}
+	data := make(logrus.Fields, len(f.fields)+len(entry.Data))
+	for key, value := range f.fields {
+		data[key] = value
+	}
+	for key, value := range entry.Data {
+		data[key] = value
+	}
+	tagged := *entry
+	tagged.Data = data
+	return &tagged
 }
 
 type formattingHook struct {
 	formatter logrus.Formatter
 	writer    io.Writer
 	logLevels []logrus.Level
+	// fields are added to every line, if set
+	fields *logFields
 }
 
 func (hook *formattingHook) Fire(entry *logrus.Entry) error {
+	if hook.fields != nil {
+		entry = hook.fields.with(entry)
+	}
 	line, err := hook.formatter.Format(entry)
 	if err != nil {
 		return err
@@ -333,7 +525,7 @@ type stringSlice struct {
 }
 
 func (s *stringSlice) String() string {
-	return strings.Join(s.values, string(filepath.Separator))
+	return strings.Join(s.values, ",")
 }
 
 func (s *stringSlice) Set(value string) error {
@@ -343,40 +535,116 @@ func (s *stringSlice) Set(value string) error {
 
 type options struct {
 	configSpecPath       string
+	configOverlayPaths   []string
+	configTokenPath      string
+	configCacheDir       string
+	configSHA256         string
+	configDigest         string
 	unresolvedConfigPath string
-	templatePaths        stringSlice
-	secretDirectories    stringSlice
-	sshKeyPath           string
-	oauthTokenPath       string
-
-	targets stringSlice
-	promote bool
-
-	verbose    bool
-	help       bool
-	printGraph bool
-
-	writeParams string
-	artifactDir string
+	configInRepo         string
+	// configFromPullRequest is set when the configuration was read from the
+	// source of a pull request with --config-in-repo
+	configFromPullRequest bool
+	templatePaths         stringSlice
+	templateParamValues   stringSlice
+	secretDirectories     stringSlice
+	configMapDirectories  stringSlice
+	profileDir            string
+	profileNamespace      string
+	sshKeyPath            string
+	oauthTokenPath        string
+
+	shardTimingsDir string
+
+	forbidClusterScopedObjects bool
+	untrusted                  bool
+	untrustedSecrets           stringSlice
+	untrustedMaxResources      stringSlice
+	untrustedRuntimeClass      string
+	policyDir                  string
+	policy                     *policyclient.Policy
+	gateNamespaces             stringSlice
+	gateClusterScoped          bool
+	gateHTTPHosts              stringSlice
+
+	resourceProfile string
+	keepRPMRepo     bool
+
+	targets       stringSlice
+	promote       bool
+	promoteDryRun bool
+	promoteOnly   bool
+	// fromNamespace is the namespace of an earlier execution to promote
+	// the images of
+	fromNamespace string
+
+	verbose      bool
+	printGraph   bool
+	graphFormat  string
+	dryRun       bool
+	validateOnly bool
+	logFormat    string
+	timeout      time.Duration
+	metricsAddr  string
+	// maxConcurrency bounds the steps of the graph that run at once
+	maxConcurrency int
+
+	// terminationGracePeriod bounds the teardown after an interruption
+	terminationGracePeriod     time.Duration
+	deleteNamespaceOnInterrupt bool
+
+	clientWarningLevels clientWarningLevels
+
+	// checkpointPath records the steps that completed, to resume from
+	checkpointPath string
+
+	// consoleOutput controls the output of failed steps on the console
+	consoleOutput util.ConsoleOutputOptions
+
+	// strictJobSpec fails on conflicts between the sources of the job
+	strictJobSpec bool
+
+	// setFlags are the names of the flags set on the command line
+	setFlags               []string
+	usageReportAddress     string
+	usageReportAnnotations bool
+
+	inputsOutput string
+
+	writeParams       string
+	writeParamsFormat string
+	artifactDir       string
 
 	gitRef                 string
+	uniqueAttemptNames     bool
 	namespace              string
+	skipNamespaceInit      bool
+	namespaceLockMode      string
+	namespaceLock          *namespaceLock
 	baseNamespace          string
 	extraInputHash         stringSlice
 	idleCleanupDuration    time.Duration
 	idleCleanupDurationSet bool
 	cleanupDuration        time.Duration
 	cleanupDurationSet     bool
+	retrier                *util.Retrier
 
 	inputHash                  string
+	namespacePrefix            string
+	inputHashLength            int
 	secrets                    []*coreapi.Secret
+	configMaps                 []*coreapi.ConfigMap
 	templates                  []*templateapi.Template
+	templateParameters         templateParameters
+	additionalResources        []*unstructured.Unstructured
 	graphConfig                api.GraphConfiguration
 	configSpec                 *api.ReleaseBuildConfiguration
 	jobSpec                    *api.JobSpec
 	clusterConfig              *rest.Config
 	podPendingTimeout          time.Duration
 	consoleHost                string
+	clusterMetadata            string
+	clusterMetadataOverrides   api.ClusterMetadata
 	nodeName                   string
 	leaseServer                string
 	leaseServerCredentialsFile string
@@ -385,6 +653,9 @@ type options struct {
 
 	givePrAuthorAccessToNamespace bool
 	impersonateUser               string
+	impersonateGroups             stringSlice
+	kubeconfig                    string
+	kubeContext                   string
 	authors                       []string
 
 	resolverAddress string
@@ -402,13 +673,45 @@ type options struct {
 
 	pullSecretPath string
 	pullSecret     *coreapi.Secret
+	pullSecretFrom string
 
 	pushSecretPath string
 	pushSecret     *coreapi.Secret
 
+	pushImagesTo        string
+	pushImagesSelection stringSlice
+	pushImagesSecretDir string
+	pushImagesSecret    *coreapi.Secret
+
 	uploadSecretPath string
 	uploadSecret     *coreapi.Secret
 
+	uploadArtifactsTo   string
+	uploadArtifactsRoot string
+	s3UploadSecretPath  string
+	uploadConcurrency   int
+	uploadRetries       int
+
+	githubStatusTokenPath string
+	githubStatusContext   string
+	githubStatusURL       string
+	githubEndpoint        string
+	commitStatus          *steps.CommitStatusReporter
+	// interrupted is set when the execution was cancelled before it ended
+	interrupted bool
+
+	registryStorageBudget string
+	registryBudgetBytes   int64
+	pruneOverBudget       bool
+	pruneIntermediate     bool
+
+	buildBaselinePath        string
+	buildBaseline            *steps.CacheStatistics
+	buildRegressionTolerance float64
+
+	featuresPath string
+	featureGates features.Gates
+
 	cloneAuthConfig *steps.CloneAuthConfig
 
 	resultsOptions results.Options
@@ -421,7 +724,12 @@ type options struct {
 	multiStageParamOverrides stringSlice
 	dependencyOverrides      stringSlice
 
+	allowedImageRegistries stringSlice
+	deniedImageRegistries  stringSlice
+
 	targetAdditionalSuffix string
+
+	registryOverride string
 }
 
 func bindOptions(flag *flag.FlagSet) *options {
@@ -431,8 +739,6 @@ func bindOptions(flag *flag.FlagSet) *options {
 	}
 
 	// command specific options
-	flag.BoolVar(&opt.help, "h", false, "short for --help")
-	flag.BoolVar(&opt.help, "help", false, "See help for this command.")
 	flag.BoolVar(&opt.verbose, "v", false, "Show verbose output.")
 
 	// what we will run
@@ -442,35 +748,86 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.leaseServerCredentialsFile, "lease-server-credentials-file", "", "The path to credentials file used to access the lease server. The content is of the form <username>:<password>.")
 	flag.DurationVar(&opt.leaseAcquireTimeout, "lease-acquire-timeout", leaseAcquireTimeout, "Maximum amount of time to wait for lease acquisition")
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
-	flag.StringVar(&opt.configSpecPath, "config", "", "The configuration file. If not specified the CONFIG_SPEC environment variable or the configresolver will be used.")
+	flag.Var(&configPaths{o: opt}, "config", "The configuration file or an http(s) URL to fetch it from. If not specified the CONFIG_SPEC environment variable or the configresolver will be used. May be repeated or be a directory, in which case the files are merged onto the first one in order.")
+	flag.StringVar(&opt.configTokenPath, "config-token-path", "", "A path to a bearer token used to fetch the configuration when --config is a URL.")
+	flag.StringVar(&opt.configCacheDir, "config-cache-dir", "", "A directory in which configuration fetched from a URL is cached, to be used when the URL cannot be reached.")
+	flag.StringVar(&opt.configSHA256, "config-sha256", "", "The expected SHA-256 digest of the configuration loaded from --config or CONFIG_SPEC. ci-operator refuses to run if the configuration does not match.")
 	flag.StringVar(&opt.unresolvedConfigPath, "unresolved-config", "", "The configuration file, before resolution. If not specified the UNRESOLVED_CONFIG environment variable will be used, if set.")
-	flag.Var(&opt.targets, "target", "One or more targets in the configuration to build. Only steps that are required for this target will be run.")
-	flag.BoolVar(&opt.printGraph, "print-graph", opt.printGraph, "Print a directed graph of the build steps and exit. Intended for use with the golang digraph utility.")
+	flag.StringVar(&opt.configInRepo, "config-in-repo", "", "The directory the source under test is cloned to. The configuration is read from the .ci-operator.yaml file in it, which must be checked out at the tested revision. Configuration from a pull request is validated like the configuration of --untrusted jobs, with the secrets of --untrusted-secret and the ceilings of --untrusted-max-resource, and cannot be promoted.")
+	flag.Var(&opt.targets, "target", "One or more targets in the configuration to build. Only steps that are required for this target will be run. Targets may be glob patterns like 'e2e-*', and a leading '!' excludes the matching steps and the dependencies only they need.")
+	flag.BoolVar(&opt.printGraph, "print-graph", opt.printGraph, "Print a directed graph of the build steps and exit. Intended for use with the golang digraph utility, unless --graph-format is set.")
+	flag.StringVar(&opt.graphFormat, "graph-format", graphFormatDigraph, fmt.Sprintf("Format of the graph printed by --print-graph, one of %s. The dot and mermaid formats describe every step and include the post steps, like promotion.", strings.Join(graphFormats, ", ")))
+	flag.StringVar(&opt.inputsOutput, "inputs-output", "", "Write the resolved inputs of every step, the links it requires and creates and the parameters it provides as JSON to this path before running anything.")
+	flag.StringVar(&opt.logFormat, "log-format", logFormatText, "Format of the logs on stdout: text, or json to write every line as a JSON object tagged with the namespace and job name, and with the step for the progress of the steps.")
+	opt.clientWarningLevels = defaultClientWarningLevels()
+	flag.Var(opt.clientWarningLevels, "client-warning-level", fmt.Sprintf("The level warnings of the API server are logged at, as category=level where the category is %s or %s and the level is a log level or %s to suppress them. Each warning is logged once. Can be passed multiple times.", deprecationWarnings, otherWarnings, suppressedWarnings))
+	flag.StringVar(&opt.usageReportAddress, "usage-report-address", "", "Opt-in: POST a JSON summary of the flags and configuration fields used by this execution to this address. Only their names are reported, never their values.")
+	flag.BoolVar(&opt.usageReportAnnotations, "usage-report-annotations", false, "Opt-in: record the names of the flags and configuration fields used by this execution as annotations of the test namespace.")
+	flag.DurationVar(&opt.timeout, "timeout", 0, "Bound the execution of the graph to this duration. Steps still running are cancelled and fail with a timeout, and their artifacts are gathered. Unbounded by default.")
+	flag.IntVar(&opt.maxConcurrency, "max-concurrency", 0, "Maximum number of steps of the graph, like image builds and tests, that run at the same time. Steps that are ready wait for a running step to finish. Unlimited by default.")
+	flag.DurationVar(&opt.terminationGracePeriod, "termination-grace-period", 30*time.Second, "When interrupted, spend at most this duration cancelling the builds and deleting the pods that are still running before exiting. Set to 0 to exit without tearing them down.")
+	flag.BoolVar(&opt.deleteNamespaceOnInterrupt, "delete-namespace-on-interrupt", false, "When interrupted, also delete the test namespace as part of the teardown bounded by --termination-grace-period.")
+	flag.BoolVar(&opt.consoleOutput.StripANSI, "strip-ansi", false, "Strip the ANSI escape sequences, like colors, from the output of failed steps printed on the console.")
+	flag.BoolVar(&opt.consoleOutput.CollapseRepeatedLines, "collapse-repeated-lines", false, "Print consecutive identical lines of the output of failed steps once, with the number of repetitions.")
+	flag.IntVar(&opt.consoleOutput.MaxBytes, "max-step-output", 0, "Print at most the last given number of bytes of the output of each failed step on the console, saving the full output to the artifacts. Unlimited by default.")
+	flag.StringVar(&opt.checkpointPath, "checkpoint", "", "Record the steps that complete successfully, with the hash of their inputs, in this file. A later execution with the same inputs and checkpoint skips them, as long as what they created still exists in the namespace, resuming from the steps that failed or did not run. Steps providing parameters only known once they ran, like leases and step outputs, always run.")
+	flag.StringVar(&opt.metricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on at /metrics while the job runs, e.g. :9090: step durations and results, build wait times, namespace initialization latency and pod retries. Disabled by default.")
+	flag.BoolVar(&opt.strictJobSpec, "strict-job-spec", false, "Fail instead of logging a warning when the refs of JOB_SPEC conflict with --git-ref, and fail when the refs of the job are not those of the org, repo and branch of the configuration.")
+	flag.BoolVar(&opt.validateOnly, "validate-only", false, "Load and validate the configuration and build the graph of its steps without contacting the cluster, then print the errors found as JSON and exit.")
+	flag.BoolVar(&opt.dryRun, "dry-run", opt.dryRun, "Print the objects every step would create and the actions it would perform, then exit without changing anything in the cluster.")
 
 	// add to the graph of things we run or create
-	flag.Var(&opt.templatePaths, "template", "A set of paths to optional templates to add as stages to this job. Each template is expected to contain at least one restart=Never pod. Parameters are filled from environment or from the automatic parameters generated by the operator.")
-	flag.Var(&opt.secretDirectories, "secret-dir", "One or more directories that should converted into secrets in the test namespace. If the directory contains a single file with name .dockercfg or config.json it becomes a pull secret.")
+	flag.StringVar(&opt.shardTimingsDir, "shard-timings-dir", "", "A directory with the timings of sharded tests from a previous run, as saved in the shard-timings artifact directory, used to balance the tests between the shards.")
+	flag.BoolVar(&opt.untrusted, "untrusted", false, "Run a job whose configuration or commands come from untrusted authors: promotion and uploads are disabled, only secrets from --untrusted-secret can be mounted, resources are limited by --untrusted-max-resource, cluster profiles, leases, cluster claims, additional resources, manifests, gates and dedicated namespaces cannot be used and the namespace is labeled and annotated with ci.openshift.io/untrusted for network policies.")
+	flag.Var(&opt.untrustedSecrets, "untrusted-secret", "A secret that tests of an untrusted job may mount, by name for secrets in the test namespace or as namespace/name for credentials. Can be passed multiple times.")
+	flag.StringVar(&opt.resourceProfile, "resource-profile", "", "Path to a file with a resources block, like the one of the configuration, whose requests and limits apply to the build, test and template pods for each step and resource the configuration sets none for.")
+	flag.BoolVar(&opt.keepRPMRepo, "keep-rpm-repo", false, "Keep serving the RPMs until the namespace is deleted, instead of deleting the RPM repository server once every step requiring it finished. The server is always kept when this execution does not hold the namespace lock, as executions sharing the namespace use the same server.")
+	flag.Var(&opt.untrustedMaxResources, "untrusted-max-resource", "The most of a resource any step of an untrusted job can request, as name=quantity, like cpu=4 (default) or memory=16Gi (default). Can be passed multiple times.")
+	flag.StringVar(&opt.untrustedRuntimeClass, "untrusted-runtime-class", "", "The RuntimeClass, like a gVisor or Kata Containers sandbox, that test and template pods of an untrusted job run with, replacing any runtime_class of the configuration.")
+	flag.Var(&opt.gateNamespaces, "gate-namespace", "A namespace the resource and image stream tag gates of tests may check besides the test namespace. Can be passed multiple times.")
+	flag.BoolVar(&opt.gateClusterScoped, "gate-cluster-scoped", false, "Allow the resource gates of tests to check cluster-scoped resources.")
+	flag.Var(&opt.gateHTTPHosts, "gate-http-host", "A host the HTTP gates of tests may send requests to, as ci-operator sends them from inside the cluster. HTTP gates are not allowed unless their host is passed. Can be passed multiple times.")
+	flag.BoolVar(&opt.forbidClusterScopedObjects, "forbid-cluster-scoped-objects", false, "Reject templates, manifests and steps that create cluster-scoped objects, like ClusterRoles or CRDs, unless their kind is listed in the allowed_cluster_scoped_kinds of the configuration. The scope of a kind is discovered from the cluster.")
+	flag.StringVar(&opt.policyDir, "policy-dir", "", "A directory of Rego policies evaluated with opa against every object before it is created, updated or patched. Objects for which the rules in package ci_operator produce a deny message are rejected and every evaluation is recorded in the policy-audit.jsonl artifact.")
+	flag.Var(&opt.templatePaths, "template", "A set of paths to optional templates to add as stages to this job. Each template is expected to contain at least one restart=Never pod. Parameters are filled from environment or from the automatic parameters generated by the operator. A path may be followed by :ALIAS to name the template, so the same template can be added more than once. JOB_NAME_SAFE defaults to the alias for aliased templates, so the objects of the copies do not collide.")
+	flag.Var(&opt.templateParamValues, "template-param", "Set a parameter of one template as TEMPLATE:PARAMETER=value, where TEMPLATE is the name or alias of the template. Takes precedence over the parameters generated by the operator. Can be passed multiple times.")
+	flag.Var(&opt.secretDirectories, "secret-dir", "One or more directories that should converted into secrets in the test namespace. If the directory contains a single file with name .dockercfg or config.json it becomes a pull secret. Instead of a path, takes comma-separated options: path=DIR (required), name=NAME of the secret instead of the base name of the directory, type=TYPE of the secret, key=FILE to include only some files (repeatable) and recursive=true to include nested directories, whose files are keyed by their relative path with dots as separators.")
+	flag.Var(&opt.configMapDirectories, "configmap-dir", "One or more directories that should be converted into ConfigMaps in the test namespace, for configuration that is not sensitive. Takes the same values as --secret-dir, except for the type option.")
+	flag.StringVar(&opt.profileDir, "profile-dir", "", "A directory containing one directory per cluster profile. The profile of each targeted test is loaded from it, unless provided with --secret-dir.")
+	flag.StringVar(&opt.profileNamespace, "profile-namespace", "", "A namespace holding the Secrets and ConfigMaps of cluster profiles. Used for profiles that are not found in --profile-dir.")
 	flag.StringVar(&opt.sshKeyPath, "ssh-key-path", "", "A path of the private ssh key that is going to be used to clone a private repository.")
 	flag.StringVar(&opt.oauthTokenPath, "oauth-token-path", "", "A path of the OAuth token that is going to be used to clone a private repository.")
 
 	// the target namespace and cleanup behavior
 	flag.Var(&opt.extraInputHash, "input-hash", "Add arbitrary inputs to the build input hash to make the created namespace unique.")
 	flag.StringVar(&opt.namespace, "namespace", "", "Namespace to create builds into, defaults to build_id from JOB_SPEC. If the string '{id}' is in this value it will be replaced with the build input hash.")
+	flag.StringVar(&opt.namespacePrefix, "namespace-prefix", defaultNamespacePrefix, "Prefix of the namespace named after the build input hash, when --namespace is not set.")
+	flag.IntVar(&opt.inputHashLength, "input-hash-length", defaultInputHashLength, fmt.Sprintf("Length of the build input hash naming the namespace, between %d and %d. Longer hashes reduce collisions.", defaultInputHashLength, maxInputHashLength))
 	flag.StringVar(&opt.baseNamespace, "base-namespace", "stable", "Namespace to read builds from, defaults to stable.")
 	flag.DurationVar(&opt.idleCleanupDuration, "delete-when-idle", opt.idleCleanupDuration, "If no pod is running for longer than this interval, delete the namespace. Set to zero to retain the contents. Requires the namespace TTL controller to be deployed.")
 	flag.DurationVar(&opt.cleanupDuration, "delete-after", opt.cleanupDuration, "If namespace exists for longer than this interval, delete the namespace. Set to zero to retain the contents. Requires the namespace TTL controller to be deployed.")
 
 	// actions to add to the graph
 	flag.BoolVar(&opt.promote, "promote", false, "When all other targets complete, publish the set of images built by this job into the release configuration.")
+	flag.BoolVar(&opt.promoteDryRun, "promote-dry-run", false, "Like --promote, but print the tags the promotion would create or overwrite in which image streams instead of promoting.")
+	flag.BoolVar(&opt.promoteOnly, "promote-only", false, "Skip building and testing and promote the images already present in the pipeline image stream of the namespace. Implies --promote.")
+	flag.StringVar(&opt.fromNamespace, "from-namespace", "", "Promote the images an earlier execution built in this namespace, after verifying that it ran with the same inputs and revisions and recorded every step of the graph as succeeded. Implies --promote-only and --skip-namespace-init.")
 
 	// output control
 	flag.StringVar(&opt.artifactDir, "artifact-dir", "", "DEPRECATED. Does nothing, set $ARTIFACTS instead.")
-	flag.StringVar(&opt.writeParams, "write-params", "", "If set write an env-compatible file with the output of the job.")
+	flag.StringVar(&opt.writeParams, "write-params", "", "If set write a file with the parameters of the job, like NAMESPACE, the pull specs of the images in IMAGE_* and LOCAL_IMAGE_* and RPM_REPO_*, in the format given with --write-params-format.")
+	flag.StringVar(&opt.writeParamsFormat, "write-params-format", string(steps.ParametersFormatEnv), "The format of the file written with --write-params: env for KEY=value lines, export for the same lines as export statements, json or yaml.")
 
 	// experimental flags
-	flag.StringVar(&opt.gitRef, "git-ref", "", "Populate the job spec from this local Git reference. If JOB_SPEC is set, the refs field will be overwritten.")
+	flag.StringVar(&opt.gitRef, "git-ref", "", "Populate the job spec from this local Git reference. If JOB_SPEC is set, its refs field is overwritten and the override is logged; see --strict-job-spec.")
+	flag.BoolVar(&opt.uniqueAttemptNames, "unique-attempt-names", false, "Suffix the names of the pods and template instances with the build ID and store artifacts under attempt-<build ID>, so that retries in the same namespace do not collide.")
+	flag.StringVar(&opt.namespaceLockMode, "namespace-lock", namespaceLockNone, fmt.Sprintf("What to do when another execution runs in the same namespace, which it locks with a Lease: %s to share it unaware of each other, %s for the other execution to end, %s to run alongside it, or %s to fail.", namespaceLockNone, namespaceLockWait, namespaceLockAttach, namespaceLockFail))
+	flag.BoolVar(&opt.skipNamespaceInit, "skip-namespace-init", false, "Run in the externally managed namespace given by --namespace without creating or initializing it. ci-operator verifies that the namespace has the permissions, service accounts, imagestream and secrets it needs and fails with a list of what is missing.")
 	flag.BoolVar(&opt.givePrAuthorAccessToNamespace, "give-pr-author-access-to-namespace", true, "Give view access to the temporarily created namespace to the PR author.")
 	flag.StringVar(&opt.impersonateUser, "as", "", "Username to impersonate")
+	flag.Var(&opt.impersonateGroups, "as-group", "Group to impersonate, may be repeated. Requires --as.")
+	flag.StringVar(&opt.kubeconfig, "kubeconfig", "", "Paths to the kubeconfig files to use, separated like $PATH and merged like $KUBECONFIG. Every file must exist. Defaults to $KUBECONFIG, then to the in-cluster configuration.")
+	flag.StringVar(&opt.kubeContext, "context", "", "Name of the context in the kubeconfig to use instead of its current context.")
 
 	// flags needed for the configresolver
 	flag.StringVar(&opt.resolverAddress, "resolver-address", configResolverAddress, "Address of configresolver")
@@ -481,22 +838,50 @@ func bindOptions(flag *flag.FlagSet) *options {
 
 	flag.StringVar(&opt.injectTest, "with-test-from", "", "Inject a test from another ci-operator config, specified by ORG/REPO@BRANCH{__VARIANT}:TEST or JSON (used by configresolver)")
 
+	flag.StringVar(&opt.pullSecretFrom, "pull-secret-from", "", "A <namespace>/<name> pull secret on the cluster to copy into the test namespace and use from the builder, default and template service accounts, e.g. to avoid registry rate limits.")
 	flag.StringVar(&opt.pullSecretPath, "image-import-pull-secret", "", "A set of dockercfg credentials used to import images for the tag_specification.")
 	flag.StringVar(&opt.pushSecretPath, "image-mirror-push-secret", "", "A set of dockercfg credentials used to mirror images for the promotion.")
+	flag.StringVar(&opt.pushImagesTo, "push-images-to", "", "Push the images built by this job to this repository, like quay.io/user/prefix, tagging each by its name, and print the resulting pullspecs. Requires --push-images-secret-dir.")
+	flag.Var(&opt.pushImagesSelection, "push-image", "An image to push with --push-images-to: NAME for a pipeline image or stable:NAME for a stable image. May be specified multiple times, defaults to all images built by the configuration.")
+	flag.StringVar(&opt.pushImagesSecretDir, "push-images-secret-dir", "", "A directory holding the .dockerconfigjson with the credentials for the repository given with --push-images-to or promotion.registry_mirror.")
 	flag.StringVar(&opt.uploadSecretPath, "gcs-upload-secret", "", "GCS credentials used to upload logs and artifacts.")
+	flag.StringVar(&opt.uploadArtifactsTo, "upload-artifacts-to", "", "Upload the artifacts to this location, like gs://bucket/path or s3://bucket/path, once the execution finishes. Requires --gcs-upload-secret or --s3-upload-secret.")
+	flag.StringVar(&opt.uploadArtifactsRoot, "upload-artifacts", "", "Upload the artifacts to this bucket, like gs://bucket or s3://bucket, once the execution finishes, under the path Prow stores the artifacts of the job at. Requires --gcs-upload-secret or --s3-upload-secret.")
+	flag.StringVar(&opt.s3UploadSecretPath, "s3-upload-secret", "", "S3 credentials used to upload artifacts to s3:// locations.")
+	flag.IntVar(&opt.uploadConcurrency, "upload-concurrency", 16, "Maximum number of concurrent uploads of artifacts.")
+	flag.IntVar(&opt.uploadRetries, "upload-retries", 5, "Number of times an artifact that failed to upload is retried.")
+
+	flag.StringVar(&opt.githubStatusTokenPath, "github-status-token", "", "A file holding a GitHub token used to report the outcome of the job, and of each of the --target, as commit statuses on the tested commits. For systems other than Prow that do not report statuses themselves.")
+	flag.StringVar(&opt.githubStatusContext, "github-status-context", "ci/ci-operator", "The context of the commit status reported with --github-status-token. Targets are reported under <context>/<target>.")
+	flag.StringVar(&opt.githubStatusURL, "github-status-url", "", "The URL commit statuses link to, the console of the test namespace by default.")
+	flag.StringVar(&opt.githubEndpoint, "github-endpoint", github.DefaultAPIEndpoint, "The GitHub API endpoint commit statuses are reported to.")
+
+	flag.StringVar(&opt.registryStorageBudget, "registry-storage-budget", "", "Warn when the pipeline images take more registry storage than this quantity, like 50Gi.")
+	flag.BoolVar(&opt.pruneOverBudget, "prune-over-budget", false, "Delete intermediate pipeline images that no remaining step requires while the registry storage budget is exceeded. Requires --registry-storage-budget and --namespace-lock=wait or fail.")
+	flag.BoolVar(&opt.pruneIntermediate, "prune-intermediate-images", false, "Delete every intermediate pipeline image, like bin and test-bin, as soon as all the steps that require it have succeeded. Images built from the images section are kept. Requires --namespace-lock=wait or fail.")
+	flag.StringVar(&opt.buildBaselinePath, "build-baseline", "", "Path to the results.json of an earlier execution. Images whose build duration or size grew by more than --build-regression-tolerance compared to it are reported as regressions.")
+	flag.Float64Var(&opt.buildRegressionTolerance, "build-regression-tolerance", 0.2, "The growth of the build duration or size of an image over --build-baseline that is tolerated before it is reported, like 0.2 for 20%.")
+	flag.StringVar(&opt.featuresPath, "features", "", fmt.Sprintf("Path to a YAML file that enables or disables experimental features, like 'ImportWatchdog: false'. Features can also be set with $%s as comma-separated Feature=true|false pairs, which take precedence over the file.", features.EnvVar))
 
 	flag.StringVar(&opt.hiveKubeconfigPath, "hive-kubeconfig", "", "Path to the kubeconfig file to use for requests to Hive.")
 
 	flag.Var(&opt.multiStageParamOverrides, "multi-stage-param", "A repeatable option where one or more environment parameters can be passed down to the multi-stage steps. This parameter should be in the format NAME=VAL. e.g --multi-stage-param PARAM1=VAL1 --multi-stage-param PARAM2=VAL2.")
+	flag.Var(&opt.allowedImageRegistries, "allowed-image-registry", "A registry, like quay.io, or a repository prefix, like quay.io/openshift, that base images, test step dependencies, template images and the base images of Dockerfiles from outside of the cluster may come from. If set, images from anywhere else are rejected. Dockerfiles in the repository are only checked with --config-in-repo. Can be passed multiple times.")
+	flag.Var(&opt.deniedImageRegistries, "denied-image-registry", "A registry, like docker.io, or a repository prefix that base images, test step dependencies and template images may not come from, even if allowed with --allowed-image-registry. Can be passed multiple times.")
+	flag.StringVar(&opt.clusterMetadata, "cluster-metadata", "", "The cloud the job runs on, like platform=aws,region=us-east-1, exposed to templates and multi-stage steps as CLUSTER_PLATFORM and CLUSTER_REGION. Fields that are not given are detected from the build cluster.")
 	flag.Var(&opt.dependencyOverrides, "dependency-override-param", "A repeatable option used to override dependencies with external pull specs. This parameter should be in the format ENVVARNAME=PULLSPEC, e.g. --dependency-override-param=OO_INDEX=registry.mydomain.com:5000/pushed/myimage. This would override the value for the OO_INDEX environment variable for any tests/steps that currently have that dependency configured.")
 
 	flag.StringVar(&opt.targetAdditionalSuffix, "target-additional-suffix", "", "Inject an additional suffix onto the targeted test's 'as' name. Used for adding an aggregate index")
+	flag.StringVar(&opt.registryOverride, "registry-override", "", "Host of the registry to use in IMAGE_FORMAT instead of discovering it, for clusters that expose their registry in a non-standard way.")
 
 	opt.resultsOptions.Bind(flag)
 	return opt
 }
 
 func (o *options) Complete() error {
+	if err := o.configureFeatures(); err != nil {
+		return results.ForReason("loading_args").ForError(err)
+	}
 	jobSpec, err := api.ResolveSpecFromEnv()
 	if err != nil {
 		if len(o.gitRef) == 0 {
@@ -507,6 +892,7 @@ func (o *options) Complete() error {
 		if refErr != nil {
 			return fmt.Errorf("failed to determine job spec: failed to resolve --git-ref: %w", refErr)
 		}
+		logrus.Infof("JOB_SPEC is not set, using the refs of --git-ref: %s", describeRefs(spec.Refs))
 		jobSpec = spec
 	} else if len(o.gitRef) > 0 {
 		// Read from $JOB_SPEC but --git-ref was also passed, so merge them
@@ -514,15 +900,19 @@ func (o *options) Complete() error {
 		if err != nil {
 			return fmt.Errorf("failed to determine job spec: failed to resolve --git-ref: %w", err)
 		}
+		if err := checkProvenance("the refs of JOB_SPEC and --git-ref", refsConflicts(jobSpec.Refs, spec.Refs), o.strictJobSpec); err != nil {
+			return results.ForReason("loading_args").ForError(err)
+		}
+		logrus.Infof("The refs of --git-ref (%s) override the refs of JOB_SPEC (%s)", describeRefs(spec.Refs), describeRefs(jobSpec.Refs))
 		jobSpec.Refs = spec.Refs
 	}
 	jobSpec.BaseNamespace = o.baseNamespace
-	target := "all"
-	if len(o.targets.values) > 0 {
-		target = o.targets.values[0]
-	}
 	o.jobSpec = jobSpec
-	o.jobSpec.Target = target
+	o.jobSpec.Target = jobTarget(o.targets.values)
+	jobLogFields.set("job", jobSpec.Job)
+	if o.uniqueAttemptNames {
+		o.jobSpec.SetAttempt(attemptFor(jobSpec))
+	}
 
 	info := o.getResolverInfo(jobSpec)
 	o.resolverClient = server.NewResolverClient(o.resolverAddress)
@@ -530,6 +920,55 @@ func (o *options) Complete() error {
 	if o.unresolvedConfigPath != "" && o.configSpecPath != "" {
 		return errors.New("cannot set --config and --unresolved-config at the same time")
 	}
+	if err := o.completeConfigPaths(); err != nil {
+		return err
+	}
+	if o.fromNamespace != "" {
+		if o.namespace != "" && o.namespace != o.fromNamespace {
+			return errors.New("cannot set --from-namespace together with a different --namespace")
+		}
+		o.namespace = o.fromNamespace
+		o.skipNamespaceInit = true
+		o.promoteOnly = true
+	}
+	if o.skipNamespaceInit && (o.namespace == "" || strings.Contains(o.namespace, "{id}")) {
+		return errors.New("--skip-namespace-init requires --namespace to name an existing namespace")
+	}
+	if err := validateNamespaceNaming(o.namespacePrefix, o.inputHashLength); err != nil {
+		return err
+	}
+	if o.promoteDryRun || o.promoteOnly {
+		o.promote = true
+	}
+	if err := validateGraphFormat(o.graphFormat); err != nil {
+		return err
+	}
+	if err := validateNamespaceLockMode(o.namespaceLockMode); err != nil {
+		return err
+	}
+	if o.consoleOutput.MaxBytes < 0 {
+		return fmt.Errorf("--max-step-output must not be negative, got %d", o.consoleOutput.MaxBytes)
+	}
+	if o.censor != nil {
+		o.consoleOutput.Censor = o.censor
+	}
+	util.SetConsoleOutputOptions(o.consoleOutput)
+	if o.configInRepo != "" {
+		if o.configSpecPath != "" || o.unresolvedConfigPath != "" {
+			return errors.New("cannot set --config-in-repo together with --config or --unresolved-config")
+		}
+		if _, set := os.LookupEnv("CONFIG_SPEC"); set {
+			return errors.New("cannot set --config-in-repo when the CONFIG_SPEC environment variable is set")
+		}
+	}
+	if o.configSHA256 != "" && o.configSpecPath == "" {
+		if _, set := os.LookupEnv("CONFIG_SPEC"); !set {
+			return errors.New("--config-sha256 can only verify configuration loaded from --config or CONFIG_SPEC")
+		}
+	}
+	if o.configTokenPath != "" && !isConfigURL(o.configSpecPath) {
+		return errors.New("--config-token-path requires --config to be an http(s) URL")
+	}
 	if o.unresolvedConfigPath != "" && o.resolverAddress == "" {
 		return errors.New("cannot request resolved config with --unresolved-config unless providing --resolver-address")
 	}
@@ -544,7 +983,7 @@ func (o *options) Complete() error {
 		if o.resolverAddress == "" {
 			return errors.New("cannot request config with injected test without providing --resolver-address")
 		}
-		if o.unresolvedConfigPath != "" || o.configSpecPath != "" {
+		if o.unresolvedConfigPath != "" || o.configSpecPath != "" || o.configInRepo != "" {
 			return errors.New("cannot request injecting test into locally provided config")
 		}
 		config, err = o.resolverClient.ConfigWithTest(info, injectTest)
@@ -559,6 +998,25 @@ func (o *options) Complete() error {
 	if len(o.gitRef) != 0 && config.CanonicalGoRepository != nil {
 		o.jobSpec.Refs.PathAlias = *config.CanonicalGoRepository
 	}
+	if err := verifyExpectedMetadata(o.jobSpec, config.ExpectedMetadata); err != nil {
+		return results.ForReason("verifying_config").ForError(err)
+	}
+	if conflicts := metadataConflicts(mainRefs(o.jobSpec), config.Metadata); o.strictJobSpec {
+		if err := checkProvenance("the refs of the job and the configuration", conflicts, true); err != nil {
+			return results.ForReason("loading_args").ForError(err)
+		}
+	} else if len(conflicts) != 0 {
+		// jobs like rehearsals legitimately test a configuration with the
+		// refs of another repository
+		logrus.Debugf("The refs of the job and the configuration differ: %s", strings.Join(conflicts, "; "))
+	}
+	if o.resourceProfile != "" {
+		profile, err := loadResourceProfile(o.resourceProfile)
+		if err != nil {
+			return results.ForReason("loading_args").ForError(err)
+		}
+		config.Resources = applyResourceProfile(config.Resources, profile)
+	}
 	o.configSpec = config
 	o.jobSpec.Metadata = config.Metadata
 	if err := validation.IsValidResolvedConfiguration(o.configSpec); err != nil {
@@ -568,6 +1026,33 @@ func (o *options) Complete() error {
 	if err := validation.IsValidGraphConfiguration(o.graphConfig.Steps); err != nil {
 		return results.ForReason("validating_config").ForError(err)
 	}
+	if o.untrusted {
+		if err := o.validateUntrustedOptions(); err != nil {
+			return err
+		}
+	} else if o.untrustedRuntimeClass != "" {
+		return errors.New("--untrusted-runtime-class can only be used with --untrusted")
+	}
+	if o.configFromPullRequest && o.promote {
+		return errors.New("--promote cannot be used with configuration from a pull request")
+	}
+	// the configuration is resolved from the step registry by now, so the
+	// steps and observers it references are held to the ceilings as well
+	if o.untrusted || o.configFromPullRequest {
+		ceilings, err := untrustedCeilings(o.untrustedMaxResources.values)
+		if err != nil {
+			return fmt.Errorf("invalid --untrusted-max-resource: %w", err)
+		}
+		if err := validateUntrusted(o.configSpec, sets.New(o.untrustedSecrets.values...), ceilings); err != nil {
+			if o.configFromPullRequest {
+				return results.ForReason("untrusted_config").WithError(err).Errorf("configuration from the pull request is not allowed: %v", err)
+			}
+			return results.ForReason("untrusted_config").WithError(err).Errorf("configuration is not allowed for an untrusted job: %v", err)
+		}
+	}
+	if o.untrusted && o.untrustedRuntimeClass != "" {
+		enforceRuntimeClass(o.configSpec, o.untrustedRuntimeClass)
+	}
 	if o.verbose {
 		config, _ := yaml.Marshal(o.configSpec)
 		logrus.WithField("config", string(config)).Trace("Resolved configuration.")
@@ -615,26 +1100,43 @@ func (o *options) Complete() error {
 			return fmt.Errorf("could not get secret from path %s: %w", cloneAuthSecretPath, err)
 		}
 	}
+	if credentials := o.configSpec.CloneCredentials; credentials != nil {
+		if o.cloneAuthConfig != nil {
+			return errors.New("clone_credentials cannot be used together with --ssh-key-path or --oauth-token-path")
+		}
+		o.cloneAuthConfig = cloneAuthConfigFor(*credentials)
+	}
 
-	for _, path := range o.secretDirectories.values {
-		secret, err := util.SecretFromDir(path)
-		name := filepath.Base(path)
+	for _, value := range o.secretDirectories.values {
+		dir, err := parseSecretDir(value)
 		if err != nil {
-			return fmt.Errorf("failed to generate secret %s: %w", name, err)
+			return fmt.Errorf("invalid --secret-dir %s: %w", value, err)
 		}
-		secret.Name = name
-		if len(secret.Data) == 1 {
-			if _, ok := secret.Data[coreapi.DockerConfigJsonKey]; ok {
-				secret.Type = coreapi.SecretTypeDockerConfigJson
-			}
-			if _, ok := secret.Data[coreapi.DockerConfigKey]; ok {
-				secret.Type = coreapi.SecretTypeDockercfg
-			}
+		secret, err := dir.secret()
+		if err != nil {
+			return fmt.Errorf("failed to generate secret %s: %w", dir.name, err)
 		}
 		o.secrets = append(o.secrets, secret)
 	}
 
-	for _, path := range o.templatePaths.values {
+	for _, value := range o.configMapDirectories.values {
+		dir, err := parseConfigMapDir(value)
+		if err != nil {
+			return fmt.Errorf("invalid --configmap-dir %s: %w", value, err)
+		}
+		configMap, err := dir.configMap()
+		if err != nil {
+			return fmt.Errorf("failed to generate ConfigMap %s: %w", dir.name, err)
+		}
+		o.configMaps = append(o.configMaps, configMap)
+	}
+
+	o.templateParameters = templateParameters{}
+	for _, value := range o.templatePaths.values {
+		path, alias, err := parseTemplatePath(value)
+		if err != nil {
+			return fmt.Errorf("invalid --template %s: %w", value, err)
+		}
 		contents, err := os.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("could not read dir %s for template: %w", path, err)
@@ -651,17 +1153,51 @@ func (o *options) Complete() error {
 			template.Name = filepath.Base(path)
 			template.Name = strings.TrimSuffix(template.Name, filepath.Ext(template.Name))
 		}
+		if alias != "" {
+			template.Name = alias
+			if err := o.templateParameters.add(alias + ":JOB_NAME_SAFE=" + alias); err != nil {
+				return err
+			}
+		}
+		if hasTemplate(o.templates, template.Name) {
+			return fmt.Errorf("template %s is added more than once, add its copies with different aliases like --template %s:ALIAS", template.Name, path)
+		}
+		if o.untrusted && o.untrustedRuntimeClass != "" {
+			steps.SetTemplateRuntimeClass(template, o.untrustedRuntimeClass)
+		}
 		o.templates = append(o.templates, template)
 	}
+	for _, value := range o.templateParamValues.values {
+		if err := o.templateParameters.add(value); err != nil {
+			return fmt.Errorf("invalid --template-param: %w", err)
+		}
+	}
+	for name := range o.templateParameters {
+		if !hasTemplate(o.templates, name) {
+			return fmt.Errorf("invalid --template-param: no template is named %s", name)
+		}
+	}
+	if o.policyDir != "" {
+		if o.policy, err = policyFromDir(o.policyDir); err != nil {
+			return fmt.Errorf("could not load policies from --policy-dir: %w", err)
+		}
+	}
+	if o.additionalResources, err = steps.AdditionalResourceObjects(o.configSpec.AdditionalResources, os.ReadFile); err != nil {
+		return results.ForReason("validating_config").ForError(err)
+	}
 
-	clusterConfig, err := util.LoadClusterConfig()
+	clusterConfig, err := util.LoadClusterConfigFor(o.kubeconfig, o.kubeContext)
 	if err != nil {
 		return fmt.Errorf("failed to load cluster config: %w", err)
 	}
 
+	if len(o.impersonateGroups.values) > 0 && len(o.impersonateUser) == 0 {
+		return errors.New("--as-group requires --as")
+	}
 	if len(o.impersonateUser) > 0 {
-		clusterConfig.Impersonate = rest.ImpersonationConfig{UserName: o.impersonateUser}
+		clusterConfig.Impersonate = rest.ImpersonationConfig{UserName: o.impersonateUser, Groups: o.impersonateGroups.values}
 	}
+	clusterConfig.UserAgent = userAgent(o.jobSpec)
 
 	if o.verbose {
 		clusterConfig.ContentType = "application/json"
@@ -670,6 +1206,27 @@ func (o *options) Complete() error {
 
 	o.clusterConfig = clusterConfig
 
+	if o.forbidClusterScopedObjects || len(o.additionalResources) > 0 {
+		if err := o.validateObjectsNamespaced(); err != nil {
+			return results.ForReason("validating_config").ForError(err)
+		}
+	}
+
+	var profileClient ctrlruntimeclient.Reader
+	if o.profileNamespace != "" {
+		if profileClient, err = ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{}); err != nil {
+			return fmt.Errorf("failed to construct client: %w", err)
+		}
+	}
+	if err := o.resolveClusterProfiles(context.TODO(), profileClient); err != nil {
+		return err
+	}
+
+	if o.pullSecretFrom != "" {
+		if _, err := parseSecretReference(o.pullSecretFrom); err != nil {
+			return fmt.Errorf("invalid --pull-secret-from: %w", err)
+		}
+	}
 	if o.pullSecretPath != "" {
 		if o.pullSecret, err = getDockerConfigSecret(api.RegistryPullCredentialsSecret, o.pullSecretPath); err != nil {
 			return fmt.Errorf("could not get pull secret %s from path %s: %w", api.RegistryPullCredentialsSecret, o.pullSecretPath, err)
@@ -681,6 +1238,80 @@ func (o *options) Complete() error {
 		}
 	}
 
+	if o.pushImagesTo != "" {
+		if err := validation.ValidatePushRepository(o.pushImagesTo); err != nil {
+			return fmt.Errorf("invalid --push-images-to: %w", err)
+		}
+	}
+	if o.pushImagesTo != "" || o.registryMirror() != "" {
+		if o.pushImagesSecretDir == "" {
+			return errors.New("--push-images-to and promotion.registry_mirror require --push-images-secret-dir")
+		}
+		if o.pushImagesSecret, err = getDockerConfigSecret(api.PushImagesCredentialsSecret, filepath.Join(o.pushImagesSecretDir, coreapi.DockerConfigJsonKey)); err != nil {
+			return fmt.Errorf("could not get push secret %s from directory %s: %w", api.PushImagesCredentialsSecret, o.pushImagesSecretDir, err)
+		}
+	} else if o.pushImagesSecretDir != "" {
+		return errors.New("--push-images-secret-dir requires --push-images-to or promotion.registry_mirror")
+	}
+	if len(o.pushImagesSelection.values) != 0 && o.pushImagesTo == "" {
+		return errors.New("--push-image requires --push-images-to, as promotion.registry_mirror mirrors the promoted images")
+	}
+	// oc image mirror reads a single auth file, so the push secrets need the
+	// credentials to pull the images they mirror as well.
+	for _, secret := range []*coreapi.Secret{o.pushSecret, o.pushImagesSecret} {
+		if err := mergePullCredentials(secret, o.pullSecret); err != nil {
+			return fmt.Errorf("could not merge pull secret %s into push secret: %w", api.RegistryPullCredentialsSecret, err)
+		}
+	}
+
+	if !validParametersFormat(steps.ParametersFormat(o.writeParamsFormat)) {
+		return fmt.Errorf("--write-params-format must be one of %v", steps.ParametersFormats)
+	}
+	if err := o.completeUploadOptions(); err != nil {
+		return err
+	}
+	if o.maxConcurrency < 0 {
+		return fmt.Errorf("--max-concurrency must not be negative, got %d", o.maxConcurrency)
+	}
+	if o.terminationGracePeriod < 0 {
+		return fmt.Errorf("--termination-grace-period must not be negative, got %s", o.terminationGracePeriod)
+	}
+	if o.deleteNamespaceOnInterrupt && o.skipNamespaceInit {
+		return errors.New("cannot set --delete-namespace-on-interrupt together with --skip-namespace-init, which runs in an externally managed namespace")
+	}
+	if o.clusterMetadata != "" {
+		if o.clusterMetadataOverrides, err = api.ParseClusterMetadata(o.clusterMetadata); err != nil {
+			return fmt.Errorf("invalid --cluster-metadata: %w", err)
+		}
+	}
+	if o.githubStatusTokenPath != "" {
+		if o.commitStatus, err = o.commitStatusReporter(); err != nil {
+			return fmt.Errorf("could not set up commit status reporting: %w", err)
+		}
+	}
+	if o.registryStorageBudget != "" {
+		budget, err := resource.ParseQuantity(o.registryStorageBudget)
+		if err != nil || budget.Sign() <= 0 {
+			return fmt.Errorf("--registry-storage-budget must be a positive quantity, got %q", o.registryStorageBudget)
+		}
+		o.registryBudgetBytes = budget.Value()
+	} else if o.pruneOverBudget {
+		return errors.New("--prune-over-budget requires --registry-storage-budget")
+	}
+	if o.pruneOverBudget && o.pruneIntermediate {
+		return errors.New("--prune-over-budget and --prune-intermediate-images are mutually exclusive")
+	}
+	if (o.pruneOverBudget || o.pruneIntermediate) && o.namespaceLockMode != namespaceLockWait && o.namespaceLockMode != namespaceLockFail {
+		return fmt.Errorf("pruning pipeline images requires --namespace-lock=%s or --namespace-lock=%s: executions sharing the namespace require the same images, and pruning them while another execution runs breaks it", namespaceLockWait, namespaceLockFail)
+	}
+	if o.buildRegressionTolerance < 0 {
+		return fmt.Errorf("--build-regression-tolerance must not be negative, got %v", o.buildRegressionTolerance)
+	}
+	if o.buildBaselinePath != "" {
+		if o.buildBaseline, err = steps.LoadBuildBaseline(o.buildBaselinePath); err != nil {
+			return err
+		}
+	}
 	if o.uploadSecretPath != "" {
 		gcsSecretName := resolveGCSCredentialsSecret(o.jobSpec)
 		if o.uploadSecret, err = getSecret(gcsSecretName, o.uploadSecretPath); err != nil {
@@ -693,6 +1324,7 @@ func (o *options) Complete() error {
 		if err != nil {
 			return fmt.Errorf("could not load Hive kube config from path %s: %w", o.hiveKubeconfigPath, err)
 		}
+		kubeConfig.UserAgent = userAgent(o.jobSpec)
 		o.hiveKubeconfig = kubeConfig
 	}
 
@@ -702,7 +1334,42 @@ func (o *options) Complete() error {
 
 	handleTargetAdditionalSuffix(o)
 
-	return overrideTestStepDependencyParams(o)
+	if err := overrideTestStepDependencyParams(o); err != nil {
+		return err
+	}
+
+	if len(o.allowedImageRegistries.values) != 0 || len(o.deniedImageRegistries.values) != 0 {
+		policy := imagePolicy{allowed: o.allowedImageRegistries.values, denied: o.deniedImageRegistries.values}
+		if err := policy.validate(o.configSpec, o.templates, o.templateParameters, o.configInRepo); err != nil {
+			return results.ForReason("image_policy").WithError(err).Errorf("configuration uses images that are not allowed: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateObjectsNamespaced ensures the additional resources, and with
+// --forbid-cluster-scoped-objects the objects of the templates, are
+// namespaced, asking the cluster for the scope of their kinds.
+func (o *options) validateObjectsNamespaced() error {
+	httpClient, err := rest.HTTPClientFor(o.clusterConfig)
+	if err != nil {
+		return fmt.Errorf("could not create the HTTP client for the cluster: %w", err)
+	}
+	mapper, err := apiutil.NewDynamicRESTMapper(o.clusterConfig, httpClient)
+	if err != nil {
+		return fmt.Errorf("could not create the REST mapper for the cluster: %w", err)
+	}
+	for _, object := range o.additionalResources {
+		if err := scopeclient.ValidateObjectNamespaced(mapper, object.GroupVersionKind(), object.GetName(), nil); err != nil {
+			return fmt.Errorf("additional resource: %w", err)
+		}
+	}
+	if o.forbidClusterScopedObjects {
+		if err := validation.ValidateTemplateObjectsNamespaced(mapper, o.templates, o.configSpec.AllowedClusterScopedKinds); err != nil {
+			return fmt.Errorf("templates create cluster-scoped objects: %w", err)
+		}
+	}
+	return nil
 }
 
 func parseKeyValParams(input []string, paramType string) (map[string]string, error) {
@@ -724,6 +1391,17 @@ func parseKeyValParams(input []string, paramType string) (map[string]string, err
 	return params, nil
 }
 
+// jobTarget is the target recorded in the job spec: the first target naming a
+// single step, as patterns and exclusions are not valid label values.
+func jobTarget(targets []string) string {
+	for _, target := range targets {
+		if api.IsPlainTarget(target) {
+			return target
+		}
+	}
+	return "all"
+}
+
 func handleTargetAdditionalSuffix(o *options) {
 	if o.targetAdditionalSuffix == "" {
 		return
@@ -734,7 +1412,7 @@ func handleTargetAdditionalSuffix(o *options) {
 			if test.As == target {
 				targetWithSuffix := fmt.Sprintf("%s-%s", test.As, o.targetAdditionalSuffix)
 				o.configSpec.Tests[i].As = targetWithSuffix
-				if j == 0 { //only set if it is the first target
+				if o.jobSpec.Target == target { //only set if it is the target of the job
 					o.jobSpec.Target = targetWithSuffix
 				}
 				o.targets.values[j] = targetWithSuffix
@@ -827,9 +1505,16 @@ func excludeContextCancelledErrors(errs []error) []error {
 	return ret
 }
 
+// Report reports the outcome of the execution. Cancellations are left out of
+// the JUnit output, as the steps they interrupted did not fail, but an
+// interrupted execution never reports success.
 func (o *options) Report(errs ...error) {
-	if len(errs) > 0 {
-		o.writeFailingJUnit(errs)
+	if junitErrs := excludeContextCancelledErrors(errs); len(junitErrs) > 0 {
+		o.writeFailingJUnit(junitErrs)
+	}
+
+	if o.commitStatus != nil {
+		o.commitStatus.Finished(errs, o.interrupted)
 	}
 
 	reporter, loadErr := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost)
@@ -838,17 +1523,54 @@ func (o *options) Report(errs ...error) {
 		return
 	}
 
-	errorToReport := excludeContextCancelledErrors(errs)
-	for _, err := range errorToReport {
+	for _, err := range errs {
 		reporter.Report(err)
 	}
 
-	if len(errorToReport) == 0 {
+	if len(errs) == 0 {
+		if o.interrupted {
+			reporter.Report(results.ForReason("interrupted").ForError(errors.New("execution was interrupted")))
+			return
+		}
 		reporter.Report(nil)
 	}
 }
 
-func (o *options) Run() []error {
+// completeUploadOptions determines where to upload the artifacts to and
+// validates the options uploading them. Errors name the flag that was passed.
+func (o *options) completeUploadOptions() error {
+	uploadFlag := "--upload-artifacts-to"
+	if o.uploadArtifactsRoot != "" {
+		if o.uploadArtifactsTo != "" {
+			return errors.New("--upload-artifacts and --upload-artifacts-to are mutually exclusive")
+		}
+		jobPath, err := upload.JobPath(&o.jobSpec.JobSpec)
+		if err != nil {
+			return fmt.Errorf("could not determine where to upload artifacts: %w", err)
+		}
+		o.uploadArtifactsTo = strings.TrimSuffix(o.uploadArtifactsRoot, "/") + "/" + jobPath
+		uploadFlag = "--upload-artifacts"
+	}
+	if o.uploadArtifactsTo == "" {
+		return nil
+	}
+	if strings.HasPrefix(o.uploadArtifactsTo, "s3://") {
+		if o.s3UploadSecretPath == "" {
+			return errors.New("uploading artifacts to S3 requires --s3-upload-secret")
+		}
+	} else if o.uploadSecretPath == "" {
+		return errors.New("uploading artifacts to GCS requires --gcs-upload-secret")
+	}
+	if _, set := api.Artifacts(); !set {
+		return fmt.Errorf("%s requires $ARTIFACTS to be set", uploadFlag)
+	}
+	if o.uploadConcurrency < 1 {
+		return errors.New("--upload-concurrency must be positive")
+	}
+	return nil
+}
+
+func (o *options) Run() (runErrs []error) {
 	start := time.Now()
 	defer func() {
 		logrus.Infof("Ran for %s", time.Since(start).Truncate(time.Second))
@@ -858,12 +1580,22 @@ func (o *options) Run() []error {
 		logrus.Infof("error: Process interrupted with signal %s, cancelling execution...", s)
 		cancel()
 	}
+	defer func() {
+		o.interrupted = ctx.Err() != nil
+	}()
 	var leaseClient *lease.Client
 	if o.leaseServer != "" && o.leaseServerCredentialsFile != "" {
 		leaseClient = &o.leaseClient
 	}
 
 	o.resolveConsoleHost()
+	o.resolveClusterMetadata()
+
+	if o.metricsAddr != "" {
+		if err := metrics.Serve(o.metricsAddr); err != nil {
+			logrus.WithError(err).Warnf("Could not serve metrics on %s.", o.metricsAddr)
+		}
+	}
 
 	client, err := coreclientset.NewForConfig(o.clusterConfig)
 	if err != nil {
@@ -876,7 +1608,42 @@ func (o *options) Run() []error {
 	}
 
 	// load the graph from the configuration
-	buildSteps, postSteps, err := defaults.FromConfig(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote, o.clusterConfig, o.podPendingTimeout, leaseClient, o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.censor, o.hiveKubeconfig, o.consoleHost, o.nodeName, nodeArchitectures, o.targetAdditionalSuffix)
+	params := api.NewDeferredParameters(nil)
+	gatePolicy := steps.GatePolicy{
+		Namespaces:    sets.New(o.gateNamespaces.values...),
+		ClusterScoped: o.gateClusterScoped,
+		HTTPHosts:     sets.New(o.gateHTTPHosts.values...),
+	}
+	buildSteps, postSteps, err := defaults.FromConfig(ctx, defaults.Options{
+		Config:                 o.configSpec,
+		GraphConfig:            &o.graphConfig,
+		JobSpec:                o.jobSpec,
+		Templates:              o.templates,
+		TemplateParameters:     o.templateParameters,
+		ParamFile:              o.writeParams,
+		ParamFormat:            steps.ParametersFormat(o.writeParamsFormat),
+		Promote:                o.promote,
+		ClusterConfig:          o.clusterConfig,
+		PodPendingTimeout:      o.podPendingTimeout,
+		LeaseClient:            leaseClient,
+		RequiredTargets:        o.targets.values,
+		CloneAuthConfig:        o.cloneAuthConfig,
+		PullSecret:             o.pullSecret,
+		PushSecret:             o.pushSecret,
+		PushImages:             o.pushImagesOptions(),
+		Policy:                 o.policy,
+		ForbidClusterScoped:    o.forbidClusterScopedObjects,
+		Censor:                 o.censor,
+		HiveKubeconfig:         o.hiveKubeconfig,
+		GatePolicy:             gatePolicy,
+		ConsoleHost:            o.consoleHost,
+		NodeName:               o.nodeName,
+		NodeArchitectures:      nodeArchitectures,
+		TargetAdditionalSuffix: o.targetAdditionalSuffix,
+		RegistryOverride:       o.registryOverride,
+		ShardTimingsDir:        o.shardTimingsDir,
+		Params:                 params,
+	})
 	if err != nil {
 		return []error{results.ForReason("defaulting_config").WithError(err).Errorf("failed to generate steps from config: %v", err)}
 	}
@@ -891,6 +1658,20 @@ func (o *options) Run() []error {
 	if err := o.writeMetadataJSON(); err != nil {
 		return []error{fmt.Errorf("unable to write metadata.json for build: %w", err)}
 	}
+	if err := linkFinalAttempt(o.jobSpec); err != nil {
+		logrus.WithError(err).Warn("Could not link the artifacts of this attempt.")
+	}
+	var checkpoint *steps.Checkpoint
+	if o.checkpointPath != "" {
+		if checkpoint, err = steps.LoadCheckpoint(o.checkpointPath, o.inputHash); err != nil {
+			return []error{fmt.Errorf("could not load checkpoint: %w", err)}
+		}
+		checkpointClient, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+		if err != nil {
+			return []error{fmt.Errorf("could not get client to verify the checkpoint: %w", err)}
+		}
+		buildSteps = checkpoint.SkipCompleted(buildSteps, checkpointClient, o.jobSpec)
+	}
 	// convert the full graph into the subset we must run
 	nodes, err := api.BuildPartialGraph(buildSteps, o.targets.values)
 	if err != nil {
@@ -901,30 +1682,71 @@ func (o *options) Run() []error {
 		return append([]error{results.ForReason("building_graph").ForError(errors.New("could not sort nodes"))}, errs...)
 	}
 	logrus.Infof("Running %s", strings.Join(nodeNames(stepList), ", "))
+	if o.inputsOutput != "" {
+		if err := o.writeInputs(stepList, postSteps); err != nil {
+			return []error{fmt.Errorf("could not write step inputs: %w", err)}
+		}
+	}
+	if err := o.saveParameterDocumentation(stepList, postSteps); err != nil {
+		logrus.WithError(err).Warn("Could not save the documentation of the parameters.")
+	}
 	if o.printGraph {
-		if err := printDigraph(os.Stdout, stepList); err != nil {
+		if err := printGraph(os.Stdout, o.graphFormat, stepList, postSteps); err != nil {
 			return []error{fmt.Errorf("could not print graph: %w", err)}
 		}
 		return nil
 	}
+	if o.dryRun {
+		var dryRun bytes.Buffer
+		if err := printDryRun(ctx, io.MultiWriter(os.Stdout, &dryRun), steps.AdditionalResourcesDryRun(o.additionalResources), stepList, postSteps); err != nil {
+			return []error{fmt.Errorf("could not describe the steps: %w", err)}
+		}
+		_ = api.SaveArtifact(o.censor, api.CIOperatorDryRunFilename, dryRun.Bytes())
+		return nil
+	}
+	// a dry run or printing the graph does not use ci-operator, so only the
+	// jobs that go on to run the steps are reported
+	o.reportUsage(ctx)
 	graph, errs := calculateGraph(stepList)
 	if errs != nil {
 		return errs
 	}
+	required := requiredCapabilities(o.graphConfig.Steps, stepList, len(o.templates) > 0, !o.skipNamespaceInit)
+	if err := o.checkClusterCapabilities(ctx, required); err != nil {
+		return []error{results.ForReason("checking_capabilities").WithError(err).Errorf("pre-flight checks failed: %v", err)}
+	}
 	defer func() {
 		serializedGraph, err := json.Marshal(graph)
 		if err != nil {
 			logrus.WithError(err).Error("Failed to marshal graph")
 			return
 		}
-
-		_ = api.SaveArtifact(o.censor, api.CIOperatorStepGraphJSONFilename, serializedGraph)
+
+		_ = api.SaveArtifact(o.censor, api.CIOperatorStepGraphJSONFilename, serializedGraph)
+		if err := o.saveRunReport(*graph, stepList, postSteps, params, runErrs); err != nil {
+			logrus.WithError(err).Warn("Could not save the report of the run.")
+		}
+	}()
+	// initialize the namespace if necessary and create any resources that must
+	// exist prior to execution, or verify that they exist in a namespace that
+	// is managed externally
+	initializeNamespace := o.initializeNamespace
+	if o.skipNamespaceInit {
+		initializeNamespace = func(ctx context.Context) error {
+			return o.verifyExistingNamespace(ctx, required)
+		}
+	}
+	namespaceStart := time.Now()
+	// the lock may be taken before the initialization fails
+	defer func() {
+		if o.namespaceLock != nil {
+			o.namespaceLock.release(context.Background())
+		}
 	}()
-	// initialize the namespace if necessary and create any resources that must
-	// exist prior to execution
-	if err := o.initializeNamespace(); err != nil {
+	if err := initializeNamespace(ctx); err != nil {
 		return []error{results.ForReason("initializing_namespace").WithError(err).Errorf("could not initialize namespace: %v", err)}
 	}
+	metrics.NamespaceInitialization.Observe(time.Since(namespaceStart).Seconds())
 
 	return interrupt.New(handler, o.saveNamespaceArtifacts).Run(func() []error {
 		if leaseClient != nil {
@@ -943,34 +1765,114 @@ func (o *options) Run() []error {
 		}
 		runtimeObject := &coreapi.ObjectReference{Namespace: o.namespace}
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobStarted", eventJobDescription(o.jobSpec, o.namespace))
+		statusClient, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+		if err != nil {
+			return []error{fmt.Errorf("could not get client for status reporting: %w", err)}
+		}
+		statusReporter := steps.NewStatusReporter(statusClient, o.jobSpec, stepList)
+		if o.fromNamespace != "" {
+			recorded, err := verifyRecordedSteps(ctx, statusClient, o.namespace, o.jobSpec, stepList)
+			if err != nil {
+				return []error{results.ForReason("verifying_namespace").WithError(err).Errorf("cannot promote from namespace %s: %v", o.namespace, err)}
+			}
+			statusReporter.Resume(*recorded)
+		} else {
+			statusReporter.Initialize(ctx)
+		}
+		registryUsage := o.registryUsage(statusClient, stepList, postSteps)
 		// execute the graph
-		suites, graphDetails, errs := steps.Run(ctx, nodes)
+		graphCtx := ctx
+		if o.timeout > 0 {
+			var cancelGraph context.CancelFunc
+			graphCtx, cancelGraph = context.WithTimeout(ctx, o.timeout)
+			defer cancelGraph()
+		}
+		observers := []steps.StepObserver{statusReporter, registryUsage, steps.NewStepMetrics(), steps.NewProgressLogger()}
+		if !o.keepRPMRepo {
+			if o.namespaceLock.held() {
+				observers = append(observers, steps.NewRPMRepoReaper(statusClient, o.jobSpec, stepList, postSteps))
+			} else {
+				logrus.Infof("Not deleting the RPM repository server, as namespace %s is not locked by this execution.", o.namespace)
+			}
+		}
+		if o.commitStatus != nil {
+			o.commitStatus.Started(o.commitStatusURL())
+			observers = append(observers, o.commitStatus)
+		}
+		if checkpoint != nil {
+			observers = append(observers, checkpoint)
+		}
+		var suites *junit.TestSuites
+		var graphDetails []api.CIOperatorStepDetails
+		var errs []error
+		if o.promoteOnly {
+			logrus.Info("Skipping all steps but the promotion, which uses the images already in the pipeline image stream.")
+		} else {
+			suites, graphDetails, errs = steps.RunWithConcurrency(graphCtx, nodes, o.maxConcurrency, observers...)
+		}
+		if ctx.Err() == nil && errors.Is(graphCtx.Err(), context.DeadlineExceeded) {
+			logrus.Warnf("The execution timed out after %s, gathering the artifacts of the namespace.", o.timeout)
+			o.saveNamespaceArtifacts()
+		}
+		if suites != nil && len(errs) > 0 {
+			for _, suite := range suites.Suites {
+				suite.Properties = append(suite.Properties, failureCategoryProperty(errs))
+			}
+		}
 		if err := o.writeJUnit(suites, "operator"); err != nil {
 			logrus.WithError(err).Warn("Unable to write JUnit result.")
 		}
+		if ctx.Err() != nil {
+			// the partial results are written, the steps still running can
+			// be torn down before we exit
+			o.teardown(statusClient)
+		}
 		graph.MergeFrom(graphDetails...)
+		o.writeRunResults(ctx, statusClient, start, registryUsage.Report(), errs)
 		// Rewrite the Metadata JSON to catch custom metadata if it has been generated by the job
 		if err := o.writeMetadataJSON(); err != nil {
 			logrus.WithError(err).Warn("Unable to update metadata.json for build")
 		}
-		if len(errs) > 0 {
+		failed := len(errs) > 0
+		var wrapped []error
+		if failed {
 			eventRecorder.Event(runtimeObject, coreapi.EventTypeWarning, "CiJobFailed", eventJobDescription(o.jobSpec, o.namespace))
-			var wrapped []error
 			for _, err := range errs {
 				wrapped = append(wrapped, &errWroteJUnit{wrapped: results.ForReason("executing_graph").WithError(err).Errorf("could not run steps: %v", err)})
 			}
-			return wrapped
 		}
 
+		// post-steps run depending on the outcome of the graph, unless the
+		// execution was interrupted
 		for _, step := range postSteps {
+			if ctx.Err() != nil {
+				break
+			}
+			if !steps.PostStepShouldRun(step, failed) {
+				logrus.Infof("Skipping post step %s, which does not run for this outcome.", step.Name())
+				continue
+			}
+			if planner, ok := step.(releasesteps.PromotionPlanner); ok && o.promoteDryRun {
+				plan, err := planner.PlanPromotion(ctx)
+				if err != nil {
+					return append(wrapped, results.ForReason("planning_promotion").WithError(err).Errorf("could not plan post step %s: %v", step.Name(), err))
+				}
+				logrus.Infof("Post step %s would:\n%s", step.Name(), strings.Join(plan, "\n"))
+				continue
+			}
+			statusReporter.StepStarted(step)
 			details, err := runStep(ctx, step)
+			statusReporter.StepFinished(step, err)
 			graph.MergeFrom(details)
 			if err != nil {
 				eventRecorder.Event(runtimeObject, coreapi.EventTypeWarning, "PostStepFailed",
 					fmt.Sprintf("Post step %s failed while %s", step.Name(), eventJobDescription(o.jobSpec, o.namespace)))
-				return []error{results.ForReason("executing_post").WithError(err).Errorf("could not run post step %s: %v", step.Name(), err)}
+				return append(wrapped, results.ForReason("executing_post").WithError(err).Errorf("could not run post step %s: %v", step.Name(), err))
 			}
 		}
+		if failed {
+			return wrapped
+		}
 
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobSucceeded", eventJobDescription(o.jobSpec, o.namespace))
 		return nil
@@ -1006,6 +1908,38 @@ func runStep(ctx context.Context, step api.Step) (api.CIOperatorStepDetails, err
 	}, err
 }
 
+// commitStatusReporter creates the reporter of commit statuses with the
+// token from --github-status-token.
+func (o *options) commitStatusReporter() (*steps.CommitStatusReporter, error) {
+	if _, err := secrets.ReadFromFile(o.githubStatusTokenPath, o.censor); err != nil {
+		return nil, fmt.Errorf("could not read the GitHub token: %w", err)
+	}
+	getToken := func() []byte {
+		token, err := secrets.ReadFromFile(o.githubStatusTokenPath, o.censor)
+		if err != nil {
+			logrus.WithError(err).Warn("Could not read the GitHub token.")
+		}
+		return []byte(token)
+	}
+	censor := func(content []byte) []byte {
+		o.censor.Censor(&content)
+		return content
+	}
+	client, err := github.NewClient(getToken, censor, github.DefaultGraphQLEndpoint, o.githubEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	return steps.NewCommitStatusReporter(client, o.jobSpec.Refs, o.githubStatusContext, o.targets.values)
+}
+
+// commitStatusURL is the URL commit statuses link to.
+func (o *options) commitStatusURL() string {
+	if o.githubStatusURL != "" || o.consoleHost == "" {
+		return o.githubStatusURL
+	}
+	return fmt.Sprintf("https://%s/k8s/cluster/projects/%s", o.consoleHost, o.namespace)
+}
+
 func (o *options) resolveConsoleHost() {
 	if client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{}); err != nil {
 		logrus.WithError(err).Warn("Could not create client for accessing Routes. Will not resolve console URL.")
@@ -1019,6 +1953,25 @@ func (o *options) resolveConsoleHost() {
 	}
 }
 
+// resolveClusterMetadata detects the cloud the build cluster runs on, unless
+// --cluster-metadata gives all of it.
+func (o *options) resolveClusterMetadata() {
+	metadata := o.clusterMetadataOverrides
+	if metadata.Platform == "" || metadata.Region == "" {
+		if client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{}); err != nil {
+			logrus.WithError(err).Warn("Could not create client for accessing the Infrastructure. Will not detect the cloud of the cluster.")
+		} else if detected, err := api.ResolveClusterMetadata(context.TODO(), client); err != nil {
+			logrus.WithError(err).Warn("Could not detect the cloud of the cluster.")
+		} else {
+			metadata = detected.Merge(o.clusterMetadataOverrides)
+		}
+	}
+	if metadata.Platform != "" {
+		logrus.Debugf("Running on platform %s in region %q.", metadata.Platform, metadata.Region)
+	}
+	o.jobSpec.ClusterMetadata = metadata
+}
+
 func (o *options) resolveInputs(steps []api.Step) error {
 	var inputs api.InputDefinition
 	for _, step := range steps {
@@ -1068,16 +2021,24 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	}
 
 	sort.Strings(inputs)
-	o.inputHash = inputHash(inputs)
+	o.inputHash = inputHash(inputs, o.inputHashLength)
 
 	// input hash is unique for a given job definition and input refs
 	if len(o.namespace) == 0 {
-		o.namespace = "ci-op-{id}"
+		o.namespace = o.namespacePrefix + "{id}"
 	}
 	o.namespace = strings.Replace(o.namespace, "{id}", o.inputHash, -1)
+	if err := validateNamespaceName(o.namespace, servesRPMs(o.configSpec)); err != nil {
+		return err
+	}
+	if err := validateDedicatedNamespaceNames(o.namespace, o.configSpec); err != nil {
+		return err
+	}
+	o.jobSpec.SetInputHash(o.inputHash)
 	// TODO: instead of mutating this here, we should pass the parts of graph execution that are resolved
 	// after the graph is created but before it is run down into the run step.
 	o.jobSpec.SetNamespace(o.namespace)
+	jobLogFields.set("namespace", o.namespace)
 
 	// If we can resolve the field, use it. If not, don't.
 	if o.consoleHost != "" {
@@ -1089,59 +2050,59 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	return nil
 }
 
-func (o *options) initializeNamespace() error {
+func (o *options) initializeNamespace(ctx context.Context) error {
 	// We have to keep the project client because it return a project for a projectCreationRequest, ctrlruntimeclient can not do dark magic like that
 	projectGetter, err := projectclientset.NewForConfig(o.clusterConfig)
 	if err != nil {
 		return fmt.Errorf("could not get project client for cluster config: %w", err)
 	}
-	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	watchClient, err := ctrlruntimeclient.NewWithWatch(o.clusterConfig, ctrlruntimeclient.Options{})
 	if err != nil {
 		return fmt.Errorf("failed to construct client: %w", err)
 	}
-	client = ctrlruntimeclient.NewNamespacedClient(client, o.namespace)
-	ctx := context.Background()
+	client := ctrlruntimeclient.NewNamespacedClient(policyclient.Wrap(watchClient, o.policy), o.namespace)
+	o.retrier = util.NewRetrier()
 
 	logrus.Debugf("Creating namespace %s", o.namespace)
-	authTimeout := 15 * time.Second
-	initBeginning := time.Now()
 	for {
-		project, err := projectGetter.ProjectV1().ProjectRequests().Create(context.TODO(), &projectapi.ProjectRequest{
-			ObjectMeta: meta.ObjectMeta{
-				Name:   o.namespace,
-				Labels: map[string]string{api.DPTPRequesterLabel: "ci-operator"},
-			},
-			DisplayName: fmt.Sprintf("%s - %s", o.namespace, o.jobSpec.Job),
-			Description: jobDescription(o.jobSpec),
-		}, meta.CreateOptions{})
-		if err != nil && !kerrors.IsAlreadyExists(err) {
-			return fmt.Errorf("could not set up namespace for test: %w", err)
-		}
-		if err != nil {
-			project, err = projectGetter.ProjectV1().Projects().Get(context.TODO(), o.namespace, meta.GetOptions{})
+		var project *projectapi.Project
+		if err := o.retrier.Retry(ctx, "create namespace", namespaceBackoff, isNamespaceNotReady, func(ctx context.Context) error {
+			var err error
+			project, err = projectGetter.ProjectV1().ProjectRequests().Create(ctx, &projectapi.ProjectRequest{
+				ObjectMeta: meta.ObjectMeta{
+					Name:   o.namespace,
+					Labels: map[string]string{api.DPTPRequesterLabel: "ci-operator"},
+				},
+				DisplayName: fmt.Sprintf("%s - %s", o.namespace, o.jobSpec.Job),
+				Description: jobDescription(o.jobSpec),
+			}, meta.CreateOptions{})
+			if err != nil && !kerrors.IsAlreadyExists(err) {
+				return err
+			}
 			if err != nil {
-				if kerrors.IsNotFound(err) {
-					continue
+				project, err = projectGetter.ProjectV1().Projects().Get(ctx, o.namespace, meta.GetOptions{})
+				// wait for the namespace to show up and for the auth caches to catch up
+				if kerrors.IsNotFound(err) || kerrors.IsForbidden(err) {
+					return fmt.Errorf("%w: %v", errNamespaceNotReady, err)
 				}
-				// wait a few seconds for auth caches to catch up
-				if kerrors.IsForbidden(err) && time.Since(initBeginning) < authTimeout {
-					time.Sleep(time.Second)
-					continue
-				}
-				return fmt.Errorf("failed to wait for authentication cache to warm up after %s: %w", authTimeout, err)
 			}
+			return err
+		}); err != nil {
+			return fmt.Errorf("could not set up namespace for test: %w", err)
 		}
-		if project.Status.Phase == coreapi.NamespaceTerminating {
-			logrus.Info("Waiting for namespace to finish terminating before creating another")
-			time.Sleep(3 * time.Second)
-			continue
+		if project.Status.Phase != coreapi.NamespaceTerminating {
+			break
+		}
+		logrus.Info("Waiting for namespace to finish terminating before creating another")
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("could not set up namespace for test: %w", ctx.Err())
+		case <-time.After(namespaceTerminationInterval):
 		}
-		break
 	}
 
 	ssarStart := time.Now()
-	var selfSubjectAccessReviewSucceeded bool
-	for i := 0; i < 30; i++ {
+	err = o.retrier.Retry(ctx, "wait for namespace RBAC", namespaceBackoff, func(error) bool { return true }, func(ctx context.Context) error {
 		sar := &authapi.SelfSubjectAccessReview{Spec: authapi.SelfSubjectAccessReviewSpec{ResourceAttributes: &authapi.ResourceAttributes{
 			Namespace: o.namespace,
 			Verb:      "create",
@@ -1149,19 +2110,26 @@ func (o *options) initializeNamespace() error {
 		}}}
 		if err := client.Create(ctx, sar); err != nil {
 			logrus.WithError(err).Warn("Failed to create SelfSubjectAccessReview when checking to see if the namespace was initialized.")
-			continue
+			return err
 		}
-		if sar.Status.Allowed {
-			selfSubjectAccessReviewSucceeded = true
-			break
+		if !sar.Status.Allowed {
+			return fmt.Errorf("%w: RBAC is not initialized", errNamespaceNotReady)
 		}
-		logrus.Debugf("[%d/30] RBAC in namespace not yet ready, sleeping for a second...", i)
-		time.Sleep(time.Second)
-	}
+		return nil
+	})
 	logrus.Debugf("Spent %v waiting for RBAC to initialize in the new namespace.", time.Since(ssarStart))
-	if !selfSubjectAccessReviewSucceeded {
+	if err != nil {
 		logrus.Error("Timed out waiting for RBAC to initialize in the test namespace.")
-		return errors.New("timed out waiting for RBAC")
+		return fmt.Errorf("timed out waiting for RBAC: %w", err)
+	}
+
+	// Executions hashing to the same namespace race on the resources created
+	// below, the pipeline imagestream and the secrets.
+	if o.namespaceLockMode != namespaceLockNone {
+		o.namespaceLock = newNamespaceLock(client, o.namespace, namespaceLockIdentity(o.jobSpec.Job, o.jobSpec.BuildID))
+		if err := o.namespaceLock.acquire(ctx, o.namespaceLockMode, namespaceLockPollInterval); err != nil {
+			return err
+		}
 	}
 
 	// Annotate the namespace for cleanup by external tooling (ci-ns-ttl-controller)
@@ -1189,7 +2157,23 @@ func (o *options) initializeNamespace() error {
 	// if the namespace will be reused.
 	annotationUpdates[nsttl.AnnotationNamespaceLastActive] = time.Now().Format(time.RFC3339)
 
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	if o.configDigest != "" {
+		annotationUpdates[configDigestAnnotation] = o.configDigest
+	}
+	if o.untrusted {
+		annotationUpdates[untrustedLabel] = "true"
+	}
+	if o.usageReportAnnotations {
+		if report, err := o.usageReport(); err != nil {
+			logrus.WithError(err).Warn("Could not summarize the usage of ci-operator.")
+		} else {
+			for key, value := range report.annotations() {
+				annotationUpdates[key] = value
+			}
+		}
+	}
+
+	if err := o.retrier.Retry(ctx, "update namespace", transientBackoff, isConflictOrTransientError, func(ctx context.Context) error {
 		ns := &coreapi.Namespace{}
 		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: o.namespace}, ns); err != nil {
 			return err
@@ -1199,6 +2183,12 @@ func (o *options) initializeNamespace() error {
 			ns.Labels = map[string]string{}
 		}
 		ns.Labels[api.AutoScalePodsLabel] = "true"
+		for key, value := range steps.StandardLabels(o.jobSpec) {
+			ns.Labels[key] = value
+		}
+		if o.untrusted {
+			ns.Labels[untrustedLabel] = "true"
+		}
 
 		if ns.Annotations == nil {
 			ns.Annotations = make(map[string]string)
@@ -1219,7 +2209,7 @@ func (o *options) initializeNamespace() error {
 		}
 
 		updateErr := client.Update(ctx, ns)
-		if kerrors.IsForbidden(updateErr) {
+		if kerrors.IsForbidden(updateErr) && !o.untrusted {
 			logrus.WithError(err).Warn("Could not edit namespace because you do not have permission to update the namespace.")
 			return nil
 		}
@@ -1229,45 +2219,42 @@ func (o *options) initializeNamespace() error {
 	}
 
 	pullStart := time.Now()
-	var imagePullSecretsMinted bool
-	for i := 0; i < 119; i++ {
-		imagePullSecretsMinted = true
-		serviceAccounts := map[string]*coreapi.ServiceAccount{
-			"builder": {},
-			"default": {},
-		}
-		for name := range serviceAccounts {
-			if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: name}, serviceAccounts[name]); err != nil && !kerrors.IsNotFound(err) {
+	err = o.retrier.Retry(ctx, "wait for image pull secrets", namespaceBackoff, isNamespaceNotReady, func(ctx context.Context) error {
+		for _, name := range []string{"builder", "default"} {
+			serviceAccount := &coreapi.ServiceAccount{}
+			if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: name}, serviceAccount); err != nil && !kerrors.IsNotFound(err) {
 				return fmt.Errorf("failed to fetch service account %s: %w", name, err)
 			}
-			imagePullSecretsMinted = imagePullSecretsMinted && len(serviceAccounts[name].ImagePullSecrets) > 0
-		}
-		if imagePullSecretsMinted {
-			break
+			if len(serviceAccount.ImagePullSecrets) == 0 {
+				return fmt.Errorf("%w: image pull secrets of service account %s are not minted", errNamespaceNotReady, name)
+			}
 		}
-		logrus.Debugf("[%d/120] Image pull secrets in namespace not yet ready, sleeping for a second...", i)
-		time.Sleep(time.Second)
-	}
+		return nil
+	})
 	logrus.Debugf("Spent %v waiting for image pull secrets to initialize in the new namespace.", time.Since(pullStart))
-	if !imagePullSecretsMinted {
+	if err != nil {
 		logrus.Error("Timed out waiting for image pull secrets in the test namespace.")
-		return errors.New("timed out waiting for image pull secrets")
+		return fmt.Errorf("timed out waiting for image pull secrets: %w", err)
+	}
+
+	if err := o.provisionPullSecret(ctx, client); err != nil {
+		return err
 	}
 
 	if o.givePrAuthorAccessToNamespace && len(o.authors) > 0 {
 		roleBinding := generateAuthorAccessRoleBinding(o.namespace, o.authors)
 		// Generate rolebinding for all the PR Authors.
 		logrus.WithField("authors", o.authors).Debugf("Creating ci-op-author-access rolebinding in namespace %s", o.namespace)
-		if err := client.Create(ctx, roleBinding); err != nil && !kerrors.IsAlreadyExists(err) {
+		if err := o.createWithRetries(ctx, client, "create rolebinding", roleBinding); err != nil {
 			return fmt.Errorf("could not create role binding for: %w", err)
 		}
 
 	}
 
-	for _, secret := range []*coreapi.Secret{o.pullSecret, o.pushSecret, o.uploadSecret} {
+	for _, secret := range []*coreapi.Secret{o.pullSecret, o.pushSecret, o.pushImagesSecret, o.uploadSecret} {
 		if secret != nil {
 			secret.Immutable = utilpointer.Bool(true)
-			if err := client.Create(ctx, secret); err != nil && !kerrors.IsAlreadyExists(err) {
+			if err := o.createWithRetries(ctx, client, "create secret", secret); err != nil {
 				return fmt.Errorf("couldn't create secret %s: %w", secret.Name, err)
 			}
 		}
@@ -1311,13 +2298,18 @@ func (o *options) initializeNamespace() error {
 			LookupPolicy: imageapi.ImageLookupPolicy{Local: true},
 		},
 	}
-	if err := client.Create(ctx, is); err != nil {
-		if !kerrors.IsAlreadyExists(err) {
-			return fmt.Errorf("could not set up pipeline imagestream for test: %w", err)
-		}
-		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: api.PipelineImageStream}, is); err != nil {
-			return fmt.Errorf("failed to get pipeline imagestream: %w", err)
+	if err := o.retrier.Retry(ctx, "create imagestream", transientBackoff, util.IsTransientError, func(ctx context.Context) error {
+		if err := client.Create(ctx, is); err != nil {
+			if !kerrors.IsAlreadyExists(err) {
+				return fmt.Errorf("could not set up pipeline imagestream for test: %w", err)
+			}
+			if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: api.PipelineImageStream}, is); err != nil {
+				return fmt.Errorf("failed to get pipeline imagestream: %w", err)
+			}
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
 	o.jobSpec.SetOwner(&meta.OwnerReference{
 		APIVersion: "image.openshift.io/v1",
@@ -1326,15 +2318,23 @@ func (o *options) initializeNamespace() error {
 		UID:        is.UID,
 	})
 
+	if err := o.resolveCloneCredentials(ctx, client); err != nil {
+		return err
+	}
 	if o.cloneAuthConfig != nil && o.cloneAuthConfig.Secret != nil {
 		o.cloneAuthConfig.Secret.Immutable = utilpointer.Bool(true)
-		if err := client.Create(ctx, o.cloneAuthConfig.Secret); err != nil && !kerrors.IsAlreadyExists(err) {
+		if err := o.createWithRetries(ctx, client, "create secret", o.cloneAuthConfig.Secret); err != nil {
 			return fmt.Errorf("couldn't create secret %s for %s authentication: %w", o.cloneAuthConfig.Secret.Name, o.cloneAuthConfig.Type, err)
 		}
 	}
 
 	for _, secret := range o.secrets {
-		created, err := util.UpsertImmutableSecret(ctx, client, secret)
+		var created bool
+		err := o.retrier.Retry(ctx, "sync secret", transientBackoff, util.IsTransientError, func(ctx context.Context) error {
+			var err error
+			created, err = util.UpsertImmutableSecret(ctx, client, secret)
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("could not update secret %s: %w", secret.Name, err)
 		}
@@ -1344,6 +2344,27 @@ func (o *options) initializeNamespace() error {
 			logrus.Debugf("Updated secret %s", secret.Name)
 		}
 	}
+	for _, configMap := range o.configMaps {
+		var created bool
+		err := o.retrier.Retry(ctx, "sync configmap", transientBackoff, util.IsTransientError, func(ctx context.Context) error {
+			var err error
+			created, err = util.UpsertImmutableConfigMap(ctx, client, configMap)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("could not update ConfigMap %s: %w", configMap.Name, err)
+		}
+		if created {
+			logrus.Debugf("Created ConfigMap %s", configMap.Name)
+		} else {
+			logrus.Debugf("Updated ConfigMap %s", configMap.Name)
+		}
+	}
+	if err := o.retrier.Retry(ctx, "apply additional resources", transientBackoff, util.IsTransientError, func(ctx context.Context) error {
+		return steps.ApplyAdditionalResources(ctx, client, o.namespace, o.jobSpec.Owner(), o.additionalResources)
+	}); err != nil {
+		return fmt.Errorf("could not create additional resources: %w", err)
+	}
 	pdb, mutateFn := pdb(steps.CreatedByCILabel, o.namespace)
 	if _, err := crcontrollerutil.CreateOrUpdate(ctx, client, pdb, mutateFn); err != nil && !kerrors.IsAlreadyExists(err) {
 		return fmt.Errorf("failed to create pdb for label key %s: %w", steps.CreatedByCILabel, err)
@@ -1471,6 +2492,115 @@ func (o *options) writeMetadataJSON() error {
 	return nil
 }
 
+// runResults is the content of results.json, which summarizes the execution
+// for those tuning the configuration.
+type runResults struct {
+	Cache        *steps.CacheStatistics     `json:"cache"`
+	Registry     *steps.RegistryUsageReport `json:"registry,omitempty"`
+	ConfigDigest string                     `json:"config_digest,omitempty"`
+	Retries      []util.RetryStatistics     `json:"retries,omitempty"`
+	Failures     []results.Failure          `json:"failures,omitempty"`
+	Features     features.Gates             `json:"features,omitempty"`
+}
+
+const resultsJSONFile = "results.json"
+
+// writeRunResults reports which pipeline images were reused and which were
+// built by this execution, both in the log and in results.json, together
+// with the registry storage the images took and the classified failures.
+// The durations of the builds and sizes of the images are also written to a
+// report, along with their regressions compared to the baseline.
+func (o *options) writeRunResults(ctx context.Context, client ctrlruntimeclient.Reader, start time.Time, registry *steps.RegistryUsageReport, errs []error) {
+	stats, err := steps.GatherCacheStatistics(ctx, client, o.namespace, start)
+	if err != nil {
+		logrus.WithError(err).Warn("Unable to gather build cache statistics.")
+		return
+	}
+	logrus.Info(stats.Summary())
+	if o.buildBaseline != nil {
+		stats.Regressions = stats.BuildRegressions(o.buildBaseline, o.buildRegressionTolerance)
+		for _, regression := range stats.Regressions {
+			logrus.Warnf("Build regression: %s.", regression)
+		}
+	}
+	if stats.Rebuilt > 0 {
+		if err := api.SaveArtifact(o.censor, steps.BuildPerformanceFilename, []byte(stats.PerformanceReport())); err != nil {
+			logrus.WithError(err).Warnf("Unable to write %s.", steps.BuildPerformanceFilename)
+		}
+	}
+	data, err := json.MarshalIndent(runResults{Cache: stats, Registry: registry, ConfigDigest: o.configDigest, Retries: o.retrier.Statistics(), Failures: results.Failures(errs...), Features: o.featureGates}, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Warn("Unable to marshal build cache statistics.")
+		return
+	}
+	if err := api.SaveArtifact(o.censor, resultsJSONFile, data); err != nil {
+		logrus.WithError(err).Warnf("Unable to write %s.", resultsJSONFile)
+	}
+}
+
+// linkFinalAttempt points the alias for the final attempt at the artifact
+// directory of this attempt, replacing the link to any earlier attempt.
+func linkFinalAttempt(jobSpec *api.JobSpec) error {
+	artifactDir, set := api.Artifacts()
+	if !set || jobSpec.Attempt() == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(artifactDir, jobSpec.AttemptArtifactDir("")), 0777); err != nil {
+		return fmt.Errorf("could not create the artifact directory of the attempt: %w", err)
+	}
+	link := filepath.Join(artifactDir, api.FinalAttemptArtifactDir)
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove the link to the previous attempt: %w", err)
+	}
+	return os.Symlink(jobSpec.AttemptArtifactDir(""), link)
+}
+
+// attemptFor identifies this execution of the job, preferring the build ID
+// Prow assigned to it.
+func attemptFor(jobSpec *api.JobSpec) string {
+	if jobSpec.BuildID != "" {
+		return jobSpec.BuildID
+	}
+	return strconv.FormatInt(time.Now().Unix(), 10)
+}
+
+// userAgent identifies the requests of ci-operator and the job it runs for,
+// so that cluster admins can attribute the load on the API server.
+func userAgent(jobSpec *api.JobSpec) string {
+	agent := fmt.Sprintf("ci-operator/%s", version.Version)
+	if jobSpec != nil && jobSpec.Job != "" {
+		agent += fmt.Sprintf(" (job %s)", jobSpec.Job)
+	}
+	return agent
+}
+
+// registryUsage tracks the storage taken by the pipeline images. The images
+// built from the configuration are never pruned, as they are the output of
+// the job. Nothing is pruned unless this execution holds the namespace lock,
+// as executions sharing the namespace require the same intermediate images.
+func (o *options) registryUsage(client ctrlruntimeclient.Client, stepList api.OrderedStepList, postSteps []api.Step) *steps.RegistryUsage {
+	var keep []api.PipelineImageStreamTagReference
+	for _, image := range o.configSpec.Images {
+		keep = append(keep, image.To)
+	}
+	var all []api.Step
+	for _, node := range stepList {
+		all = append(all, node.Step)
+	}
+	policy := steps.PruneNever
+	switch {
+	case o.pruneIntermediate:
+		policy = steps.PruneUnused
+	case o.pruneOverBudget:
+		policy = steps.PruneOverBudget
+	}
+	if policy != steps.PruneNever && !o.namespaceLock.held() {
+		logrus.Warnf("Not pruning pipeline images, as namespace %s is not locked by this execution.", o.namespace)
+		policy = steps.PruneNever
+	}
+	return steps.NewRegistryUsage(client, o.namespace, o.registryBudgetBytes, policy, keep, append(all, postSteps...))
+}
+
 func (o *options) findCustomMetadataFile(artifactDir string) (customProwMetadataFile string, err error) {
 	// Try to find the custom prow metadata file. We assume that there's only one. If there's more than one,
 	// we'll just use the first one that we find.
@@ -1603,10 +2733,11 @@ func (o *options) writeFailingJUnit(errs []error) {
 	suites := &junit.TestSuites{
 		Suites: []*junit.TestSuite{
 			{
-				Name:      "job",
-				NumTests:  uint(len(errs)),
-				NumFailed: uint(len(errs)),
-				TestCases: testCases,
+				Name:       "job",
+				NumTests:   uint(len(errs)),
+				NumFailed:  uint(len(errs)),
+				Properties: []*junit.TestSuiteProperty{failureCategoryProperty(errs)},
+				TestCases:  testCases,
 			},
 		},
 	}
@@ -1615,6 +2746,12 @@ func (o *options) writeFailingJUnit(errs []error) {
 	}
 }
 
+// failureCategoryProperty records the category of the failure of the job in
+// the JUnit results, so reporting tools can tell failures apart.
+func failureCategoryProperty(errs []error) *junit.TestSuiteProperty {
+	return &junit.TestSuiteProperty{Name: "failure_category", Value: string(results.CategoryFor(errs...))}
+}
+
 func (o *options) writeJUnit(suites *junit.TestSuites, name string) error {
 	if suites == nil {
 		return nil
@@ -1637,8 +2774,9 @@ func (o *options) writeJUnit(suites *junit.TestSuites, name string) error {
 // short display names that are safe for use in kubernetes as resource names.
 var oneWayNameEncoding = base32.NewEncoding("bcdfghijklmnpqrstvwxyz0123456789").WithPadding(base32.NoPadding)
 
-// inputHash returns a string that hashes the unique parts of the input to avoid collisions.
-func inputHash(inputs api.InputDefinition) string {
+// inputHash returns a string of the given length that hashes the unique parts
+// of the input to avoid collisions.
+func inputHash(inputs api.InputDefinition, length int) string {
 	hash := sha256.New()
 
 	// the inputs form a part of the hash
@@ -1652,7 +2790,7 @@ func inputHash(inputs api.InputDefinition) string {
 	// the hash. This increases chances of collision
 	// but we can tolerate it as our input space is
 	// tiny.
-	return oneWayNameEncoding.EncodeToString(hash.Sum(nil)[:5])
+	return oneWayNameEncoding.EncodeToString(hash.Sum(nil))[:length]
 }
 
 // saveNamespaceArtifacts is a best effort attempt to save ci-operator namespace artifacts to disk
@@ -1668,6 +2806,27 @@ func (o *options) saveNamespaceArtifacts() {
 		data, _ = json.MarshalIndent(events, "", "  ")
 		path = filepath.Join(namespaceDir, "events.json")
 		_ = api.SaveArtifact(o.censor, path, data)
+
+		// tests in dedicated namespaces run their pods there
+		var tests []api.TestStepConfiguration
+		if o.configSpec != nil {
+			tests = o.configSpec.Tests
+		}
+		for _, test := range tests {
+			if test.DedicatedNamespace == nil {
+				continue
+			}
+			namespace := o.jobSpec.Dedicated(test.As).Namespace()
+			if _, err := kubeClient.Namespaces().Get(context.TODO(), namespace, meta.GetOptions{}); err != nil {
+				continue
+			}
+			pods, _ := kubeClient.Pods(namespace).List(context.TODO(), meta.ListOptions{})
+			data, _ := json.MarshalIndent(pods, "", "  ")
+			_ = api.SaveArtifact(o.censor, filepath.Join(namespaceDir, test.As, "pods.json"), data)
+			events, _ := kubeClient.Events(namespace).List(context.TODO(), meta.ListOptions{})
+			data, _ = json.MarshalIndent(events, "", "  ")
+			_ = api.SaveArtifact(o.censor, filepath.Join(namespaceDir, test.As, "events.json"), data)
+		}
 	}
 
 	if buildClient, err := buildclientset.NewForConfig(o.clusterConfig); err == nil {
@@ -1840,6 +2999,77 @@ func printDigraph(w io.Writer, steps api.OrderedStepList) error {
 	return nil
 }
 
+// stepInputsOutput is written to --inputs-output before anything is run.
+type stepInputsOutput struct {
+	InputHash string             `json:"input_hash"`
+	Namespace string             `json:"namespace"`
+	Steps     []steps.StepInputs `json:"steps"`
+}
+
+// writeInputs writes the resolved inputs and the links of the steps and post
+// steps, in the order they would run.
+func (o *options) writeInputs(stepList api.OrderedStepList, postSteps []api.Step) error {
+	var all []api.Step
+	for _, node := range stepList {
+		all = append(all, node.Step)
+	}
+	inputs, err := steps.Inputs(append(all, postSteps...))
+	if err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(stepInputsOutput{InputHash: o.inputHash, Namespace: o.namespace, Steps: inputs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal the inputs: %w", err)
+	}
+	return os.WriteFile(o.inputsOutput, raw, 0644)
+}
+
+// saveParameterDocumentation documents the parameters of the job and those
+// provided by the steps and post steps in an artifact.
+func (o *options) saveParameterDocumentation(stepList api.OrderedStepList, postSteps []api.Step) error {
+	var all []api.Step
+	for _, node := range stepList {
+		all = append(all, node.Step)
+	}
+	raw, err := json.MarshalIndent(steps.DocumentParameters(defaults.JobParameters(o.jobSpec), append(all, postSteps...)), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal the parameters: %w", err)
+	}
+	return api.SaveArtifact(o.censor, api.CIOperatorParametersFilename, raw)
+}
+
+// printDryRun prints what the initialization of the namespace, the steps
+// and the post steps would do, in the order they would run.
+func printDryRun(ctx context.Context, w io.Writer, initialization []steps.StepDryRun, stepList api.OrderedStepList, postSteps []api.Step) error {
+	var all []api.Step
+	for _, node := range stepList {
+		all = append(all, node.Step)
+	}
+	stepsDryRun, err := steps.DryRun(ctx, append(all, postSteps...))
+	if err != nil {
+		return err
+	}
+	dryRun := append(initialization, stepsDryRun...)
+	for _, step := range dryRun {
+		for _, action := range step.Actions {
+			if action.Object == nil {
+				continue
+			}
+			gvk, err := apiutil.GVKForObject(action.Object, scheme.Scheme)
+			if err != nil {
+				return fmt.Errorf("could not determine the kind of an object of step %s: %w", step.Step, err)
+			}
+			action.Object.GetObjectKind().SetGroupVersionKind(gvk)
+		}
+	}
+	raw, err := yaml.Marshal(dryRun)
+	if err != nil {
+		return fmt.Errorf("could not marshal the actions: %w", err)
+	}
+	_, err = w.Write(raw)
+	return err
+}
+
 func calculateGraph(nodes api.OrderedStepList) (*api.CIOperatorStepGraph, []error) {
 	if err := validateSteps(nodes); err != nil {
 		return nil, err
@@ -1961,6 +3191,73 @@ func getHashFromBytes(b []byte) string {
 	return oneWayNameEncoding.EncodeToString(hash.Sum(nil)[:5])
 }
 
+// policyAuditFile is the artifact recording the evaluations of the policies.
+const policyAuditFile = "policy-audit.jsonl"
+
+// policyFromDir loads the policies to evaluate objects with, recording the
+// evaluations in the artifacts when they are collected.
+func policyFromDir(dir string) (*policyclient.Policy, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	if _, err := exec.LookPath("opa"); err != nil {
+		return nil, fmt.Errorf("evaluating policies requires the opa binary: %w", err)
+	}
+	var audit io.Writer = io.Discard
+	if artifactDir, set := api.Artifacts(); set {
+		if err := os.MkdirAll(artifactDir, 0777); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(filepath.Join(artifactDir, policyAuditFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("could not open the policy audit: %w", err)
+		}
+		audit = file
+	}
+	evaluator, err := policyclient.NewOPAEvaluator(dir)
+	if err != nil {
+		if closer, ok := audit.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return nil, err
+	}
+	return policyclient.New(evaluator, audit), nil
+}
+
+// closePolicy stops the evaluation of the policies and closes their audit.
+func (o *options) closePolicy() {
+	if o.policy == nil {
+		return
+	}
+	if err := o.policy.Close(); err != nil {
+		logrus.WithError(err).Warn("Could not close the policies.")
+	}
+}
+
+// registryMirror returns the repository promoted images are mirrored to,
+// when the job promotes for real.
+func (o *options) registryMirror() string {
+	if !o.promote || o.promoteDryRun || o.configSpec == nil || o.configSpec.PromotionConfiguration == nil {
+		return ""
+	}
+	return o.configSpec.PromotionConfiguration.RegistryMirror
+}
+
+// pushImagesOptions returns the options to push images with, if any. The
+// repository given with --push-images-to takes precedence over the mirror
+// of the promotion configuration, which only gets the promoted images.
+func (o *options) pushImagesOptions() *releasesteps.PushImagesOptions {
+	if o.pushImagesTo != "" {
+		return &releasesteps.PushImagesOptions{Repository: o.pushImagesTo, Images: o.pushImagesSelection.values}
+	}
+	if mirror := o.registryMirror(); mirror != "" {
+		return &releasesteps.PushImagesOptions{Repository: mirror, Promoted: true}
+	}
+	return nil
+}
+
 func getDockerConfigSecret(name, filename string) (*coreapi.Secret, error) {
 	src, err := os.ReadFile(filename)
 	if err != nil {
@@ -1977,6 +3274,47 @@ func getDockerConfigSecret(name, filename string) (*coreapi.Secret, error) {
 	}, nil
 }
 
+// mergePullCredentials adds the registries of the pull secret that the push
+// secret has no credentials for to the push secret.
+func mergePullCredentials(push, pull *coreapi.Secret) error {
+	if push == nil || pull == nil {
+		return nil
+	}
+	var pushConfig, pullConfig map[string]json.RawMessage
+	if err := json.Unmarshal(push.Data[coreapi.DockerConfigJsonKey], &pushConfig); err != nil {
+		return fmt.Errorf("could not parse secret %s: %w", push.Name, err)
+	}
+	if err := json.Unmarshal(pull.Data[coreapi.DockerConfigJsonKey], &pullConfig); err != nil {
+		return fmt.Errorf("could not parse secret %s: %w", pull.Name, err)
+	}
+	pushAuths, pullAuths := map[string]json.RawMessage{}, map[string]json.RawMessage{}
+	if raw, ok := pushConfig["auths"]; ok {
+		if err := json.Unmarshal(raw, &pushAuths); err != nil {
+			return fmt.Errorf("could not parse auths of secret %s: %w", push.Name, err)
+		}
+	}
+	if raw, ok := pullConfig["auths"]; ok {
+		if err := json.Unmarshal(raw, &pullAuths); err != nil {
+			return fmt.Errorf("could not parse auths of secret %s: %w", pull.Name, err)
+		}
+	}
+	for registry, auth := range pullAuths {
+		if _, ok := pushAuths[registry]; !ok {
+			pushAuths[registry] = auth
+		}
+	}
+	raw, err := json.Marshal(pushAuths)
+	if err != nil {
+		return err
+	}
+	if pushConfig == nil {
+		pushConfig = map[string]json.RawMessage{}
+	}
+	pushConfig["auths"] = raw
+	push.Data[coreapi.DockerConfigJsonKey], err = json.Marshal(pushConfig)
+	return err
+}
+
 func getSecret(name, filename string) (*coreapi.Secret, error) {
 	src, err := os.ReadFile(filename)
 	if err != nil {
@@ -1993,6 +3331,15 @@ func getSecret(name, filename string) (*coreapi.Secret, error) {
 	}, nil
 }
 
+func validParametersFormat(format steps.ParametersFormat) bool {
+	for _, valid := range steps.ParametersFormats {
+		if format == valid {
+			return true
+		}
+	}
+	return false
+}
+
 func resolveGCSCredentialsSecret(jobSpec *api.JobSpec) string {
 	if jobSpec.DecorationConfig != nil && jobSpec.DecorationConfig.GCSCredentialsSecret != nil {
 		return *jobSpec.DecorationConfig.GCSCredentialsSecret
@@ -2045,7 +3392,7 @@ func (o *options) getInjectTest() (*api.MetadataWithTest, error) {
 	return api.MetadataTestFromString(o.injectTest)
 }
 
-// loadConfig loads the standard configuration path, env, or configresolver (in that order of priority)
+// loadConfig loads the configuration from the source under test, the standard configuration path, env, or configresolver (in that order of priority)
 func (o *options) loadConfig(info *api.Metadata) (*api.ReleaseBuildConfiguration, error) {
 	var raw string
 
@@ -2053,11 +3400,26 @@ func (o *options) loadConfig(info *api.Metadata) (*api.ReleaseBuildConfiguration
 	unresolvedConfigEnv, unresolvedConfigSet := os.LookupEnv("UNRESOLVED_CONFIG")
 
 	switch {
+	case len(o.configInRepo) > 0:
+		data, err := o.configFromRepo()
+		if err != nil {
+			return nil, results.ForReason("config_in_repo").WithError(err).Errorf("--config-in-repo error: %v", err)
+		}
+		raw = string(data)
+	case isConfigURL(o.configSpecPath):
+		data, err := o.configFromURL()
+		if err != nil {
+			return nil, results.ForReason("config_url").WithError(err).Errorf("--config error: %v", err)
+		}
+		raw = string(data)
 	case len(o.configSpecPath) > 0:
 		data, err := gzip.ReadFileMaybeGZIP(o.configSpecPath)
 		if err != nil {
 			return nil, fmt.Errorf("--config error: %w", err)
 		}
+		if data, err = o.mergeConfigOverlays(data); err != nil {
+			return nil, err
+		}
 		raw = string(data)
 	case configSpecSet:
 		if len(configSpecEnv) == 0 {
@@ -2090,13 +3452,27 @@ func (o *options) loadConfig(info *api.Metadata) (*api.ReleaseBuildConfiguration
 		err = results.ForReason("config_resolver").ForError(err)
 		return configSpec, err
 	}
+	digest, err := verifyConfigDigest([]byte(raw), o.configSHA256)
+	if err != nil {
+		return nil, results.ForReason("verifying_config").ForError(err)
+	}
+	o.configDigest = digest
 	configSpec := api.ReleaseBuildConfiguration{}
 	if err := yaml.UnmarshalStrict([]byte(raw), &configSpec); err != nil {
+		if len(o.configOverlayPaths) > 0 {
+			return nil, fmt.Errorf("invalid configuration merged from files %s: %w\nvalue:\n%s", strings.Join(append([]string{o.configSpecPath}, o.configOverlayPaths...), ", "), err, raw)
+		}
 		if len(o.configSpecPath) > 0 {
 			return nil, fmt.Errorf("invalid configuration in file %s: %w\nvalue:\n%s", o.configSpecPath, err, raw)
 		}
 		return nil, fmt.Errorf("invalid configuration: %w\nvalue:\n%s", err, raw)
 	}
+	if len(o.configInRepo) > 0 {
+		if configSpec.Metadata == (api.Metadata{}) && info != nil {
+			configSpec.Metadata = *info
+		}
+		o.configFromPullRequest = o.jobSpec.Refs != nil && len(o.jobSpec.Refs.Pulls) > 0
+	}
 	if o.registryPath != "" {
 		refs, chains, workflows, _, _, observers, err := load.Registry(o.registryPath, load.RegistryFlag(0))
 		if err != nil {
@@ -2106,6 +3482,11 @@ func (o *options) loadConfig(info *api.Metadata) (*api.ReleaseBuildConfiguration
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve configuration: %w", err)
 		}
+	} else {
+		var err error
+		if configSpec, err = resolveInlineSteps(configSpec); err != nil {
+			return nil, fmt.Errorf("failed to resolve configuration: %w", err)
+		}
 	}
 	return &configSpec, nil
 }
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
 
+	coreapi "k8s.io/api/core/v1"
 	rbacapi "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -31,6 +37,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	releasesteps "github.com/openshift/ci-tools/pkg/steps/release"
 	"github.com/openshift/ci-tools/pkg/testhelper"
 	utilgzip "github.com/openshift/ci-tools/pkg/util/gzip"
 )
@@ -1000,7 +1007,7 @@ func TestBuildPartialGraph(t *testing.T) {
 						},
 						To: api.PipelineImageStreamTagReference("oc-bin-image"),
 					},
-					&api.ReleaseBuildConfiguration{}, api.ResourceConfiguration{}, nil, nil, nil, nil,
+					&api.ReleaseBuildConfiguration{}, api.ResourceConfiguration{}, nil, nil, nil, nil, nil,
 				),
 				steps.OutputImageTagStep(api.OutputImageTagStepConfiguration{From: api.PipelineImageStreamTagReference("oc-bin-image")}, nil, nil),
 				steps.ImagesReadyStep(steps.OutputImageTagStep(api.OutputImageTagStepConfiguration{From: api.PipelineImageStreamTagReference("oc-bin-image")}, nil, nil).Creates()),
@@ -1008,8 +1015,11 @@ func TestBuildPartialGraph(t *testing.T) {
 			targetName: "[images]",
 			expectedErrors: []error{
 				errors.New("steps are missing dependencies"),
-				errors.New(`step [output::] is missing dependencies: <&api.internalImageStreamLink{name:"stable"}>, <&api.internalImageStreamTagLink{name:"pipeline", tag:"oc-bin-image", unsatisfiableError:""}>`),
-				errors.New(`step oc-bin-image is missing dependencies: "cli" is neither an imported nor a built image`),
+				errors.New("step [output::] is missing dependencies:\n" +
+					"  * image tag pipeline:oc-bin-image; it would be created by oc-bin-image, which cannot run either\n" +
+					"  * release images \"latest\"; it is provided by `releases.latest` or `tag_specification`"),
+				errors.New("step oc-bin-image is missing dependencies:\n" +
+					"  * image tag pipeline:cli: \"cli\" is neither an imported nor a built image; it is provided by an entry named \"cli\" in `base_images`, `base_rpm_images` or `images`"),
 			},
 		},
 	}
@@ -1609,3 +1619,233 @@ func TestHandleTargetAdditionalSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		jobSpec  *api.JobSpec
+		expected string
+	}{{
+		name:     "without a job",
+		expected: "ci-operator/0",
+	}, {
+		name:     "with a job",
+		jobSpec:  &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "pull-ci-org-repo-master-unit"}},
+		expected: "ci-operator/0 (job pull-ci-org-repo-master-unit)",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := userAgent(tc.jobSpec); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, arg := range []string{"-h", "--help"} {
+		t.Run(arg, func(t *testing.T) {
+			flagSet := flag.NewFlagSet("ci-operator", flag.ContinueOnError)
+			var out bytes.Buffer
+			flagSet.SetOutput(&out)
+			flagSet.Usage = usageFor(flagSet)
+			bindOptions(flagSet)
+			if err := flagSet.Parse([]string{arg}); !errors.Is(err, flag.ErrHelp) {
+				t.Fatalf("expected %v, got %v", flag.ErrHelp, err)
+			}
+			for _, section := range []string{"Usage:\n  ci-operator [flags]", "Examples:\n", "Flags:\n", "-dry-run"} {
+				if !strings.Contains(out.String(), section) {
+					t.Errorf("expected usage to contain %q, got:\n%s", section, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLinkFinalAttempt(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARTIFACTS", dir)
+	jobSpec := &api.JobSpec{}
+	for _, attempt := range []string{"1", "2"} {
+		jobSpec.SetAttempt(attempt)
+		if err := linkFinalAttempt(jobSpec); err != nil {
+			t.Fatalf("attempt %s: unexpected error: %v", attempt, err)
+		}
+	}
+	target, err := os.Readlink(filepath.Join(dir, api.FinalAttemptArtifactDir))
+	if err != nil {
+		t.Fatalf("could not read the link: %v", err)
+	}
+	if target != "attempt-2" {
+		t.Errorf("expected the link to point at the last attempt, got %s", target)
+	}
+}
+
+func TestFormattingHookJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fields   logrus.Fields
+		entry    logrus.Fields
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no job fields",
+			entry:    logrus.Fields{"step": "src"},
+			expected: map[string]interface{}{"level": "info", "msg": "message", "step": "src", "time": "2026-01-02T03:04:05Z"},
+		},
+		{
+			name:     "job fields are added",
+			fields:   logrus.Fields{"job": "pull-ci-org-repo-master-unit", "namespace": "ci-op-1234"},
+			entry:    logrus.Fields{"step": "unit"},
+			expected: map[string]interface{}{"job": "pull-ci-org-repo-master-unit", "level": "info", "msg": "message", "namespace": "ci-op-1234", "step": "unit", "time": "2026-01-02T03:04:05Z"},
+		},
+		{
+			name:     "fields of the entry win",
+			fields:   logrus.Fields{"namespace": "ci-op-1234"},
+			entry:    logrus.Fields{"namespace": "other"},
+			expected: map[string]interface{}{"level": "info", "msg": "message", "namespace": "other", "time": "2026-01-02T03:04:05Z"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			censor := secrets.NewDynamicCensor()
+			out := &bytes.Buffer{}
+			hook := &formattingHook{formatter: &logrus.TextFormatter{}, writer: out}
+			if err := hook.setFormat(logFormatJSON, &censor); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			hook.fields = &logFields{fields: logrus.Fields{}}
+			for key, value := range tc.fields {
+				hook.fields.set(key, value)
+			}
+			entry := &logrus.Entry{
+				Logger:  logrus.New(),
+				Data:    tc.entry,
+				Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				Level:   logrus.InfoLevel,
+				Message: "message",
+			}
+			if err := hook.Fire(entry); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var actual map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &actual); err != nil {
+				t.Fatalf("line is not JSON: %v: %s", err, out.String())
+			}
+			testhelper.Diff(t, "line", actual, tc.expected)
+			if len(tc.fields) != 0 && len(entry.Data) != len(tc.entry) {
+				t.Errorf("the fields of the entry were modified: %v", entry.Data)
+			}
+		})
+	}
+}
+
+func TestFormattingHookInvalidFormat(t *testing.T) {
+	censor := secrets.NewDynamicCensor()
+	hook := &formattingHook{}
+	if err := hook.setFormat("yaml", &censor); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestPushImagesOptions(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{PromotionConfiguration: &api.PromotionConfiguration{RegistryMirror: "quay.io/org/mirror"}}
+	for _, tc := range []struct {
+		name     string
+		options  options
+		expected *releasesteps.PushImagesOptions
+	}{{
+		name:    "nothing is pushed without promotion",
+		options: options{configSpec: config},
+	}, {
+		name:     "promoted images are mirrored",
+		options:  options{configSpec: config, promote: true},
+		expected: &releasesteps.PushImagesOptions{Repository: "quay.io/org/mirror", Promoted: true},
+	}, {
+		name:    "nothing is mirrored on a dry run",
+		options: options{configSpec: config, promote: true, promoteDryRun: true},
+	}, {
+		name:     "the flag takes precedence",
+		options:  options{configSpec: config, promote: true, pushImagesTo: "quay.io/user/prefix"},
+		expected: &releasesteps.PushImagesOptions{Repository: "quay.io/user/prefix"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "options", tc.options.pushImagesOptions(), tc.expected)
+		})
+	}
+}
+
+func TestCompleteUploadOptions(t *testing.T) {
+	if artifacts, set := os.LookupEnv("ARTIFACTS"); set {
+		t.Cleanup(func() { _ = os.Setenv("ARTIFACTS", artifacts) })
+	}
+	if err := os.Unsetenv("ARTIFACTS"); err != nil {
+		t.Fatal(err)
+	}
+	jobSpec := &api.JobSpec{JobSpec: downwardapi.JobSpec{Type: prowapi.PeriodicJob, Job: "job", BuildID: "1"}}
+	for _, tc := range []struct {
+		name        string
+		options     options
+		expected    string
+		expectedErr error
+	}{{
+		name:    "nothing to upload",
+		options: options{jobSpec: jobSpec},
+	}, {
+		name:        "upload to a location",
+		options:     options{jobSpec: jobSpec, uploadArtifactsTo: "gs://bucket/path", uploadSecretPath: "secret", uploadConcurrency: 1},
+		expected:    "gs://bucket/path",
+		expectedErr: errors.New("--upload-artifacts-to requires $ARTIFACTS to be set"),
+	}, {
+		name:        "upload to the path of the job",
+		options:     options{jobSpec: jobSpec, uploadArtifactsRoot: "gs://bucket/", uploadSecretPath: "secret", uploadConcurrency: 1},
+		expected:    "gs://bucket/logs/job/1/artifacts",
+		expectedErr: errors.New("--upload-artifacts requires $ARTIFACTS to be set"),
+	}, {
+		name:        "both flags",
+		options:     options{jobSpec: jobSpec, uploadArtifactsTo: "gs://bucket/path", uploadArtifactsRoot: "gs://bucket"},
+		expected:    "gs://bucket/path",
+		expectedErr: errors.New("--upload-artifacts and --upload-artifacts-to are mutually exclusive"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := tc.options
+			err := o.completeUploadOptions()
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			testhelper.Diff(t, "upload location", o.uploadArtifactsTo, tc.expected)
+		})
+	}
+}
+
+func TestMergePullCredentials(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		push        string
+		pull        string
+		expected    string
+		expectedErr error
+	}{{
+		name:     "registries of the pull secret are added",
+		push:     `{"auths":{"quay.io":{"auth":"cHVzaA=="}}}`,
+		pull:     `{"auths":{"registry.ci.openshift.org":{"auth":"cHVsbA=="}}}`,
+		expected: `{"auths":{"quay.io":{"auth":"cHVzaA=="},"registry.ci.openshift.org":{"auth":"cHVsbA=="}}}`,
+	}, {
+		name:     "push credentials take precedence",
+		push:     `{"auths":{"quay.io":{"auth":"cHVzaA=="}}}`,
+		pull:     `{"auths":{"quay.io":{"auth":"cHVsbA=="}}}`,
+		expected: `{"auths":{"quay.io":{"auth":"cHVzaA=="}}}`,
+	}, {
+		name:        "invalid pull secret",
+		push:        `{"auths":{}}`,
+		pull:        `{`,
+		expectedErr: errors.New("could not parse secret pull: unexpected end of JSON input"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			push := &coreapi.Secret{ObjectMeta: metav1.ObjectMeta{Name: "push"}, Data: map[string][]byte{coreapi.DockerConfigJsonKey: []byte(tc.push)}}
+			pull := &coreapi.Secret{ObjectMeta: metav1.ObjectMeta{Name: "pull"}, Data: map[string][]byte{coreapi.DockerConfigJsonKey: []byte(tc.pull)}}
+			err := mergePullCredentials(push, pull)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err == nil {
+				testhelper.Diff(t, "push secret", string(push.Data[coreapi.DockerConfigJsonKey]), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	authapi "k8s.io/api/authorization/v1"
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imageapi "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// namespacePermissions are the permissions ci-operator needs in a namespace
+// it does not initialize to provide the required capabilities to the steps.
+func namespacePermissions(required []capability) []authapi.ResourceAttributes {
+	permissions := []authapi.ResourceAttributes{
+		{Verb: "create", Resource: "pods"},
+		{Verb: "create", Resource: "secrets"},
+	}
+	for _, c := range required {
+		if c == capabilityProjects {
+			continue
+		}
+		permissions = append(permissions, authapi.ResourceAttributes{Verb: "create", Group: strings.Split(c.groupVersion, "/")[0], Resource: c.resource})
+	}
+	return permissions
+}
+
+// accessReviewer determines whether the user may act on resources.
+type accessReviewer func(ctx context.Context, attributes authapi.ResourceAttributes) (bool, error)
+
+// selfSubjectAccessReviewer reviews access with a SelfSubjectAccessReview.
+func selfSubjectAccessReviewer(client ctrlruntimeclient.Client) accessReviewer {
+	return func(ctx context.Context, attributes authapi.ResourceAttributes) (bool, error) {
+		sar := &authapi.SelfSubjectAccessReview{Spec: authapi.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes}}
+		if err := client.Create(ctx, sar); err != nil {
+			return false, err
+		}
+		return sar.Status.Allowed, nil
+	}
+}
+
+// verifyNamespace checks that an externally managed namespace has everything
+// initializeNamespace would otherwise set up, failing with the list of what
+// is missing so it can be provisioned all at once.
+func (o *options) verifyNamespace(ctx context.Context, client ctrlruntimeclient.Client, canI accessReviewer, required []capability) error {
+	var missing []string
+	check := func(description string, obj ctrlruntimeclient.Object, key ctrlruntimeclient.ObjectKey) bool {
+		err := client.Get(ctx, key, obj)
+		switch {
+		case err == nil:
+			return true
+		case kerrors.IsNotFound(err):
+			missing = append(missing, fmt.Sprintf("%s does not exist", description))
+		default:
+			missing = append(missing, fmt.Sprintf("%s cannot be read: %v", description, err))
+		}
+		return false
+	}
+
+	ns := &coreapi.Namespace{}
+	if check(fmt.Sprintf("namespace %s", o.namespace), ns, ctrlruntimeclient.ObjectKey{Name: o.namespace}) && ns.Status.Phase == coreapi.NamespaceTerminating {
+		missing = append(missing, fmt.Sprintf("namespace %s is terminating", o.namespace))
+	}
+	for _, attributes := range namespacePermissions(required) {
+		attributes.Namespace = o.namespace
+		resource := attributes.Resource
+		if attributes.Group != "" {
+			resource = fmt.Sprintf("%s.%s", attributes.Resource, attributes.Group)
+		}
+		allowed, err := canI(ctx, attributes)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("permission to %s %s could not be reviewed: %v", attributes.Verb, resource, err))
+		} else if !allowed {
+			missing = append(missing, fmt.Sprintf("permission to %s %s", attributes.Verb, resource))
+		}
+	}
+	for _, name := range []string{"builder", "default"} {
+		sa := &coreapi.ServiceAccount{}
+		if check(fmt.Sprintf("service account %s", name), sa, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: name}) && len(sa.ImagePullSecrets) == 0 {
+			missing = append(missing, fmt.Sprintf("service account %s has no image pull secrets", name))
+		}
+	}
+	is := &imageapi.ImageStream{}
+	if check(fmt.Sprintf("imagestream %s", api.PipelineImageStream), is, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: api.PipelineImageStream}) {
+		o.jobSpec.SetOwner(&meta.OwnerReference{
+			APIVersion: "image.openshift.io/v1",
+			Kind:       "ImageStream",
+			Name:       api.PipelineImageStream,
+			UID:        is.UID,
+		})
+	}
+	secrets := append([]*coreapi.Secret{o.pullSecret, o.pushSecret, o.pushImagesSecret, o.uploadSecret}, o.secrets...)
+	if o.cloneAuthConfig != nil {
+		secrets = append(secrets, o.cloneAuthConfig.Secret)
+	}
+	for _, secret := range secrets {
+		if secret != nil && secret.Name != "" {
+			check(fmt.Sprintf("secret %s", secret.Name), &coreapi.Secret{}, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: secret.Name})
+		}
+	}
+	for _, object := range o.additionalResources {
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(object.GroupVersionKind())
+		check(fmt.Sprintf("%s %s", object.GetKind(), object.GetName()), existing, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: object.GetName()})
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("namespace %s is not ready to run the job:\n  - %s", o.namespace, strings.Join(missing, "\n  - "))
+	}
+	return nil
+}
+
+// verifyExistingNamespace verifies the namespace given with --namespace when
+// --skip-namespace-init is set, instead of initializing it.
+func (o *options) verifyExistingNamespace(ctx context.Context, required []capability) error {
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to construct client: %w", err)
+	}
+	logrus.Debugf("Verifying the externally managed namespace %s", o.namespace)
+	return o.verifyNamespace(ctx, ctrlruntimeclient.NewNamespacedClient(client, o.namespace), selfSubjectAccessReviewer(client), required)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	authapi "k8s.io/api/authorization/v1"
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imageapi "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestVerifyNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := coreapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := imageapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	serviceAccount := func(name string, pullSecrets bool) *coreapi.ServiceAccount {
+		sa := &coreapi.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: "managed", Name: name}}
+		if pullSecrets {
+			sa.ImagePullSecrets = []coreapi.LocalObjectReference{{Name: name + "-dockercfg"}}
+		}
+		return sa
+	}
+	allowed := func(context.Context, authapi.ResourceAttributes) (bool, error) { return true, nil }
+	noBuilds := func(_ context.Context, attributes authapi.ResourceAttributes) (bool, error) {
+		return attributes.Resource != "builds", nil
+	}
+	var testCases = []struct {
+		name        string
+		objects     []runtime.Object
+		canI        accessReviewer
+		required    []capability
+		expectedErr error
+	}{
+		{
+			name: "ready",
+			objects: []runtime.Object{
+				&coreapi.Namespace{ObjectMeta: meta.ObjectMeta{Name: "managed"}},
+				serviceAccount("builder", true),
+				serviceAccount("default", true),
+				&imageapi.ImageStream{ObjectMeta: meta.ObjectMeta{Namespace: "managed", Name: api.PipelineImageStream, UID: "uid"}},
+				&coreapi.Secret{ObjectMeta: meta.ObjectMeta{Namespace: "managed", Name: "credentials"}},
+			},
+			canI:     allowed,
+			required: []capability{capabilityImageStreams, capabilityBuilds, capabilityTemplates},
+		},
+		{
+			name: "builds are not needed",
+			objects: []runtime.Object{
+				&coreapi.Namespace{ObjectMeta: meta.ObjectMeta{Name: "managed"}},
+				serviceAccount("builder", true),
+				serviceAccount("default", true),
+				&imageapi.ImageStream{ObjectMeta: meta.ObjectMeta{Namespace: "managed", Name: api.PipelineImageStream, UID: "uid"}},
+				&coreapi.Secret{ObjectMeta: meta.ObjectMeta{Namespace: "managed", Name: "credentials"}},
+			},
+			canI:     noBuilds,
+			required: []capability{capabilityImageStreams},
+		},
+		{
+			name: "missing resources",
+			objects: []runtime.Object{
+				&coreapi.Namespace{ObjectMeta: meta.ObjectMeta{Name: "managed"}},
+				serviceAccount("builder", false),
+			},
+			canI:        noBuilds,
+			required:    []capability{capabilityImageStreams, capabilityBuilds},
+			expectedErr: errors.New("namespace managed is not ready to run the job:\n  - permission to create builds.build.openshift.io\n  - service account builder has no image pull secrets\n  - service account default does not exist\n  - imagestream pipeline does not exist\n  - secret credentials does not exist"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &options{
+				namespace: "managed",
+				jobSpec:   &api.JobSpec{},
+				secrets:   []*coreapi.Secret{{ObjectMeta: meta.ObjectMeta{Name: "credentials"}}},
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(tc.objects...).Build()
+			err := o.verifyNamespace(context.Background(), client, tc.canI, tc.required)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err == nil && o.jobSpec.Owner() == nil {
+				t.Error("expected the pipeline imagestream to own the objects of the job")
+			}
+		})
+	}
+}
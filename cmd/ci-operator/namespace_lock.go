@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coordinationapi "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// namespaceLockNone lets executions share the namespace unaware of each
+	// other.
+	namespaceLockNone = "none"
+	// namespaceLockWait waits for the execution holding the namespace to end.
+	namespaceLockWait = "wait"
+	// namespaceLockAttach runs in the namespace held by another execution,
+	// reusing what it creates, without taking the lock.
+	namespaceLockAttach = "attach"
+	// namespaceLockFail fails when another execution holds the namespace.
+	namespaceLockFail = "fail"
+)
+
+var namespaceLockModes = []string{namespaceLockNone, namespaceLockWait, namespaceLockAttach, namespaceLockFail}
+
+func validateNamespaceLockMode(mode string) error {
+	for _, valid := range namespaceLockModes {
+		if mode == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("--namespace-lock must be one of %s, got %q", strings.Join(namespaceLockModes, ", "), mode)
+}
+
+const (
+	// namespaceLockName is the name of the Lease locking the namespace.
+	namespaceLockName = "ci-operator"
+	// namespaceLockDuration is how long the lock is held without a heartbeat,
+	// so the namespace of an execution that was killed is not locked forever.
+	namespaceLockDuration = 2 * time.Minute
+	// namespaceLockPollInterval is how often a waiting execution checks the lock.
+	namespaceLockPollInterval = 15 * time.Second
+)
+
+// namespaceLock is a Lease in the namespace that the execution holds while it
+// runs, renewing it periodically.
+type namespaceLock struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	identity  string
+	now       func() time.Time
+
+	stopHeartbeat context.CancelFunc
+}
+
+// namespaceLockIdentity identifies the execution holding the lock: the build
+// of the job that scheduled it and the process in the pod it runs in, so that
+// executions never share the identity, even when they run in the same pod.
+func namespaceLockIdentity(job, buildID string) string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s/%s/%s/%d", job, buildID, hostname, os.Getpid())
+}
+
+func newNamespaceLock(client ctrlruntimeclient.Client, namespace, identity string) *namespaceLock {
+	return &namespaceLock{client: client, namespace: namespace, identity: identity, now: time.Now}
+}
+
+// expired determines whether the holder of the lease stopped renewing it.
+func (l *namespaceLock) expired(lease *coordinationapi.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	duration := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	return l.now().After(lease.Spec.RenewTime.Add(duration))
+}
+
+func (l *namespaceLock) hold(lease *coordinationapi.Lease) {
+	now := meta.NewMicroTime(l.now())
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != l.identity {
+		lease.Spec.AcquireTime = &now
+	}
+	lease.Spec.HolderIdentity = utilpointer.String(l.identity)
+	lease.Spec.LeaseDurationSeconds = utilpointer.Int32(int32(namespaceLockDuration.Seconds()))
+	lease.Spec.RenewTime = &now
+}
+
+// tryAcquire takes the lock if it is free, expired or already ours, and
+// returns the holder otherwise. Losing a race for the lock is reported as the
+// lock being held by an unknown holder.
+func (l *namespaceLock) tryAcquire(ctx context.Context) (string, bool, error) {
+	lease := &coordinationapi.Lease{}
+	if err := l.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: l.namespace, Name: namespaceLockName}, lease); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return "", false, fmt.Errorf("could not get the namespace lock: %w", err)
+		}
+		lease = &coordinationapi.Lease{ObjectMeta: meta.ObjectMeta{Namespace: l.namespace, Name: namespaceLockName}}
+		l.hold(lease)
+		if err := l.client.Create(ctx, lease); err != nil {
+			if kerrors.IsAlreadyExists(err) {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("could not create the namespace lock: %w", err)
+		}
+		return "", true, nil
+	}
+	if holder := lease.Spec.HolderIdentity; holder != nil && *holder != "" && *holder != l.identity && !l.expired(lease) {
+		return *holder, false, nil
+	}
+	l.hold(lease)
+	if err := l.client.Update(ctx, lease); err != nil {
+		if kerrors.IsConflict(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("could not take the namespace lock: %w", err)
+	}
+	return "", true, nil
+}
+
+// acquire locks the namespace as the mode dictates, keeping the lock held
+// until release is called.
+func (l *namespaceLock) acquire(ctx context.Context, mode string, pollInterval time.Duration) error {
+	holder, acquired, err := l.tryAcquire(ctx)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		switch mode {
+		case namespaceLockFail:
+			return fmt.Errorf("namespace %s is in use by %s", l.namespace, describeHolder(holder))
+		case namespaceLockAttach:
+			logrus.Infof("Namespace %s is in use by %s, attaching to it.", l.namespace, describeHolder(holder))
+			return nil
+		}
+		logrus.Infof("Namespace %s is in use by %s, waiting for it to be released.", l.namespace, describeHolder(holder))
+		if err := wait.PollUntilContextCancel(ctx, pollInterval, false, func(ctx context.Context) (bool, error) {
+			_, acquired, err := l.tryAcquire(ctx)
+			return acquired, err
+		}); err != nil {
+			return fmt.Errorf("could not lock namespace %s: %w", l.namespace, err)
+		}
+	}
+	logrus.Debugf("Locked namespace %s as %s.", l.namespace, l.identity)
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	l.stopHeartbeat = cancel
+	go l.heartbeat(heartbeatCtx)
+	return nil
+}
+
+// held determines whether this execution holds the lock, as opposed to having
+// attached to a namespace held by another one or not locking it at all.
+func (l *namespaceLock) held() bool {
+	return l != nil && l.stopHeartbeat != nil
+}
+
+func describeHolder(holder string) string {
+	if holder == "" {
+		return "another execution"
+	}
+	return holder
+}
+
+// heartbeat renews the lock until the context is cancelled.
+func (l *namespaceLock) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(namespaceLockDuration / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lease := &coordinationapi.Lease{}
+			if err := l.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: l.namespace, Name: namespaceLockName}, lease); err != nil {
+				logrus.WithError(err).Warnf("Failed to get the lock of namespace %s for heartbeating", l.namespace)
+				continue
+			}
+			if holder := lease.Spec.HolderIdentity; holder == nil || *holder != l.identity {
+				logrus.Warnf("The lock of namespace %s was taken over by %s.", l.namespace, describeHolder(utilpointer.StringDeref(holder, "")))
+				return
+			}
+			l.hold(lease)
+			if err := l.client.Update(ctx, lease); err != nil {
+				logrus.WithError(err).Warnf("Failed to renew the lock of namespace %s.", l.namespace)
+			}
+		}
+	}
+}
+
+// release stops renewing the lock and frees it, if it is still ours.
+func (l *namespaceLock) release(ctx context.Context) {
+	if l.stopHeartbeat == nil {
+		return
+	}
+	l.stopHeartbeat()
+	l.stopHeartbeat = nil
+	lease := &coordinationapi.Lease{}
+	if err := l.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: l.namespace, Name: namespaceLockName}, lease); err != nil {
+		logrus.WithError(err).Warnf("Failed to get the lock of namespace %s to release it.", l.namespace)
+		return
+	}
+	if holder := lease.Spec.HolderIdentity; holder == nil || *holder != l.identity {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	if err := l.client.Update(ctx, lease); err != nil {
+		logrus.WithError(err).Warnf("Failed to release the lock of namespace %s.", l.namespace)
+	}
+}
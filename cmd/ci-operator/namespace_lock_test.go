@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	coordinationapi "k8s.io/api/coordination/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func heldLease(holder string, renewed time.Time) *coordinationapi.Lease {
+	renewTime := meta.NewMicroTime(renewed)
+	return &coordinationapi.Lease{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: namespaceLockName},
+		Spec: coordinationapi.LeaseSpec{
+			HolderIdentity:       utilpointer.String(holder),
+			LeaseDurationSeconds: utilpointer.Int32(int32(namespaceLockDuration.Seconds())),
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+func getHolder(t *testing.T, client ctrlruntimeclient.Client) string {
+	t.Helper()
+	lease := &coordinationapi.Lease{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: namespaceLockName}, lease); err != nil {
+		t.Fatalf("could not get the lease: %v", err)
+	}
+	return utilpointer.StringDeref(lease.Spec.HolderIdentity, "")
+}
+
+func TestNamespaceLockAcquire(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name           string
+		existing       *coordinationapi.Lease
+		mode           string
+		expectedErr    bool
+		expectedHolder string
+		expectedHeld   bool
+	}{
+		{
+			name:           "free namespace is locked",
+			mode:           namespaceLockFail,
+			expectedHolder: "me",
+			expectedHeld:   true,
+		},
+		{
+			name:           "released lock is taken",
+			existing:       heldLease("", now),
+			mode:           namespaceLockFail,
+			expectedHolder: "me",
+			expectedHeld:   true,
+		},
+		{
+			name:           "expired lock is taken over",
+			existing:       heldLease("other", now.Add(-time.Hour)),
+			mode:           namespaceLockFail,
+			expectedHolder: "me",
+			expectedHeld:   true,
+		},
+		{
+			name:           "our own lock is renewed",
+			existing:       heldLease("me", now.Add(-time.Minute)),
+			mode:           namespaceLockFail,
+			expectedHolder: "me",
+			expectedHeld:   true,
+		},
+		{
+			name:           "held lock fails",
+			existing:       heldLease("other", now.Add(-time.Minute)),
+			mode:           namespaceLockFail,
+			expectedErr:    true,
+			expectedHolder: "other",
+		},
+		{
+			name:           "held lock is attached to",
+			existing:       heldLease("other", now.Add(-time.Minute)),
+			mode:           namespaceLockAttach,
+			expectedHolder: "other",
+		},
+		{
+			name:           "held lock is waited for until it expires",
+			existing:       heldLease("other", now.Add(-time.Minute)),
+			mode:           namespaceLockWait,
+			expectedHolder: "me",
+			expectedHeld:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fakectrlruntimeclient.NewClientBuilder()
+			if tc.existing != nil {
+				builder = builder.WithObjects(tc.existing)
+			}
+			client := builder.Build()
+			lock := newNamespaceLock(client, "ns", "me")
+			polls := 0
+			lock.now = func() time.Time {
+				// every poll moves the clock, so a waiting lock sees the
+				// holder expire
+				polls++
+				return now.Add(time.Duration(polls) * time.Minute)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			err := lock.acquire(ctx, tc.mode, time.Millisecond)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			testhelper.Diff(t, "holder", getHolder(t, client), tc.expectedHolder)
+			testhelper.Diff(t, "held", lock.held(), tc.expectedHeld)
+			lock.release(context.Background())
+			if tc.expectedHeld {
+				testhelper.Diff(t, "holder after release", getHolder(t, client), "")
+			}
+		})
+	}
+}
+
+func TestNamespaceLockReleaseTakenOver(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().Build()
+	lock := newNamespaceLock(client, "ns", "me")
+	if err := lock.acquire(context.Background(), namespaceLockFail, time.Millisecond); err != nil {
+		t.Fatalf("could not lock: %v", err)
+	}
+	lease := &coordinationapi.Lease{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: namespaceLockName}, lease); err != nil {
+		t.Fatal(err)
+	}
+	lease.Spec.HolderIdentity = utilpointer.String("other")
+	if err := client.Update(context.Background(), lease); err != nil {
+		t.Fatal(err)
+	}
+	lock.release(context.Background())
+	testhelper.Diff(t, "holder", getHolder(t, client), "other")
+}
+
+func TestValidateNamespaceLockMode(t *testing.T) {
+	for _, mode := range namespaceLockModes {
+		if err := validateNamespaceLockMode(mode); err != nil {
+			t.Errorf("%s: unexpected error: %v", mode, err)
+		}
+	}
+	if err := validateNamespaceLockMode("steal"); err == nil {
+		t.Error("expected an error for an unknown mode, got none")
+	}
+}
+
+func TestNamespaceLockIdentity(t *testing.T) {
+	first, second := namespaceLockIdentity("job", "1"), namespaceLockIdentity("job", "2")
+	if first == second {
+		t.Errorf("expected builds of the same job to have different identities, got %s", first)
+	}
+	if suffix := fmt.Sprintf("/%d", os.Getpid()); !strings.HasSuffix(first, suffix) {
+		t.Errorf("expected the identity to end with the process ID %s, got %s", suffix, first)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+const (
+	// defaultNamespacePrefix prefixes the namespaces named after the input hash.
+	defaultNamespacePrefix = "ci-op-"
+	// defaultInputHashLength is the length of the input hash, also its minimum:
+	// shorter hashes collide too often.
+	defaultInputHashLength = 8
+	// maxInputHashLength is the length of the whole encoded SHA-256 sum.
+	maxInputHashLength = 52
+)
+
+// validateNamespaceNaming checks that the namespaces named with the prefix and
+// a hash of the length are valid.
+func validateNamespaceNaming(prefix string, hashLength int) error {
+	if hashLength < defaultInputHashLength || hashLength > maxInputHashLength {
+		return fmt.Errorf("--input-hash-length must be between %d and %d, got %d", defaultInputHashLength, maxInputHashLength, hashLength)
+	}
+	if errs := validation.IsDNS1123Label(prefix + strings.Repeat("a", hashLength)); len(errs) != 0 {
+		return fmt.Errorf("--namespace-prefix %q with a hash of %d characters does not make a valid namespace name: %s", prefix, hashLength, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// servesRPMs determines whether the configuration serves RPMs, which exposes a
+// route named after the namespace.
+func servesRPMs(config *api.ReleaseBuildConfiguration) bool {
+	if config == nil {
+		return false
+	}
+	if config.RpmBuildCommands != "" {
+		return true
+	}
+	for _, step := range config.RawSteps {
+		if step.RPMServeStepConfiguration != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNamespaceName checks that the namespace is a valid name, and that
+// the names derived from it are: the default host of a route is
+// <route>-<namespace>.<domain>, and its first label must be a DNS label.
+func validateNamespaceName(namespace string, rpms bool) error {
+	if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+		return fmt.Errorf("namespace %q is not a valid name: %s", namespace, strings.Join(errs, ", "))
+	}
+	if rpms {
+		host := fmt.Sprintf("%s-%s", steps.RPMRepoName, namespace)
+		if errs := validation.IsDNS1123Label(host); len(errs) != 0 {
+			return fmt.Errorf("namespace %q is too long to serve RPMs, the host of the route %s would be invalid: %s", namespace, host, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// validateDedicatedNamespaceNames checks that the dedicated namespaces of the
+// tests, named <namespace>-<test>, are valid names. The configuration only
+// validates them for the default naming of the namespace.
+func validateDedicatedNamespaceNames(namespace string, config *api.ReleaseBuildConfiguration) error {
+	if config == nil {
+		return nil
+	}
+	for _, test := range config.Tests {
+		if test.DedicatedNamespace == nil {
+			continue
+		}
+		name := fmt.Sprintf("%s-%s", namespace, test.As)
+		if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+			return fmt.Errorf("namespace %q is too long for test %s, its dedicated namespace %s would be invalid: %s", namespace, test.As, name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
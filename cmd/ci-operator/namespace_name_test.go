@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestInputHash(t *testing.T) {
+	inputs := api.InputDefinition{"a", "b"}
+	short := inputHash(inputs, defaultInputHashLength)
+	if len(short) != defaultInputHashLength {
+		t.Errorf("expected a hash of %d characters, got %q", defaultInputHashLength, short)
+	}
+	long := inputHash(inputs, maxInputHashLength)
+	if len(long) != maxInputHashLength {
+		t.Errorf("expected a hash of %d characters, got %q", maxInputHashLength, long)
+	}
+	if !strings.HasPrefix(long, short) {
+		t.Errorf("expected the longer hash %q to extend the default one %q", long, short)
+	}
+}
+
+func TestValidateNamespaceNaming(t *testing.T) {
+	testCases := []struct {
+		name       string
+		prefix     string
+		hashLength int
+		expected   error
+	}{
+		{
+			name:       "defaults",
+			prefix:     defaultNamespacePrefix,
+			hashLength: defaultInputHashLength,
+		},
+		{
+			name:       "longest hash",
+			prefix:     "ci-",
+			hashLength: maxInputHashLength,
+		},
+		{
+			name:       "hash too short",
+			prefix:     defaultNamespacePrefix,
+			hashLength: 4,
+			expected:   errors.New("--input-hash-length must be between 8 and 52, got 4"),
+		},
+		{
+			name:       "invalid prefix",
+			prefix:     "CI_",
+			hashLength: defaultInputHashLength,
+			expected:   errors.New(`--namespace-prefix "CI_" with a hash of 8 characters does not make a valid namespace name: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`),
+		},
+		{
+			name:       "prefix too long",
+			prefix:     strings.Repeat("p", 20),
+			hashLength: maxInputHashLength,
+			expected:   errors.New(`--namespace-prefix "pppppppppppppppppppp" with a hash of 52 characters does not make a valid namespace name: must be no more than 63 characters`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", validateNamespaceNaming(tc.prefix, tc.hashLength), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestValidateNamespaceName(t *testing.T) {
+	long := "ci-op-" + strings.Repeat("a", 52)
+	testCases := []struct {
+		name      string
+		namespace string
+		rpms      bool
+		expected  error
+	}{
+		{
+			name:      "valid",
+			namespace: "ci-op-12345678",
+			rpms:      true,
+		},
+		{
+			name:      "long namespace without RPMs",
+			namespace: long,
+		},
+		{
+			name:      "long namespace serving RPMs",
+			namespace: long,
+			rpms:      true,
+			expected:  errors.New(`namespace "` + long + `" is too long to serve RPMs, the host of the route rpm-repo-` + long + ` would be invalid: must be no more than 63 characters`),
+		},
+		{
+			name:      "invalid namespace",
+			namespace: "ci_op",
+			expected:  errors.New(`namespace "ci_op" is not a valid name: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", validateNamespaceName(tc.namespace, tc.rpms), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestValidateDedicatedNamespaceNames(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{
+		{As: "unit"},
+		{As: "e2e", DedicatedNamespace: &api.DedicatedNamespace{}},
+	}}
+	testCases := []struct {
+		name      string
+		namespace string
+		expected  error
+	}{
+		{
+			name:      "valid",
+			namespace: "ci-op-12345678",
+		},
+		{
+			name:      "longest namespace",
+			namespace: strings.Repeat("a", 59),
+		},
+		{
+			name:      "namespace too long for the dedicated namespace",
+			namespace: strings.Repeat("a", 60),
+			expected:  errors.New(`namespace "` + strings.Repeat("a", 60) + `" is too long for test e2e, its dedicated namespace ` + strings.Repeat("a", 60) + `-e2e would be invalid: must be no more than 63 characters`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", validateDedicatedNamespaceNames(tc.namespace, config), tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	authapi "k8s.io/api/authorization/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	authclientset "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// capability is an API that the cluster must serve for the execution graph to
+// be able to run.
+type capability struct {
+	// description is a human-readable name for the capability
+	description  string
+	groupVersion string
+	resource     string
+	// verb, when set, requires that we are allowed to perform the
+	// action on the resource, not only that the API is served
+	verb string
+}
+
+func (c capability) String() string {
+	if c.verb != "" {
+		return fmt.Sprintf("%s (%s %s.%s)", c.description, c.verb, c.resource, c.groupVersion)
+	}
+	return fmt.Sprintf("%s (%s.%s)", c.description, c.resource, c.groupVersion)
+}
+
+var (
+	capabilityImageStreams = capability{description: "image streams", groupVersion: "image.openshift.io/v1", resource: "imagestreams"}
+	capabilityBuilds       = capability{description: "builds", groupVersion: "build.openshift.io/v1", resource: "builds"}
+	capabilityRoutes       = capability{description: "routes", groupVersion: "route.openshift.io/v1", resource: "routes"}
+	capabilityTemplates    = capability{description: "templates", groupVersion: "template.openshift.io/v1", resource: "templateinstances"}
+	capabilityProjects     = capability{description: "project creation", groupVersion: "project.openshift.io/v1", resource: "projectrequests", verb: "create"}
+)
+
+// checkClusterCapabilities fails early when the cluster cannot provide the
+// required capabilities, instead of letting the steps fail late in the run.
+func (o *options) checkClusterCapabilities(ctx context.Context, required []capability) error {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(o.clusterConfig)
+	if err != nil {
+		return fmt.Errorf("could not get discovery client for cluster config: %w", err)
+	}
+	authClient, err := authclientset.NewForConfig(o.clusterConfig)
+	if err != nil {
+		return fmt.Errorf("could not get auth client for cluster config: %w", err)
+	}
+	return checkCapabilities(ctx, discoveryClient, authClient, required)
+}
+
+// requiredCapabilities determines which cluster capabilities the steps that
+// are about to be executed need. Projects are only requested when ci-operator
+// initializes the namespace itself.
+func requiredCapabilities(configs []api.StepConfiguration, stepList api.OrderedStepList, hasTemplates, initNamespace bool) []capability {
+	names := sets.New[string]()
+	for _, node := range stepList {
+		names.Insert(node.Step.Name())
+	}
+	var required []capability
+	if initNamespace {
+		required = append(required, capabilityProjects)
+	}
+	required = append(required, capabilityImageStreams)
+	var needsBuilds, needsRoutes bool
+	for _, config := range configs {
+		switch {
+		case config.SourceStepConfiguration != nil:
+			needsBuilds = needsBuilds || names.Has(config.SourceStepConfiguration.TargetName())
+		case config.PipelineImageCacheStepConfiguration != nil:
+			needsBuilds = needsBuilds || names.Has(config.PipelineImageCacheStepConfiguration.TargetName())
+		case config.ProjectDirectoryImageBuildStepConfiguration != nil:
+			needsBuilds = needsBuilds || names.Has(config.ProjectDirectoryImageBuildStepConfiguration.TargetName())
+		case config.RPMImageInjectionStepConfiguration != nil:
+			needsBuilds = needsBuilds || names.Has(config.RPMImageInjectionStepConfiguration.TargetName())
+		case config.BundleSourceStepConfiguration != nil:
+			needsBuilds = needsBuilds || names.Has(config.BundleSourceStepConfiguration.TargetName())
+		case config.IndexGeneratorStepConfiguration != nil:
+			needsBuilds = needsBuilds || names.Has(config.IndexGeneratorStepConfiguration.TargetName())
+		case config.RPMServeStepConfiguration != nil:
+			needsRoutes = needsRoutes || names.Has(config.RPMServeStepConfiguration.TargetName())
+		}
+	}
+	if needsBuilds {
+		required = append(required, capabilityBuilds)
+	}
+	if needsRoutes {
+		required = append(required, capabilityRoutes)
+	}
+	if hasTemplates {
+		required = append(required, capabilityTemplates)
+	}
+	return required
+}
+
+// checkCapabilities verifies that the cluster provides every required
+// capability, returning an error with a full report if it does not.
+func checkCapabilities(ctx context.Context, discoveryClient discovery.ServerResourcesInterface, authClient authclientset.SelfSubjectAccessReviewsGetter, required []capability) error {
+	var report []string
+	var missing int
+	for _, c := range required {
+		problem := checkCapability(ctx, discoveryClient, authClient, c)
+		status := "available"
+		if problem != "" {
+			missing++
+			status = problem
+		}
+		report = append(report, fmt.Sprintf("  * %s: %s", c, status))
+	}
+	logrus.Debugf("Cluster capabilities:\n%s", strings.Join(report, "\n"))
+	if missing > 0 {
+		return fmt.Errorf("the cluster is missing %d required capabilities:\n%s", missing, strings.Join(report, "\n"))
+	}
+	return nil
+}
+
+func checkCapability(ctx context.Context, discoveryClient discovery.ServerResourcesInterface, authClient authclientset.SelfSubjectAccessReviewsGetter, c capability) string {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(c.groupVersion)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return fmt.Sprintf("API group %s is not served", c.groupVersion)
+		}
+		return fmt.Sprintf("could not discover API group %s: %v", c.groupVersion, err)
+	}
+	var served bool
+	for _, resource := range resources.APIResources {
+		if resource.Name == c.resource {
+			served = true
+			break
+		}
+	}
+	if !served {
+		return fmt.Sprintf("resource %s is not served by %s", c.resource, c.groupVersion)
+	}
+	if c.verb == "" {
+		return ""
+	}
+	review, err := authClient.SelfSubjectAccessReviews().Create(ctx, &authapi.SelfSubjectAccessReview{
+		Spec: authapi.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authapi.ResourceAttributes{
+				Verb:     c.verb,
+				Group:    strings.Split(c.groupVersion, "/")[0],
+				Resource: c.resource,
+			},
+		},
+	}, meta.CreateOptions{})
+	if err != nil {
+		return fmt.Sprintf("could not determine access: %v", err)
+	}
+	if !review.Status.Allowed {
+		reason := review.Status.Reason
+		if reason == "" {
+			reason = "no reason given"
+		}
+		return fmt.Sprintf("not allowed to %s %s: %s", c.verb, c.resource, reason)
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	authapi "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestRequiredCapabilities(t *testing.T) {
+	configs := []api.StepConfiguration{
+		{SourceStepConfiguration: &api.SourceStepConfiguration{From: api.PipelineImageStreamTagReferenceRoot, To: api.PipelineImageStreamTagReferenceSource}},
+		{RPMServeStepConfiguration: &api.RPMServeStepConfiguration{From: api.PipelineImageStreamTagReferenceRPMs}},
+	}
+	nodeFor := func(config api.StepConfiguration) *api.StepNode {
+		switch {
+		case config.SourceStepConfiguration != nil:
+			return &api.StepNode{Step: steps.SourceStep(*config.SourceStepConfiguration, api.ResourceConfiguration{}, nil, nil, nil, nil, nil)}
+		default:
+			return &api.StepNode{Step: steps.RPMServerStep(*config.RPMServeStepConfiguration, nil, nil)}
+		}
+	}
+	for _, tc := range []struct {
+		name         string
+		stepList     api.OrderedStepList
+		hasTemplates bool
+		skipInit     bool
+		expected     []capability
+	}{{
+		name:     "nothing to run still needs a namespace",
+		expected: []capability{capabilityProjects, capabilityImageStreams},
+	}, {
+		name:     "source build needs builds",
+		stepList: api.OrderedStepList{nodeFor(configs[0])},
+		expected: []capability{capabilityProjects, capabilityImageStreams, capabilityBuilds},
+	}, {
+		name:         "rpm serving and templates",
+		stepList:     api.OrderedStepList{nodeFor(configs[1])},
+		hasTemplates: true,
+		expected:     []capability{capabilityProjects, capabilityImageStreams, capabilityRoutes, capabilityTemplates},
+	}, {
+		name:     "externally managed namespace does not need project creation",
+		stepList: api.OrderedStepList{nodeFor(configs[0])},
+		skipInit: true,
+		expected: []capability{capabilityImageStreams, capabilityBuilds},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "capabilities", requiredCapabilities(configs, tc.stepList, tc.hasTemplates, !tc.skipInit), tc.expected, cmp.AllowUnexported(capability{}))
+		})
+	}
+}
+
+func TestCheckCapabilities(t *testing.T) {
+	served := []*metav1.APIResourceList{
+		{GroupVersion: "image.openshift.io/v1", APIResources: []metav1.APIResource{{Name: "imagestreams"}}},
+		{GroupVersion: "project.openshift.io/v1", APIResources: []metav1.APIResource{{Name: "projectrequests"}}},
+		{GroupVersion: "route.openshift.io/v1", APIResources: []metav1.APIResource{{Name: "routes/status"}}},
+	}
+	for _, tc := range []struct {
+		name        string
+		required    []capability
+		allowed     bool
+		expectedErr string
+	}{{
+		name:     "all capabilities available",
+		required: []capability{capabilityProjects, capabilityImageStreams},
+		allowed:  true,
+	}, {
+		name:     "missing capabilities are reported together",
+		required: []capability{capabilityProjects, capabilityImageStreams, capabilityBuilds, capabilityRoutes},
+		expectedErr: `the cluster is missing 3 required capabilities:
+  * project creation (create projectrequests.project.openshift.io/v1): not allowed to create projectrequests: forbidden
+  * image streams (imagestreams.image.openshift.io/v1): available
+  * builds (builds.build.openshift.io/v1): API group build.openshift.io/v1 is not served
+  * routes (routes.route.openshift.io/v1): resource routes is not served by route.openshift.io/v1`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fakekubeclient.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				return true, &authapi.SelfSubjectAccessReview{Status: authapi.SubjectAccessReviewStatus{Allowed: tc.allowed, Reason: "forbidden"}}, nil
+			})
+			discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: served}}
+			err := checkCapabilities(context.Background(), discoveryClient, kubeClient.AuthorizationV1(), tc.required)
+			var actual string
+			if err != nil {
+				actual = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actual); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
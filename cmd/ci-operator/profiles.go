@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+// resolveClusterProfiles loads the cluster profile of every targeted test
+// which declares one, unless the profile was already provided explicitly
+// with --secret-dir. Profiles are read from --profile-dir, where each
+// profile is a directory named after it, or else copied from the Secret
+// (and ConfigMap, if the profile has one) in --profile-namespace.
+func (o *options) resolveClusterProfiles(ctx context.Context, client ctrlruntimeclient.Reader) error {
+	if o.profileDir == "" && o.profileNamespace == "" {
+		return nil
+	}
+	provided := sets.New[string]()
+	for _, secret := range o.secrets {
+		provided.Insert(secret.Name)
+	}
+	for _, test := range o.configSpec.Tests {
+		profile := test.GetClusterProfile()
+		name := api.ClusterProfileSecretName(test.As)
+		if profile == "" || provided.Has(name) || (len(o.targets.values) > 0 && !api.TargetsSelect(o.targets.values, test.As)) {
+			continue
+		}
+		var secret *coreapi.Secret
+		var err error
+		if o.profileDir != "" {
+			secret, err = clusterProfileFromDir(o.profileDir, profile)
+		}
+		if secret == nil && err == nil && o.profileNamespace != "" {
+			secret, err = clusterProfileFromNamespace(ctx, client, o.profileNamespace, profile)
+		}
+		if err != nil {
+			return fmt.Errorf("could not load cluster profile %s for test %s: %w", profile, test.As, err)
+		}
+		if secret == nil {
+			return fmt.Errorf("cluster profile %s for test %s was not found", profile, test.As)
+		}
+		logrus.Debugf("Loaded cluster profile %s for test %s", profile, test.As)
+		secret.Name = name
+		o.secrets = append(o.secrets, secret)
+		provided.Insert(name)
+	}
+	return nil
+}
+
+// clusterProfileFromDir loads the profile from its directory, returning nil
+// if the directory does not contain it.
+func clusterProfileFromDir(dir string, profile api.ClusterProfile) (*coreapi.Secret, error) {
+	path := filepath.Join(dir, string(profile))
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return util.SecretFromDir(path)
+}
+
+// clusterProfileFromNamespace merges the Secret and ConfigMap that make up
+// the profile in the central namespace into one Secret.
+func clusterProfileFromNamespace(ctx context.Context, client ctrlruntimeclient.Reader, namespace string, profile api.ClusterProfile) (*coreapi.Secret, error) {
+	source := &coreapi.Secret{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: profile.Secret()}, source); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get secret %s/%s: %w", namespace, profile.Secret(), err)
+	}
+	ret := &coreapi.Secret{Type: coreapi.SecretTypeOpaque, Data: map[string][]byte{}}
+	for k, v := range source.Data {
+		ret.Data[k] = v
+	}
+	if name := profile.ConfigMap(); name != "" {
+		cm := &coreapi.ConfigMap{}
+		if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, cm); err != nil {
+			return nil, fmt.Errorf("could not get config map %s/%s: %w", namespace, name, err)
+		}
+		for k, v := range cm.Data {
+			ret.Data[k] = []byte(v)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestResolveClusterProfiles(t *testing.T) {
+	profileDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(profileDir, "aws"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "aws", "credentials"), []byte("from-dir"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		&coreapi.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "cluster-secrets-gcp"},
+			Data:       map[string][]byte{"credentials": []byte("from-namespace")},
+		},
+		&coreapi.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "cluster-profile-gcp"},
+			Data:       map[string]string{"vars.yaml": "base_domain: example.com"},
+		},
+	).Build()
+	tests := []api.TestStepConfiguration{
+		{As: "e2e-aws", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{ClusterProfile: api.ClusterProfileAWS}},
+		{As: "e2e-gcp", OpenshiftInstallerClusterTestConfiguration: &api.OpenshiftInstallerClusterTestConfiguration{ClusterTestConfiguration: api.ClusterTestConfiguration{ClusterProfile: api.ClusterProfileGCP}}},
+		{As: "e2e-azure", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{ClusterProfile: api.ClusterProfileAzure4}},
+		{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+	}
+	for _, tc := range []struct {
+		name             string
+		targets          []string
+		profileDir       string
+		profileNamespace string
+		secrets          []*coreapi.Secret
+		expected         []*coreapi.Secret
+		expectedErr      bool
+	}{{
+		name:    "no sources configured",
+		targets: []string{"e2e-aws"},
+	}, {
+		name:       "profile from directory",
+		targets:    []string{"e2e-aws"},
+		profileDir: profileDir,
+		expected: []*coreapi.Secret{{
+			ObjectMeta: metav1.ObjectMeta{Name: "e2e-aws-cluster-profile"},
+			Type:       coreapi.SecretTypeOpaque,
+			Data:       map[string][]byte{"credentials": []byte("from-dir")},
+		}},
+	}, {
+		name:             "profile from namespace merges the config map",
+		targets:          []string{"e2e-gcp"},
+		profileDir:       profileDir,
+		profileNamespace: "ci",
+		expected: []*coreapi.Secret{{
+			ObjectMeta: metav1.ObjectMeta{Name: "e2e-gcp-cluster-profile"},
+			Type:       coreapi.SecretTypeOpaque,
+			Data: map[string][]byte{
+				"credentials": []byte("from-namespace"),
+				"vars.yaml":   []byte("base_domain: example.com"),
+			},
+		}},
+	}, {
+		name:       "profile passed with --secret-dir is not overridden",
+		targets:    []string{"e2e-aws"},
+		profileDir: profileDir,
+		secrets:    []*coreapi.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "e2e-aws-cluster-profile"}}},
+		expected:   []*coreapi.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "e2e-aws-cluster-profile"}}},
+	}, {
+		name:             "missing profile is an error",
+		targets:          []string{"e2e-azure"},
+		profileDir:       profileDir,
+		profileNamespace: "ci",
+		expectedErr:      true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &options{
+				configSpec:       &api.ReleaseBuildConfiguration{Tests: tests},
+				targets:          stringSlice{values: tc.targets},
+				profileDir:       tc.profileDir,
+				profileNamespace: tc.profileNamespace,
+				secrets:          tc.secrets,
+			}
+			err := o.resolveClusterProfiles(context.Background(), client)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			testhelper.Diff(t, "secrets", o.secrets, tc.expected)
+		})
+	}
+}
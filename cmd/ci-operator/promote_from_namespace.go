@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// verifyRecordedSteps reads the status the earlier execution recorded in the
+// namespace and verifies that it ran with the same inputs and revisions and
+// that every step of the graph succeeded, as the promotion requires, so the
+// images in the pipeline image stream are the ones the job built and tested.
+func verifyRecordedSteps(ctx context.Context, client ctrlruntimeclient.Reader, namespace string, jobSpec *api.JobSpec, stepList api.OrderedStepList) (*steps.ExecutionStatus, error) {
+	status, err := steps.ReadExecutionStatus(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if status.InputHash != jobSpec.InputHash() {
+		return nil, fmt.Errorf("the earlier execution ran with the input hash %q, not %q", status.InputHash, jobSpec.InputHash())
+	}
+	if revisions := steps.Revisions(jobSpec); !reflect.DeepEqual(status.Revisions, revisions) {
+		return nil, fmt.Errorf("the earlier execution tested the revisions %s, not %s", formatRevisions(status.Revisions), formatRevisions(revisions))
+	}
+	if unsucceeded := status.Unsucceeded(sets.New[string](nodeNames(stepList)...)); len(unsucceeded) != 0 {
+		return nil, fmt.Errorf("the earlier execution did not complete every step: %s", strings.Join(unsucceeded, ", "))
+	}
+	return status, nil
+}
+
+func formatRevisions(revisions []string) string {
+	if len(revisions) == 0 {
+		return "(none)"
+	}
+	return strings.Join(revisions, ", ")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestVerifyRecordedSteps(t *testing.T) {
+	stepList := api.OrderedStepList{
+		{Step: &fakeValidationStep{name: "src"}},
+		{Step: &fakeValidationStep{name: "bin"}},
+		{Step: &fakeValidationStep{name: "unit"}},
+	}
+	jobSpec := &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseSHA: "base", Pulls: []prowapi.Pull{{Number: 1, SHA: "head"}}}}}
+	jobSpec.SetInputHash("1234")
+	revisions := []string{"org/repo@base", "org/repo#1@head"}
+	succeeded := []steps.StepCondition{
+		{Name: "src", Phase: steps.StepPhaseSucceeded},
+		{Name: "bin", Phase: steps.StepPhaseSucceeded},
+		{Name: "unit", Phase: steps.StepPhaseSucceeded},
+		{Name: "[output-images]", Phase: steps.StepPhaseSucceeded},
+	}
+	var testCases = []struct {
+		name        string
+		recorded    steps.ExecutionStatus
+		expectedErr error
+	}{
+		{
+			name:     "every step succeeded",
+			recorded: steps.ExecutionStatus{InputHash: "1234", Revisions: revisions, Conditions: succeeded},
+		},
+		{
+			name: "steps failed or did not run",
+			recorded: steps.ExecutionStatus{InputHash: "1234", Revisions: revisions, Conditions: []steps.StepCondition{
+				{Name: "src", Phase: steps.StepPhaseSucceeded},
+				{Name: "unit", Phase: steps.StepPhaseFailed},
+			}},
+			expectedErr: errors.New("the earlier execution did not complete every step: bin (not recorded), unit (Failed)"),
+		},
+		{
+			name:        "inputs differ",
+			recorded:    steps.ExecutionStatus{InputHash: "5678", Revisions: revisions, Conditions: succeeded},
+			expectedErr: errors.New(`the earlier execution ran with the input hash "5678", not "1234"`),
+		},
+		{
+			name:        "inputs not recorded",
+			recorded:    steps.ExecutionStatus{Conditions: succeeded},
+			expectedErr: errors.New(`the earlier execution ran with the input hash "", not "1234"`),
+		},
+		{
+			name:        "revisions differ",
+			recorded:    steps.ExecutionStatus{InputHash: "1234", Revisions: []string{"org/repo@base", "org/repo#1@old"}, Conditions: succeeded},
+			expectedErr: errors.New("the earlier execution tested the revisions org/repo@base, org/repo#1@old, not org/repo@base, org/repo#1@head"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := json.Marshal(tc.recorded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.ConfigMap{
+				ObjectMeta: meta.ObjectMeta{Namespace: "ci-op-1234", Name: steps.StatusConfigMapName},
+				Data:       map[string]string{steps.StatusConfigMapKey: string(raw)},
+			}).Build()
+			status, err := verifyRecordedSteps(context.Background(), client, "ci-op-1234", jobSpec, stepList)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err == nil {
+				testhelper.Diff(t, "status", status.Conditions, tc.recorded.Conditions)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// parseSecretReference parses a <namespace>/<name> reference to a secret.
+func parseSecretReference(ref string) (ctrlruntimeclient.ObjectKey, error) {
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return ctrlruntimeclient.ObjectKey{}, fmt.Errorf("expected <namespace>/<name>, got %q", ref)
+	}
+	return ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, nil
+}
+
+// provisionPullSecret copies the central pull secret from --pull-secret-from
+// into the test namespace and lets the builder and default service accounts
+// pull with it. Template steps add it to the service accounts they create.
+func (o *options) provisionPullSecret(ctx context.Context, client ctrlruntimeclient.Client) error {
+	if o.pullSecretFrom == "" {
+		return nil
+	}
+	key, err := parseSecretReference(o.pullSecretFrom)
+	if err != nil {
+		return err
+	}
+	central := &coreapi.Secret{}
+	if err := client.Get(ctx, key, central); err != nil {
+		return fmt.Errorf("could not get the central pull secret %s: %w", key, err)
+	}
+	if central.Type != coreapi.SecretTypeDockerConfigJson && central.Type != coreapi.SecretTypeDockercfg {
+		return fmt.Errorf("the central pull secret %s must be of type %s or %s, not %s", key, coreapi.SecretTypeDockerConfigJson, coreapi.SecretTypeDockercfg, central.Type)
+	}
+	secret := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: o.namespace, Name: api.PullRateLimitBypassSecret},
+		Type:       central.Type,
+		Data:       central.Data,
+		Immutable:  utilpointer.Bool(true),
+	}
+	if err := client.Create(ctx, secret); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create the pull secret: %w", err)
+	}
+	for _, name := range []string{"builder", "default"} {
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			sa := &coreapi.ServiceAccount{}
+			if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: o.namespace, Name: name}, sa); err != nil {
+				return err
+			}
+			if !steps.AddPullSecret(sa, api.PullRateLimitBypassSecret) {
+				return nil
+			}
+			return client.Update(ctx, sa)
+		}); err != nil {
+			return fmt.Errorf("could not add the pull secret to service account %s: %w", name, err)
+		}
+	}
+	logrus.Debugf("Provisioned the pull secret from %s.", key)
+	return nil
+}
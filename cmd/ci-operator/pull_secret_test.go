@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestParseSecretReference(t *testing.T) {
+	for ref, valid := range map[string]bool{
+		"ci/pull-secret": true,
+		"pull-secret":    false,
+		"ci/":            false,
+		"/pull-secret":   false,
+		"ci/pull/secret": false,
+	} {
+		if _, err := parseSecretReference(ref); (err == nil) != valid {
+			t.Errorf("%s: expected valid=%t, got error %v", ref, valid, err)
+		}
+	}
+}
+
+func TestProvisionPullSecret(t *testing.T) {
+	central := &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "pull-secret"},
+		Type:       coreapi.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{coreapi.DockerConfigJsonKey: []byte("{}")},
+	}
+	builder := &coreapi.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "builder"}}
+	defaultSA := &coreapi.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "default"}}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(central, builder, defaultSA).Build()
+	o := &options{namespace: "test", pullSecretFrom: "ci/pull-secret"}
+	if err := o.provisionPullSecret(context.Background(), client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret := &coreapi.Secret{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "test", Name: api.PullRateLimitBypassSecret}, secret); err != nil {
+		t.Fatalf("expected the pull secret to be copied: %v", err)
+	}
+	testhelper.Diff(t, "data", secret.Data, central.Data)
+	for _, name := range []string{"builder", "default"} {
+		sa := &coreapi.ServiceAccount{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "test", Name: name}, sa); err != nil {
+			t.Fatalf("could not get service account %s: %v", name, err)
+		}
+		testhelper.Diff(t, name+" image pull secrets", sa.ImagePullSecrets, []coreapi.LocalObjectReference{{Name: api.PullRateLimitBypassSecret}})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// loadResourceProfile reads the resources block of --resource-profile, which
+// has the form of the resources block of the configuration.
+func loadResourceProfile(path string) (api.ResourceConfiguration, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read resource profile: %w", err)
+	}
+	var profile api.ResourceConfiguration
+	if err := yaml.UnmarshalStrict(raw, &profile); err != nil {
+		return nil, fmt.Errorf("could not parse resource profile: %w", err)
+	}
+	return profile, nil
+}
+
+// applyResourceProfile returns the resources of the configuration with the
+// requirements of the profile for each step and resource the configuration
+// sets none for. The configuration is considered per step, with the defaults
+// it sets for every step: when the configuration sets either the request or
+// the limit of a resource for a step, the profile is ignored for that
+// resource so that the two cannot conflict. Profile requests above the limit
+// and profile limits below the request that a step ends up with are skipped
+// as well, as the pods of the step could not be created otherwise.
+func applyResourceProfile(resources, profile api.ResourceConfiguration) api.ResourceConfiguration {
+	merged := api.ResourceConfiguration{}
+	for name, requirements := range resources {
+		merged[name] = api.ResourceRequirements{
+			Requests: copyResourceList(requirements.Requests),
+			Limits:   copyResourceList(requirements.Limits),
+		}
+	}
+	names := make([]string, 0, len(profile))
+	for name := range profile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		requirements := merged[name]
+		for resourceName, quantity := range profile[name].Requests {
+			if setsResource(resources.RequirementsForStep(name), resourceName) {
+				continue
+			}
+			var limits []string
+			for _, step := range affectedSteps(resources, name) {
+				limits = append(limits, resources.RequirementsForStep(step).Limits[resourceName])
+			}
+			if crosses(quantity, limits, 1) {
+				logrus.Warnf("Ignoring the %s request of %s in the resource profile for %s, which is above the limit of the configuration.", resourceName, quantity, name)
+				continue
+			}
+			requirements.Requests = withResource(requirements.Requests, resourceName, quantity)
+		}
+		setRequirements(merged, name, requirements)
+	}
+	for _, name := range names {
+		requirements := merged[name]
+		for resourceName, quantity := range profile[name].Limits {
+			if setsResource(resources.RequirementsForStep(name), resourceName) {
+				continue
+			}
+			var requests []string
+			for _, step := range affectedSteps(merged, name) {
+				requests = append(requests, merged.RequirementsForStep(step).Requests[resourceName])
+			}
+			if crosses(quantity, requests, -1) {
+				logrus.Warnf("Ignoring the %s limit of %s in the resource profile for %s, which is below the request of the step.", resourceName, quantity, name)
+				continue
+			}
+			requirements.Limits = withResource(requirements.Limits, resourceName, quantity)
+		}
+		setRequirements(merged, name, requirements)
+	}
+	return merged
+}
+
+// setRequirements records the requirements of the step, unless they are
+// empty and the step had none.
+func setRequirements(resources api.ResourceConfiguration, name string, requirements api.ResourceRequirements) {
+	if _, set := resources[name]; set || len(requirements.Requests) != 0 || len(requirements.Limits) != 0 {
+		resources[name] = requirements
+	}
+}
+
+// affectedSteps are the steps of the configuration the requirements for the
+// name apply to: the step itself, or every step for the defaults.
+func affectedSteps(resources api.ResourceConfiguration, name string) []string {
+	if name != "*" {
+		return []string{name}
+	}
+	steps := []string{"*"}
+	for step := range resources {
+		if step != "*" {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// crosses determines whether the quantity is on the given side of any of the
+// bounds, 1 for above and -1 for below. Unset and invalid quantities are not
+// considered, as the validation of the configuration reports them.
+func crosses(quantity string, bounds []string, side int) bool {
+	value, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return false
+	}
+	for _, raw := range bounds {
+		if raw == "" {
+			continue
+		}
+		bound, err := resource.ParseQuantity(raw)
+		if err != nil {
+			continue
+		}
+		if value.Cmp(bound) == side {
+			return true
+		}
+	}
+	return false
+}
+
+func setsResource(requirements api.ResourceRequirements, resource string) bool {
+	_, requested := requirements.Requests[resource]
+	_, limited := requirements.Limits[resource]
+	return requested || limited
+}
+
+func withResource(list api.ResourceList, resource, quantity string) api.ResourceList {
+	if list == nil {
+		list = api.ResourceList{}
+	}
+	list[resource] = quantity
+	return list
+}
+
+func copyResourceList(list api.ResourceList) api.ResourceList {
+	if list == nil {
+		return nil
+	}
+	ret := api.ResourceList{}
+	for resource, quantity := range list {
+		ret[resource] = quantity
+	}
+	return ret
+}
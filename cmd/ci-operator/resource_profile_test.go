@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestApplyResourceProfile(t *testing.T) {
+	var testCases = []struct {
+		name      string
+		resources api.ResourceConfiguration
+		profile   api.ResourceConfiguration
+		expected  api.ResourceConfiguration
+	}{
+		{
+			name:      "no profile",
+			resources: api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "100m"}}},
+			expected:  api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "100m"}}},
+		},
+		{
+			name:     "profile only",
+			profile:  api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "1"}, Limits: api.ResourceList{"memory": "4Gi"}}},
+			expected: api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "1"}, Limits: api.ResourceList{"memory": "4Gi"}}},
+		},
+		{
+			name: "configuration wins for the resources it sets",
+			resources: api.ResourceConfiguration{
+				"*":   {Requests: api.ResourceList{"cpu": "100m", "memory": "8Gi"}},
+				"src": {Limits: api.ResourceList{"cpu": "2"}},
+			},
+			profile: api.ResourceConfiguration{
+				"*":    {Requests: api.ResourceList{"cpu": "1", "memory": "1Gi"}, Limits: api.ResourceList{"memory": "4Gi"}},
+				"src":  {Requests: api.ResourceList{"cpu": "1", "memory": "2Gi"}},
+				"unit": {Requests: api.ResourceList{"memory": "6Gi"}},
+			},
+			expected: api.ResourceConfiguration{
+				"*":   {Requests: api.ResourceList{"cpu": "100m", "memory": "8Gi"}},
+				"src": {Limits: api.ResourceList{"cpu": "2"}},
+			},
+		},
+		{
+			name: "profile applies to the resources a step sets none for",
+			resources: api.ResourceConfiguration{
+				"*":   {Requests: api.ResourceList{"cpu": "100m"}},
+				"src": {Limits: api.ResourceList{"cpu": "2"}},
+			},
+			profile: api.ResourceConfiguration{
+				"src":  {Requests: api.ResourceList{"cpu": "1", "memory": "2Gi"}},
+				"unit": {Requests: api.ResourceList{"memory": "6Gi"}},
+			},
+			expected: api.ResourceConfiguration{
+				"*":    {Requests: api.ResourceList{"cpu": "100m"}},
+				"src":  {Requests: api.ResourceList{"memory": "2Gi"}, Limits: api.ResourceList{"cpu": "2"}},
+				"unit": {Requests: api.ResourceList{"memory": "6Gi"}},
+			},
+		},
+		{
+			name: "profile requests above limits and limits below requests are skipped",
+			resources: api.ResourceConfiguration{
+				"src":  {Limits: api.ResourceList{"cpu": "500m"}},
+				"unit": {Requests: api.ResourceList{"memory": "8Gi"}},
+			},
+			profile: api.ResourceConfiguration{
+				"*":   {Requests: api.ResourceList{"cpu": "1", "memory": "1Gi"}, Limits: api.ResourceList{"memory": "4Gi"}},
+				"bin": {Requests: api.ResourceList{"memory": "2Gi"}, Limits: api.ResourceList{"memory": "1Gi"}},
+			},
+			expected: api.ResourceConfiguration{
+				"*":    {Requests: api.ResourceList{"memory": "1Gi"}},
+				"bin":  {Requests: api.ResourceList{"memory": "2Gi"}},
+				"src":  {Limits: api.ResourceList{"cpu": "500m"}},
+				"unit": {Requests: api.ResourceList{"memory": "8Gi"}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "resources", applyResourceProfile(tc.resources, tc.profile), tc.expected)
+		})
+	}
+}
+
+func TestLoadResourceProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	if err := os.WriteFile(path, []byte("'*':\n  requests:\n    cpu: 500m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := loadResourceProfile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "profile", profile, api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "500m"}}})
+}
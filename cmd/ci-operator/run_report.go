@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/defaults"
+	"github.com/openshift/ci-tools/pkg/secrets"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// runReportFilename is the artifact summarizing the execution for the people
+// browsing the artifacts of a job, who cannot be expected to read JUnit.
+const runReportFilename = "index.html"
+
+const (
+	stepResultSucceeded   = "succeeded"
+	stepResultFailed      = "failed"
+	stepResultInterrupted = "interrupted"
+	stepResultNotRun      = "not run"
+)
+
+type runReport struct {
+	Job        string
+	Namespace  string
+	Failed     bool
+	Steps      []reportStep
+	Parameters []reportParameter
+}
+
+type reportStep struct {
+	Name        string
+	Description string
+	Result      string
+	StartedAt   string
+	Duration    string
+	Links       []reportLink
+	Substeps    []reportStep
+}
+
+type reportLink struct {
+	Name string
+	URL  string
+}
+
+type reportParameter struct {
+	Name        string
+	Value       string
+	Resolved    bool
+	ProvidedBy  string
+	Description string
+}
+
+// saveRunReport saves the report of the steps in the graph, in the order they
+// ran, and of the parameters that were resolved during the execution. The
+// values of the parameters that contain a secret are redacted as a whole.
+// The run failed when it returned errors, even if no step did.
+func (o *options) saveRunReport(graph api.CIOperatorStepGraph, stepList api.OrderedStepList, postSteps []api.Step, params *api.DeferredParameters, errs []error) error {
+	artifactDir, ok := api.Artifacts()
+	if !ok {
+		return nil
+	}
+	var all []api.Step
+	for _, node := range stepList {
+		all = append(all, node.Step)
+	}
+	documented := steps.DocumentParameters(defaults.JobParameters(o.jobSpec), append(all, postSteps...))
+	report := newRunReport(o.jobSpec, o.namespace, graph, documented, params.Resolved(), errs, o.censor, artifactDir)
+	var buf bytes.Buffer
+	if err := writeRunReport(&buf, report); err != nil {
+		return err
+	}
+	return api.SaveArtifact(o.censor, runReportFilename, buf.Bytes())
+}
+
+func newRunReport(jobSpec *api.JobSpec, namespace string, graph api.CIOperatorStepGraph, documented []steps.DocumentedParameter, resolved map[string]string, errs []error, censor *secrets.DynamicCensor, artifactDir string) runReport {
+	report := runReport{Job: jobSpec.Job, Namespace: namespace, Failed: len(errs) != 0}
+	for _, details := range graph {
+		step := newReportStep(details.CIOperatorStepDetailInfo, jobSpec, artifactDir)
+		for _, substep := range details.Substeps {
+			step.Substeps = append(step.Substeps, newReportStep(substep, jobSpec, artifactDir))
+		}
+		if step.Result == stepResultFailed {
+			report.Failed = true
+		}
+		report.Steps = append(report.Steps, step)
+	}
+	seen := map[string]bool{}
+	for _, parameter := range documented {
+		value, ok := resolved[parameter.Name]
+		report.Parameters = append(report.Parameters, reportParameter{
+			Name:        parameter.Name,
+			Value:       steps.RedactParameterValue(censor, value),
+			Resolved:    ok,
+			ProvidedBy:  parameter.ProvidedBy,
+			Description: parameter.Description,
+		})
+		seen[parameter.Name] = true
+	}
+	// parameters given as input are not provided by any step
+	var inputs []string
+	for name := range resolved {
+		if !seen[name] {
+			inputs = append(inputs, name)
+		}
+	}
+	sort.Strings(inputs)
+	for _, name := range inputs {
+		report.Parameters = append(report.Parameters, reportParameter{Name: name, Value: steps.RedactParameterValue(censor, resolved[name]), Resolved: true})
+	}
+	return report
+}
+
+func newReportStep(info api.CIOperatorStepDetailInfo, jobSpec *api.JobSpec, artifactDir string) reportStep {
+	step := reportStep{Name: info.StepName, Description: info.Description}
+	switch {
+	case info.Failed != nil && *info.Failed:
+		step.Result = stepResultFailed
+	case info.FinishedAt != nil:
+		step.Result = stepResultSucceeded
+	case info.StartedAt != nil:
+		step.Result = stepResultInterrupted
+	default:
+		step.Result = stepResultNotRun
+	}
+	if info.StartedAt != nil {
+		step.StartedAt = info.StartedAt.UTC().Format(time.RFC3339)
+	}
+	if info.Duration != nil {
+		step.Duration = info.Duration.Truncate(time.Second).String()
+	}
+	if info.LogURL != "" {
+		step.Links = append(step.Links, reportLink{Name: "log", URL: info.LogURL})
+	}
+	if dir := jobSpec.AttemptArtifactDir(info.StepName); isDir(filepath.Join(artifactDir, dir)) {
+		step.Links = append(step.Links, reportLink{Name: "artifacts", URL: relativeURL(dir) + "/"})
+	}
+	if buildLog := path.Join("build-logs", info.StepName+".log.gz"); exists(filepath.Join(artifactDir, buildLog)) {
+		step.Links = append(step.Links, reportLink{Name: "build log", URL: relativeURL(buildLog)})
+	}
+	return step
+}
+
+func relativeURL(p string) string {
+	return (&url.URL{Path: p}).String()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func writeRunReport(w io.Writer, report runReport) error {
+	if err := runReportTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("could not render the report: %w", err)
+	}
+	return nil
+}
+
+var runReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>{{ .Job }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+.succeeded { color: #2e7d32; }
+.failed { color: #c62828; font-weight: bold; }
+.interrupted, .not-run, .unresolved { color: #757575; }
+.substep td:first-child { padding-left: 2em; }
+</style>
+</head>
+<body>
+<h1>{{ .Job }} {{ if .Failed }}<span class="failed">failed</span>{{ else }}<span class="succeeded">succeeded</span>{{ end }}</h1>
+<p>Namespace: {{ .Namespace }}</p>
+<h2>Steps</h2>
+<table>
+<tr><th>Step</th><th>Result</th><th>Started</th><th>Duration</th><th>Links</th></tr>
+{{- range .Steps }}
+{{ template "step" . }}
+{{- range .Substeps }}
+{{ template "substep" . }}
+{{- end }}
+{{- end }}
+</table>
+<h2>Parameters</h2>
+<table>
+<tr><th>Name</th><th>Value</th><th>Provided by</th><th>Description</th></tr>
+{{- range .Parameters }}
+<tr><td>{{ .Name }}</td><td>{{ if .Resolved }}<code>{{ .Value }}</code>{{ else }}<span class="unresolved">not resolved</span>{{ end }}</td><td>{{ .ProvidedBy }}</td><td>{{ .Description }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+{{ define "cells" }}<td>{{ .Name }}{{ with .Description }}<br><small>{{ . }}</small>{{ end }}</td><td class="{{ if eq .Result "not run" }}not-run{{ else }}{{ .Result }}{{ end }}">{{ .Result }}</td><td>{{ .StartedAt }}</td><td>{{ .Duration }}</td><td>{{ range $i, $link := .Links }}{{ if $i }} {{ end }}<a href="{{ $link.URL }}">{{ $link.Name }}</a>{{ end }}</td>{{ end }}
+{{- define "step" }}<tr>{{ template "cells" . }}</tr>{{ end }}
+{{- define "substep" }}<tr class="substep">{{ template "cells" . }}</tr>{{ end }}
+`))
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+// secretDir describes a secret created from a directory with --secret-dir.
+// The flag takes either the path of the directory, or comma-separated
+// options like path=/creds,name=pull-secret,type=kubernetes.io/dockerconfigjson.
+type secretDir struct {
+	// path is the directory holding the files of the secret
+	path string
+	// name is the name of the secret, the base name of the directory by default
+	name string
+	// secretType is the type of the secret, detected from the files by default
+	secretType coreapi.SecretType
+	// keys are the only files included in the secret, if set
+	keys []string
+	// recursive includes the files of nested directories
+	recursive bool
+}
+
+var secretDirOptions = []string{"path", "name", "type", "key", "recursive"}
+
+// isSecretDirOptions determines whether the value of --secret-dir holds
+// options rather than a plain path.
+func isSecretDirOptions(value string) bool {
+	for _, option := range secretDirOptions {
+		if strings.HasPrefix(value, option+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSecretDir(value string) (secretDir, error) {
+	if !isSecretDirOptions(value) {
+		return secretDir{path: value, name: filepath.Base(value)}, nil
+	}
+	var ret secretDir
+	for _, option := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(option, "=")
+		if !found {
+			return secretDir{}, fmt.Errorf("expected key=value, got %q", option)
+		}
+		switch key {
+		case "path":
+			ret.path = val
+		case "name":
+			ret.name = val
+		case "type":
+			ret.secretType = coreapi.SecretType(val)
+		case "key":
+			ret.keys = append(ret.keys, val)
+		case "recursive":
+			recursive, err := strconv.ParseBool(val)
+			if err != nil {
+				return secretDir{}, fmt.Errorf("invalid value for recursive: %w", err)
+			}
+			ret.recursive = recursive
+		default:
+			return secretDir{}, fmt.Errorf("unknown option %q, expected one of %s", key, strings.Join(secretDirOptions, ", "))
+		}
+	}
+	if ret.path == "" {
+		return secretDir{}, fmt.Errorf("the path option is required")
+	}
+	if ret.name == "" {
+		ret.name = filepath.Base(ret.path)
+	}
+	if errs := validation.IsDNS1123Subdomain(ret.name); len(errs) != 0 {
+		return secretDir{}, fmt.Errorf("%q is not a valid secret name: %s", ret.name, strings.Join(errs, ", "))
+	}
+	return ret, nil
+}
+
+// secretKeysByType are the keys secrets of a type must hold.
+var secretKeysByType = map[coreapi.SecretType]string{
+	coreapi.SecretTypeDockerConfigJson: coreapi.DockerConfigJsonKey,
+	coreapi.SecretTypeDockercfg:        coreapi.DockerConfigKey,
+	coreapi.SecretTypeSSHAuth:          coreapi.SSHAuthPrivateKey,
+	coreapi.SecretTypeTLS:              coreapi.TLSCertKey,
+}
+
+// secret reads the files of the directory into a secret.
+func (d secretDir) secret() (*coreapi.Secret, error) {
+	load := util.SecretFromDir
+	if d.recursive {
+		load = util.SecretFromDirTree
+	}
+	secret, err := load(d.path)
+	if err != nil {
+		return nil, err
+	}
+	secret.Name = d.name
+	if len(d.keys) != 0 {
+		data := map[string][]byte{}
+		var missing []string
+		for _, key := range d.keys {
+			value, ok := secret.Data[key]
+			if !ok {
+				missing = append(missing, key)
+				continue
+			}
+			data[key] = value
+		}
+		if len(missing) != 0 {
+			sort.Strings(missing)
+			return nil, fmt.Errorf("%s does not hold the keys %s", d.path, strings.Join(missing, ", "))
+		}
+		secret.Data = data
+	}
+	if d.secretType == "" {
+		if len(secret.Data) == 1 {
+			if _, ok := secret.Data[coreapi.DockerConfigJsonKey]; ok {
+				secret.Type = coreapi.SecretTypeDockerConfigJson
+			}
+			if _, ok := secret.Data[coreapi.DockerConfigKey]; ok {
+				secret.Type = coreapi.SecretTypeDockercfg
+			}
+		}
+		return secret, nil
+	}
+	secret.Type = d.secretType
+	if key, ok := secretKeysByType[d.secretType]; ok {
+		if _, ok := secret.Data[key]; !ok {
+			return nil, fmt.Errorf("a secret of type %s must hold the key %s", d.secretType, key)
+		}
+	}
+	return secret, nil
+}
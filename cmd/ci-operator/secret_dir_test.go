@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+)
+
+func TestParseSecretDir(t *testing.T) {
+	for _, tc := range []struct {
+		value       string
+		expected    secretDir
+		expectedErr bool
+	}{{
+		value:    "/secrets/gcs",
+		expected: secretDir{path: "/secrets/gcs", name: "gcs"},
+	}, {
+		value:    "/secrets/name=odd",
+		expected: secretDir{path: "/secrets/name=odd", name: "name=odd"},
+	}, {
+		value:    "path=/creds,name=pull-secret,type=kubernetes.io/dockerconfigjson",
+		expected: secretDir{path: "/creds", name: "pull-secret", secretType: coreapi.SecretTypeDockerConfigJson},
+	}, {
+		value:    "path=/creds,key=token,key=ca.crt,recursive=true",
+		expected: secretDir{path: "/creds", name: "creds", keys: []string{"token", "ca.crt"}, recursive: true},
+	}, {
+		value:       "name=pull-secret",
+		expectedErr: true,
+	}, {
+		value:       "path=/creds,mode=0600",
+		expectedErr: true,
+	}, {
+		value:       "path=/creds,name=Not_Valid",
+		expectedErr: true,
+	}, {
+		value:       "path=/creds,recursive=maybe",
+		expectedErr: true,
+	}} {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := parseSecretDir(tc.value)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(secretDir{})); diff != "" {
+				t.Errorf("unexpected secret dir: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSecretDirSecret(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		".dockerconfigjson": "{}",
+		"token":             "secret",
+		"nested/ca.crt":     "cert",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name         string
+		dir          secretDir
+		expectedType coreapi.SecretType
+		expectedData map[string][]byte
+		expectedErr  bool
+	}{{
+		name:         "whole directory",
+		dir:          secretDir{path: dir, name: "creds"},
+		expectedType: coreapi.SecretTypeOpaque,
+		expectedData: map[string][]byte{".dockerconfigjson": []byte("{}"), "token": []byte("secret")},
+	}, {
+		name:         "nested directories",
+		dir:          secretDir{path: dir, name: "creds", recursive: true},
+		expectedType: coreapi.SecretTypeOpaque,
+		expectedData: map[string][]byte{".dockerconfigjson": []byte("{}"), "token": []byte("secret"), "nested.ca.crt": []byte("cert")},
+	}, {
+		name:         "single pull secret key is detected",
+		dir:          secretDir{path: dir, name: "creds", keys: []string{".dockerconfigjson"}},
+		expectedType: coreapi.SecretTypeDockerConfigJson,
+		expectedData: map[string][]byte{".dockerconfigjson": []byte("{}")},
+	}, {
+		name:         "explicit type",
+		dir:          secretDir{path: dir, name: "creds", keys: []string{".dockerconfigjson", "token"}, secretType: coreapi.SecretTypeDockerConfigJson},
+		expectedType: coreapi.SecretTypeDockerConfigJson,
+		expectedData: map[string][]byte{".dockerconfigjson": []byte("{}"), "token": []byte("secret")},
+	}, {
+		name:        "missing key",
+		dir:         secretDir{path: dir, name: "creds", keys: []string{"password"}},
+		expectedErr: true,
+	}, {
+		name:        "type without its key",
+		dir:         secretDir{path: dir, name: "creds", keys: []string{"token"}, secretType: coreapi.SecretTypeDockerConfigJson},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			secret, err := tc.dir.secret()
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if secret.Name != tc.dir.name {
+				t.Errorf("expected name %s, got %s", tc.dir.name, secret.Name)
+			}
+			if secret.Type != tc.expectedType {
+				t.Errorf("expected type %s, got %s", tc.expectedType, secret.Type)
+			}
+			if diff := cmp.Diff(tc.expectedData, secret.Data); diff != "" {
+				t.Errorf("unexpected data: %s", diff)
+			}
+		})
+	}
+}
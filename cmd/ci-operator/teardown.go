@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// teardown cancels the builds and deletes the pods the interrupted steps
+// left running and, with --delete-namespace-on-interrupt, the namespace.
+// It takes at most --termination-grace-period, so that we still exit before
+// the process is killed.
+func (o *options) teardown(client ctrlruntimeclient.Client) {
+	if o.terminationGracePeriod == 0 {
+		return
+	}
+	logrus.Infof("Tearing down the steps that are still running, for at most %s.", o.terminationGracePeriod)
+	ctx, cancel := context.WithTimeout(context.Background(), o.terminationGracePeriod)
+	defer cancel()
+	if err := steps.Teardown(ctx, client, o.jobSpec); err != nil {
+		logrus.WithError(err).Warn("Could not tear down the steps that are still running.")
+	}
+	if !o.deleteNamespaceOnInterrupt {
+		return
+	}
+	logrus.Infof("Deleting namespace %s.", o.namespace)
+	if err := client.Delete(ctx, &coreapi.Namespace{ObjectMeta: meta.ObjectMeta{Name: o.namespace}}); err != nil && !kerrors.IsNotFound(err) {
+		logrus.WithError(err).Warnf("Could not delete namespace %s.", o.namespace)
+	}
+}
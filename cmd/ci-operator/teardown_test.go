@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestTeardown(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		gracePeriod     time.Duration
+		deleteNamespace bool
+		expectedDeleted bool
+	}{{
+		name:        "pods are deleted but the namespace is kept by default",
+		gracePeriod: time.Minute,
+	}, {
+		name:            "namespace is deleted when requested",
+		gracePeriod:     time.Minute,
+		deleteNamespace: true,
+		expectedDeleted: true,
+	}, {
+		name:            "nothing is torn down without a grace period",
+		deleteNamespace: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobSpec := &api.JobSpec{}
+			jobSpec.SetNamespace("ns")
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+				&coreapi.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ns"}},
+				&coreapi.Pod{
+					ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "test"},
+					Spec:       coreapi.PodSpec{RestartPolicy: coreapi.RestartPolicyNever},
+					Status:     coreapi.PodStatus{Phase: coreapi.PodRunning},
+				},
+			).Build()
+			o := &options{
+				jobSpec:                    jobSpec,
+				namespace:                  "ns",
+				terminationGracePeriod:     tc.gracePeriod,
+				deleteNamespaceOnInterrupt: tc.deleteNamespace,
+			}
+			o.teardown(client)
+
+			err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Name: "ns"}, &coreapi.Namespace{})
+			if deleted := kerrors.IsNotFound(err); deleted != tc.expectedDeleted {
+				t.Errorf("expected the namespace to be deleted: %t, got error %v", tc.expectedDeleted, err)
+			}
+			err = client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "test"}, &coreapi.Pod{})
+			if deleted := kerrors.IsNotFound(err); deleted != (tc.gracePeriod > 0) {
+				t.Errorf("expected the pod to be deleted: %t, got error %v", tc.gracePeriod > 0, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	templateapi "github.com/openshift/api/template/v1"
+)
+
+// parseTemplatePath splits a --template value of the form path[:alias]. The
+// alias names the template instead of its own name, so the same template can
+// be executed more than once.
+func parseTemplatePath(value string) (string, string, error) {
+	path, alias, found := strings.Cut(value, ":")
+	if !found {
+		return value, "", nil
+	}
+	if path == "" {
+		return "", "", errors.New("the path of the template is empty")
+	}
+	if errs := validation.IsDNS1123Label(alias); len(errs) != 0 {
+		return "", "", fmt.Errorf("invalid alias %q: %s", alias, strings.Join(errs, ", "))
+	}
+	return path, alias, nil
+}
+
+// templateParameters are the values --template-param gives to the
+// parameters of each template, by the name of the template.
+type templateParameters map[string]map[string]string
+
+// add parses a --template-param value of the form name:PARAM=value.
+func (p templateParameters) add(value string) error {
+	name, parameter, found := strings.Cut(value, ":")
+	if !found || name == "" {
+		return fmt.Errorf("%q must be of the form template:PARAMETER=value", value)
+	}
+	key, parameterValue, found := strings.Cut(parameter, "=")
+	if !found || key == "" {
+		return fmt.Errorf("%q must be of the form template:PARAMETER=value", value)
+	}
+	if p[name] == nil {
+		p[name] = map[string]string{}
+	}
+	p[name][key] = parameterValue
+	return nil
+}
+
+func hasTemplate(templates []*templateapi.Template, name string) bool {
+	for _, template := range templates {
+		if template.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTemplatePath(t *testing.T) {
+	for _, tc := range []struct {
+		value         string
+		expectedPath  string
+		expectedAlias string
+		expectedErr   bool
+	}{
+		{value: "templates/e2e.yaml", expectedPath: "templates/e2e.yaml"},
+		{value: "templates/e2e.yaml:e2e-gcp", expectedPath: "templates/e2e.yaml", expectedAlias: "e2e-gcp"},
+		{value: "templates/e2e.yaml:E2E_GCP", expectedErr: true},
+		{value: ":e2e-gcp", expectedErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			path, alias, err := parseTemplatePath(tc.value)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if path != tc.expectedPath || alias != tc.expectedAlias {
+				t.Errorf("expected %s and %s, got %s and %s", tc.expectedPath, tc.expectedAlias, path, alias)
+			}
+		})
+	}
+}
+
+func TestTemplateParametersAdd(t *testing.T) {
+	parameters := templateParameters{}
+	for _, value := range []string{"e2e-aws:CLUSTER_TYPE=aws", "e2e-gcp:CLUSTER_TYPE=gcp", "e2e-gcp:ARGS=a=b"} {
+		if err := parameters.add(value); err != nil {
+			t.Fatalf("unexpected error for %s: %v", value, err)
+		}
+	}
+	expected := templateParameters{
+		"e2e-aws": {"CLUSTER_TYPE": "aws"},
+		"e2e-gcp": {"CLUSTER_TYPE": "gcp", "ARGS": "a=b"},
+	}
+	if diff := cmp.Diff(expected, parameters); diff != "" {
+		t.Errorf("unexpected parameters: %s", diff)
+	}
+	for _, value := range []string{"CLUSTER_TYPE=aws", "e2e-aws:=aws", "e2e-aws:CLUSTER_TYPE"} {
+		if err := parameters.add(value); err == nil {
+			t.Errorf("expected an error for %s", value)
+		}
+	}
+}
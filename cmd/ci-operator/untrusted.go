@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// untrustedLabel marks the namespaces of untrusted jobs, both as a label, so
+// network policies can select them, and as an annotation.
+var untrustedLabel = fmt.Sprintf("%s/untrusted", steps.CiAnnotationPrefix)
+
+// defaultUntrustedCeilings limit the resources any step of an untrusted job
+// can request, unless overridden with --untrusted-max-resource.
+var defaultUntrustedCeilings = map[string]string{
+	"cpu":    "4",
+	"memory": "16Gi",
+}
+
+// untrustedCeilings parses the resource ceilings from name=quantity pairs,
+// on top of the defaults.
+func untrustedCeilings(values []string) (map[string]resource.Quantity, error) {
+	raw := map[string]string{}
+	for name, quantity := range defaultUntrustedCeilings {
+		raw[name] = quantity
+	}
+	for _, value := range values {
+		name, quantity, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not in the name=quantity form", value)
+		}
+		raw[name] = quantity
+	}
+	ceilings := map[string]resource.Quantity{}
+	for name, value := range raw {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ceiling for %s: %w", name, err)
+		}
+		ceilings[name] = quantity
+	}
+	return ceilings, nil
+}
+
+// validateUntrusted makes sure a configuration written by untrusted authors
+// only mounts the allowed secrets, stays within the resource ceilings and
+// does not use cluster profiles, leases or claims. Secrets in the test namespace are allowed by
+// name, credentials from other namespaces by namespace/name. Fields that
+// create arbitrary objects, reach outside of the test namespace or create
+// other namespaces cannot be used at all. The configuration must be resolved
+// from the step registry, so the steps it references are checked as well.
+func validateUntrusted(config *api.ReleaseBuildConfiguration, allowedSecrets sets.Set[string], ceilings map[string]resource.Quantity) error {
+	var errs []error
+	if credentials := config.CloneCredentials; credentials != nil && !allowedSecrets.Has(credentials.Namespace+"/"+credentials.Name) {
+		errs = append(errs, fmt.Errorf("clone_credentials: secret %s/%s is not allowed", credentials.Namespace, credentials.Name))
+	}
+	if len(config.AdditionalResources) != 0 {
+		errs = append(errs, errors.New("additional_resources cannot be used"))
+	}
+	if len(config.AllowedClusterScopedKinds) != 0 {
+		errs = append(errs, errors.New("allowed_cluster_scoped_kinds cannot be used"))
+	}
+	names := make([]string, 0, len(config.Resources))
+	for name := range config.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, exceededCeilings(fmt.Sprintf("resources[%s]", name), config.Resources[name], ceilings)...)
+	}
+	for _, test := range config.Tests {
+		if profile := test.GetClusterProfile(); profile != "" {
+			errs = append(errs, fmt.Errorf("tests[%s]: cluster profile %s cannot be used", test.As, profile))
+		}
+		if test.ClusterClaim != nil {
+			errs = append(errs, fmt.Errorf("tests[%s]: cluster_claim cannot be used", test.As))
+		}
+		if test.ManifestTestConfiguration != nil {
+			errs = append(errs, fmt.Errorf("tests[%s]: manifests cannot be used", test.As))
+		}
+		if len(test.Gates) != 0 {
+			errs = append(errs, fmt.Errorf("tests[%s]: gates cannot be used", test.As))
+		}
+		if test.DedicatedNamespace != nil {
+			errs = append(errs, fmt.Errorf("tests[%s]: dedicated_namespace cannot be used", test.As))
+		}
+		if len(testLeases(test)) != 0 {
+			errs = append(errs, fmt.Errorf("tests[%s]: leases cannot be used", test.As))
+		}
+		secrets := test.Secrets
+		if test.Secret != nil {
+			secrets = append([]*api.Secret{test.Secret}, secrets...)
+		}
+		for _, secret := range secrets {
+			if !allowedSecrets.Has(secret.Name) {
+				errs = append(errs, fmt.Errorf("tests[%s]: secret %s is not allowed", test.As, secret.Name))
+			}
+		}
+		if test.MultiStageTestConfiguration != nil {
+			// the steps of the registry can only be checked once resolved
+			errs = append(errs, fmt.Errorf("tests[%s]: steps are not resolved from the registry", test.As))
+		}
+		for _, step := range literalSteps(test) {
+			for _, credential := range step.Credentials {
+				if !allowedSecrets.Has(credential.Namespace + "/" + credential.Name) {
+					errs = append(errs, fmt.Errorf("tests[%s]: step %s: credentials %s/%s are not allowed", test.As, step.As, credential.Namespace, credential.Name))
+				}
+			}
+			if len(step.Leases) != 0 {
+				errs = append(errs, fmt.Errorf("tests[%s]: step %s: leases cannot be used", test.As, step.As))
+			}
+			errs = append(errs, exceededCeilings(fmt.Sprintf("tests[%s]: step %s", test.As, step.As), step.Resources, ceilings)...)
+			for _, sidecar := range step.Sidecars {
+				errs = append(errs, exceededCeilings(fmt.Sprintf("tests[%s]: step %s: sidecar %s", test.As, step.As, sidecar.Name), sidecar.Resources, ceilings)...)
+			}
+		}
+		if literal := test.MultiStageTestConfigurationLiteral; literal != nil {
+			for _, observer := range literal.Observers {
+				errs = append(errs, exceededCeilings(fmt.Sprintf("tests[%s]: observer %s", test.As, observer.Name), observer.Resources, ceilings)...)
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// literalSteps returns the steps of a multi-stage test resolved from the
+// configuration and the step registry.
+func literalSteps(test api.TestStepConfiguration) []api.LiteralTestStep {
+	var literal []api.LiteralTestStep
+	if test.MultiStageTestConfigurationLiteral != nil {
+		literal = append(literal, test.MultiStageTestConfigurationLiteral.Pre...)
+		literal = append(literal, test.MultiStageTestConfigurationLiteral.Test...)
+		literal = append(literal, test.MultiStageTestConfigurationLiteral.Post...)
+	}
+	return literal
+}
+
+// testLeases returns the leases a multi-stage test acquires for all of its
+// steps.
+func testLeases(test api.TestStepConfiguration) []api.StepLease {
+	switch {
+	case test.MultiStageTestConfiguration != nil:
+		return test.MultiStageTestConfiguration.Leases
+	case test.MultiStageTestConfigurationLiteral != nil:
+		return test.MultiStageTestConfigurationLiteral.Leases
+	}
+	return nil
+}
+
+// enforceRuntimeClass makes the container tests and the steps of the
+// multi-stage tests run with the given RuntimeClass, replacing the one they
+// set, so the configuration cannot opt out of the sandbox.
+func enforceRuntimeClass(config *api.ReleaseBuildConfiguration, runtimeClass string) {
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		if test.ContainerTestConfiguration != nil {
+			test.ContainerTestConfiguration.RuntimeClass = runtimeClass
+		}
+		if literal := test.MultiStageTestConfigurationLiteral; literal != nil {
+			for _, phase := range [][]api.LiteralTestStep{literal.Pre, literal.Test, literal.Post} {
+				for j := range phase {
+					phase[j].RuntimeClass = runtimeClass
+				}
+			}
+		}
+	}
+}
+
+func exceededCeilings(context string, requirements api.ResourceRequirements, ceilings map[string]resource.Quantity) []error {
+	var errs []error
+	for _, field := range []struct {
+		name string
+		list api.ResourceList
+	}{{name: "requests", list: requirements.Requests}, {name: "limits", list: requirements.Limits}} {
+		names := make([]string, 0, len(field.list))
+		for name := range field.list {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ceiling, limited := ceilings[name]
+			if !limited {
+				continue
+			}
+			quantity, err := resource.ParseQuantity(field.list[name])
+			if err != nil {
+				// invalid quantities are reported by the validation of the configuration
+				continue
+			}
+			if quantity.Cmp(ceiling) > 0 {
+				errs = append(errs, fmt.Errorf("%s: %s %s of %s exceed the ceiling of %s", context, name, field.name, field.list[name], ceiling.String()))
+			}
+		}
+	}
+	return errs
+}
+
+// validateUntrustedOptions rejects the options that publish anything outside
+// of the test namespace in --untrusted mode.
+func (o *options) validateUntrustedOptions() error {
+	if o.promote {
+		return errors.New("--promote cannot be used with --untrusted")
+	}
+	if o.pushImagesTo != "" {
+		return errors.New("--push-images-to cannot be used with --untrusted")
+	}
+	if o.uploadArtifactsTo != "" {
+		return errors.New("--upload-artifacts-to cannot be used with --untrusted")
+	}
+	if o.uploadArtifactsRoot != "" {
+		return errors.New("--upload-artifacts cannot be used with --untrusted")
+	}
+	if o.untrustedRuntimeClass != "" {
+		if errs := validation.IsDNS1123Subdomain(o.untrustedRuntimeClass); len(errs) != 0 {
+			return fmt.Errorf("--untrusted-runtime-class: %q is not a valid RuntimeClass name: %s", o.untrustedRuntimeClass, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
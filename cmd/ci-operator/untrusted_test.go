@@ -0,0 +1,321 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestUntrustedCeilings(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		values      []string
+		expected    map[string]resource.Quantity
+		expectedErr error
+	}{
+		{
+			name:     "defaults",
+			expected: map[string]resource.Quantity{"cpu": resource.MustParse("4"), "memory": resource.MustParse("16Gi")},
+		},
+		{
+			name:     "overrides and additions",
+			values:   []string{"cpu=8", "nvidia.com/gpu=0"},
+			expected: map[string]resource.Quantity{"cpu": resource.MustParse("8"), "memory": resource.MustParse("16Gi"), "nvidia.com/gpu": resource.MustParse("0")},
+		},
+		{
+			name:        "malformed",
+			values:      []string{"cpu"},
+			expectedErr: errors.New(`"cpu" is not in the name=quantity form`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := untrustedCeilings(tc.values)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err == nil {
+				testhelper.Diff(t, "ceilings", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateUntrusted(t *testing.T) {
+	ceilings := map[string]resource.Quantity{"cpu": resource.MustParse("4"), "memory": resource.MustParse("16Gi")}
+	resolver := registry.NewResolver(registry.ReferenceByName{
+		"e2e":   {As: "e2e", Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "2"}}},
+		"large": {As: "large", Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "6"}}},
+	}, nil, nil, registry.ObserverByName{
+		"monitor": {Name: "monitor", Resources: api.ResourceRequirements{Limits: api.ResourceList{"memory": "32Gi"}}},
+	})
+	var testCases = []struct {
+		name        string
+		config      api.ReleaseBuildConfiguration
+		unresolved  bool
+		expectedErr error
+	}{
+		{
+			name: "allowed configuration",
+			config: api.ReleaseBuildConfiguration{
+				Resources: api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "2"}, Limits: api.ResourceList{"memory": "16Gi"}}},
+				Tests: []api.TestStepConfiguration{
+					{As: "unit", Secrets: []*api.Secret{{Name: "codecov"}}},
+					{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Test: []api.TestStep{{Reference: pointer.String("e2e")}, {LiteralTestStep: &api.LiteralTestStep{As: "upload", Credentials: []api.CredentialReference{{Namespace: "test-credentials", Name: "upload"}}}}},
+					}},
+				},
+			},
+		},
+		{
+			name: "forbidden configuration",
+			config: api.ReleaseBuildConfiguration{
+				CloneCredentials:          &api.CloneCredentials{Namespace: "ci", Name: "clone"},
+				AdditionalResources:       []api.AdditionalResource{{File: "rbac.yaml"}},
+				AllowedClusterScopedKinds: []string{"ClusterRole"},
+				Resources:                 api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "8"}, Limits: api.ResourceList{"memory": "32Gi"}}},
+				Tests: []api.TestStepConfiguration{
+					{As: "unit", Secret: &api.Secret{Name: "token"}, Gates: []api.StepGate{{HTTP: &api.HTTPGate{URL: "http://internal"}}}},
+					{As: "e2e", DedicatedNamespace: &api.DedicatedNamespace{}, MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						ClusterProfile: api.ClusterProfileAWS,
+						Leases:         []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE"}},
+						Test: []api.TestStep{{LiteralTestStep: &api.LiteralTestStep{
+							As:          "test",
+							Leases:      []api.StepLease{{ResourceType: "gcp-quota-slice", Env: "GCP_RESOURCE"}},
+							Credentials: []api.CredentialReference{{Namespace: "ci", Name: "admin"}},
+							Resources:   api.ResourceRequirements{Requests: api.ResourceList{"cpu": "5"}},
+							Sidecars:    []api.Sidecar{{Name: "db", Resources: api.ResourceRequirements{Limits: api.ResourceList{"memory": "64Gi"}}}},
+						}}},
+					}},
+					{As: "claim", ClusterClaim: &api.ClusterClaim{}, ManifestTestConfiguration: &api.ManifestTestConfiguration{}},
+				},
+			},
+			expectedErr: errors.New("[" +
+				"clone_credentials: secret ci/clone is not allowed, " +
+				"additional_resources cannot be used, " +
+				"allowed_cluster_scoped_kinds cannot be used, " +
+				"resources[*]: cpu requests of 8 exceed the ceiling of 4, " +
+				"resources[*]: memory limits of 32Gi exceed the ceiling of 16Gi, " +
+				"tests[unit]: gates cannot be used, " +
+				"tests[unit]: secret token is not allowed, " +
+				"tests[e2e]: cluster profile aws cannot be used, " +
+				"tests[e2e]: dedicated_namespace cannot be used, " +
+				"tests[e2e]: leases cannot be used, " +
+				"tests[e2e]: step test: credentials ci/admin are not allowed, " +
+				"tests[e2e]: step test: leases cannot be used, " +
+				"tests[e2e]: step test: cpu requests of 5 exceed the ceiling of 4, " +
+				"tests[e2e]: step test: sidecar db: memory limits of 64Gi exceed the ceiling of 16Gi, " +
+				"tests[claim]: cluster_claim cannot be used, " +
+				"tests[claim]: manifests cannot be used" +
+				"]"),
+		},
+		{
+			name: "registry steps and observers over the ceilings",
+			config: api.ReleaseBuildConfiguration{
+				Tests: []api.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Test:      []api.TestStep{{Reference: pointer.String("large")}},
+						Observers: &api.Observers{Enable: []string{"monitor"}},
+					}},
+				},
+			},
+			expectedErr: errors.New("[" +
+				"tests[e2e]: step large: cpu requests of 6 exceed the ceiling of 4, " +
+				"tests[e2e]: observer monitor: memory limits of 32Gi exceed the ceiling of 16Gi" +
+				"]"),
+		},
+		{
+			name: "unresolved configuration",
+			config: api.ReleaseBuildConfiguration{
+				Tests: []api.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+						Test: []api.TestStep{{Reference: pointer.String("large")}},
+					}},
+				},
+			},
+			unresolved:  true,
+			expectedErr: errors.New("tests[e2e]: steps are not resolved from the registry"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if !tc.unresolved {
+				resolved, err := registry.ResolveConfig(resolver, tc.config)
+				if err != nil {
+					t.Fatalf("failed to resolve the configuration: %v", err)
+				}
+				tc.config = resolved
+			}
+			err := validateUntrusted(&tc.config, sets.New("codecov", "test-credentials/upload"), ceilings)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestEnforceRuntimeClass(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{
+			{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			{As: "escape", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src", RuntimeClass: "runc"}},
+			{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Test: []api.LiteralTestStep{{As: "test"}, {As: "runc", RuntimeClass: "runc"}},
+				Post: []api.LiteralTestStep{{As: "gather"}},
+			}},
+		},
+	}
+	enforceRuntimeClass(config, "gvisor")
+	testhelper.Diff(t, "container test", config.Tests[0].ContainerTestConfiguration.RuntimeClass, "gvisor")
+	testhelper.Diff(t, "container test setting runc", config.Tests[1].ContainerTestConfiguration.RuntimeClass, "gvisor")
+	var actual []string
+	for _, step := range literalSteps(config.Tests[2]) {
+		actual = append(actual, step.RuntimeClass)
+	}
+	testhelper.Diff(t, "steps", actual, []string{"gvisor", "gvisor", "gvisor"})
+}
+
+// untrustedFields records, for every field of the configuration, whether
+// validateUntrusted restricts it. A new field fails TestUntrustedFields until
+// it is decided whether untrusted authors may use it.
+var untrustedFields = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(api.ReleaseBuildConfiguration{}): {
+		"Metadata":                  false,
+		"ExpectedMetadata":          false,
+		"BaseImages":                false,
+		"BaseRPMImages":             false,
+		"BuildRootImage":            false,
+		"ReleaseTagConfiguration":   false,
+		"Releases":                  false,
+		"BinaryBuildCommands":       false,
+		"TestBinaryBuildCommands":   false,
+		"RpmBuildCommands":          false,
+		"RpmBuildLocation":          false,
+		"CanonicalGoRepository":     false,
+		"CloneCredentials":          true,
+		"SourceCache":               false,
+		"Images":                    false,
+		"Operator":                  false,
+		"Tests":                     true,
+		"RawSteps":                  false,
+		"PromotionConfiguration":    false, // promotion is disabled by validateUntrustedOptions
+		"Resources":                 true,
+		"AllowedClusterScopedKinds": true,
+		"AdditionalResources":       true,
+	},
+	reflect.TypeOf(api.TestStepConfiguration{}): {
+		"As":                                 false,
+		"Commands":                           false,
+		"Cluster":                            false,
+		"Secret":                             true,
+		"Secrets":                            true,
+		"Cron":                               false,
+		"Interval":                           false,
+		"MinimumInterval":                    false,
+		"ReleaseController":                  false,
+		"Postsubmit":                         false,
+		"ClusterClaim":                       true,
+		"AlwaysRun":                          false,
+		"RunIfChanged":                       false,
+		"Optional":                           false,
+		"Portable":                           false,
+		"SkipIfOnlyChanged":                  false,
+		"Timeout":                            false,
+		"RunTimeout":                         false,
+		"Shards":                             false,
+		"Gates":                              true,
+		"DedicatedNamespace":                 true,
+		"ContainerTestConfiguration":         false,
+		"MultiStageTestConfiguration":        true,
+		"MultiStageTestConfigurationLiteral": true,
+		"OpenshiftAnsibleClusterTestConfiguration":                  true,
+		"OpenshiftAnsibleSrcClusterTestConfiguration":               true,
+		"OpenshiftAnsibleCustomClusterTestConfiguration":            true,
+		"OpenshiftInstallerClusterTestConfiguration":                true,
+		"OpenshiftInstallerUPIClusterTestConfiguration":             true,
+		"OpenshiftInstallerUPISrcClusterTestConfiguration":          true,
+		"OpenshiftInstallerCustomTestImageClusterTestConfiguration": true,
+		"ManifestTestConfiguration":                                 true,
+	},
+	reflect.TypeOf(api.LiteralTestStep{}): {
+		"As":                false,
+		"From":              false,
+		"FromImage":         false,
+		"Commands":          false,
+		"Resources":         true,
+		"Timeout":           false,
+		"GracePeriod":       false,
+		"Credentials":       true,
+		"Environment":       false,
+		"Dependencies":      false,
+		"DNSConfig":         false,
+		"HostAliases":       false,
+		"RuntimeClass":      true,
+		"Leases":            true,
+		"OptionalOnSuccess": false,
+		"BestEffort":        false,
+		"RunIf":             false,
+		"NoKubeconfig":      false,
+		"Cli":               false,
+		"Observers":         false,
+		"RunAsScript":       false,
+		"Sidecars":          true,
+		"Outputs":           false,
+	},
+}
+
+func TestUntrustedFields(t *testing.T) {
+	for typ, fields := range untrustedFields {
+		seen := map[string]bool{}
+		var walk func(reflect.Type)
+		walk = func(typ reflect.Type) {
+			for i := 0; i < typ.NumField(); i++ {
+				field := typ.Field(i)
+				if field.Anonymous {
+					walk(field.Type)
+					continue
+				}
+				seen[field.Name] = true
+				if _, known := fields[field.Name]; !known {
+					t.Errorf("%s.%s: decide whether untrusted configurations may use the field, restrict it in validateUntrusted if not and record it in untrustedFields", typ.Name(), field.Name)
+				}
+			}
+		}
+		walk(typ)
+		for name := range fields {
+			if !seen[name] {
+				t.Errorf("%s.%s: the field does not exist anymore, remove it from untrustedFields", typ.Name(), name)
+			}
+		}
+	}
+}
+
+func TestValidateUntrustedOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		options     options
+		expectedErr error
+	}{
+		{
+			name: "no publishing options",
+		},
+		{
+			name:        "uploading to a location",
+			options:     options{uploadArtifactsTo: "gs://bucket/path"},
+			expectedErr: errors.New("--upload-artifacts-to cannot be used with --untrusted"),
+		},
+		{
+			name:        "uploading to a bucket",
+			options:     options{uploadArtifactsRoot: "gs://bucket"},
+			expectedErr: errors.New("--upload-artifacts cannot be used with --untrusted"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "error", tc.options.validateUntrustedOptions(), tc.expectedErr, testhelper.EquateErrorMessage)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+)
+
+var (
+	// usageFlagsAnnotation lists the flags ci-operator was run with.
+	usageFlagsAnnotation = fmt.Sprintf("%s/usage-flags", steps.CiAnnotationPrefix)
+	// usageConfigFieldsAnnotation lists the fields the configuration sets.
+	usageConfigFieldsAnnotation = fmt.Sprintf("%s/usage-config-fields", steps.CiAnnotationPrefix)
+	// usageTemplateParametersAnnotation lists the parameters the templates use.
+	usageTemplateParametersAnnotation = fmt.Sprintf("%s/usage-template-parameters", steps.CiAnnotationPrefix)
+)
+
+// usageFieldsDepth limits the depth of the configuration fields reported, so
+// the report stays small while still telling e.g. tests.steps.leases apart.
+const usageFieldsDepth = 3
+
+// usageNestedFields are the objects of the configuration whose fields are
+// reported. Other objects, like base_images, are keyed by user-defined
+// names, which are not features.
+var usageNestedFields = sets.New[string]("tests.steps", "tests.literal_steps")
+
+// usageReport summarizes the features used by an execution for the owners of
+// the platform. It never holds the values of the flags or of the
+// configuration, only their names.
+type usageReport struct {
+	Job          string   `json:"job,omitempty"`
+	Namespace    string   `json:"namespace,omitempty"`
+	Flags        []string `json:"flags,omitempty"`
+	ConfigFields []string `json:"config_fields,omitempty"`
+	// TemplateParameters are the parameters the templates given with
+	// --template declare, which are the features of ci-operator they use.
+	TemplateParameters []string `json:"template_parameters,omitempty"`
+}
+
+// setFlagNames lists the flags set on the command line.
+func setFlagNames(flagSet *flag.FlagSet) []string {
+	var names []string
+	flagSet.Visit(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return names
+}
+
+// configFields lists the paths of the fields set in the configuration, with
+// the fields of the elements of lists reported under the name of the list,
+// e.g. tests.steps.workflow or images.build_args.
+func configFields(config *api.ReleaseBuildConfiguration) ([]string, error) {
+	if config == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal the configuration: %w", err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, fmt.Errorf("could not unmarshal the configuration: %w", err)
+	}
+	found := sets.New[string]()
+	collectConfigFields("", object, found)
+	return sets.List(found), nil
+}
+
+func collectConfigFields(prefix string, object map[string]interface{}, found sets.Set[string]) {
+	for key, value := range object {
+		field := key
+		if prefix != "" {
+			field = fmt.Sprintf("%s.%s", prefix, key)
+		}
+		found.Insert(field)
+		if strings.Count(field, ".")+1 >= usageFieldsDepth {
+			continue
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if usageNestedFields.Has(field) {
+				collectConfigFields(field, value, found)
+			}
+		case []interface{}:
+			for _, item := range value {
+				if item, ok := item.(map[string]interface{}); ok {
+					collectConfigFields(field, item, found)
+				}
+			}
+		}
+	}
+}
+
+// usageTemplateParameters lists the parameters the templates declare. The
+// parameters of images are reported by their prefix, e.g. LOCAL_IMAGE_*,
+// as the names of the images are not features.
+func usageTemplateParameters(templates []*templateapi.Template) []string {
+	found := sets.New[string]()
+	for _, template := range templates {
+		for _, parameter := range template.Parameters {
+			found.Insert(usageTemplateParameter(parameter.Name))
+		}
+	}
+	return sets.List(found)
+}
+
+func usageTemplateParameter(name string) string {
+	switch {
+	case name == utils.ImageFormatEnv:
+		return name
+	case utils.IsPipelineImageEnv(name):
+		return utils.PipelineImageEnvFor("*")
+	case utils.IsInitialImageEnv(name):
+		return utils.InitialImageEnv("*")
+	case utils.IsReleaseImageEnv(name):
+		return utils.ReleaseImageEnv("*")
+	case utils.IsStableImageEnv(name):
+		return utils.StableImageEnv("*")
+	case utils.IsRPMRepoEnv(name):
+		return "RPM_REPO_*"
+	}
+	return name
+}
+
+// usageReport summarizes the usage of the current execution.
+func (o *options) usageReport() (*usageReport, error) {
+	fields, err := configFields(o.configSpec)
+	if err != nil {
+		return nil, err
+	}
+	report := &usageReport{Namespace: o.namespace, Flags: o.setFlags, ConfigFields: fields, TemplateParameters: usageTemplateParameters(o.templates)}
+	if o.jobSpec != nil {
+		report.Job = o.jobSpec.Job
+	}
+	return report, nil
+}
+
+// annotations records the report on the namespace.
+func (r *usageReport) annotations() map[string]string {
+	return map[string]string{
+		usageFlagsAnnotation:              strings.Join(r.Flags, ","),
+		usageConfigFieldsAnnotation:       strings.Join(r.ConfigFields, ","),
+		usageTemplateParametersAnnotation: strings.Join(r.TemplateParameters, ","),
+	}
+}
+
+// send posts the report to the address.
+func (r *usageReport) send(ctx context.Context, client *http.Client, address string) error {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal the usage report: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("could not create the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send the usage report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the usage report was rejected with status %s", resp.Status)
+	}
+	return nil
+}
+
+// reportUsage sends the usage report to --usage-report-address, if set.
+// Reporting is best effort and never fails the execution.
+func (o *options) reportUsage(ctx context.Context) {
+	if o.usageReportAddress == "" {
+		return
+	}
+	report, err := o.usageReport()
+	if err != nil {
+		logrus.WithError(err).Warn("Could not summarize the usage of ci-operator.")
+		return
+	}
+	if err := report.send(ctx, http.DefaultClient, o.usageReportAddress); err != nil {
+		logrus.WithError(err).Warn("Could not report the usage of ci-operator.")
+	}
+}
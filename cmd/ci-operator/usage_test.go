@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestSetFlagNames(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	bindOptions(flagSet)
+	if err := flagSet.Parse([]string{"--target=unit", "--dry-run", "--secret-dir=/tmp/secret"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "flags", setFlagNames(flagSet), []string{"dry-run", "secret-dir", "target"})
+}
+
+func TestConfigFields(t *testing.T) {
+	workflow := "e2e-aws"
+	config := &api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			BaseImages: map[string]api.ImageStreamTagReference{"os": {Namespace: "ocp", Name: "4.14", Tag: "base"}},
+		},
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{{
+			To:                               "component",
+			ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{BuildArgs: []api.BuildArg{{Name: "A", Value: "B"}}},
+		}},
+		Tests: []api.TestStepConfiguration{
+			{As: "unit", Commands: "make test", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+			{As: "e2e", MultiStageTestConfiguration: &api.MultiStageTestConfiguration{
+				Workflow: &workflow,
+				Leases:   []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE"}},
+			}},
+		},
+	}
+	fields, err := configFields(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"base_images",
+		"images",
+		"images.build_args",
+		"images.build_args.name",
+		"images.build_args.value",
+		"images.to",
+		"tests",
+		"tests.as",
+		"tests.commands",
+		"tests.container",
+		"tests.steps",
+		"tests.steps.leases",
+		"tests.steps.workflow",
+		"zz_generated_metadata",
+	}
+	testhelper.Diff(t, "fields", fields, expected)
+}
+
+func TestUsageTemplateParameters(t *testing.T) {
+	templates := []*templateapi.Template{
+		{Parameters: []templateapi.Parameter{{Name: "JOB_NAME_SAFE"}, {Name: "IMAGE_FORMAT"}, {Name: "LOCAL_IMAGE_SRC"}, {Name: "IMAGE_TESTS"}}},
+		{Parameters: []templateapi.Parameter{{Name: "LOCAL_IMAGE_BIN"}, {Name: "RELEASE_IMAGE_LATEST"}, {Name: "INITIAL_IMAGE_CLI"}, {Name: "RPM_REPO_OPENSHIFT_ORIGIN"}, {Name: "CLUSTER_TYPE"}}},
+	}
+	testhelper.Diff(t, "parameters", usageTemplateParameters(templates), []string{"CLUSTER_TYPE", "IMAGE_*", "IMAGE_FORMAT", "INITIAL_IMAGE_*", "JOB_NAME_SAFE", "LOCAL_IMAGE_*", "RELEASE_IMAGE_*", "RPM_REPO_*"})
+}
+
+func TestUsageReportSend(t *testing.T) {
+	var received usageReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected a POST, got %s", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("could not decode the report: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	o := &options{
+		namespace:  "ci-op-1234",
+		jobSpec:    &api.JobSpec{},
+		setFlags:   []string{"target"},
+		configSpec: &api.ReleaseBuildConfiguration{Tests: []api.TestStepConfiguration{{As: "unit", Commands: "make"}}},
+		templates:  []*templateapi.Template{{Parameters: []templateapi.Parameter{{Name: "JOB_NAME_SAFE"}}}},
+	}
+	o.jobSpec.Job = "pull-ci-org-repo-master-unit"
+	report, err := o.usageReport()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := report.send(context.Background(), server.Client(), server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "report", received, usageReport{
+		Job:                "pull-ci-org-repo-master-unit",
+		Namespace:          "ci-op-1234",
+		Flags:              []string{"target"},
+		ConfigFields:       []string{"tests", "tests.as", "tests.commands", "zz_generated_metadata"},
+		TemplateParameters: []string{"JOB_NAME_SAFE"},
+	})
+	testhelper.Diff(t, "annotations", report.annotations(), map[string]string{
+		usageFlagsAnnotation:              "target",
+		usageConfigFieldsAnnotation:       "tests,tests.as,tests.commands,zz_generated_metadata",
+		usageTemplateParametersAnnotation: "JOB_NAME_SAFE",
+	})
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer rejecting.Close()
+	if err := report.send(context.Background(), rejecting.Client(), rejecting.URL); err == nil {
+		t.Error("expected an error when the report is rejected")
+	}
+}
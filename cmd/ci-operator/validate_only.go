@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/defaults"
+	"github.com/openshift/ci-tools/pkg/registry/server"
+	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/validation"
+)
+
+// validationStage is the stage of --validate-only that found an error.
+type validationStage string
+
+const (
+	validationStageLoading validationStage = "loading"
+	validationStageConfig  validationStage = "config"
+	validationStageGraph   validationStage = "graph"
+)
+
+// validationError is an error found by --validate-only.
+type validationError struct {
+	Stage   validationStage `json:"stage"`
+	Message string          `json:"message"`
+}
+
+// validationReport is printed by --validate-only.
+type validationReport struct {
+	Valid  bool              `json:"valid"`
+	Errors []validationError `json:"errors,omitempty"`
+}
+
+// validateConfig loads the configuration, validates all of its values and
+// builds the graph of its steps, collecting every error found on the way. It
+// does not contact the cluster, so it can be used as a presubmit for the
+// repositories holding configuration.
+func (o *options) validateConfig() []validationError {
+	jobSpec, err := api.ResolveSpecFromEnv()
+	if err != nil {
+		jobSpec = &api.JobSpec{}
+		if len(o.gitRef) > 0 {
+			if jobSpec, err = jobSpecFromGitRef(o.gitRef); err != nil {
+				return []validationError{{Stage: validationStageLoading, Message: fmt.Sprintf("failed to resolve --git-ref: %v", err)}}
+			}
+		}
+	}
+	o.jobSpec = jobSpec
+	o.resolverClient = server.NewResolverClient(o.resolverAddress)
+	config, err := o.loadConfig(o.getResolverInfo(jobSpec))
+	if err != nil {
+		return []validationError{{Stage: validationStageLoading, Message: err.Error()}}
+	}
+
+	var errs []validationError
+	for _, err := range validation.ResolvedConfigurationErrors(config) {
+		errs = append(errs, validationError{Stage: validationStageConfig, Message: err.Error()})
+	}
+	graphConfig := defaults.FromConfigStatic(config)
+	if err := validation.IsValidGraphConfiguration(graphConfig.Steps); err != nil {
+		var aggregate utilerrors.Aggregate
+		if !errors.As(err, &aggregate) {
+			aggregate = utilerrors.NewAggregate([]error{err})
+		}
+		for _, err := range aggregate.Errors() {
+			errs = append(errs, validationError{Stage: validationStageGraph, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// runValidateOnly prints the report of validateConfig and returns the exit
+// code for it.
+func (o *options) runValidateOnly(w io.Writer) int {
+	errs := o.validateConfig()
+	raw, err := json.MarshalIndent(validationReport{Valid: len(errs) == 0, Errors: errs}, "", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "could not marshal the validation report: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(w, string(raw))
+	if len(errs) == 0 {
+		return 0
+	}
+	reason := results.Reason("validating_config")
+	if errs[0].Stage == validationStageLoading {
+		reason = "loading_config"
+	}
+	return results.ExitCode(results.ForReason(reason).ForError(errors.New(errs[0].Message)))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+const validConfig = `build_root:
+  image_stream_tag:
+    namespace: ci
+    name: root
+    tag: latest
+resources:
+  '*':
+    requests:
+      cpu: 100m
+tests:
+- as: unit
+  commands: make test
+  container:
+    from: src
+zz_generated_metadata:
+  org: org
+  repo: repo
+  branch: master
+`
+
+func TestValidateOnly(t *testing.T) {
+	t.Setenv("JOB_SPEC", "")
+	var testCases = []struct {
+		name         string
+		config       string
+		expected     validationReport
+		expectedCode int
+	}{{
+		name:     "valid configuration",
+		config:   validConfig,
+		expected: validationReport{Valid: true},
+	}, {
+		name:   "unknown key",
+		config: validConfig + "unknown: value\n",
+		expected: validationReport{Errors: []validationError{{
+			Stage: validationStageLoading,
+		}}},
+		expectedCode: 2,
+	}, {
+		name: "invalid values and graph",
+		config: validConfig + `images:
+- from: missing
+  to: image
+- from: src
+  to: image
+`,
+		expected: validationReport{Errors: []validationError{
+			{Stage: validationStageConfig, Message: "images[1]: duplicate image name 'image' (previously defined by field 'images[0]')"},
+			{Stage: validationStageGraph, Message: "configuration contains duplicate target: image"},
+			{Stage: validationStageGraph, Message: "configuration contains duplicate target: [output:stable:image]"},
+			{Stage: validationStageGraph, Message: `images[image].from: unknown image "missing" (it must be one of base_images or another image built by the configuration)`},
+		}},
+		expectedCode: 2,
+	}}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(testCase.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			o := &options{configSpecPath: path}
+			var out bytes.Buffer
+			code := o.runValidateOnly(&out)
+			if code != testCase.expectedCode {
+				t.Errorf("expected exit code %d, got %d", testCase.expectedCode, code)
+			}
+			var report validationReport
+			if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+				t.Fatalf("could not unmarshal the report: %v", err)
+			}
+			// errors from loading include the whole configuration
+			for i := range report.Errors {
+				if report.Errors[i].Stage == validationStageLoading {
+					report.Errors[i].Message = ""
+				}
+			}
+			testhelper.Diff(t, "report", report, testCase.expected)
+		})
+	}
+}
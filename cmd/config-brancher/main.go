@@ -183,7 +183,7 @@ func updateRelease(config *api.ReleaseBuildConfiguration, futureRelease string)
 func updateImages(config *api.ReleaseBuildConfiguration, currentRelease, futureRelease string) {
 	for name := range config.InputConfiguration.BaseImages {
 		image := config.InputConfiguration.BaseImages[name]
-		if api.RefersToOfficialImage(image.Namespace, api.WithOKD) && image.Name == currentRelease {
+		if image.PullSpec == "" && api.RefersToOfficialImage(image.Namespace, api.WithOKD) && image.Name == currentRelease {
 			image.Name = futureRelease
 		}
 		config.InputConfiguration.BaseImages[name] = image
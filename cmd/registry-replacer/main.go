@@ -605,7 +605,7 @@ func pruneOCPBuilderReplacements(config *api.ReleaseBuildConfiguration) error {
 		}
 
 		// Fun special case: We set up a replacement for this ourselves to prevent direct references to api.ci
-		if imagestreamTagReference.Namespace == orgRepoTag.org && imagestreamTagReference.Name == orgRepoTag.repo && imagestreamTagReference.Tag == orgRepoTag.tag {
+		if imagestreamTagReference.PullSpec == "" && imagestreamTagReference.Namespace == orgRepoTag.org && imagestreamTagReference.Name == orgRepoTag.repo && imagestreamTagReference.Tag == orgRepoTag.tag {
 			return true, nil
 		}
 
@@ -10,6 +10,10 @@
 #
 #     $ ci-operator … --print-graph | hack/graphviz.sh > out.png
 #     $ ci-operator … --print-graph | hack/graphviz.sh | $image_viewer -
+#
+# `ci-operator --print-graph --graph-format=dot` prints a Graphviz graph that
+# also describes the steps and includes the post steps; it can be piped to
+# `dot` directly.
 set -euo pipefail
 
 awk_prog="$(cat <<'EOF'
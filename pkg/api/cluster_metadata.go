@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ClusterPlatformEnv exposes the platform of the cloud the job runs on,
+	// like aws or gcp, to templates and multi-stage steps.
+	ClusterPlatformEnv = "CLUSTER_PLATFORM"
+	// ClusterRegionEnv exposes the region of the cloud the job runs on.
+	ClusterRegionEnv = "CLUSTER_REGION"
+	// ClusterTypeEnv is the cluster type, like aws or azure4, templates and
+	// steps install clusters of.
+	ClusterTypeEnv = "CLUSTER_TYPE"
+)
+
+// ClusterMetadata describes the cloud the job runs on, either detected from
+// the build cluster or given with --cluster-metadata.
+type ClusterMetadata struct {
+	// Platform is the lowercase platform of the cloud, like aws or gcp
+	Platform string `json:"platform,omitempty"`
+	// Region is the region of the cloud, if it has any
+	Region string `json:"region,omitempty"`
+}
+
+// Env exposes the known metadata as environment variables.
+func (m ClusterMetadata) Env() []coreapi.EnvVar {
+	var env []coreapi.EnvVar
+	if m.Platform != "" {
+		env = append(env, coreapi.EnvVar{Name: ClusterPlatformEnv, Value: m.Platform})
+	}
+	if m.Region != "" {
+		env = append(env, coreapi.EnvVar{Name: ClusterRegionEnv, Value: m.Region})
+	}
+	return env
+}
+
+// Merge returns the metadata with the fields set in the overrides replaced.
+func (m ClusterMetadata) Merge(overrides ClusterMetadata) ClusterMetadata {
+	if overrides.Platform != "" {
+		m.Platform = overrides.Platform
+	}
+	if overrides.Region != "" {
+		m.Region = overrides.Region
+	}
+	return m
+}
+
+// ParseClusterMetadata parses comma-separated key=value pairs, like
+// platform=aws,region=us-east-1.
+func ParseClusterMetadata(value string) (ClusterMetadata, error) {
+	var ret ClusterMetadata
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(pair, "=")
+		if !found || val == "" {
+			return ClusterMetadata{}, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		switch key {
+		case "platform":
+			ret.Platform = strings.ToLower(val)
+		case "region":
+			ret.Region = val
+		default:
+			return ClusterMetadata{}, fmt.Errorf("unknown key %q, expected platform or region", key)
+		}
+	}
+	return ret, nil
+}
+
+// regionFields are the fields of the status of the Infrastructure that hold
+// the region of the platforms that have one.
+var regionFields = map[string][]string{
+	"aws":          {"aws", "region"},
+	"gcp":          {"gcp", "region"},
+	"alibabacloud": {"alibabaCloud", "region"},
+	"powervs":      {"powervs", "region"},
+	"ibmcloud":     {"ibmcloud", "location"},
+}
+
+// ResolveClusterMetadata detects the platform and region of the cluster from
+// its Infrastructure.
+func ResolveClusterMetadata(ctx context.Context, client ctrlruntimeclient.Client) (ClusterMetadata, error) {
+	infrastructure := &unstructured.Unstructured{}
+	infrastructure.SetAPIVersion("config.openshift.io/v1")
+	infrastructure.SetKind("Infrastructure")
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: "cluster"}, infrastructure); err != nil {
+		return ClusterMetadata{}, fmt.Errorf("failed to get the infrastructure of the cluster: %w", err)
+	}
+	platform, _, err := unstructured.NestedString(infrastructure.Object, "status", "platformStatus", "type")
+	if err != nil || platform == "" {
+		return ClusterMetadata{}, fmt.Errorf("failed to determine the platform of the cluster")
+	}
+	ret := ClusterMetadata{Platform: strings.ToLower(platform)}
+	if field, ok := regionFields[ret.Platform]; ok {
+		ret.Region, _, _ = unstructured.NestedString(infrastructure.Object, append([]string{"status", "platformStatus"}, field...)...)
+	}
+	return ret, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseClusterMetadata(t *testing.T) {
+	for _, tc := range []struct {
+		value       string
+		expected    ClusterMetadata
+		expectedErr bool
+	}{{
+		value:    "platform=AWS,region=us-east-1",
+		expected: ClusterMetadata{Platform: "aws", Region: "us-east-1"},
+	}, {
+		value:    "platform=azure",
+		expected: ClusterMetadata{Platform: "azure"},
+	}, {
+		value:       "platform",
+		expectedErr: true,
+	}, {
+		value:       "zone=us-east-1a",
+		expectedErr: true,
+	}} {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := ParseClusterMetadata(tc.value)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected metadata: %s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveClusterMetadata(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		status      map[string]interface{}
+		expected    ClusterMetadata
+		expectedErr bool
+	}{{
+		name:     "AWS",
+		status:   map[string]interface{}{"platformStatus": map[string]interface{}{"type": "AWS", "aws": map[string]interface{}{"region": "us-east-1"}}},
+		expected: ClusterMetadata{Platform: "aws", Region: "us-east-1"},
+	}, {
+		name:     "Azure has no region",
+		status:   map[string]interface{}{"platformStatus": map[string]interface{}{"type": "Azure", "azure": map[string]interface{}{"cloudName": "AzurePublicCloud"}}},
+		expected: ClusterMetadata{Platform: "azure"},
+	}, {
+		name:        "no platform",
+		status:      map[string]interface{}{},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			infrastructure := &unstructured.Unstructured{Object: map[string]interface{}{"status": tc.status}}
+			infrastructure.SetAPIVersion("config.openshift.io/v1")
+			infrastructure.SetKind("Infrastructure")
+			infrastructure.SetName("cluster")
+			client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(infrastructure).Build()
+			actual, err := ResolveClusterMetadata(context.Background(), client)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected metadata: %s", diff)
+			}
+		})
+	}
+}
@@ -9,9 +9,17 @@ import (
 const (
 	RegistryPullCredentialsSecret = "registry-pull-credentials"
 
+	// PullRateLimitBypassSecret is the name of the pull secret copied into the
+	// test namespace to avoid the rate limits of public registries.
+	PullRateLimitBypassSecret = "pull-rate-limit-bypass"
+
 	RegistryPushCredentialsCICentralSecret          = "registry-push-credentials-ci-central"
 	RegistryPushCredentialsCICentralSecretMountPath = "/etc/push-secret"
 
+	// PushImagesCredentialsSecret holds the credentials used to push images to
+	// the repository given with --push-images-to.
+	PushImagesCredentialsSecret = "push-images-credentials"
+
 	GCSUploadCredentialsSecret          = "gce-sa-credentials-gcs-publisher"
 	GCSUploadCredentialsSecretMountPath = "/secrets/gcs"
 
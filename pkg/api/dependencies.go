@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxNearMisses is the maximum number of similar names suggested for a
+// dependency no step creates.
+const maxNearMisses = 3
+
+// missingDependencyReport explains to a user why a step cannot run: what
+// each of the missing links is, which waiting step or stanza of the
+// configuration would provide it and which similar names the steps of the
+// graph provide, as the missing name is often a typo.
+func missingDependencyReport(step Step, missing []StepLink, waiting []*StepNode, available []StepLink) string {
+	var lines []string
+	seen := map[string]bool{}
+	for _, link := range missing {
+		line := describeLink(link)
+		if explanation := link.UnsatisfiableError(); explanation != "" {
+			line = fmt.Sprintf("%s: %s", line, explanation)
+		}
+		if creators := waitingCreators(link, waiting); len(creators) > 0 {
+			line = fmt.Sprintf("%s; it would be created by %s, which cannot run either", line, strings.Join(creators, ", "))
+		} else if provider := linkProvider(link); provider != "" {
+			line = fmt.Sprintf("%s; it is provided by %s", line, provider)
+		}
+		if candidates := nearMisses(link, available); len(candidates) > 0 {
+			line = fmt.Sprintf("%s; did you mean %s?", line, strings.Join(candidates, " or "))
+		}
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return fmt.Sprintf("step %s is missing dependencies:\n  * %s", step.Name(), strings.Join(lines, "\n  * "))
+}
+
+// waitingCreators returns the names of the waiting steps creating the link.
+func waitingCreators(link StepLink, waiting []*StepNode) []string {
+	var names []string
+	for _, node := range waiting {
+		if HasAllLinks([]StepLink{link}, node.Step.Creates()) {
+			names = append(names, node.Step.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describeLink names the artifact the link refers to.
+func describeLink(link StepLink) string {
+	switch l := link.(type) {
+	case *internalImageStreamLink:
+		return fmt.Sprintf("release images %q", ReleaseNameFrom(l.name))
+	case *internalImageStreamTagLink:
+		if IsReleasePayloadStream(l.name) {
+			return fmt.Sprintf("release payload %q", l.tag)
+		}
+		return fmt.Sprintf("image tag %s:%s", l.name, l.tag)
+	case *externalImageLink:
+		return fmt.Sprintf("image %s/%s:%s", l.namespace, l.name, l.tag)
+	case *imagesReadyLink:
+		return "the images built by the configuration"
+	case *rpmRepoLink:
+		return "the RPM repository"
+	case allStepsLink:
+		return "all other steps"
+	default:
+		return fmt.Sprintf("%v", link)
+	}
+}
+
+// linkProvider names the stanza of the configuration providing the link.
+func linkProvider(link StepLink) string {
+	switch l := link.(type) {
+	case *internalImageStreamLink:
+		return releaseProvider(ReleaseNameFrom(l.name))
+	case *internalImageStreamTagLink:
+		switch {
+		case IsReleasePayloadStream(l.name):
+			return releaseProvider(l.tag)
+		case IsReleaseStream(l.name):
+			return releaseProvider(ReleaseNameFrom(l.name))
+		case l.name != PipelineImageStream:
+			return ""
+		}
+		switch PipelineImageStreamTagReference(l.tag) {
+		case PipelineImageStreamTagReferenceRoot, PipelineImageStreamTagReferenceSource:
+			return "`build_root`"
+		case PipelineImageStreamTagReferenceBinaries:
+			return "`binary_build_commands`"
+		case PipelineImageStreamTagReferenceTestBinaries:
+			return "`test_binary_build_commands`"
+		case PipelineImageStreamTagReferenceRPMs:
+			return "`rpm_build_commands`"
+		default:
+			return fmt.Sprintf("an entry named %q in `base_images`, `base_rpm_images` or `images`", l.tag)
+		}
+	case *externalImageLink:
+		return "an entry of `images` promoted with `promotion`"
+	case *imagesReadyLink:
+		return "`images`"
+	case *rpmRepoLink:
+		return "`rpm_build_commands`"
+	default:
+		return ""
+	}
+}
+
+func releaseProvider(name string) string {
+	switch name {
+	case InitialReleaseName, LatestReleaseName:
+		return fmt.Sprintf("`releases.%s` or `tag_specification`", name)
+	default:
+		return fmt.Sprintf("`releases.%s`", name)
+	}
+}
+
+// nearMisses returns the names of the available links of the same kind as
+// the link that are similar to its name, the most similar first.
+func nearMisses(link StepLink, available []StepLink) []string {
+	kind, name := linkKey(link)
+	if name == "" {
+		return nil
+	}
+	distances := map[string]int{}
+	for _, candidate := range available {
+		candidateKind, candidateName := linkKey(candidate)
+		if candidateKind != kind || candidateName == "" || candidateName == name {
+			continue
+		}
+		distance := editDistance(name, candidateName)
+		threshold := len(name) / 3
+		if threshold < 2 {
+			threshold = 2
+		}
+		if distance > threshold && !strings.Contains(candidateName, name) && !strings.Contains(name, candidateName) {
+			continue
+		}
+		distances[LinkName(candidate)] = distance
+	}
+	var names []string
+	for candidate := range distances {
+		names = append(names, candidate)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if distances[names[i]] != distances[names[j]] {
+			return distances[names[i]] < distances[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxNearMisses {
+		names = names[:maxNearMisses]
+	}
+	return names
+}
+
+// linkKey returns the kind of the link and the name that is compared with
+// the names of other links of the same kind.
+func linkKey(link StepLink) (string, string) {
+	switch l := link.(type) {
+	case *internalImageStreamLink:
+		return "stream", l.name
+	case *internalImageStreamTagLink:
+		return "tag/" + l.name, l.tag
+	case *externalImageLink:
+		return "external", LinkName(l)
+	default:
+		return "", ""
+	}
+}
+
+// editDistance is the Levenshtein distance between the strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
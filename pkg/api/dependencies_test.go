@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMissingDependencyReport(t *testing.T) {
+	t.Parallel()
+	blocked := &fakeStep{name: "blocked", creates: []StepLink{InternalImageLink("cli")}}
+	for _, tc := range []struct {
+		name      string
+		missing   []StepLink
+		available []StepLink
+		expected  string
+	}{{
+		name:    "pipeline image with a typo",
+		missing: []StepLink{InternalImageLink("bni")},
+		available: []StepLink{
+			InternalImageLink(PipelineImageStreamTagReferenceBinaries),
+			InternalImageLink(PipelineImageStreamTagReferenceTestBinaries),
+			ReleaseImageTagLink(LatestReleaseName, "bin"),
+		},
+		expected: "step unit is missing dependencies:\n" +
+			"  * image tag pipeline:bni; it is provided by an entry named \"bni\" in `base_images`, `base_rpm_images` or `images`; did you mean pipeline:bin?",
+	}, {
+		name:    "well-known pipeline image",
+		missing: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceRPMs), RPMRepoLink()},
+		expected: "step unit is missing dependencies:\n" +
+			"  * image tag pipeline:rpms; it is provided by `rpm_build_commands`\n" +
+			"  * the RPM repository; it is provided by `rpm_build_commands`",
+	}, {
+		name:      "release",
+		missing:   []StepLink{ReleaseImagesLink("intial"), ReleasePayloadImageLink("intial")},
+		available: []StepLink{ReleaseImagesLink(InitialReleaseName), ReleaseImagesLink("custom")},
+		expected: "step unit is missing dependencies:\n" +
+			"  * release images \"intial\"; it is provided by `releases.intial`; did you mean stable-initial?\n" +
+			"  * release payload \"intial\"; it is provided by `releases.intial`",
+	}, {
+		name:    "created by a step that cannot run",
+		missing: []StepLink{InternalImageLink("cli", StepLinkWithUnsatisfiableErrorMessage("cli is not built"))},
+		expected: "step unit is missing dependencies:\n" +
+			"  * image tag pipeline:cli: cli is not built; it would be created by blocked, which cannot run either",
+	}} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			actual := missingDependencyReport(&fakeStep{name: "unit"}, tc.missing, []*StepNode{{Step: blocked}}, tc.available)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected report: %s", diff)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "bin", expected: 3},
+		{a: "bin", b: "bin", expected: 0},
+		{a: "bni", b: "bin", expected: 2},
+		{a: "kitten", b: "sitting", expected: 3},
+	} {
+		if actual := editDistance(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("expected the distance between %q and %q to be %d, got %d", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -36,6 +38,24 @@ type Step interface {
 	Objects() []ctrlruntimeclient.Object
 }
 
+// DryRunAction describes something a step would do when it runs.
+// +k8s:deepcopy-gen=false
+type DryRunAction struct {
+	// Description is a short, human readable summary of the action.
+	Description string `json:"description"`
+	// Object is the object the step would create or update, if any. Values
+	// that can only be resolved from the cluster while the step runs are
+	// replaced by placeholders in angle brackets.
+	Object ctrlruntimeclient.Object `json:"object,omitempty"`
+}
+
+// DryRunner is implemented by steps that can describe the actions they would
+// perform without changing anything in the cluster.
+// +k8s:deepcopy-gen=false
+type DryRunner interface {
+	DryRun(ctx context.Context) ([]DryRunAction, error)
+}
+
 type InputDefinition []string
 
 // +k8s:deepcopy-gen=false
@@ -101,6 +121,50 @@ func (l *internalImageStreamTagLink) UnsatisfiableError() string {
 	return l.unsatisfiableError
 }
 
+// ImageStreamFor returns the image stream in the namespace of the job that the
+// link refers to, and the tag in it when the link refers to a single tag.
+func ImageStreamFor(link StepLink) (stream, tag string, ok bool) {
+	switch l := link.(type) {
+	case *internalImageStreamLink:
+		return l.name, "", true
+	case *internalImageStreamTagLink:
+		return l.name, l.tag, true
+	default:
+		return "", "", false
+	}
+}
+
+// PipelineImageTagFor returns the tag in the pipeline image stream that the
+// link refers to, if it refers to one.
+func PipelineImageTagFor(link StepLink) (PipelineImageStreamTagReference, bool) {
+	l, ok := link.(*internalImageStreamTagLink)
+	if !ok || l.name != PipelineImageStream {
+		return "", false
+	}
+	return PipelineImageStreamTagReference(l.tag), true
+}
+
+// LinkName returns a stable, human-readable name for the link, suitable for
+// exposing the links steps require and create to external systems.
+func LinkName(link StepLink) string {
+	switch l := link.(type) {
+	case *internalImageStreamLink:
+		return l.name
+	case *internalImageStreamTagLink:
+		return fmt.Sprintf("%s:%s", l.name, l.tag)
+	case *externalImageLink:
+		return fmt.Sprintf("%s/%s:%s", l.namespace, l.name, l.tag)
+	case *imagesReadyLink:
+		return "[images]"
+	case *rpmRepoLink:
+		return "[rpms]"
+	case allStepsLink:
+		return "[all]"
+	default:
+		return fmt.Sprintf("%T", link)
+	}
+}
+
 func AllStepsLink() StepLink {
 	return allStepsLink{}
 }
@@ -333,37 +397,148 @@ func BuildGraph(steps []Step) StepGraph {
 	return ret
 }
 
+// isTargetPattern determines whether a target selects steps with a glob
+// pattern rather than by name. Brackets are part of the names of some steps,
+// so only wildcards make a pattern.
+func isTargetPattern(target string) bool {
+	return strings.ContainsAny(target, "*?")
+}
+
+// IsPlainTarget determines whether a target names a single step, rather than
+// matching steps with a pattern or excluding them with a leading `!`.
+func IsPlainTarget(target string) bool {
+	return !strings.HasPrefix(target, "!") && !isTargetPattern(target)
+}
+
+// TargetMatches determines whether the target, a name or a glob pattern,
+// selects the step with the given name.
+func TargetMatches(target, name string) bool {
+	if isTargetPattern(target) {
+		matched, err := path.Match(target, name)
+		return err == nil && matched
+	}
+	return target == name
+}
+
+// TargetsSelect determines whether the targets select the step with the
+// given name, by the same rules BuildPartialGraph uses: a step is selected
+// when a name or pattern matches it and no exclusion does, and when only
+// exclusions are given every step they do not match is selected. Steps
+// that are excluded only because they depend on an excluded step are not
+// recognized here.
+func TargetsSelect(targets []string, name string) bool {
+	included, onlyExclusions := false, true
+	for _, target := range targets {
+		if pattern := strings.TrimPrefix(target, "!"); pattern != target {
+			if TargetMatches(pattern, name) {
+				return false
+			}
+			continue
+		}
+		onlyExclusions = false
+		if TargetMatches(target, name) {
+			included = true
+		}
+	}
+	return included || (len(targets) > 0 && onlyExclusions)
+}
+
 // BuildPartialGraph returns a graph or graphs that include
-// only the dependencies of the named steps.
+// only the dependencies of the named steps. Names may be glob
+// patterns, selecting every step they match, and names or patterns
+// prefixed with `!` exclude the steps they match along with every
+// step depending on them. When only exclusions are given, all steps
+// that are not excluded are included.
 func BuildPartialGraph(steps []Step, names []string) (StepGraph, error) {
 	if len(names) == 0 {
 		return BuildGraph(steps), nil
 	}
 
+	var included, patterns, excluded []string
+	for _, name := range names {
+		pattern := strings.TrimPrefix(name, "!")
+		if isTargetPattern(pattern) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid target pattern %q: %w", name, err)
+			}
+		}
+		switch {
+		case strings.HasPrefix(name, "!"):
+			excluded = append(excluded, pattern)
+		case isTargetPattern(name):
+			patterns = append(patterns, name)
+		default:
+			included = append(included, name)
+		}
+	}
+
+	var unmatched []string
+	isExcluded := make([]bool, len(steps))
+	for _, pattern := range excluded {
+		var matched bool
+		for i, step := range steps {
+			if TargetMatches(pattern, step.Name()) {
+				isExcluded[i] = true
+				matched = true
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, "!"+pattern)
+		}
+	}
+	excludeDependents(steps, isExcluded)
+
 	var required []StepLink
 	candidates := make([]bool, len(steps))
 	var allNames []string
 	for i, step := range steps {
 		allNames = append(allNames, step.Name())
-		for j, name := range names {
+		for j, name := range included {
 			if name != step.Name() {
 				continue
 			}
+			if isExcluded[i] {
+				return nil, fmt.Errorf("target %s is excluded", name)
+			}
 			candidates[i] = true
 			required = append(required, step.Requires()...)
-			names = append(names[:j], names[j+1:]...)
+			included = append(included[:j], included[j+1:]...)
 			break
 		}
 	}
-	if len(names) > 0 {
-		return nil, fmt.Errorf("the following names were not found in the config or were duplicates: %s (from %s)", strings.Join(names, ", "), strings.Join(allNames, ", "))
+	for _, pattern := range patterns {
+		var matched bool
+		for i, step := range steps {
+			if !TargetMatches(pattern, step.Name()) {
+				continue
+			}
+			matched = true
+			if !candidates[i] && !isExcluded[i] {
+				candidates[i] = true
+				required = append(required, step.Requires()...)
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	if len(included) > 0 {
+		return nil, fmt.Errorf("the following names were not found in the config or were duplicates: %s (from %s)", strings.Join(included, ", "), strings.Join(allNames, ", "))
+	}
+	if len(unmatched) > 0 {
+		return nil, fmt.Errorf("the following patterns did not match any step: %s (from %s)", strings.Join(unmatched, ", "), strings.Join(allNames, ", "))
+	}
+	if len(excluded) == len(names) {
+		for i := range steps {
+			candidates[i] = !isExcluded[i]
+		}
 	}
 
 	// identify all other steps that provide any links required by the current set
 	for {
 		added := 0
 		for i, step := range steps {
-			if candidates[i] {
+			if candidates[i] || isExcluded[i] {
 				continue
 			}
 			if HasAnyLinks(required, step.Creates()) {
@@ -386,77 +561,175 @@ func BuildPartialGraph(steps []Step, names []string) (StepGraph, error) {
 	return BuildGraph(targeted), nil
 }
 
+// excludeDependents marks as excluded every step that requires a link only
+// excluded steps create, until no more steps are excluded.
+func excludeDependents(steps []Step, isExcluded []bool) {
+	for {
+		added := 0
+		for i, step := range steps {
+			if isExcluded[i] {
+				continue
+			}
+			for _, link := range step.Requires() {
+				var createdByExcluded, createdByIncluded bool
+				for j, other := range steps {
+					if !HasAnyLinks([]StepLink{link}, other.Creates()) {
+						continue
+					}
+					if isExcluded[j] {
+						createdByExcluded = true
+					} else {
+						createdByIncluded = true
+					}
+				}
+				if createdByExcluded && !createdByIncluded {
+					isExcluded[i] = true
+					added++
+					break
+				}
+			}
+		}
+		if added == 0 {
+			return
+		}
+	}
+}
+
 // TopologicalSort validates nodes form a DAG and orders them topologically.
+// The order is deterministic: steps are ordered by the round in which all
+// they require is created, and by name within a round. A cycle is reported
+// once as a *CycleError with the steps that form it.
 func (g StepGraph) TopologicalSort() (OrderedStepList, []error) {
-	var ret OrderedStepList
-	var satisfied []StepLink
-	if err := iterateDAG(g, nil, sets.New[string](), func(*StepNode) {}); err != nil {
+	if err := findCycles(g); err != nil {
 		return nil, err
 	}
-	seen := make(map[Step]struct{})
-	for len(g) > 0 {
-		var changed bool
-		var waiting []*StepNode
-		for _, node := range g {
-			for _, child := range node.Children {
-				if _, ok := seen[child.Step]; !ok {
-					waiting = append(waiting, child)
-				}
-			}
-			if _, ok := seen[node.Step]; ok {
-				continue
-			}
-			if !HasAllLinks(node.Step.Requires(), satisfied) {
+	var pending []*StepNode
+	g.IterateAllEdges(func(node *StepNode) {
+		pending = append(pending, node)
+	})
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Step.Name() < pending[j].Step.Name()
+	})
+	var ret OrderedStepList
+	var satisfied []StepLink
+	for len(pending) > 0 {
+		var ready, waiting []*StepNode
+		for _, node := range pending {
+			if HasAllLinks(node.Step.Requires(), satisfied) {
+				ready = append(ready, node)
+			} else {
 				waiting = append(waiting, node)
-				continue
 			}
+		}
+		if len(ready) == 0 {
+			return nil, missingDependencies(waiting, satisfied)
+		}
+		for _, node := range ready {
 			satisfied = append(satisfied, node.Step.Creates()...)
 			ret = append(ret, node)
-			seen[node.Step] = struct{}{}
-			changed = true
-		}
-		if !changed && len(waiting) > 0 {
-			errMessages := sets.Set[string]{}
-			for _, node := range waiting {
-				missing := sets.Set[string]{}
-				for _, link := range node.Step.Requires() {
-					if !HasAllLinks([]StepLink{link}, satisfied) {
-						if msg := link.UnsatisfiableError(); msg != "" {
-							missing.Insert(msg)
-						} else {
-							missing.Insert(fmt.Sprintf("<%#v>", link))
-						}
-					}
+		}
+		pending = waiting
+	}
+	return ret, nil
+}
+
+// missingDependencies reports the steps that cannot run because they require
+// something no step creates. Steps only waiting for other steps that cannot
+// run are not reported, unless no step is left otherwise.
+func missingDependencies(waiting []*StepNode, satisfied []StepLink) []error {
+	var pending []StepLink
+	for _, node := range waiting {
+		pending = append(pending, node.Step.Creates()...)
+	}
+	available := append(append([]StepLink{}, satisfied...), pending...)
+	errMessages := sets.Set[string]{}
+	allMessages := sets.Set[string]{}
+	for _, node := range waiting {
+		var missing []StepLink
+		var blocked bool
+		for _, link := range node.Step.Requires() {
+			if !HasAllLinks([]StepLink{link}, satisfied) {
+				missing = append(missing, link)
+				if !HasAllLinks([]StepLink{link}, pending) {
+					blocked = true
 				}
-				// De-Duplicate errors
-				errMessages.Insert(fmt.Sprintf("step %s is missing dependencies: %s", node.Step.Name(), strings.Join(sets.List(missing), ", ")))
-			}
-			ret := make([]error, 0, errMessages.Len()+1)
-			ret = append(ret, errors.New("steps are missing dependencies"))
-			for _, message := range sets.List(errMessages) {
-				ret = append(ret, errors.New(message))
 			}
-			return nil, ret
 		}
-		g = waiting
+		// De-Duplicate errors
+		message := missingDependencyReport(node.Step, missing, waiting, available)
+		allMessages.Insert(message)
+		if blocked {
+			errMessages.Insert(message)
+		}
 	}
-	return ret, nil
+	if errMessages.Len() == 0 {
+		errMessages = allMessages
+	}
+	ret := make([]error, 0, errMessages.Len()+1)
+	ret = append(ret, errors.New("steps are missing dependencies"))
+	for _, message := range sets.List(errMessages) {
+		ret = append(ret, errors.New(message))
+	}
+	return ret
+}
+
+// CycleError is a cycle in the graph. Path holds the names of the steps that
+// form the cycle, each creating something the next one requires, starting
+// and ending with the same step.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("cycle in graph: %s", strings.Join(e.Path, " -> "))
 }
 
-// iterateDAG applies a function to every node of a DAG, detecting cycles.
-func iterateDAG(graph StepGraph, path []string, inPath sets.Set[string], f func(*StepNode)) (ret []error) {
+// findCycles returns every cycle of the graph once, ordered by their path.
+func findCycles(graph StepGraph) []error {
+	cycles := map[string]*CycleError{}
+	iterateDAG(graph, nil, sets.New[string](), func(cycle []string) {
+		err := &CycleError{Path: normalizeCycle(cycle)}
+		cycles[err.Error()] = err
+	})
+	var ret []error
+	for _, key := range sets.List(sets.KeySet(cycles)) {
+		ret = append(ret, cycles[key])
+	}
+	return ret
+}
+
+// normalizeCycle rotates the cycle to start with its first step by name, so
+// that the same cycle found from different steps is reported once.
+func normalizeCycle(cycle []string) []string {
+	steps := cycle[:len(cycle)-1]
+	first := 0
+	for i, name := range steps {
+		if name < steps[first] {
+			first = i
+		}
+	}
+	ret := append(append([]string{}, steps[first:]...), steps[:first]...)
+	return append(ret, ret[0])
+}
+
+// iterateDAG walks every path of the graph, calling cycle with the steps
+// of every cycle it finds, the step that closes it repeated at the end.
+func iterateDAG(graph StepGraph, path []string, inPath sets.Set[string], cycle func([]string)) {
 	for _, node := range graph {
 		name := node.Step.Name()
 		if inPath.Has(name) {
-			ret = append(ret, fmt.Errorf("cycle in graph: %s -> %s", strings.Join(path, " -> "), name))
+			for i := range path {
+				if path[i] == name {
+					cycle(append(append([]string{}, path[i:]...), name))
+					break
+				}
+			}
 			continue
 		}
 		inPath.Insert(name)
-		ret = append(ret, iterateDAG(node.Children, append(path, name), inPath, f)...)
+		iterateDAG(node.Children, append(path, name), inPath, cycle)
 		inPath.Delete(name)
-		f(node)
 	}
-	return ret
 }
 
 // IterateAllEdges applies an operation to every node in the graph once.
@@ -565,6 +838,9 @@ func mergeSteps(into, from CIOperatorStepDetails) CIOperatorStepDetails {
 	if into.Failed == nil {
 		into.Failed = from.Failed
 	}
+	if into.AttemptNames == nil {
+		into.AttemptNames = from.AttemptNames
+	}
 	if into.Substeps == nil {
 		into.Substeps = from.Substeps
 	}
@@ -589,6 +865,9 @@ type CIOperatorStepDetailInfo struct {
 	Manifests    []ctrlruntimeclient.Object `json:"manifests,omitempty"`
 	LogURL       string                     `json:"log_url,omitempty"`
 	Failed       *bool                      `json:"failed,omitempty"`
+	// AttemptNames maps the names of the objects the step created to the
+	// names they were given for this attempt, when they differ.
+	AttemptNames map[string]string `json:"attempt_names,omitempty"`
 }
 
 func (c *CIOperatorStepDetailInfo) UnmarshalJSON(data []byte) error {
@@ -628,6 +907,14 @@ func (c *CIOperatorStepDetailInfo) UnmarshalJSON(data []byte) error {
 
 const CIOperatorStepGraphJSONFilename = "ci-operator-step-graph.json"
 
+// CIOperatorDryRunFilename is the artifact that holds the actions of the
+// steps when ci-operator is asked for a dry run.
+const CIOperatorDryRunFilename = "ci-operator-dry-run.yaml"
+
+// CIOperatorParametersFilename is the artifact that documents the parameters
+// available to the steps of a job.
+const CIOperatorParametersFilename = "parameters.json"
+
 // StepGraphJSONURL takes a base url like https://storage.googleapis.com/origin-ci-test/pr-logs/pull/openshift_ci-tools/999/pull-ci-openshift-ci-tools-master-validate-vendor/1283812971092381696
 // and returns the full url for the step graph json document.
 func StepGraphJSONURL(baseJobURL string) string {
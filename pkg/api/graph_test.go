@@ -271,6 +271,138 @@ func (f *fakeSortStep) Requires() (ret []StepLink) {
 	return
 }
 
+func TestBuildPartialGraph(t *testing.T) {
+	src := &fakeStep{
+		name:    "src",
+		creates: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceSource)},
+	}
+	bin := &fakeStep{
+		name:     "bin",
+		requires: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceSource)},
+		creates:  []StepLink{InternalImageLink(PipelineImageStreamTagReferenceBinaries)},
+	}
+	images := &fakeStep{
+		name:     "images",
+		requires: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceBinaries)},
+		creates:  []StepLink{ImagesReadyLink()},
+	}
+	unit := &fakeStep{
+		name:     "unit",
+		requires: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceSource)},
+	}
+	e2eAWS := &fakeStep{
+		name:     "e2e-aws",
+		requires: []StepLink{ImagesReadyLink()},
+	}
+	e2eGCP := &fakeStep{
+		name:     "e2e-gcp",
+		requires: []StepLink{ImagesReadyLink()},
+	}
+	steps := []Step{src, bin, images, unit, e2eAWS, e2eGCP}
+
+	for _, tc := range []struct {
+		name          string
+		targets       []string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "exact names",
+			targets:  []string{"unit"},
+			expected: []string{"src", "unit"},
+		},
+		{
+			name:     "pattern selects every match",
+			targets:  []string{"e2e-*"},
+			expected: []string{"bin", "e2e-aws", "e2e-gcp", "images", "src"},
+		},
+		{
+			name:     "pattern with exclusion",
+			targets:  []string{"e2e-*", "!e2e-gcp"},
+			expected: []string{"bin", "e2e-aws", "images", "src"},
+		},
+		{
+			name:     "only exclusions drop the excluded subtree",
+			targets:  []string{"!images"},
+			expected: []string{"bin", "src", "unit"},
+		},
+		{
+			name:     "pattern matching only excluded steps",
+			targets:  []string{"e2e-*", "!images"},
+			expected: nil,
+		},
+		{
+			name:          "pattern without match",
+			targets:       []string{"e2e-*", "integration-*"},
+			expectedError: "the following patterns did not match any step: integration-* (from src, bin, images, unit, e2e-aws, e2e-gcp)",
+		},
+		{
+			name:          "invalid pattern",
+			targets:       []string{"e2e-[*"},
+			expectedError: `invalid target pattern "e2e-[*": syntax error in pattern`,
+		},
+		{
+			name:          "target that is excluded",
+			targets:       []string{"e2e-aws", "!e2e-*"},
+			expectedError: "target e2e-aws is excluded",
+		},
+		{
+			name:          "target depending on an excluded step",
+			targets:       []string{"e2e-aws", "!images"},
+			expectedError: "target e2e-aws is excluded",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			graph, err := BuildPartialGraph(steps, tc.targets)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			testhelper.Diff(t, "error", actualError, tc.expectedError)
+			var actual []string
+			seen := map[string]bool{}
+			var collect func(nodes []*StepNode)
+			collect = func(nodes []*StepNode) {
+				for _, node := range nodes {
+					if !seen[node.Step.Name()] {
+						seen[node.Step.Name()] = true
+						actual = append(actual, node.Step.Name())
+					}
+					collect(node.Children)
+				}
+			}
+			collect(graph)
+			sort.Strings(actual)
+			testhelper.Diff(t, "steps", actual, tc.expected)
+		})
+	}
+}
+
+func TestTargetsSelect(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		targets  []string
+		step     string
+		expected bool
+	}{
+		{name: "no targets", step: "unit"},
+		{name: "by name", targets: []string{"unit"}, step: "unit", expected: true},
+		{name: "other name", targets: []string{"unit"}, step: "e2e"},
+		{name: "by pattern", targets: []string{"e2e-*"}, step: "e2e-aws", expected: true},
+		{name: "excluded", targets: []string{"e2e-*", "!e2e-gcp"}, step: "e2e-gcp"},
+		{name: "excluded by pattern", targets: []string{"e2e-aws", "!e2e-*"}, step: "e2e-aws"},
+		{name: "only exclusions select the rest", targets: []string{"!images"}, step: "unit", expected: true},
+		{name: "only exclusions", targets: []string{"!images"}, step: "images"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := TargetsSelect(testCase.targets, testCase.step); actual != testCase.expected {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
 func TestTopologicalSort(t *testing.T) {
 	t.Parallel()
 	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -300,16 +432,14 @@ func TestTopologicalSort(t *testing.T) {
 	}, {
 		name: "missing dependency",
 		expected: []error{
-			errors.New(`step missing0 is missing dependencies: <api.fakeSortLink{name:"missing1"}>`),
+			errors.New("step missing0 is missing dependencies:\n  * {missing1}"),
 			errors.New("steps are missing dependencies"),
 		},
 		steps: []Step{&missing0},
 	}, {
 		name: "cycle",
 		expected: []error{
-			errors.New("cycle in graph: cycle0 -> cycle1 -> cycle2 -> cycle3 -> cycle1"),
-			errors.New("cycle in graph: cycle0 -> cycle2 -> cycle3 -> cycle1 -> cycle2"),
-			errors.New("cycle in graph: cycle0 -> cycle3 -> cycle1 -> cycle2 -> cycle3"),
+			errors.New("cycle in graph: cycle1 -> cycle2 -> cycle3 -> cycle1"),
 		},
 		steps: []Step{&cycle0, &cycle1, &cycle2, &cycle3},
 	}} {
@@ -356,6 +486,67 @@ func TestTopologicalSort(t *testing.T) {
 	}
 }
 
+func TestTopologicalSortIsDeterministic(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	steps := []Step{
+		&fakeSortStep{name: "root"},
+		&fakeSortStep{name: "src", requires: []string{"root"}},
+		&fakeSortStep{name: "img2", requires: []string{"root"}},
+		&fakeSortStep{name: "bin", requires: []string{"src"}},
+		&fakeSortStep{name: "img1", requires: []string{"bin"}},
+		&fakeSortStep{name: "img0", requires: []string{"root", "bin"}},
+	}
+	// steps are ordered by round, and by name within a round
+	expected := []string{"root", "img2", "src", "bin", "img0", "img1"}
+	for i := 0; i < 20; i++ {
+		rnd.Shuffle(len(steps), func(i, j int) {
+			steps[i], steps[j] = steps[j], steps[i]
+		})
+		nodes, errs := BuildGraph(steps).TopologicalSort()
+		if len(errs) != 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		var actual []string
+		for _, node := range nodes {
+			actual = append(actual, node.Step.Name())
+		}
+		testhelper.Diff(t, "order", actual, expected)
+	}
+}
+
+func TestTopologicalSortCycles(t *testing.T) {
+	steps := []Step{
+		&fakeSortStep{name: "root"},
+		&fakeSortStep{name: "b", requires: []string{"root", "a"}},
+		&fakeSortStep{name: "a", requires: []string{"root", "b"}},
+		&fakeSortStep{name: "z", requires: []string{"root", "z"}},
+	}
+	_, errs := BuildGraph(steps).TopologicalSort()
+	var cycles [][]string
+	for _, err := range errs {
+		var cycle *CycleError
+		if !errors.As(err, &cycle) {
+			t.Fatalf("expected a cycle error, got %v", err)
+		}
+		cycles = append(cycles, cycle.Path)
+	}
+	testhelper.Diff(t, "cycles", cycles, [][]string{{"a", "b", "a"}, {"z", "z"}})
+}
+
+func TestNormalizeCycle(t *testing.T) {
+	for _, tc := range []struct {
+		cycle    []string
+		expected []string
+	}{
+		{cycle: []string{"a", "a"}, expected: []string{"a", "a"}},
+		{cycle: []string{"a", "b", "c", "a"}, expected: []string{"a", "b", "c", "a"}},
+		{cycle: []string{"c", "a", "b", "c"}, expected: []string{"a", "b", "c", "a"}},
+	} {
+		testhelper.Diff(t, "cycle", normalizeCycle(tc.cycle), tc.expected)
+	}
+}
+
 func TestReleaseNames(t *testing.T) {
 	var testCases = []string{
 		LatestReleaseName,
@@ -127,6 +127,10 @@ func insertTagReferencesFromSteps(config api.MultiStageTestConfigurationLiteral,
 }
 
 func insert(item api.ImageStreamTagReference, m map[string]types.NamespacedName) {
+	if item.PullSpec != "" {
+		// images imported from external registries are not image stream tags
+		return
+	}
 	if _, ok := m[imageStreamTagReferenceToString(item)]; ok {
 		return
 	}
@@ -104,6 +104,23 @@ func TestTestInputImageStreamTagsFromConfigParsing(t *testing.T) {
 	}
 }
 
+func TestTestInputImageStreamTagsFromResolvedConfigSkipsPullSpecs(t *testing.T) {
+	t.Parallel()
+	config := api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{BaseImages: map[string]api.ImageStreamTagReference{
+			"external": {PullSpec: "quay.io/org/repo:tag"},
+			"os":       {Namespace: "ocp", Name: "builder", Tag: "rhel-9"},
+		}},
+	}
+	result, err := TestInputImageStreamTagsFromResolvedConfig(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "ocp/builder:rhel-9"; result.String() != expected {
+		t.Errorf("expected only %s, got %s", expected, result.String())
+	}
+}
+
 func TestTestInputImageStreamTagsFromResolvedConfigErrorsOnUnresolvedConfig(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"path"
 	"runtime/debug"
 
 	"github.com/sirupsen/logrus"
@@ -12,6 +13,14 @@ import (
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 )
 
+const (
+	// AttemptArtifactDirPrefix prefixes the artifact directory of an attempt.
+	AttemptArtifactDirPrefix = "attempt-"
+	// FinalAttemptArtifactDir links to the artifact directory of the last
+	// attempt that ran.
+	FinalAttemptArtifactDir = "attempt-final"
+)
+
 // JobSpec is a superset of the upstream spec.
 // +k8s:deepcopy-gen=false
 type JobSpec struct {
@@ -27,14 +36,32 @@ type JobSpec struct {
 	// if set, any new artifacts will be a child of this object
 	owner *meta.OwnerReference
 
+	// if set, identifies this execution in the names of the pods
+	// and template instances it creates
+	attempt string
+
+	// inputHash identifies the inputs of the job
+	inputHash string
+
 	Metadata               Metadata
 	Target                 string
 	TargetAdditionalSuffix string
+
+	// ClusterMetadata describes the cloud the job runs on
+	ClusterMetadata ClusterMetadata
+
+	// parent is set for a test that runs in a namespace of its own, named
+	// after the namespace of the parent with namespaceSuffix appended
+	parent          *JobSpec
+	namespaceSuffix string
 }
 
 // Namespace returns the namespace of the job. Must not be evaluated
 // at step construction time because its unset there
 func (s *JobSpec) Namespace() string {
+	if s.parent != nil {
+		return fmt.Sprintf("%s-%s", s.parent.Namespace(), s.namespaceSuffix)
+	}
 	if s.namespace == "" {
 		logrus.Warn("Warning, namespace accessed before it was set, this is a bug in ci-operator. Stack:")
 		logrus.Warn(string(debug.Stack()))
@@ -46,11 +73,80 @@ func (s *JobSpec) SetNamespace(namespace string) {
 	s.namespace = namespace
 }
 
+// Dedicated returns the job spec of a test that runs in a namespace of its
+// own, named after the namespace of this job with the suffix appended. The
+// namespace, attempt and input hash are those of this job, even when they
+// are set after Dedicated was called.
+func (s *JobSpec) Dedicated(suffix string) *JobSpec {
+	spec := *s
+	spec.parent = s
+	spec.namespaceSuffix = suffix
+	return &spec
+}
+
+// Parent returns the job spec a dedicated job spec was derived from, if any.
+func (s *JobSpec) Parent() *JobSpec {
+	return s.parent
+}
+
+// SetAttempt makes the names of the pods and template instances that steps
+// create unique to this execution, so that retries in the same namespace
+// do not collide with the objects of earlier attempts.
+func (s *JobSpec) SetAttempt(attempt string) {
+	s.attempt = attempt
+}
+
+// NameForAttempt returns the name for an object a step creates for this
+// execution. The name is unchanged unless an attempt was set.
+func (s *JobSpec) NameForAttempt(name string) string {
+	if s.Attempt() == "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s", name, s.Attempt())
+}
+
+// SetInputHash records the hash of the inputs of the job.
+func (s *JobSpec) SetInputHash(hash string) {
+	s.inputHash = hash
+}
+
+// InputHash returns the hash of the inputs of the job, once resolved.
+func (s *JobSpec) InputHash() string {
+	if s.parent != nil {
+		return s.parent.InputHash()
+	}
+	return s.inputHash
+}
+
+// Attempt returns the identifier of this execution, if one was set.
+func (s *JobSpec) Attempt() string {
+	if s.parent != nil {
+		return s.parent.Attempt()
+	}
+	return s.attempt
+}
+
+// AttemptArtifactDir returns the directory, relative to the artifact
+// directory, in which the artifacts of a step are stored. When an attempt
+// is set, artifacts are laid out per attempt so that retries do not
+// overwrite each other.
+func (s *JobSpec) AttemptArtifactDir(dir string) string {
+	if s.Attempt() == "" {
+		return dir
+	}
+	return path.Join(AttemptArtifactDirPrefix+s.Attempt(), dir)
+}
+
 func (s *JobSpec) RawSpec() string {
 	return s.rawSpec
 }
 
+// Owner returns the object new objects are children of, if any. Objects in
+// a dedicated namespace have no owner since it would be in another namespace.
 func (s *JobSpec) Owner() *meta.OwnerReference {
+	if s.parent != nil {
+		return nil
+	}
 	return s.owner
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 )
 
@@ -43,3 +44,47 @@ func TestUniqueHash(t *testing.T) {
 		})
 	}
 }
+
+func TestNameForAttempt(t *testing.T) {
+	jobSpec := JobSpec{}
+	if actual := jobSpec.NameForAttempt("e2e"); actual != "e2e" {
+		t.Errorf("expected the name to be unchanged without an attempt, got %s", actual)
+	}
+	jobSpec.SetAttempt("1234")
+	if actual := jobSpec.NameForAttempt("e2e"); actual != "e2e-1234" {
+		t.Errorf("expected the name to include the attempt, got %s", actual)
+	}
+}
+
+func TestAttemptArtifactDir(t *testing.T) {
+	jobSpec := JobSpec{}
+	if actual := jobSpec.AttemptArtifactDir("e2e"); actual != "e2e" {
+		t.Errorf("expected the directory to be unchanged without an attempt, got %s", actual)
+	}
+	jobSpec.SetAttempt("1234")
+	if actual := jobSpec.AttemptArtifactDir("e2e"); actual != "attempt-1234/e2e" {
+		t.Errorf("expected the directory to be scoped to the attempt, got %s", actual)
+	}
+}
+
+func TestDedicatedJobSpec(t *testing.T) {
+	jobSpec := &JobSpec{}
+	jobSpec.SetOwner(&metav1.OwnerReference{Name: "owner"})
+	dedicated := jobSpec.Dedicated("e2e")
+	jobSpec.SetNamespace("ci-op-1234")
+	jobSpec.SetAttempt("2")
+	jobSpec.SetInputHash("hash")
+
+	if actual, expected := dedicated.Namespace(), "ci-op-1234-e2e"; actual != expected {
+		t.Errorf("expected namespace %s, got %s", expected, actual)
+	}
+	if actual, expected := dedicated.NameForAttempt("e2e"), "e2e-2"; actual != expected {
+		t.Errorf("expected name %s, got %s", expected, actual)
+	}
+	if actual, expected := dedicated.InputHash(), "hash"; actual != expected {
+		t.Errorf("expected input hash %s, got %s", expected, actual)
+	}
+	if dedicated.Owner() != nil {
+		t.Errorf("expected no owner, got %v", dedicated.Owner())
+	}
+}
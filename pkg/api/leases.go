@@ -17,3 +17,16 @@ func LeasesForTest(s *MultiStageTestConfigurationLiteral) (ret []StepLease) {
 	ret = append(ret, s.Leases...)
 	return
 }
+
+// LeasesForContainerTest returns the lease of the cluster profile of a
+// container test, if it declares one, like multi-stage tests acquire.
+func LeasesForContainerTest(c *ContainerTestConfiguration) []StepLease {
+	if c == nil || c.ClusterProfile == "" {
+		return nil
+	}
+	return []StepLease{{
+		ResourceType: c.ClusterProfile.LeaseType(),
+		Env:          DefaultLeaseEnv,
+		Count:        1,
+	}}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"reflect"
 	"testing"
 
 	"k8s.io/utils/diff"
@@ -47,3 +48,13 @@ func TestLeasesForTest(t *testing.T) {
 		})
 	}
 }
+
+func TestLeasesForContainerTest(t *testing.T) {
+	if leases := LeasesForContainerTest(&ContainerTestConfiguration{From: "src"}); leases != nil {
+		t.Errorf("expected no lease without a cluster profile, got %v", leases)
+	}
+	expected := []StepLease{{ResourceType: "aws-quota-slice", Env: DefaultLeaseEnv, Count: 1}}
+	if actual := LeasesForContainerTest(&ContainerTestConfiguration{From: "src", ClusterProfile: ClusterProfileAWS}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected leases: %s", diff.ObjectReflectDiff(expected, actual))
+	}
+}
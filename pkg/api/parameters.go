@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"os"
+	"sort"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -16,6 +17,57 @@ type Parameters interface {
 	Get(name string) (string, error)
 }
 
+// Parameter is a value a step provides to the steps that run after it,
+// exposed to them by name.
+// +k8s:deepcopy-gen=false
+type Parameter struct {
+	Name string
+	// Description documents the value for the users of the parameter.
+	Description string
+	// Value resolves the value, once the step that provides it has run.
+	Value func() (string, error)
+	// Link is the link a step needs to require to use the value, if any.
+	Link StepLink
+}
+
+// ParameterProvider is implemented by steps that describe the parameters
+// they provide, so they can be documented and linked to their consumers.
+// +k8s:deepcopy-gen=false
+type ParameterProvider interface {
+	Parameters() []Parameter
+}
+
+// ParametersFor returns the parameters the step provides, sorted by name.
+// Steps that are not a ParameterProvider are described by the names in
+// their ParameterMap.
+func ParametersFor(step Step) []Parameter {
+	var parameters []Parameter
+	if provider, ok := step.(ParameterProvider); ok {
+		parameters = append(parameters, provider.Parameters()...)
+	} else {
+		for name, fn := range step.Provides() {
+			parameters = append(parameters, Parameter{Name: name, Value: fn})
+		}
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		return parameters[i].Name < parameters[j].Name
+	})
+	return parameters
+}
+
+// ParameterMapFor maps the parameters by name, for the implementations of
+// Step.Provides by a ParameterProvider.
+func ParameterMapFor(parameters []Parameter) ParameterMap {
+	if len(parameters) == 0 {
+		return nil
+	}
+	m := make(ParameterMap, len(parameters))
+	for _, parameter := range parameters {
+		m[parameter.Name] = parameter.Value
+	}
+	return m
+}
+
 type overrideParameters struct {
 	params    Parameters
 	overrides map[string]string
@@ -81,6 +133,18 @@ func (p *DeferredParameters) Map() (map[string]string, error) {
 	return m, nil
 }
 
+// Resolved returns the values of the parameters that were resolved so far,
+// without resolving the others.
+func (p *DeferredParameters) Resolved() map[string]string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	m := make(map[string]string, len(p.values))
+	for k, v := range p.values {
+		m[k] = v
+	}
+	return m
+}
+
 func (p *DeferredParameters) Set(name, value string) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
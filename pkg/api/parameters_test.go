@@ -131,6 +131,23 @@ func TestDeferredParametersGetSet(t *testing.T) {
 	}
 }
 
+func TestDeferredParametersResolved(t *testing.T) {
+	dp := NewDeferredParameters(nil)
+	dp.Set("set", "value")
+	dp.Add("resolved", func() (string, error) { return "lazy", nil })
+	dp.Add("unresolved", func() (string, error) {
+		t.Error("unresolved parameter was resolved")
+		return "", nil
+	})
+	if _, err := dp.Get("resolved"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"set": "value", "resolved": "lazy"}
+	if actual := dp.Resolved(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("unexpected resolved parameters: %s", diff.ObjectReflectDiff(expected, actual))
+	}
+}
+
 func TestDeferredParametersParent(t *testing.T) {
 	for _, tc := range []struct {
 		name        string
@@ -203,3 +220,60 @@ func TestDeferredParametersParent(t *testing.T) {
 		})
 	}
 }
+
+type providerStep struct {
+	fakeStep
+	parameters []Parameter
+}
+
+func (s *providerStep) Parameters() []Parameter { return s.parameters }
+
+func TestParametersFor(t *testing.T) {
+	value := func() (string, error) { return "value", nil }
+	var testCases = []struct {
+		name     string
+		step     Step
+		expected []Parameter
+	}{{
+		name: "parameters of a provider are sorted",
+		step: &providerStep{parameters: []Parameter{
+			{Name: "LOCAL_IMAGE_SRC", Description: "source", Value: value, Link: InternalImageLink(PipelineImageStreamTagReferenceSource)},
+			{Name: "IMAGE_FORMAT", Description: "format", Value: value, Link: ImagesReadyLink()},
+		}},
+		expected: []Parameter{
+			{Name: "IMAGE_FORMAT", Description: "format", Link: ImagesReadyLink()},
+			{Name: "LOCAL_IMAGE_SRC", Description: "source", Link: InternalImageLink(PipelineImageStreamTagReferenceSource)},
+		},
+	}, {
+		name:     "steps that are not providers are described by their parameter map",
+		step:     &mapStep{parameters: ParameterMap{"B": value, "A": value}},
+		expected: []Parameter{{Name: "A"}, {Name: "B"}},
+	}, {
+		name: "steps without parameters",
+		step: &fakeStep{},
+	}}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := ParametersFor(testCase.step)
+			for i := range actual {
+				if v, err := actual[i].Value(); err != nil || v != "value" {
+					t.Errorf("parameter %s: unexpected value %q, error %v", actual[i].Name, v, err)
+				}
+				actual[i].Value = nil
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("unexpected parameters: %s", diff.ObjectReflectDiff(testCase.expected, actual))
+			}
+			if m := ParameterMapFor(ParametersFor(testCase.step)); len(m) != len(testCase.expected) {
+				t.Errorf("expected %d parameters in the map, got %d", len(testCase.expected), len(m))
+			}
+		})
+	}
+}
+
+type mapStep struct {
+	fakeStep
+	parameters ParameterMap
+}
+
+func (s *mapStep) Provides() ParameterMap { return s.parameters }
@@ -2,7 +2,9 @@ package api
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"text/template"
 
 	"github.com/sirupsen/logrus"
 
@@ -20,8 +22,44 @@ const (
 
 	PromotionStepName     = "promotion"
 	PromotionQuayStepName = "promotion-quay"
+	PushImagesStepName    = "push-images"
 )
 
+// PromotionTagTemplateData is the data available to promotion tag templates.
+type PromotionTagTemplateData struct {
+	Commit string
+	Branch string
+	Date   string
+}
+
+var (
+	promotionTagRegex        = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+	invalidPromotionTagChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+)
+
+// RenderPromotionTagTemplate renders a promotion tag template, ensuring the
+// result is a valid tag. Characters of the data that are not valid in a tag,
+// like the slashes in branch names, are replaced with dashes.
+func RenderPromotionTagTemplate(tagTemplate string, data PromotionTagTemplateData) (string, error) {
+	data = PromotionTagTemplateData{
+		Commit: invalidPromotionTagChars.ReplaceAllString(data.Commit, "-"),
+		Branch: invalidPromotionTagChars.ReplaceAllString(data.Branch, "-"),
+		Date:   invalidPromotionTagChars.ReplaceAllString(data.Date, "-"),
+	}
+	parsed, err := template.New("tag").Option("missingkey=error").Parse(tagTemplate)
+	if err != nil {
+		return "", fmt.Errorf("could not parse tag template %q: %w", tagTemplate, err)
+	}
+	var tag strings.Builder
+	if err := parsed.Execute(&tag, data); err != nil {
+		return "", fmt.Errorf("could not render tag template %q: %w", tagTemplate, err)
+	}
+	if !promotionTagRegex.MatchString(tag.String()) {
+		return "", fmt.Errorf("tag template %q rendered an invalid tag %q", tagTemplate, tag.String())
+	}
+	return tag.String(), nil
+}
+
 // PromotionTargets adapts the single-target configuration to the multi-target paradigm.
 // This function will be removed when the previous implementation is removed.
 func PromotionTargets(c *PromotionConfiguration) []PromotionTarget {
@@ -34,6 +72,7 @@ func PromotionTargets(c *PromotionConfiguration) []PromotionTarget {
 		Namespace:        c.Namespace,
 		Tag:              c.Tag,
 		TagByCommit:      c.TagByCommit,
+		TagTemplates:     c.TagTemplates,
 		ExcludedImages:   c.ExcludedImages,
 		AdditionalImages: c.AdditionalImages,
 		Disabled:         c.Disabled,
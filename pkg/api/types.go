@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
@@ -34,6 +35,11 @@ func IsPromotionJob(jobLabels map[string]string) bool {
 type ReleaseBuildConfiguration struct {
 	Metadata Metadata `json:"zz_generated_metadata"`
 
+	// ExpectedMetadata names the repository and branch this configuration
+	// is for. When it is set, ci-operator fails before running anything
+	// if the refs of the job are for another repository or branch.
+	ExpectedMetadata *ExpectedMetadata `json:"metadata,omitempty"`
+
 	InputConfiguration `json:",inline"`
 
 	// BinaryBuildCommands will create a "bin" image based on "src" that
@@ -61,6 +67,18 @@ type ReleaseBuildConfiguration struct {
 	// cloning from is ignored.
 	CanonicalGoRepository *string `json:"canonical_go_repository,omitempty"`
 
+	// CloneCredentials references a secret on the build cluster that
+	// is used to clone private repositories.
+	CloneCredentials *CloneCredentials `json:"clone_credentials,omitempty"`
+
+	// SourceCache caches the clone of the base revision of the repository
+	// in an image stream of the base namespace of ci-operator, shared by
+	// the jobs of the repository. Jobs that do not test pull requests add
+	// the clone to the cache and jobs of the same base revision clone their
+	// changes on top of it, as long as the build root image is the same.
+	// The clones of the five most recently cached base revisions are kept.
+	SourceCache bool `json:"source_cache,omitempty"`
+
 	// Images describes the images that are built
 	// baseImage the project as part of the release
 	// process. The name of each image is its "to" value
@@ -90,6 +108,50 @@ type ReleaseBuildConfiguration struct {
 	// input types. The special name '*' may be used to set default
 	// requests and limits.
 	Resources ResourceConfiguration `json:"resources,omitempty"`
+
+	// AllowedClusterScopedKinds lists the kinds of cluster-scoped objects,
+	// like ClusterRole, that templates in this configuration may create
+	// when ci-operator forbids cluster-scoped objects.
+	AllowedClusterScopedKinds []string `json:"allowed_cluster_scoped_kinds,omitempty"`
+
+	// AdditionalResources are objects created in the test namespace when it
+	// is set up, before any test runs, like a ConfigMap or an instance of a
+	// custom resource the tests need. They are not named resources, as that
+	// configures the resources of the steps.
+	AdditionalResources []AdditionalResource `json:"additional_resources,omitempty"`
+}
+
+// AdditionalResource is an object created in the test namespace. Exactly
+// one of the manifest or the file must be set.
+type AdditionalResource struct {
+	// Manifest is the object, as YAML or JSON.
+	Manifest string `json:"manifest,omitempty"`
+	// File is the path of a file holding the object, relative to the
+	// directory ci-operator runs in, which is usually the source under test.
+	File string `json:"file,omitempty"`
+}
+
+// CloneCredentialsType is the kind of credentials used to clone.
+type CloneCredentialsType string
+
+const (
+	// CloneCredentialsSSH clones with the private key in the `ssh-privatekey`
+	// key of the secret.
+	CloneCredentialsSSH CloneCredentialsType = "ssh"
+	// CloneCredentialsOAuth clones with the token in the `oauth-token` key of
+	// the secret.
+	CloneCredentialsOAuth CloneCredentialsType = "oauth"
+)
+
+// CloneCredentials references the secret holding the credentials used to
+// clone the repositories under test.
+type CloneCredentials struct {
+	// Namespace is the namespace of the secret.
+	Namespace string `json:"namespace"`
+	// Name is the name of the secret.
+	Name string `json:"name"`
+	// Type is the kind of credentials the secret holds, `ssh` or `oauth`.
+	Type CloneCredentialsType `json:"type"`
 }
 
 // Metadata describes the source repo for which a config is written
@@ -100,6 +162,14 @@ type Metadata struct {
 	Variant string `json:"variant,omitempty"`
 }
 
+// ExpectedMetadata names the repository and branch a configuration may run
+// for. Fields that are not set match any value.
+type ExpectedMetadata struct {
+	Org    string `json:"org,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
 // BuildsImage checks if an image is built by the release configuration.
 func (config ReleaseBuildConfiguration) BuildsImage(name string) bool {
 	for _, i := range config.Images {
@@ -380,9 +450,17 @@ type ImageStreamTagReference struct {
 
 	// As is an optional string to use as the intermediate name for this reference.
 	As string `json:"as,omitempty"`
+
+	// PullSpec is the pull spec of an image in an external registry, e.g.
+	// quay.io/org/repo:tag, to import instead of an image stream tag. It is
+	// only supported for base images, and excludes the other fields.
+	PullSpec string `json:"pull_spec,omitempty"`
 }
 
 func (i *ImageStreamTagReference) ISTagName() string {
+	if i.PullSpec != "" {
+		return i.PullSpec
+	}
 	return fmt.Sprintf("%s/%s:%s", i.Namespace, i.Name, i.Tag)
 }
 
@@ -453,6 +531,12 @@ type PromotionConfiguration struct {
 	// to be promoted.
 	TagByCommit bool `json:"tag_by_commit,omitempty"`
 
+	// TagTemplates are Go templates for additional tags each image
+	// is promoted as, like `sha-{{.Commit}}`. The templates can use
+	// the `.Commit` and `.Branch` that were built and the `.Date`
+	// of the promotion in the YYYYMMDD format.
+	TagTemplates []string `json:"tag_templates,omitempty"`
+
 	// ExcludedImages are image names that will not be promoted.
 	// Exclusions are made before additional_images are included.
 	// Use exclusions when you want to build images for testing
@@ -479,11 +563,51 @@ type PromotionConfiguration struct {
 	// bot uses this option to facilitate image sharing.
 	RegistryOverride string `json:"registry_override,omitempty"`
 
+	// RegistryMirror is a repository outside of the CI registry, like
+	// quay.io/org/prefix, the promoted images are also pushed to, tagged
+	// by the name they are promoted as. The push is retried on failure and
+	// every tag is verified to resolve to the digest of the promoted image.
+	// It needs the credentials given to ci-operator with
+	// --push-images-secret-dir.
+	RegistryMirror string `json:"registry_mirror,omitempty"`
+
 	// DisableBuildCache stops us from uploading the build cache.
 	// This is useful (only) for CI chat bot invocations where
 	// promotion does not imply output artifacts are being created
 	// for posterity.
 	DisableBuildCache bool `json:"disable_build_cache,omitempty"`
+
+	// RunIf determines the outcome of the run the promotion happens
+	// for. Images are only promoted when all steps succeeded by default.
+	RunIf RunCondition `json:"run_if,omitempty"`
+}
+
+// RunCondition determines the outcome of a run a post-step runs for.
+type RunCondition string
+
+const (
+	// RunIfSuccess runs the post-step only when everything before succeeded.
+	RunIfSuccess RunCondition = "success"
+	// RunIfFailure runs the post-step only when something before failed.
+	RunIfFailure RunCondition = "failure"
+	// RunIfAlways runs the post-step regardless of the outcome.
+	RunIfAlways RunCondition = "always"
+)
+
+// ValidRunConditions are the values accepted for `run_if`.
+var ValidRunConditions = []RunCondition{RunIfSuccess, RunIfFailure, RunIfAlways}
+
+// ShouldRun determines whether a post-step with the condition runs after
+// a run that failed or succeeded. An empty condition runs always.
+func (c RunCondition) ShouldRun(failed bool) bool {
+	switch c {
+	case RunIfSuccess:
+		return !failed
+	case RunIfFailure:
+		return failed
+	default:
+		return true
+	}
 }
 
 type PromotionTarget struct {
@@ -506,6 +630,12 @@ type PromotionTarget struct {
 	// to be promoted.
 	TagByCommit bool `json:"tag_by_commit,omitempty"`
 
+	// TagTemplates are Go templates for additional tags each image
+	// is promoted as, like `sha-{{.Commit}}`. The templates can use
+	// the `.Commit` and `.Branch` that were built and the `.Date`
+	// of the promotion in the YYYYMMDD format.
+	TagTemplates []string `json:"tag_templates,omitempty"`
+
 	// ExcludedImages are image names that will not be promoted.
 	// Exclusions are made before additional_images are included.
 	// Use exclusions when you want to build images for testing
@@ -732,6 +862,38 @@ type TestStepConfiguration struct {
 	// Timeout overrides maximum prowjob duration
 	Timeout *prowv1.Duration `json:"timeout,omitempty"`
 
+	// RunTimeout bounds how long ci-operator lets a container or multi-stage
+	// test run, not counting the time spent acquiring its leases, claiming
+	// its cluster or waiting for its gates. Tests running for longer are
+	// cancelled, which still runs their post steps and gathers their
+	// artifacts.
+	RunTimeout *prowv1.Duration `json:"run_timeout,omitempty"`
+
+	// Shards splits a container test into this many pods that run in
+	// parallel. Each pod is given its index in $SHARD_INDEX (starting
+	// at 0) and the number of shards in $SHARD_TOTAL and is expected to
+	// run its part of the tests. The test fails if any shard fails.
+	// When ci-operator is given the timings of the tests from previous
+	// runs, the tests assigned to the shard are listed in $SHARD_TESTS.
+	// The shard with the least expected duration is also given the tests
+	// of the other shards in $SHARD_EXCLUDED_TESTS and runs every test
+	// that is not listed there, so that new tests are run as well. The
+	// shard may report the durations of the tests it ran as JSON in
+	// $SHARD_TIMINGS_FILE to keep the timings up to date.
+	Shards int `json:"shards,omitempty"`
+
+	// Gates are conditions on external systems, like an artifact being
+	// published upstream, that must hold before the test starts. They
+	// are checked in order, each until it holds or times out.
+	Gates []StepGate `json:"gates,omitempty"`
+
+	// DedicatedNamespace runs a container or multi-stage test in a
+	// namespace of its own, named after the namespace of the job and the
+	// test, so that heavyweight tests do not share quotas and cleanup with
+	// the rest of the job. The images of the job are pulled from its
+	// namespace.
+	DedicatedNamespace *DedicatedNamespace `json:"dedicated_namespace,omitempty"`
+
 	// Only one of the following can be not-null.
 	ContainerTestConfiguration                                *ContainerTestConfiguration                                `json:"container,omitempty"`
 	MultiStageTestConfiguration                               *MultiStageTestConfiguration                               `json:"steps,omitempty"`
@@ -743,6 +905,7 @@ type TestStepConfiguration struct {
 	OpenshiftInstallerUPIClusterTestConfiguration             *OpenshiftInstallerUPIClusterTestConfiguration             `json:"openshift_installer_upi,omitempty"`
 	OpenshiftInstallerUPISrcClusterTestConfiguration          *OpenshiftInstallerUPISrcClusterTestConfiguration          `json:"openshift_installer_upi_src,omitempty"`
 	OpenshiftInstallerCustomTestImageClusterTestConfiguration *OpenshiftInstallerCustomTestImageClusterTestConfiguration `json:"openshift_installer_custom_test_image,omitempty"`
+	ManifestTestConfiguration                                 *ManifestTestConfiguration                                 `json:"manifests,omitempty"`
 }
 
 func (config TestStepConfiguration) TargetName() string {
@@ -753,6 +916,127 @@ func (config TestStepConfiguration) IsPeriodic() bool {
 	return config.Interval != nil || config.MinimumInterval != nil || config.Cron != nil || config.ReleaseController
 }
 
+// DedicatedNamespace configures the namespace a test runs in on its own. The
+// namespace is annotated for cleanup like the namespace of the job and is
+// given the secrets and image streams of the job.
+type DedicatedNamespace struct {
+	// Quota are the hard limits of the ResourceQuota of the namespace, by
+	// the name of the resource, like requests.cpu or pods. The namespace
+	// has no quota if not set.
+	Quota ResourceList `json:"quota,omitempty"`
+}
+
+// StepGate is a condition on an external system that must hold before a test
+// starts. Exactly one of `http`, `image_stream_tag` and `resource` must be set.
+type StepGate struct {
+	// HTTP waits for a URL to answer with a successful status. Only hosts
+	// ci-operator is configured to allow may be requested.
+	HTTP *HTTPGate `json:"http,omitempty"`
+	// ImageStreamTag waits for a tag to exist.
+	ImageStreamTag *ImageStreamTagGate `json:"image_stream_tag,omitempty"`
+	// Resource waits for a condition of a resource in the cluster to be True.
+	Resource *ResourceGate `json:"resource,omitempty"`
+	// Timeout bounds how long to wait for the gate, 30 minutes by default.
+	Timeout *prowv1.Duration `json:"timeout,omitempty"`
+	// Interval is how long to wait before checking the gate again, 10
+	// seconds by default. It doubles after every check, up to 5 minutes.
+	Interval *prowv1.Duration `json:"interval,omitempty"`
+}
+
+const (
+	DefaultGateTimeout  = 30 * time.Minute
+	DefaultGateInterval = 10 * time.Second
+	MaxGateInterval     = 5 * time.Minute
+)
+
+// GateTimeout is how long to wait for the gate to hold.
+func (g StepGate) GateTimeout() time.Duration {
+	if g.Timeout == nil {
+		return DefaultGateTimeout
+	}
+	return g.Timeout.Duration
+}
+
+// GateInterval is how long to wait before the first check is repeated.
+func (g StepGate) GateInterval() time.Duration {
+	if g.Interval == nil {
+		return DefaultGateInterval
+	}
+	return g.Interval.Duration
+}
+
+// HTTPGate holds once a GET request to the URL answers with a 2xx status.
+type HTTPGate struct {
+	URL string `json:"url"`
+}
+
+// ImageStreamTagGate holds once the image stream tag exists.
+type ImageStreamTagGate struct {
+	// Namespace of the image stream, the test namespace by default.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Tag       string `json:"tag"`
+}
+
+// ResourceGate holds once the resource has a status condition of the given
+// type whose status is True.
+type ResourceGate struct {
+	APIVersion string `json:"api_version"`
+	Kind       string `json:"kind"`
+	// Namespace of the resource, the test namespace by default. Other
+	// namespaces must be allowed by ci-operator.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Condition is the type of the condition, like Available.
+	Condition string `json:"condition"`
+	// ClusterScoped must be set for resources that are not namespaced, in
+	// which case Namespace must be empty. Cluster-scoped resources must be
+	// allowed by ci-operator.
+	ClusterScoped bool `json:"cluster_scoped,omitempty"`
+}
+
+// TestTimeout is how long ci-operator lets the test run, zero when unbounded.
+func (config TestStepConfiguration) TestTimeout() time.Duration {
+	if config.RunTimeout == nil {
+		return 0
+	}
+	return config.RunTimeout.Duration
+}
+
+// GetClusterProfile returns the cluster profile the test declares, if any.
+func (config TestStepConfiguration) GetClusterProfile() ClusterProfile {
+	switch {
+	case config.ContainerTestConfiguration != nil:
+		return config.ContainerTestConfiguration.ClusterProfile
+	case config.MultiStageTestConfigurationLiteral != nil:
+		return config.MultiStageTestConfigurationLiteral.ClusterProfile
+	case config.MultiStageTestConfiguration != nil:
+		return config.MultiStageTestConfiguration.ClusterProfile
+	case config.OpenshiftAnsibleClusterTestConfiguration != nil:
+		return config.OpenshiftAnsibleClusterTestConfiguration.ClusterProfile
+	case config.OpenshiftAnsibleSrcClusterTestConfiguration != nil:
+		return config.OpenshiftAnsibleSrcClusterTestConfiguration.ClusterProfile
+	case config.OpenshiftAnsibleCustomClusterTestConfiguration != nil:
+		return config.OpenshiftAnsibleCustomClusterTestConfiguration.ClusterProfile
+	case config.OpenshiftInstallerClusterTestConfiguration != nil:
+		return config.OpenshiftInstallerClusterTestConfiguration.ClusterProfile
+	case config.OpenshiftInstallerUPIClusterTestConfiguration != nil:
+		return config.OpenshiftInstallerUPIClusterTestConfiguration.ClusterProfile
+	case config.OpenshiftInstallerUPISrcClusterTestConfiguration != nil:
+		return config.OpenshiftInstallerUPISrcClusterTestConfiguration.ClusterProfile
+	case config.OpenshiftInstallerCustomTestImageClusterTestConfiguration != nil:
+		return config.OpenshiftInstallerCustomTestImageClusterTestConfiguration.ClusterProfile
+	default:
+		return ""
+	}
+}
+
+// ClusterProfileSecretName is the name of the Secret in the test namespace
+// that holds the cluster profile for the test.
+func ClusterProfileSecretName(test string) string {
+	return test + "-cluster-profile"
+}
+
 // Cloud is the name of a cloud provider, e.g., aws cluster topology, etc.
 type Cloud string
 
@@ -939,6 +1223,11 @@ type LiteralTestStep struct {
 	Dependencies []StepDependency `json:"dependencies,omitempty"`
 	// DnsConfig for step's Pod.
 	DNSConfig *StepDNSConfig `json:"dnsConfig,omitempty"`
+	// HostAliases are entries added to the /etc/hosts file of the step's Pod.
+	HostAliases []StepHostAlias `json:"host_aliases,omitempty"`
+	// RuntimeClass is the name of the RuntimeClass the step's Pod runs with,
+	// like a sandboxed runtime for untrusted code.
+	RuntimeClass string `json:"runtime_class,omitempty"`
 	// Leases lists resources that should be acquired for the test.
 	Leases []StepLease `json:"leases,omitempty"`
 	// OptionalOnSuccess defines if this step should be skipped as long
@@ -951,6 +1240,10 @@ type LiteralTestStep struct {
 	// to true in MultiStageTestConfiguration. This option is applicable to
 	// `post` steps.
 	BestEffort *bool `json:"best_effort,omitempty"`
+	// RunIf determines the outcome of the `pre` and `test` steps this
+	// step runs for: `success`, `failure` or `always`, the default. This
+	// option is applicable to `post` steps.
+	RunIf RunCondition `json:"run_if,omitempty"`
 	// NoKubeconfig determines that no $KUBECONFIG will exist in $SHARED_DIR,
 	// so no local copy of it will be created for the step and if the step
 	// creates one, it will not be propagated.
@@ -963,6 +1256,37 @@ type LiteralTestStep struct {
 	// RunAsScript defines if this step should be executed as a script mounted
 	// in the test container instead of being executed directly via bash
 	RunAsScript *bool `json:"run_as_script,omitempty"`
+	// Sidecars are containers run next to the test container of the step, in
+	// the same pod, e.g. a database the test reaches on localhost. The step
+	// succeeds or fails with the test container, and the sidecars are stopped
+	// once it exits.
+	Sidecars []Sidecar `json:"sidecars,omitempty"`
+	// Outputs are the names of values the step computes for the steps after
+	// it, like the URL of a cluster it provisioned. The step writes them as
+	// NAME=value lines to ${SHARED_DIR}/outputs.env. Once it succeeds, they
+	// are stored in a secret, set as <STEP>_<NAME> environment variables of
+	// the following steps of the test from it and provided as
+	// <TEST>_<STEP>_<NAME> parameters of the test.
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// Sidecar is a container run next to the test container of a step.
+type Sidecar struct {
+	// Name is the name of the container, unique in the step.
+	Name string `json:"name"`
+	// From is the image the container runs, resolved like the image of the step.
+	From string `json:"from"`
+	// Commands is the command(s) run in the container with /bin/sh.
+	Commands string `json:"commands"`
+	// Environment are the environment variables of the container.
+	Environment map[string]string `json:"env,omitempty"`
+	// Ports are the ports the container listens on.
+	Ports []int32 `json:"ports,omitempty"`
+	// Readiness is a command run in the container until it succeeds; the
+	// commands of the step only start once it does.
+	Readiness string `json:"readiness,omitempty"`
+	// Resources defines the resource requirements of the container.
+	Resources ResourceRequirements `json:"resources,omitempty"`
 }
 
 // StepParameter is a variable set by the test, with an optional default.
@@ -1005,6 +1329,15 @@ type StepDNSConfig struct {
 	Searches []string `json:"searches,omitempty"`
 }
 
+// StepHostAlias adds an entry to the /etc/hosts file of the Pod of a step,
+// for hostnames that are not in the cluster DNS.
+type StepHostAlias struct {
+	// IP is the address the hostnames resolve to.
+	IP string `json:"ip"`
+	// Hostnames are the names resolving to the IP.
+	Hostnames []string `json:"hostnames"`
+}
+
 // StepLease defines a resource that needs to be acquired prior to execution.
 // The resource name will be exposed to the step via the specificed environment
 // variable.
@@ -1072,6 +1405,9 @@ type MultiStageTestConfiguration struct {
 	AllowBestEffortPostSteps *bool `json:"allow_best_effort_post_steps,omitempty"`
 	// Observers are the observers that should be running
 	Observers *Observers `json:"observers,omitempty"`
+	// SharedVolume is a volume mounted into every step of the test, for data
+	// the steps pass on that does not fit into SHARED_DIR.
+	SharedVolume *SharedVolume `json:"shared_volume,omitempty"`
 	// DependencyOverrides allows a step to override a dependency with a fully-qualified pullspec. This will probably only ever
 	// be used with rehearsals. Otherwise, the overrides should be passed in as parameters to ci-operator.
 	DependencyOverrides DependencyOverrides `json:"dependency_overrides,omitempty"`
@@ -1109,6 +1445,9 @@ type MultiStageTestConfigurationLiteral struct {
 	AllowBestEffortPostSteps *bool `json:"allow_best_effort_post_steps,omitempty"`
 	// Observers are the observers that need to be run
 	Observers []Observer `json:"observers,omitempty"`
+	// SharedVolume is a volume mounted into every step of the test, for data
+	// the steps pass on that does not fit into SHARED_DIR.
+	SharedVolume *SharedVolume `json:"shared_volume,omitempty"`
 	// DependencyOverrides allows a step to override a dependency with a fully-qualified pullspec. This will probably only ever
 	// be used with rehearsals. Otherwise, the overrides should be passed in as parameters to ci-operator.
 	DependencyOverrides DependencyOverrides `json:"dependency_overrides,omitempty"`
@@ -1117,6 +1456,23 @@ type MultiStageTestConfigurationLiteral struct {
 	Timeout *prowv1.Duration `json:"timeout,omitempty"`
 }
 
+// SharedVolumeMountEnv is the parameter holding the path the shared volume
+// of a multi-stage test is mounted at.
+const SharedVolumeMountEnv = "SHARED_VOLUME_DIR"
+
+// SharedVolume is a PersistentVolumeClaim created for a multi-stage test and
+// mounted at $SHARED_VOLUME_DIR into each of its steps, which run one after
+// the other. Unlike SHARED_DIR, its content is not limited in size and is not
+// gathered as artifacts.
+type SharedVolume struct {
+	// Size is the requested size of the volume as a Kubernetes quantity,
+	// like 10Gi.
+	Size string `json:"size"`
+	// StorageClass is the storage class of the volume, the default storage
+	// class of the cluster if not set.
+	StorageClass string `json:"storage_class,omitempty"`
+}
+
 // TestEnvironment has the values of parameters for multi-stage tests.
 type TestEnvironment map[string]string
 
@@ -1155,6 +1511,74 @@ type ContainerTestConfiguration struct {
 	// If the step should clone the source code prior to running the command.
 	// Defaults to `true` for `base_images`, `false` otherwise.
 	Clone *bool `json:"clone,omitempty"`
+	// Expose makes a port of the test reachable by other tests with a
+	// Service, and optionally a Route, for as long as the test runs.
+	Expose *TestExposure `json:"expose,omitempty"`
+	// DnsConfig for the test's Pod.
+	DNSConfig *StepDNSConfig `json:"dnsConfig,omitempty"`
+	// HostAliases are entries added to the /etc/hosts file of the test's Pod.
+	HostAliases []StepHostAlias `json:"host_aliases,omitempty"`
+	// RuntimeClass is the name of the RuntimeClass the test's Pod runs with,
+	// like a sandboxed runtime for untrusted code.
+	RuntimeClass string `json:"runtime_class,omitempty"`
+	// ClusterProfile mounts the credentials of the profile into the test's
+	// Pod at $CLUSTER_PROFILE_DIR and sets $CLUSTER_TYPE for it, and leases
+	// a resource of the profile into $LEASED_RESOURCE, like for the steps of
+	// multi-stage tests.
+	ClusterProfile ClusterProfile `json:"cluster_profile,omitempty"`
+}
+
+// TestExposure exposes a port of the pod of a test to the other tests. The
+// URL it is reachable at is provided as the <AS>_URL parameter, which steps
+// of multi-stage tests receive when they list it in their `env`. The tests
+// are not ordered, so consumers need to wait for the URL to answer.
+type TestExposure struct {
+	// Port is the port the test listens on.
+	Port int32 `json:"port"`
+	// Route also exposes the port outside of the cluster with a Route, whose
+	// host is used in the URL.
+	Route bool `json:"route,omitempty"`
+}
+
+// ManifestTestConfiguration describes a test that applies Kubernetes
+// manifests, like Deployments, Jobs or custom resources, to the test
+// namespace and waits for one of its Pods or Jobs to complete. Parameters
+// like ${NAMESPACE} or ${IMAGE_FORMAT} are substituted in the manifests, the
+// way they are in templates.
+type ManifestTestConfiguration struct {
+	// Objects are the manifests applied to the test namespace, in order.
+	// They must not set their namespace.
+	Objects []AdditionalResource `json:"objects"`
+	// WaitFor is the Pod or Job of the manifests the test waits for. The
+	// logs of the Pod, or of every Pod of the Job, are gathered, along with
+	// the artifacts of those with an `artifacts` volume.
+	WaitFor ManifestWaitFor `json:"wait_for"`
+}
+
+// ManifestWaitFor identifies an object of the manifests of a test.
+type ManifestWaitFor struct {
+	// Kind is the kind of the object, either Pod or Job.
+	Kind string `json:"kind"`
+	// Name is the name of the object.
+	Name string `json:"name"`
+}
+
+// StepOutputEnv is the variable holding the output of a step in the
+// environment of the steps after it.
+func StepOutputEnv(step, name string) string {
+	return strings.ToUpper(strings.ReplaceAll(step, "-", "_")) + "_" + name
+}
+
+// TestStepOutputEnv is the parameter holding the output of a step of the
+// multi-stage test with the given name.
+func TestStepOutputEnv(as, step, name string) string {
+	return strings.ToUpper(strings.ReplaceAll(as, "-", "_")) + "_" + StepOutputEnv(step, name)
+}
+
+// ExposedURLEnv is the parameter holding the URL of the test exposed under
+// the given name.
+func ExposedURLEnv(as string) string {
+	return strings.ToUpper(strings.ReplaceAll(as, "-", "_")) + "_URL"
 }
 
 // ClusterProfile is the name of a set of input variables
@@ -1820,6 +2244,18 @@ type OpenshiftInstallerClusterTestConfiguration struct {
 	// will be upgraded. The `run-upgrade-tests` function will be
 	// available for the commands.
 	Upgrade bool `json:"upgrade,omitempty"`
+	// FromRelease is the name of the release that is installed before
+	// upgrading to the release built by the job. Defaults to `initial`
+	// and may only be set for upgrade tests.
+	FromRelease string `json:"from_release,omitempty"`
+}
+
+// UpgradeFrom returns the name of the release that upgrade tests install.
+func (c OpenshiftInstallerClusterTestConfiguration) UpgradeFrom() string {
+	if c.FromRelease != "" {
+		return c.FromRelease
+	}
+	return InitialReleaseName
 }
 
 // OpenshiftInstallerSrcClusterTestConfiguration describes a
@@ -1912,6 +2348,10 @@ type SourceStepConfiguration struct {
 	// ClonerefsPath is the path in the above image where the
 	// clonerefs tool is placed
 	ClonerefsPath string `json:"clonerefs_path"`
+
+	// Cache clones the source code on top of the clone of the same base
+	// revision cached by an earlier job, see SourceCache.
+	Cache bool `json:"cache,omitempty"`
 }
 
 func (config SourceStepConfiguration) TargetName() string {
@@ -2047,12 +2487,58 @@ type ProjectDirectoryImageBuildStepConfiguration struct {
 	// promoted unless explicitly targeted. Use for builds which
 	// are invoked only when testing certain parts of the repo.
 	Optional bool `json:"optional,omitempty"`
+
+	// CacheFromPromoted enables the layer cache for the build, using
+	// the image previously promoted for this component as the cache
+	// source. Has no effect if the image is not promoted or if it has
+	// not been promoted yet.
+	CacheFromPromoted bool `json:"cache_from_promoted,omitempty"`
+
+	// ReusePromoted skips the build if the image previously promoted for
+	// this component was built from the same source revisions, build
+	// configuration and pipeline images, and uses the promoted image
+	// instead. Images the Dockerfile pulls from outside of the pipeline
+	// are not considered, so do not use it for builds that depend on them.
+	ReusePromoted bool `json:"reuse_promoted,omitempty"`
+
+	// ContextIncludes limits the build context to these paths, relative
+	// to the context_dir, instead of copying the whole directory. The
+	// Dockerfile is always included.
+	ContextIncludes []string `json:"context_includes,omitempty"`
+
+	// ContextExcludes are .dockerignore patterns for files in the build
+	// context that the build does not use. They are added after the
+	// patterns of any .dockerignore in the context_dir.
+	ContextExcludes []string `json:"context_excludes,omitempty"`
+
+	// Timeout is how long a single attempt of the build may take before
+	// it is cancelled and the step fails.
+	Timeout *prowv1.Duration `json:"timeout,omitempty"`
+
+	// NoOutputTimeout is how long the build may run without writing to its
+	// log before it is considered stalled, cancelled and retried. Defaults
+	// to 20 minutes.
+	NoOutputTimeout *prowv1.Duration `json:"no_output_timeout,omitempty"`
+
+	// RunTimeout bounds how long ci-operator lets the build step run,
+	// including the retries of the build. A step running for longer is
+	// cancelled and fails with a timeout.
+	RunTimeout *prowv1.Duration `json:"run_timeout,omitempty"`
 }
 
 func (config ProjectDirectoryImageBuildStepConfiguration) TargetName() string {
 	return string(config.To)
 }
 
+// BuildTimeout is how long ci-operator lets the build step run, zero when
+// unbounded.
+func (config ProjectDirectoryImageBuildStepConfiguration) BuildTimeout() time.Duration {
+	if config.RunTimeout == nil {
+		return 0
+	}
+	return config.RunTimeout.Duration
+}
+
 // ProjectDirectoryImageBuildInputs holds inputs for an image build from the repo under test
 type ProjectDirectoryImageBuildInputs struct {
 	// ContextDir is the directory in the project
@@ -343,3 +343,30 @@ func TestInputImageTagStepConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClusterProfile(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config TestStepConfiguration
+		want   ClusterProfile
+	}{{
+		name:   "container test has no profile",
+		config: TestStepConfiguration{ContainerTestConfiguration: &ContainerTestConfiguration{From: "src"}},
+	}, {
+		name:   "multi-stage test",
+		config: TestStepConfiguration{MultiStageTestConfigurationLiteral: &MultiStageTestConfigurationLiteral{ClusterProfile: ClusterProfileAWS}},
+		want:   ClusterProfileAWS,
+	}, {
+		name: "template test",
+		config: TestStepConfiguration{OpenshiftInstallerClusterTestConfiguration: &OpenshiftInstallerClusterTestConfiguration{
+			ClusterTestConfiguration: ClusterTestConfiguration{ClusterProfile: ClusterProfileGCP},
+		}},
+		want: ClusterProfileGCP,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if ret := tc.config.GetClusterProfile(); ret != tc.want {
+				t.Errorf("got %v, want %v", ret, tc.want)
+			}
+		})
+	}
+}
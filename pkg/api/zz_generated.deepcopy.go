@@ -9,6 +9,21 @@ import (
 	"k8s.io/test-infra/prow/apis/prowjobs/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalResource) DeepCopyInto(out *AdditionalResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalResource.
+func (in *AdditionalResource) DeepCopy() *AdditionalResource {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildArg) DeepCopyInto(out *BuildArg) {
 	*out = *in
@@ -131,6 +146,21 @@ func (in *ClaimRelease) DeepCopy() *ClaimRelease {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneCredentials) DeepCopyInto(out *CloneCredentials) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneCredentials.
+func (in *CloneCredentials) DeepCopy() *CloneCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterClaim) DeepCopyInto(out *ClusterClaim) {
 	*out = *in
@@ -186,6 +216,23 @@ func (in *ContainerTestConfiguration) DeepCopyInto(out *ContainerTestConfigurati
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(TestExposure)
+		**out = **in
+	}
+	if in.DNSConfig != nil {
+		in, out := &in.DNSConfig, &out.DNSConfig
+		*out = new(StepDNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostAliases != nil {
+		in, out := &in.HostAliases, &out.HostAliases
+		*out = make([]StepHostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerTestConfiguration.
@@ -213,6 +260,28 @@ func (in *CredentialReference) DeepCopy() *CredentialReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DedicatedNamespace) DeepCopyInto(out *DedicatedNamespace) {
+	*out = *in
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DedicatedNamespace.
+func (in *DedicatedNamespace) DeepCopy() *DedicatedNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(DedicatedNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in DependencyOverrides) DeepCopyInto(out *DependencyOverrides) {
 	{
@@ -234,6 +303,21 @@ func (in DependencyOverrides) DeepCopy() DependencyOverrides {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpectedMetadata) DeepCopyInto(out *ExpectedMetadata) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpectedMetadata.
+func (in *ExpectedMetadata) DeepCopy() *ExpectedMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpectedMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GraphConfiguration) DeepCopyInto(out *GraphConfiguration) {
 	*out = *in
@@ -256,6 +340,21 @@ func (in *GraphConfiguration) DeepCopy() *GraphConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPGate) DeepCopyInto(out *HTTPGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPGate.
+func (in *HTTPGate) DeepCopy() *HTTPGate {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageBuildInputs) DeepCopyInto(out *ImageBuildInputs) {
 	*out = *in
@@ -311,6 +410,21 @@ func (in *ImageStreamSource) DeepCopy() *ImageStreamSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageStreamTagGate) DeepCopyInto(out *ImageStreamTagGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageStreamTagGate.
+func (in *ImageStreamTagGate) DeepCopy() *ImageStreamTagGate {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageStreamTagGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageStreamTagReference) DeepCopyInto(out *ImageStreamTagReference) {
 	*out = *in
@@ -504,6 +618,13 @@ func (in *LiteralTestStep) DeepCopyInto(out *LiteralTestStep) {
 		*out = new(StepDNSConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HostAliases != nil {
+		in, out := &in.HostAliases, &out.HostAliases
+		*out = make([]StepHostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Leases != nil {
 		in, out := &in.Leases, &out.Leases
 		*out = make([]StepLease, len(*in))
@@ -534,6 +655,18 @@ func (in *LiteralTestStep) DeepCopyInto(out *LiteralTestStep) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]Sidecar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiteralTestStep.
@@ -546,6 +679,42 @@ func (in *LiteralTestStep) DeepCopy() *LiteralTestStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManifestTestConfiguration) DeepCopyInto(out *ManifestTestConfiguration) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]AdditionalResource, len(*in))
+		copy(*out, *in)
+	}
+	out.WaitFor = in.WaitFor
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManifestTestConfiguration.
+func (in *ManifestTestConfiguration) DeepCopy() *ManifestTestConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ManifestTestConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManifestWaitFor) DeepCopyInto(out *ManifestWaitFor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManifestWaitFor.
+func (in *ManifestWaitFor) DeepCopy() *ManifestWaitFor {
+	if in == nil {
+		return nil
+	}
+	out := new(ManifestWaitFor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemoryBackedVolume) DeepCopyInto(out *MemoryBackedVolume) {
 	*out = *in
@@ -660,6 +829,11 @@ func (in *MultiStageTestConfiguration) DeepCopyInto(out *MultiStageTestConfigura
 		*out = new(Observers)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SharedVolume != nil {
+		in, out := &in.SharedVolume, &out.SharedVolume
+		*out = new(SharedVolume)
+		**out = **in
+	}
 	if in.DependencyOverrides != nil {
 		in, out := &in.DependencyOverrides, &out.DependencyOverrides
 		*out = make(DependencyOverrides, len(*in))
@@ -744,6 +918,11 @@ func (in *MultiStageTestConfigurationLiteral) DeepCopyInto(out *MultiStageTestCo
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SharedVolume != nil {
+		in, out := &in.SharedVolume, &out.SharedVolume
+		*out = new(SharedVolume)
+		**out = **in
+	}
 	if in.DependencyOverrides != nil {
 		in, out := &in.DependencyOverrides, &out.DependencyOverrides
 		*out = make(DependencyOverrides, len(*in))
@@ -1124,6 +1303,31 @@ func (in *ProjectDirectoryImageBuildInputs) DeepCopy() *ProjectDirectoryImageBui
 func (in *ProjectDirectoryImageBuildStepConfiguration) DeepCopyInto(out *ProjectDirectoryImageBuildStepConfiguration) {
 	*out = *in
 	in.ProjectDirectoryImageBuildInputs.DeepCopyInto(&out.ProjectDirectoryImageBuildInputs)
+	if in.ContextIncludes != nil {
+		in, out := &in.ContextIncludes, &out.ContextIncludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContextExcludes != nil {
+		in, out := &in.ContextExcludes, &out.ContextExcludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.NoOutputTimeout != nil {
+		in, out := &in.NoOutputTimeout, &out.NoOutputTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RunTimeout != nil {
+		in, out := &in.RunTimeout, &out.RunTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectDirectoryImageBuildStepConfiguration.
@@ -1407,12 +1611,22 @@ func (in *Release) DeepCopy() *Release {
 func (in *ReleaseBuildConfiguration) DeepCopyInto(out *ReleaseBuildConfiguration) {
 	*out = *in
 	out.Metadata = in.Metadata
+	if in.ExpectedMetadata != nil {
+		in, out := &in.ExpectedMetadata, &out.ExpectedMetadata
+		*out = new(ExpectedMetadata)
+		**out = **in
+	}
 	in.InputConfiguration.DeepCopyInto(&out.InputConfiguration)
 	if in.CanonicalGoRepository != nil {
 		in, out := &in.CanonicalGoRepository, &out.CanonicalGoRepository
 		*out = new(string)
 		**out = **in
 	}
+	if in.CloneCredentials != nil {
+		in, out := &in.CloneCredentials, &out.CloneCredentials
+		*out = new(CloneCredentials)
+		**out = **in
+	}
 	if in.Images != nil {
 		in, out := &in.Images, &out.Images
 		*out = make([]ProjectDirectoryImageBuildStepConfiguration, len(*in))
@@ -1451,6 +1665,16 @@ func (in *ReleaseBuildConfiguration) DeepCopyInto(out *ReleaseBuildConfiguration
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.AllowedClusterScopedKinds != nil {
+		in, out := &in.AllowedClusterScopedKinds, &out.AllowedClusterScopedKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdditionalResources != nil {
+		in, out := &in.AdditionalResources, &out.AdditionalResources
+		*out = make([]AdditionalResource, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseBuildConfiguration.
@@ -1530,6 +1754,21 @@ func (in ResourceConfiguration) DeepCopy() ResourceConfiguration {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceGate) DeepCopyInto(out *ResourceGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceGate.
+func (in *ResourceGate) DeepCopy() *ResourceGate {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in ResourceList) DeepCopyInto(out *ResourceList) {
 	{
@@ -1595,6 +1834,49 @@ func (in *Secret) DeepCopy() *Secret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedVolume) DeepCopyInto(out *SharedVolume) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedVolume.
+func (in *SharedVolume) DeepCopy() *SharedVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Sidecar) DeepCopyInto(out *Sidecar) {
+	*out = *in
+	if in.Environment != nil {
+		in, out := &in.Environment, &out.Environment
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Sidecar.
+func (in *Sidecar) DeepCopy() *Sidecar {
+	if in == nil {
+		return nil
+	}
+	out := new(Sidecar)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SourceStepConfiguration) DeepCopyInto(out *SourceStepConfiguration) {
 	*out = *in
@@ -1731,6 +2013,66 @@ func (in *StepDependency) DeepCopy() *StepDependency {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepGate) DeepCopyInto(out *StepGate) {
+	*out = *in
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPGate)
+		**out = **in
+	}
+	if in.ImageStreamTag != nil {
+		in, out := &in.ImageStreamTag, &out.ImageStreamTag
+		*out = new(ImageStreamTagGate)
+		**out = **in
+	}
+	if in.Resource != nil {
+		in, out := &in.Resource, &out.Resource
+		*out = new(ResourceGate)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepGate.
+func (in *StepGate) DeepCopy() *StepGate {
+	if in == nil {
+		return nil
+	}
+	out := new(StepGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepHostAlias) DeepCopyInto(out *StepHostAlias) {
+	*out = *in
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepHostAlias.
+func (in *StepHostAlias) DeepCopy() *StepHostAlias {
+	if in == nil {
+		return nil
+	}
+	out := new(StepHostAlias)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StepLease) DeepCopyInto(out *StepLease) {
 	*out = *in
@@ -1823,6 +2165,21 @@ func (in TestEnvironment) DeepCopy() TestEnvironment {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestExposure) DeepCopyInto(out *TestExposure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestExposure.
+func (in *TestExposure) DeepCopy() *TestExposure {
+	if in == nil {
+		return nil
+	}
+	out := new(TestExposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TestStep) DeepCopyInto(out *TestStep) {
 	*out = *in
@@ -1902,6 +2259,23 @@ func (in *TestStepConfiguration) DeepCopyInto(out *TestStepConfiguration) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.RunTimeout != nil {
+		in, out := &in.RunTimeout, &out.RunTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Gates != nil {
+		in, out := &in.Gates, &out.Gates
+		*out = make([]StepGate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DedicatedNamespace != nil {
+		in, out := &in.DedicatedNamespace, &out.DedicatedNamespace
+		*out = new(DedicatedNamespace)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ContainerTestConfiguration != nil {
 		in, out := &in.ContainerTestConfiguration, &out.ContainerTestConfiguration
 		*out = new(ContainerTestConfiguration)
@@ -1952,6 +2326,11 @@ func (in *TestStepConfiguration) DeepCopyInto(out *TestStepConfiguration) {
 		*out = new(OpenshiftInstallerCustomTestImageClusterTestConfiguration)
 		**out = **in
 	}
+	if in.ManifestTestConfiguration != nil {
+		in, out := &in.ManifestTestConfiguration, &out.ManifestTestConfiguration
+		*out = new(ManifestTestConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestStepConfiguration.
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,6 +29,7 @@ import (
 	imagev1 "github.com/openshift/api/image/v1"
 	templateapi "github.com/openshift/api/template/v1"
 	buildclientset "github.com/openshift/client-go/build/clientset/versioned/typed/build/v1"
+	projectclientset "github.com/openshift/client-go/project/clientset/versioned/typed/project/v1"
 	templateclientset "github.com/openshift/client-go/template/clientset/versioned/typed/template/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
@@ -40,69 +42,99 @@ import (
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/clusterinstall"
+	"github.com/openshift/ci-tools/pkg/steps/labelingclient"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/multi_stage"
+	"github.com/openshift/ci-tools/pkg/steps/policyclient"
 	releasesteps "github.com/openshift/ci-tools/pkg/steps/release"
+	"github.com/openshift/ci-tools/pkg/steps/scopeclient"
 	"github.com/openshift/ci-tools/pkg/steps/secretrecordingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
 )
 
 type inputImageSet map[api.InputImage]struct{}
 
+// Options are the inputs the execution graph is generated from.
+type Options struct {
+	Config             *api.ReleaseBuildConfiguration
+	GraphConfig        *api.GraphConfiguration
+	JobSpec            *api.JobSpec
+	Templates          []*templateapi.Template
+	TemplateParameters map[string]map[string]string
+	ParamFile          string
+	ParamFormat        steps.ParametersFormat
+	Promote            bool
+	ClusterConfig      *rest.Config
+	PodPendingTimeout  time.Duration
+	LeaseClient        *lease.Client
+	RequiredTargets    []string
+	CloneAuthConfig    *steps.CloneAuthConfig
+	PullSecret         *coreapi.Secret
+	PushSecret         *coreapi.Secret
+	PushImages         *releasesteps.PushImagesOptions
+	Policy             *policyclient.Policy
+	Censor             *secrets.DynamicCensor
+	HiveKubeconfig     *rest.Config
+	GatePolicy         steps.GatePolicy
+	ConsoleHost        string
+	NodeName           string
+	NodeArchitectures  []string
+	// TargetAdditionalSuffix is appended to the names of the test targets.
+	TargetAdditionalSuffix string
+	RegistryOverride       string
+	ShardTimingsDir        string
+	// ForbidClusterScoped refuses to create cluster-scoped objects, other
+	// than those of the kinds the configuration allows.
+	ForbidClusterScoped bool
+	// Params are the parameters of the job, to which the parameters the
+	// steps provide are added.
+	Params *api.DeferredParameters
+}
+
 // FromConfig generates the final execution graph.
 // It interprets the human-friendly fields in the release build configuration
 // and pre-parsed graph configuration and generates steps for them, returning
 // the full set of steps requires for the build, including defaulted steps,
 // generated steps and all raw steps that the user provided.
-func FromConfig(
-	ctx context.Context,
-	config *api.ReleaseBuildConfiguration,
-	graphConf *api.GraphConfiguration,
-	jobSpec *api.JobSpec,
-	templates []*templateapi.Template,
-	paramFile string,
-	promote bool,
-	clusterConfig *rest.Config,
-	podPendingTimeout time.Duration,
-	leaseClient *lease.Client,
-	requiredTargets []string,
-	cloneAuthConfig *steps.CloneAuthConfig,
-	pullSecret, pushSecret *coreapi.Secret,
-	censor *secrets.DynamicCensor,
-	hiveKubeconfig *rest.Config,
-	consoleHost string,
-	nodeName string,
-	nodeArchitectures []string,
-	targetAdditionalSuffix string,
-) ([]api.Step, []api.Step, error) {
-	crclient, err := ctrlruntimeclient.NewWithWatch(clusterConfig, ctrlruntimeclient.Options{})
-	crclient = secretrecordingclient.Wrap(crclient, censor)
+func FromConfig(ctx context.Context, o Options) ([]api.Step, []api.Step, error) {
+	crclient, err := ctrlruntimeclient.NewWithWatch(o.ClusterConfig, ctrlruntimeclient.Options{})
+	crclient = policyclient.Wrap(crclient, o.Policy)
+	if o.ForbidClusterScoped {
+		crclient = scopeclient.Wrap(crclient, o.Config.AllowedClusterScopedKinds)
+	}
+	crclient = secretrecordingclient.Wrap(crclient, o.Censor)
+	crclient = labelingclient.Wrap(crclient, func() map[string]string { return steps.StandardLabels(o.JobSpec) }, steps.MultiStageStepNameLabel)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to construct client: %w", err)
 	}
 	client := loggingclient.New(crclient)
-	buildGetter, err := buildclientset.NewForConfig(clusterConfig)
+	buildGetter, err := buildclientset.NewForConfig(o.ClusterConfig)
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not get build client for cluster config: %w", err)
 	}
-	buildClient := steps.NewBuildClient(client, buildGetter.RESTClient(), nodeArchitectures)
+	buildClient := steps.NewBuildClient(client, buildGetter.RESTClient(), o.NodeArchitectures)
 
-	templateGetter, err := templateclientset.NewForConfig(clusterConfig)
+	templateGetter, err := templateclientset.NewForConfig(o.ClusterConfig)
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not get template client for cluster config: %w", err)
 	}
 	templateClient := steps.NewTemplateClient(client, templateGetter.RESTClient())
 
-	coreGetter, err := coreclientset.NewForConfig(clusterConfig)
+	coreGetter, err := coreclientset.NewForConfig(o.ClusterConfig)
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not get core client for cluster config: %w", err)
 	}
 
-	podClient := kubernetes.NewPodClient(client, clusterConfig, coreGetter.RESTClient(), podPendingTimeout)
+	podClient := kubernetes.NewPodClient(client, o.ClusterConfig, coreGetter.RESTClient(), o.PodPendingTimeout, o.Censor)
+
+	projectGetter, err := projectclientset.NewForConfig(o.ClusterConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get project client for cluster config: %w", err)
+	}
 
 	var hiveClient ctrlruntimeclient.WithWatch
-	if hiveKubeconfig != nil {
-		hiveClient, err = ctrlruntimeclient.NewWithWatch(hiveKubeconfig, ctrlruntimeclient.Options{})
+	if o.HiveKubeconfig != nil {
+		hiveClient, err = ctrlruntimeclient.NewWithWatch(o.HiveKubeconfig, ctrlruntimeclient.Options{})
 		if err != nil {
 			return nil, nil, fmt.Errorf("could not get Hive client for Hive kube config: %w", err)
 		}
@@ -110,7 +142,7 @@ func FromConfig(
 	httpClient := retryablehttp.NewClient()
 	httpClient.Logger = nil
 
-	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient.StandardClient(), requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, consoleHost, nodeName, targetAdditionalSuffix)
+	return fromConfig(ctx, o.Config, o.GraphConfig, o.JobSpec, o.Templates, o.TemplateParameters, o.ParamFile, o.ParamFormat, o.Promote, client, buildClient, templateClient, podClient, projectGetter.ProjectRequests(), o.LeaseClient, hiveClient, o.GatePolicy, httpClient.StandardClient(), o.RequiredTargets, o.CloneAuthConfig, o.PullSecret, o.PushSecret, o.PushImages, o.Params, o.Censor, o.ConsoleHost, o.NodeName, o.TargetAdditionalSuffix, o.RegistryOverride, o.ShardTimingsDir)
 }
 
 func fromConfig(
@@ -119,33 +151,40 @@ func fromConfig(
 	graphConf *api.GraphConfiguration,
 	jobSpec *api.JobSpec,
 	templates []*templateapi.Template,
+	templateParameters map[string]map[string]string,
 	paramFile string,
+	paramFormat steps.ParametersFormat,
 	promote bool,
 	client loggingclient.LoggingClient,
 	buildClient steps.BuildClient,
 	templateClient steps.TemplateClient,
 	podClient kubernetes.PodClient,
+	projectRequests projectclientset.ProjectRequestInterface,
 	leaseClient *lease.Client,
 	hiveClient ctrlruntimeclient.WithWatch,
+	gatePolicy steps.GatePolicy,
 	httpClient release.HTTPClient,
 	requiredTargets []string,
 	cloneAuthConfig *steps.CloneAuthConfig,
 	pullSecret, pushSecret *coreapi.Secret,
+	pushImages *releasesteps.PushImagesOptions,
 	params *api.DeferredParameters,
 	censor *secrets.DynamicCensor,
 	consoleHost string,
 	nodeName string,
 	targetAdditionalSuffix string,
+	registryOverride string,
+	shardTimingsDir string,
 ) ([]api.Step, []api.Step, error) {
 	requiredNames := sets.New[string]()
-	for _, target := range requiredTargets {
-		requiredNames.Insert(target)
-	}
-	params.Add("JOB_NAME", func() (string, error) { return jobSpec.Job, nil })
-	params.Add("JOB_NAME_HASH", func() (string, error) { return jobSpec.JobNameHash(), nil })
-	params.Add("JOB_NAME_SAFE", func() (string, error) { return strings.Replace(jobSpec.Job, "_", "-", -1), nil })
-	params.Add("UNIQUE_HASH", func() (string, error) { return jobSpec.UniqueHash(), nil })
-	params.Add("NAMESPACE", func() (string, error) { return jobSpec.Namespace(), nil })
+	for _, image := range config.Images {
+		if api.TargetsSelect(requiredTargets, string(image.To)) {
+			requiredNames.Insert(string(image.To))
+		}
+	}
+	for _, parameter := range JobParameters(jobSpec) {
+		params.Add(parameter.Name, parameter.Value)
+	}
 	inputImages := make(inputImageSet)
 	var overridableSteps, buildSteps, postSteps []api.Step
 	var imageStepLinks []api.StepLink
@@ -159,7 +198,7 @@ func fromConfig(
 	rawSteps = append(graphConf.Steps, rawSteps...)
 	for _, rawStep := range rawSteps {
 		if testStep := rawStep.TestStepConfiguration; testStep != nil {
-			steps, err := stepForTest(config, params, podClient, leaseClient, templateClient, client, hiveClient, jobSpec, inputImages, testStep, &imageConfigs, pullSecret, censor, nodeName, targetAdditionalSuffix)
+			steps, err := stepForTest(config, params, podClient, leaseClient, templateClient, client, projectRequests, hiveClient, gatePolicy, jobSpec, inputImages, testStep, &imageConfigs, pullSecret, censor, nodeName, targetAdditionalSuffix, shardTimingsDir)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -233,7 +272,11 @@ func fromConfig(
 				continue
 			}
 
-			step = steps.InputImageTagStep(&conf, client, jobSpec)
+			if conf.BaseImage.PullSpec != "" {
+				step = steps.ExternalInputImageTagStep(&conf, client, jobSpec, httpClient, pullSecret)
+			} else {
+				step = steps.InputImageTagStep(&conf, client, jobSpec)
+			}
 			inputImages[conf.InputImage] = struct{}{}
 		} else if rawStep.PipelineImageCacheStepConfiguration != nil {
 			step = steps.PipelineImageCacheStep(*rawStep.PipelineImageCacheStepConfiguration, config.Resources, buildClient, podClient, jobSpec, pullSecret)
@@ -244,7 +287,7 @@ func fromConfig(
 		} else if rawStep.IndexGeneratorStepConfiguration != nil {
 			step = steps.IndexGeneratorStep(*rawStep.IndexGeneratorStepConfiguration, config, config.Resources, buildClient, podClient, jobSpec, pullSecret)
 		} else if rawStep.ProjectDirectoryImageBuildStepConfiguration != nil {
-			step = steps.ProjectDirectoryImageBuildStep(*rawStep.ProjectDirectoryImageBuildStepConfiguration, config, config.Resources, buildClient, podClient, jobSpec, pullSecret)
+			step = steps.ProjectDirectoryImageBuildStep(*rawStep.ProjectDirectoryImageBuildStepConfiguration, config, config.Resources, buildClient, podClient, jobSpec, pullSecret, promotedTagFor(config, rawStep.ProjectDirectoryImageBuildStepConfiguration.To))
 		} else if rawStep.ProjectDirectoryImageBuildInputs != nil {
 			step = steps.GitSourceStep(*rawStep.ProjectDirectoryImageBuildInputs, config.Resources, buildClient, podClient, jobSpec, cloneAuthConfig, pullSecret)
 		} else if rawStep.RPMImageInjectionStepConfiguration != nil {
@@ -254,13 +297,13 @@ func fromConfig(
 		} else if rawStep.OutputImageTagStepConfiguration != nil {
 			step = steps.OutputImageTagStep(*rawStep.OutputImageTagStepConfiguration, client, jobSpec)
 			// all required or non-optional output images are considered part of [images]
-			if requiredNames.Has(string(rawStep.OutputImageTagStepConfiguration.From)) || !rawStep.OutputImageTagStepConfiguration.Optional {
+			if api.TargetsSelect(requiredTargets, string(rawStep.OutputImageTagStepConfiguration.From)) || !rawStep.OutputImageTagStepConfiguration.Optional {
 				stepLinks = append(stepLinks, step.Creates()...)
 			}
 		} else if rawStep.ReleaseImagesTagStepConfiguration != nil {
 			// if the user has specified a tag_specification we always
 			// will import those images to the stable stream
-			step = releasesteps.ReleaseImagesTagStep(*rawStep.ReleaseImagesTagStepConfiguration, client, params, jobSpec)
+			step = releasesteps.ReleaseImagesTagStep(*rawStep.ReleaseImagesTagStepConfiguration, client, params, jobSpec, registryOverride)
 			stepLinks = append(stepLinks, step.Creates()...)
 
 			hasReleaseStep = true
@@ -301,16 +344,28 @@ func fromConfig(
 	}
 
 	for _, template := range templates {
-		step := steps.TemplateExecutionStep(template, params, podClient, templateClient, jobSpec, config.Resources)
+		var templateParams api.Parameters = params
+		if overrides := templateParameters[template.Name]; len(overrides) > 0 {
+			templateParams = api.NewOverrideParameters(params, overrides)
+		}
+		step := steps.TemplateExecutionStep(template, templateParams, podClient, templateClient, jobSpec, config.Resources, censor)
+		var clusterType string
 		var hasClusterType, hasUseLease bool
 		for _, p := range template.Parameters {
-			hasClusterType = hasClusterType || p.Name == "CLUSTER_TYPE"
-			hasUseLease = hasUseLease || p.Name == "USE_LEASE_CLIENT"
+			if p.Name == api.ClusterTypeEnv {
+				hasClusterType, clusterType = true, p.Value
+			}
+			// templates that deploy clusters either ask for a lease explicitly
+			// or consume the name of the leased resource
+			hasUseLease = hasUseLease || p.Name == "USE_LEASE_CLIENT" || p.Name == api.DefaultLeaseEnv
 			if hasClusterType && hasUseLease {
-				clusterType, err := params.Get("CLUSTER_TYPE")
+				value, err := templateParams.Get(api.ClusterTypeEnv)
 				if err != nil {
 					return nil, nil, fmt.Errorf("failed to get \"CLUSTER_TYPE\" parameter: %w", err)
 				}
+				if value != "" {
+					clusterType = value
+				}
 				lease, err := api.LeaseTypeFromClusterType(clusterType)
 				if err != nil {
 					return nil, nil, fmt.Errorf("cannot resolve lease type from cluster type: %w", err)
@@ -329,7 +384,7 @@ func fromConfig(
 	}
 
 	if len(paramFile) > 0 {
-		step := steps.WriteParametersStep(params, paramFile)
+		step := steps.WriteParametersStep(params, paramFile, paramFormat)
 		buildSteps = append(buildSteps, step)
 		addProvidesForStep(step, params)
 	}
@@ -360,6 +415,10 @@ func fromConfig(
 		}
 	}
 
+	if pushImages != nil {
+		postSteps = append(postSteps, releasesteps.PushImagesStep(config, *pushImages, requiredNames, jobSpec, podClient))
+	}
+
 	return append(overridableSteps, buildSteps...), postSteps, nil
 }
 
@@ -383,7 +442,9 @@ func stepForTest(
 	leaseClient *lease.Client,
 	templateClient steps.TemplateClient,
 	client loggingclient.LoggingClient,
+	projectRequests projectclientset.ProjectRequestInterface,
 	hiveClient ctrlruntimeclient.WithWatch,
+	gatePolicy steps.GatePolicy,
 	jobSpec *api.JobSpec,
 	inputImages inputImageSet,
 	c *api.TestStepConfiguration,
@@ -392,14 +453,24 @@ func stepForTest(
 	censor *secrets.DynamicCensor,
 	nodeName string,
 	targetAdditionalSuffix string,
+	shardTimingsDir string,
 ) ([]api.Step, error) {
+	// the test itself runs in its dedicated namespace, if any, while the
+	// steps around it work in the namespace of the job
+	testJobSpec := jobSpec
+	if c.DedicatedNamespace != nil {
+		testJobSpec = jobSpec.Dedicated(c.As)
+	}
 	if test := c.MultiStageTestConfigurationLiteral; test != nil {
 		leases := api.LeasesForTest(test)
 		if len(leases) != 0 {
 			params = api.NewDeferredParameters(params)
 		}
 		var ret []api.Step
-		step := multi_stage.MultiStageTestStep(*c, config, params, podClient, jobSpec, leases, nodeName, targetAdditionalSuffix)
+		step := multi_stage.MultiStageTestStep(*c, config, params, podClient, testJobSpec, leases, nodeName, targetAdditionalSuffix)
+		if c.DedicatedNamespace != nil {
+			step = steps.DedicatedNamespaceStep(*c.DedicatedNamespace, step, client, projectRequests, testJobSpec)
+		}
 		if len(leases) != 0 {
 			step = steps.LeaseStep(leaseClient, leases, step, jobSpec.Namespace)
 		}
@@ -410,6 +481,9 @@ func stepForTest(
 			source := releasesteps.NewReleaseSourceFromClusterClaim(c.As, c.ClusterClaim, hiveClient)
 			ret = append(ret, releasesteps.ImportReleaseStep(name, nodeName, target, source, false, config.Resources, podClient, jobSpec, pullSecret, nil))
 		}
+		if len(c.Gates) != 0 {
+			step = steps.GatedStep(c.Gates, step, client, gatePolicy, jobSpec)
+		}
 		addProvidesForStep(step, params)
 		ret = append(ret, step)
 		ret = append(ret, stepsForStepImages(client, jobSpec, inputImages, test, imageConfigs)...)
@@ -420,7 +494,7 @@ func stepForTest(
 			return nil, nil
 		}
 		params = api.NewDeferredParameters(params)
-		step, err := clusterinstall.E2ETestStep(*c.OpenshiftInstallerClusterTestConfiguration, *c, params, podClient, templateClient, jobSpec, config.Resources)
+		step, err := clusterinstall.E2ETestStep(*c.OpenshiftInstallerClusterTestConfiguration, *c, params, podClient, templateClient, jobSpec, config.Resources, censor)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create end to end test step: %w", err)
 		}
@@ -432,10 +506,36 @@ func stepForTest(
 		addProvidesForStep(step, params)
 		return []api.Step{step}, nil
 	}
-	step := steps.TestStep(*c, config.Resources, podClient, jobSpec, nodeName)
+	if c.ManifestTestConfiguration != nil {
+		var step api.Step = steps.ManifestTestStep(*c, params, podClient, jobSpec)
+		if len(c.Gates) != 0 {
+			step = steps.GatedStep(c.Gates, step, client, gatePolicy, jobSpec)
+		}
+		return []api.Step{step}, nil
+	}
+	leases := api.LeasesForContainerTest(c.ContainerTestConfiguration)
+	testParams := params
+	if len(leases) != 0 {
+		testParams = api.NewDeferredParameters(params)
+	}
+	step := steps.ShardedTestStep(*c, config.Resources, podClient, testJobSpec, testParams, nodeName, shardTimingsDir)
+	if c.ContainerTestConfiguration != nil && c.ContainerTestConfiguration.Expose != nil {
+		// the URL of an exposed test is shared with the other tests
+		addProvidesForStep(step, params)
+	}
+	if c.DedicatedNamespace != nil {
+		step = steps.DedicatedNamespaceStep(*c.DedicatedNamespace, step, client, projectRequests, testJobSpec)
+	}
+	if len(leases) != 0 {
+		step = steps.LeaseStep(leaseClient, leases, step, jobSpec.Namespace)
+		addProvidesForStep(step, testParams)
+	}
 	if c.ClusterClaim != nil {
 		step = steps.ClusterClaimStep(c.As, c.ClusterClaim, hiveClient, client, jobSpec, step, censor)
 	}
+	if len(c.Gates) != 0 {
+		step = steps.GatedStep(c.Gates, step, client, gatePolicy, jobSpec)
+	}
 	return []api.Step{step}, nil
 }
 
@@ -480,12 +580,37 @@ func stepsForStepImages(
 	return
 }
 
+// JobParameters are the parameters describing the job, available to every step.
+func JobParameters(jobSpec *api.JobSpec) []api.Parameter {
+	return []api.Parameter{
+		{Name: "JOB_NAME", Description: "The job name from the JOB_SPEC", Value: func() (string, error) { return jobSpec.Job, nil }},
+		{Name: "JOB_NAME_HASH", Description: "A short hash of the job name for making tasks unique, not accounting for --target-additional-suffix", Value: func() (string, error) { return jobSpec.JobNameHash(), nil }},
+		{Name: "JOB_NAME_SAFE", Description: "The job name in a form safe for use as a Kubernetes resource name", Value: func() (string, error) { return strings.Replace(jobSpec.Job, "_", "-", -1), nil }},
+		{Name: "UNIQUE_HASH", Description: "A hash for making tasks unique, even when jobs share their name because of --target-additional-suffix", Value: func() (string, error) { return jobSpec.UniqueHash(), nil }},
+		{Name: "NAMESPACE", Description: "The namespace generated for the inputs of the job or the value of --namespace", Value: func() (string, error) { return jobSpec.Namespace(), nil }},
+		{Name: api.ClusterPlatformEnv, Description: "The platform of the cloud the job runs on, like aws or gcp, detected from the build cluster or given with --cluster-metadata", Value: func() (string, error) { return jobSpec.ClusterMetadata.Platform, nil }},
+		{Name: api.ClusterRegionEnv, Description: "The region of the cloud the job runs on, detected from the build cluster or given with --cluster-metadata", Value: func() (string, error) { return jobSpec.ClusterMetadata.Region, nil }},
+	}
+}
+
+// ParameterReference describes every parameter steps may use: those of the
+// job and those the steps may provide, sorted by name.
+func ParameterReference() []api.Parameter {
+	reference := JobParameters(&api.JobSpec{})
+	reference = append(reference, steps.ParameterReference()...)
+	reference = append(reference, releasesteps.ParameterReference()...)
+	sort.Slice(reference, func(i, j int) bool {
+		return reference[i].Name < reference[j].Name
+	})
+	return reference
+}
+
 // addProvidesForStep adds any required parameters to the deferred parameters map.
 // Use this when a step may still need to run even if all parameters are provided
 // by the caller as environment variables.
 func addProvidesForStep(step api.Step, params *api.DeferredParameters) {
-	for name, fn := range step.Provides() {
-		params.Add(name, fn)
+	for _, parameter := range api.ParametersFor(step) {
+		params.Add(parameter.Name, parameter.Value)
 	}
 }
 
@@ -493,7 +618,7 @@ func addProvidesForStep(step api.Step, params *api.DeferredParameters) {
 // environment, replace the step with a shim that automatically provides those variables.
 // Returns true if the step was replaced.
 func checkForFullyQualifiedStep(step api.Step, params *api.DeferredParameters) (api.Step, bool) {
-	provides := step.Provides()
+	provides := api.ParameterMapFor(api.ParametersFor(step))
 
 	if values, ok := paramsHasAllParametersAsInput(params, provides); ok {
 		step = steps.InputEnvironmentStep(step.Name(), values, step.Creates())
@@ -752,7 +877,7 @@ func FromConfigStatic(config *api.ReleaseBuildConfiguration) api.GraphConfigurat
 
 	for i := range config.Tests {
 		test := &config.Tests[i]
-		if test.ContainerTestConfiguration != nil || test.MultiStageTestConfigurationLiteral != nil || (test.OpenshiftInstallerClusterTestConfiguration != nil && test.OpenshiftInstallerClusterTestConfiguration.Upgrade) {
+		if test.ContainerTestConfiguration != nil || test.MultiStageTestConfigurationLiteral != nil || test.ManifestTestConfiguration != nil || (test.OpenshiftInstallerClusterTestConfiguration != nil && test.OpenshiftInstallerClusterTestConfiguration.Upgrade) {
 			if test.Secret != nil {
 				test.Secrets = append(test.Secrets, test.Secret)
 			}
@@ -834,12 +959,22 @@ func runtimeStepConfigsForBuild(
 				Tag:       "latest",
 			},
 			ClonerefsPath: "/clonerefs",
+			Cache:         config.SourceCache,
 		}}
 		buildSteps = append(buildSteps, step)
 	}
 	return buildSteps, nil
 }
 
+// promotedTagFor determines where the image is promoted to, if it is.
+func promotedTagFor(config *api.ReleaseBuildConfiguration, image api.PipelineImageStreamTagReference) *api.ImageStreamTagReference {
+	promoted, _ := releasesteps.PromotedTagsWithRequiredImages(config)
+	if tags := promoted[string(image)]; len(tags) > 0 {
+		return &tags[0]
+	}
+	return nil
+}
+
 func paramsHasAllParametersAsInput(p api.Parameters, params map[string]func() (string, error)) (map[string]string, bool) {
 	if len(params) == 0 {
 		return nil, false
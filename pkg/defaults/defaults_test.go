@@ -1007,7 +1007,7 @@ func TestFromConfig(t *testing.T) {
 	}
 	buildClient := steps.NewBuildClient(client, nil, nil)
 	var templateClient steps.TemplateClient
-	podClient := kubernetes.NewPodClient(client, nil, nil, 0)
+	podClient := kubernetes.NewPodClient(client, nil, nil, 0, nil)
 
 	clusterPool := hivev1.ClusterPool{
 		ObjectMeta: meta.ObjectMeta{
@@ -1052,6 +1052,7 @@ func TestFromConfig(t *testing.T) {
 		paramFiles     string
 		promote        bool
 		templates      []*templateapi.Template
+		templateParams map[string]map[string]string
 		env            api.Parameters
 		params         map[string]string
 		expectedSteps  []string
@@ -1351,6 +1352,42 @@ func TestFromConfig(t *testing.T) {
 			"CLUSTER_TYPE":      "aws",
 			api.DefaultLeaseEnv: "",
 		},
+	}, {
+		name: "template test consuming the leased resource",
+		templates: []*templateapi.Template{{
+			ObjectMeta: meta.ObjectMeta{Name: "template"},
+			Parameters: []templateapi.Parameter{
+				{Name: "CLUSTER_TYPE", Required: true},
+				{Name: api.DefaultLeaseEnv, Required: true},
+			},
+		}},
+		params:        map[string]string{"CLUSTER_TYPE": "gcp"},
+		expectedSteps: []string{"template", "[output-images]", "[images]"},
+		expectedParams: map[string]string{
+			"CLUSTER_TYPE":      "gcp",
+			api.DefaultLeaseEnv: "",
+		},
+	}, {
+		name: "copies of a template with their own parameters",
+		templates: []*templateapi.Template{{
+			ObjectMeta: meta.ObjectMeta{Name: "e2e-aws"},
+			Parameters: []templateapi.Parameter{
+				{Name: "USE_LEASE_CLIENT"},
+				{Name: "CLUSTER_TYPE", Required: true},
+			},
+		}, {
+			ObjectMeta: meta.ObjectMeta{Name: "e2e-gcp"},
+			Parameters: []templateapi.Parameter{
+				{Name: "USE_LEASE_CLIENT"},
+				{Name: "CLUSTER_TYPE", Required: true},
+			},
+		}},
+		templateParams: map[string]map[string]string{
+			"e2e-aws": {"CLUSTER_TYPE": "aws"},
+			"e2e-gcp": {"CLUSTER_TYPE": "gcp"},
+		},
+		expectedSteps:  []string{"e2e-aws", "e2e-gcp", "[output-images]", "[images]"},
+		expectedParams: map[string]string{api.DefaultLeaseEnv: ""},
 	}, {
 		name:       "param files",
 		paramFiles: "param_files",
@@ -1452,7 +1489,7 @@ func TestFromConfig(t *testing.T) {
 				params.Add(k, func() (string, error) { return v, nil })
 			}
 			graphConf := FromConfigStatic(&tc.config)
-			configSteps, post, err := fromConfig(context.Background(), &tc.config, &graphConf, &jobSpec, tc.templates, tc.paramFiles, tc.promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, params, &secrets.DynamicCensor{}, "", "", "")
+			configSteps, post, err := fromConfig(context.Background(), &tc.config, &graphConf, &jobSpec, tc.templates, tc.templateParams, tc.paramFiles, steps.ParametersFormatEnv, tc.promote, client, buildClient, templateClient, podClient, nil, leaseClient, hiveClient, steps.GatePolicy{}, httpClient, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, nil, params, &secrets.DynamicCensor{}, "", "", "", "", "")
 			if diff := cmp.Diff(tc.expectedErr, err); diff != "" {
 				t.Errorf("unexpected error: %v", diff)
 			}
@@ -1477,6 +1514,9 @@ func TestFromConfig(t *testing.T) {
 				"JOB_NAME_SAFE": "job-name",
 				"UNIQUE_HASH":   jobSpec.UniqueHash(),
 				"NAMESPACE":     ns,
+
+				"CLUSTER_PLATFORM": "",
+				"CLUSTER_REGION":   "",
 			} {
 				tc.expectedParams[k] = v
 			}
@@ -1519,3 +1559,29 @@ func TestRegistryDomain(t *testing.T) {
 		})
 	}
 }
+
+func TestParameterReference(t *testing.T) {
+	var names []string
+	for _, parameter := range ParameterReference() {
+		if parameter.Description == "" {
+			t.Errorf("parameter %s is not documented", parameter.Name)
+		}
+		names = append(names, parameter.Name)
+	}
+	expected := []string{
+		"CLUSTER_PLATFORM",
+		"CLUSTER_REGION",
+		"IMAGE_<COMPONENT>",
+		"IMAGE_FORMAT",
+		"JOB_NAME",
+		"JOB_NAME_HASH",
+		"JOB_NAME_SAFE",
+		"LEASED_RESOURCE",
+		"LOCAL_IMAGE_<COMPONENT>",
+		"NAMESPACE",
+		"RELEASE_IMAGE_<NAME>",
+		"RPM_REPO_<ORG>_<REPO>",
+		"UNIQUE_HASH",
+	}
+	testhelper.Diff(t, "parameters", names, expected)
+}
@@ -0,0 +1,133 @@
+// Package features gates experimental behaviors of ci-operator, so that they
+// can be rolled out gradually, for example one build cluster at a time.
+package features
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Feature is the name of an experimental behavior.
+type Feature string
+
+const (
+	// ImportWatchdog imports image stream tags again when their import fails
+	// or makes no progress, instead of waiting for the step to time out.
+	ImportWatchdog Feature = "ImportWatchdog"
+)
+
+// EnvVar overrides the gates of the --features file with comma-separated
+// Feature=true|false pairs.
+const EnvVar = "CI_OPERATOR_FEATURES"
+
+// defaults are the known features and whether they are enabled when they
+// are not configured.
+var defaults = map[Feature]bool{
+	ImportWatchdog: true,
+}
+
+// Gates holds whether each of the known features is enabled.
+type Gates map[Feature]bool
+
+// Defaults returns the gates of the known features when none is configured.
+func Defaults() Gates {
+	ret := Gates{}
+	for feature, enabled := range defaults {
+		ret[feature] = enabled
+	}
+	return ret
+}
+
+// Set enables or disables a known feature.
+func (g Gates) Set(feature Feature, enabled bool) error {
+	if _, known := defaults[feature]; !known {
+		return fmt.Errorf("unknown feature %q, known features are: %s", feature, strings.Join(knownFeatures(), ", "))
+	}
+	g[feature] = enabled
+	return nil
+}
+
+// SetFromList sets the gates from comma-separated Feature=true|false pairs.
+func (g Gates) SetFromList(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, raw, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("expected Feature=true|false, got %q", pair)
+		}
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid value for feature %s: %w", name, err)
+		}
+		if err := g.Set(Feature(name), enabled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetFromFile sets the gates from a YAML file that maps features to whether
+// they are enabled.
+func (g Gates) SetFromFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read feature gates: %w", err)
+	}
+	var gates map[Feature]bool
+	if err := yaml.UnmarshalStrict(raw, &gates); err != nil {
+		return fmt.Errorf("failed to parse feature gates from %s: %w", path, err)
+	}
+	for feature, enabled := range gates {
+		if err := g.Set(feature, enabled); err != nil {
+			return fmt.Errorf("invalid feature gates in %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// String lists the gates sorted by feature, like Feature=true.
+func (g Gates) String() string {
+	var pairs []string
+	for feature, enabled := range g {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", feature, enabled))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func knownFeatures() []string {
+	var ret []string
+	for feature := range defaults {
+		ret = append(ret, string(feature))
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+var (
+	lock    sync.RWMutex
+	current = Defaults()
+)
+
+// Configure sets the gates of the execution. It is called once at startup,
+// before any of the gated behaviors is queried.
+func Configure(gates Gates) {
+	lock.Lock()
+	defer lock.Unlock()
+	current = gates
+}
+
+// Enabled determines whether the feature is enabled for the execution.
+func Enabled(feature Feature) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	return current[feature]
+}
@@ -0,0 +1,81 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestSetFromList(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		value       string
+		expected    Gates
+		expectedErr string
+	}{{
+		name:     "empty",
+		expected: Gates{ImportWatchdog: true},
+	}, {
+		name:     "disabled feature",
+		value:    "ImportWatchdog=false",
+		expected: Gates{ImportWatchdog: false},
+	}, {
+		name:        "unknown feature",
+		value:       "ImportWatchdog=false,Teleport=true",
+		expectedErr: `unknown feature "Teleport", known features are: ImportWatchdog`,
+	}, {
+		name:        "invalid value",
+		value:       "ImportWatchdog=maybe",
+		expectedErr: `invalid value for feature ImportWatchdog: strconv.ParseBool: parsing "maybe": invalid syntax`,
+	}, {
+		name:        "missing value",
+		value:       "ImportWatchdog",
+		expectedErr: `expected Feature=true|false, got "ImportWatchdog"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			gates := Defaults()
+			err := gates.SetFromList(tc.value)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			testhelper.Diff(t, "error", actualErr, tc.expectedErr)
+			if err == nil {
+				testhelper.Diff(t, "gates", gates, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSetFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "features.yaml")
+	if err := os.WriteFile(path, []byte("ImportWatchdog: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gates := Defaults()
+	if err := gates.SetFromFile(path); err != nil {
+		t.Fatalf("failed to load the gates: %v", err)
+	}
+	testhelper.Diff(t, "gates", gates.String(), "ImportWatchdog=false")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("Teleport: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gates.SetFromFile(invalid); err == nil {
+		t.Error("expected an error for an unknown feature")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	defer Configure(Defaults())
+	if !Enabled(ImportWatchdog) {
+		t.Errorf("expected %s to be enabled by default", ImportWatchdog)
+	}
+	Configure(Gates{ImportWatchdog: false})
+	if Enabled(ImportWatchdog) {
+		t.Errorf("expected %s to be disabled", ImportWatchdog)
+	}
+}
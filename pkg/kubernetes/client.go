@@ -19,6 +19,7 @@ import (
 	toolswatch "k8s.io/client-go/tools/watch"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 )
 
@@ -77,14 +78,18 @@ type PodClient interface {
 	WithNewLoggingClient() PodClient
 	Exec(namespace, pod string, opts *coreapi.PodExecOptions) (remotecommand.Executor, error)
 	GetLogs(namespace, name string, opts *coreapi.PodLogOptions) *rest.Request
+	// Censor returns the censor of the secrets the client saw, to be applied
+	// to the logs of pods before they are saved.
+	Censor() *secrets.DynamicCensor
 }
 
-func NewPodClient(ctrlclient loggingclient.LoggingClient, config *rest.Config, client rest.Interface, pendingTimeout time.Duration) PodClient {
+func NewPodClient(ctrlclient loggingclient.LoggingClient, config *rest.Config, client rest.Interface, pendingTimeout time.Duration, censor *secrets.DynamicCensor) PodClient {
 	return &podClient{
 		LoggingClient:  ctrlclient,
 		config:         config,
 		client:         client,
 		pendingTimeout: pendingTimeout,
+		censor:         censor,
 	}
 }
 
@@ -93,6 +98,7 @@ type podClient struct {
 	config         *rest.Config
 	client         rest.Interface
 	pendingTimeout time.Duration
+	censor         *secrets.DynamicCensor
 }
 
 func (c podClient) GetPendingTimeout() time.Duration { return c.pendingTimeout }
@@ -110,6 +116,8 @@ func (c podClient) GetLogs(namespace, name string, opts *coreapi.PodLogOptions)
 	return c.client.Get().Namespace(namespace).Name(name).Resource("pods").SubResource("log").VersionedParams(opts, scheme.ParameterCodec)
 }
 
+func (c podClient) Censor() *secrets.DynamicCensor { return c.censor }
+
 func (c podClient) WithNewLoggingClient() PodClient {
 	c.LoggingClient = c.New()
 	return c
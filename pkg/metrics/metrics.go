@@ -0,0 +1,91 @@
+// Package metrics holds the Prometheus metrics ci-operator publishes about
+// the execution of its steps when it is run with --metrics-addr.
+package metrics
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const namespace = "ci_operator"
+
+// durationBuckets range from a second to about nine hours.
+var durationBuckets = prometheus.ExponentialBuckets(1, 2, 16)
+
+var (
+	// StepDuration observes how long steps ran, by step and result.
+	StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "step_duration_seconds",
+		Help:      "How long steps ran, by step and result.",
+		Buckets:   durationBuckets,
+	}, []string{"step", "result"})
+	// StepsTotal counts the steps that ran, by step and result.
+	StepsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "steps_total",
+		Help:      "Steps that ran, by step and result.",
+	}, []string{"step", "result"})
+	// BuildWait observes how long builds waited to start after they were
+	// created.
+	BuildWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "build_wait_seconds",
+		Help:      "How long builds waited to start after they were created.",
+		Buckets:   durationBuckets,
+	})
+	// NamespaceInitialization observes how long initializing the test
+	// namespace took.
+	NamespaceInitialization = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "namespace_initialization_seconds",
+		Help:      "How long initializing the test namespace took.",
+		Buckets:   durationBuckets,
+	})
+	// PodRetries counts the attempts to create pods that were retried, by
+	// reason.
+	PodRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pod_retries_total",
+		Help:      "Attempts to create pods that were retried, by reason.",
+	}, []string{"reason"})
+)
+
+// Registry holds the metrics of ci-operator. It is separate from the default
+// registry so other programs using the packages of ci-operator do not publish
+// them.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(StepDuration, StepsTotal, BuildWait, NamespaceInitialization, PodRetries)
+}
+
+// Result labels the metrics of an operation by whether it failed.
+func Result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// Serve publishes the metrics on the address until the process exits.
+func Serve(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	go func() {
+		if err := http.Serve(listener, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.WithError(err).Warn("The metrics server stopped.")
+		}
+	}()
+	logrus.Debugf("Serving metrics on %s", listener.Addr())
+	return nil
+}
@@ -117,6 +117,9 @@ func (r *registry) mergeWorkflow(config *api.MultiStageTestConfiguration) ([][]a
 	if config.AllowBestEffortPostSteps == nil {
 		config.AllowBestEffortPostSteps = workflow.AllowBestEffortPostSteps
 	}
+	if config.SharedVolume == nil {
+		config.SharedVolume = workflow.SharedVolume
+	}
 	return overridden, errs
 }
 
@@ -132,6 +135,7 @@ func (r *registry) resolveTest(
 		AllowBestEffortPostSteps: config.AllowBestEffortPostSteps,
 		Leases:                   config.Leases,
 		DependencyOverrides:      config.DependencyOverrides,
+		SharedVolume:             config.SharedVolume,
 	}
 	if config.Workflow != nil {
 		stack.push(stackRecordForTest("workflow/"+*config.Workflow, nil, nil))
@@ -0,0 +1,187 @@
+package results
+
+import (
+	"strings"
+)
+
+// Category is the broad class of a failure, telling who is expected to act
+// on it: the author of the configuration, the owner of an input, the
+// maintainers of the infrastructure or the author of the code under test.
+type Category string
+
+const (
+	// CategoryConfig failures come from an invalid configuration or
+	// invalid arguments.
+	CategoryConfig Category = "config"
+	// CategoryInputResolution failures come from inputs, like releases or
+	// images, that could not be resolved or imported.
+	CategoryInputResolution Category = "input_resolution"
+	// CategoryInfrastructure failures come from the cluster or the services
+	// the job depends on.
+	CategoryInfrastructure Category = "infrastructure"
+	// CategoryTest failures come from the code under test, which failed to
+	// build or whose tests failed.
+	CategoryTest Category = "test"
+	// CategoryPromotion failures happen while publishing the images built
+	// by a successful job.
+	CategoryPromotion Category = "promotion"
+	// CategoryUnknown failures could not be classified.
+	CategoryUnknown Category = "unknown"
+)
+
+// categories lists the categories from the most to the least severe. When a
+// job fails for many reasons, it is classified by the most severe category.
+var categories = []Category{CategoryConfig, CategoryInputResolution, CategoryInfrastructure, CategoryPromotion, CategoryTest, CategoryUnknown}
+
+// exitCodes are the exit codes of ci-operator for each category. Test
+// failures keep the exit code ci-operator has always used for failures.
+var exitCodes = map[Category]int{
+	CategoryConfig:          2,
+	CategoryInputResolution: 3,
+	CategoryInfrastructure:  4,
+	CategoryPromotion:       5,
+	CategoryTest:            1,
+	CategoryUnknown:         1,
+}
+
+// contextReasons only describe the context of a failure, so they are not
+// classified and the more specific reasons they wrap decide.
+var contextReasons = map[Reason]bool{
+	"executing_graph": true,
+	"executing_post":  true,
+	ReasonUnknown:     true,
+}
+
+// reasonCategories classifies the reasons of the errors. Every reason used in
+// the tree must either be classified here or be a context reason.
+var reasonCategories = map[Reason]Category{
+	"building_graph":          CategoryConfig,
+	"config_in_repo":          CategoryConfig,
+	"config_resolver":         CategoryConfig,
+	"config_resolver_literal": CategoryConfig,
+	"config_url":              CategoryConfig,
+	"defaulting_config":       CategoryConfig,
+	"denied_by_policy":        CategoryConfig,
+	"image_policy":            CategoryConfig,
+	"loading_args":            CategoryConfig,
+	"loading_config":          CategoryConfig,
+	"missing_cluster_profile": CategoryConfig,
+	"untrusted_config":        CategoryConfig,
+	"validating_config":       CategoryConfig,
+	"verifying_config":        CategoryConfig,
+	"verifying_namespace":     CategoryConfig,
+
+	"importing_release":      CategoryInputResolution,
+	"invalid_release":        CategoryInputResolution,
+	"missing_cli":            CategoryInputResolution,
+	"missing_release":        CategoryInputResolution,
+	"reading_release":        CategoryInputResolution,
+	"resolving_cli_override": CategoryInputResolution,
+	"resolving_inputs":       CategoryInputResolution,
+	"resolving_release":      CategoryInputResolution,
+	"tagging_input_image":    CategoryInputResolution,
+
+	"acquiring_cluster_claim":  CategoryInfrastructure,
+	"acquiring_lease":          CategoryInfrastructure,
+	"binding_roles":            CategoryInfrastructure,
+	"build_stalled":            CategoryInfrastructure,
+	"checking_capabilities":    CategoryInfrastructure,
+	"create_dockercfg_secrets": CategoryInfrastructure,
+	"creating_release_stream":  CategoryInfrastructure,
+	"creating_roles":           CategoryInfrastructure,
+	"creating_service_account": CategoryInfrastructure,
+	"creating_stable_images":   CategoryInfrastructure,
+	"evaluating_policy":        CategoryInfrastructure,
+	"initializing_namespace":   CategoryInfrastructure,
+	"interrupted":              CategoryInfrastructure,
+	"pod_pending":              CategoryInfrastructure,
+	"releasing_cluster_claim":  CategoryInfrastructure,
+	"releasing_lease":          CategoryInfrastructure,
+	"serving_rpms":             CategoryInfrastructure,
+	"setting_up_namespace":     CategoryInfrastructure,
+	"utilizing_cluster_claim":  CategoryInfrastructure,
+	"utilizing_lease":          CategoryInfrastructure,
+	"writing_parameters":       CategoryInfrastructure,
+
+	"assembling_release":         CategoryTest,
+	"build_timed_out":            CategoryTest,
+	"building_bundle_source":     CategoryTest,
+	"building_cache_image":       CategoryTest,
+	"building_image_from_source": CategoryTest,
+	"building_index_generator":   CategoryTest,
+	"building_project_image":     CategoryTest,
+	"cloning_source":             CategoryTest,
+	"creating_release":           CategoryTest,
+	"creating_release_images":    CategoryTest,
+	"executing_manifests":        CategoryTest,
+	"executing_multi_stage_test": CategoryTest,
+	"executing_template":         CategoryTest,
+	"executing_test":             CategoryTest,
+	"generating_index":           CategoryTest,
+	"injecting_rpms":             CategoryTest,
+	"installing_cluster":         CategoryTest,
+	"running_pod":                CategoryTest,
+	"running_shards":             CategoryTest,
+	"step_failed":                CategoryTest,
+	"step_timed_out":             CategoryTest,
+	"tagging_output_image":       CategoryTest,
+	"timed_out":                  CategoryTest,
+	"waiting_for_gate":           CategoryTest,
+
+	"planning_promotion": CategoryPromotion,
+	"promoting_images":   CategoryPromotion,
+	"pushing_images":     CategoryPromotion,
+}
+
+// CategoryForReason returns the category of the reason, if it has one.
+func CategoryForReason(reason Reason) (Category, bool) {
+	category, ok := reasonCategories[reason]
+	return category, ok
+}
+
+// CategoryFor classifies the errors by the most specific reason in each of
+// their chains, returning the most severe category found.
+func CategoryFor(errs ...error) Category {
+	found := map[Category]bool{}
+	for _, chain := range Reasons(errs...) {
+		reasons := strings.Split(chain, ":")
+		category := CategoryUnknown
+		for i := len(reasons) - 1; i >= 0; i-- {
+			if c, ok := CategoryForReason(Reason(reasons[i])); ok {
+				category = c
+				break
+			}
+		}
+		found[category] = true
+	}
+	for _, category := range categories {
+		if found[category] {
+			return category
+		}
+	}
+	return CategoryUnknown
+}
+
+// ExitCode is the exit code of ci-operator when it fails with the errors.
+func ExitCode(errs ...error) int {
+	return exitCodes[CategoryFor(errs...)]
+}
+
+// Failure describes an error for the reports of an execution.
+type Failure struct {
+	Category Category `json:"category"`
+	Reasons  []string `json:"reasons,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Failures describes the errors.
+func Failures(errs ...error) []Failure {
+	var failures []Failure
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		failures = append(failures, Failure{Category: CategoryFor(err), Reasons: Reasons(err), Message: err.Error()})
+	}
+	return failures
+}
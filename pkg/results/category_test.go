@@ -0,0 +1,173 @@
+package results
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCategoryFor(t *testing.T) {
+	podFailure := ForReason("executing_graph").ForError(ForReason("step_failed").ForError(ForReason("running_pod").ForError(errors.New("exit 1"))))
+	namespaceFailure := ForReason("initializing_namespace").ForError(errors.New("forbidden"))
+	for _, tc := range []struct {
+		name             string
+		errs             []error
+		expected         Category
+		expectedExitCode int
+	}{
+		{
+			name:             "no reason",
+			errs:             []error{errors.New("failure")},
+			expected:         CategoryUnknown,
+			expectedExitCode: 1,
+		},
+		{
+			name:             "most specific reason decides",
+			errs:             []error{podFailure},
+			expected:         CategoryTest,
+			expectedExitCode: 1,
+		},
+		{
+			name:             "outer reason decides when inner reasons are not classified",
+			errs:             []error{ForReason("loading_args").ForError(ForReason("not_classified").ForError(errors.New("bad")))},
+			expected:         CategoryConfig,
+			expectedExitCode: 2,
+		},
+		{
+			name:             "most severe category of many errors",
+			errs:             []error{podFailure, namespaceFailure},
+			expected:         CategoryInfrastructure,
+			expectedExitCode: 4,
+		},
+		{
+			name:             "aggregated errors",
+			errs:             []error{utilerrors.NewAggregate([]error{podFailure, ForReason("promoting_images").ForError(errors.New("denied"))})},
+			expected:         CategoryPromotion,
+			expectedExitCode: 5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "category", CategoryFor(tc.errs...), tc.expected)
+			testhelper.Diff(t, "exit code", ExitCode(tc.errs...), tc.expectedExitCode)
+		})
+	}
+}
+
+func TestFailures(t *testing.T) {
+	err := ForReason("resolving_inputs").WithError(errors.New("not found")).Errorf("could not resolve inputs: not found")
+	testhelper.Diff(t, "failures", Failures(err, nil), []Failure{{
+		Category: CategoryInputResolution,
+		Reasons:  []string{"resolving_inputs"},
+		Message:  "could not resolve inputs: not found",
+	}})
+}
+
+// TestEveryReasonIsClassified parses the tree for the reasons given to
+// ForReason, so a new reason cannot be introduced without a category.
+func TestEveryReasonIsClassified(t *testing.T) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, root := range []string{"../../cmd", "../../pkg"} {
+		if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil || !strings.Contains(string(raw), "Reason") {
+				return err
+			}
+			file, err := parser.ParseFile(fset, path, raw, 0)
+			if err != nil {
+				return err
+			}
+			files = append(files, file)
+			return nil
+		}); err != nil {
+			t.Fatalf("could not parse the tree: %v", err)
+		}
+	}
+	// reasons may be named constants, like api.ReasonPending
+	constants := map[string]string{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+				for _, spec := range gen.Specs {
+					value := spec.(*ast.ValueSpec)
+					for i, name := range value.Names {
+						if i < len(value.Values) && strings.HasPrefix(name.Name, "Reason") {
+							if reason, ok := stringLiteral(value.Values[i]); ok {
+								constants[name.Name] = reason
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	var found int
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if fun, ok := call.Fun.(*ast.SelectorExpr); !ok || fun.Sel.Name != "ForReason" {
+				if ident, ok := call.Fun.(*ast.Ident); !ok || ident.Name != "ForReason" {
+					return true
+				}
+			}
+			reason, ok := reasonArgument(call.Args[0], constants)
+			if !ok {
+				// reasons computed at runtime cannot be checked
+				return true
+			}
+			found++
+			if _, classified := reasonCategories[Reason(reason)]; !classified && !contextReasons[Reason(reason)] {
+				t.Errorf("%s: reason %q has no category", fset.Position(call.Pos()), reason)
+			}
+			return true
+		})
+	}
+	if found == 0 {
+		t.Fatal("found no reasons in the tree")
+	}
+}
+
+func reasonArgument(expr ast.Expr, constants map[string]string) (string, bool) {
+	switch arg := expr.(type) {
+	case *ast.BasicLit:
+		return stringLiteral(arg)
+	case *ast.Ident:
+		reason, ok := constants[arg.Name]
+		return reason, ok
+	case *ast.SelectorExpr:
+		reason, ok := constants[arg.Sel.Name]
+		return reason, ok
+	case *ast.CallExpr:
+		// a conversion, like results.Reason("reason")
+		if len(arg.Args) == 1 {
+			return reasonArgument(arg.Args[0], constants)
+		}
+	}
+	return "", false
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
@@ -0,0 +1,106 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// AdditionalResourcesStepName names the creation of the additional resources
+// in the dry run.
+const AdditionalResourcesStepName = "additional-resources"
+
+// AdditionalResourceObjects decodes the additional resources of the
+// configuration, reading the files they reference with readFile. Objects
+// must not set their namespace, as they are created in the test namespace.
+func AdditionalResourceObjects(resources []api.AdditionalResource, readFile func(string) ([]byte, error)) ([]*unstructured.Unstructured, error) {
+	return decodeObjects("additional_resources", resources, readFile, nil)
+}
+
+// decodeObjects decodes the objects at the field of the configuration,
+// reading the files they reference with readFile and passing the raw
+// manifests through expand, if set.
+func decodeObjects(field string, resources []api.AdditionalResource, readFile func(string) ([]byte, error), expand func([]byte) ([]byte, error)) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for i, resource := range resources {
+		raw := []byte(resource.Manifest)
+		if resource.File != "" {
+			var err error
+			if raw, err = readFile(resource.File); err != nil {
+				return nil, fmt.Errorf("%s[%d]: could not read %s: %w", field, i, resource.File, err)
+			}
+		}
+		if expand != nil {
+			var err error
+			if raw, err = expand(raw); err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", field, i, err)
+			}
+		}
+		object := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(raw, &object.Object); err != nil {
+			return nil, fmt.Errorf("%s[%d]: could not decode the object: %w", field, i, err)
+		}
+		switch {
+		case object.GetAPIVersion() == "" || object.GetKind() == "" || object.GetName() == "":
+			return nil, fmt.Errorf("%s[%d]: the object must set apiVersion, kind and metadata.name", field, i)
+		case object.GetNamespace() != "":
+			return nil, fmt.Errorf("%s[%d]: %s %s must not set its namespace, it is created in the test namespace", field, i, object.GetKind(), object.GetName())
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// ApplyAdditionalResources creates the objects in the namespace, or updates
+// them when they exist from an earlier execution. The objects are owned by
+// the owner, so they are removed with it.
+func ApplyAdditionalResources(ctx context.Context, client ctrlruntimeclient.Client, namespace string, owner *meta.OwnerReference, objects []*unstructured.Unstructured) error {
+	for _, object := range objects {
+		object := object.DeepCopy()
+		object.SetNamespace(namespace)
+		labels := object.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[CreatedByCILabel] = "true"
+		object.SetLabels(labels)
+		if owner != nil {
+			object.SetOwnerReferences(append(object.GetOwnerReferences(), *owner))
+		}
+		err := client.Create(ctx, object)
+		if kerrors.IsAlreadyExists(err) {
+			existing := &unstructured.Unstructured{}
+			existing.SetGroupVersionKind(object.GroupVersionKind())
+			if err = client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(object), existing); err == nil {
+				object.SetResourceVersion(existing.GetResourceVersion())
+				err = client.Update(ctx, object)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("could not apply %s %s: %w", object.GetKind(), object.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// AdditionalResourcesDryRun describes the creation of the objects.
+func AdditionalResourcesDryRun(objects []*unstructured.Unstructured) []StepDryRun {
+	if len(objects) == 0 {
+		return nil
+	}
+	dryRun := StepDryRun{Step: AdditionalResourcesStepName}
+	for _, object := range objects {
+		dryRun.Actions = append(dryRun.Actions, api.DryRunAction{
+			Description: fmt.Sprintf("Apply %s %s in the test namespace", object.GetKind(), object.GetName()),
+			Object:      object,
+		})
+	}
+	return []StepDryRun{dryRun}
+}
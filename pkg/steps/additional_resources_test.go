@@ -0,0 +1,98 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestAdditionalResourceObjects(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: from-file\ndata:\n  key: value\n",
+	}
+	readFile := func(path string) ([]byte, error) {
+		if content, ok := files[path]; ok {
+			return []byte(content), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	var testCases = []struct {
+		name          string
+		resources     []api.AdditionalResource
+		expectedNames []string
+		expectedErr   error
+	}{
+		{
+			name: "manifests and files",
+			resources: []api.AdditionalResource{
+				{Manifest: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "inline"}}`},
+				{File: "config.yaml"},
+			},
+			expectedNames: []string{"inline", "from-file"},
+		},
+		{
+			name:        "missing file",
+			resources:   []api.AdditionalResource{{File: "missing.yaml"}},
+			expectedErr: errors.New("additional_resources[0]: could not read missing.yaml: file does not exist"),
+		},
+		{
+			name:        "incomplete object",
+			resources:   []api.AdditionalResource{{Manifest: "kind: ConfigMap\n"}},
+			expectedErr: errors.New("additional_resources[0]: the object must set apiVersion, kind and metadata.name"),
+		},
+		{
+			name:        "namespace set",
+			resources:   []api.AdditionalResource{{Manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n  namespace: other\n"}},
+			expectedErr: errors.New("additional_resources[0]: ConfigMap cm must not set its namespace, it is created in the test namespace"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objects, err := AdditionalResourceObjects(tc.resources, readFile)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			var names []string
+			for _, object := range objects {
+				names = append(names, object.GetName())
+			}
+			testhelper.Diff(t, "names", names, tc.expectedNames)
+		})
+	}
+}
+
+func TestApplyAdditionalResources(t *testing.T) {
+	objects, err := AdditionalResourceObjects([]api.AdditionalResource{
+		{Manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  key: new\n"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "settings"},
+		Data:       map[string]string{"key": "old"},
+	}).Build()
+	owner := &meta.OwnerReference{APIVersion: "image.openshift.io/v1", Kind: "ImageStream", Name: "pipeline", UID: "uid"}
+	if err := ApplyAdditionalResources(context.Background(), client, "ns", owner, objects); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "settings"}, configMap); err != nil {
+		t.Fatal(err)
+	}
+	testhelper.Diff(t, "data", configMap.Data, map[string]string{"key": "new"})
+	testhelper.Diff(t, "labels", configMap.Labels, map[string]string{CreatedByCILabel: "true"})
+	testhelper.Diff(t, "owners", configMap.OwnerReferences, []meta.OwnerReference{*owner})
+
+	dryRun := AdditionalResourcesDryRun(objects)
+	if len(dryRun) != 1 || len(dryRun[0].Actions) != 1 || dryRun[0].Actions[0].Description != "Apply ConfigMap settings in the test namespace" {
+		t.Errorf("unexpected dry run: %v", dryRun)
+	}
+}
@@ -0,0 +1,135 @@
+package steps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	buildapi "github.com/openshift/api/build/v1"
+)
+
+// BuildPerformanceFilename is the artifact holding the report of the
+// duration of the stages of the builds and the size of the images.
+const BuildPerformanceFilename = "build-performance.txt"
+
+// BuildStageDurations are the durations of the stages of a build, as the
+// build reports them.
+type BuildStageDurations struct {
+	// CloneMilliseconds is the duration of fetching the inputs, like
+	// cloning the source code.
+	CloneMilliseconds int64 `json:"cloneMilliseconds,omitempty"`
+	// PullMilliseconds is the duration of pulling the base images.
+	PullMilliseconds int64 `json:"pullMilliseconds,omitempty"`
+	// BuildMilliseconds is the duration of building the image.
+	BuildMilliseconds int64 `json:"buildMilliseconds,omitempty"`
+	// PushMilliseconds is the duration of pushing the image.
+	PushMilliseconds int64 `json:"pushMilliseconds,omitempty"`
+}
+
+// buildStageDurations sums the durations of the stages of the build, if it
+// reported any.
+func buildStageDurations(build *buildapi.Build) *BuildStageDurations {
+	if len(build.Status.Stages) == 0 {
+		return nil
+	}
+	durations := &BuildStageDurations{}
+	for _, stage := range build.Status.Stages {
+		switch stage.Name {
+		case buildapi.StageFetchInputs:
+			durations.CloneMilliseconds += stage.DurationMilliseconds
+		case buildapi.StagePullImages:
+			durations.PullMilliseconds += stage.DurationMilliseconds
+		case buildapi.StageBuild:
+			durations.BuildMilliseconds += stage.DurationMilliseconds
+		case buildapi.StagePushImage:
+			durations.PushMilliseconds += stage.DurationMilliseconds
+		}
+	}
+	return durations
+}
+
+// LoadBuildBaseline reads the build statistics an earlier execution wrote to
+// its results.json, to compare the builds of this execution with.
+func LoadBuildBaseline(path string) (*CacheStatistics, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the build baseline: %w", err)
+	}
+	var results struct {
+		Cache *CacheStatistics `json:"cache"`
+	}
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return nil, fmt.Errorf("could not parse the build baseline %s: %w", path, err)
+	}
+	if results.Cache == nil {
+		return nil, fmt.Errorf("the build baseline %s has no build statistics", path)
+	}
+	return results.Cache, nil
+}
+
+// BuildRegressions compares the images built by this execution with the
+// ones the baseline built and describes those whose build duration or size
+// grew by more than the tolerance, like 0.2 for 20%.
+func (s *CacheStatistics) BuildRegressions(baseline *CacheStatistics, tolerance float64) []string {
+	previous := map[string]BuildCacheStatistics{}
+	for _, build := range baseline.Builds {
+		if !build.Reused {
+			previous[build.Image] = build
+		}
+	}
+	var regressions []string
+	for _, build := range s.Builds {
+		before, ok := previous[build.Image]
+		if build.Reused || !ok {
+			continue
+		}
+		if regressed(before.DurationMilliseconds, build.DurationMilliseconds, tolerance) {
+			regressions = append(regressions, fmt.Sprintf("image %s took %s to build, up from %s in the baseline", build.Image, formatMilliseconds(build.DurationMilliseconds), formatMilliseconds(before.DurationMilliseconds)))
+		}
+		if regressed(before.PushedBytes, build.PushedBytes, tolerance) {
+			regressions = append(regressions, fmt.Sprintf("image %s takes %s, up from %s in the baseline", build.Image, formatBytes(build.PushedBytes), formatBytes(before.PushedBytes)))
+		}
+	}
+	return regressions
+}
+
+func regressed(baseline, current int64, tolerance float64) bool {
+	return baseline > 0 && float64(current) > float64(baseline)*(1+tolerance)
+}
+
+func formatMilliseconds(milliseconds int64) string {
+	return (time.Duration(milliseconds) * time.Millisecond).Truncate(time.Second).String()
+}
+
+// PerformanceReport formats a table of the images this execution built, with
+// the duration of the stages of their builds and their size, followed by
+// the regressions, if any.
+func (s *CacheStatistics) PerformanceReport() string {
+	var report bytes.Buffer
+	w := tabwriter.NewWriter(&report, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tCLONE\tPULL\tBUILD\tPUSH\tTOTAL\tSIZE")
+	for _, build := range s.Builds {
+		if build.Reused {
+			continue
+		}
+		stages := build.Stages
+		if stages == nil {
+			stages = &BuildStageDurations{}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", build.Image,
+			formatMilliseconds(stages.CloneMilliseconds), formatMilliseconds(stages.PullMilliseconds),
+			formatMilliseconds(stages.BuildMilliseconds), formatMilliseconds(stages.PushMilliseconds),
+			formatMilliseconds(build.DurationMilliseconds), formatBytes(build.PushedBytes))
+	}
+	_ = w.Flush()
+	if len(s.Regressions) != 0 {
+		report.WriteString("\nRegressions:\n")
+		for _, regression := range s.Regressions {
+			fmt.Fprintf(&report, "  * %s\n", regression)
+		}
+	}
+	return report.String()
+}
@@ -0,0 +1,104 @@
+package steps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestBuildStageDurations(t *testing.T) {
+	build := &buildapi.Build{Status: buildapi.BuildStatus{Stages: []buildapi.StageInfo{
+		{Name: buildapi.StageFetchInputs, DurationMilliseconds: 1000},
+		{Name: buildapi.StagePullImages, DurationMilliseconds: 2000},
+		{Name: buildapi.StageBuild, DurationMilliseconds: 3000},
+		{Name: buildapi.StagePostCommit, DurationMilliseconds: 500},
+		{Name: buildapi.StagePushImage, DurationMilliseconds: 4000},
+	}}}
+	testhelper.Diff(t, "durations", buildStageDurations(build), &BuildStageDurations{
+		CloneMilliseconds: 1000,
+		PullMilliseconds:  2000,
+		BuildMilliseconds: 3000,
+		PushMilliseconds:  4000,
+	})
+	if durations := buildStageDurations(&buildapi.Build{}); durations != nil {
+		t.Errorf("expected no durations for a build without stages, got %v", durations)
+	}
+}
+
+func TestLoadBuildBaseline(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	var testCases = []struct {
+		name        string
+		path        string
+		expected    *CacheStatistics
+		expectedErr error
+	}{
+		{
+			name:     "results",
+			path:     write("results.json", `{"cache": {"builds": [{"image": "bin", "build": "bin", "reused": false, "durationMilliseconds": 60000}], "reused": 0, "rebuilt": 1}}`),
+			expected: &CacheStatistics{Builds: []BuildCacheStatistics{{Image: "bin", Build: "bin", DurationMilliseconds: 60000}}, Rebuilt: 1},
+		},
+		{
+			name:        "no statistics",
+			path:        write("empty.json", `{}`),
+			expectedErr: errors.New("the build baseline " + filepath.Join(dir, "empty.json") + " has no build statistics"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := LoadBuildBaseline(tc.path)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			testhelper.Diff(t, "baseline", actual, tc.expected)
+		})
+	}
+}
+
+func TestBuildRegressions(t *testing.T) {
+	baseline := &CacheStatistics{Builds: []BuildCacheStatistics{
+		{Image: "src", DurationMilliseconds: 60000, PushedBytes: 1 << 30},
+		{Image: "bin", DurationMilliseconds: 60000, PushedBytes: 1 << 30},
+		{Image: "test-bin", Reused: true, DurationMilliseconds: 1000},
+	}}
+	current := &CacheStatistics{Builds: []BuildCacheStatistics{
+		{Image: "src", DurationMilliseconds: 70000, PushedBytes: 2 << 30},
+		{Image: "bin", DurationMilliseconds: 120000, PushedBytes: 1 << 30},
+		{Image: "test-bin", DurationMilliseconds: 60000},
+		{Image: "new", DurationMilliseconds: 60000},
+		{Image: "cached", Reused: true},
+	}}
+	testhelper.Diff(t, "regressions", current.BuildRegressions(baseline, 0.2), []string{
+		"image src takes 2Gi, up from 1Gi in the baseline",
+		"image bin took 2m0s to build, up from 1m0s in the baseline",
+	})
+}
+
+func TestPerformanceReport(t *testing.T) {
+	stats := &CacheStatistics{
+		Builds: []BuildCacheStatistics{
+			{Image: "bin", DurationMilliseconds: 95000, PushedBytes: 1 << 30, Stages: &BuildStageDurations{CloneMilliseconds: 5000, PullMilliseconds: 10000, BuildMilliseconds: 60000, PushMilliseconds: 20000}},
+			{Image: "cached", Reused: true},
+			{Image: "src", DurationMilliseconds: 30000, PushedBytes: 512 << 20},
+		},
+		Regressions: []string{"image bin took 1m35s to build, up from 1m0s in the baseline"},
+	}
+	expected := `IMAGE  CLONE  PULL  BUILD  PUSH  TOTAL  SIZE
+bin    5s     10s   1m0s   20s   1m35s  1Gi
+src    0s     0s    0s     0s    30s    512Mi
+
+Regressions:
+  * image bin took 1m35s to build, up from 1m0s in the baseline
+`
+	testhelper.Diff(t, "report", stats.PerformanceReport(), expected)
+}
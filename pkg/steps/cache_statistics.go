@@ -0,0 +1,158 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// BuildCacheStatistics describes how a single pipeline image was produced.
+type BuildCacheStatistics struct {
+	// Image is the pipeline image the build produces.
+	Image string `json:"image"`
+	// Build is the name of the build that produced the image.
+	Build string `json:"build"`
+	// Reused is true when the build was created by an earlier execution
+	// in the same namespace, so nothing was built by this one.
+	Reused bool `json:"reused"`
+	// DurationMilliseconds is the total duration of the build.
+	DurationMilliseconds int64 `json:"durationMilliseconds"`
+	// PulledBytes is the size of the layers of the base image, which the
+	// build pulls as it always forces a pull.
+	PulledBytes int64 `json:"pulledBytes,omitempty"`
+	// PushedBytes is the size of the layers of the image that was built
+	// which its base image does not have, so they are new to the push.
+	PushedBytes int64 `json:"pushedBytes,omitempty"`
+	// Stages are the durations of the stages of the build, like cloning
+	// the source, pulling the base images, building and pushing the image.
+	Stages *BuildStageDurations `json:"stages,omitempty"`
+}
+
+// CacheStatistics summarizes which pipeline images were reused from the
+// namespace and which were built by the current execution.
+type CacheStatistics struct {
+	Builds []BuildCacheStatistics `json:"builds"`
+	// Reused is the number of images that did not need to be built.
+	Reused int `json:"reused"`
+	// Rebuilt is the number of images built by this execution.
+	Rebuilt int `json:"rebuilt"`
+	// CloneDurationMilliseconds is the duration of the build that clones
+	// the source code, if it ran.
+	CloneDurationMilliseconds int64 `json:"cloneDurationMilliseconds,omitempty"`
+	// Regressions describe the images whose build duration or size grew
+	// compared to a baseline, if one was given.
+	Regressions []string `json:"regressions,omitempty"`
+}
+
+// GatherCacheStatistics inspects the builds in the namespace to determine
+// which pipeline images were reused and which were built by an execution
+// that started at the given time.
+func GatherCacheStatistics(ctx context.Context, client ctrlruntimeclient.Reader, namespace string, since time.Time) (*CacheStatistics, error) {
+	builds := &buildapi.BuildList{}
+	if err := client.List(ctx, builds, ctrlruntimeclient.InNamespace(namespace), ctrlruntimeclient.HasLabels{CreatesLabel}); err != nil {
+		return nil, fmt.Errorf("could not list builds: %w", err)
+	}
+	sort.Slice(builds.Items, func(i, j int) bool {
+		return builds.Items[i].Name < builds.Items[j].Name
+	})
+	stats := &CacheStatistics{Builds: []BuildCacheStatistics{}}
+	for _, build := range builds.Items {
+		if build.Status.Phase != buildapi.BuildPhaseComplete {
+			continue
+		}
+		image := build.Labels[CreatesLabel]
+		item := BuildCacheStatistics{
+			Image:                image,
+			Build:                build.Name,
+			Reused:               build.CreationTimestamp.Time.Before(since),
+			DurationMilliseconds: buildDuration(&build).Milliseconds(),
+			Stages:               buildStageDurations(&build),
+		}
+		var baseLayers []imagev1.ImageLayer
+		if strategy := build.Spec.Strategy.DockerStrategy; strategy != nil {
+			baseLayers = imageLayers(ctx, client, strategy.From)
+			item.PulledBytes = layersSize(baseLayers, nil)
+		}
+		item.PushedBytes = layersSize(imageLayers(ctx, client, build.Spec.Output.To), baseLayers)
+		if item.Reused {
+			stats.Reused++
+		} else {
+			stats.Rebuilt++
+			if image == string(api.PipelineImageStreamTagReferenceSource) {
+				stats.CloneDurationMilliseconds = item.DurationMilliseconds
+			}
+		}
+		stats.Builds = append(stats.Builds, item)
+	}
+	return stats, nil
+}
+
+// imageLayers determines the layers of the image stream tag, if the
+// reference points to one. Missing data is not an error, as the sizes are
+// informational.
+func imageLayers(ctx context.Context, client ctrlruntimeclient.Reader, ref *corev1.ObjectReference) []imagev1.ImageLayer {
+	if ref == nil || ref.Kind != "ImageStreamTag" {
+		return nil
+	}
+	ist := &imagev1.ImageStreamTag{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, ist); err != nil {
+		logrus.WithError(err).Debugf("Could not determine the size of image %s/%s.", ref.Namespace, ref.Name)
+		return nil
+	}
+	return ist.Image.DockerImageLayers
+}
+
+// layersSize sums the size of the layers, except those the existing image
+// already has.
+func layersSize(layers, existing []imagev1.ImageLayer) int64 {
+	known := sets.New[string]()
+	for _, layer := range existing {
+		known.Insert(layer.Name)
+	}
+	var size int64
+	for _, layer := range layers {
+		if known.Has(layer.Name) {
+			continue
+		}
+		// the same layer only has to be pushed once
+		known.Insert(layer.Name)
+		size += layer.LayerSize
+	}
+	return size
+}
+
+// Summary formats the statistics for the log.
+func (s *CacheStatistics) Summary() string {
+	var reused, rebuilt []string
+	var pulled, pushed int64
+	for _, build := range s.Builds {
+		if build.Reused {
+			reused = append(reused, build.Image)
+			continue
+		}
+		rebuilt = append(rebuilt, build.Image)
+		pulled += build.PulledBytes
+		pushed += build.PushedBytes
+	}
+	lines := []string{
+		fmt.Sprintf("Reused %d images from the namespace: %s", s.Reused, strings.Join(reused, ", ")),
+		fmt.Sprintf("Built %d images, pulling %d and pushing %d bytes: %s", s.Rebuilt, pulled, pushed, strings.Join(rebuilt, ", ")),
+	}
+	if s.CloneDurationMilliseconds != 0 {
+		lines = append(lines, fmt.Sprintf("Cloned the source code in %s", (time.Duration(s.CloneDurationMilliseconds)*time.Millisecond).Truncate(time.Second)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,79 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestGatherCacheStatistics(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	build := func(name, from string, created time.Time, phase buildapi.BuildPhase) *buildapi.Build {
+		b := &buildapi.Build{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace:         "ns",
+				Name:              name,
+				Labels:            map[string]string{CreatesLabel: name},
+				CreationTimestamp: meta.NewTime(created),
+			},
+			Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{
+				Strategy: buildapi.BuildStrategy{DockerStrategy: &buildapi.DockerBuildStrategy{}},
+				Output:   buildapi.BuildOutput{To: &corev1.ObjectReference{Kind: "ImageStreamTag", Namespace: "ns", Name: "pipeline:" + name}},
+			}},
+			Status: buildapi.BuildStatus{
+				Phase:               phase,
+				StartTimestamp:      &meta.Time{Time: created},
+				CompletionTimestamp: &meta.Time{Time: created.Add(time.Minute)},
+			},
+		}
+		if from != "" {
+			b.Spec.Strategy.DockerStrategy.From = &corev1.ObjectReference{Kind: "ImageStreamTag", Namespace: "ns", Name: "pipeline:" + from}
+		}
+		return b
+	}
+	ist := func(name string, sizes ...int64) *imagev1.ImageStreamTag {
+		tag := &imagev1.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "pipeline:" + name}}
+		for _, size := range sizes {
+			tag.Image.DockerImageLayers = append(tag.Image.DockerImageLayers, imagev1.ImageLayer{Name: fmt.Sprintf("sha256:%d", size), LayerSize: size})
+		}
+		return tag
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+		build("src", "root", start.Add(time.Minute), buildapi.BuildPhaseComplete),
+		build("bin", "src", start.Add(-time.Hour), buildapi.BuildPhaseComplete),
+		build("test-bin", "src", start.Add(time.Minute), buildapi.BuildPhaseFailed),
+		ist("root", 10, 20),
+		// the layers of the base are not pushed and a layer is pushed once
+		ist("src", 10, 20, 40, 40),
+		ist("bin", 10, 20, 40, 50),
+	).Build()
+
+	actual, err := GatherCacheStatistics(context.Background(), client, "ns", start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &CacheStatistics{
+		Builds: []BuildCacheStatistics{
+			{Image: "bin", Build: "bin", Reused: true, DurationMilliseconds: 60000, PulledBytes: 70, PushedBytes: 50},
+			{Image: "src", Build: "src", DurationMilliseconds: 60000, PulledBytes: 30, PushedBytes: 40},
+		},
+		Reused:                    1,
+		Rebuilt:                   1,
+		CloneDurationMilliseconds: 60000,
+	}
+	testhelper.Diff(t, "statistics", actual, expected)
+	expectedSummary := `Reused 1 images from the namespace: bin
+Built 1 images, pulling 30 and pushing 40 bytes: src
+Cloned the source code in 1m0s`
+	testhelper.Diff(t, "summary", actual.Summary(), expectedSummary)
+}
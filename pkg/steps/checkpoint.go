@@ -0,0 +1,197 @@
+package steps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	appsapi "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// Checkpoint is a StepObserver that records the steps that completed
+// successfully in a file, so that a later execution with the same inputs
+// can skip them and resume from the step that failed.
+type Checkpoint struct {
+	path string
+
+	lock  sync.Mutex
+	state checkpointState
+}
+
+type checkpointState struct {
+	// InputHash is the hash of the inputs of the whole execution. Steps
+	// recorded for other inputs are never skipped.
+	InputHash string `json:"inputHash"`
+	// Steps maps the names of the steps that completed to the hash of
+	// their own inputs at the time.
+	Steps map[string]string `json:"steps"`
+}
+
+// LoadCheckpoint reads the checkpoint file at the path, if it exists. Steps
+// recorded by an execution with different inputs are discarded.
+func LoadCheckpoint(path, inputHash string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, state: checkpointState{InputHash: inputHash, Steps: map[string]string{}}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read checkpoint: %w", err)
+	}
+	var previous checkpointState
+	if err := json.Unmarshal(raw, &previous); err != nil {
+		return nil, fmt.Errorf("could not parse checkpoint %s: %w", path, err)
+	}
+	if previous.InputHash != inputHash {
+		logrus.Infof("Ignoring checkpoint %s written for different inputs.", path)
+		return c, nil
+	}
+	for name, hash := range previous.Steps {
+		c.state.Steps[name] = hash
+	}
+	return c, nil
+}
+
+// SkipCompleted replaces the steps recorded as completed, whose inputs did
+// not change since, with steps that succeed without doing anything as long as
+// what they created still exists in the namespace of the job, and run the
+// step again otherwise.
+func (c *Checkpoint) SkipCompleted(steps []api.Step, client ctrlruntimeclient.Reader, jobSpec *api.JobSpec) []api.Step {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var ret []api.Step
+	for _, step := range steps {
+		if hash, ok := c.state.Steps[step.Name()]; ok && hash != "" && hash == stepInputHash(step) && !providesFromRun(step) {
+			step = &checkpointedStep{Step: step, client: client, jobSpec: jobSpec}
+		}
+		ret = append(ret, step)
+	}
+	return ret
+}
+
+func (c *Checkpoint) StepStarted(api.Step) {}
+
+func (c *Checkpoint) StepFinished(step api.Step, err error) {
+	hash := stepInputHash(step)
+	if err != nil || hash == "" || providesFromRun(step) {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.state.Steps[step.Name()] = hash
+	if err := c.write(); err != nil {
+		logrus.WithError(err).Warnf("Could not record step %s in the checkpoint.", step.Name())
+	}
+}
+
+// write replaces the checkpoint file atomically, so that an interrupted
+// execution never leaves a truncated file behind.
+func (c *Checkpoint) write() error {
+	raw, err := json.MarshalIndent(c.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create checkpoint: %w", err)
+	}
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("could not write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("could not write checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// stepInputHash hashes the inputs of a step. Steps whose inputs cannot be
+// determined hash to an empty string, which is never recorded as completed.
+func stepInputHash(step api.Step) string {
+	inputs, err := step.Inputs()
+	if err != nil {
+		return ""
+	}
+	sorted := append(api.InputDefinition{}, inputs...)
+	sort.Strings(sorted)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(sorted, "\n"))))
+}
+
+// checkpointedStep stands in for a step that completed in a previous execution.
+type checkpointedStep struct {
+	api.Step
+	client  ctrlruntimeclient.Reader
+	jobSpec *api.JobSpec
+}
+
+func (s *checkpointedStep) SubTests() []*junit.TestCase {
+	if subTests, ok := s.Step.(SubtestReporter); ok {
+		return subTests.SubTests()
+	}
+	return nil
+}
+
+func (s *checkpointedStep) Timeout() time.Duration {
+	if timeout, ok := s.Step.(TimeoutReporter); ok {
+		return timeout.Timeout()
+	}
+	return 0
+}
+
+func (s *checkpointedStep) Run(ctx context.Context) error {
+	if err := s.verifyCreated(ctx); err != nil {
+		logrus.Infof("Running %s again, although it completed in a previous execution: %v.", s.Step.Description(), err)
+		return s.Step.Run(ctx)
+	}
+	logrus.Infof("Skipping %s, completed in a previous execution.", s.Step.Description())
+	return nil
+}
+
+// verifyCreated checks that what the step created in the previous execution
+// still exists. Links that cannot be verified are never assumed to exist.
+func (s *checkpointedStep) verifyCreated(ctx context.Context) error {
+	namespace := s.jobSpec.Namespace()
+	for _, link := range s.Step.Creates() {
+		var key ctrlruntimeclient.ObjectKey
+		var obj ctrlruntimeclient.Object
+		stream, tag, isImageStream := api.ImageStreamFor(link)
+		switch {
+		case api.ImagesReadyLink().SatisfiedBy(link):
+			// only orders the steps, nothing is created
+			continue
+		case api.RPMRepoLink().SatisfiedBy(link):
+			key, obj = ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: RPMRepoName}, &appsapi.Deployment{}
+		case isImageStream && tag == "":
+			key, obj = ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: stream}, &imagev1.ImageStream{}
+		case isImageStream:
+			key, obj = ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: fmt.Sprintf("%s:%s", stream, tag)}, &imagev1.ImageStreamTag{}
+		default:
+			return fmt.Errorf("cannot verify that %s still exists", api.LinkName(link))
+		}
+		if err := s.client.Get(ctx, key, obj); kerrors.IsNotFound(err) {
+			return fmt.Errorf("%s does not exist anymore", api.LinkName(link))
+		} else if err != nil {
+			return fmt.Errorf("could not verify that %s still exists: %w", api.LinkName(link), err)
+		}
+	}
+	return nil
+}
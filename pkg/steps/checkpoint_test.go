@@ -0,0 +1,103 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+type inputStep struct {
+	fakeStep
+	inputs   api.InputDefinition
+	fromRun  bool
+	timeout  time.Duration
+	subTests []*junit.TestCase
+}
+
+func (s *inputStep) Inputs() (api.InputDefinition, error) { return s.inputs, nil }
+func (s *inputStep) ProvidesFromRun() bool                { return s.fromRun }
+func (s *inputStep) Timeout() time.Duration               { return s.timeout }
+func (s *inputStep) SubTests() []*junit.TestCase          { return s.subTests }
+
+func TestCheckpoint(t *testing.T) {
+	if err := imagev1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	src := &inputStep{fakeStep: fakeStep{name: "src", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}, inputs: api.InputDefinition{"a"}, timeout: time.Hour}
+	bin := &inputStep{fakeStep: fakeStep{name: "bin"}, inputs: api.InputDefinition{"b"}}
+	e2e := &inputStep{fakeStep: fakeStep{name: "e2e"}, inputs: api.InputDefinition{"c"}}
+	rpms := &inputStep{fakeStep: fakeStep{name: "rpms", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRPMs)}}, inputs: api.InputDefinition{"d"}}
+	lease := &inputStep{fakeStep: fakeStep{name: "lease"}, inputs: api.InputDefinition{"e"}, fromRun: true}
+
+	first, err := LoadCheckpoint(path, "hash")
+	if err != nil {
+		t.Fatalf("could not load missing checkpoint: %v", err)
+	}
+	first.StepFinished(src, nil)
+	first.StepFinished(bin, nil)
+	first.StepFinished(e2e, errors.New("flaked"))
+	first.StepFinished(rpms, nil)
+	first.StepFinished(lease, nil)
+
+	bin.inputs = api.InputDefinition{"changed"}
+	second, err := LoadCheckpoint(path, "hash")
+	if err != nil {
+		t.Fatalf("could not load checkpoint: %v", err)
+	}
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		&imagev1.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "pipeline:src"}},
+	).Build()
+	skipped := second.SkipCompleted([]api.Step{src, bin, e2e, rpms, lease}, client, jobSpec)
+	for i, expected := range []bool{true, false, false, true, false} {
+		if _, ok := skipped[i].(*checkpointedStep); ok != expected {
+			t.Errorf("step %s: expected skipped to be %v, got %v", skipped[i].Name(), expected, ok)
+		}
+	}
+	if timeout := skipped[0].(TimeoutReporter).Timeout(); timeout != time.Hour {
+		t.Errorf("expected the timeout of the step to be forwarded, got %s", timeout)
+	}
+	if err := skipped[0].Run(context.Background()); err != nil {
+		t.Errorf("skipped step failed: %v", err)
+	}
+	if src.numRuns != 0 {
+		t.Errorf("expected the skipped step not to run, ran %d times", src.numRuns)
+	}
+	if err := skipped[3].Run(context.Background()); err != nil {
+		t.Errorf("step failed: %v", err)
+	}
+	if rpms.numRuns != 1 {
+		t.Errorf("expected the step whose image was deleted to run again, ran %d times", rpms.numRuns)
+	}
+
+	other, err := LoadCheckpoint(path, "other")
+	if err != nil {
+		t.Fatalf("could not load checkpoint: %v", err)
+	}
+	for _, step := range other.SkipCompleted([]api.Step{src}, client, jobSpec) {
+		if _, ok := step.(*checkpointedStep); ok {
+			t.Errorf("expected steps recorded for other inputs not to be skipped")
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCheckpoint(path, "hash"); err == nil {
+		t.Errorf("expected an error for a malformed checkpoint")
+	}
+}
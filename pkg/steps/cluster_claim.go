@@ -60,6 +60,11 @@ func (s *clusterClaimStep) Requires() []api.StepLink            { return s.wrapp
 func (s *clusterClaimStep) Creates() []api.StepLink             { return s.wrapped.Creates() }
 func (s *clusterClaimStep) Objects() []ctrlruntimeclient.Object { return s.wrapped.Objects() }
 func (s *clusterClaimStep) Provides() api.ParameterMap          { return s.wrapped.Provides() }
+func (s *clusterClaimStep) Parameters() []api.Parameter         { return api.ParametersFor(s.wrapped) }
+
+func (s *clusterClaimStep) ProvidesFromRun() bool { return providesFromRun(s.wrapped) }
+
+func (s *clusterClaimStep) defersSlot() bool { return defersSlot(s.wrapped) }
 
 func (s *clusterClaimStep) Run(ctx context.Context) error {
 	return results.ForReason("utilizing_cluster_claim").ForError(s.run(ctx))
@@ -115,7 +120,8 @@ func (s *clusterClaimStep) run(ctx context.Context) error {
 		return aggregateWrappedErrorAndReleaseError(acquireErr, releaseErr)
 	}
 
-	wrappedErr := results.ForReason("executing_test").ForError(s.wrapped.Run(ctx))
+	// the timeout of the test does not include the time spent on the claim
+	wrappedErr := results.ForReason("executing_test").ForError(runWithTimeout(ctx, s.wrapped))
 	releaseErr := results.ForReason("releasing_cluster_claim").ForError(s.releaseCluster(CleanupCtx, clusterClaim, false))
 
 	return aggregateWrappedErrorAndReleaseError(wrappedErr, releaseErr)
@@ -126,7 +132,7 @@ func (s *clusterClaimStep) acquireCluster(ctx context.Context, waitForClaim func
 	if err != nil {
 		return nil, err
 	}
-	logrus.Infof("Claiming cluster from pool %s/%s owned by %s", clusterPool.Namespace, clusterPool.Name, clusterPool.Labels["owner"])
+	LoggerFor(ctx).Infof("Claiming cluster from pool %s/%s owned by %s", clusterPool.Namespace, clusterPool.Name, clusterPool.Labels["owner"])
 
 	claimName := s.jobSpec.ProwJobID
 	claimNamespace := clusterPool.Namespace
@@ -147,14 +153,14 @@ func (s *clusterClaimStep) acquireCluster(ctx context.Context, waitForClaim func
 	if err := s.hiveClient.Create(ctx, claim); err != nil {
 		return nil, fmt.Errorf("failed to created cluster claim %s in namespace %s: %w", claimName, claimNamespace, err)
 	}
-	logrus.Infof("Waiting for cluster claim %s/%s to be fulfilled.", claimNamespace, claimName)
+	LoggerFor(ctx).Infof("Waiting for cluster claim %s/%s to be fulfilled.", claimNamespace, claimName)
 	claimStart := time.Now()
 	into := &hivev1.ClusterClaim{}
 	if err := waitForClaim(s.hiveClient, claimNamespace, claimName, into, s.clusterClaim.Timeout.Duration); err != nil {
 		return claim, fmt.Errorf("failed to wait for the created cluster claim to become ready: %w", err)
 	}
 	claim = into
-	logrus.Infof("The claimed cluster %s is ready after %s.", claim.Spec.Namespace, time.Since(claimStart).Truncate(time.Second))
+	LoggerFor(ctx).Infof("The claimed cluster %s is ready after %s.", claim.Spec.Namespace, time.Since(claimStart).Truncate(time.Second))
 	clusterDeployment := &hivev1.ClusterDeployment{}
 	if err := s.hiveClient.Get(ctx, ctrlruntimeclient.ObjectKey{Name: claim.Spec.Namespace, Namespace: claim.Spec.Namespace}, clusterDeployment); err != nil {
 		return claim, fmt.Errorf("failed to get cluster deployment %s in namespace %s: %w", claim.Spec.Namespace, claim.Spec.Namespace, err)
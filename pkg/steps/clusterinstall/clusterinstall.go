@@ -16,6 +16,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
@@ -45,6 +46,7 @@ func E2ETestStep(
 	templateClient steps.TemplateClient,
 	jobSpec *api.JobSpec,
 	resources api.ResourceConfiguration,
+	censor *secrets.DynamicCensor,
 ) (api.Step, error) {
 	var template *templateapi.Template
 	if err := yaml.Unmarshal([]byte(installTemplateE2E), &template); err != nil {
@@ -69,17 +71,22 @@ func E2ETestStep(
 			}
 		}
 
-		// ensure we depend on the release image
+		// the template installs from RELEASE_IMAGE_INITIAL, resolve it from
+		// the release we upgrade from
+		from := config.UpgradeFrom()
 		name := utils.ReleaseImageEnv(api.InitialReleaseName)
 		template.Parameters = append(template.Parameters, templateapi.Parameter{
 			Required: true,
 			Name:     name,
 		})
+		if from != api.InitialReleaseName {
+			params = &aliasedParameters{Parameters: params, aliases: map[string]string{name: utils.ReleaseImageEnv(from)}}
+		}
 
-		// ensure the installer image points to the initial state
+		// ensure the installer image points to the release we upgrade from
 		name = utils.StableImageEnv("installer")
 		if !params.HasInput(name) {
-			overrides[name] = "stable-initial:installer"
+			overrides[name] = fmt.Sprintf("%s:installer", api.ReleaseStreamFor(from))
 		}
 		template.Parameters = append(template.Parameters, templateapi.Parameter{
 			Required: true,
@@ -97,7 +104,7 @@ func E2ETestStep(
 		params = api.NewOverrideParameters(params, overrides)
 	}
 
-	step := steps.TemplateExecutionStep(template, params, podClient, templateClient, jobSpec, resources)
+	step := steps.TemplateExecutionStep(template, params, podClient, templateClient, jobSpec, resources, censor)
 	subTests, ok := step.(nestedSubTests)
 	if !ok {
 		return nil, fmt.Errorf("unexpected %T", step)
@@ -134,10 +141,18 @@ func (s *e2eTestStep) run(ctx context.Context) error {
 
 func (s *e2eTestStep) Requires() []api.StepLink {
 	links := s.step.Requires()
-	if s.config.Upgrade {
-		links = append([]api.StepLink{api.ReleasePayloadImageLink("initial")}, links...)
+	if !s.config.Upgrade {
+		return links
 	}
-	return links
+	// RELEASE_IMAGE_INITIAL is resolved from the release we upgrade from
+	requires := []api.StepLink{api.ReleasePayloadImageLink(s.config.UpgradeFrom())}
+	initial := api.ReleasePayloadImageLink(api.InitialReleaseName)
+	for _, link := range links {
+		if !link.SatisfiedBy(initial) {
+			requires = append(requires, link)
+		}
+	}
+	return requires
 }
 
 func (s *e2eTestStep) Creates() []api.StepLink {
@@ -150,6 +165,60 @@ func (s *e2eTestStep) Provides() api.ParameterMap {
 
 func (s *e2eTestStep) Name() string { return s.testConfig.As }
 
+// upgradePhases names the phase of an upgrade test each container of the
+// template runs, so that they are reported separately.
+var upgradePhases = map[string]string{
+	"setup":    "install",
+	"test":     "upgrade",
+	"teardown": "teardown",
+}
+
+// SubTests reports the containers of upgrade tests that run the install,
+// upgrade and teardown phases as test cases named after the phases.
+func (s *e2eTestStep) SubTests() []*junit.TestCase {
+	subTests := s.nestedSubTests.SubTests()
+	if !s.config.Upgrade {
+		return subTests
+	}
+	renamed := make([]*junit.TestCase, 0, len(subTests))
+	for _, test := range subTests {
+		idx := strings.LastIndex(test.Name, " container ")
+		if idx == -1 {
+			renamed = append(renamed, test)
+			continue
+		}
+		phase, ok := upgradePhases[test.Name[idx+len(" container "):]]
+		if !ok {
+			renamed = append(renamed, test)
+			continue
+		}
+		tc := *test
+		tc.Name = fmt.Sprintf("%s - %s phase", s.Description(), phase)
+		renamed = append(renamed, &tc)
+	}
+	return renamed
+}
+
+// aliasedParameters resolves some parameters from the values of others.
+type aliasedParameters struct {
+	api.Parameters
+	aliases map[string]string
+}
+
+func (p *aliasedParameters) Has(name string) bool {
+	if alias, ok := p.aliases[name]; ok {
+		name = alias
+	}
+	return p.Parameters.Has(name)
+}
+
+func (p *aliasedParameters) Get(name string) (string, error) {
+	if alias, ok := p.aliases[name]; ok {
+		name = alias
+	}
+	return p.Parameters.Get(name)
+}
+
 func (s *e2eTestStep) Description() string {
 	if s.config.Upgrade {
 		return fmt.Sprintf("Run cluster install and upgrade %s", s.testConfig.As)
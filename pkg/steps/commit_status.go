@@ -0,0 +1,145 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/github"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// maxStatusDescription is the longest description GitHub accepts for a
+// commit status.
+const maxStatusDescription = 140
+
+// CommitStatusClient sets statuses on commits, like the GitHub client.
+type CommitStatusClient interface {
+	CreateStatus(org, repo, SHA string, s github.Status) error
+}
+
+// CommitStatusReporter is a StepObserver that reports the outcome of the job
+// as a commit status on the commits it tests, and the outcome of each of the
+// targets under its own context. It allows running ci-operator from systems
+// that, unlike Prow, do not report statuses themselves.
+type CommitStatusReporter struct {
+	client    CommitStatusClient
+	org, repo string
+	shas      []string
+	context   string
+	targets   sets.Set[string]
+
+	lock      sync.Mutex
+	targetURL string
+	finished  sets.Set[string]
+}
+
+// NewCommitStatusReporter creates a reporter for the commits of the pull
+// requests of the refs or, without pull requests, for the base commit.
+func NewCommitStatusReporter(client CommitStatusClient, refs *prowapi.Refs, context string, targets []string) (*CommitStatusReporter, error) {
+	if refs == nil {
+		return nil, fmt.Errorf("commit statuses can only be reported for jobs that test refs")
+	}
+	var shas []string
+	for _, pull := range refs.Pulls {
+		shas = append(shas, pull.SHA)
+	}
+	if len(shas) == 0 {
+		shas = append(shas, refs.BaseSHA)
+	}
+	for _, sha := range shas {
+		if sha == "" {
+			return nil, fmt.Errorf("commit statuses cannot be reported for %s/%s without resolved commits", refs.Org, refs.Repo)
+		}
+	}
+	return &CommitStatusReporter{
+		client:   client,
+		org:      refs.Org,
+		repo:     refs.Repo,
+		shas:     shas,
+		context:  context,
+		targets:  sets.New[string](targets...),
+		finished: sets.New[string](),
+	}, nil
+}
+
+// targetContext is the context of the status of a target.
+func (r *CommitStatusReporter) targetContext(target string) string {
+	return fmt.Sprintf("%s/%s", r.context, target)
+}
+
+// Started sets the job and all of its targets as pending, linking to the
+// given URL.
+func (r *CommitStatusReporter) Started(targetURL string) {
+	r.lock.Lock()
+	r.targetURL = targetURL
+	r.lock.Unlock()
+	r.report(r.context, github.StatusPending, "Job is running")
+	for _, target := range sets.List(r.targets) {
+		r.report(r.targetContext(target), github.StatusPending, "Target is running")
+	}
+}
+
+func (r *CommitStatusReporter) StepStarted(api.Step) {}
+
+func (r *CommitStatusReporter) StepFinished(step api.Step, err error) {
+	if !r.targets.Has(step.Name()) {
+		return
+	}
+	r.lock.Lock()
+	r.finished.Insert(step.Name())
+	r.lock.Unlock()
+	if err != nil {
+		r.report(r.targetContext(step.Name()), github.StatusFailure, fmt.Sprintf("Target failed: %v", err))
+		return
+	}
+	r.report(r.targetContext(step.Name()), github.StatusSuccess, "Target succeeded")
+}
+
+// Finished reports the outcome of the job. Targets that did not run, like
+// when their dependencies failed, are reported as errors, and so is the job
+// when it was interrupted, whatever the errors of the steps.
+func (r *CommitStatusReporter) Finished(errs []error, interrupted bool) {
+	r.lock.Lock()
+	unfinished := sets.List(r.targets.Difference(r.finished))
+	r.lock.Unlock()
+	for _, target := range unfinished {
+		r.report(r.targetContext(target), github.StatusError, "Target did not run")
+	}
+	if interrupted {
+		r.report(r.context, github.StatusError, "Job was interrupted")
+		return
+	}
+	if len(errs) == 0 {
+		r.report(r.context, github.StatusSuccess, "Job succeeded")
+		return
+	}
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	sort.Strings(messages)
+	r.report(r.context, github.StatusFailure, fmt.Sprintf("Job failed: %s", strings.Join(messages, "; ")))
+}
+
+// report sets the status on all commits. Failures are not fatal to the
+// execution of the job, so they are only logged.
+func (r *CommitStatusReporter) report(context, state, description string) {
+	if len(description) > maxStatusDescription {
+		description = description[:maxStatusDescription-3] + "..."
+	}
+	r.lock.Lock()
+	status := github.Status{State: state, TargetURL: r.targetURL, Description: description, Context: context}
+	r.lock.Unlock()
+	for _, sha := range r.shas {
+		if err := r.client.CreateStatus(r.org, r.repo, sha, status); err != nil {
+			logrus.WithError(err).Warnf("Failed to set the %s status of %s/%s@%s.", context, r.org, r.repo, sha)
+		}
+	}
+}
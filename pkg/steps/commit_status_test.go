@@ -0,0 +1,97 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestNewCommitStatusReporter(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		refs         *prowapi.Refs
+		expectedSHAs []string
+		expectedErr  bool
+	}{{
+		name:        "no refs",
+		expectedErr: true,
+	}, {
+		name:         "postsubmit",
+		refs:         &prowapi.Refs{Org: "org", Repo: "repo", BaseSHA: "base"},
+		expectedSHAs: []string{"base"},
+	}, {
+		name:         "pull requests",
+		refs:         &prowapi.Refs{Org: "org", Repo: "repo", BaseSHA: "base", Pulls: []prowapi.Pull{{SHA: "first"}, {SHA: "second"}}},
+		expectedSHAs: []string{"first", "second"},
+	}, {
+		name:        "unresolved commit",
+		refs:        &prowapi.Refs{Org: "org", Repo: "repo"},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter, err := NewCommitStatusReporter(fakegithub.NewFakeClient(), tc.refs, "ci/ci-operator", nil)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil {
+				testhelper.Diff(t, "commits", reporter.shas, tc.expectedSHAs)
+			}
+		})
+	}
+}
+
+func TestCommitStatusReporter(t *testing.T) {
+	client := fakegithub.NewFakeClient()
+	refs := &prowapi.Refs{Org: "org", Repo: "repo", BaseSHA: "base", Pulls: []prowapi.Pull{{SHA: "head"}}}
+	reporter, err := NewCommitStatusReporter(client, refs, "ci/ci-operator", []string{"unit", "e2e", "lint"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.Started("https://console.example.com/k8s/cluster/projects/ci-op-1234")
+	pending := func(context, description string) github.Status {
+		return github.Status{State: github.StatusPending, TargetURL: "https://console.example.com/k8s/cluster/projects/ci-op-1234", Description: description, Context: context}
+	}
+	testhelper.Diff(t, "statuses when started", client.CreatedStatuses["head"], []github.Status{
+		pending("ci/ci-operator", "Job is running"),
+		pending("ci/ci-operator/e2e", "Target is running"),
+		pending("ci/ci-operator/lint", "Target is running"),
+		pending("ci/ci-operator/unit", "Target is running"),
+	})
+
+	reporter.StepFinished(&fakeStep{name: "src"}, nil)
+	reporter.StepFinished(&fakeStep{name: "unit"}, nil)
+	reporter.StepFinished(&fakeStep{name: "e2e"}, errors.New("the test failed"))
+	reporter.Finished([]error{errors.New("the test failed")}, false)
+	finished := func(context, state, description string) github.Status {
+		status := pending(context, description)
+		status.State = state
+		return status
+	}
+	testhelper.Diff(t, "statuses when finished", client.CreatedStatuses["head"], []github.Status{
+		finished("ci/ci-operator", github.StatusFailure, "Job failed: the test failed"),
+		finished("ci/ci-operator/e2e", github.StatusFailure, "Target failed: the test failed"),
+		finished("ci/ci-operator/lint", github.StatusError, "Target did not run"),
+		finished("ci/ci-operator/unit", github.StatusSuccess, "Target succeeded"),
+	})
+	if _, reported := client.CreatedStatuses["base"]; reported {
+		t.Error("expected no statuses on the base commit of a pull request")
+	}
+
+	client = fakegithub.NewFakeClient()
+	reporter, err = NewCommitStatusReporter(client, refs, "ci/ci-operator", []string{"unit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.Started("https://console.example.com/k8s/cluster/projects/ci-op-1234")
+	reporter.StepFinished(&fakeStep{name: "unit"}, nil)
+	reporter.Finished(nil, true)
+	testhelper.Diff(t, "statuses when interrupted", client.CreatedStatuses["head"], []github.Status{
+		finished("ci/ci-operator", github.StatusError, "Job was interrupted"),
+		finished("ci/ci-operator/unit", github.StatusSuccess, "Target succeeded"),
+	})
+}
@@ -0,0 +1,161 @@
+package steps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/version"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/api/image/docker10"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ContentHashLabel is set on built images to the hash of everything that
+// went into the build, so that a later build with identical content can
+// reuse the image instead of building it again.
+const ContentHashLabel = "io.openshift.ci.content-hash"
+
+// buildContent is everything that determines the result of an image build.
+type buildContent struct {
+	// Operator is the version of ci-operator, which determines how the
+	// build is set up.
+	Operator string                                          `json:"operator"`
+	Sources  []sourceRevision                                `json:"sources"`
+	Config   api.ProjectDirectoryImageBuildStepConfiguration `json:"config"`
+	// Images maps the pipeline images the build uses to their digests.
+	Images map[string]string `json:"images"`
+}
+
+type sourceRevision struct {
+	Org     string   `json:"org"`
+	Repo    string   `json:"repo"`
+	BaseSHA string   `json:"base_sha"`
+	Pulls   []string `json:"pulls,omitempty"`
+}
+
+func revisionFor(refs prowv1.Refs) sourceRevision {
+	revision := sourceRevision{Org: refs.Org, Repo: refs.Repo, BaseSHA: refs.BaseSHA}
+	for _, pull := range refs.Pulls {
+		revision.Pulls = append(revision.Pulls, pull.SHA)
+	}
+	return revision
+}
+
+// identifiedRevision determines whether the refs name the exact commits the
+// source is built from, which is not the case when they only name branches.
+func identifiedRevision(refs prowv1.Refs) bool {
+	if refs.BaseSHA == "" {
+		return false
+	}
+	for _, pull := range refs.Pulls {
+		if pull.SHA == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// contentHash identifies the content of an image build by the version of
+// ci-operator, the revisions of the source code, the build configuration and
+// the digests of the images the build uses. Optional fields that do not
+// influence the result are ignored. When the source is not identified by
+// commits, the content is unknown and the hash is empty.
+func contentHash(jobSpec *api.JobSpec, config api.ProjectDirectoryImageBuildStepConfiguration, images map[string]string) (string, error) {
+	if jobSpec.Refs == nil && len(jobSpec.ExtraRefs) == 0 {
+		return "", nil
+	}
+	if jobSpec.Refs != nil && !identifiedRevision(*jobSpec.Refs) {
+		return "", nil
+	}
+	for _, refs := range jobSpec.ExtraRefs {
+		if !identifiedRevision(refs) {
+			return "", nil
+		}
+	}
+	content := buildContent{Operator: version.Version, Config: config, Images: images}
+	content.Config.Optional = false
+	content.Config.CacheFromPromoted = false
+	content.Config.ReusePromoted = false
+	content.Config.Timeout = nil
+	content.Config.NoOutputTimeout = nil
+	content.Config.RunTimeout = nil
+	if jobSpec.Refs != nil {
+		content.Sources = append(content.Sources, revisionFor(*jobSpec.Refs))
+	}
+	for _, refs := range jobSpec.ExtraRefs {
+		content.Sources = append(content.Sources, revisionFor(refs))
+	}
+	sort.Slice(content.Sources, func(i, j int) bool {
+		return content.Sources[i].Org+"/"+content.Sources[i].Repo < content.Sources[j].Org+"/"+content.Sources[j].Repo
+	})
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal build content: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(raw)), nil
+}
+
+// resolveIdenticalPromotedImage returns the name of the promoted image if it
+// was built from content with the given hash, or an empty string otherwise.
+func resolveIdenticalPromotedImage(ctx context.Context, client ctrlruntimeclient.Reader, promoted api.ImageStreamTagReference, hash string) (string, error) {
+	ist := &imagev1.ImageStreamTag{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: promoted.Namespace, Name: fmt.Sprintf("%s:%s", promoted.Name, promoted.Tag)}, ist); err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("could not get promoted image %s: %w", promoted.ISTagName(), err)
+	}
+	if len(ist.Image.DockerImageMetadata.Raw) == 0 {
+		return "", nil
+	}
+	metadata := &docker10.DockerImage{}
+	if err := json.Unmarshal(ist.Image.DockerImageMetadata.Raw, metadata); err != nil {
+		return "", fmt.Errorf("malformed Docker image metadata on promoted image %s: %w", promoted.ISTagName(), err)
+	}
+	if metadata.Config == nil || metadata.Config.Labels[ContentHashLabel] != hash {
+		return "", nil
+	}
+	return ist.Image.Name, nil
+}
+
+// tagPromotedImage tags the promoted image into the pipeline image stream in
+// place of a build and waits for the tag to be imported.
+func tagPromotedImage(ctx context.Context, client ctrlruntimeclient.Client, jobSpec *api.JobSpec, promoted api.ImageStreamTagReference, image string, to api.PipelineImageStreamTagReference) error {
+	ist := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s:%s", api.PipelineImageStream, to),
+			Namespace: jobSpec.Namespace(),
+		},
+		Tag: &imagev1.TagReference{
+			ReferencePolicy: imagev1.TagReferencePolicy{
+				Type: imagev1.LocalTagReferencePolicy,
+			},
+			From: &coreapi.ObjectReference{
+				Kind:      "ImageStreamImage",
+				Name:      fmt.Sprintf("%s@%s", promoted.Name, image),
+				Namespace: promoted.Namespace,
+			},
+			ImportPolicy: imagev1.TagImportPolicy{
+				ImportMode: imagev1.ImportModePreserveOriginal,
+			},
+		},
+	}
+	if err := client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create imagestreamtag for promoted image: %w", err)
+	}
+	if err := waitForTagImport(ctx, client, jobSpec.Namespace(), api.PipelineImageStream, string(to), 10*time.Second, 35*time.Minute); err != nil {
+		return fmt.Errorf("could not resolve tag %s in imagestream %s: %w", to, api.PipelineImageStream, err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestContentHash(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.Refs = &prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"}
+	config := api.ProjectDirectoryImageBuildStepConfiguration{
+		From: "base",
+		To:   "component",
+		ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+			DockerfilePath: "Dockerfile",
+		},
+	}
+	images := map[string]string{"base": "sha256:base"}
+	expected, err := contentHash(jobSpec, config, images)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		mutate  func(*api.JobSpec, *api.ProjectDirectoryImageBuildStepConfiguration, map[string]string)
+		changed bool
+	}{{
+		name:   "identical content",
+		mutate: func(*api.JobSpec, *api.ProjectDirectoryImageBuildStepConfiguration, map[string]string) {},
+	}, {
+		name: "options that do not change the image",
+		mutate: func(_ *api.JobSpec, config *api.ProjectDirectoryImageBuildStepConfiguration, _ map[string]string) {
+			config.Optional = true
+			config.CacheFromPromoted = true
+			config.ReusePromoted = true
+		},
+	}, {
+		name: "different revision",
+		mutate: func(jobSpec *api.JobSpec, _ *api.ProjectDirectoryImageBuildStepConfiguration, _ map[string]string) {
+			jobSpec.Refs.Pulls = []prowv1.Pull{{Number: 1, SHA: "pull"}}
+		},
+		changed: true,
+	}, {
+		name: "different Dockerfile",
+		mutate: func(_ *api.JobSpec, config *api.ProjectDirectoryImageBuildStepConfiguration, _ map[string]string) {
+			config.DockerfilePath = "Dockerfile.rhel"
+		},
+		changed: true,
+	}, {
+		name: "different base image",
+		mutate: func(_ *api.JobSpec, _ *api.ProjectDirectoryImageBuildStepConfiguration, images map[string]string) {
+			images["base"] = "sha256:other"
+		},
+		changed: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobSpec := &api.JobSpec{}
+			jobSpec.Refs = &prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"}
+			config := config
+			images := map[string]string{"base": "sha256:base"}
+			tc.mutate(jobSpec, &config, images)
+			actual, err := contentHash(jobSpec, config, images)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if changed := actual != expected; changed != tc.changed {
+				t.Errorf("expected hash to change: %t, got %s and %s", tc.changed, expected, actual)
+			}
+		})
+	}
+}
+
+func TestContentHashUnidentifiedRevision(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		jobSpec *api.JobSpec
+	}{{
+		name:    "no refs",
+		jobSpec: &api.JobSpec{},
+	}, {
+		name:    "branch without a resolved commit",
+		jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowv1.Refs{Org: "org", Repo: "repo", BaseRef: "main"}}},
+	}, {
+		name:    "pull request without a commit",
+		jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base", Pulls: []prowv1.Pull{{Number: 1}}}}},
+	}, {
+		name: "extra refs without a resolved commit",
+		jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{
+			Refs:      &prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"},
+			ExtraRefs: []prowv1.Refs{{Org: "org", Repo: "other", BaseRef: "main"}},
+		}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := contentHash(tc.jobSpec, api.ProjectDirectoryImageBuildStepConfiguration{To: "component"}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if hash != "" {
+				t.Errorf("expected no hash for an unidentified revision, got %s", hash)
+			}
+		})
+	}
+}
+
+func TestResolveIdenticalPromotedImage(t *testing.T) {
+	promoted := api.ImageStreamTagReference{Namespace: "ocp", Name: "4.14", Tag: "component"}
+	ist := func(labels string) *imagev1.ImageStreamTag {
+		return &imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: "4.14:component"},
+			Image: imagev1.Image{
+				ObjectMeta:          metav1.ObjectMeta{Name: "sha256:promoted"},
+				DockerImageMetadata: runtime.RawExtension{Raw: []byte(`{"kind":"DockerImage","apiVersion":"1.0","Config":{"Labels":` + labels + `}}`)},
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		objects  []ctrlruntimeclient.Object
+		expected string
+	}{{
+		name: "nothing promoted",
+	}, {
+		name:    "promoted before content hashes were recorded",
+		objects: []ctrlruntimeclient.Object{ist(`{}`)},
+	}, {
+		name:    "promoted from different content",
+		objects: []ctrlruntimeclient.Object{ist(`{"io.openshift.ci.content-hash":"other"}`)},
+	}, {
+		name:     "promoted from identical content",
+		objects:  []ctrlruntimeclient.Object{ist(`{"io.openshift.ci.content-hash":"hash"}`)},
+		expected: "sha256:promoted",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build()
+			actual, err := resolveIdenticalPromotedImage(context.Background(), client, promoted, "hash")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
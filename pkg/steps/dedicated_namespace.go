@@ -0,0 +1,319 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	projectapi "github.com/openshift/api/project/v1"
+	projectclient "github.com/openshift/client-go/project/clientset/versioned/typed/project/v1"
+
+	authapi "k8s.io/api/authorization/v1"
+	coreapi "k8s.io/api/core/v1"
+	rbacapi "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/nsttl"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+const (
+	// DedicatedNamespaceQuota is the name of the ResourceQuota of a
+	// dedicated namespace.
+	DedicatedNamespaceQuota = "ci-operator"
+	// imagePullerClusterRole allows the service accounts of a dedicated
+	// namespace to pull the images of the job.
+	imagePullerClusterRole = "system:image-puller"
+)
+
+var (
+	dedicatedNamespaceRBACTimeout       = 2 * time.Minute
+	dedicatedNamespacePullSecretTimeout = 5 * time.Minute
+)
+
+// dedicatedNamespaceStep wraps a test and sets up the namespace of its own it
+// runs in before it runs it.
+type dedicatedNamespaceStep struct {
+	config          api.DedicatedNamespace
+	wrapped         api.Step
+	client          ctrlruntimeclient.Client
+	projectRequests projectclient.ProjectRequestInterface
+	jobSpec         *api.JobSpec
+}
+
+// DedicatedNamespaceStep creates the namespace of jobSpec, which must have
+// been derived from the job spec of the job with Dedicated, before the
+// wrapped test runs in it. The namespace is requested as a project, like the
+// namespace of the job, annotated for cleanup like it and is given its own
+// quota, the secrets of the job and image streams tagging the images of the
+// job, which its service accounts are allowed to pull from the namespace of
+// the job.
+func DedicatedNamespaceStep(config api.DedicatedNamespace, wrapped api.Step, client ctrlruntimeclient.Client, projectRequests projectclient.ProjectRequestInterface, jobSpec *api.JobSpec) api.Step {
+	return &dedicatedNamespaceStep{
+		config:          config,
+		wrapped:         wrapped,
+		client:          client,
+		projectRequests: projectRequests,
+		jobSpec:         jobSpec,
+	}
+}
+
+func (s *dedicatedNamespaceStep) Inputs() (api.InputDefinition, error) { return s.wrapped.Inputs() }
+func (s *dedicatedNamespaceStep) Validate() error                      { return s.wrapped.Validate() }
+func (s *dedicatedNamespaceStep) Name() string                         { return s.wrapped.Name() }
+func (s *dedicatedNamespaceStep) Description() string                  { return s.wrapped.Description() }
+func (s *dedicatedNamespaceStep) Requires() []api.StepLink             { return s.wrapped.Requires() }
+func (s *dedicatedNamespaceStep) Creates() []api.StepLink              { return s.wrapped.Creates() }
+func (s *dedicatedNamespaceStep) Objects() []ctrlruntimeclient.Object  { return s.wrapped.Objects() }
+func (s *dedicatedNamespaceStep) Provides() api.ParameterMap           { return s.wrapped.Provides() }
+func (s *dedicatedNamespaceStep) Parameters() []api.Parameter          { return api.ParametersFor(s.wrapped) }
+
+func (s *dedicatedNamespaceStep) SubTests() []*junit.TestCase {
+	if subTests, ok := s.wrapped.(SubtestReporter); ok {
+		return subTests.SubTests()
+	}
+	return nil
+}
+
+func (s *dedicatedNamespaceStep) ProvidesFromRun() bool { return providesFromRun(s.wrapped) }
+
+func (s *dedicatedNamespaceStep) Run(ctx context.Context) error {
+	if err := s.setup(ctx); err != nil {
+		return results.ForReason("setting_up_namespace").WithError(err).Errorf("could not set up namespace %s for %s: %v", s.jobSpec.Namespace(), s.wrapped.Name(), err)
+	}
+	return runWithTimeout(ctx, s.wrapped)
+}
+
+func (s *dedicatedNamespaceStep) setup(ctx context.Context) error {
+	parent := s.jobSpec.Parent().Namespace()
+	namespace := s.jobSpec.Namespace()
+	if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+		return fmt.Errorf("invalid namespace name: %s", strings.Join(errs, ", "))
+	}
+	logger := LoggerFor(ctx)
+	logger.Infof("Setting up namespace %s for %s.", namespace, s.wrapped.Name())
+	if err := s.createNamespace(ctx, parent, namespace); err != nil {
+		return err
+	}
+	if len(s.config.Quota) != 0 {
+		quota, err := dedicatedNamespaceQuota(namespace, s.config.Quota)
+		if err != nil {
+			return err
+		}
+		if err := createIfNotExists(ctx, s.client, quota); err != nil {
+			return fmt.Errorf("could not create quota: %w", err)
+		}
+	}
+	if err := createIfNotExists(ctx, s.client, imagePullerRoleBinding(parent, namespace)); err != nil {
+		return fmt.Errorf("could not allow the namespace to pull images: %w", err)
+	}
+	if err := s.copySecrets(ctx, parent, namespace); err != nil {
+		return err
+	}
+	if err := s.tagImages(ctx, parent, namespace); err != nil {
+		return err
+	}
+	return s.waitForPullSecrets(ctx, namespace)
+}
+
+// createNamespace requests the namespace as a project, which grants the
+// requester the permissions it has in the namespace of the job, waits for
+// them to be effective and annotates the namespace, which a ProjectRequest
+// cannot do.
+func (s *dedicatedNamespaceStep) createNamespace(ctx context.Context, parent, namespace string) error {
+	job := &coreapi.Namespace{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: parent}, job); err != nil {
+		return fmt.Errorf("could not get namespace %s: %w", parent, err)
+	}
+	project, err := s.projectRequests.Create(ctx, &projectapi.ProjectRequest{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   namespace,
+			Labels: map[string]string{api.DPTPRequesterLabel: "ci-operator"},
+		},
+		DisplayName: fmt.Sprintf("%s - %s", namespace, s.jobSpec.Job),
+		Description: fmt.Sprintf("Dedicated namespace of %s in %s", s.wrapped.Name(), parent),
+	}, meta.CreateOptions{})
+	if err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return fmt.Errorf("could not request project: %w", err)
+		}
+		ns := &coreapi.Namespace{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: namespace}, ns); err != nil {
+			return fmt.Errorf("could not get namespace: %w", err)
+		}
+		if ns.Status.Phase == coreapi.NamespaceTerminating {
+			return fmt.Errorf("the namespace is terminating")
+		}
+	} else if project.Status.Phase == coreapi.NamespaceTerminating {
+		return fmt.Errorf("the namespace is terminating")
+	}
+	if err := s.waitForRBAC(ctx, namespace); err != nil {
+		return err
+	}
+	// the namespace is cleaned up by external tooling like the namespace of
+	// the job
+	annotations := map[string]string{}
+	for _, key := range []string{nsttl.AnnotationIdleCleanupDurationTTL, nsttl.AnnotationCleanupDurationTTL, nsttl.AnnotationNamespaceLastActive} {
+		if value, ok := job.Annotations[key]; ok {
+			annotations[key] = value
+		}
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ns := &coreapi.Namespace{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: namespace}, ns); err != nil {
+			return err
+		}
+		if ns.Annotations == nil {
+			ns.Annotations = map[string]string{}
+		}
+		for key, value := range annotations {
+			ns.Annotations[key] = value
+		}
+		return s.client.Update(ctx, ns)
+	}); err != nil {
+		return fmt.Errorf("could not annotate namespace: %w", err)
+	}
+	return nil
+}
+
+// waitForRBAC waits for the permissions granted by the project request to be
+// effective, as the namespace of the job does.
+func (s *dedicatedNamespaceStep) waitForRBAC(ctx context.Context, namespace string) error {
+	if err := wait.PollUntilContextTimeout(ctx, time.Second, dedicatedNamespaceRBACTimeout, true, func(ctx context.Context) (bool, error) {
+		sar := &authapi.SelfSubjectAccessReview{Spec: authapi.SelfSubjectAccessReviewSpec{ResourceAttributes: &authapi.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      "create",
+			Resource:  "rolebindings",
+		}}}
+		if err := s.client.Create(ctx, sar); err != nil {
+			return false, err
+		}
+		return sar.Status.Allowed, nil
+	}); err != nil {
+		return fmt.Errorf("RBAC was not initialized: %w", err)
+	}
+	return nil
+}
+
+func dedicatedNamespaceQuota(namespace string, quota api.ResourceList) (*coreapi.ResourceQuota, error) {
+	hard := coreapi.ResourceList{}
+	for name, value := range quota {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota for %s: %w", name, err)
+		}
+		hard[coreapi.ResourceName(name)] = quantity
+	}
+	return &coreapi.ResourceQuota{
+		ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: DedicatedNamespaceQuota},
+		Spec:       coreapi.ResourceQuotaSpec{Hard: hard},
+	}, nil
+}
+
+// imagePullerRoleBinding allows the service accounts of the dedicated
+// namespace to pull from the image streams of the job.
+func imagePullerRoleBinding(parent, namespace string) *rbacapi.RoleBinding {
+	return &rbacapi.RoleBinding{
+		ObjectMeta: meta.ObjectMeta{Namespace: parent, Name: fmt.Sprintf("image-puller-%s", namespace)},
+		RoleRef:    rbacapi.RoleRef{APIGroup: rbacapi.GroupName, Kind: "ClusterRole", Name: imagePullerClusterRole},
+		Subjects:   []rbacapi.Subject{{APIGroup: rbacapi.GroupName, Kind: rbacapi.GroupKind, Name: fmt.Sprintf("system:serviceaccounts:%s", namespace)}},
+	}
+}
+
+// copySecrets copies the secrets of the job, like the cluster profile and
+// the pull secret, except for those of its service accounts.
+func (s *dedicatedNamespaceStep) copySecrets(ctx context.Context, parent, namespace string) error {
+	secrets := &coreapi.SecretList{}
+	if err := s.client.List(ctx, secrets, ctrlruntimeclient.InNamespace(parent)); err != nil {
+		return fmt.Errorf("could not list secrets: %w", err)
+	}
+	var errs []error
+	for _, secret := range secrets.Items {
+		if _, ok := secret.Annotations[coreapi.ServiceAccountNameKey]; ok || secret.Type == coreapi.SecretTypeServiceAccountToken {
+			continue
+		}
+		secretCopy := &coreapi.Secret{
+			ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: secret.Name, Labels: secret.Labels},
+			Type:       secret.Type,
+			Data:       secret.Data,
+			Immutable:  secret.Immutable,
+		}
+		if err := createIfNotExists(ctx, s.client, secretCopy); err != nil {
+			errs = append(errs, fmt.Errorf("could not copy secret %s: %w", secret.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// tagImages creates an image stream in the namespace for each image stream
+// of the job, tagging the images the job has at this point by digest, so that
+// the test refers to the images as it would in the namespace of the job.
+func (s *dedicatedNamespaceStep) tagImages(ctx context.Context, parent, namespace string) error {
+	streams := &imagev1.ImageStreamList{}
+	if err := s.client.List(ctx, streams, ctrlruntimeclient.InNamespace(parent)); err != nil {
+		return fmt.Errorf("could not list image streams: %w", err)
+	}
+	var errs []error
+	for _, stream := range streams.Items {
+		streamCopy := &imagev1.ImageStream{
+			ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: stream.Name},
+			Spec:       imagev1.ImageStreamSpec{LookupPolicy: imagev1.ImageLookupPolicy{Local: true}},
+		}
+		for _, tag := range stream.Status.Tags {
+			if len(tag.Items) == 0 {
+				continue
+			}
+			streamCopy.Spec.Tags = append(streamCopy.Spec.Tags, imagev1.TagReference{
+				Name: tag.Tag,
+				From: &coreapi.ObjectReference{
+					Kind:      "ImageStreamImage",
+					Namespace: parent,
+					Name:      fmt.Sprintf("%s@%s", stream.Name, tag.Items[0].Image),
+				},
+			})
+		}
+		if err := createIfNotExists(ctx, s.client, streamCopy); err != nil {
+			errs = append(errs, fmt.Errorf("could not create image stream %s: %w", stream.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// waitForPullSecrets waits for the pull secrets of the default service
+// account, without which pods cannot pull from the registry of the cluster.
+func (s *dedicatedNamespaceStep) waitForPullSecrets(ctx context.Context, namespace string) error {
+	if err := wait.PollUntilContextTimeout(ctx, 2*time.Second, dedicatedNamespacePullSecretTimeout, true, func(ctx context.Context) (bool, error) {
+		serviceAccount := &coreapi.ServiceAccount{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: "default"}, serviceAccount); err != nil {
+			if kerrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return len(serviceAccount.ImagePullSecrets) != 0, nil
+	}); err != nil {
+		return fmt.Errorf("image pull secrets were not minted: %w", err)
+	}
+	return nil
+}
+
+func createIfNotExists(ctx context.Context, client ctrlruntimeclient.Client, obj ctrlruntimeclient.Object) error {
+	if err := client.Create(ctx, obj); err != nil && !kerrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
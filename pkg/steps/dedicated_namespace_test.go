@@ -0,0 +1,129 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	projectapi "github.com/openshift/api/project/v1"
+
+	authapi "k8s.io/api/authorization/v1"
+	coreapi "k8s.io/api/core/v1"
+	rbacapi "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/nsttl"
+)
+
+// fakeProjectRequests creates the namespace of the project, as the API does.
+type fakeProjectRequests struct {
+	client   ctrlruntimeclient.Client
+	requests []string
+}
+
+func (f *fakeProjectRequests) Create(ctx context.Context, request *projectapi.ProjectRequest, _ meta.CreateOptions) (*projectapi.Project, error) {
+	f.requests = append(f.requests, request.Name)
+	ns := &coreapi.Namespace{ObjectMeta: meta.ObjectMeta{Name: request.Name, Labels: request.Labels}}
+	if err := f.client.Create(ctx, ns); err != nil {
+		return nil, err
+	}
+	return &projectapi.Project{ObjectMeta: ns.ObjectMeta}, nil
+}
+
+func TestDedicatedNamespaceStep(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, client ctrlruntimeclient.WithWatch, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+			if sar, ok := obj.(*authapi.SelfSubjectAccessReview); ok {
+				sar.Status.Allowed = true
+				return nil
+			}
+			return client.Create(ctx, obj, opts...)
+		},
+	}).WithRuntimeObjects(
+		&coreapi.Namespace{ObjectMeta: meta.ObjectMeta{Name: "ci-op-1234", Annotations: map[string]string{
+			nsttl.AnnotationIdleCleanupDurationTTL: "1h0m0s",
+			"other":                                "value",
+		}}},
+		&coreapi.Secret{ObjectMeta: meta.ObjectMeta{Namespace: "ci-op-1234", Name: "cluster-profile"}, Data: map[string][]byte{"key": []byte("value")}},
+		&coreapi.Secret{ObjectMeta: meta.ObjectMeta{Namespace: "ci-op-1234", Name: "default-token", Annotations: map[string]string{coreapi.ServiceAccountNameKey: "default"}}},
+		&imagev1.ImageStream{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ci-op-1234", Name: api.PipelineImageStream},
+			Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{
+				{Tag: "src", Items: []imagev1.TagEvent{{Image: "sha256:new"}, {Image: "sha256:old"}}},
+				{Tag: "pending"},
+			}},
+		},
+		&coreapi.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: "ci-op-1234-e2e", Name: "default"}, ImagePullSecrets: []coreapi.LocalObjectReference{{Name: "default-dockercfg"}}},
+	).Build()
+	jobSpec := &api.JobSpec{}
+	dedicated := jobSpec.Dedicated("e2e")
+	// the namespace of the job is set after the steps are created
+	jobSpec.SetNamespace("ci-op-1234")
+	wrapped := &fakeStep{name: "e2e"}
+	quota := api.DedicatedNamespace{Quota: api.ResourceList{"pods": "10"}}
+
+	projectRequests := &fakeProjectRequests{client: client}
+	if err := DedicatedNamespaceStep(quota, wrapped, client, projectRequests, dedicated).Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wrapped.numRuns != 1 {
+		t.Errorf("expected the step to run once, ran %d times", wrapped.numRuns)
+	}
+
+	ctx := context.Background()
+	ns := &coreapi.Namespace{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: "ci-op-1234-e2e"}, ns); err != nil {
+		t.Fatalf("could not get namespace: %v", err)
+	}
+	if diff := cmp.Diff([]string{"ci-op-1234-e2e"}, projectRequests.requests); diff != "" {
+		t.Errorf("unexpected project requests: %s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{nsttl.AnnotationIdleCleanupDurationTTL: "1h0m0s"}, ns.Annotations); diff != "" {
+		t.Errorf("unexpected annotations: %s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{api.DPTPRequesterLabel: "ci-operator"}, ns.Labels); diff != "" {
+		t.Errorf("unexpected labels: %s", diff)
+	}
+	resourceQuota := &coreapi.ResourceQuota{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1234-e2e", Name: DedicatedNamespaceQuota}, resourceQuota); err != nil {
+		t.Fatalf("could not get quota: %v", err)
+	}
+	if diff := cmp.Diff(coreapi.ResourceList{"pods": resource.MustParse("10")}, resourceQuota.Spec.Hard, cmp.Comparer(func(x, y resource.Quantity) bool { return x.Cmp(y) == 0 })); diff != "" {
+		t.Errorf("unexpected quota: %s", diff)
+	}
+	binding := &rbacapi.RoleBinding{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1234", Name: "image-puller-ci-op-1234-e2e"}, binding); err != nil {
+		t.Fatalf("could not get role binding: %v", err)
+	}
+	if diff := cmp.Diff([]rbacapi.Subject{{APIGroup: rbacapi.GroupName, Kind: rbacapi.GroupKind, Name: "system:serviceaccounts:ci-op-1234-e2e"}}, binding.Subjects); diff != "" {
+		t.Errorf("unexpected subjects: %s", diff)
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1234-e2e", Name: "cluster-profile"}, &coreapi.Secret{}); err != nil {
+		t.Errorf("the secret was not copied: %v", err)
+	}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1234-e2e", Name: "default-token"}, &coreapi.Secret{}); !kerrors.IsNotFound(err) {
+		t.Errorf("the secret of the service account should not be copied, got %v", err)
+	}
+	stream := &imagev1.ImageStream{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1234-e2e", Name: api.PipelineImageStream}, stream); err != nil {
+		t.Fatalf("could not get image stream: %v", err)
+	}
+	expected := imagev1.ImageStreamSpec{
+		LookupPolicy: imagev1.ImageLookupPolicy{Local: true},
+		Tags: []imagev1.TagReference{{
+			Name: "src",
+			From: &coreapi.ObjectReference{Kind: "ImageStreamImage", Namespace: "ci-op-1234", Name: "pipeline@sha256:new"},
+		}},
+	}
+	if diff := cmp.Diff(expected, stream.Spec); diff != "" {
+		t.Errorf("unexpected image stream: %s", diff)
+	}
+}
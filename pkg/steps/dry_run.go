@@ -0,0 +1,38 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// StepDryRun holds the actions a step would perform.
+type StepDryRun struct {
+	Step    string             `json:"step"`
+	Actions []api.DryRunAction `json:"actions"`
+}
+
+// DryRun describes what every step would do, in order, without changing
+// anything in the cluster. Steps that cannot describe their actions in
+// detail are represented by their description.
+func DryRun(ctx context.Context, steps []api.Step) ([]StepDryRun, error) {
+	var ret []StepDryRun
+	for _, step := range steps {
+		actions := []api.DryRunAction{{Description: step.Description()}}
+		if runner, ok := step.(api.DryRunner); ok {
+			var err error
+			if actions, err = runner.DryRun(ctx); err != nil {
+				return nil, fmt.Errorf("could not describe step %s: %w", step.Name(), err)
+			}
+		}
+		ret = append(ret, StepDryRun{Step: step.Name(), Actions: actions})
+	}
+	return ret, nil
+}
+
+// DryRunPlaceholder stands in for a value that can only be resolved from the
+// cluster while the step runs.
+func DryRunPlaceholder(value string) string {
+	return fmt.Sprintf("<%s>", value)
+}
@@ -0,0 +1,48 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestDryRun(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("job-namespace")
+	output := &outputImageTagStep{
+		config: api.OutputImageTagStepConfiguration{
+			From: api.PipelineImageStreamTagReferenceRoot,
+			To:   api.ImageStreamTagReference{Namespace: "ocp", Name: "4.14", Tag: "root"},
+		},
+		jobSpec: jobSpec,
+	}
+	actual, err := DryRun(context.Background(), []api.Step{&fakeStep{name: "fake"}, output})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []StepDryRun{
+		{Step: "fake", Actions: []api.DryRunAction{{Description: "fake"}}},
+		{Step: output.Name(), Actions: []api.DryRunAction{{
+			Description: "Tag pipeline:root as ocp/4.14:root",
+			Object: &imagev1.ImageStreamTag{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: "4.14:root"},
+				Tag: &imagev1.TagReference{
+					From: &corev1.ObjectReference{
+						Kind:      "ImageStreamImage",
+						Namespace: "job-namespace",
+						Name:      "pipeline@<image of pipeline:root>",
+					},
+					ReferencePolicy: imagev1.TagReferencePolicy{Type: imagev1.LocalTagReferencePolicy},
+				},
+			},
+		}}},
+	}
+	testhelper.Diff(t, "dry run", actual, expected)
+}
@@ -0,0 +1,114 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ExposedTestLabel selects the pod of an exposed test for its Service.
+const ExposedTestLabel = "ci.openshift.io/exposed-test"
+
+// exposedRouteTimeout is how long consumers wait for the Route of an exposed
+// test to be admitted. The tests are not ordered, so the exposed test may
+// start well after its consumers.
+var exposedRouteTimeout = 30 * time.Minute
+
+// exposedObjects returns the Service, and the Route if requested, that expose
+// the pod of the test.
+func exposedObjects(as string, expose api.TestExposure, jobSpec *api.JobSpec) (*coreapi.Service, *routev1.Route) {
+	commonMeta := meta.ObjectMeta{
+		Name:      as,
+		Namespace: jobSpec.Namespace(),
+		Labels:    labelsFor(jobSpec, map[string]string{ExposedTestLabel: as}),
+	}
+	service := &coreapi.Service{
+		ObjectMeta: commonMeta,
+		Spec: coreapi.ServiceSpec{
+			Ports: []coreapi.ServicePort{{
+				Port:       expose.Port,
+				Protocol:   coreapi.ProtocolTCP,
+				TargetPort: intstr.FromInt(int(expose.Port)),
+			}},
+			Selector: map[string]string{ExposedTestLabel: as},
+		},
+	}
+	if !expose.Route {
+		return service, nil
+	}
+	route := &routev1.Route{
+		ObjectMeta: commonMeta,
+		Spec: routev1.RouteSpec{
+			To: routev1.RouteTargetReference{
+				Kind: "Service",
+				Name: as,
+			},
+			Port: &routev1.RoutePort{
+				TargetPort: intstr.FromInt(int(expose.Port)),
+			},
+		},
+	}
+	return service, route
+}
+
+// expose creates the objects exposing the pod of the test and returns a
+// function deleting them.
+func (s *podStep) expose(ctx context.Context) (func(), error) {
+	service, route := exposedObjects(s.config.As, *s.config.Expose, s.jobSpec)
+	objects := []ctrlruntimeclient.Object{service}
+	if route != nil {
+		objects = append(objects, route)
+	}
+	var created []ctrlruntimeclient.Object
+	teardown := func() {
+		for _, o := range created {
+			if err := s.client.Delete(CleanupCtx, o); err != nil && !kerrors.IsNotFound(err) {
+				logrus.WithError(err).Warnf("Could not delete %T %s exposing test %s.", o, o.GetName(), s.config.As)
+			}
+		}
+	}
+	for _, o := range objects {
+		if err := s.client.Create(ctx, o); err != nil && !kerrors.IsAlreadyExists(err) {
+			teardown()
+			return nil, fmt.Errorf("could not create %T %s exposing test %s: %w", o, o.GetName(), s.config.As, err)
+		}
+		created = append(created, o)
+	}
+	return teardown, nil
+}
+
+// exposedURL resolves the URL of the exposed test: the host of its Route, or
+// the address of its Service in the cluster.
+func (s *podStep) exposedURL() (string, error) {
+	if !s.config.Expose.Route {
+		return fmt.Sprintf("http://%s.%s.svc:%d", s.config.As, s.jobSpec.Namespace(), s.config.Expose.Port), nil
+	}
+	host, err := admittedHostForRoute(s.client, s.jobSpec.Namespace(), s.config.As, exposedRouteTimeout)
+	if err != nil {
+		return "", fmt.Errorf("unable to calculate the URL of test %s: %w", s.config.As, err)
+	}
+	return fmt.Sprintf("http://%s", host), nil
+}
+
+func (s *podStep) Parameters() []api.Parameter {
+	if s.config.Expose == nil {
+		return nil
+	}
+	return []api.Parameter{{
+		Name:        api.ExposedURLEnv(s.config.As),
+		Description: fmt.Sprintf("URL test %s is exposed at while it runs", s.config.As),
+		Value:       s.exposedURL,
+	}}
+}
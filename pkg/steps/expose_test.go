@@ -0,0 +1,114 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+// exposeRecordingClient records the services that exist when pods are
+// created.
+type exposeRecordingClient struct {
+	*podStatusChangingClient
+	servicesAtPodCreation []string
+}
+
+func (c *exposeRecordingClient) Create(ctx context.Context, o ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	if _, ok := o.(*corev1.Pod); ok {
+		services := &corev1.ServiceList{}
+		if err := c.List(ctx, services); err != nil {
+			return err
+		}
+		for _, service := range services.Items {
+			c.servicesAtPodCreation = append(c.servicesAtPodCreation, service.Name)
+		}
+	}
+	return c.podStatusChangingClient.Create(ctx, o, opts...)
+}
+
+func TestPodStepExpose(t *testing.T) {
+	if err := routev1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	ps, _ := preparePodStep("ns")
+	ps.config.As = "server"
+	ps.config.Expose = &api.TestExposure{Port: 8080, Route: true}
+	client := &exposeRecordingClient{podStatusChangingClient: &podStatusChangingClient{WithWatch: fakectrlruntimeclient.NewClientBuilder().Build(), dest: corev1.PodSucceeded}}
+	ps.client = kubernetes.NewPodClient(loggingclient.New(client), nil, nil, 0, nil)
+
+	if err := ps.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "services at pod creation", client.servicesAtPodCreation, []string{"server"})
+
+	pod := &corev1.Pod{}
+	if err := ps.client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "server"}, pod); err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	testhelper.Diff(t, "pod label", pod.Labels[ExposedTestLabel], "server")
+
+	for _, o := range []ctrlruntimeclient.Object{&corev1.Service{}, &routev1.Route{}} {
+		if err := ps.client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "server"}, o); !kerrors.IsNotFound(err) {
+			t.Errorf("expected %T to be deleted with the step, got %v", o, err)
+		}
+	}
+}
+
+func TestPodStepExposedURL(t *testing.T) {
+	if err := routev1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	route := &routev1.Route{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "server"},
+		Status: routev1.RouteStatus{Ingress: []routev1.RouteIngress{{
+			Host:       "server.apps.example.com",
+			Conditions: []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: corev1.ConditionTrue}},
+		}}},
+	}
+	for _, tc := range []struct {
+		name     string
+		expose   api.TestExposure
+		expected string
+	}{
+		{
+			name:     "service",
+			expose:   api.TestExposure{Port: 8080},
+			expected: "http://server.ns.svc:8080",
+		},
+		{
+			name:     "route",
+			expose:   api.TestExposure{Port: 8080, Route: true},
+			expected: "http://server.apps.example.com",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ps, _ := preparePodStep("ns")
+			ps.config.As = "server"
+			ps.config.Expose = &tc.expose
+			ps.client = kubernetes.NewPodClient(loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithObjects(route.DeepCopy()).Build()), nil, nil, 0, nil)
+			params := ps.Parameters()
+			if len(params) != 1 {
+				t.Fatalf("expected a single parameter, got %v", params)
+			}
+			testhelper.Diff(t, "name", params[0].Name, "SERVER_URL")
+			url, err := params[0].Value()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testhelper.Diff(t, "url", url, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+
+	coreapi "k8s.io/api/core/v1"
+
+	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+	"github.com/openshift/ci-tools/pkg/release"
+)
+
+// manifestMediaTypes are the manifests accepted when resolving the digest of
+// an external image. Manifest lists are preferred, so the digest is the same
+// one the image is imported with.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// externalImageReference is an image in a registry outside the cluster.
+type externalImageReference struct {
+	registry   string
+	repository string
+	// reference is the tag or the digest of the image
+	reference string
+	digest    bool
+}
+
+func parseExternalImage(pullSpec string) (*externalImageReference, error) {
+	named, err := reference.ParseNormalizedNamed(pullSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pull spec %q: %w", pullSpec, err)
+	}
+	ref := &externalImageReference{registry: reference.Domain(named), repository: reference.Path(named)}
+	if canonical, ok := named.(reference.Canonical); ok {
+		ref.reference, ref.digest = canonical.Digest().String(), true
+		return ref, nil
+	}
+	ref.reference = reference.TagNameOnly(named).(reference.Tagged).Tag()
+	return ref, nil
+}
+
+// endpoint is the host serving the registry API, docker.io being the only
+// registry not served at its own domain.
+func (r *externalImageReference) endpoint() string {
+	if r.registry == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return r.registry
+}
+
+// digestPullSpec is the pull spec of the image by its digest.
+func (r *externalImageReference) digestPullSpec(digest string) string {
+	return fmt.Sprintf("%s/%s@%s", r.registry, r.repository, digest)
+}
+
+// resolveExternalDigest resolves the digest of the image from the registry,
+// authenticating with the credentials of the pull secret when the registry
+// asks for them. Pull specs by digest are not resolved.
+func resolveExternalDigest(ctx context.Context, client release.HTTPClient, pullSpec string, pullSecret *coreapi.Secret) (string, error) {
+	ref, err := parseExternalImage(pullSpec)
+	if err != nil {
+		return "", err
+	}
+	if ref.digest {
+		return ref.reference, nil
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.endpoint(), ref.repository, ref.reference)
+	resp, err := headManifest(ctx, client, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := registryToken(ctx, client, resp.Header.Get("WWW-Authenticate"), ref.registry, pullSecret)
+		if err != nil {
+			return "", fmt.Errorf("could not authenticate to %s: %w", ref.registry, err)
+		}
+		if resp, err = headManifest(ctx, client, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not resolve %s: the registry responded with status %s", pullSpec, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("could not resolve %s: the registry did not respond with the digest", pullSpec)
+	}
+	return digest, nil
+}
+
+func headManifest(ctx context.Context, client release.HTTPClient, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the request: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not request %s: %w", manifestURL, err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// registryToken requests a token for the challenge of the registry, as
+// described by the token authentication of the distribution specification.
+func registryToken(ctx context.Context, client release.HTTPClient, challenge, registry string, pullSecret *coreapi.Secret) (string, error) {
+	scheme, params, found := strings.Cut(challenge, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported challenge %q", challenge)
+	}
+	query := url.Values{}
+	var realm string
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		value = strings.Trim(value, `"`)
+		switch key {
+		case "realm":
+			realm = value
+		case "service", "scope":
+			query.Set(key, value)
+		}
+	}
+	if realm == "" {
+		return "", fmt.Errorf("challenge %q has no realm", challenge)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?%s", realm, query.Encode()), nil)
+	if err != nil {
+		return "", fmt.Errorf("could not create the request: %w", err)
+	}
+	username, password, err := registryCredentials(pullSecret, registry)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not request a token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the token was refused with status %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read the token: %w", err)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("could not parse the token: %w", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+// registryCredentials finds the credentials for the registry in the pull
+// secret. Images are pulled anonymously without them.
+func registryCredentials(pullSecret *coreapi.Secret, registry string) (string, string, error) {
+	if pullSecret == nil {
+		return "", "", nil
+	}
+	var dockercfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(pullSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
+		return "", "", fmt.Errorf("failed to deserialize pull secret: %w", err)
+	}
+	entry, ok := dockercfg.Auths[registry]
+	if !ok && registry == "docker.io" {
+		entry, ok = dockercfg.Auths["https://index.docker.io/v1/"]
+	}
+	if !ok {
+		return "", "", nil
+	}
+	return entry.Username, entry.Password, nil
+}
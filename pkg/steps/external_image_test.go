@@ -0,0 +1,171 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+const externalDigest = "sha256:8f6b9d9d7d4b0c7a2f0e2c1b1d6a3f6e6c7b0a9d8e7f6a5b4c3d2e1f0a9b8c7d"
+
+// fakeRegistry serves the manifest of org/repo:tag, requiring a token
+// obtained with the credentials user:pass when authenticated is set.
+func fakeRegistry(authenticated bool) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:org/repo:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token":"secret"}`)
+		case "/v2/org/repo/manifests/tag":
+			if authenticated && r.Header.Get("Authorization") != "Bearer secret" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:org/repo:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !strings.Contains(r.Header.Get("Accept"), "application/vnd.docker.distribution.manifest.list.v2+json") {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", externalDigest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func pullSecretFor(registry string) *coreapi.Secret {
+	return &coreapi.Secret{
+		Data: map[string][]byte{
+			coreapi.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths":{%q:{"auth":"dXNlcjpwYXNz"}}}`, registry)),
+		},
+	}
+}
+
+func TestResolveExternalDigest(t *testing.T) {
+	anonymous := fakeRegistry(false)
+	defer anonymous.Close()
+	authenticated := fakeRegistry(true)
+	defer authenticated.Close()
+	anonymousHost := strings.TrimPrefix(anonymous.URL, "https://")
+	authenticatedHost := strings.TrimPrefix(authenticated.URL, "https://")
+
+	for _, tc := range []struct {
+		name        string
+		server      *httptest.Server
+		pullSpec    string
+		pullSecret  *coreapi.Secret
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "pull spec by digest is not resolved",
+			server:   anonymous,
+			pullSpec: "quay.io/org/repo@" + externalDigest,
+			expected: externalDigest,
+		},
+		{
+			name:     "anonymous registry",
+			server:   anonymous,
+			pullSpec: anonymousHost + "/org/repo:tag",
+			expected: externalDigest,
+		},
+		{
+			name:        "unknown tag",
+			server:      anonymous,
+			pullSpec:    anonymousHost + "/org/repo:other",
+			expectedErr: true,
+		},
+		{
+			name:       "authenticated registry",
+			server:     authenticated,
+			pullSpec:   authenticatedHost + "/org/repo:tag",
+			pullSecret: pullSecretFor(authenticatedHost),
+			expected:   externalDigest,
+		},
+		{
+			name:        "authenticated registry without credentials",
+			server:      authenticated,
+			pullSpec:    authenticatedHost + "/org/repo:tag",
+			pullSecret:  pullSecretFor("quay.io"),
+			expectedErr: true,
+		},
+		{
+			name:        "invalid pull spec",
+			server:      anonymous,
+			pullSpec:    "quay.io/Org/repo:tag",
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			digest, err := resolveExternalDigest(context.Background(), tc.server.Client(), tc.pullSpec, tc.pullSecret)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			testhelper.Diff(t, "digest", digest, tc.expected)
+		})
+	}
+}
+
+func TestExternalInputImageTagStep(t *testing.T) {
+	server := fakeRegistry(false)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	config := &api.InputImageTagStepConfiguration{
+		InputImage: api.InputImage{
+			BaseImage: api.ImageStreamTagReference{PullSpec: host + "/org/repo:tag"},
+			To:        "external",
+		},
+	}
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("target-namespace")
+	client := loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build())
+	step := ExternalInputImageTagStep(config, client, jobSpec, server.Client(), nil).(*inputImageTagStep)
+
+	inputs, err := step.Inputs()
+	if err != nil {
+		t.Fatalf("could not resolve the inputs: %v", err)
+	}
+	testhelper.Diff(t, "inputs", inputs, api.InputDefinition{externalDigest})
+	expected := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pipeline:external",
+			Namespace: "target-namespace",
+		},
+		Tag: &imagev1.TagReference{
+			ReferencePolicy: imagev1.TagReferencePolicy{
+				Type: imagev1.LocalTagReferencePolicy,
+			},
+			From: &coreapi.ObjectReference{
+				Kind: "DockerImage",
+				Name: fmt.Sprintf("%s/org/repo@%s", host, externalDigest),
+			},
+			ImportPolicy: imagev1.TagImportPolicy{
+				ImportMode: imagev1.ImportModePreserveOriginal,
+			},
+		},
+	}
+	testhelper.Diff(t, "image stream tag", step.imageStreamTag(), expected)
+}
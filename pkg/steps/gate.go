@@ -0,0 +1,266 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// gateCheck determines whether a gate holds. An error is returned when the
+// gate cannot be checked; it is retried like a gate that does not hold.
+type gateCheck func(ctx context.Context) (bool, error)
+
+// GatePolicy limits what gates may check, as they check it with the
+// credentials of ci-operator. By default, resource and image stream tag gates
+// may only check the test namespace and HTTP gates are not allowed.
+type GatePolicy struct {
+	// Namespaces resource and image stream tag gates may check besides the
+	// test namespace.
+	Namespaces sets.Set[string]
+	// ClusterScoped allows resource gates to check cluster-scoped resources.
+	ClusterScoped bool
+	// HTTPHosts are the hosts HTTP gates may send requests to.
+	HTTPHosts sets.Set[string]
+}
+
+// validate checks that the gate only checks what the policy allows.
+func (p GatePolicy) validate(gate api.StepGate) error {
+	switch {
+	case gate.HTTP != nil:
+		u, err := url.Parse(gate.HTTP.URL)
+		if err != nil {
+			return fmt.Errorf("invalid URL %q: %w", gate.HTTP.URL, err)
+		}
+		if !p.HTTPHosts.Has(u.Hostname()) {
+			return fmt.Errorf("HTTP gates may not send requests to %s", u.Hostname())
+		}
+	case gate.ImageStreamTag != nil:
+		if gate.ImageStreamTag.Namespace != "" && !p.Namespaces.Has(gate.ImageStreamTag.Namespace) {
+			return fmt.Errorf("image stream tag gates may not check namespace %s, only the test namespace", gate.ImageStreamTag.Namespace)
+		}
+	case gate.Resource != nil:
+		if gate.Resource.ClusterScoped && !p.ClusterScoped {
+			return fmt.Errorf("resource gates may not check cluster-scoped %s %s", gate.Resource.Kind, gate.Resource.Name)
+		}
+		if gate.Resource.Namespace != "" && !p.Namespaces.Has(gate.Resource.Namespace) {
+			return fmt.Errorf("resource gates may not check namespace %s, only the test namespace", gate.Resource.Namespace)
+		}
+	}
+	return nil
+}
+
+// checkRedirect holds the redirects of HTTP gates to the allowed hosts, so a
+// gate cannot reach other hosts through an allowed one.
+func (p GatePolicy) checkRedirect(request *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if !p.HTTPHosts.Has(request.URL.Hostname()) {
+		return fmt.Errorf("HTTP gates may not be redirected to %s", request.URL.Hostname())
+	}
+	return nil
+}
+
+// gatedStep wraps another step and waits for its gates to hold before it
+// runs it.
+type gatedStep struct {
+	gates      []api.StepGate
+	wrapped    api.Step
+	client     ctrlruntimeclient.Reader
+	policy     GatePolicy
+	httpClient *http.Client
+	jobSpec    *api.JobSpec
+}
+
+// GatedStep waits for the conditions on external systems to hold before the
+// wrapped step runs. The step fails when one does not hold in time, or when
+// the policy does not allow it.
+func GatedStep(gates []api.StepGate, wrapped api.Step, client ctrlruntimeclient.Reader, policy GatePolicy, jobSpec *api.JobSpec) api.Step {
+	return &gatedStep{
+		gates:      gates,
+		wrapped:    wrapped,
+		client:     client,
+		policy:     policy,
+		httpClient: &http.Client{Timeout: time.Minute, CheckRedirect: policy.checkRedirect},
+		jobSpec:    jobSpec,
+	}
+}
+
+func (s *gatedStep) Inputs() (api.InputDefinition, error) { return s.wrapped.Inputs() }
+func (s *gatedStep) Validate() error {
+	var errs []error
+	for i, gate := range s.gates {
+		if err := s.policy.validate(gate); err != nil {
+			errs = append(errs, fmt.Errorf("gate %d: %w", i, err))
+		}
+	}
+	if err := s.wrapped.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (s *gatedStep) Name() string                        { return s.wrapped.Name() }
+func (s *gatedStep) Description() string                 { return s.wrapped.Description() }
+func (s *gatedStep) Requires() []api.StepLink            { return s.wrapped.Requires() }
+func (s *gatedStep) Creates() []api.StepLink             { return s.wrapped.Creates() }
+func (s *gatedStep) Objects() []ctrlruntimeclient.Object { return s.wrapped.Objects() }
+func (s *gatedStep) Provides() api.ParameterMap          { return s.wrapped.Provides() }
+func (s *gatedStep) Parameters() []api.Parameter         { return api.ParametersFor(s.wrapped) }
+
+func (s *gatedStep) SubTests() []*junit.TestCase {
+	if subTests, ok := s.wrapped.(SubtestReporter); ok {
+		return subTests.SubTests()
+	}
+	return nil
+}
+
+func (s *gatedStep) ProvidesFromRun() bool { return providesFromRun(s.wrapped) }
+
+func (s *gatedStep) defersSlot() bool { return true }
+
+func (s *gatedStep) Run(ctx context.Context) error {
+	for i, gate := range s.gates {
+		if err := s.policy.validate(gate); err != nil {
+			return results.ForReason("waiting_for_gate").WithError(err).Errorf("gate %d of %s is not allowed: %v", i, s.wrapped.Name(), err)
+		}
+		if err := s.wait(ctx, gate); err != nil {
+			return results.ForReason("waiting_for_gate").WithError(err).Errorf("gate %d of %s did not hold: %v", i, s.wrapped.Name(), err)
+		}
+	}
+	if !defersSlot(s.wrapped) {
+		if err := acquireDeferredSlot(ctx); err != nil {
+			return err
+		}
+	}
+	// the timeout of the test does not include the time spent on the gates
+	return runWithTimeout(ctx, s.wrapped)
+}
+
+// wait checks the gate until it holds, backing off between the checks.
+func (s *gatedStep) wait(ctx context.Context, gate api.StepGate) error {
+	check, description := s.check(gate)
+	ctx, cancel := context.WithTimeout(ctx, gate.GateTimeout())
+	defer cancel()
+	logger := LoggerFor(ctx)
+	logger.Infof("Waiting for %s before running %s.", description, s.wrapped.Name())
+	interval := gate.GateInterval()
+	var lastErr error
+	for {
+		held, err := check(ctx)
+		if err == nil && held {
+			logger.Infof("Gate passed: %s.", description)
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+			logger.WithError(err).Debugf("Could not check %s.", description)
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%s: %w (last error: %v)", description, ctx.Err(), lastErr)
+			}
+			return fmt.Errorf("%s: %w", description, ctx.Err())
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > api.MaxGateInterval {
+			interval = api.MaxGateInterval
+		}
+	}
+}
+
+func (s *gatedStep) check(gate api.StepGate) (gateCheck, string) {
+	switch {
+	case gate.HTTP != nil:
+		return s.checkHTTP(gate.HTTP.URL), fmt.Sprintf("%s to answer", gate.HTTP.URL)
+	case gate.ImageStreamTag != nil:
+		key := ctrlruntimeclient.ObjectKey{Namespace: s.namespace(gate.ImageStreamTag.Namespace), Name: fmt.Sprintf("%s:%s", gate.ImageStreamTag.Name, gate.ImageStreamTag.Tag)}
+		return s.checkImageStreamTag(key), fmt.Sprintf("imagestreamtag %s to exist", key)
+	case gate.Resource != nil:
+		r := *gate.Resource
+		namespace := ""
+		if !r.ClusterScoped {
+			namespace = s.namespace(r.Namespace)
+		}
+		key := ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: r.Name}
+		return s.checkResource(schema.FromAPIVersionAndKind(r.APIVersion, r.Kind), key, r.Condition), fmt.Sprintf("condition %s of %s %s to be True", r.Condition, r.Kind, key)
+	default:
+		return func(context.Context) (bool, error) { return false, errors.New("the gate has no check") }, "an empty gate"
+	}
+}
+
+func (s *gatedStep) namespace(namespace string) string {
+	if namespace == "" {
+		return s.jobSpec.Namespace()
+	}
+	return namespace
+}
+
+func (s *gatedStep) checkHTTP(url string) gateCheck {
+	return func(ctx context.Context) (bool, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, err
+		}
+		response, err := s.httpClient.Do(request)
+		if err != nil {
+			return false, err
+		}
+		_ = response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return false, fmt.Errorf("got status %d", response.StatusCode)
+		}
+		return true, nil
+	}
+}
+
+func (s *gatedStep) checkImageStreamTag(key ctrlruntimeclient.ObjectKey) gateCheck {
+	return func(ctx context.Context) (bool, error) {
+		err := s.client.Get(ctx, key, &imagev1.ImageStreamTag{})
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+}
+
+func (s *gatedStep) checkResource(gvk schema.GroupVersionKind, key ctrlruntimeclient.ObjectKey, condition string) gateCheck {
+	return func(ctx context.Context) (bool, error) {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := s.client.Get(ctx, key, obj); err != nil {
+			if kerrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		if err != nil {
+			return false, err
+		}
+		for _, raw := range conditions {
+			c, ok := raw.(map[string]interface{})
+			if ok && c["type"] == condition {
+				return c["status"] == "True", nil
+			}
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,146 @@
+package steps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestGatedStep(t *testing.T) {
+	var requests int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// the same server under a host the policy does not allow
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+			return
+		}
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := GatePolicy{HTTPHosts: sets.New(serverURL.Hostname()), Namespaces: sets.New("allowed", "ocp")}
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace("test-namespace")
+	deployment.SetName("registry")
+	if err := unstructured.SetNestedSlice(deployment.Object, []interface{}{
+		map[string]interface{}{"type": "Progressing", "status": "False"},
+		map[string]interface{}{"type": "Available", "status": "True"},
+	}, "status", "conditions"); err != nil {
+		t.Fatal(err)
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+		&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: "4.14:cli"}},
+		deployment,
+	).Build()
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("test-namespace")
+	short := &prowv1.Duration{Duration: 100 * time.Millisecond}
+	interval := &prowv1.Duration{Duration: time.Millisecond}
+
+	for _, tc := range []struct {
+		name        string
+		gate        api.StepGate
+		expectedErr bool
+	}{{
+		name: "URL answers after retries",
+		gate: api.StepGate{HTTP: &api.HTTPGate{URL: server.URL}, Interval: interval},
+	}, {
+		name: "tag exists",
+		gate: api.StepGate{ImageStreamTag: &api.ImageStreamTagGate{Namespace: "ocp", Name: "4.14", Tag: "cli"}, Interval: interval},
+	}, {
+		name:        "tag does not exist",
+		gate:        api.StepGate{ImageStreamTag: &api.ImageStreamTagGate{Name: "4.14", Tag: "cli"}, Timeout: short, Interval: interval},
+		expectedErr: true,
+	}, {
+		name: "condition is true",
+		gate: api.StepGate{Resource: &api.ResourceGate{APIVersion: "apps/v1", Kind: "Deployment", Name: "registry", Condition: "Available"}, Interval: interval},
+	}, {
+		name:        "condition is false",
+		gate:        api.StepGate{Resource: &api.ResourceGate{APIVersion: "apps/v1", Kind: "Deployment", Name: "registry", Condition: "Progressing"}, Timeout: short, Interval: interval},
+		expectedErr: true,
+	}, {
+		name:        "HTTP host is not allowed",
+		gate:        api.StepGate{HTTP: &api.HTTPGate{URL: "https://example.com/healthz"}, Interval: interval},
+		expectedErr: true,
+	}, {
+		name:        "HTTP redirect to a host that is not allowed",
+		gate:        api.StepGate{HTTP: &api.HTTPGate{URL: server.URL + "/redirect"}, Timeout: short, Interval: interval},
+		expectedErr: true,
+	}, {
+		name:        "tag in another namespace is not allowed",
+		gate:        api.StepGate{ImageStreamTag: &api.ImageStreamTagGate{Namespace: "other", Name: "4.14", Tag: "cli"}, Interval: interval},
+		expectedErr: true,
+	}, {
+		name:        "resource in another namespace is not allowed",
+		gate:        api.StepGate{Resource: &api.ResourceGate{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "other", Name: "registry", Condition: "Available"}, Interval: interval},
+		expectedErr: true,
+	}, {
+		name:        "cluster-scoped resource is not allowed",
+		gate:        api.StepGate{Resource: &api.ResourceGate{APIVersion: "v1", Kind: "Node", Name: "worker", Condition: "Ready", ClusterScoped: true}, Interval: interval},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			wrapped := &fakeStep{name: "e2e"}
+			err := GatedStep([]api.StepGate{tc.gate}, wrapped, client, policy, jobSpec).Run(context.Background())
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if expected := map[bool]int{true: 0, false: 1}[tc.expectedErr]; wrapped.numRuns != expected {
+				t.Errorf("expected the step to run %d times, ran %d times", expected, wrapped.numRuns)
+			}
+		})
+	}
+}
+
+func TestGatedStepValidate(t *testing.T) {
+	gates := []api.StepGate{
+		{HTTP: &api.HTTPGate{URL: "https://example.com/healthz"}},
+		{Resource: &api.ResourceGate{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "allowed", Name: "registry", Condition: "Available"}},
+		{Resource: &api.ResourceGate{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "other", Name: "registry", Condition: "Available"}},
+		{Resource: &api.ResourceGate{APIVersion: "v1", Kind: "Node", Name: "worker", Condition: "Ready", ClusterScoped: true}},
+		{ImageStreamTag: &api.ImageStreamTagGate{Namespace: "allowed", Name: "4.14", Tag: "cli"}},
+	}
+	for _, tc := range []struct {
+		name     string
+		policy   GatePolicy
+		expected string
+	}{{
+		name:     "default policy",
+		expected: "[gate 0: HTTP gates may not send requests to example.com, gate 1: resource gates may not check namespace allowed, only the test namespace, gate 2: resource gates may not check namespace other, only the test namespace, gate 3: resource gates may not check cluster-scoped Node worker, gate 4: image stream tag gates may not check namespace allowed, only the test namespace]",
+	}, {
+		name:     "allowed by the policy",
+		policy:   GatePolicy{HTTPHosts: sets.New("example.com"), Namespaces: sets.New("allowed"), ClusterScoped: true},
+		expected: "gate 2: resource gates may not check namespace other, only the test namespace",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := GatedStep(gates, &fakeStep{name: "e2e"}, nil, tc.policy, nil).Validate()
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/features"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+var (
+	// stuckImportTimeout is how long the import of a tag can go without
+	// either succeeding or failing before it is considered stuck. Imports
+	// occasionally wedge and never report an outcome.
+	stuckImportTimeout = 5 * time.Minute
+	// maxImportAttempts is how many times a tag is imported before its
+	// import is considered failed.
+	maxImportAttempts = 3
+)
+
+// importWatchdog follows the import of a tag in an image stream, requesting
+// it again when it fails or gets stuck.
+type importWatchdog struct {
+	stream, tag string
+	// attempts is the number of imports of the tag so far
+	attempts int
+	// requested is when the tag was last imported
+	requested time.Time
+}
+
+// failedImport returns the message of the failed import condition of the tag
+// for the current generation of its spec, if there is one.
+func failedImport(is *imagev1.ImageStream, tag string, generation int64) (string, bool) {
+	for _, event := range is.Status.Tags {
+		if event.Tag != tag {
+			continue
+		}
+		for _, condition := range event.Conditions {
+			if condition.Generation >= generation && condition.Type == imagev1.ImportSuccess && condition.Status == coreapi.ConditionFalse {
+				return condition.Message, true
+			}
+		}
+	}
+	return "", false
+}
+
+// check inspects the image stream and returns whether the tag is imported.
+// A failed or stuck import is requested again by resetting the generation
+// of the tag in the spec, until the attempts are exhausted, at which point
+// the error of the registry is returned.
+func (w *importWatchdog) check(ctx context.Context, client ctrlruntimeclient.Client, is *imagev1.ImageStream, now time.Time) (bool, error) {
+	if _, exists := util.ResolvePullSpec(is, w.tag, true); exists {
+		return true, nil
+	}
+	if !features.Enabled(features.ImportWatchdog) {
+		return false, nil
+	}
+	var ref *imagev1.TagReference
+	for i := range is.Spec.Tags {
+		if is.Spec.Tags[i].Name == w.tag {
+			ref = &is.Spec.Tags[i]
+		}
+	}
+	if ref == nil {
+		return false, nil
+	}
+	var generation int64
+	if ref.Generation != nil {
+		generation = *ref.Generation
+	}
+	reason, failed := failedImport(is, w.tag, generation)
+	stuck := now.Sub(w.requested) > stuckImportTimeout
+	if !failed && !stuck {
+		return false, nil
+	}
+	if !failed {
+		reason = fmt.Sprintf("the import made no progress in %s", stuckImportTimeout)
+	}
+	if w.attempts >= maxImportAttempts {
+		return false, fmt.Errorf("failed to import %s:%s after %d attempts: %s", w.stream, w.tag, w.attempts, reason)
+	}
+	logrus.Warnf("Importing %s:%s again: %s", w.stream, w.tag, reason)
+	zero := int64(0)
+	ref.Generation = &zero
+	if err := client.Update(ctx, is); err != nil {
+		if kerrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to request another import of %s:%s: %w", w.stream, w.tag, err)
+	}
+	w.attempts++
+	w.requested = now
+	return false, nil
+}
+
+// waitForTagImport waits for the tag of the image stream to be imported,
+// importing it again when the import fails or gets stuck instead of waiting
+// for the timeout.
+func waitForTagImport(ctx context.Context, client ctrlruntimeclient.Client, namespace, stream, tag string, interval, timeout time.Duration) error {
+	watchdog := importWatchdog{stream: stream, tag: tag, attempts: 1, requested: time.Now()}
+	importCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return wait.PollImmediateUntil(interval, func() (bool, error) {
+		is := &imagev1.ImageStream{}
+		if err := client.Get(importCtx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: stream}, is); err != nil {
+			return false, err
+		}
+		imported, err := watchdog.check(importCtx, client, is, time.Now())
+		if !imported && err == nil {
+			logrus.Debugf("Waiting to import %s:%s ...", stream, tag)
+		}
+		return imported, err
+	}, importCtx.Done())
+}
@@ -0,0 +1,121 @@
+package steps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/features"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestImportWatchdog(t *testing.T) {
+	one := int64(1)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := func(status ...imagev1.NamedTagEventList) *imagev1.ImageStream {
+		return &imagev1.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline"},
+			Spec:       imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{{Name: "src", Generation: &one}}},
+			Status:     imagev1.ImageStreamStatus{DockerImageRepository: "registry/ns/pipeline", Tags: status},
+		}
+	}
+	failed := imagev1.NamedTagEventList{Tag: "src", Conditions: []imagev1.TagEventCondition{{
+		Type: imagev1.ImportSuccess, Status: coreapi.ConditionFalse, Generation: 1, Message: "manifest unknown",
+	}}}
+	for _, tc := range []struct {
+		name               string
+		stream             *imagev1.ImageStream
+		attempts           int
+		now                time.Time
+		expectedImported   bool
+		expectedErr        string
+		expectedAttempts   int
+		expectedGeneration int64
+		disabled           bool
+	}{{
+		name:               "imported",
+		stream:             stream(imagev1.NamedTagEventList{Tag: "src", Items: []imagev1.TagEvent{{Image: "sha256:abc"}}}),
+		attempts:           1,
+		now:                start,
+		expectedImported:   true,
+		expectedAttempts:   1,
+		expectedGeneration: 1,
+	}, {
+		name:               "still importing",
+		stream:             stream(),
+		attempts:           1,
+		now:                start.Add(time.Minute),
+		expectedAttempts:   1,
+		expectedGeneration: 1,
+	}, {
+		name:               "stuck import is requested again",
+		stream:             stream(),
+		attempts:           1,
+		now:                start.Add(10 * time.Minute),
+		expectedAttempts:   2,
+		expectedGeneration: 0,
+	}, {
+		name:               "failed import is requested again",
+		stream:             stream(failed),
+		attempts:           1,
+		now:                start,
+		expectedAttempts:   2,
+		expectedGeneration: 0,
+	}, {
+		name:               "failed import after all attempts",
+		stream:             stream(failed),
+		attempts:           3,
+		now:                start,
+		expectedErr:        "failed to import pipeline:src after 3 attempts: manifest unknown",
+		expectedAttempts:   3,
+		expectedGeneration: 1,
+	}, {
+		name:               "stuck import after all attempts",
+		stream:             stream(),
+		attempts:           3,
+		now:                start.Add(10 * time.Minute),
+		expectedErr:        "failed to import pipeline:src after 3 attempts: the import made no progress in 5m0s",
+		expectedAttempts:   3,
+		expectedGeneration: 1,
+	}, {
+		name:               "failed import is not requested again without the feature",
+		stream:             stream(failed),
+		attempts:           1,
+		now:                start,
+		disabled:           true,
+		expectedAttempts:   1,
+		expectedGeneration: 1,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.disabled {
+				features.Configure(features.Gates{features.ImportWatchdog: false})
+				defer features.Configure(features.Defaults())
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.stream).Build()
+			is := &imagev1.ImageStream{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKeyFromObject(tc.stream), is); err != nil {
+				t.Fatal(err)
+			}
+			watchdog := importWatchdog{stream: "pipeline", tag: "src", attempts: tc.attempts, requested: start}
+			imported, err := watchdog.check(context.Background(), client, is, tc.now)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			testhelper.Diff(t, "error", actualErr, tc.expectedErr)
+			testhelper.Diff(t, "imported", imported, tc.expectedImported)
+			testhelper.Diff(t, "attempts", watchdog.attempts, tc.expectedAttempts)
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKeyFromObject(tc.stream), is); err != nil {
+				t.Fatal(err)
+			}
+			testhelper.Diff(t, "generation", *is.Spec.Tags[0].Generation, tc.expectedGeneration)
+		})
+	}
+}
@@ -10,16 +10,15 @@ import (
 	coreapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/release"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
-	"github.com/openshift/ci-tools/pkg/util"
 )
 
 // inputImageTagStep will ensure that a tag exists
@@ -29,6 +28,9 @@ type inputImageTagStep struct {
 	config  *api.InputImageTagStepConfiguration
 	client  loggingclient.LoggingClient
 	jobSpec *api.JobSpec
+	// httpClient and pullSecret resolve images in external registries
+	httpClient release.HTTPClient
+	pullSecret *coreapi.Secret
 
 	imageName string
 }
@@ -37,6 +39,18 @@ func (s *inputImageTagStep) Inputs() (api.InputDefinition, error) {
 	if len(s.imageName) > 0 {
 		return api.InputDefinition{s.imageName}, nil
 	}
+	if pullSpec := s.config.BaseImage.PullSpec; pullSpec != "" {
+		if s.httpClient == nil {
+			return nil, fmt.Errorf("could not resolve base image from %s: external images are not supported", pullSpec)
+		}
+		digest, err := resolveExternalDigest(context.TODO(), s.httpClient, pullSpec, s.pullSecret)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve base image from %s: %w", pullSpec, err)
+		}
+		logrus.Debugf("Resolved %s to %s.", pullSpec, digest)
+		s.imageName = digest
+		return api.InputDefinition{digest}, nil
+	}
 	from := imagev1.ImageStreamTag{}
 	namespace := s.config.BaseImage.Namespace
 	name := fmt.Sprintf("%s:%s", s.config.BaseImage.Name, s.config.BaseImage.Tag)
@@ -63,13 +77,42 @@ func (s *inputImageTagStep) Run(ctx context.Context) error {
 }
 
 func (s *inputImageTagStep) run(ctx context.Context) error {
-	logrus.Infof("Tagging %s into %s:%s.", s.config.BaseImage.ISTagName(), api.PipelineImageStream, s.config.To)
+	LoggerFor(ctx).Infof("Tagging %s into %s:%s.", s.config.BaseImage.ISTagName(), api.PipelineImageStream, s.config.To)
 
 	if _, err := s.Inputs(); err != nil {
 		return fmt.Errorf("could not resolve inputs for image tag step: %w", err)
 	}
 
-	ist := &imagev1.ImageStreamTag{
+	ist := s.imageStreamTag()
+
+	if err := s.client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create imagestreamtag for input image: %w", err)
+	}
+
+	// Wait image is ready
+	if err := waitForTagImport(ctx, s.client, s.jobSpec.Namespace(), api.PipelineImageStream, string(s.config.To), 10*time.Second, 35*time.Minute); err != nil {
+		logrus.WithError(err).Errorf("Could not resolve tag %s in imagestream %s.", s.config.To, api.PipelineImageStream)
+		return err
+	}
+	return nil
+}
+
+func (s *inputImageTagStep) imageStreamTag() *imagev1.ImageStreamTag {
+	from := &coreapi.ObjectReference{
+		Kind:      "ImageStreamImage",
+		Name:      fmt.Sprintf("%s@%s", s.config.BaseImage.Name, s.imageName),
+		Namespace: s.config.BaseImage.Namespace,
+	}
+	if pullSpec := s.config.BaseImage.PullSpec; pullSpec != "" {
+		// The image is imported by the digest that went into the input hash,
+		// with the pull secrets of the namespace.
+		ref, err := parseExternalImage(pullSpec)
+		if err == nil {
+			pullSpec = ref.digestPullSpec(s.imageName)
+		}
+		from = &coreapi.ObjectReference{Kind: "DockerImage", Name: pullSpec}
+	}
+	return &imagev1.ImageStreamTag{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s:%s", api.PipelineImageStream, s.config.To),
 			Namespace: s.jobSpec.Namespace(),
@@ -78,39 +121,24 @@ func (s *inputImageTagStep) run(ctx context.Context) error {
 			ReferencePolicy: imagev1.TagReferencePolicy{
 				Type: imagev1.LocalTagReferencePolicy,
 			},
-			From: &coreapi.ObjectReference{
-				Kind:      "ImageStreamImage",
-				Name:      fmt.Sprintf("%s@%s", s.config.BaseImage.Name, s.imageName),
-				Namespace: s.config.BaseImage.Namespace,
-			},
+			From: from,
 			ImportPolicy: imagev1.TagImportPolicy{
 				ImportMode: imagev1.ImportModePreserveOriginal,
 			},
 		},
 	}
+}
 
-	if err := s.client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("failed to create imagestreamtag for input image: %w", err)
-	}
-
-	// Wait image is ready
-	importCtx, cancel := context.WithTimeout(ctx, 35*time.Minute)
-	defer cancel()
-	if err := wait.PollImmediateUntil(10*time.Second, func() (bool, error) {
-		pipeline := &imagev1.ImageStream{}
-		if err := s.client.Get(importCtx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PipelineImageStream}, pipeline); err != nil {
-			return false, err
-		}
-		_, exists := util.ResolvePullSpec(pipeline, string(s.config.To), true)
-		if !exists {
-			logrus.Debugf("Waiting to import %s ...", ist.ObjectMeta.Name)
-		}
-		return exists, nil
-	}, importCtx.Done()); err != nil {
-		logrus.WithError(err).Errorf("Could not resolve tag %s in imagestream %s.", s.config.To, api.PipelineImageStream)
-		return err
+// DryRun describes the image stream tag the step would create. The image is
+// resolved with the inputs of the step, before any step runs.
+func (s *inputImageTagStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	if _, err := s.Inputs(); err != nil {
+		return nil, fmt.Errorf("could not resolve inputs for image tag step: %w", err)
 	}
-	return nil
+	return []api.DryRunAction{{
+		Description: fmt.Sprintf("Tag %s into %s:%s and wait for the import", s.config.BaseImage.ISTagName(), api.PipelineImageStream, s.config.To),
+		Object:      s.imageStreamTag(),
+	}}, nil
 }
 
 func (s *inputImageTagStep) Requires() []api.StepLink {
@@ -122,10 +150,11 @@ func (s *inputImageTagStep) Creates() []api.StepLink {
 }
 
 func (s *inputImageTagStep) Provides() api.ParameterMap {
-	tag := s.config.To
-	return api.ParameterMap{
-		utils.PipelineImageEnvFor(tag): utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, string(tag)),
-	}
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *inputImageTagStep) Parameters() []api.Parameter {
+	return []api.Parameter{utils.PipelineImageParameter(s.client, s.jobSpec.Namespace, s.config.To)}
 }
 
 func (s *inputImageTagStep) Name() string { return s.config.TargetName() }
@@ -149,3 +178,21 @@ func InputImageTagStep(
 		jobSpec: jobSpec,
 	}
 }
+
+// ExternalInputImageTagStep is an InputImageTagStep that also supports base
+// images in external registries, which it resolves with the client and the
+// credentials of the pull secret.
+func ExternalInputImageTagStep(
+	config *api.InputImageTagStepConfiguration,
+	client loggingclient.LoggingClient,
+	jobSpec *api.JobSpec,
+	httpClient release.HTTPClient,
+	pullSecret *coreapi.Secret) api.Step {
+	return &inputImageTagStep{
+		config:     config,
+		client:     client,
+		jobSpec:    jobSpec,
+		httpClient: httpClient,
+		pullSecret: pullSecret,
+	}
+}
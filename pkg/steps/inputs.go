@@ -0,0 +1,71 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// StepInputs holds the resolved inputs of a step and the links it requires
+// and creates.
+type StepInputs struct {
+	Step     string              `json:"step"`
+	Inputs   api.InputDefinition `json:"inputs,omitempty"`
+	Requires []string            `json:"requires,omitempty"`
+	Creates  []string            `json:"creates,omitempty"`
+	// Provides documents the parameters the step provides to later steps.
+	Provides []ProvidedParameter `json:"provides,omitempty"`
+}
+
+// ProvidedParameter documents a parameter provided by a step.
+type ProvidedParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Requires names the link steps need to require to use the parameter.
+	Requires string `json:"requires,omitempty"`
+}
+
+// Inputs describes the inputs and outputs of every step, in order, so that
+// external systems can decide what a job will consume and produce before it
+// runs.
+func Inputs(steps []api.Step) ([]StepInputs, error) {
+	var ret []StepInputs
+	for _, step := range steps {
+		inputs, err := step.Inputs()
+		if err != nil {
+			return nil, fmt.Errorf("could not determine inputs for step %s: %w", step.Name(), err)
+		}
+		ret = append(ret, StepInputs{
+			Step:     step.Name(),
+			Inputs:   inputs,
+			Requires: linkNames(step.Requires()),
+			Creates:  linkNames(step.Creates()),
+			Provides: providedParameters(step),
+		})
+	}
+	return ret, nil
+}
+
+func providedParameters(step api.Step) []ProvidedParameter {
+	var provided []ProvidedParameter
+	for _, parameter := range api.ParametersFor(step) {
+		provided = append(provided, documentParameter(parameter))
+	}
+	return provided
+}
+
+func documentParameter(parameter api.Parameter) ProvidedParameter {
+	documented := ProvidedParameter{Name: parameter.Name, Description: parameter.Description}
+	if parameter.Link != nil {
+		documented.Requires = api.LinkName(parameter.Link)
+	}
+	return documented
+}
+
+func linkNames(links []api.StepLink) []string {
+	var names []string
+	for _, link := range links {
+		names = append(names, api.LinkName(link))
+	}
+	return names
+}
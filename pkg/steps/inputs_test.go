@@ -0,0 +1,73 @@
+package steps
+
+import (
+	"testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestInputs(t *testing.T) {
+	steps := []api.Step{
+		&fakeStep{
+			name:    "src",
+			creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)},
+		},
+		&fakeStep{
+			name: "unit",
+			requires: []api.StepLink{
+				api.InternalImageLink(api.PipelineImageStreamTagReferenceSource),
+				api.ExternalImageLink(api.ImageStreamTagReference{Namespace: "ocp", Name: "4.14", Tag: "cli"}),
+			},
+			creates: []api.StepLink{api.ReleaseImagesLink(api.LatestReleaseName), api.ImagesReadyLink()},
+		},
+		LeaseStep(nil, []api.StepLease{{ResourceType: "aws-quota-slice", Env: api.DefaultLeaseEnv}}, &fakeStep{name: "e2e"}, emptyNamespace),
+		&rpmServerStep{jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowapi.Refs{Org: "org", Repo: "some-repo"}}}},
+	}
+	actual, err := Inputs(steps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []StepInputs{
+		{Step: "src", Creates: []string{"pipeline:src"}},
+		{
+			Step:     "unit",
+			Requires: []string{"pipeline:src", "ocp/4.14:cli"},
+			Creates:  []string{"stable", "[images]"},
+		},
+		{
+			Step: "e2e",
+			Provides: []ProvidedParameter{
+				{Name: api.DefaultLeaseEnv, Description: "Names of the leased aws-quota-slice resources, separated by spaces"},
+			},
+		},
+		{
+			Step:     "[serve:rpms]",
+			Requires: []string{"pipeline:rpms"},
+			Creates:  []string{"[rpms]"},
+			Provides: []ProvidedParameter{
+				{Name: "RPM_REPO_ORG_SOME_REPO", Description: "URL of the repository serving the RPMs built from org/some-repo", Requires: "[rpms]"},
+			},
+		},
+	}
+	testhelper.Diff(t, "inputs", actual, expected)
+}
+
+func TestDocumentParameters(t *testing.T) {
+	job := []api.Parameter{{Name: "NAMESPACE", Description: "namespace"}}
+	steps := []api.Step{
+		&fakeStep{name: "src"},
+		LeaseStep(nil, []api.StepLease{{ResourceType: "aws-quota-slice", Env: api.DefaultLeaseEnv}}, &fakeStep{name: "e2e"}, emptyNamespace),
+	}
+	expected := []DocumentedParameter{
+		{
+			ProvidedParameter: ProvidedParameter{Name: api.DefaultLeaseEnv, Description: "Names of the leased aws-quota-slice resources, separated by spaces"},
+			ProvidedBy:        "e2e",
+		},
+		{ProvidedParameter: ProvidedParameter{Name: "NAMESPACE", Description: "namespace"}},
+	}
+	testhelper.Diff(t, "parameters", DocumentParameters(job, steps), expected)
+}
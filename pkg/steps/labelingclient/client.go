@@ -0,0 +1,72 @@
+package labelingclient
+
+import (
+	"context"
+
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+)
+
+type stepKey struct{}
+
+// WithStep records the step on whose behalf objects are created with the
+// context, so that they can be labelled with it and the lines logged for it
+// can be tagged with it.
+func WithStep(ctx context.Context, step string) context.Context {
+	return context.WithValue(ctx, stepKey{}, step)
+}
+
+// StepFrom returns the step recorded with WithStep, if any.
+func StepFrom(ctx context.Context) string {
+	step, _ := ctx.Value(stepKey{}).(string)
+	return step
+}
+
+// Wrap wraps the upstream client, labelling every object created through it
+// with the labels returned by labels and, when the context was set up with
+// WithStep, with the step under stepLabel. Labels the object already carries
+// are not overwritten. Template instances also pass the labels on to the
+// objects of their template.
+func Wrap(upstream ctrlruntimeclient.WithWatch, labels func() map[string]string, stepLabel string) ctrlruntimeclient.WithWatch {
+	return &client{
+		WithWatch: upstream,
+		labels:    labels,
+		stepLabel: stepLabel,
+	}
+}
+
+type client struct {
+	ctrlruntimeclient.WithWatch
+	labels    func() map[string]string
+	stepLabel string
+}
+
+func (c *client) Create(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	labels := c.labels()
+	if step := StepFrom(ctx); step != "" {
+		labels = utils.SanitizeLabels(withDefaults(map[string]string{c.stepLabel: step}, labels))
+	}
+	obj.SetLabels(withDefaults(obj.GetLabels(), labels))
+	if instance, ok := obj.(*templateapi.TemplateInstance); ok {
+		instance.Spec.Template.ObjectLabels = withDefaults(instance.Spec.Template.ObjectLabels, labels)
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+func withDefaults(labels, defaults map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return labels
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range defaults {
+		if _, set := labels[key]; !set {
+			labels[key] = value
+		}
+	}
+	return labels
+}
@@ -0,0 +1,47 @@
+package labelingclient
+
+import (
+	"context"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCreate(t *testing.T) {
+	labels := func() map[string]string {
+		return map[string]string{"ci.openshift.io/job": "job", "ci.openshift.io/hash": "abc"}
+	}
+	scheme := runtime.NewScheme()
+	if err := coreapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := templateapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	client := Wrap(fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).Build(), labels, "ci.openshift.io/step")
+
+	pod := &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod", Labels: map[string]string{"ci.openshift.io/hash": "own"}}}
+	if err := client.Create(WithStep(context.Background(), "[images]"), pod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "pod labels", pod.Labels, map[string]string{
+		"ci.openshift.io/job":  "job",
+		"ci.openshift.io/hash": "own",
+		"ci.openshift.io/step": "images",
+	})
+
+	instance := &templateapi.TemplateInstance{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "instance"}}
+	if err := client.Create(context.Background(), instance); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"ci.openshift.io/job": "job", "ci.openshift.io/hash": "abc"}
+	testhelper.Diff(t, "template instance labels", instance.Labels, expected)
+	testhelper.Diff(t, "template object labels", instance.Spec.Template.ObjectLabels, expected)
+}
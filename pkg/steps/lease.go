@@ -65,13 +65,16 @@ func (s *leaseStep) Creates() []api.StepLink             { return s.wrapped.Crea
 func (s *leaseStep) Objects() []ctrlruntimeclient.Object { return s.wrapped.Objects() }
 
 func (s *leaseStep) Provides() api.ParameterMap {
-	parameters := s.wrapped.Provides()
-	if parameters == nil {
-		parameters = api.ParameterMap{}
-	}
+	return api.ParameterMapFor(s.Parameters())
+}
+
+// Parameters adds the names of the leased resources to the parameters of
+// the wrapped step.
+func (s *leaseStep) Parameters() []api.Parameter {
+	parameters := api.ParametersFor(s.wrapped)
 	for i := range s.leases {
 		l := &s.leases[i]
-		parameters[l.Env] = func() (string, error) {
+		value := func() (string, error) {
 			if len(l.resources) == 0 {
 				return "", nil
 			}
@@ -90,6 +93,11 @@ func (s *leaseStep) Provides() api.ParameterMap {
 			}
 			return builder.String(), nil
 		}
+		parameters = append(parameters, api.Parameter{
+			Name:        l.Env,
+			Description: fmt.Sprintf("Names of the leased %s resources, separated by spaces", l.ResourceType),
+			Value:       value,
+		})
 	}
 	return parameters
 }
@@ -101,6 +109,11 @@ func (s *leaseStep) SubTests() []*junit.TestCase {
 	return nil
 }
 
+// ProvidesFromRun is true, as the resources are only known once leased.
+func (s *leaseStep) ProvidesFromRun() bool { return true }
+
+func (s *leaseStep) defersSlot() bool { return true }
+
 func (s *leaseStep) Run(ctx context.Context) error {
 	return results.ForReason("utilizing_lease").ForError(s.run(ctx))
 }
@@ -110,14 +123,20 @@ func (s *leaseStep) run(ctx context.Context) error {
 	for i := range s.leases {
 		types = append(types, s.leases[i].ResourceType)
 	}
-	logrus.Infof("Acquiring leases for test %s: %v", s.Name(), types)
+	LoggerFor(ctx).Infof("Acquiring leases for test %s: %v", s.Name(), types)
 	client := *s.client
 	ctx, cancel := context.WithCancel(ctx)
 	if err := acquireLeases(client, ctx, cancel, s.leases); err != nil {
 		return err
 	}
-	wrappedErr := results.ForReason("executing_test").ForError(s.wrapped.Run(ctx))
-	logrus.Infof("Releasing leases for test %s", s.Name())
+	var wrappedErr error
+	if err := acquireDeferredSlot(ctx); err != nil {
+		wrappedErr = err
+	} else {
+		// the timeout of the test does not include the time spent on the leases
+		wrappedErr = results.ForReason("executing_test").ForError(runWithTimeout(ctx, s.wrapped))
+	}
+	LoggerFor(ctx).Infof("Releasing leases for test %s", s.Name())
 	releaseErr := results.ForReason("releasing_lease").ForError(releaseLeases(client, s.leases))
 
 	return aggregateWrappedErrorAndReleaseError(wrappedErr, releaseErr)
@@ -157,7 +176,7 @@ func acquireLeases(
 			if err == lease.ErrNotFound {
 				printResourceMetrics(client, l.ResourceType)
 			}
-			errs = append(errs, results.ForReason(results.Reason("acquiring_lease")).WithError(err).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
+			errs = append(errs, results.ForReason("acquiring_lease").WithError(err).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
 			break
 		}
 		logrus.Infof("Acquired %d lease(s) for %s: %v", l.Count, l.ResourceType, names)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -248,3 +249,20 @@ func TestAcquireRelease(t *testing.T) {
 		t.Fatalf("wrong calls to the lease client: %s", diff.ObjectDiff(calls, expected))
 	}
 }
+
+func TestLeaseStepTimeout(t *testing.T) {
+	var calls []string
+	client := lease.NewFakeClient("owner", "url", 0, nil, &calls)
+	withLease := LeaseStep(&client, []api.StepLease{{ResourceType: "rtype", Count: 1}}, &slowStep{fakeStep: fakeStep{name: "slow"}, timeout: 10 * time.Millisecond}, func() string { return "" })
+	if _, ok := withLease.(TimeoutReporter); ok {
+		t.Error("expected the timeout of the test not to bound the acquisition of the leases")
+	}
+	err := withLease.Run(context.Background())
+	if reasons := results.Reasons(err); !reflect.DeepEqual(reasons, []string{"utilizing_lease:executing_test:step_timed_out"}) {
+		t.Errorf("unexpected reasons: %v", reasons)
+	}
+	expected := []string{"acquire owner rtype free leased random", "releaseone owner rtype_0 free"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("wrong calls to the lease client: %s", diff.ObjectDiff(calls, expected))
+	}
+}
@@ -0,0 +1,18 @@
+package steps
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/steps/labelingclient"
+)
+
+// LoggerFor returns the logger for the step running with the context, which
+// tags the lines with the name of the step.
+func LoggerFor(ctx context.Context) *logrus.Entry {
+	if step := labelingclient.StepFrom(ctx); step != "" {
+		return logrus.WithField("step", step)
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,363 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	batchv1 "k8s.io/api/batch/v1"
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+// manifestParameter matches the references to parameters in manifests, like
+// ${NAMESPACE}.
+var manifestParameter = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// jobNameLabel is set by the Job controller on the pods of a Job.
+const jobNameLabel = "job-name"
+
+type manifestTestStep struct {
+	config    api.TestStepConfiguration
+	params    api.Parameters
+	podClient kubernetes.PodClient
+	jobSpec   *api.JobSpec
+	readFile  func(string) ([]byte, error)
+
+	subTestsLock sync.Mutex
+	subTests     []*junit.TestCase
+}
+
+// ManifestTestStep applies the manifests of the test to the test namespace
+// and waits for the Pod or Job it names to complete.
+func ManifestTestStep(config api.TestStepConfiguration, params api.Parameters, podClient kubernetes.PodClient, jobSpec *api.JobSpec) api.Step {
+	return &manifestTestStep{
+		config:    config,
+		params:    params,
+		podClient: podClient,
+		jobSpec:   jobSpec,
+		readFile:  os.ReadFile,
+	}
+}
+
+func (s *manifestTestStep) Inputs() (api.InputDefinition, error) {
+	return nil, nil
+}
+
+func (*manifestTestStep) Validate() error { return nil }
+
+func (s *manifestTestStep) Run(ctx context.Context) error {
+	return results.ForReason("executing_manifests").ForError(s.run(ctx))
+}
+
+// expand substitutes the references to parameters in the raw manifest.
+// Referencing a parameter that is not defined is an error, as the manifest
+// would otherwise be applied with the literal reference.
+func (s *manifestTestStep) expand(raw []byte) ([]byte, error) {
+	var expandErr error
+	expanded := manifestParameter.ReplaceAllFunc(raw, func(match []byte) []byte {
+		name := string(manifestParameter.FindSubmatch(match)[1])
+		if !s.params.Has(name) && utils.IsStableImageEnv(name) {
+			format, err := s.params.Get(utils.ImageFormatEnv)
+			if err != nil {
+				if expandErr == nil {
+					expandErr = fmt.Errorf("could not resolve image format: %w", err)
+				}
+				return match
+			}
+			return []byte(strings.Replace(format, api.ComponentFormatReplacement, utils.StableImageNameFrom(name), -1))
+		}
+		if !s.params.Has(name) {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("parameter %s is not defined", name)
+			}
+			return match
+		}
+		value, err := s.params.Get(name)
+		if err != nil {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("cannot resolve parameter %s: %w", name, err)
+			}
+			return match
+		}
+		return []byte(value)
+	})
+	return expanded, expandErr
+}
+
+// objects decodes the manifests of the test with the parameters substituted
+// and returns them along with the object the test waits for.
+func (s *manifestTestStep) objects() ([]*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	objects, err := decodeObjects(fmt.Sprintf("tests[%s].manifests.objects", s.config.As), s.config.ManifestTestConfiguration.Objects, s.readFile, s.expand)
+	if err != nil {
+		return nil, nil, err
+	}
+	waitFor := s.config.ManifestTestConfiguration.WaitFor
+	for _, object := range objects {
+		if object.GetKind() == waitFor.Kind && object.GetName() == waitFor.Name {
+			return objects, object, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("the manifests do not contain %s %s to wait for", waitFor.Kind, waitFor.Name)
+}
+
+func (s *manifestTestStep) run(ctx context.Context) error {
+	LoggerFor(ctx).Infof("Executing manifests of test %s", s.config.As)
+	objects, target, err := s.objects()
+	if err != nil {
+		return err
+	}
+	if err := addArtifactsToTarget(target); err != nil {
+		return err
+	}
+
+	namespace := s.jobSpec.Namespace()
+	go func() {
+		<-ctx.Done()
+		// the other objects may keep the test running, like the Deployment
+		// a Pod waits for, so everything that was applied is deleted
+		for i := len(objects) - 1; i >= 0; i-- {
+			object := objects[i]
+			logrus.Infof("cleanup: Deleting %s %s", object.GetKind(), object.GetName())
+			if err := s.deleteObject(CleanupCtx, object); err != nil {
+				logrus.WithError(err).Errorf("Could not delete %s %s.", object.GetKind(), object.GetName())
+			}
+		}
+	}()
+
+	// Pods and Jobs cannot be updated to run again, so those left behind by
+	// an earlier execution are replaced.
+	logrus.Debugf("Deleting %s %s left behind by an earlier execution", target.GetKind(), target.GetName())
+	if err := s.deleteObject(ctx, target); err != nil {
+		return fmt.Errorf("could not delete %s %s: %w", target.GetKind(), target.GetName(), err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(target.GroupVersionKind())
+		err := s.podClient.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: target.GetName()}, existing)
+		if kerrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}); err != nil {
+		return fmt.Errorf("could not wait for %s %s to be deleted: %w", target.GetKind(), target.GetName(), err)
+	}
+
+	if err := ApplyAdditionalResources(ctx, s.podClient, namespace, s.jobSpec.Owner(), objects); err != nil {
+		return fmt.Errorf("could not apply the manifests: %w", err)
+	}
+
+	switch target.GetKind() {
+	case "Pod":
+		artifactDir, _ := s.artifactDir()
+		err = s.waitForPod(ctx, target.GetName(), artifactDir)
+	case "Job":
+		err = s.waitForJob(ctx, target.GetName())
+	default:
+		err = fmt.Errorf("cannot wait for %s %s, only Pods and Jobs are supported", target.GetKind(), target.GetName())
+	}
+	if err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("manifests test cancelled")
+	default:
+		return nil
+	}
+}
+
+// addArtifactsToTarget adds the artifacts container to the Pod, or to the
+// pods of the Job, the test waits for when they mount the artifacts volume.
+func addArtifactsToTarget(target *unstructured.Unstructured) error {
+	switch target.GetKind() {
+	case "Pod":
+		pod := &coreapi.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(target.Object, pod); err != nil {
+			return fmt.Errorf("could not decode pod %s: %w", target.GetName(), err)
+		}
+		addArtifactsToPod(pod)
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		if err != nil {
+			return fmt.Errorf("could not encode pod %s: %w", target.GetName(), err)
+		}
+		target.Object = object
+	case "Job":
+		job := &batchv1.Job{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(target.Object, job); err != nil {
+			return fmt.Errorf("could not decode job %s: %w", target.GetName(), err)
+		}
+		pod := &coreapi.Pod{Spec: job.Spec.Template.Spec}
+		addArtifactsToPod(pod)
+		job.Spec.Template.Spec = pod.Spec
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(job)
+		if err != nil {
+			return fmt.Errorf("could not encode job %s: %w", target.GetName(), err)
+		}
+		target.Object = object
+	}
+	return nil
+}
+
+// deleteObject deletes the object of the manifests, if it exists.
+func (s *manifestTestStep) deleteObject(ctx context.Context, manifest *unstructured.Unstructured) error {
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(manifest.GroupVersionKind())
+	object.SetNamespace(s.jobSpec.Namespace())
+	object.SetName(manifest.GetName())
+	if err := s.podClient.Delete(ctx, object, ctrlruntimeclient.PropagationPolicy(meta.DeletePropagationBackground)); err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// artifactDir is the directory the artifacts of the test are gathered to, if
+// artifacts were requested.
+func (s *manifestTestStep) artifactDir() (string, bool) {
+	artifactDir, ok := api.Artifacts()
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(artifactDir, s.jobSpec.AttemptArtifactDir(s.config.As)), true
+}
+
+func (s *manifestTestStep) waitForPod(ctx context.Context, name, artifactDir string) error {
+	var notifier util.ContainerNotifier = util.NopNotifier
+	if _, ok := api.Artifacts(); ok {
+		artifacts := NewArtifactWorker(s.podClient, artifactDir, s.jobSpec.Namespace())
+		pod := &coreapi.Pod{}
+		if err := s.podClient.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: name}, pod); err != nil {
+			return fmt.Errorf("unable to retrieve pod %s - possibly deleted: %w", name, err)
+		}
+		addArtifactContainersFromPod(pod, artifacts)
+		notifier = artifacts
+	}
+	testCaseNotifier := NewTestCaseNotifier(notifier)
+	_, err := util.WaitForPodCompletion(ctx, s.podClient, s.jobSpec.Namespace(), name, testCaseNotifier, util.WaitForPodFlag(0))
+	s.subTestsLock.Lock()
+	s.subTests = append(s.subTests, testCaseNotifier.SubTests(fmt.Sprintf("%s - %s ", s.Description(), name))...)
+	s.subTestsLock.Unlock()
+	if err != nil {
+		return fmt.Errorf("pod %q failed: %w", name, err)
+	}
+	return nil
+}
+
+// waitForJob waits for the Job to complete. Its pods are followed as they are
+// created, so their logs and artifacts are gathered the way they are for a
+// Pod, each into a directory named after the pod.
+func (s *manifestTestStep) waitForJob(ctx context.Context, name string) error {
+	var jobErr error
+	followed := sets.New[string]()
+	var wg sync.WaitGroup
+	err := wait.PollUntilContextCancel(ctx, 10*time.Second, true, func(pollCtx context.Context) (bool, error) {
+		if artifactDir, ok := s.artifactDir(); ok {
+			pods := &coreapi.PodList{}
+			if err := s.podClient.List(pollCtx, pods, ctrlruntimeclient.InNamespace(s.jobSpec.Namespace()), ctrlruntimeclient.MatchingLabels{jobNameLabel: name}); err != nil {
+				return false, fmt.Errorf("could not list the pods of job %s: %w", name, err)
+			}
+			for _, pod := range pods.Items {
+				if followed.Has(pod.Name) {
+					continue
+				}
+				followed.Insert(pod.Name)
+				wg.Add(1)
+				go func(pod string) {
+					defer wg.Done()
+					// the Job reports the failure of its pods
+					if err := s.waitForPod(ctx, pod, filepath.Join(artifactDir, pod)); err != nil {
+						logrus.WithError(err).Debugf("Pod %s of job %s did not succeed.", pod, name)
+					}
+				}(pod.Name)
+			}
+		}
+		job := &batchv1.Job{}
+		if err := s.podClient.Get(pollCtx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: name}, job); err != nil {
+			return false, fmt.Errorf("could not get job %s: %w", name, err)
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != coreapi.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return true, nil
+			case batchv1.JobFailed:
+				jobErr = fmt.Errorf("job %s failed: %s", name, condition.Message)
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	wg.Wait()
+	if err != nil {
+		return fmt.Errorf("could not wait for job %s to complete: %w", name, err)
+	}
+	return jobErr
+}
+
+func (s *manifestTestStep) SubTests() []*junit.TestCase {
+	return s.subTests
+}
+
+// Requires links the parameters referenced by the manifests to the steps that
+// provide them. When a file cannot be read, the step conservatively requires
+// all the images, as it fails when it runs anyway.
+func (s *manifestTestStep) Requires() []api.StepLink {
+	var links []api.StepLink
+	for _, object := range s.config.ManifestTestConfiguration.Objects {
+		manifest := object.Manifest
+		if object.File != "" {
+			raw, err := s.readFile(object.File)
+			if err != nil {
+				links = append(links, api.ImagesReadyLink())
+				continue
+			}
+			manifest = string(raw)
+		}
+		for _, match := range manifestParameter.FindAllStringSubmatch(manifest, -1) {
+			if link, ok := utils.LinkForEnv(match[1]); ok {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+func (s *manifestTestStep) Creates() []api.StepLink {
+	return []api.StepLink{}
+}
+
+func (s *manifestTestStep) Provides() api.ParameterMap {
+	return nil
+}
+
+func (s *manifestTestStep) Name() string { return s.config.As }
+
+func (s *manifestTestStep) Description() string {
+	return fmt.Sprintf("Run manifests of test %s", s.config.As)
+}
+
+func (s *manifestTestStep) Objects() []ctrlruntimeclient.Object {
+	return s.podClient.Objects()
+}
@@ -0,0 +1,217 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+func TestManifestTestStepExpand(t *testing.T) {
+	params := api.NewDeferredParameters(nil)
+	params.Add("NAMESPACE", func() (string, error) { return "ci-op-1234", nil })
+	params.Add(utils.ImageFormatEnv, func() (string, error) { return "registry.ci/ci-op-1234/stable:${component}", nil })
+	step := ManifestTestStep(api.TestStepConfiguration{}, params, nil, nil).(*manifestTestStep)
+	for _, tc := range []struct {
+		name        string
+		raw         string
+		expected    string
+		expectedErr error
+	}{{
+		name:     "parameters",
+		raw:      "namespace: ${NAMESPACE}\nimage: ${IMAGE_TESTS}\nliteral: $NAMESPACE",
+		expected: "namespace: ci-op-1234\nimage: registry.ci/ci-op-1234/stable:tests\nliteral: $NAMESPACE",
+	}, {
+		name:        "undefined parameter",
+		raw:         "name: ${UNDEFINED}",
+		expectedErr: errors.New("parameter UNDEFINED is not defined"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			expanded, err := step.expand([]byte(tc.raw))
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			if err == nil {
+				testhelper.Diff(t, "manifest", string(expanded), tc.expected)
+			}
+		})
+	}
+}
+
+func TestManifestTestStepRequires(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		objects  []api.AdditionalResource
+		expected []api.StepLink
+	}{{
+		name: "inline manifests",
+		objects: []api.AdditionalResource{
+			{Manifest: "image: ${IMAGE_FORMAT}"},
+			{Manifest: "namespace: ${NAMESPACE}"},
+		},
+		expected: []api.StepLink{api.ImagesReadyLink()},
+	}, {
+		name:     "manifest in a file",
+		objects:  []api.AdditionalResource{{File: "job.yaml"}},
+		expected: []api.StepLink{api.InternalImageLink("src")},
+	}, {
+		name:     "missing file",
+		objects:  []api.AdditionalResource{{File: "missing.yaml"}},
+		expected: []api.StepLink{api.ImagesReadyLink()},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			step := ManifestTestStep(api.TestStepConfiguration{
+				ManifestTestConfiguration: &api.ManifestTestConfiguration{Objects: tc.objects},
+			}, nil, nil, nil)
+			step.(*manifestTestStep).readFile = func(path string) ([]byte, error) {
+				if path != "job.yaml" {
+					return nil, os.ErrNotExist
+				}
+				return []byte("image: ${LOCAL_IMAGE_SRC}"), nil
+			}
+			testhelper.Diff(t, "requires", step.Requires(), tc.expected, api.Comparer())
+		})
+	}
+}
+
+func TestManifestTestStepRun(t *testing.T) {
+	job := func(condition string) api.AdditionalResource {
+		return api.AdditionalResource{Manifest: "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: test\nstatus:\n  conditions:\n  - type: " + condition + "\n    status: \"True\"\n    message: the test " + condition + "\n"}
+	}
+	for _, tc := range []struct {
+		name        string
+		job         api.AdditionalResource
+		waitFor     api.ManifestWaitFor
+		expectedErr error
+	}{{
+		name:    "completed job",
+		job:     job("Complete"),
+		waitFor: api.ManifestWaitFor{Kind: "Job", Name: "test"},
+	}, {
+		name:        "failed job",
+		job:         job("Failed"),
+		waitFor:     api.ManifestWaitFor{Kind: "Job", Name: "test"},
+		expectedErr: errors.New("job test failed: the test Failed"),
+	}, {
+		name:        "missing wait target",
+		job:         job("Complete"),
+		waitFor:     api.ManifestWaitFor{Kind: "Job", Name: "other"},
+		expectedErr: errors.New("the manifests do not contain Job other to wait for"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobSpec := &api.JobSpec{}
+			jobSpec.SetNamespace("ci-op-1234")
+			params := api.NewDeferredParameters(nil)
+			params.Add("NAMESPACE", func() (string, error) { return "ci-op-1234", nil })
+			client := &testhelper_kube.FakePodClient{
+				FakePodExecutor: &testhelper_kube.FakePodExecutor{
+					LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build()),
+				},
+			}
+			step := ManifestTestStep(api.TestStepConfiguration{
+				As: "e2e",
+				ManifestTestConfiguration: &api.ManifestTestConfiguration{
+					Objects: []api.AdditionalResource{
+						{Manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\ndata:\n  namespace: ${NAMESPACE}\n"},
+						tc.job,
+					},
+					WaitFor: tc.waitFor,
+				},
+			}, params, client, jobSpec)
+			err := step.Run(context.Background())
+			testhelper.Diff(t, "error", errors.Unwrap(err), tc.expectedErr, testhelper.EquateErrorMessage)
+			if err != nil {
+				return
+			}
+			config := &coreapi.ConfigMap{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1234", Name: "config"}, config); err != nil {
+				t.Fatalf("could not get the config map: %v", err)
+			}
+			testhelper.Diff(t, "config map data", config.Data, map[string]string{"namespace": "ci-op-1234"})
+		})
+	}
+}
+
+func TestManifestTestStepCancel(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ci-op-1234")
+	client := &testhelper_kube.FakePodClient{
+		FakePodExecutor: &testhelper_kube.FakePodExecutor{
+			LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build()),
+		},
+	}
+	step := ManifestTestStep(api.TestStepConfiguration{
+		As: "e2e",
+		ManifestTestConfiguration: &api.ManifestTestConfiguration{
+			Objects: []api.AdditionalResource{
+				{Manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"},
+				{Manifest: "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: test\n"},
+			},
+			WaitFor: api.ManifestWaitFor{Kind: "Job", Name: "test"},
+		},
+	}, api.NewDeferredParameters(nil), client, jobSpec)
+	configKey := ctrlruntimeclient.ObjectKey{Namespace: "ci-op-1234", Name: "config"}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// cancel the test once the manifests are applied
+		_ = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+			return client.Get(ctx, configKey, &coreapi.ConfigMap{}) == nil, nil
+		})
+		cancel()
+	}()
+	if err := step.Run(ctx); err == nil {
+		t.Fatal("expected the cancelled test to fail")
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+		return kerrors.IsNotFound(client.Get(ctx, configKey, &coreapi.ConfigMap{})), nil
+	}); err != nil {
+		t.Errorf("expected every applied object to be deleted when the test is cancelled: %v", err)
+	}
+}
+
+func TestAddArtifactsToTarget(t *testing.T) {
+	target := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      containers:
+      - name: test
+        volumeMounts:
+        - name: artifacts
+          mountPath: /tmp/artifacts
+      volumes:
+      - name: artifacts
+        emptyDir: {}
+`), &target.Object); err != nil {
+		t.Fatal(err)
+	}
+	if err := addArtifactsToTarget(target); err != nil {
+		t.Fatal(err)
+	}
+	containers, _, err := unstructured.NestedSlice(target.Object, "spec", "template", "spec", "containers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, container := range containers {
+		names = append(names, container.(map[string]interface{})["name"].(string))
+	}
+	testhelper.Diff(t, "containers", names, []string{"test", "artifacts"})
+}
@@ -84,6 +84,11 @@ func (s *multiStageTestStep) generatePods(
 			logrus.Infof(fmt.Sprintf("Skipping optional step %s", name))
 			continue
 		}
+		if !step.RunIf.ShouldRun(s.flags&hasPrevErrs != 0) {
+			logrus.Infof("Skipping step %s, which only runs on %s", name, step.RunIf)
+			continue
+		}
+		podName := s.jobSpec.NameForAttempt(name)
 		image := step.From
 		if link, ok := step.FromImageTag(); ok {
 			image = fmt.Sprintf("%s:%s", api.PipelineImageStream, link)
@@ -106,7 +111,7 @@ func (s *multiStageTestStep) generatePods(
 			delete(resources.Limits, api.ShmResource)
 		}
 		if bestEffortSteps != nil && step.BestEffort != nil && *step.BestEffort {
-			bestEffortSteps.Insert(name)
+			bestEffortSteps.Insert(podName)
 		}
 		p := func(i int64) *int64 {
 			return &i
@@ -132,8 +137,16 @@ func (s *multiStageTestStep) generatePods(
 		} else {
 			commands = []string{"/bin/bash", "-c", CommandPrefix + step.Commands}
 		}
+		var sidecarImages []string
+		if len(step.Sidecars) != 0 {
+			commands = waitForSidecars(step.Sidecars, commands)
+			for _, sidecar := range step.Sidecars {
+				stream, tag, _ := s.config.DependencyParts(api.StepDependency{Name: sidecar.From}, claimRelease)
+				sidecarImages = append(sidecarImages, fmt.Sprintf("%s:%s", stream, tag))
+			}
+		}
 		labels := map[string]string{base_steps.LabelMetadataStep: step.As}
-		pod, err := base_steps.GenerateBasePod(s.jobSpec, labels, name, s.nodeName,
+		pod, err := base_steps.GenerateBasePod(s.jobSpec, labels, podName, s.nodeName,
 			containerName, commands, image, resources, artifactDir, s.jobSpec.DecorationConfig,
 			s.jobSpec.RawSpec(), secretVolumeMounts, &base_steps.GeneratePodOptions{PropagateExitCode: genPodOpts.IsObserver})
 		if err != nil {
@@ -152,16 +165,8 @@ func (s *multiStageTestStep) generatePods(
 			pod.Spec.AutomountServiceAccountToken = &no
 		}
 		pod.Spec.TerminationGracePeriodSeconds = terminationGracePeriodSeconds
-		if step.DNSConfig != nil {
-			if pod.Spec.DNSConfig == nil {
-				pod.Spec.DNSConfig = &coreapi.PodDNSConfig{}
-			}
-			pod.Spec.DNSConfig.Nameservers = append(pod.Spec.DNSConfig.Nameservers, step.DNSConfig.Nameservers...)
-			pod.Spec.DNSConfig.Searches = append(pod.Spec.DNSConfig.Searches, step.DNSConfig.Searches...)
-			if len(pod.Spec.DNSConfig.Nameservers) > 0 {
-				pod.Spec.DNSPolicy = coreapi.DNSNone
-			}
-		}
+		base_steps.SetPodDNS(&pod.Spec, step.DNSConfig, step.HostAliases)
+		base_steps.SetPodRuntimeClass(&pod.Spec, step.RuntimeClass)
 		pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{Name: homeVolumeName, VolumeSource: coreapi.VolumeSource{EmptyDir: &coreapi.EmptyDirVolumeSource{}}})
 		pod.Spec.Volumes = append(pod.Spec.Volumes, secretVolumes...)
 		for idx := range pod.Spec.Containers {
@@ -185,6 +190,7 @@ func (s *multiStageTestStep) generatePods(
 			{Name: "JOB_NAME_HASH", Value: s.jobSpec.JobNameHash()},
 			{Name: "UNIQUE_HASH", Value: s.jobSpec.UniqueHash()},
 		}...)
+		container.Env = append(container.Env, s.jobSpec.ClusterMetadata.Env()...)
 		container.Env = append(container.Env, env...)
 		container.Env = append(container.Env, s.generateParams(step.Environment)...)
 		depEnv, depErrs := s.envForDependencies(step)
@@ -230,7 +236,17 @@ func (s *multiStageTestStep) generatePods(
 			addCliInjector(imagestream, pod)
 		}
 		addSharedDirSecret(s.name, pod)
+		// observers run alongside the steps, which alone use the volume
+		if s.sharedVolume != nil && !genPodOpts.IsObserver {
+			addSharedVolume(sharedVolumeName(s.name), pod)
+		}
 		addCredentials(step.Credentials, pod)
+		if len(step.Sidecars) != 0 {
+			if err := addSidecars(pod, step.Sidecars, sidecarImages); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
 		if step.RunAsScript != nil && *step.RunAsScript {
 			addCommandScript(commandConfigMapForTest(s.name), pod)
 		}
@@ -378,12 +394,34 @@ func (s *multiStageTestStep) generateParams(env []api.StepParameter) []coreapi.E
 		}
 		if v, ok := s.env[env.Name]; ok {
 			value = v
+		} else if v, ok := s.exposedURL(env.Name); ok {
+			value = v
 		}
 		ret = append(ret, coreapi.EnvVar{Name: env.Name, Value: value})
 	}
 	return ret
 }
 
+// exposedURL resolves the parameter holding the URL of an exposed test, if
+// the parameter is one.
+func (s *multiStageTestStep) exposedURL(name string) (string, bool) {
+	if s.config == nil || s.params == nil {
+		return "", false
+	}
+	for _, test := range s.config.Tests {
+		if c := test.ContainerTestConfiguration; c == nil || c.Expose == nil || api.ExposedURLEnv(test.As) != name {
+			continue
+		}
+		value, err := s.params.Get(name)
+		if err != nil {
+			logrus.WithError(err).Warnf("Could not resolve the URL of exposed test %s.", test.As)
+			return "", false
+		}
+		return value, true
+	}
+	return "", false
+}
+
 func (s *multiStageTestStep) envForDependencies(step api.LiteralTestStep) ([]coreapi.EnvVar, []error) {
 	var env []coreapi.EnvVar
 	var errs []error
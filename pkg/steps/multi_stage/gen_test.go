@@ -42,6 +42,20 @@ func TestGeneratePods(t *testing.T) {
 						Nameservers: []string{"nameserver1", "nameserver2"},
 						Searches:    []string{"my.dns.search1", "my.dns.search2"},
 					},
+				}, {
+					As: "step4", From: "src", Commands: "command4", Sidecars: []api.Sidecar{{
+						Name:        "database",
+						From:        "stable:postgres",
+						Commands:    "postgres",
+						Environment: map[string]string{"POSTGRES_USER": "test", "POSTGRES_DB": "test"},
+						Ports:       []int32{5432},
+						Readiness:   "pg_isready",
+						Resources:   api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m"}},
+					}, {
+						Name:     "cache",
+						From:     "pipeline:redis",
+						Commands: "redis-server",
+					}},
 				}},
 			}},
 		},
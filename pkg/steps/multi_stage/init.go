@@ -27,13 +27,18 @@ var (
 	uidRangeRegexp = regexp.MustCompile(`^(\d+)/\d+`)
 )
 
-func (s *multiStageTestStep) createSharedDirSecret(ctx context.Context) error {
-	logrus.Debugf("Creating multi-stage test shared directory %q", s.name)
-	secret := &coreapi.Secret{ObjectMeta: meta.ObjectMeta{
+// sharedDirSecret holds the shared directory of the steps.
+func (s *multiStageTestStep) sharedDirSecret() *coreapi.Secret {
+	return &coreapi.Secret{ObjectMeta: meta.ObjectMeta{
 		Namespace: s.jobSpec.Namespace(),
 		Name:      s.name,
 		Labels:    map[string]string{api.SkipCensoringLabel: "true"},
 	}}
+}
+
+func (s *multiStageTestStep) createSharedDirSecret(ctx context.Context) error {
+	logrus.Debugf("Creating multi-stage test shared directory %q", s.name)
+	secret := s.sharedDirSecret()
 	if err := s.client.Delete(ctx, secret); err != nil && !kerrors.IsNotFound(err) {
 		return fmt.Errorf("cannot delete shared directory %q: %w", s.name, err)
 	}
@@ -78,22 +83,27 @@ func (s *multiStageTestStep) createCredentials(ctx context.Context) error {
 	return nil
 }
 
-func (s *multiStageTestStep) createCommandConfigMaps(ctx context.Context) error {
-	logrus.Debugf("Creating multi-stage test commands configmap for %q", s.name)
+// commandConfigMap holds the commands of every step.
+func (s *multiStageTestStep) commandConfigMap() *coreapi.ConfigMap {
 	data := make(map[string]string)
 	for _, step := range append(s.pre, append(s.test, s.post...)...) {
 		data[step.As] = step.Commands
 	}
-	name := commandConfigMapForTest(s.name)
 	yes := true
-	commands := &coreapi.ConfigMap{
+	return &coreapi.ConfigMap{
 		ObjectMeta: meta.ObjectMeta{
-			Name:      name,
+			Name:      commandConfigMapForTest(s.name),
 			Namespace: s.jobSpec.Namespace(),
 		},
 		Data:      data,
 		Immutable: &yes,
 	}
+}
+
+func (s *multiStageTestStep) createCommandConfigMaps(ctx context.Context) error {
+	logrus.Debugf("Creating multi-stage test commands configmap for %q", s.name)
+	commands := s.commandConfigMap()
+	name := commands.Name
 	// delete old command configmap if it exists
 	if err := s.client.Delete(ctx, commands); err != nil && !kerrors.IsNotFound(err) {
 		return fmt.Errorf("could not delete command configmap %s: %w", name, err)
@@ -104,7 +114,9 @@ func (s *multiStageTestStep) createCommandConfigMaps(ctx context.Context) error
 	return nil
 }
 
-func (s *multiStageTestStep) setupRBAC(ctx context.Context) error {
+// rbacObjects are the service account the steps run as, along with its
+// role and role bindings.
+func (s *multiStageTestStep) rbacObjects() (*coreapi.ServiceAccount, *rbacapi.Role, []rbacapi.RoleBinding) {
 	labels := map[string]string{MultiStageTestLabel: s.name}
 	ns := s.jobSpec.Namespace()
 	m := meta.ObjectMeta{Namespace: ns, Name: s.name, Labels: labels}
@@ -160,6 +172,11 @@ func (s *multiStageTestStep) setupRBAC(ctx context.Context) error {
 			Subjects: subj,
 		})
 	}
+	return sa, role, bindings
+}
+
+func (s *multiStageTestStep) setupRBAC(ctx context.Context) error {
+	sa, role, bindings := s.rbacObjects()
 	if err := util.CreateRBACs(ctx, sa, role, bindings, s.client, 1*time.Second, 1*time.Minute); err != nil {
 		return err
 	}
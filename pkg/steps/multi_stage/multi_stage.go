@@ -8,10 +8,9 @@ import (
 	"sync"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
 	coreapi "k8s.io/api/core/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
@@ -19,6 +18,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/results"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
 )
@@ -59,13 +59,13 @@ const (
 	// MultiStageTestLabel is the label we use to mark a pod as part of a multi-stage test
 	MultiStageTestLabel = "ci.openshift.io/multi-stage-test"
 	// ClusterProfileMountPath is where we mount the cluster profile in a pod
-	ClusterProfileMountPath = "/var/run/secrets/ci.openshift.io/cluster-profile"
+	ClusterProfileMountPath = base_steps.ClusterProfileMountPath
 	// SecretMountPath is where we mount the shared dir secret
 	SecretMountPath = "/var/run/secrets/ci.openshift.io/multi-stage"
 	// SecretMountEnv is the env we use to expose the shared dir
 	SecretMountEnv = "SHARED_DIR"
 	// ClusterProfileMountEnv is the env we use to expose the cluster profile dir
-	ClusterProfileMountEnv = "CLUSTER_PROFILE_DIR"
+	ClusterProfileMountEnv = base_steps.ClusterProfileMountEnv
 	// CliMountPath is where we mount the cli in a pod
 	CliMountPath = "/cli"
 	// CommandPrefix is the prefix we add to a user's commands
@@ -102,6 +102,12 @@ type multiStageTestStep struct {
 	leases          []api.StepLease
 	clusterClaim    *api.ClusterClaim
 	vpnConf         *vpnConf
+	// timeout bounds how long the pre and test steps may run
+	timeout time.Duration
+	// outputs are the values of the outputs written by the steps so far
+	outputs map[string]string
+	// sharedVolume is mounted into every step, if set
+	sharedVolume *api.SharedVolume
 }
 
 func MultiStageTestStep(
@@ -152,6 +158,8 @@ func newMultiStageTestStep(
 		flags:            flags,
 		leases:           leases,
 		clusterClaim:     testConfig.ClusterClaim,
+		timeout:          testConfig.TestTimeout(),
+		sharedVolume:     ms.SharedVolume,
 		subLock:          &sync.Mutex{},
 	}
 }
@@ -161,7 +169,7 @@ func (s *multiStageTestStep) profileSecretName() string {
 	if s.additionalSuffix != "" {
 		name = strings.TrimSuffix(name, fmt.Sprintf("-%s", s.additionalSuffix))
 	}
-	return name + "-cluster-profile"
+	return api.ClusterProfileSecretName(name)
 }
 
 func (s *multiStageTestStep) Inputs() (api.InputDefinition, error) {
@@ -175,7 +183,7 @@ func (s *multiStageTestStep) Run(ctx context.Context) error {
 }
 
 func (s *multiStageTestStep) run(ctx context.Context) error {
-	logrus.Infof("Running multi-stage test %s", s.name)
+	base_steps.LoggerFor(ctx).Infof("Running multi-stage test %s", s.name)
 	if s.profile != "" {
 		if err := s.getProfileData(ctx); err != nil {
 			return err
@@ -188,6 +196,11 @@ func (s *multiStageTestStep) run(ctx context.Context) error {
 	if err := s.createSharedDirSecret(ctx); err != nil {
 		return fmt.Errorf("failed to create secret: %w", err)
 	}
+	if s.sharedVolume != nil {
+		if err := s.createSharedVolume(ctx); err != nil {
+			return fmt.Errorf("failed to create shared volume: %w", err)
+		}
+	}
 	if err := s.createCredentials(ctx); err != nil {
 		return fmt.Errorf("failed to create credentials: %w", err)
 	}
@@ -236,6 +249,92 @@ func (s *multiStageTestStep) Name() string { return s.name }
 func (s *multiStageTestStep) Description() string {
 	return fmt.Sprintf("Run multi-stage test %s", s.name)
 }
+
+// DryRun describes the objects the test sets up and the pods of its steps,
+// in the order they run. The values of leases and of the cluster profile,
+// and the content of the credentials, are only known when the test runs, so
+// placeholders are used instead.
+func (s *multiStageTestStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	env, err := s.environmentFrom(func(name string) (string, error) {
+		return base_steps.DryRunPlaceholder(name), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	actions := []api.DryRunAction{{Description: fmt.Sprintf("Create secret %s for the shared directory", s.name), Object: s.sharedDirSecret()}}
+	if s.sharedVolume != nil {
+		claim, err := s.sharedVolumeClaim()
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Create persistent volume claim %s for the shared volume", claim.Name), Object: claim})
+	}
+	copied := sets.New[string]()
+	for _, step := range append(s.pre, append(s.test, s.post...)...) {
+		for _, credential := range step.Credentials {
+			name := fmt.Sprintf("%s-%s", credential.Namespace, credential.Name)
+			if copied.Has(name) {
+				continue
+			}
+			copied.Insert(name)
+			actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Copy secret %s/%s to secret %s", credential.Namespace, credential.Name, name)})
+		}
+	}
+	commands := s.commandConfigMap()
+	actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Create configmap %s with the commands of the steps", commands.Name), Object: commands})
+	sa, role, bindings := s.rbacObjects()
+	actions = append(actions,
+		api.DryRunAction{Description: fmt.Sprintf("Create service account %s for the steps", sa.Name), Object: sa},
+		api.DryRunAction{Description: fmt.Sprintf("Create role %s", role.Name), Object: role},
+	)
+	for i := range bindings {
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Create role binding %s", bindings[i].Name), Object: &bindings[i]})
+	}
+	observers, err := s.generateObservers(s.observers, nil, nil, &generatePodOptions{IsObserver: true})
+	if err != nil {
+		return nil, err
+	}
+	for i := range observers {
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Run observer pod %s while the steps run", observers[i].Name), Object: &observers[i]})
+	}
+	for _, phase := range []struct {
+		name  string
+		steps []api.LiteralTestStep
+	}{{name: "pre", steps: s.pre}, {name: "test", steps: s.test}, {name: "post", steps: s.post}} {
+		pods, _, err := s.generatePods(phase.steps, env, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pods {
+			actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Run %s step pod %s and wait for it to complete", phase.name, pods[i].Name), Object: &pods[i]})
+		}
+	}
+	return actions, nil
+}
+
+// AttemptNames reports the names of the pods of the steps and observers
+// for this attempt.
+func (s *multiStageTestStep) AttemptNames() map[string]string {
+	var ret map[string]string
+	var names []string
+	for _, step := range append(s.pre, append(s.test, s.post...)...) {
+		names = append(names, step.As)
+	}
+	for _, observer := range s.observers {
+		names = append(names, observer.Name)
+	}
+	for _, step := range names {
+		name := fmt.Sprintf("%s-%s", s.name, step)
+		if attemptName := s.jobSpec.NameForAttempt(name); attemptName != name {
+			if ret == nil {
+				ret = map[string]string{}
+			}
+			ret[name] = attemptName
+		}
+	}
+	return ret
+}
+
 func (s *multiStageTestStep) Objects() []ctrlruntimeclient.Object {
 	return s.client.Objects()
 }
@@ -284,6 +383,15 @@ func (s *multiStageTestStep) Requires() (ret []api.StepLink) {
 			imageStream, name, _ := s.config.DependencyParts(dependency, claimRelease)
 			ret = append(ret, api.LinkForImage(imageStream, name))
 		}
+
+		for _, sidecar := range step.Sidecars {
+			imageStream, name, explicit := s.config.DependencyParts(api.StepDependency{Name: sidecar.From}, claimRelease)
+			if explicit {
+				ret = append(ret, api.LinkForImage(imageStream, name))
+			} else {
+				needsReleaseImage = true
+			}
+		}
 	}
 	if s.profile != "" {
 		needsReleasePayload = true
@@ -305,10 +413,12 @@ func (s *multiStageTestStep) Requires() (ret []api.StepLink) {
 
 func (s *multiStageTestStep) Creates() []api.StepLink { return nil }
 func (s *multiStageTestStep) Provides() api.ParameterMap {
-	return nil
+	return api.ParameterMapFor(s.Parameters())
 }
 func (s *multiStageTestStep) SubTests() []*junit.TestCase { return s.subTests }
 
+func (s *multiStageTestStep) Timeout() time.Duration { return s.timeout }
+
 // getProfileData fetches the content of the cluster profile secret.
 // This is done both to guarantee it has been correctly imported into the test
 // namespace and to gather information used when generating the test pods.
@@ -355,9 +465,17 @@ func (s *multiStageTestStep) readVPNData(secret *coreapi.Secret) error {
 }
 
 func (s *multiStageTestStep) environment() ([]coreapi.EnvVar, error) {
+	return s.environmentFrom(func(name string) (string, error) {
+		return s.params.Get(name)
+	})
+}
+
+// environmentFrom returns the variables of the leases and the cluster profile
+// of the test, with their values looked up with get.
+func (s *multiStageTestStep) environmentFrom(get func(name string) (string, error)) ([]coreapi.EnvVar, error) {
 	var ret []coreapi.EnvVar
 	for _, l := range s.leases {
-		val, err := s.params.Get(l.Env)
+		val, err := get(l.Env)
 		if err != nil {
 			return nil, err
 		}
@@ -366,7 +484,7 @@ func (s *multiStageTestStep) environment() ([]coreapi.EnvVar, error) {
 
 	if s.profile != "" {
 		for _, e := range envForProfile {
-			val, err := s.params.Get(e)
+			val, err := get(e)
 			if err != nil {
 				return nil, err
 			}
@@ -10,6 +10,8 @@ import (
 
 	coreapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	prowdapi "k8s.io/test-infra/prow/pod-utils/downwardapi"
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/openshift/ci-tools/pkg/api"
@@ -284,3 +286,69 @@ func TestProfileSecretName(t *testing.T) {
 		})
 	}
 }
+
+func TestDryRun(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "e2e",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Pre: []api.LiteralTestStep{{
+					As: "setup", From: "src", Commands: "setup",
+					Credentials: []api.CredentialReference{{Namespace: "ci", Name: "creds", MountPath: "/creds"}},
+				}},
+				Test: []api.LiteralTestStep{{
+					As: "test", From: "src", Commands: "test",
+					Credentials: []api.CredentialReference{{Namespace: "ci", Name: "creds", MountPath: "/creds"}},
+				}},
+				Post: []api.LiteralTestStep{{As: "teardown", From: "src", Commands: "teardown"}},
+			},
+		}},
+	}
+	jobSpec := api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build id",
+			ProwJobID: "prow job id",
+			Type:      "periodic",
+			DecorationConfig: &prowapi.DecorationConfig{
+				UtilityImages: &prowapi.UtilityImages{Sidecar: "sidecar", Entrypoint: "entrypoint"},
+			},
+		},
+	}
+	jobSpec.SetNamespace("ns")
+	leases := []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE", Count: 1}}
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, leases, "", "")
+	actions, err := step.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var descriptions []string
+	for _, action := range actions {
+		descriptions = append(descriptions, action.Description)
+	}
+	expected := []string{
+		"Create secret e2e for the shared directory",
+		"Copy secret ci/creds to secret ci-creds",
+		"Create configmap e2e-commands with the commands of the steps",
+		"Create service account e2e for the steps",
+		"Create role e2e",
+		"Create role binding e2e",
+		"Create role binding e2e-view",
+		"Run pre step pod e2e-setup and wait for it to complete",
+		"Run test step pod e2e-test and wait for it to complete",
+		"Run post step pod e2e-teardown and wait for it to complete",
+	}
+	if diff := cmp.Diff(expected, descriptions); diff != "" {
+		t.Errorf("unexpected actions: %s", diff)
+	}
+	pod := actions[len(actions)-1].Object.(*coreapi.Pod)
+	var leased string
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.Name == "LEASED_RESOURCE" {
+			leased = env.Value
+		}
+	}
+	if leased != "<LEASED_RESOURCE>" {
+		t.Errorf("expected a placeholder for the lease, got %q", leased)
+	}
+}
@@ -0,0 +1,186 @@
+package multi_stage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+// StepOutputsFile is the file in the shared directory steps write the values
+// of their outputs to, as NAME=value lines.
+const StepOutputsFile = "outputs.env"
+
+// outputsFor returns the outputs declared by the step with the given name.
+func (s *multiStageTestStep) outputsFor(step string) []string {
+	for _, literal := range append(append(s.pre, s.test...), s.post...) {
+		if literal.As == step {
+			return literal.Outputs
+		}
+	}
+	return nil
+}
+
+// outputsSecretName is the name of the secret holding the outputs of the steps
+// that ran so far, keyed by the variables the following steps see them as.
+func (s *multiStageTestStep) outputsSecretName() string {
+	return s.name + "-outputs"
+}
+
+// readOutputs stores the outputs the step of the pod wrote to the shared
+// directory in the secret of the outputs. It fails if any of the outputs the
+// step declares is missing.
+func (s *multiStageTestStep) readOutputs(ctx context.Context, pod *coreapi.Pod) error {
+	step := pod.Labels[base_steps.LabelMetadataStep]
+	names := s.outputsFor(step)
+	if len(names) == 0 {
+		return nil
+	}
+	secret := &coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: s.name}, secret); err != nil {
+		return fmt.Errorf("could not read the outputs of step %s: %w", step, err)
+	}
+	values := parseOutputs(secret.Data[StepOutputsFile])
+	var missing []string
+	s.subLock.Lock()
+	defer s.subLock.Unlock()
+	if s.outputs == nil {
+		s.outputs = map[string]string{}
+	}
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		s.outputs[api.StepOutputEnv(step, name)] = value
+	}
+	if err := s.writeOutputs(ctx); err != nil {
+		return fmt.Errorf("could not store the outputs of step %s: %w", step, err)
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("step %s did not write the outputs %s to $%s/%s", step, strings.Join(missing, ", "), SecretMountEnv, StepOutputsFile)
+	}
+	return nil
+}
+
+// writeOutputs creates or updates the secret of the outputs with the outputs
+// stored so far. The secret is censored from the artifacts like the other
+// secrets of the namespace.
+func (s *multiStageTestStep) writeOutputs(ctx context.Context) error {
+	secret := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: s.outputsSecretName()},
+		Data:       map[string][]byte{},
+	}
+	for name, value := range s.outputs {
+		secret.Data[name] = []byte(value)
+	}
+	err := s.client.Create(ctx, secret)
+	if !kerrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing := &coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(secret), existing); err != nil {
+		return err
+	}
+	existing.Data = secret.Data
+	return s.client.Update(ctx, existing)
+}
+
+// parseOutputs parses NAME=value lines, ignoring blank lines and comments.
+// Values may be enclosed in single or double quotes.
+func parseOutputs(raw []byte) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			continue
+		}
+		if len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values
+}
+
+// addOutputs sets the outputs of the steps that ran before in the environment
+// of the test container of the pod, as <STEP>_<NAME> variables read from the
+// secret of the outputs, replacing the values it already has.
+func (s *multiStageTestStep) addOutputs(pod *coreapi.Pod) {
+	s.subLock.Lock()
+	defer s.subLock.Unlock()
+	if len(s.outputs) == 0 {
+		return
+	}
+	fromSecret := func(name string) coreapi.EnvVar {
+		return coreapi.EnvVar{Name: name, ValueFrom: &coreapi.EnvVarSource{SecretKeyRef: &coreapi.SecretKeySelector{
+			LocalObjectReference: coreapi.LocalObjectReference{Name: s.outputsSecretName()},
+			Key:                  name,
+		}}}
+	}
+	container := &pod.Spec.Containers[0]
+	set := map[string]bool{}
+	for i, env := range container.Env {
+		if _, ok := s.outputs[env.Name]; ok {
+			container.Env[i] = fromSecret(env.Name)
+			set[env.Name] = true
+		}
+	}
+	var names []string
+	for name := range s.outputs {
+		if !set[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		container.Env = append(container.Env, fromSecret(name))
+	}
+}
+
+// ProvidesFromRun determines whether the test provides the outputs of steps,
+// which are only known once they ran.
+func (s *multiStageTestStep) ProvidesFromRun() bool {
+	return len(s.Parameters()) != 0
+}
+
+// Parameters provides the outputs of the steps as parameters of the test.
+func (s *multiStageTestStep) Parameters() []api.Parameter {
+	var parameters []api.Parameter
+	for _, step := range append(append(s.pre, s.test...), s.post...) {
+		for _, name := range step.Outputs {
+			step, name := step.As, name
+			parameters = append(parameters, api.Parameter{
+				Name:        api.TestStepOutputEnv(s.name, step, name),
+				Description: fmt.Sprintf("Output %s of step %s of test %s", name, step, s.name),
+				Value: func() (string, error) {
+					s.subLock.Lock()
+					defer s.subLock.Unlock()
+					value, ok := s.outputs[api.StepOutputEnv(step, name)]
+					if !ok {
+						return "", fmt.Errorf("step %s of test %s has not written output %s", step, s.name, name)
+					}
+					return value, nil
+				},
+			})
+		}
+	}
+	return parameters
+}
@@ -0,0 +1,115 @@
+package multi_stage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+func TestParseOutputs(t *testing.T) {
+	raw := []byte(`# written by the install step
+CLUSTER_NAME=ci-op-1234
+
+export API_URL="https://api.example.com:6443"
+QUOTED='single quoted'
+EMPTY=
+not a pair
+`)
+	expected := map[string]string{
+		"CLUSTER_NAME": "ci-op-1234",
+		"API_URL":      "https://api.example.com:6443",
+		"QUOTED":       "single quoted",
+		"EMPTY":        "",
+	}
+	if diff := cmp.Diff(expected, parseOutputs(raw)); diff != "" {
+		t.Errorf("unexpected outputs: %s", diff)
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	secret := &coreapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "e2e"},
+		Data:       map[string][]byte{StepOutputsFile: []byte("CLUSTER_NAME=ci-op-1234\nUNDECLARED=value\n")},
+	}
+	kubeClient := loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithObjects(secret).Build())
+	client := &testhelper_kube.FakePodClient{
+		PendingTimeout:  30 * time.Minute,
+		FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: kubeClient},
+	}
+	step := MultiStageTestStep(api.TestStepConfiguration{
+		As: "e2e",
+		MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+			Pre:  []api.LiteralTestStep{{As: "ipi-install", Outputs: []string{"CLUSTER_NAME"}}},
+			Test: []api.LiteralTestStep{{As: "test", Outputs: []string{"CLUSTER_NAME"}}},
+		},
+	}, &api.ReleaseBuildConfiguration{}, nil, client, jobSpec, nil, "node-name", "").(*multiStageTestStep)
+
+	parameters := step.Provides()
+	if _, err := parameters["E2E_IPI_INSTALL_CLUSTER_NAME"](); err == nil {
+		t.Error("expected an error for an output that was not written yet")
+	}
+
+	install := &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{base_steps.LabelMetadataStep: "ipi-install"}}}
+	if err := step.readOutputs(context.Background(), install); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, err := parameters["E2E_IPI_INSTALL_CLUSTER_NAME"](); err != nil || value != "ci-op-1234" {
+		t.Errorf("expected parameter to be ci-op-1234, got %q (error: %v)", value, err)
+	}
+	if _, err := parameters["E2E_TEST_CLUSTER_NAME"](); err == nil {
+		t.Error("expected an error for the output of the same name of another step")
+	}
+	outputs := &coreapi.Secret{}
+	if err := kubeClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "e2e-outputs"}, outputs); err != nil {
+		t.Fatalf("could not get the secret of the outputs: %v", err)
+	}
+	if diff := cmp.Diff(map[string][]byte{"IPI_INSTALL_CLUSTER_NAME": []byte("ci-op-1234")}, outputs.Data); diff != "" {
+		t.Errorf("unexpected outputs in the secret: %s", diff)
+	}
+
+	pod := &coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{
+		Env: []coreapi.EnvVar{{Name: "IPI_INSTALL_CLUSTER_NAME", Value: "default"}, {Name: "OTHER", Value: "other"}},
+	}}}}
+	step.addOutputs(pod)
+	fromSecret := &coreapi.EnvVarSource{SecretKeyRef: &coreapi.SecretKeySelector{
+		LocalObjectReference: coreapi.LocalObjectReference{Name: "e2e-outputs"},
+		Key:                  "IPI_INSTALL_CLUSTER_NAME",
+	}}
+	expected := []coreapi.EnvVar{{Name: "IPI_INSTALL_CLUSTER_NAME", ValueFrom: fromSecret}, {Name: "OTHER", Value: "other"}}
+	if diff := cmp.Diff(expected, pod.Spec.Containers[0].Env); diff != "" {
+		t.Errorf("unexpected environment: %s", diff)
+	}
+
+	test := &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{base_steps.LabelMetadataStep: "test"}}}
+	if err := step.readOutputs(context.Background(), test); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := kubeClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "e2e-outputs"}, outputs); err != nil {
+		t.Fatalf("could not get the secret of the outputs: %v", err)
+	}
+	if diff := cmp.Diff(map[string][]byte{"IPI_INSTALL_CLUSTER_NAME": []byte("ci-op-1234"), "TEST_CLUSTER_NAME": []byte("ci-op-1234")}, outputs.Data); diff != "" {
+		t.Errorf("unexpected outputs in the secret after the second step: %s", diff)
+	}
+
+	secret.Data[StepOutputsFile] = nil
+	if err := kubeClient.Update(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := step.readOutputs(context.Background(), test); err == nil {
+		t.Error("expected an error for a declared output that was not written")
+	}
+}
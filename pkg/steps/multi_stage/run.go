@@ -32,7 +32,7 @@ func (s *multiStageTestStep) runSteps(
 	secretVolumeMounts []coreapi.VolumeMount,
 ) error {
 	start := time.Now()
-	logrus.Infof("Running multi-stage phase %s", phase)
+	base_steps.LoggerFor(ctx).Infof("Running multi-stage phase %s", phase)
 	pods, bestEffortSteps, err := s.generatePods(steps, env, secretVolumes, secretVolumeMounts, nil)
 	if err != nil {
 		s.flags |= hasPrevErrs
@@ -74,7 +74,7 @@ func (s *multiStageTestStep) runSteps(
 		}
 	}
 	s.subTests = append(s.subTests, testCase)
-	logrus.Infof("Step phase %s %s after %s.", phase, verb, duration.Truncate(time.Second))
+	base_steps.LoggerFor(ctx).Infof("Step phase %s %s after %s.", phase, verb, duration.Truncate(time.Second))
 
 	return err
 }
@@ -82,7 +82,11 @@ func (s *multiStageTestStep) runSteps(
 func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, bestEffortSteps sets.Set[string]) error {
 	var errs []error
 	for _, pod := range pods {
+		s.addOutputs(&pod)
 		err := s.runPod(ctx, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
+		if err == nil {
+			err = s.readOutputs(ctx, &pod)
+		}
 		if err == nil {
 			continue
 		}
@@ -134,7 +138,7 @@ func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []c
 
 func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notifier *base_steps.TestCaseNotifier, flags util.WaitForPodFlag) error {
 	start := time.Now()
-	logrus.Infof("Running step %s.", pod.Name)
+	base_steps.LoggerFor(ctx).Infof("Running step %s.", pod.Name)
 	client := s.client.WithNewLoggingClient()
 	if _, err := util.CreateOrRestartPod(ctx, client, pod); err != nil {
 		return fmt.Errorf("failed to create or restart %s pod: %w", pod.Name, err)
@@ -149,7 +153,7 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	if err != nil {
 		verb = "failed"
 	}
-	logrus.Infof("Step %s %s after %s.", pod.Name, verb, duration.Truncate(time.Second))
+	base_steps.LoggerFor(ctx).Infof("Step %s %s after %s.", pod.Name, verb, duration.Truncate(time.Second))
 	s.subLock.Lock()
 	s.subSteps = append(s.subSteps, api.CIOperatorStepDetailInfo{
 		StepName:    pod.Name,
@@ -164,7 +168,7 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	s.subLock.Unlock()
 	if err != nil {
 		linksText := strings.Builder{}
-		linksText.WriteString(fmt.Sprintf("Link to step on registry info site: https://steps.ci.openshift.org/reference/%s", strings.TrimPrefix(pod.Name, s.name+"-")))
+		linksText.WriteString(fmt.Sprintf("Link to step on registry info site: https://steps.ci.openshift.org/reference/%s", pod.Labels[base_steps.LabelMetadataStep]))
 		linksText.WriteString(fmt.Sprintf("\nLink to job on registry info site: https://steps.ci.openshift.org/job?org=%s&repo=%s&branch=%s&test=%s", s.config.Metadata.Org, s.config.Metadata.Repo, s.config.Metadata.Branch, s.name))
 		if s.config.Metadata.Variant != "" {
 			linksText.WriteString(fmt.Sprintf("&variant=%s", s.config.Metadata.Variant))
@@ -37,7 +37,7 @@ func TestRun(t *testing.T) {
 			expected: []string{
 				"test-pre0", "test-pre1",
 				"test-test0", "test-test1",
-				"test-post0",
+				"test-post0", "test-post3",
 			},
 		},
 		{
@@ -45,7 +45,7 @@ func TestRun(t *testing.T) {
 			failures: sets.New[string]("test-pre0"),
 			expected: []string{
 				"test-pre0",
-				"test-post0", "test-post1",
+				"test-post0", "test-post1", "test-post2",
 			},
 		}, {
 			name:     "failure in a test step, post should run",
@@ -53,7 +53,7 @@ func TestRun(t *testing.T) {
 			expected: []string{
 				"test-pre0", "test-pre1",
 				"test-test0",
-				"test-post0", "test-post1",
+				"test-post0", "test-post1", "test-post2",
 			},
 		},
 		{
@@ -62,7 +62,7 @@ func TestRun(t *testing.T) {
 			expected: []string{
 				"test-pre0", "test-pre1",
 				"test-test0", "test-test1",
-				"test-post0",
+				"test-post0", "test-post3",
 			},
 		},
 	} {
@@ -105,9 +105,14 @@ func TestRun(t *testing.T) {
 			step := MultiStageTestStep(api.TestStepConfiguration{
 				As: name,
 				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
-					Pre:                []api.LiteralTestStep{{As: "pre0"}, {As: "pre1"}},
-					Test:               []api.LiteralTestStep{{As: "test0"}, {As: "test1"}},
-					Post:               []api.LiteralTestStep{{As: "post0"}, {As: "post1", OptionalOnSuccess: &yes}},
+					Pre:  []api.LiteralTestStep{{As: "pre0"}, {As: "pre1"}},
+					Test: []api.LiteralTestStep{{As: "test0"}, {As: "test1"}},
+					Post: []api.LiteralTestStep{
+						{As: "post0"},
+						{As: "post1", OptionalOnSuccess: &yes},
+						{As: "post2", RunIf: api.RunIfFailure},
+						{As: "post3", RunIf: api.RunIfSuccess},
+					},
 					AllowSkipOnSuccess: &yes,
 				},
 			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "")
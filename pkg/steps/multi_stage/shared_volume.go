@@ -0,0 +1,91 @@
+package multi_stage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// SharedVolumeMountPath is where we mount the shared volume of a test
+const SharedVolumeMountPath = "/var/run/ci.openshift.io/shared-volume"
+
+// sharedVolumeName is the name of the PersistentVolumeClaim of the shared
+// volume of the test.
+func sharedVolumeName(test string) string {
+	return fmt.Sprintf("%s-shared-volume", test)
+}
+
+// createSharedVolume creates the claim for the shared volume of the test,
+// replacing one left behind by an earlier execution so that steps do not
+// see its content.
+func (s *multiStageTestStep) createSharedVolume(ctx context.Context) error {
+	claim, err := s.sharedVolumeClaim()
+	if err != nil {
+		return err
+	}
+	name := claim.Name
+	logrus.Debugf("Creating multi-stage test shared volume %q", name)
+	if err := s.client.Delete(ctx, claim); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("cannot delete shared volume %q: %w", name, err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		err := s.client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(claim), &coreapi.PersistentVolumeClaim{})
+		if kerrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}); err != nil {
+		return fmt.Errorf("could not wait for shared volume %q to be deleted: %w", name, err)
+	}
+	return s.client.Create(ctx, claim)
+}
+
+// sharedVolumeClaim is the claim for the shared volume of the test.
+func (s *multiStageTestStep) sharedVolumeClaim() (*coreapi.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(s.sharedVolume.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size of the shared volume: %w", err)
+	}
+	claim := &coreapi.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: sharedVolumeName(s.name)},
+		Spec: coreapi.PersistentVolumeClaimSpec{
+			AccessModes: []coreapi.PersistentVolumeAccessMode{coreapi.ReadWriteOnce},
+			Resources: coreapi.ResourceRequirements{
+				Requests: coreapi.ResourceList{coreapi.ResourceStorage: size},
+			},
+		},
+	}
+	if s.sharedVolume.StorageClass != "" {
+		claim.Spec.StorageClassName = &s.sharedVolume.StorageClass
+	}
+	return claim, nil
+}
+
+// addSharedVolume mounts the shared volume into the step and exposes its path.
+func addSharedVolume(claim string, pod *coreapi.Pod) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
+		Name: claim,
+		VolumeSource: coreapi.VolumeSource{
+			PersistentVolumeClaim: &coreapi.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+		},
+	})
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, coreapi.VolumeMount{
+		Name:      claim,
+		MountPath: SharedVolumeMountPath,
+	})
+	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, coreapi.EnvVar{
+		Name:  api.SharedVolumeMountEnv,
+		Value: SharedVolumeMountPath,
+	})
+}
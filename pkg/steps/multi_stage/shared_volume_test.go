@@ -0,0 +1,83 @@
+package multi_stage
+
+import (
+	"context"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+func TestCreateSharedVolume(t *testing.T) {
+	storageClass := "fast"
+	for _, tc := range []struct {
+		name     string
+		volume   api.SharedVolume
+		existing []ctrlruntimeclient.Object
+		expected coreapi.PersistentVolumeClaimSpec
+	}{{
+		name:   "default storage class",
+		volume: api.SharedVolume{Size: "10Gi"},
+		expected: coreapi.PersistentVolumeClaimSpec{
+			AccessModes: []coreapi.PersistentVolumeAccessMode{coreapi.ReadWriteOnce},
+			Resources:   coreapi.ResourceRequirements{Requests: coreapi.ResourceList{coreapi.ResourceStorage: resource.MustParse("10Gi")}},
+		},
+	}, {
+		name:   "stale claim is replaced",
+		volume: api.SharedVolume{Size: "1Gi", StorageClass: storageClass},
+		existing: []ctrlruntimeclient.Object{&coreapi.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "e2e-shared-volume"},
+			Spec: coreapi.PersistentVolumeClaimSpec{
+				Resources: coreapi.ResourceRequirements{Requests: coreapi.ResourceList{coreapi.ResourceStorage: resource.MustParse("5Gi")}},
+			},
+		}},
+		expected: coreapi.PersistentVolumeClaimSpec{
+			AccessModes:      []coreapi.PersistentVolumeAccessMode{coreapi.ReadWriteOnce},
+			Resources:        coreapi.ResourceRequirements{Requests: coreapi.ResourceList{coreapi.ResourceStorage: resource.MustParse("1Gi")}},
+			StorageClassName: &storageClass,
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobSpec := api.JobSpec{}
+			jobSpec.SetNamespace("ns")
+			client := &testhelper_kube.FakePodClient{
+				FakePodExecutor: &testhelper_kube.FakePodExecutor{
+					LoggingClient: loggingclient.New(fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.existing...).Build()),
+				},
+			}
+			step := &multiStageTestStep{name: "e2e", client: client, jobSpec: &jobSpec, sharedVolume: &tc.volume}
+			if err := step.createSharedVolume(context.Background()); err != nil {
+				t.Fatalf("failed to create the shared volume: %v", err)
+			}
+			claim := &coreapi.PersistentVolumeClaim{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "e2e-shared-volume"}, claim); err != nil {
+				t.Fatalf("failed to get the shared volume: %v", err)
+			}
+			testhelper.Diff(t, "claim", claim.Spec, tc.expected)
+		})
+	}
+}
+
+func TestAddSharedVolume(t *testing.T) {
+	pod := &coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{Name: "test"}}}}
+	addSharedVolume("e2e-shared-volume", pod)
+	testhelper.Diff(t, "pod", pod, &coreapi.Pod{Spec: coreapi.PodSpec{
+		Volumes: []coreapi.Volume{{
+			Name:         "e2e-shared-volume",
+			VolumeSource: coreapi.VolumeSource{PersistentVolumeClaim: &coreapi.PersistentVolumeClaimVolumeSource{ClaimName: "e2e-shared-volume"}},
+		}},
+		Containers: []coreapi.Container{{
+			Name:         "test",
+			VolumeMounts: []coreapi.VolumeMount{{Name: "e2e-shared-volume", MountPath: SharedVolumeMountPath}},
+			Env:          []coreapi.EnvVar{{Name: api.SharedVolumeMountEnv, Value: SharedVolumeMountPath}},
+		}},
+	}})
+}
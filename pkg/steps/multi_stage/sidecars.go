@@ -0,0 +1,116 @@
+package multi_stage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
+)
+
+const (
+	// sidecarsVolumeName is the volume the sidecars signal their readiness in.
+	sidecarsVolumeName = "sidecars"
+	// sidecarsMountPath is where the sidecars volume is mounted.
+	sidecarsMountPath = "/tmp/sidecars"
+	// entrypointMarkerFile is written by the entrypoint of the test container
+	// when the commands of the step exit.
+	entrypointMarkerFile = "/logs/marker-file.txt"
+)
+
+// sidecarScript runs the commands of a sidecar until the test container exits.
+// It marks the sidecar ready once the readiness command succeeds, or failed
+// when the commands exit before that. It always exits successfully, so the
+// step succeeds or fails with the test container.
+const sidecarScript = `sh -c "${SIDECAR_COMMANDS}" &
+pid=$!
+if [ -n "${SIDECAR_READINESS}" ]; then
+  until sh -c "${SIDECAR_READINESS}"; do
+    if ! kill -0 "${pid}" 2>/dev/null; then
+      touch "` + sidecarsMountPath + `/${SIDECAR_NAME}-failed"
+      exit 0
+    fi
+    sleep 1
+  done
+fi
+touch "` + sidecarsMountPath + `/${SIDECAR_NAME}-ready"
+until [ -f "` + entrypointMarkerFile + `" ]; do
+  sleep 1
+done
+kill "${pid}" 2>/dev/null || true
+exit 0
+`
+
+// waitForSidecars wraps the commands of the test container so that they only
+// start once every sidecar is ready.
+func waitForSidecars(sidecars []api.Sidecar, commands []string) []string {
+	var names []string
+	for _, sidecar := range sidecars {
+		names = append(names, sidecar.Name)
+	}
+	script := fmt.Sprintf(`for name in %s; do
+  until [ -f "%s/${name}-ready" ]; do
+    if [ -f "%s/${name}-failed" ]; then
+      echo "sidecar ${name} exited before it was ready" >&2
+      exit 1
+    fi
+    sleep 1
+  done
+done
+exec "$@"
+`, strings.Join(names, " "), sidecarsMountPath, sidecarsMountPath)
+	return append([]string{"/bin/bash", "-c", script, "wait-for-sidecars"}, commands...)
+}
+
+// addSidecars adds a container for every sidecar of the step to the pod,
+// running the image resolved for it.
+func addSidecars(pod *coreapi.Pod, sidecars []api.Sidecar, images []string) error {
+	mount := coreapi.VolumeMount{Name: sidecarsVolumeName, MountPath: sidecarsMountPath}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
+		Name: sidecarsVolumeName,
+		VolumeSource: coreapi.VolumeSource{
+			EmptyDir: &coreapi.EmptyDirVolumeSource{},
+		},
+	})
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, mount)
+	for i, sidecar := range sidecars {
+		resources, err := base_steps.ResourcesFor(sidecar.Resources)
+		if err != nil {
+			return fmt.Errorf("sidecar %s: %w", sidecar.Name, err)
+		}
+		env := []coreapi.EnvVar{
+			{Name: "SIDECAR_NAME", Value: sidecar.Name},
+			{Name: "SIDECAR_COMMANDS", Value: sidecar.Commands},
+			{Name: "SIDECAR_READINESS", Value: sidecar.Readiness},
+		}
+		var keys []string
+		for key := range sidecar.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			env = append(env, coreapi.EnvVar{Name: key, Value: sidecar.Environment[key]})
+		}
+		var ports []coreapi.ContainerPort
+		for _, port := range sidecar.Ports {
+			ports = append(ports, coreapi.ContainerPort{ContainerPort: port, Protocol: coreapi.ProtocolTCP})
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, coreapi.Container{
+			Name:                     sidecar.Name,
+			Image:                    images[i],
+			Command:                  []string{"/bin/sh", "-c", sidecarScript},
+			Env:                      env,
+			Ports:                    ports,
+			Resources:                resources,
+			TerminationMessagePolicy: coreapi.TerminationMessageFallbackToLogsOnError,
+			VolumeMounts: []coreapi.VolumeMount{
+				mount,
+				{Name: "logs", MountPath: "/logs"},
+			},
+		})
+	}
+	return nil
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
 	coreapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,9 +42,9 @@ func (s *outputImageTagStep) Run(ctx context.Context) error {
 func (s *outputImageTagStep) run(ctx context.Context) error {
 	toNamespace := s.namespace()
 	if string(s.config.From) == s.config.To.Tag && toNamespace == s.jobSpec.Namespace() && s.config.To.Name == api.StableImageStream {
-		logrus.Infof("Tagging %s into %s", s.config.From, s.config.To.Name)
+		LoggerFor(ctx).Infof("Tagging %s into %s", s.config.From, s.config.To.Name)
 	} else {
-		logrus.Infof("Tagging %s into %s", s.config.From, s.config.To.ISTagName())
+		LoggerFor(ctx).Infof("Tagging %s into %s", s.config.From, s.config.To.ISTagName())
 	}
 	from := &imagev1.ImageStreamTag{}
 	namespace := s.jobSpec.Namespace()
@@ -90,6 +88,15 @@ func (s *outputImageTagStep) run(ctx context.Context) error {
 	return nil
 }
 
+// DryRun describes the image stream tag the step would create or update.
+func (s *outputImageTagStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	ist := s.imageStreamTag(DryRunPlaceholder(fmt.Sprintf("image of %s:%s", api.PipelineImageStream, s.config.From)))
+	return []api.DryRunAction{{
+		Description: fmt.Sprintf("Tag %s:%s as %s/%s", api.PipelineImageStream, s.config.From, ist.Namespace, ist.Name),
+		Object:      ist,
+	}}, nil
+}
+
 func (s *outputImageTagStep) Requires() []api.StepLink {
 	return []api.StepLink{
 		api.InternalImageLink(s.config.From),
@@ -113,14 +120,16 @@ func (s *outputImageTagStep) Creates() (ret []api.StepLink) {
 }
 
 func (s *outputImageTagStep) Provides() api.ParameterMap {
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *outputImageTagStep) Parameters() []api.Parameter {
 	if len(s.config.To.As) == 0 {
 		return nil
 	}
-	return api.ParameterMap{
-		utils.StableImageEnv(s.config.To.As): utils.ImageDigestFor(s.client, func() string {
-			return s.config.To.Namespace
-		}, s.config.To.Name, s.config.To.Tag),
-	}
+	return []api.Parameter{utils.ImageParameter(utils.StableImageEnv(s.config.To.As), fmt.Sprintf("Pull spec of the %s output image", s.config.To.As), utils.ImageDigestFor(s.client, func() string {
+		return s.config.To.Namespace
+	}, s.config.To.Name, s.config.To.Tag))}
 }
 
 func (s *outputImageTagStep) Name() string { return s.config.TargetName() }
@@ -0,0 +1,50 @@
+package steps
+
+import (
+	"sort"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/utils"
+)
+
+// ParameterReference describes the parameters the steps of this package may
+// provide, with placeholders in angle brackets for the parts that depend on
+// the configuration. It is generated from the providers themselves, so it
+// can document the parameters without a configuration.
+func ParameterReference() []api.Parameter {
+	var reference []api.Parameter
+	reference = append(reference, utils.PipelineImageParameter(nil, nil, "<component>"))
+	reference = append(reference, (&outputImageTagStep{config: api.OutputImageTagStepConfiguration{To: api.ImageStreamTagReference{As: "<component>"}}}).Parameters()...)
+	reference = append(reference, (&rpmServerStep{jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowapi.Refs{Org: "<org>", Repo: "<repo>"}}}}).Parameters()...)
+	reference = append(reference, (&leaseStep{leases: []stepLease{{StepLease: api.StepLease{ResourceType: "<type>", Env: api.DefaultLeaseEnv}}}, wrapped: &inputEnvironmentStep{}}).Parameters()...)
+	return reference
+}
+
+// DocumentedParameter documents a parameter available to the steps of a job.
+type DocumentedParameter struct {
+	ProvidedParameter
+	// ProvidedBy names the step providing the parameter, it is empty for the
+	// parameters describing the job itself.
+	ProvidedBy string `json:"provided_by,omitempty"`
+}
+
+// DocumentParameters documents the parameters of the job and those provided
+// by the steps, sorted by name.
+func DocumentParameters(job []api.Parameter, steps []api.Step) []DocumentedParameter {
+	var documented []DocumentedParameter
+	for _, parameter := range job {
+		documented = append(documented, DocumentedParameter{ProvidedParameter: documentParameter(parameter)})
+	}
+	for _, step := range steps {
+		for _, parameter := range api.ParametersFor(step) {
+			documented = append(documented, DocumentedParameter{ProvidedParameter: documentParameter(parameter), ProvidedBy: step.Name()})
+		}
+	}
+	sort.SliceStable(documented, func(i, j int) bool {
+		return documented[i].Name < documented[j].Name
+	})
+	return documented
+}
@@ -69,12 +69,14 @@ func (s *pipelineImageCacheStep) Creates() []api.StepLink {
 }
 
 func (s *pipelineImageCacheStep) Provides() api.ParameterMap {
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *pipelineImageCacheStep) Parameters() []api.Parameter {
 	if len(s.config.To) == 0 {
 		return nil
 	}
-	return api.ParameterMap{
-		utils.PipelineImageEnvFor(s.config.To): utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, string(s.config.To)),
-	}
+	return []api.Parameter{utils.PipelineImageParameter(s.client, s.jobSpec.Namespace, s.config.To)}
 }
 
 func (s *pipelineImageCacheStep) Name() string { return s.config.TargetName() }
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -28,6 +31,12 @@ const (
 	testSecretVolumePrefix = "test-secret"
 	testSecretDefaultPath  = "/usr/test-secrets"
 
+	// ClusterProfileMountPath is where we mount the cluster profile in a pod
+	ClusterProfileMountPath = "/var/run/secrets/ci.openshift.io/cluster-profile"
+	// ClusterProfileMountEnv is the env we use to expose the cluster profile dir
+	ClusterProfileMountEnv = "CLUSTER_PROFILE_DIR"
+	clusterProfileVolume   = "cluster-profile"
+
 	openshiftCIEnv = "OPENSHIFT_CI"
 )
 
@@ -53,6 +62,20 @@ type PodStepConfiguration struct {
 	Secrets            []*api.Secret
 	MemoryBackedVolume *api.MemoryBackedVolume
 	Clone              bool
+	// Timeout bounds how long the step may run, if set
+	Timeout time.Duration
+	// Expose makes the pod reachable by other steps while it runs, if set
+	Expose *api.TestExposure
+	// DNSConfig and HostAliases customize the name resolution of the pod
+	DNSConfig   *api.StepDNSConfig
+	HostAliases []api.StepHostAlias
+	// RuntimeClass is the RuntimeClass the pod runs with, if set
+	RuntimeClass string
+	// ClusterProfile is mounted into the pod, if set
+	ClusterProfile api.ClusterProfile
+	// Leases are acquired around the pod, which gets the names of the
+	// leased resources in their Env from the parameters
+	Leases []api.StepLease
 }
 
 type GeneratePodOptions struct {
@@ -66,10 +89,29 @@ type podStep struct {
 	resources api.ResourceConfiguration
 	client    kubernetes.PodClient
 	jobSpec   *api.JobSpec
+	// params resolve the names of the leased resources, if any
+	params api.Parameters
 
 	subTests []*junit.TestCase
 
 	clusterClaim *api.ClusterClaim
+
+	// shard is set when the pod runs one shard of a sharded test
+	shard *shard
+}
+
+// shard identifies one of the pods a sharded test is split into.
+type shard struct {
+	index, total int
+	// tests are the tests assigned to the shard by their timings
+	tests []string
+	// excluded are the tests assigned to the other shards, set on the shard
+	// that runs the tests that have no timings
+	excluded []string
+	// timingsFile is where the timings the shard reported are gathered to
+	timingsFile string
+	// artifactDir is where the artifacts of the shard are gathered to
+	artifactDir string
 }
 
 func (s *podStep) Inputs() (api.InputDefinition, error) {
@@ -84,36 +126,41 @@ func (s *podStep) Run(ctx context.Context) error {
 
 func (s *podStep) run(ctx context.Context) error {
 	if !util.IsBitSet(s.config.WaitFlags, util.SkipLogs) {
-		logrus.Infof("Executing %s %s", s.name, s.config.As)
+		LoggerFor(ctx).Infof("Executing %s %s", s.name, s.config.As)
 	}
-	containerResources, err := ResourcesFor(s.resources.RequirementsForStep(s.config.As))
+	pod, err := s.pod()
 	if err != nil {
-		return fmt.Errorf("unable to calculate %s pod resources for %s: %w", s.name, s.config.As, err)
+		return err
 	}
-
-	if s.config.From.Namespace != "" {
-		return errors.New("pod step does not support an image stream tag reference outside the namespace")
-	}
-	image := fmt.Sprintf("%s:%s", s.config.From.Name, s.config.From.Tag)
-
-	pod, err := s.generatePodForStep(image, containerResources, s.config.Clone)
-	if err != nil {
-		return fmt.Errorf("pod step was invalid: %w", err)
-	}
-	testCaseNotifier := NewTestCaseNotifier(util.NopNotifier)
-
-	if owner := s.jobSpec.Owner(); owner != nil {
-		pod.OwnerReferences = append(pod.OwnerReferences, *owner)
+	var notifier util.ContainerNotifier = util.NopNotifier
+	if s.shard != nil && hasArtifactsVolume(pod) {
+		artifactDir, _ := api.Artifacts()
+		dir := filepath.Join(artifactDir, pod.Name)
+		artifacts := NewArtifactWorker(s.client, dir, s.jobSpec.Namespace())
+		addArtifactContainersFromPod(pod, artifacts)
+		notifier = artifacts
+		s.shard.timingsFile = filepath.Join(dir, shardTimingsFile)
+		s.shard.artifactDir = dir
 	}
+	testCaseNotifier := NewTestCaseNotifier(notifier)
 
+	name := pod.Name
 	go func() {
 		<-ctx.Done()
-		logrus.Infof("cleanup: Deleting %s pod %s", s.name, s.config.As)
-		if err := s.client.Delete(CleanupCtx, &coreapi.Pod{ObjectMeta: meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: s.config.As}}); err != nil && !kerrors.IsNotFound(err) {
+		logrus.Infof("cleanup: Deleting %s pod %s", s.name, name)
+		if err := s.client.Delete(CleanupCtx, &coreapi.Pod{ObjectMeta: meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: name}}); err != nil && !kerrors.IsNotFound(err) {
 			logrus.WithError(err).Warnf("Could not delete %s pod.", s.name)
 		}
 	}()
 
+	if s.config.Expose != nil {
+		teardown, err := s.expose(ctx)
+		if err != nil {
+			return err
+		}
+		defer teardown()
+	}
+
 	pod, err = util.CreateOrRestartPod(ctx, s.client, pod)
 	if err != nil {
 		return fmt.Errorf("failed to create or restart %s pod: %w", s.name, err)
@@ -128,6 +175,84 @@ func (s *podStep) run(ctx context.Context) error {
 	return nil
 }
 
+func (s *podStep) Timeout() time.Duration { return s.config.Timeout }
+
+func (s *podStep) pod() (*coreapi.Pod, error) {
+	containerResources, err := ResourcesFor(s.resources.RequirementsForStep(s.config.As))
+	if err != nil {
+		return nil, fmt.Errorf("unable to calculate %s pod resources for %s: %w", s.name, s.config.As, err)
+	}
+
+	if s.config.From.Namespace != "" {
+		return nil, errors.New("pod step does not support an image stream tag reference outside the namespace")
+	}
+	image := fmt.Sprintf("%s:%s", s.config.From.Name, s.config.From.Tag)
+
+	pod, err := s.generatePodForStep(image, containerResources, s.config.Clone)
+	if err != nil {
+		return nil, fmt.Errorf("pod step was invalid: %w", err)
+	}
+	if owner := s.jobSpec.Owner(); owner != nil {
+		pod.OwnerReferences = append(pod.OwnerReferences, *owner)
+	}
+	if s.config.Expose != nil {
+		pod.Labels[ExposedTestLabel] = s.config.As
+	}
+	if s.shard != nil {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, []coreapi.EnvVar{
+			{Name: shardIndexEnv, Value: strconv.Itoa(s.shard.index)},
+			{Name: shardTotalEnv, Value: strconv.Itoa(s.shard.total)},
+		}...)
+		if len(s.shard.tests) != 0 {
+			pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, coreapi.EnvVar{Name: shardTestsEnv, Value: strings.Join(s.shard.tests, "\n")})
+		}
+		if len(s.shard.excluded) != 0 {
+			pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, coreapi.EnvVar{Name: shardExcludedTestsEnv, Value: strings.Join(s.shard.excluded, "\n")})
+		}
+		if _, set := api.Artifacts(); set {
+			addShardTimingsArtifact(pod)
+		}
+	}
+	return pod, nil
+}
+
+// DryRun describes the pod the step would run.
+func (s *podStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	pod, err := s.pod()
+	if err != nil {
+		return nil, err
+	}
+	var actions []api.DryRunAction
+	if s.config.Expose != nil {
+		service, route := exposedObjects(s.config.As, *s.config.Expose, s.jobSpec)
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Create service %s exposing the pod", service.Name), Object: service})
+		if route != nil {
+			actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Create route %s exposing the pod", route.Name), Object: route})
+		}
+	}
+	return append(actions, api.DryRunAction{
+		Description: fmt.Sprintf("Run %s pod %s and wait for it to complete", s.name, pod.Name),
+		Object:      pod,
+	}), nil
+}
+
+// podName is the name of the pod, before it is made unique to the attempt.
+// Shards are told apart by their index.
+func (s *podStep) podName() string {
+	if s.shard != nil {
+		return fmt.Sprintf("%s-shard-%d", s.config.As, s.shard.index)
+	}
+	return s.config.As
+}
+
+// AttemptNames reports the name of the pod for this attempt.
+func (s *podStep) AttemptNames() map[string]string {
+	if name := s.jobSpec.NameForAttempt(s.podName()); name != s.podName() {
+		return map[string]string{s.podName(): name}
+	}
+	return nil
+}
+
 func (s *podStep) SubTests() []*junit.TestCase {
 	return s.subTests
 }
@@ -146,12 +271,15 @@ func (s *podStep) Creates() []api.StepLink {
 }
 
 func (s *podStep) Provides() api.ParameterMap {
-	return nil
+	return api.ParameterMapFor(s.Parameters())
 }
 
 func (s *podStep) Name() string { return s.config.As }
 
 func (s *podStep) Description() string {
+	if s.shard != nil {
+		return fmt.Sprintf("Run test %s (shard %d/%d)", s.config.As, s.shard.index+1, s.shard.total)
+	}
 	return fmt.Sprintf("Run test %s", s.config.As)
 }
 
@@ -159,8 +287,8 @@ func (s *podStep) Objects() []ctrlruntimeclient.Object {
 	return s.client.Objects()
 }
 
-func TestStep(config api.TestStepConfiguration, resources api.ResourceConfiguration, client kubernetes.PodClient, jobSpec *api.JobSpec, nodeName string) api.Step {
-	return PodStep(
+func TestStep(config api.TestStepConfiguration, resources api.ResourceConfiguration, client kubernetes.PodClient, jobSpec *api.JobSpec, params api.Parameters, nodeName string) api.Step {
+	step := PodStep(
 		"test",
 		PodStepConfiguration{
 			As:                 config.As,
@@ -170,12 +298,21 @@ func TestStep(config api.TestStepConfiguration, resources api.ResourceConfigurat
 			Secrets:            config.Secrets,
 			MemoryBackedVolume: config.ContainerTestConfiguration.MemoryBackedVolume,
 			Clone:              *config.ContainerTestConfiguration.Clone,
+			Timeout:            config.TestTimeout(),
+			Expose:             config.ContainerTestConfiguration.Expose,
+			DNSConfig:          config.ContainerTestConfiguration.DNSConfig,
+			HostAliases:        config.ContainerTestConfiguration.HostAliases,
+			RuntimeClass:       config.ContainerTestConfiguration.RuntimeClass,
+			ClusterProfile:     config.ContainerTestConfiguration.ClusterProfile,
+			Leases:             api.LeasesForContainerTest(config.ContainerTestConfiguration),
 		},
 		resources,
 		client,
 		jobSpec,
 		config.ClusterClaim,
-	)
+	).(*podStep)
+	step.params = params
+	return step
 }
 
 func PodStep(name string, config PodStepConfiguration, resources api.ResourceConfiguration, client kubernetes.PodClient, jobSpec *api.JobSpec, clusterClaim *api.ClusterClaim) api.Step {
@@ -242,7 +379,7 @@ func GenerateBasePod(
 		{Name: "GIT_CONFIG_VALUE_0", Value: "*"},
 	}...)
 
-	artifactDir = fmt.Sprintf("artifacts/%s", artifactDir)
+	artifactDir = fmt.Sprintf("artifacts/%s", jobSpec.AttemptArtifactDir(artifactDir))
 	if err := addPodUtils(pod, artifactDir, decorationConfig, rawJobSpec, secretsToCensor, generatePodOptions, jobSpec); err != nil {
 		return nil, fmt.Errorf("failed to decorate pod: %w", err)
 	}
@@ -290,7 +427,7 @@ func (s *podStep) generatePodForStep(image string, containerResources coreapi.Re
 	}
 
 	artifactDir := s.name
-	pod, err := GenerateBasePod(s.jobSpec, s.config.Labels, s.config.As,
+	pod, err := GenerateBasePod(s.jobSpec, s.config.Labels, s.jobSpec.NameForAttempt(s.podName()),
 		s.config.NodeName, s.name, []string{"/bin/bash", "-c", "#!/bin/bash\nset -eu\n" + s.config.Commands},
 		image, containerResources, artifactDir, s.jobSpec.DecorationConfig, s.jobSpec.RawSpec(),
 		secretVolumeMounts, &GeneratePodOptions{Clone: clone, PropagateExitCode: false})
@@ -307,6 +444,31 @@ func (s *podStep) generatePodForStep(image string, containerResources coreapi.Re
 		}...)
 	}
 	pod.Spec.Volumes = append(pod.Spec.Volumes, secretVolumes...)
+	if profile := s.config.ClusterProfile; profile != "" {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{
+			Name: clusterProfileVolume,
+			VolumeSource: coreapi.VolumeSource{
+				Secret: &coreapi.SecretVolumeSource{SecretName: api.ClusterProfileSecretName(s.config.As)},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, coreapi.VolumeMount{
+			Name:      clusterProfileVolume,
+			MountPath: ClusterProfileMountPath,
+		})
+		container.Env = append(container.Env, []coreapi.EnvVar{
+			{Name: api.ClusterTypeEnv, Value: profile.ClusterType()},
+			{Name: ClusterProfileMountEnv, Value: ClusterProfileMountPath},
+		}...)
+	}
+	for _, lease := range s.config.Leases {
+		value, err := s.params.Get(lease.Env)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the leased %s resources: %w", lease.ResourceType, err)
+		}
+		container.Env = append(container.Env, coreapi.EnvVar{Name: lease.Env, Value: value})
+	}
+	SetPodDNS(&pod.Spec, s.config.DNSConfig, s.config.HostAliases)
+	SetPodRuntimeClass(&pod.Spec, s.config.RuntimeClass)
 
 	if v := s.config.MemoryBackedVolume; v != nil {
 		size, err := resource.ParseQuantity(v.Size)
@@ -332,6 +494,31 @@ func (s *podStep) generatePodForStep(image string, containerResources coreapi.Re
 	return pod, nil
 }
 
+// SetPodDNS adds the DNS configuration and the /etc/hosts entries of a step
+// to the spec of its pod. Nameservers replace the DNS of the cluster.
+func SetPodDNS(spec *coreapi.PodSpec, dnsConfig *api.StepDNSConfig, hostAliases []api.StepHostAlias) {
+	if dnsConfig != nil {
+		if spec.DNSConfig == nil {
+			spec.DNSConfig = &coreapi.PodDNSConfig{}
+		}
+		spec.DNSConfig.Nameservers = append(spec.DNSConfig.Nameservers, dnsConfig.Nameservers...)
+		spec.DNSConfig.Searches = append(spec.DNSConfig.Searches, dnsConfig.Searches...)
+		if len(spec.DNSConfig.Nameservers) > 0 {
+			spec.DNSPolicy = coreapi.DNSNone
+		}
+	}
+	for _, alias := range hostAliases {
+		spec.HostAliases = append(spec.HostAliases, coreapi.HostAlias{IP: alias.IP, Hostnames: alias.Hostnames})
+	}
+}
+
+// SetPodRuntimeClass makes the pod run with the RuntimeClass, if one is set.
+func SetPodRuntimeClass(spec *coreapi.PodSpec, runtimeClass string) {
+	if runtimeClass != "" {
+		spec.RuntimeClassName = &runtimeClass
+	}
+}
+
 func getVolumeFromSecret(secretName string, secretIndex int) []coreapi.Volume {
 	volumeName := testSecretVolumePrefix
 	if secretIndex > 0 {
@@ -72,7 +72,7 @@ func preparePodStep(namespace string) (*podStep, stepExpectation) {
 	}
 	jobSpec.SetNamespace(namespace)
 
-	client := kubernetes.NewPodClient(loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build()), nil, nil, 0)
+	client := kubernetes.NewPodClient(loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build()), nil, nil, 0, nil)
 	ps := PodStep(stepName, config, resources, client, jobSpec, nil)
 
 	specification := stepExpectation{
@@ -126,7 +126,7 @@ func TestPodStepExecution(t *testing.T) {
 		t.Run(tc.purpose, func(t *testing.T) {
 			ps, _ := preparePodStep(namespace)
 			ps.config.Clone = tc.clone
-			ps.client = kubernetes.NewPodClient(loggingclient.New(&podStatusChangingClient{WithWatch: fakectrlruntimeclient.NewClientBuilder().Build(), dest: tc.podStatus}), nil, nil, 0)
+			ps.client = kubernetes.NewPodClient(loggingclient.New(&podStatusChangingClient{WithWatch: fakectrlruntimeclient.NewClientBuilder().Build(), dest: tc.podStatus}), nil, nil, 0, nil)
 
 			executionExpectation := executionExpectation{
 				prerun: doneExpectation{
@@ -151,6 +151,36 @@ func TestPodStepExecution(t *testing.T) {
 	}
 }
 
+func TestPodStepAttemptNames(t *testing.T) {
+	ps, _ := preparePodStep("TestNamespace")
+	if names := ps.AttemptNames(); names != nil {
+		t.Errorf("expected no attempt names without an attempt, got %v", names)
+	}
+	ps.jobSpec.SetAttempt("test-build-id")
+	pod, err := ps.pod()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod.Name != "TestName-test-build-id" {
+		t.Errorf("expected the pod name to include the attempt, got %s", pod.Name)
+	}
+	testhelper.Diff(t, "attempt names", ps.AttemptNames(), map[string]string{"TestName": "TestName-test-build-id"})
+}
+
+func TestPodStepAttemptNamesOfShard(t *testing.T) {
+	ps, _ := preparePodStep("TestNamespace")
+	ps.shard = &shard{index: 1, total: 2}
+	ps.jobSpec.SetAttempt("test-build-id")
+	pod, err := ps.pod()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod.Name != "TestName-shard-1-test-build-id" {
+		t.Errorf("expected the pod name to include the shard and the attempt, got %s", pod.Name)
+	}
+	testhelper.Diff(t, "attempt names", ps.AttemptNames(), map[string]string{"TestName-shard-1": "TestName-shard-1-test-build-id"})
+}
+
 func TestGetPodObjectMounts(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -228,6 +258,16 @@ func TestGetPodObjectMounts(t *testing.T) {
 				expectedPodStepTemplate.clusterClaim = &api.ClusterClaim{}
 			},
 		},
+		{
+			name: "with cluster profile",
+			podStep: func(expectedPodStepTemplate *podStep) {
+				expectedPodStepTemplate.config.ClusterProfile = api.ClusterProfileAzure4
+				expectedPodStepTemplate.config.Leases = api.LeasesForContainerTest(&api.ContainerTestConfiguration{ClusterProfile: api.ClusterProfileAzure4})
+				params := api.NewDeferredParameters(nil)
+				params.Add(api.DefaultLeaseEnv, func() (string, error) { return "azure4-quota-slice-0", nil })
+				expectedPodStepTemplate.params = params
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -242,7 +282,7 @@ func TestGetPodObjectMounts(t *testing.T) {
 
 			testhelper.CompareWithFixture(t, pod.Spec.Volumes, testhelper.WithPrefix("volumes"))
 			testhelper.CompareWithFixture(t, pod.Spec.Containers[0].VolumeMounts, testhelper.WithPrefix("mounts"))
-			if podStepTemplate.clusterClaim != nil {
+			if podStepTemplate.clusterClaim != nil || podStepTemplate.config.ClusterProfile != "" {
 				testhelper.CompareWithFixture(t, pod.Spec.Containers[0].Env, testhelper.WithPrefix("env"))
 			}
 		})
@@ -314,7 +354,7 @@ func TestTestStepAndRequires(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			actual := TestStep(tc.config, nil, nil, nil, "").Requires()
+			actual := TestStep(tc.config, nil, nil, nil, nil, "").Requires()
 			if len(actual) == len(tc.expected) {
 				matches := true
 				for i := range actual {
@@ -331,3 +371,34 @@ func TestTestStepAndRequires(t *testing.T) {
 		})
 	}
 }
+
+func TestSetPodDNS(t *testing.T) {
+	spec := &corev1.PodSpec{}
+	SetPodDNS(spec, &api.StepDNSConfig{Nameservers: []string{"10.0.0.53"}, Searches: []string{"internal"}}, []api.StepHostAlias{{IP: "10.0.0.1", Hostnames: []string{"registry.internal"}}})
+	expected := &corev1.PodSpec{
+		DNSPolicy:   corev1.DNSNone,
+		DNSConfig:   &corev1.PodDNSConfig{Nameservers: []string{"10.0.0.53"}, Searches: []string{"internal"}},
+		HostAliases: []corev1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"registry.internal"}}},
+	}
+	if diff := cmp.Diff(expected, spec); diff != "" {
+		t.Errorf("unexpected pod spec: %s", diff)
+	}
+
+	spec = &corev1.PodSpec{}
+	SetPodDNS(spec, &api.StepDNSConfig{Searches: []string{"internal"}}, nil)
+	if spec.DNSPolicy != "" {
+		t.Errorf("expected the DNS policy to be kept without nameservers, got %s", spec.DNSPolicy)
+	}
+}
+
+func TestSetPodRuntimeClass(t *testing.T) {
+	spec := &corev1.PodSpec{}
+	SetPodRuntimeClass(spec, "")
+	if spec.RuntimeClassName != nil {
+		t.Errorf("expected no runtime class, got %s", *spec.RuntimeClassName)
+	}
+	SetPodRuntimeClass(spec, "gvisor")
+	if spec.RuntimeClassName == nil || *spec.RuntimeClassName != "gvisor" {
+		t.Errorf("expected the gvisor runtime class, got %v", spec.RuntimeClassName)
+	}
+}
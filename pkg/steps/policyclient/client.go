@@ -0,0 +1,340 @@
+package policyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// Query is the Rego query evaluated against every object: policies in the
+// `ci_operator` package add the reasons an object is denied to `deny`.
+const Query = "data.ci_operator.deny"
+
+// Evaluator evaluates the policies against an object, returning the reasons
+// for which the object is denied.
+type Evaluator interface {
+	Evaluate(ctx context.Context, object map[string]interface{}) ([]string, error)
+}
+
+// queryPath is where the server serves the query.
+const queryPath = "/v1/data/ci_operator/deny"
+
+// opaStartTimeout bounds how long the `opa` server may take to load the
+// policies and start serving.
+const opaStartTimeout = 30 * time.Second
+
+// NewOPAEvaluator evaluates the Rego policies in the directory with an `opa`
+// server that loads them once and is reused for every evaluation. It serves
+// on a socket in a temporary directory and runs until it is closed.
+func NewOPAEvaluator(dir string) (*OPAEvaluator, error) {
+	socketDir, err := os.MkdirTemp("", "opa")
+	if err != nil {
+		return nil, fmt.Errorf("could not create the directory of the opa socket: %w", err)
+	}
+	socket := filepath.Join(socketDir, "opa.sock")
+	var stderr bytes.Buffer
+	cmd := exec.Command("opa", "run", "--server", "--addr", "unix://"+socket, dir)
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		_ = os.RemoveAll(socketDir)
+		return nil, fmt.Errorf("could not start opa: %w", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	evaluator := &OPAEvaluator{
+		address: "http://opa",
+		client: &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}}},
+		process:   cmd.Process,
+		exited:    exited,
+		socketDir: socketDir,
+	}
+	if err := evaluator.waitForServer(); err != nil {
+		_ = evaluator.Close()
+		return nil, fmt.Errorf("opa did not start serving the policies: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return evaluator, nil
+}
+
+// OPAEvaluator evaluates the policies with an `opa` server.
+type OPAEvaluator struct {
+	address string
+	client  *http.Client
+
+	process   *os.Process
+	exited    <-chan struct{}
+	socketDir string
+}
+
+func (e *OPAEvaluator) waitForServer() error {
+	return wait.PollImmediate(100*time.Millisecond, opaStartTimeout, func() (bool, error) {
+		select {
+		case <-e.exited:
+			return false, errors.New("opa exited")
+		default:
+		}
+		resp, err := e.client.Get(e.address + "/health")
+		if err != nil {
+			return false, nil
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK, nil
+	})
+}
+
+func (e *OPAEvaluator) Evaluate(ctx context.Context, object map[string]interface{}) ([]string, error) {
+	input, err := json.Marshal(map[string]interface{}{"input": object})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal the object: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.address+queryPath, bytes.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("could not create the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not evaluate the policies: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the policy evaluation: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not evaluate the policies: %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	return denialsFrom(raw)
+}
+
+// Close stops the server.
+func (e *OPAEvaluator) Close() error {
+	if e.process != nil {
+		if err := e.process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("could not stop opa: %w", err)
+		}
+		<-e.exited
+	}
+	return os.RemoveAll(e.socketDir)
+}
+
+// opaOutput is the part of the response of the data API of `opa` we need.
+type opaOutput struct {
+	Result interface{} `json:"result"`
+}
+
+// denialsFrom returns the messages of the deny rules from the response of
+// `opa`. An undefined query result means nothing was denied.
+func denialsFrom(raw []byte) ([]string, error) {
+	var output opaOutput
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, fmt.Errorf("could not parse the policy evaluation: %w", err)
+	}
+	if output.Result == nil {
+		return nil, nil
+	}
+	values, ok := output.Result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a set of messages, not %T", Query, output.Result)
+	}
+	var denials []string
+	for _, value := range values {
+		denials = append(denials, fmt.Sprint(value))
+	}
+	return denials, nil
+}
+
+// Evaluation records the evaluation of the policies against an object.
+type Evaluation struct {
+	Time       time.Time `json:"time"`
+	Verb       string    `json:"verb"`
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+	Denials    []string  `json:"denials,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Policy evaluates every object before it is created or updated and records
+// the evaluations in an audit log, one JSON object per line.
+type Policy struct {
+	evaluator Evaluator
+
+	lock  sync.Mutex
+	audit io.Writer
+}
+
+// New returns a policy evaluating objects with the evaluator.
+func New(evaluator Evaluator, audit io.Writer) *Policy {
+	return &Policy{evaluator: evaluator, audit: audit}
+}
+
+// Close stops the evaluator and closes the audit log, when they need to be.
+func (p *Policy) Close() error {
+	var errs []error
+	for _, resource := range []interface{}{p.evaluator, p.audit} {
+		if closer, ok := resource.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Admit evaluates the policies against the object the verb is applied to,
+// returning an error with the policy messages when it is denied. The objects
+// of template instances are evaluated as well, since they are created on our
+// behalf.
+func (p *Policy) Admit(ctx context.Context, scheme *runtime.Scheme, verb string, obj ctrlruntimeclient.Object) error {
+	object, err := toUnstructured(scheme, obj)
+	if err != nil {
+		return err
+	}
+	objects := []map[string]interface{}{object}
+	if instance, ok := obj.(*templateapi.TemplateInstance); ok {
+		for i, raw := range instance.Spec.Template.Objects {
+			var object map[string]interface{}
+			if err := yaml.Unmarshal(raw.Raw, &object); err != nil {
+				return fmt.Errorf("could not decode object %d of template instance %s: %w", i, obj.GetName(), err)
+			}
+			metadata, _ := object["metadata"].(map[string]interface{})
+			if metadata == nil {
+				metadata = map[string]interface{}{}
+				object["metadata"] = metadata
+			}
+			if _, set := metadata["namespace"]; !set {
+				metadata["namespace"] = obj.GetNamespace()
+			}
+			objects = append(objects, object)
+		}
+	}
+
+	var denials []string
+	for _, object := range objects {
+		evaluation := evaluationFor(object)
+		evaluation.Verb = verb
+		objectDenials, err := p.evaluator.Evaluate(ctx, object)
+		evaluation.Denials = objectDenials
+		if err != nil {
+			evaluation.Error = err.Error()
+		}
+		p.record(evaluation)
+		if err != nil {
+			return results.ForReason("evaluating_policy").WithError(err).Errorf("could not evaluate policies for %s %s: %v", evaluation.Kind, evaluation.Name, err)
+		}
+		for _, denial := range objectDenials {
+			denials = append(denials, fmt.Sprintf("%s %s: %s", evaluation.Kind, evaluation.Name, denial))
+		}
+	}
+	if len(denials) != 0 {
+		return results.ForReason("denied_by_policy").ForError(fmt.Errorf("%s %s %s is denied by policy: %s", verbing(verb), object["kind"], obj.GetName(), strings.Join(denials, "; ")))
+	}
+	return nil
+}
+
+// verbing returns the present participle of the verb, for messages.
+func verbing(verb string) string {
+	return strings.TrimSuffix(verb, "e") + "ing"
+}
+
+func (p *Policy) record(evaluation Evaluation) {
+	evaluation.Time = time.Now()
+	raw, err := json.Marshal(evaluation)
+	if err != nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	_, _ = p.audit.Write(append(raw, '\n'))
+}
+
+func toUnstructured(scheme *runtime.Scheme, obj ctrlruntimeclient.Object) (map[string]interface{}, error) {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert %s to unstructured: %w", obj.GetName(), err)
+	}
+	if kind, _ := object["kind"].(string); kind == "" {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the kind of %s: %w", obj.GetName(), err)
+		}
+		object["apiVersion"], object["kind"] = gvk.ToAPIVersionAndKind()
+	}
+	return object, nil
+}
+
+func evaluationFor(object map[string]interface{}) Evaluation {
+	evaluation := Evaluation{}
+	evaluation.APIVersion, _ = object["apiVersion"].(string)
+	evaluation.Kind, _ = object["kind"].(string)
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		evaluation.Namespace, _ = metadata["namespace"].(string)
+		evaluation.Name, _ = metadata["name"].(string)
+	}
+	return evaluation
+}
+
+// Wrap wraps the upstream client, admitting every object created, updated or
+// patched through it with the policy. The upstream client is returned when
+// there is no policy.
+func Wrap(upstream ctrlruntimeclient.WithWatch, policy *Policy) ctrlruntimeclient.WithWatch {
+	if policy == nil {
+		return upstream
+	}
+	return &client{WithWatch: upstream, policy: policy}
+}
+
+type client struct {
+	ctrlruntimeclient.WithWatch
+	policy *Policy
+}
+
+func (c *client) Create(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	if err := c.policy.Admit(ctx, c.Scheme(), "create", obj); err != nil {
+		return err
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+func (c *client) Update(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.UpdateOption) error {
+	if err := c.policy.Admit(ctx, c.Scheme(), "update", obj); err != nil {
+		return err
+	}
+	return c.WithWatch.Update(ctx, obj, opts...)
+}
+
+// Patch admits the object the patch is computed from, which holds the
+// result of the patch for the patches we use.
+func (c *client) Patch(ctx context.Context, obj ctrlruntimeclient.Object, patch ctrlruntimeclient.Patch, opts ...ctrlruntimeclient.PatchOption) error {
+	if err := c.policy.Admit(ctx, c.Scheme(), "patch", obj); err != nil {
+		return err
+	}
+	return c.WithWatch.Patch(ctx, obj, patch, opts...)
+}
@@ -0,0 +1,186 @@
+package policyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type fakeEvaluator struct{}
+
+// Evaluate denies privileged pods and cluster roles.
+func (fakeEvaluator) Evaluate(_ context.Context, object map[string]interface{}) ([]string, error) {
+	switch object["kind"] {
+	case "ClusterRole":
+		return []string{"cluster roles are not allowed"}, nil
+	case "Pod":
+		if strings.Contains(object["metadata"].(map[string]interface{})["name"].(string), "privileged") {
+			return []string{"privileged pods are not allowed"}, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestCreate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := coreapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := templateapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	audit := &bytes.Buffer{}
+	client := Wrap(fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).Build(), New(fakeEvaluator{}, audit))
+
+	if err := client.Create(context.Background(), &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "test"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := client.Create(context.Background(), &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "privileged"}})
+	testhelper.Diff(t, "pod error", err, errors.New("creating Pod privileged is denied by policy: Pod privileged: privileged pods are not allowed"), testhelper.EquateErrorMessage)
+
+	instance := &templateapi.TemplateInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "instance"},
+		Spec: templateapi.TemplateInstanceSpec{Template: templateapi.Template{Objects: []runtime.RawExtension{
+			{Raw: []byte(`{"kind":"ClusterRole","apiVersion":"rbac.authorization.k8s.io/v1","metadata":{"name":"admin"}}`)},
+		}}},
+	}
+	err = client.Create(context.Background(), instance)
+	testhelper.Diff(t, "template instance error", err, errors.New("creating TemplateInstance instance is denied by policy: ClusterRole admin: cluster roles are not allowed"), testhelper.EquateErrorMessage)
+
+	pod := &coreapi.Pod{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "test"}, pod); err != nil {
+		t.Fatalf("could not get pod: %v", err)
+	}
+	pod.Labels = map[string]string{"privileged": "true"}
+	pod.Name = "privileged"
+	err = client.Update(context.Background(), pod)
+	testhelper.Diff(t, "update error", err, errors.New("updating Pod privileged is denied by policy: Pod privileged: privileged pods are not allowed"), testhelper.EquateErrorMessage)
+	err = client.Patch(context.Background(), pod, ctrlruntimeclient.MergeFrom(&coreapi.Pod{}))
+	testhelper.Diff(t, "patch error", err, errors.New("patching Pod privileged is denied by policy: Pod privileged: privileged pods are not allowed"), testhelper.EquateErrorMessage)
+
+	var evaluations []Evaluation
+	for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+		var evaluation Evaluation
+		if err := json.Unmarshal([]byte(line), &evaluation); err != nil {
+			t.Fatalf("could not parse audit line %q: %v", line, err)
+		}
+		evaluation.Time = time.Time{}
+		evaluations = append(evaluations, evaluation)
+	}
+	testhelper.Diff(t, "audit", evaluations, []Evaluation{
+		{Verb: "create", APIVersion: "v1", Kind: "Pod", Namespace: "ns", Name: "test"},
+		{Verb: "create", APIVersion: "v1", Kind: "Pod", Namespace: "ns", Name: "privileged", Denials: []string{"privileged pods are not allowed"}},
+		{Verb: "create", APIVersion: "template.openshift.io/v1", Kind: "TemplateInstance", Namespace: "ns", Name: "instance"},
+		{Verb: "create", APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Namespace: "ns", Name: "admin", Denials: []string{"cluster roles are not allowed"}},
+		{Verb: "update", APIVersion: "v1", Kind: "Pod", Namespace: "ns", Name: "privileged", Denials: []string{"privileged pods are not allowed"}},
+		{Verb: "patch", APIVersion: "v1", Kind: "Pod", Namespace: "ns", Name: "privileged", Denials: []string{"privileged pods are not allowed"}},
+	})
+}
+
+func TestDenialsFrom(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		output      string
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name:     "undefined",
+			output:   `{}`,
+			expected: nil,
+		},
+		{
+			name:     "denied",
+			output:   `{"result":["no privileged pods","no host network"]}`,
+			expected: []string{"no privileged pods", "no host network"},
+		},
+		{
+			name:        "not a set",
+			output:      `{"result":true}`,
+			expectedErr: errors.New("data.ci_operator.deny must be a set of messages, not bool"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := denialsFrom([]byte(tc.output))
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			testhelper.Diff(t, "denials", actual, tc.expected)
+		})
+	}
+}
+
+func TestOPAEvaluatorEvaluate(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPost || r.URL.Path != queryPath {
+			t.Errorf("expected a POST to %s, got %s %s", queryPath, r.Method, r.URL.Path)
+		}
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("could not decode the input: %v", err)
+		}
+		if body.Input["kind"] == "ClusterRole" {
+			_, _ = w.Write([]byte(`{"result":["cluster roles are not allowed"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	evaluator := &OPAEvaluator{address: server.URL, client: server.Client()}
+	for _, tc := range []struct {
+		kind     string
+		expected []string
+	}{
+		{kind: "Pod"},
+		{kind: "ClusterRole", expected: []string{"cluster roles are not allowed"}},
+	} {
+		denials, err := evaluator.Evaluate(context.Background(), map[string]interface{}{"kind": tc.kind})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.kind, err)
+		}
+		testhelper.Diff(t, tc.kind+" denials", denials, tc.expected)
+	}
+	if requests != 2 {
+		t.Errorf("expected every evaluation to be sent to the same server, got %d requests", requests)
+	}
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestPolicyClose(t *testing.T) {
+	audit := &closeRecorder{}
+	if err := New(fakeEvaluator{}, audit).Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !audit.closed {
+		t.Error("expected the audit to be closed")
+	}
+}
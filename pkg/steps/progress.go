@@ -0,0 +1,85 @@
+package steps
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// LinkObserver may be implemented by a StepObserver to be notified when a
+// step succeeded and what it created is available to the steps that require
+// it. The notification happens before any of these steps start.
+type LinkObserver interface {
+	LinksCreated(step api.Step, links []api.StepLink)
+}
+
+// StepObserverFuncs is a StepObserver that calls the functions that are set,
+// for tools embedding the execution of the graph that only need some of the
+// notifications, like to update a UI or to persist the progress.
+type StepObserverFuncs struct {
+	OnStarted      func(step api.Step)
+	OnFinished     func(step api.Step, err error)
+	OnLinksCreated func(step api.Step, links []api.StepLink)
+}
+
+func (f StepObserverFuncs) StepStarted(step api.Step) {
+	if f.OnStarted != nil {
+		f.OnStarted(step)
+	}
+}
+
+func (f StepObserverFuncs) StepFinished(step api.Step, err error) {
+	if f.OnFinished != nil {
+		f.OnFinished(step, err)
+	}
+}
+
+func (f StepObserverFuncs) LinksCreated(step api.Step, links []api.StepLink) {
+	if f.OnLinksCreated != nil {
+		f.OnLinksCreated(step, links)
+	}
+}
+
+// ProgressLogger is a StepObserver that logs the progress of the execution.
+type ProgressLogger struct {
+	now func() time.Time
+
+	lock    sync.Mutex
+	started map[string]time.Time
+}
+
+// NewProgressLogger creates an observer logging when steps start and finish.
+func NewProgressLogger() *ProgressLogger {
+	return &ProgressLogger{now: time.Now, started: map[string]time.Time{}}
+}
+
+func (l *ProgressLogger) StepStarted(step api.Step) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.started[step.Name()] = l.now()
+	logrus.Debugf("Step %s started.", step.Name())
+}
+
+func (l *ProgressLogger) StepFinished(step api.Step, err error) {
+	l.lock.Lock()
+	duration := l.now().Sub(l.started[step.Name()]).Truncate(time.Second)
+	delete(l.started, step.Name())
+	l.lock.Unlock()
+	if err != nil {
+		logrus.Infof("Step %s failed after %s.", step.Name(), duration)
+		return
+	}
+	logrus.Infof("Step %s succeeded after %s.", step.Name(), duration)
+}
+
+func (l *ProgressLogger) LinksCreated(step api.Step, links []api.StepLink) {
+	var names []string
+	for _, link := range links {
+		names = append(names, api.LinkName(link))
+	}
+	logrus.Debugf("Step %s created %s.", step.Name(), strings.Join(names, ", "))
+}
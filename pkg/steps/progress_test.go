@@ -0,0 +1,53 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestStepObserverFuncs(t *testing.T) {
+	src := api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)
+	root := &fakeStep{name: "src", creates: []api.StepLink{src}}
+	unit := &fakeStep{name: "unit", requires: []api.StepLink{src}}
+	lint := &fakeStep{name: "lint", requires: []api.StepLink{src}, runErr: errors.New("oops")}
+
+	var lock sync.Mutex
+	var events []string
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}
+	observer := StepObserverFuncs{
+		OnStarted: func(step api.Step) { record("started " + step.Name()) },
+		OnFinished: func(step api.Step, err error) {
+			record(fmt.Sprintf("finished %s: %t", step.Name(), err != nil))
+		},
+		OnLinksCreated: func(step api.Step, links []api.StepLink) {
+			record(fmt.Sprintf("%s created %d links", step.Name(), len(links)))
+		},
+	}
+	if _, _, errs := Run(context.Background(), api.BuildGraph([]api.Step{root, unit, lint}), observer); len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+
+	// the children of the root run concurrently
+	if diff := cmp.Diff([]string{"started src", "finished src: false", "src created 1 links"}, events[:3]); diff != "" {
+		t.Errorf("unexpected events for the root: %s", diff)
+	}
+	children := map[string]bool{}
+	for _, event := range events[3:] {
+		children[event] = true
+	}
+	expected := map[string]bool{"started unit": true, "finished unit: false": true, "started lint": true, "finished lint: true": true}
+	if diff := cmp.Diff(expected, children); diff != "" {
+		t.Errorf("unexpected events for the children: %s", diff)
+	}
+}
@@ -5,8 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"path"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
 
 	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	buildapi "github.com/openshift/api/build/v1"
@@ -27,6 +34,8 @@ type projectDirectoryImageBuildStep struct {
 	podClient          kubernetes.PodClient
 	jobSpec            *api.JobSpec
 	pullSecret         *coreapi.Secret
+	// promoted is where the image built by this step is promoted to
+	promoted *api.ImageStreamTagReference
 }
 
 func (s *projectDirectoryImageBuildStep) Inputs() (api.InputDefinition, error) {
@@ -35,6 +44,8 @@ func (s *projectDirectoryImageBuildStep) Inputs() (api.InputDefinition, error) {
 
 func (s *projectDirectoryImageBuildStep) Validate() error { return nil }
 
+func (s *projectDirectoryImageBuildStep) Timeout() time.Duration { return s.config.BuildTimeout() }
+
 func (s *projectDirectoryImageBuildStep) Run(ctx context.Context) error {
 	return results.ForReason("building_project_image").ForError(s.run(ctx))
 }
@@ -50,6 +61,20 @@ func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	hash, err := s.contentHash(ctx, sourceTag)
+	if err != nil {
+		return err
+	}
+	if s.promoted != nil && s.config.ReusePromoted && hash != "" {
+		image, err := resolveIdenticalPromotedImage(ctx, s.client, *s.promoted, hash)
+		if err != nil {
+			return err
+		}
+		if image != "" {
+			LoggerFor(ctx).Infof("Nothing changed since %s was promoted, tagging it as %s instead of building.", s.promoted.ISTagName(), s.config.To)
+			return tagPromotedImage(ctx, s.client, s.jobSpec, *s.promoted, image, s.config.To)
+		}
+	}
 	build := buildFromSource(
 		s.jobSpec, s.config.From, s.config.To,
 		buildapi.BuildSource{
@@ -63,9 +88,139 @@ func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
 		s.pullSecret,
 		s.config.BuildArgs,
 	)
+	if hash != "" {
+		build.Spec.Output.ImageLabels = append(build.Spec.Output.ImageLabels, buildapi.ImageLabel{Name: ContentHashLabel, Value: hash})
+	}
+	setBuildTimeouts(build, s.config.Timeout, s.config.NoOutputTimeout)
+	if s.promoted != nil && s.config.CacheFromPromoted {
+		if err := cacheFromPromoted(ctx, s.client, build, *s.promoted); err != nil {
+			return err
+		}
+	}
+	if len(s.config.ContextExcludes) != 0 {
+		repoExcludes, err := s.repoExcludes(ctx, sourceTag)
+		if err != nil {
+			return err
+		}
+		excludes := contextExcludesConfigMap(s.config, s.jobSpec.Namespace(), repoExcludes)
+		if err := s.createContextExcludes(ctx, excludes); err != nil {
+			return err
+		}
+		addContextExcludes(build, excludes)
+	}
 	return handleBuilds(ctx, s.client, s.podClient, *build)
 }
 
+// repoExcludesPodName is the name of the pod that reads the .dockerignore of
+// the repository for the build of the image.
+func repoExcludesPodName(config api.ProjectDirectoryImageBuildStepConfiguration, jobSpec *api.JobSpec) string {
+	return jobSpec.NameForAttempt(fmt.Sprintf("%s-dockerignore", config.To))
+}
+
+// hasRepoContext determines whether the build context comes from the source
+// of the repository, rather than from an input replacing the source or from
+// the generated source of an index image.
+func hasRepoContext(config api.ProjectDirectoryImageBuildStepConfiguration, sourceTag api.PipelineImageStreamTagReference) bool {
+	_, overwritten := config.Inputs[string(sourceTag)]
+	return !overwritten && !api.IsIndexImage(string(config.To))
+}
+
+// repoExcludes reads the .dockerignore in the context directory of the
+// source, so that the excludes of the configuration extend it instead of
+// replacing it. The source is only available in the cluster, so a pod
+// running the source image prints the file.
+func (s *projectDirectoryImageBuildStep) repoExcludes(ctx context.Context, sourceTag api.PipelineImageStreamTagReference) (string, error) {
+	if !hasRepoContext(s.config, sourceTag) {
+		return "", nil
+	}
+	source := fmt.Sprintf("%s:%s", api.PipelineImageStream, sourceTag)
+	baseDir, err := getWorkingDir(s.client, source, s.jobSpec.Namespace())
+	if err != nil {
+		return "", fmt.Errorf("failed to get workingDir: %w", err)
+	}
+	pod := &coreapi.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      repoExcludesPodName(s.config, s.jobSpec),
+			Namespace: s.jobSpec.Namespace(),
+		},
+		Spec: coreapi.PodSpec{
+			RestartPolicy: coreapi.RestartPolicyNever,
+			Containers: []coreapi.Container{{
+				Name:       "dockerignore",
+				Image:      source, // the pipeline image stream resolves local references
+				Command:    []string{"/bin/sh", "-c", "if [ -f .dockerignore ]; then cat .dockerignore; fi"},
+				WorkingDir: path.Join(baseDir, s.config.ContextDir),
+			}},
+		},
+	}
+	if owner := s.jobSpec.Owner(); owner != nil {
+		pod.OwnerReferences = append(pod.OwnerReferences, *owner)
+	}
+	if _, err := RunPod(ctx, s.podClient, pod); err != nil {
+		return "", fmt.Errorf("could not read the .dockerignore of the repository: %w", err)
+	}
+	raw, err := s.podClient.GetLogs(pod.Namespace, pod.Name, &coreapi.PodLogOptions{Container: "dockerignore"}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not read the .dockerignore of the repository: %w", err)
+	}
+	return string(raw), nil
+}
+
+// contentHash resolves the digests of the images the build uses and hashes
+// them together with the source revisions and the build configuration. The
+// source image is identified by the revisions it was built from instead, as
+// its digest differs for every execution.
+func (s *projectDirectoryImageBuildStep) contentHash(ctx context.Context, sourceTag api.PipelineImageStreamTagReference) (string, error) {
+	images := map[string]string{}
+	tags := []api.PipelineImageStreamTagReference{s.config.From}
+	for name := range s.config.Inputs {
+		tags = append(tags, api.PipelineImageStreamTagReference(name))
+	}
+	for _, tag := range tags {
+		if tag == "" || tag == sourceTag {
+			continue
+		}
+		digest, err := resolvePipelineImageStreamTagReference(ctx, s.client, tag, s.jobSpec)
+		if err != nil {
+			return "", err
+		}
+		images[string(tag)] = digest
+	}
+	return contentHash(s.jobSpec, s.config, images)
+}
+
+// promotedCacheAlias is the name the promoted image used as the cache of a
+// build is pulled as. It is not used by any Dockerfile, so the image is only
+// pulled to make its layers available to the build.
+const promotedCacheAlias = "ci-operator-promoted-cache"
+
+// cacheFromPromoted lets the build reuse the layers of the previously
+// promoted image, if there is one, by pulling it as a source image of the
+// build. The build then keeps its own layers so that they can in turn be
+// reused once it is promoted.
+func cacheFromPromoted(ctx context.Context, client ctrlruntimeclient.Reader, build *buildapi.Build, promoted api.ImageStreamTagReference) error {
+	ist := &imagev1.ImageStreamTag{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: promoted.Namespace, Name: fmt.Sprintf("%s:%s", promoted.Name, promoted.Tag)}, ist); err != nil {
+		if kerrors.IsNotFound(err) {
+			logrus.Debugf("No promoted image %s to use as cache for %s, building without it.", promoted.ISTagName(), build.Name)
+			return nil
+		}
+		return fmt.Errorf("could not get promoted image %s: %w", promoted.ISTagName(), err)
+	}
+	logrus.Infof("Using promoted image %s as cache for %s", promoted.ISTagName(), build.Name)
+	strategy := build.Spec.Strategy.DockerStrategy
+	strategy.NoCache = false
+	layers := buildapi.ImageOptimizationNone
+	strategy.ImageOptimizationPolicy = &layers
+	build.Spec.Source.Images = append(build.Spec.Source.Images, buildapi.ImageSource{
+		From:       coreapi.ObjectReference{Kind: "ImageStreamTag", Namespace: promoted.Namespace, Name: fmt.Sprintf("%s:%s", promoted.Name, promoted.Tag)},
+		As:         []string{promotedCacheAlias},
+		PullSecret: strategy.PullSecret,
+	})
+	build.Annotations[CacheFromAnnotation] = promoted.ISTagName()
+	return nil
+}
+
 type workingDir func(tag string) (string, error)
 type isBundleImage func(tag string) bool
 
@@ -97,15 +252,109 @@ func imagesFor(config api.ProjectDirectoryImageBuildStepConfiguration, workingDi
 				Kind: "ImageStreamTag",
 				Name: source,
 			},
-			Paths: []buildapi.ImageSourcePath{{
-				SourcePath:     fmt.Sprintf("%s/.", path.Join(baseDir, contextDir)),
-				DestinationDir: ".",
-			}},
+			Paths: contextPaths(config, path.Join(baseDir, contextDir)),
 		})
 	}
 	return sourceTag, images, nil
 }
 
+// contextPaths determines what is copied from the source image into the build
+// context: either the whole context directory or only the included paths and
+// the Dockerfile.
+func contextPaths(config api.ProjectDirectoryImageBuildStepConfiguration, contextDir string) []buildapi.ImageSourcePath {
+	if len(config.ContextIncludes) == 0 {
+		return []buildapi.ImageSourcePath{{
+			SourcePath:     fmt.Sprintf("%s/.", contextDir),
+			DestinationDir: ".",
+		}}
+	}
+	includes := sets.New[string]()
+	for _, include := range config.ContextIncludes {
+		includes.Insert(path.Clean(include))
+	}
+	if config.DockerfileLiteral == nil {
+		dockerfile := config.DockerfilePath
+		if dockerfile == "" {
+			dockerfile = "Dockerfile"
+		}
+		dockerfile = path.Clean(dockerfile)
+		included := false
+		for include := range includes {
+			if include == "." || dockerfile == include || strings.HasPrefix(dockerfile, include+"/") {
+				included = true
+				break
+			}
+		}
+		if !included {
+			includes.Insert(dockerfile)
+		}
+	}
+	var paths []buildapi.ImageSourcePath
+	for _, include := range sets.List(includes) {
+		if include == "." {
+			return []buildapi.ImageSourcePath{{
+				SourcePath:     fmt.Sprintf("%s/.", contextDir),
+				DestinationDir: ".",
+			}}
+		}
+		paths = append(paths, buildapi.ImageSourcePath{
+			SourcePath:     path.Join(contextDir, include),
+			DestinationDir: path.Dir(include),
+		})
+	}
+	return paths
+}
+
+// contextExcludesConfigMap holds the .dockerignore that keeps the excluded
+// files out of the build context, if the image excludes any. It replaces the
+// .dockerignore of the repository, so the patterns of the repository come
+// first, and the excludes of the configuration, which take precedence, last.
+func contextExcludesConfigMap(config api.ProjectDirectoryImageBuildStepConfiguration, namespace, repoExcludes string) *coreapi.ConfigMap {
+	if len(config.ContextExcludes) == 0 {
+		return nil
+	}
+	var dockerignore string
+	if repoExcludes = strings.TrimSpace(repoExcludes); repoExcludes != "" {
+		dockerignore = repoExcludes + "\n"
+	}
+	dockerignore += strings.Join(config.ContextExcludes, "\n") + "\n"
+	yes := true
+	return &coreapi.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      fmt.Sprintf("%s-context-excludes", config.To),
+			Namespace: namespace,
+		},
+		Data:      map[string]string{".dockerignore": dockerignore},
+		Immutable: &yes,
+	}
+}
+
+// addContextExcludes makes the build copy the .dockerignore of the excludes
+// into the build context.
+func addContextExcludes(build *buildapi.Build, configMap *coreapi.ConfigMap) {
+	if configMap == nil {
+		return
+	}
+	build.Spec.Source.ConfigMaps = append(build.Spec.Source.ConfigMaps, buildapi.ConfigMapBuildSource{
+		ConfigMap:      coreapi.LocalObjectReference{Name: configMap.Name},
+		DestinationDir: ".",
+	})
+}
+
+func (s *projectDirectoryImageBuildStep) createContextExcludes(ctx context.Context, configMap *coreapi.ConfigMap) error {
+	if configMap == nil {
+		return nil
+	}
+	// delete an old configmap as it is immutable and the excludes may differ
+	if err := s.client.Delete(ctx, configMap); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("could not delete context excludes configmap %s: %w", configMap.Name, err)
+	}
+	if err := s.client.Create(ctx, configMap); err != nil {
+		return fmt.Errorf("could not create context excludes configmap %s: %w", configMap.Name, err)
+	}
+	return nil
+}
+
 func getWorkingDir(client ctrlruntimeclient.Client, source, namespace string) (string, error) {
 	ist := &imagev1.ImageStreamTag{}
 	if err := client.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: source}, ist); err != nil {
@@ -121,6 +370,56 @@ func getWorkingDir(client ctrlruntimeclient.Client, source, namespace string) (s
 	return metadata.Config.WorkingDir, nil
 }
 
+// DryRun describes the build that produces the image.
+func (s *projectDirectoryImageBuildStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	sourceTag, images, err := imagesFor(s.config, func(tag string) (string, error) {
+		return DryRunPlaceholder("working directory of " + tag), nil
+	}, s.releaseBuildConfig.IsBundleImage)
+	if err != nil {
+		return nil, err
+	}
+	build := buildFromSource(
+		s.jobSpec, s.config.From, s.config.To,
+		buildapi.BuildSource{
+			Type:       buildapi.BuildSourceImage,
+			Dockerfile: s.config.DockerfileLiteral,
+			Images:     images,
+		},
+		DryRunPlaceholder(fmt.Sprintf("digest of %s:%s", api.PipelineImageStream, sourceTag)),
+		s.config.DockerfilePath,
+		s.resources,
+		s.pullSecret,
+		s.config.BuildArgs,
+	)
+	build.Spec.Output.ImageLabels = append(build.Spec.Output.ImageLabels, buildapi.ImageLabel{Name: ContentHashLabel, Value: DryRunPlaceholder("content hash")})
+	setBuildTimeouts(build, s.config.Timeout, s.config.NoOutputTimeout)
+	var actions []api.DryRunAction
+	var repoExcludes string
+	if len(s.config.ContextExcludes) != 0 && hasRepoContext(s.config, sourceTag) {
+		repoExcludes = DryRunPlaceholder(".dockerignore of the repository")
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Run pod %s to read the .dockerignore of the repository", repoExcludesPodName(s.config, s.jobSpec))})
+	}
+	if excludes := contextExcludesConfigMap(s.config, s.jobSpec.Namespace(), repoExcludes); excludes != nil {
+		actions = append(actions, api.DryRunAction{
+			Description: fmt.Sprintf("Create configmap %s with the .dockerignore of the build context", excludes.Name),
+			Object:      excludes,
+		})
+		addContextExcludes(build, excludes)
+	}
+	description := fmt.Sprintf("Create build %s to build %s:%s", build.Name, api.PipelineImageStream, s.config.To)
+	if s.promoted == nil {
+		return append(actions, api.DryRunAction{Description: description, Object: build}), nil
+	}
+	if s.config.ReusePromoted {
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Tag %s as %s:%s instead of building if it was built from identical content", s.promoted.ISTagName(), api.PipelineImageStream, s.config.To)})
+		description = fmt.Sprintf("Otherwise, create build %s to build %s:%s", build.Name, api.PipelineImageStream, s.config.To)
+	}
+	if s.config.CacheFromPromoted {
+		description += fmt.Sprintf(", using %s as the build cache if it exists", s.promoted.ISTagName())
+	}
+	return append(actions, api.DryRunAction{Description: description, Object: build}), nil
+}
+
 func (s *projectDirectoryImageBuildStep) Requires() []api.StepLink {
 	links := []api.StepLink{
 		api.InternalImageLink(api.PipelineImageStreamTagReferenceSource),
@@ -145,12 +444,14 @@ func (s *projectDirectoryImageBuildStep) Creates() []api.StepLink {
 }
 
 func (s *projectDirectoryImageBuildStep) Provides() api.ParameterMap {
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *projectDirectoryImageBuildStep) Parameters() []api.Parameter {
 	if len(s.config.To) == 0 {
 		return nil
 	}
-	return api.ParameterMap{
-		utils.PipelineImageEnvFor(s.config.To): utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, string(s.config.To)),
-	}
+	return []api.Parameter{utils.PipelineImageParameter(s.client, s.jobSpec.Namespace, s.config.To)}
 }
 
 func (s *projectDirectoryImageBuildStep) Name() string { return s.config.TargetName() }
@@ -171,6 +472,7 @@ func ProjectDirectoryImageBuildStep(
 	podClient kubernetes.PodClient,
 	jobSpec *api.JobSpec,
 	pullSecret *coreapi.Secret,
+	promoted *api.ImageStreamTagReference,
 ) api.Step {
 	return &projectDirectoryImageBuildStep{
 		config:             config,
@@ -180,5 +482,6 @@ func ProjectDirectoryImageBuildStep(
 		podClient:          podClient,
 		jobSpec:            jobSpec,
 		pullSecret:         pullSecret,
+		promoted:           promoted,
 	}
 }
@@ -1,14 +1,21 @@
 package steps
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	buildapi "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
 )
@@ -71,6 +78,38 @@ func TestImagesFor(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "build context limited to included paths",
+			config: api.ProjectDirectoryImageBuildStepConfiguration{
+				To:              "output",
+				ContextIncludes: []string{"cmd/foo", "go.mod", "vendor/"},
+				ProjectDirectoryImageBuildInputs: api.ProjectDirectoryImageBuildInputs{
+					ContextDir:     "context",
+					DockerfilePath: "images/foo/Dockerfile",
+				},
+			},
+			workingDir: func(tag string) (string, error) {
+				return "dir", nil
+			},
+			isBundleImage: func(tag string) bool {
+				return false
+			},
+			sourceTag: api.PipelineImageStreamTagReferenceSource,
+			images: []buildapi.ImageSource{
+				{
+					From: corev1.ObjectReference{
+						Kind: "ImageStreamTag",
+						Name: "pipeline:src",
+					},
+					Paths: []buildapi.ImageSourcePath{
+						{SourcePath: "dir/context/cmd/foo", DestinationDir: "cmd"},
+						{SourcePath: "dir/context/go.mod", DestinationDir: "."},
+						{SourcePath: "dir/context/images/foo/Dockerfile", DestinationDir: "images/foo"},
+						{SourcePath: "dir/context/vendor", DestinationDir: "."},
+					},
+				},
+			},
+		},
 		{
 			name: "user overwrites input",
 			config: api.ProjectDirectoryImageBuildStepConfiguration{
@@ -266,3 +305,147 @@ func TestImagesFor(t *testing.T) {
 		})
 	}
 }
+
+func TestCacheFromPromoted(t *testing.T) {
+	promoted := api.ImageStreamTagReference{Namespace: "ocp", Name: "4.14", Tag: "component"}
+	newBuild := func() *buildapi.Build {
+		skipLayers := buildapi.ImageOptimizationSkipLayers
+		return &buildapi.Build{
+			ObjectMeta: metav1.ObjectMeta{Name: "component", Annotations: map[string]string{}},
+			Spec: buildapi.BuildSpec{CommonSpec: buildapi.CommonSpec{Strategy: buildapi.BuildStrategy{
+				DockerStrategy: &buildapi.DockerBuildStrategy{NoCache: true, ImageOptimizationPolicy: &skipLayers, PullSecret: &corev1.LocalObjectReference{Name: "regcred"}},
+			}}},
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		objects  []ctrlruntimeclient.Object
+		expected *buildapi.Build
+	}{{
+		name:     "nothing promoted yet",
+		expected: newBuild(),
+	}, {
+		name:    "promoted image is used as cache",
+		objects: []ctrlruntimeclient.Object{&imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: "4.14:component"}}},
+		expected: func() *buildapi.Build {
+			b := newBuild()
+			layers := buildapi.ImageOptimizationNone
+			b.Spec.Strategy.DockerStrategy.NoCache = false
+			b.Spec.Strategy.DockerStrategy.ImageOptimizationPolicy = &layers
+			b.Spec.Source.Images = []buildapi.ImageSource{{
+				From:       corev1.ObjectReference{Kind: "ImageStreamTag", Namespace: "ocp", Name: "4.14:component"},
+				As:         []string{"ci-operator-promoted-cache"},
+				PullSecret: &corev1.LocalObjectReference{Name: "regcred"},
+			}}
+			b.Annotations[CacheFromAnnotation] = "ocp/4.14:component"
+			return b
+		}(),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(tc.objects...).Build()
+			build := newBuild()
+			if err := cacheFromPromoted(context.Background(), client, build, promoted); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, build); diff != "" {
+				t.Errorf("unexpected build: %s", diff)
+			}
+		})
+	}
+}
+
+func TestProjectDirectoryImageBuildStepTimeout(t *testing.T) {
+	unbounded := ProjectDirectoryImageBuildStep(api.ProjectDirectoryImageBuildStepConfiguration{To: "component"}, &api.ReleaseBuildConfiguration{}, nil, nil, nil, &api.JobSpec{}, nil, nil)
+	if timeout := unbounded.(TimeoutReporter).Timeout(); timeout != 0 {
+		t.Errorf("expected the build not to be bounded without a run_timeout, got %s", timeout)
+	}
+	bounded := ProjectDirectoryImageBuildStep(api.ProjectDirectoryImageBuildStepConfiguration{To: "component", RunTimeout: &prowv1.Duration{Duration: time.Hour}}, &api.ReleaseBuildConfiguration{}, nil, nil, nil, &api.JobSpec{}, nil, nil)
+	if timeout := bounded.(TimeoutReporter).Timeout(); timeout != time.Hour {
+		t.Errorf("expected the build to be bounded by its run_timeout, got %s", timeout)
+	}
+}
+
+func TestProjectDirectoryImageBuildStepDryRun(t *testing.T) {
+	promoted := &api.ImageStreamTagReference{Namespace: "ocp", Name: "4.14", Tag: "component"}
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	for _, tc := range []struct {
+		name     string
+		promoted *api.ImageStreamTagReference
+		reuse    bool
+		cache    bool
+		expected []string
+	}{{
+		name:     "not promoted",
+		expected: []string{"Create build component to build pipeline:component"},
+	}, {
+		name:     "promoted image is not reused by default",
+		promoted: promoted,
+		expected: []string{"Create build component to build pipeline:component"},
+	}, {
+		name:     "promoted image is reused",
+		promoted: promoted,
+		reuse:    true,
+		cache:    true,
+		expected: []string{
+			"Tag ocp/4.14:component as pipeline:component instead of building if it was built from identical content",
+			"Otherwise, create build component to build pipeline:component, using ocp/4.14:component as the build cache if it exists",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			step := &projectDirectoryImageBuildStep{
+				config: api.ProjectDirectoryImageBuildStepConfiguration{
+					To:                "component",
+					ReusePromoted:     tc.reuse,
+					CacheFromPromoted: tc.cache,
+				},
+				releaseBuildConfig: &api.ReleaseBuildConfiguration{},
+				jobSpec:            jobSpec,
+				promoted:           tc.promoted,
+			}
+			actions, err := step.DryRun(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var descriptions []string
+			for _, action := range actions {
+				descriptions = append(descriptions, action.Description)
+			}
+			if diff := cmp.Diff(tc.expected, descriptions); diff != "" {
+				t.Errorf("unexpected actions: %s", diff)
+			}
+		})
+	}
+}
+
+func TestContextExcludesConfigMap(t *testing.T) {
+	config := api.ProjectDirectoryImageBuildStepConfiguration{To: "component", ContextExcludes: []string{"docs", "!docs/api.md"}}
+	for _, tc := range []struct {
+		name         string
+		config       api.ProjectDirectoryImageBuildStepConfiguration
+		repoExcludes string
+		expected     map[string]string
+	}{{
+		name:   "no excludes",
+		config: api.ProjectDirectoryImageBuildStepConfiguration{To: "component"},
+	}, {
+		name:     "no .dockerignore in the repository",
+		config:   config,
+		expected: map[string]string{".dockerignore": "docs\n!docs/api.md\n"},
+	}, {
+		name:         "excludes extend the .dockerignore of the repository",
+		config:       config,
+		repoExcludes: ".git\n*.md\n",
+		expected:     map[string]string{".dockerignore": ".git\n*.md\ndocs\n!docs/api.md\n"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual map[string]string
+			if configMap := contextExcludesConfigMap(tc.config, "ns", tc.repoExcludes); configMap != nil {
+				actual = configMap.Data
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected .dockerignore: %s", diff)
+			}
+		})
+	}
+}
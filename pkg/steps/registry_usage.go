@@ -0,0 +1,233 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// RegistryImage is the storage a single pipeline image takes.
+type RegistryImage struct {
+	Tag string `json:"tag"`
+	// Bytes is the size of the layers of the image.
+	Bytes int64 `json:"bytes"`
+	// Pruned is true when the image was deleted once no step required it.
+	Pruned bool `json:"pruned,omitempty"`
+
+	layers map[string]int64
+}
+
+// RegistryUsageReport summarizes the storage taken by the pipeline images.
+type RegistryUsageReport struct {
+	Images []RegistryImage `json:"images"`
+	// Bytes is the size of the distinct layers of the images that were not
+	// pruned, as layers shared between images are only stored once.
+	Bytes int64 `json:"bytes"`
+	// PeakBytes is the largest size the images took at any point.
+	PeakBytes int64 `json:"peakBytes"`
+	// BudgetBytes is the configured storage budget, if any.
+	BudgetBytes int64 `json:"budgetBytes,omitempty"`
+}
+
+// PrunePolicy determines when intermediate pipeline images are deleted. The
+// images are only tracked for the steps of this execution, so pruning is only
+// safe when no other execution shares the namespace.
+type PrunePolicy string
+
+const (
+	// PruneNever keeps every pipeline image.
+	PruneNever PrunePolicy = "never"
+	// PruneOverBudget deletes the unused intermediate images while the
+	// images exceed the storage budget.
+	PruneOverBudget PrunePolicy = "over-budget"
+	// PruneUnused deletes every intermediate image as soon as its last
+	// consumer succeeds.
+	PruneUnused PrunePolicy = "unused"
+)
+
+// RegistryUsage is a StepObserver that records the size of every pipeline
+// image once the step that creates it succeeds and warns when the images
+// exceed the storage budget. Depending on the policy, it deletes the
+// intermediate images that no pending step requires. It counts the
+// consumers of every image: an image is unused once each of them
+// succeeded, while one that a failed step required is kept for debugging.
+type RegistryUsage struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	budget    int64
+	policy    PrunePolicy
+	// keep are the images that are never pruned
+	keep sets.Set[string]
+
+	lock sync.Mutex
+	// consumers counts the steps that require an image and have not
+	// succeeded yet
+	consumers map[string]int
+	// failed are the images that a failed step required
+	failed   sets.Set[string]
+	images   map[string]*RegistryImage
+	peak     int64
+	exceeded bool
+}
+
+// NewRegistryUsage creates an observer for the given steps, which must
+// include every step that may still require a pipeline image, including
+// the ones that run after the graph. A budget of zero only records sizes.
+func NewRegistryUsage(client ctrlruntimeclient.Client, namespace string, budget int64, policy PrunePolicy, keep []api.PipelineImageStreamTagReference, steps []api.Step) *RegistryUsage {
+	u := &RegistryUsage{
+		client:    client,
+		namespace: namespace,
+		budget:    budget,
+		policy:    policy,
+		keep:      sets.New[string](),
+		consumers: map[string]int{},
+		failed:    sets.New[string](),
+		images:    map[string]*RegistryImage{},
+	}
+	for _, tag := range keep {
+		u.keep.Insert(string(tag))
+	}
+	for _, step := range steps {
+		for _, tag := range requiredPipelineImages(step) {
+			u.consumers[tag]++
+		}
+	}
+	return u
+}
+
+func requiredPipelineImages(step api.Step) []string {
+	var tags []string
+	for _, link := range step.Requires() {
+		if tag, ok := api.PipelineImageTagFor(link); ok {
+			tags = append(tags, string(tag))
+		}
+	}
+	return tags
+}
+
+func (u *RegistryUsage) StepStarted(api.Step) {}
+
+func (u *RegistryUsage) StepFinished(step api.Step, err error) {
+	ctx := context.TODO()
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	for _, tag := range requiredPipelineImages(step) {
+		if err != nil {
+			u.failed.Insert(tag)
+		} else {
+			u.consumers[tag]--
+		}
+	}
+	if err == nil {
+		for _, link := range step.Creates() {
+			if tag, ok := api.PipelineImageTagFor(link); ok {
+				u.record(ctx, string(tag))
+			}
+		}
+	}
+	if u.policy == PruneUnused {
+		u.pruneUnused(ctx)
+	}
+	total := u.total()
+	if total > u.peak {
+		u.peak = total
+	}
+	if u.budget <= 0 || total <= u.budget {
+		u.exceeded = false
+		return
+	}
+	if !u.exceeded {
+		logrus.Warnf("Pipeline images take %s of registry storage, exceeding the budget of %s.", formatBytes(total), formatBytes(u.budget))
+		u.exceeded = true
+	}
+	if u.policy == PruneOverBudget {
+		u.pruneUnused(ctx)
+	}
+}
+
+func (u *RegistryUsage) record(ctx context.Context, tag string) {
+	ist := &imagev1.ImageStreamTag{}
+	if err := u.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: u.namespace, Name: fmt.Sprintf("%s:%s", api.PipelineImageStream, tag)}, ist); err != nil {
+		logrus.WithError(err).Debugf("Could not determine the size of pipeline image %s.", tag)
+		return
+	}
+	image := &RegistryImage{Tag: tag, layers: map[string]int64{}}
+	for _, layer := range ist.Image.DockerImageLayers {
+		image.layers[layer.Name] = layer.LayerSize
+		image.Bytes += layer.LayerSize
+	}
+	logrus.Debugf("Pipeline image %s takes %s.", tag, formatBytes(image.Bytes))
+	u.images[tag] = image
+}
+
+// total is the size of the distinct layers of the images that still exist.
+func (u *RegistryUsage) total() int64 {
+	layers := map[string]int64{}
+	for _, image := range u.images {
+		if image.Pruned {
+			continue
+		}
+		for digest, size := range image.layers {
+			layers[digest] = size
+		}
+	}
+	var total int64
+	for _, size := range layers {
+		total += size
+	}
+	return total
+}
+
+// pruneUnused deletes the intermediate images whose consumers all
+// succeeded.
+func (u *RegistryUsage) pruneUnused(ctx context.Context) {
+	for _, tag := range sets.List(sets.KeySet(u.images)) {
+		image := u.images[tag]
+		if image.Pruned || u.keep.Has(tag) || u.consumers[tag] > 0 || u.failed.Has(tag) {
+			continue
+		}
+		ist := &imagev1.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: u.namespace, Name: fmt.Sprintf("%s:%s", api.PipelineImageStream, tag)}}
+		if err := u.client.Delete(ctx, ist); err != nil && !kerrors.IsNotFound(err) {
+			logrus.WithError(err).Warnf("Could not prune pipeline image %s.", tag)
+			continue
+		}
+		logrus.Infof("Pruned pipeline image %s (%s) as no remaining step requires it.", tag, formatBytes(image.Bytes))
+		image.Pruned = true
+	}
+}
+
+// Report summarizes the recorded sizes.
+func (u *RegistryUsage) Report() *RegistryUsageReport {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	report := &RegistryUsageReport{
+		Images:      []RegistryImage{},
+		Bytes:       u.total(),
+		PeakBytes:   u.peak,
+		BudgetBytes: u.budget,
+	}
+	for _, image := range u.images {
+		report.Images = append(report.Images, *image)
+	}
+	sort.Slice(report.Images, func(i, j int) bool {
+		return report.Images[i].Tag < report.Images[j].Tag
+	})
+	return report
+}
+
+func formatBytes(bytes int64) string {
+	return resource.NewQuantity(bytes, resource.BinarySI).String()
+}
@@ -0,0 +1,127 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestRegistryUsage(t *testing.T) {
+	ist := func(name string, layers map[string]int64) *imagev1.ImageStreamTag {
+		tag := &imagev1.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "pipeline:" + name}}
+		for digest, size := range layers {
+			tag.Image.DockerImageLayers = append(tag.Image.DockerImageLayers, imagev1.ImageLayer{Name: digest, LayerSize: size})
+		}
+		return tag
+	}
+	link := func(tag string) []api.StepLink {
+		return []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReference(tag))}
+	}
+	src := &fakeStep{name: "src", creates: link("src")}
+	bin := &fakeStep{name: "bin", requires: link("src"), creates: link("bin")}
+	image := &fakeStep{name: "image", requires: link("src"), creates: link("image")}
+	unit := &fakeStep{name: "unit", requires: link("bin")}
+	promotion := &fakeStep{name: "promotion", requires: link("image")}
+	all := []api.Step{src, bin, image, unit, promotion}
+
+	for _, tc := range []struct {
+		name     string
+		budget   int64
+		policy   PrunePolicy
+		expected *RegistryUsageReport
+		existing []string
+	}{{
+		name: "sizes are recorded without a budget",
+		expected: &RegistryUsageReport{
+			Images: []RegistryImage{
+				{Tag: "bin", Bytes: 150},
+				{Tag: "image", Bytes: 300},
+				{Tag: "src", Bytes: 100},
+			},
+			Bytes:     450,
+			PeakBytes: 450,
+		},
+		existing: []string{"bin", "image", "src"},
+	}, {
+		name:   "images are kept over budget without pruning",
+		budget: 200,
+		expected: &RegistryUsageReport{
+			Images: []RegistryImage{
+				{Tag: "bin", Bytes: 150},
+				{Tag: "image", Bytes: 300},
+				{Tag: "src", Bytes: 100},
+			},
+			Bytes:       450,
+			PeakBytes:   450,
+			BudgetBytes: 200,
+		},
+		existing: []string{"bin", "image", "src"},
+	}, {
+		name:   "unused intermediate images are pruned over budget",
+		budget: 200,
+		policy: PruneOverBudget,
+		expected: &RegistryUsageReport{
+			Images: []RegistryImage{
+				{Tag: "bin", Bytes: 150},
+				{Tag: "image", Bytes: 300},
+				{Tag: "src", Bytes: 100, Pruned: true},
+			},
+			// src shares its layers with bin, so pruning it frees nothing
+			Bytes:       450,
+			PeakBytes:   450,
+			BudgetBytes: 200,
+		},
+		existing: []string{"bin", "image"},
+	}, {
+		name:   "intermediate images are pruned once their consumers succeeded",
+		policy: PruneUnused,
+		expected: &RegistryUsageReport{
+			Images: []RegistryImage{
+				{Tag: "bin", Bytes: 150},
+				{Tag: "image", Bytes: 300},
+				{Tag: "src", Bytes: 100, Pruned: true},
+			},
+			Bytes:     450,
+			PeakBytes: 450,
+		},
+		existing: []string{"bin", "image"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+				ist("src", map[string]int64{"base": 50, "source": 50}),
+				ist("bin", map[string]int64{"base": 50, "source": 50, "binaries": 50}),
+				ist("image", map[string]int64{"image": 300}),
+			).Build()
+			usage := NewRegistryUsage(client, "ns", tc.budget, tc.policy, []api.PipelineImageStreamTagReference{"image"}, all)
+			// bin and image both require src, while the failed unit tests
+			// keep bin around
+			usage.StepFinished(src, nil)
+			usage.StepFinished(bin, nil)
+			usage.StepFinished(image, nil)
+			usage.StepFinished(unit, errors.New("failed"))
+			testhelper.Diff(t, "report", usage.Report(), tc.expected, cmpopts.IgnoreUnexported(RegistryImage{}))
+			var existing []string
+			for _, tag := range []string{"bin", "image", "src"} {
+				err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "pipeline:" + tag}, &imagev1.ImageStreamTag{})
+				if err == nil {
+					existing = append(existing, tag)
+				} else if !kerrors.IsNotFound(err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			testhelper.Diff(t, "existing images", existing, tc.existing)
+		})
+	}
+}
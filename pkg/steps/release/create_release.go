@@ -198,7 +198,7 @@ func (s *assembleReleaseStep) run(ctx context.Context) error {
 	version := fmt.Sprintf("%s.test-%s-%s-%s", prefix, now.Format("2006-01-02-150405"), s.jobSpec.Namespace(), s.name)
 
 	destination := fmt.Sprintf("%s:%s", releaseImageStreamRepo, s.name)
-	logrus.Infof("Creating release image %s.", destination)
+	steps.LoggerFor(ctx).Infof("Creating release image %s.", destination)
 	podConfig := steps.PodStepConfiguration{
 		WaitFlags: util.SkipLogs,
 		As:        fmt.Sprintf("release-%s", s.name),
@@ -252,9 +252,11 @@ func (s *assembleReleaseStep) Creates() []api.StepLink {
 }
 
 func (s *assembleReleaseStep) Provides() api.ParameterMap {
-	return api.ParameterMap{
-		utils.ReleaseImageEnv(s.name): utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.ReleaseImageStream, s.name),
-	}
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *assembleReleaseStep) Parameters() []api.Parameter {
+	return []api.Parameter{utils.ReleaseImageParameter(s.client, s.jobSpec.Namespace, s.name)}
 }
 
 func (s *assembleReleaseStep) Name() string { return s.config.TargetName(s.name) }
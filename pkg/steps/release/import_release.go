@@ -84,20 +84,10 @@ func (s *importReleaseStep) run(ctx context.Context) error {
 
 	streamName := api.ReleaseStreamFor(s.name)
 
-	logrus.Infof("Importing release image %s.", s.name)
+	steps.LoggerFor(ctx).Infof("Importing release image %s.", s.name)
 
 	// create the stable image stream with lookup policy so we have a place to put our imported images
-	err = s.client.Create(ctx, &imagev1.ImageStream{
-		ObjectMeta: meta.ObjectMeta{
-			Namespace: s.jobSpec.Namespace(),
-			Name:      streamName,
-		},
-		Spec: imagev1.ImageStreamSpec{
-			LookupPolicy: imagev1.ImageLookupPolicy{
-				Local: true,
-			},
-		},
-	})
+	err = s.client.Create(ctx, s.stableImageStream())
 	if err != nil && !kerrors.IsAlreadyExists(err) {
 		return fmt.Errorf("could not create stable imagestream: %w", err)
 	}
@@ -109,32 +99,7 @@ func (s *importReleaseStep) run(ctx context.Context) error {
 	}
 	// retry importing the image a few times because we might race against establishing credentials/roles
 	// and be unable to import images on the same cluster
-	streamImport := &imagev1.ImageStreamImport{
-		ObjectMeta: meta.ObjectMeta{
-			Namespace: s.jobSpec.Namespace(),
-			Name:      "release",
-		},
-		Spec: imagev1.ImageStreamImportSpec{
-			Import: true,
-			Images: []imagev1.ImageImportSpec{
-				{
-					To: &coreapi.LocalObjectReference{
-						Name: s.name,
-					},
-					From: coreapi.ObjectReference{
-						Kind: "DockerImage",
-						Name: pullSpec,
-					},
-					ImportPolicy: imagev1.TagImportPolicy{
-						ImportMode: imagev1.ImportModePreserveOriginal,
-					},
-					ReferencePolicy: imagev1.TagReferencePolicy{
-						Type: imagev1.LocalTagReferencePolicy,
-					},
-				},
-			},
-		},
-	}
+	streamImport := s.streamImport(pullSpec)
 	if err := wait.ExponentialBackoff(wait.Backoff{Steps: 4, Duration: 1 * time.Second, Factor: 2}, func() (bool, error) {
 		if err := s.client.Create(ctx, streamImport); err != nil {
 			if kerrors.IsConflict(err) {
@@ -371,6 +336,67 @@ func hasFailedImportCondition(conditions []imagev1.TagEventCondition, generation
 	return false
 }
 
+// stableImageStream is the image stream the images of the release are
+// tagged into, with a lookup policy so that they can be referenced by tag.
+func (s *importReleaseStep) stableImageStream() *imagev1.ImageStream {
+	return &imagev1.ImageStream{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: s.jobSpec.Namespace(),
+			Name:      api.ReleaseStreamFor(s.name),
+		},
+		Spec: imagev1.ImageStreamSpec{
+			LookupPolicy: imagev1.ImageLookupPolicy{
+				Local: true,
+			},
+		},
+	}
+}
+
+// streamImport imports the release image, locking it to a digest.
+func (s *importReleaseStep) streamImport(pullSpec string) *imagev1.ImageStreamImport {
+	return &imagev1.ImageStreamImport{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: s.jobSpec.Namespace(),
+			Name:      "release",
+		},
+		Spec: imagev1.ImageStreamImportSpec{
+			Import: true,
+			Images: []imagev1.ImageImportSpec{
+				{
+					To: &coreapi.LocalObjectReference{
+						Name: s.name,
+					},
+					From: coreapi.ObjectReference{
+						Kind: "DockerImage",
+						Name: pullSpec,
+					},
+					ImportPolicy: imagev1.TagImportPolicy{
+						ImportMode: imagev1.ImportModePreserveOriginal,
+					},
+					ReferencePolicy: imagev1.TagReferencePolicy{
+						Type: imagev1.LocalTagReferencePolicy,
+					},
+				},
+			},
+		},
+	}
+}
+
+// DryRun describes the import of the release image and the extraction of
+// its images. The pull spec of the release and its content are only known
+// when the step runs, so placeholders are used instead.
+func (s *importReleaseStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	streamName := api.ReleaseStreamFor(s.name)
+	target := fmt.Sprintf("release-images-%s", s.name)
+	return []api.DryRunAction{
+		{Description: "Set up the ci-operator service account and its role to pull and push images"},
+		{Description: fmt.Sprintf("Create image stream %s for the images of the release", streamName), Object: s.stableImageStream()},
+		{Description: fmt.Sprintf("Import release image %s", s.name), Object: s.streamImport(steps.DryRunPlaceholder("pull spec of release " + s.name))},
+		{Description: fmt.Sprintf("Run pod %s to extract the image references of the release into configmap release-%s", target, target)},
+		{Description: fmt.Sprintf("Tag the images of the release into image stream %s", streamName)},
+	}, nil
+}
+
 func (s *importReleaseStep) Requires() []api.StepLink {
 	// TODO: remove this, we need it for backwards compat
 	// but should provide a separate, direct means for
@@ -399,9 +425,11 @@ func (s *importReleaseStep) Creates() []api.StepLink {
 }
 
 func (s *importReleaseStep) Provides() api.ParameterMap {
-	return api.ParameterMap{
-		utils.ReleaseImageEnv(s.name): utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.ReleaseImageStream, s.name),
-	}
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *importReleaseStep) Parameters() []api.Parameter {
+	return []api.Parameter{utils.ReleaseImageParameter(s.client, s.jobSpec.Namespace, s.name)}
 }
 
 func (s *importReleaseStep) Name() string { return s.target }
@@ -483,7 +511,7 @@ func (s *importReleaseStep) getCLIImage(ctx context.Context, target, streamName
 		return &api.ImageStreamTagReference{Name: overrideCLIStreamName, Tag: "latest"}, nil
 	}
 
-	targetCLI := fmt.Sprintf("%s-cli", target)
+	targetCLI := s.jobSpec.NameForAttempt(fmt.Sprintf("%s-cli", target))
 	if _, err := steps.RunPod(ctx, s.client, &coreapi.Pod{
 		ObjectMeta: meta.ObjectMeta{
 			Name:      targetCLI,
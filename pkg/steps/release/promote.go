@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -42,6 +43,9 @@ type promotionStep struct {
 	registry       string
 	mirrorFunc     func(source, target string, tag api.ImageStreamTagReference, date string, imageMirror map[string]string)
 	targetNameFunc func(string, api.PromotionTarget) string
+	// lookupDigest resolves the image a reference points to in its
+	// registry, registryDigest unless testing
+	lookupDigest func(ctx context.Context, reference string) (string, error)
 }
 
 func (s *promotionStep) Inputs() (api.InputDefinition, error) {
@@ -65,16 +69,12 @@ func mainRefs(refs *prowapi.Refs, extra []prowapi.Refs) *prowapi.Refs {
 }
 
 func (s *promotionStep) run(ctx context.Context) error {
-	opts := []PromotedTagsOption{
-		WithRequiredImages(s.requiredImages),
-	}
 	logger := logrus.WithField("name", s.name)
-
-	if refs := mainRefs(s.jobSpec.Refs, s.jobSpec.ExtraRefs); refs != nil {
-		opts = append(opts, WithCommitSha(refs.BaseSHA))
+	date := time.Now().Format("20060102")
+	tags, names, err := s.promotedTags(date)
+	if err != nil {
+		return err
 	}
-
-	tags, names := PromotedTagsWithRequiredImages(s.configuration, opts...)
 	if len(names) == 0 {
 		logger.Info("Nothing to promote, skipping...")
 		return nil
@@ -89,7 +89,6 @@ func (s *promotionStep) run(ctx context.Context) error {
 		return fmt.Errorf("could not resolve pipeline imagestream: %w", err)
 	}
 
-	date := time.Now().Format("20060102")
 	imageMirrorTarget, namespaces := getImageMirrorTarget(tags, pipeline, s.registry, date, s.mirrorFunc)
 	if len(imageMirrorTarget) == 0 {
 		logger.Info("Nothing to promote, skipping...")
@@ -102,12 +101,223 @@ func (s *promotionStep) run(ctx context.Context) error {
 		logger.WithError(err).Warn("Failed to ensure namespaces to promote to in central registry.")
 	}
 
-	if _, err := steps.RunPod(ctx, s.client, getPromotionPod(imageMirrorTarget, s.jobSpec.Namespace(), s.name)); err != nil {
-		return fmt.Errorf("unable to run promotion pod: %w", err)
+	transaction, err := s.beginTransaction(ctx, tags, pipeline)
+	if err != nil {
+		return fmt.Errorf("could not record the promotion: %w", err)
+	}
+	if _, err := steps.RunPod(ctx, s.client, getPromotionPod(imageMirrorTarget, s.jobSpec.Namespace(), s.jobSpec.NameForAttempt(s.name))); err != nil {
+		return s.rollback(ctx, transaction, fmt.Errorf("unable to run promotion pod: %w", err))
+	}
+	if transaction == nil {
+		return nil
+	}
+	if err := transaction.verify(ctx); err != nil {
+		return s.rollback(ctx, transaction, fmt.Errorf("not all images were promoted: %w", err))
+	}
+	if err := transaction.commit(ctx); err != nil {
+		logger.WithError(err).Warn("Failed to remove the promotion record.")
 	}
 	return nil
 }
 
+// promotedTags determines the tags the images are promoted to on the date. The
+// promotion fails when a tag template does not render a valid tag, rather than
+// promoting the images without the tag.
+func (s *promotionStep) promotedTags(date string) (map[string][]api.ImageStreamTagReference, sets.Set[string], error) {
+	opts := []PromotedTagsOption{
+		WithRequiredImages(s.requiredImages),
+	}
+	if refs := mainRefs(s.jobSpec.Refs, s.jobSpec.ExtraRefs); refs != nil {
+		data := api.PromotionTagTemplateData{
+			Commit: refs.BaseSHA,
+			Branch: refs.BaseRef,
+			Date:   date,
+		}
+		if s.configuration != nil {
+			for _, target := range api.PromotionTargets(s.configuration.PromotionConfiguration) {
+				for _, tagTemplate := range target.TagTemplates {
+					if _, err := api.RenderPromotionTagTemplate(tagTemplate, data); err != nil {
+						return nil, nil, fmt.Errorf("could not determine the tags to promote to: %w", err)
+					}
+				}
+			}
+		}
+		opts = append(opts, WithCommitSha(refs.BaseSHA), WithTagTemplateData(data))
+	}
+	tags, names := PromotedTagsWithRequiredImages(s.configuration, opts...)
+	return tags, names, nil
+}
+
+// DryRun describes the images that would be mirrored and the pod that mirrors
+// them. The pull specs of the pipeline images are only known once they are
+// built, so placeholders are used instead.
+func (s *promotionStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	date := time.Now().Format("20060102")
+	tags, names, err := s.promotedTags(date)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return []api.DryRunAction{{Description: "Nothing to promote"}}, nil
+	}
+	pipeline := &imagev1.ImageStream{}
+	for _, name := range sets.List(names) {
+		pipeline.Status.Tags = append(pipeline.Status.Tags, imagev1.NamedTagEventList{
+			Tag:   name,
+			Items: []imagev1.TagEvent{{DockerImageReference: fmt.Sprintf("<%s:%s>", api.PipelineImageStream, name)}},
+		})
+	}
+	mirrorFunc := s.mirrorFunc
+	if s.registry == api.QuayOpenShiftCIRepo {
+		// the tags in quay.io are derived from the digests of the images,
+		// which are not known yet
+		mirrorFunc = api.DefaultMirrorFunc
+	}
+	imageMirrorTarget, _ := getImageMirrorTarget(tags, pipeline, s.registry, date, mirrorFunc)
+	var actions []api.DryRunAction
+	for _, target := range sets.List(sets.KeySet(imageMirrorTarget)) {
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Mirror %s to %s", imageMirrorTarget[target], target)})
+	}
+	if s.transactional() {
+		actions = append(actions, api.DryRunAction{Description: fmt.Sprintf("Record the promotion on the image streams in %s and roll it back if it does not complete", s.registry)})
+	}
+	return append(actions, api.DryRunAction{
+		Description: "Run the pod that mirrors the images",
+		Object:      getPromotionPod(imageMirrorTarget, s.jobSpec.Namespace(), s.jobSpec.NameForAttempt(s.name)),
+	}), nil
+}
+
+// PromotionPlanner describes what a promotion would change, once the images
+// to promote are in the pipeline image stream.
+type PromotionPlanner interface {
+	PlanPromotion(ctx context.Context) ([]string, error)
+}
+
+// PlanPromotion resolves the images in the pipeline image stream and
+// describes the tags the promotion would create or overwrite, as recorded
+// on the image streams of the central registry or as served by the registry
+// the images are mirrored to otherwise.
+func (s *promotionStep) PlanPromotion(ctx context.Context) ([]string, error) {
+	date := time.Now().Format("20060102")
+	tags, names, err := s.promotedTags(date)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return []string{"Nothing to promote"}, nil
+	}
+	pipeline := &imagev1.ImageStream{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{
+		Namespace: s.jobSpec.Namespace(),
+		Name:      api.PipelineImageStream,
+	}, pipeline); err != nil {
+		return nil, fmt.Errorf("could not resolve pipeline imagestream: %w", err)
+	}
+	var plan []string
+	for _, name := range sets.List(names) {
+		if findDockerImageReference(pipeline, name) == "" {
+			plan = append(plan, fmt.Sprintf("Skip %s, which is not in the pipeline image stream", name))
+		}
+	}
+	if s.transactional() {
+		config, err := s.appCIKubeconfig()
+		if err != nil {
+			return nil, err
+		}
+		client, err := ctrlruntimeclient.New(config, ctrlruntimeclient.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct client: %w", err)
+		}
+		changes, err := newPromotionTransaction(client, fmt.Sprintf("%s/%s", s.jobSpec.Job, s.jobSpec.BuildID), tags, pipeline).plan(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return append(plan, changes...), nil
+	}
+	imageMirrorTarget, _ := getImageMirrorTarget(tags, pipeline, s.registry, date, s.mirrorFunc)
+	for _, target := range sets.List(sets.KeySet(imageMirrorTarget)) {
+		plan = append(plan, s.planMirror(ctx, imageMirrorTarget[target], target))
+	}
+	return plan, nil
+}
+
+// planMirror describes what mirroring the source to the target changes, by
+// looking up the image the target points to in its registry.
+func (s *promotionStep) planMirror(ctx context.Context, source, target string) string {
+	lookupDigest := s.lookupDigest
+	if lookupDigest == nil {
+		lookupDigest = s.registryDigest
+	}
+	intended := source
+	if i := strings.LastIndex(source, "@"); i != -1 {
+		intended = source[i+1:]
+	}
+	switch previous, err := lookupDigest(ctx, target); {
+	case err != nil:
+		return fmt.Sprintf("Mirror %s to %s, which could not be looked up: %v", source, target, err)
+	case previous == "":
+		return fmt.Sprintf("Create %s pointing to %s", target, intended)
+	case previous == intended:
+		return fmt.Sprintf("Keep %s, which already points to %s", target, intended)
+	default:
+		return fmt.Sprintf("Overwrite %s, which points to %s, with %s", target, previous, intended)
+	}
+}
+
+// registryDigest returns the digest of the image the reference points to in
+// its registry, or nothing when it does not exist. The push secret is used
+// to authenticate when it holds credentials for the registry.
+func (s *promotionStep) registryDigest(ctx context.Context, reference string) (string, error) {
+	var dockercfg credentialprovider.DockerConfigJSON
+	if s.pushSecret != nil {
+		if err := json.Unmarshal(s.pushSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
+			return "", fmt.Errorf("failed to deserialize push secret: %w", err)
+		}
+	}
+	return manifestDigest(ctx, http.DefaultClient, reference, func(registry string) (string, string) {
+		entry := dockercfg.Auths[registry]
+		return entry.Username, entry.Password
+	})
+}
+
+// transactional determines whether the promotion is tracked. Only the central
+// registry exposes image streams, so promotions to other registries are not.
+func (s *promotionStep) transactional() bool {
+	return s.registry == api.ServiceDomainAPPCIRegistry && s.configuration.PromotionConfiguration.RegistryOverride == "" && s.pushSecret != nil
+}
+
+// beginTransaction records the promotion on the image streams in the central
+// registry before any tags are changed, so that it can be rolled back.
+func (s *promotionStep) beginTransaction(ctx context.Context, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) (*promotionTransaction, error) {
+	if !s.transactional() {
+		return nil, nil
+	}
+	config, err := s.appCIKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := ctrlruntimeclient.New(config, ctrlruntimeclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct client: %w", err)
+	}
+	transaction := newPromotionTransaction(client, fmt.Sprintf("%s/%s", s.jobSpec.Job, s.jobSpec.BuildID), tags, pipeline)
+	if err := transaction.begin(ctx); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (s *promotionStep) rollback(ctx context.Context, transaction *promotionTransaction, err error) error {
+	if transaction == nil {
+		return err
+	}
+	logrus.WithError(err).Warn("Promotion failed, restoring the previous tags.")
+	if rollbackErr := transaction.rollback(ctx); rollbackErr != nil {
+		return utilerrors.NewAggregate([]error{err, fmt.Errorf("failed to restore the previous tags: %w", rollbackErr)})
+	}
+	return err
+}
+
 func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.Set[string]) error {
 	if len(namespaces) == 0 {
 		return nil
@@ -117,17 +327,10 @@ func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.Se
 	if s.configuration.PromotionConfiguration.RegistryOverride != "" {
 		return nil
 	}
-	var dockercfg credentialprovider.DockerConfigJSON
-	if err := json.Unmarshal(s.pushSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
-		return fmt.Errorf("failed to deserialize push secret: %w", err)
-	}
-
-	appCIDockercfg, hasAppCIDockercfg := dockercfg.Auths[api.ServiceDomainAPPCIRegistry]
-	if !hasAppCIDockercfg {
-		return fmt.Errorf("push secret has no entry for %s", api.ServiceDomainAPPCIRegistry)
+	appCIKubeconfig, err := s.appCIKubeconfig()
+	if err != nil {
+		return err
 	}
-
-	appCIKubeconfig := &rest.Config{Host: api.APPCIKubeAPIURL, BearerToken: appCIDockercfg.Password}
 	client, err := corev1client.NewForConfig(appCIKubeconfig)
 	if err != nil {
 		return fmt.Errorf("failed to construct kubeconfig: %w", err)
@@ -152,6 +355,22 @@ func (s *promotionStep) ensureNamespaces(ctx context.Context, namespaces sets.Se
 	return nil
 }
 
+// appCIKubeconfig authenticates to the cluster hosting the central registry
+// with the credentials we push images with.
+func (s *promotionStep) appCIKubeconfig() (*rest.Config, error) {
+	var dockercfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(s.pushSecret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
+		return nil, fmt.Errorf("failed to deserialize push secret: %w", err)
+	}
+
+	appCIDockercfg, hasAppCIDockercfg := dockercfg.Auths[api.ServiceDomainAPPCIRegistry]
+	if !hasAppCIDockercfg {
+		return nil, fmt.Errorf("push secret has no entry for %s", api.ServiceDomainAPPCIRegistry)
+	}
+
+	return &rest.Config{Host: api.APPCIKubeAPIURL, BearerToken: appCIDockercfg.Password}, nil
+}
+
 func getImageMirrorTarget(tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream, registry string, date string, mirrorFunc func(source, target string, tag api.ImageStreamTagReference, date string, imageMirror map[string]string)) (map[string]string, sets.Set[string]) {
 	if pipeline == nil {
 		return nil, nil
@@ -200,6 +419,11 @@ func getPublicImageReference(dockerImageReference, publicDockerImageRepository s
 }
 
 func getPromotionPod(imageMirrorTarget map[string]string, namespace string, name string) *coreapi.Pod {
+	return getMirrorPod(imageMirrorTarget, namespace, name, "promotion", api.RegistryPushCredentialsCICentralSecret)
+}
+
+// getMirrorPod returns a pod that mirrors the images with the push credentials in the secret.
+func getMirrorPod(imageMirrorTarget map[string]string, namespace, name, container, secret string) *coreapi.Pod {
 	keys := make([]string, 0, len(imageMirrorTarget))
 	for k := range imageMirrorTarget {
 		keys = append(keys, k)
@@ -221,14 +445,14 @@ func getPromotionPod(imageMirrorTarget map[string]string, namespace string, name
 			RestartPolicy: coreapi.RestartPolicyNever,
 			Containers: []coreapi.Container{
 				{
-					Name:    "promotion",
+					Name:    container,
 					Image:   fmt.Sprintf("%s/%s/4.12:cli", api.DomainForService(api.ServiceRegistry), "ocp"),
 					Command: command,
 					Args:    args,
 					VolumeMounts: []coreapi.VolumeMount{
 						{
 							Name:      "push-secret",
-							MountPath: "/etc/push-secret",
+							MountPath: api.RegistryPushCredentialsCICentralSecretMountPath,
 							ReadOnly:  true,
 						},
 					},
@@ -238,7 +462,7 @@ func getPromotionPod(imageMirrorTarget map[string]string, namespace string, name
 				{
 					Name: "push-secret",
 					VolumeSource: coreapi.VolumeSource{
-						Secret: &coreapi.SecretVolumeSource{SecretName: api.RegistryPushCredentialsCICentralSecret},
+						Secret: &coreapi.SecretVolumeSource{SecretName: secret},
 					},
 				},
 			},
@@ -306,6 +530,7 @@ func PromotedTags(configuration *api.ReleaseBuildConfiguration) []api.ImageStrea
 type PromotedTagsOptions struct {
 	requiredImages sets.Set[string]
 	commitSha      string
+	templateData   *api.PromotionTagTemplateData
 }
 
 type PromotedTagsOption func(options *PromotedTagsOptions)
@@ -324,6 +549,13 @@ func WithCommitSha(commitSha string) PromotedTagsOption {
 	}
 }
 
+// WithTagTemplateData renders the tag templates in the configuration with the given data.
+func WithTagTemplateData(data api.PromotionTagTemplateData) PromotedTagsOption {
+	return func(options *PromotedTagsOptions) {
+		options.templateData = &data
+	}
+}
+
 // PromotedTagsWithRequiredImages returns the tags that are being promoted for the given ReleaseBuildConfiguration
 // accounting for the list of required images. Promoted tags are mapped by the source tag in the pipeline ImageStream
 // we will promote to the output.
@@ -368,6 +600,20 @@ func PromotedTagsWithRequiredImages(configuration *api.ReleaseBuildConfiguration
 					Tag:       opts.commitSha,
 				})
 			}
+			if opts.templateData != nil {
+				for _, tagTemplate := range target.TagTemplates {
+					rendered, err := api.RenderPromotionTagTemplate(tagTemplate, *opts.templateData)
+					if err != nil {
+						logrus.WithError(err).Warnf("Not promoting %s with a templated tag.", dst)
+						continue
+					}
+					promotedTags[src] = append(promotedTags[src], api.ImageStreamTagReference{
+						Namespace: target.Namespace,
+						Name:      dst,
+						Tag:       rendered,
+					})
+				}
+			}
 		}
 	}
 	// promote the binary build if one exists and this isn't disabled
@@ -396,6 +642,10 @@ func (s *promotionStep) Provides() api.ParameterMap {
 
 func (s *promotionStep) Name() string { return fmt.Sprintf("[%s]", s.name) }
 
+func (s *promotionStep) RunIf() api.RunCondition {
+	return s.configuration.PromotionConfiguration.RunIf
+}
+
 func (s *promotionStep) targets() string {
 	var targets []string
 	for _, target := range api.PromotionTargets(s.configuration.PromotionConfiguration) {
@@ -1,6 +1,8 @@
 package release
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"sort"
 	"testing"
@@ -9,6 +11,8 @@ import (
 
 	coreapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 	"k8s.io/utils/diff"
 
 	imageapi "github.com/openshift/api/image/v1"
@@ -410,6 +414,28 @@ func TestPromotedTagsWithRequiredImages(t *testing.T) {
 			},
 			expectedRequiredImages: sets.New[string]("foo"),
 		},
+		{
+			name: "promoted image with tag templates means additional tags",
+			input: &api.ReleaseBuildConfiguration{
+				Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+					{To: api.PipelineImageStreamTagReference("foo")},
+				},
+				PromotionConfiguration: &api.PromotionConfiguration{
+					Namespace:    "roger",
+					Tag:          "latest",
+					TagTemplates: []string{"sha-{{.Commit}}", "{{.Branch}}-{{.Date}}", "{{.Branch}}/invalid"},
+				},
+			},
+			options: []PromotedTagsOption{WithTagTemplateData(api.PromotionTagTemplateData{Commit: "sha", Branch: "release/4.14", Date: "20230101"})},
+			expected: map[string][]api.ImageStreamTagReference{
+				"foo": {
+					{Namespace: "roger", Name: "foo", Tag: "latest"},
+					{Namespace: "roger", Name: "foo", Tag: "release-4.14-20230101"},
+					{Namespace: "roger", Name: "foo", Tag: "sha-sha"},
+				},
+			},
+			expectedRequiredImages: sets.New[string]("foo"),
+		},
 		{
 			name: "promoted additional image with rename",
 			input: &api.ReleaseBuildConfiguration{
@@ -867,3 +893,96 @@ func TestGetPublicImageReference(t *testing.T) {
 		})
 	}
 }
+
+func TestPromotionStepDryRun(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ci-op-test")
+	step := &promotionStep{
+		name: "promotion",
+		configuration: &api.ReleaseBuildConfiguration{
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+				{To: api.PipelineImageStreamTagReference("foo")},
+				{To: api.PipelineImageStreamTagReference("bar")},
+			},
+			PromotionConfiguration: &api.PromotionConfiguration{
+				Namespace: "roger",
+				Name:      "fred",
+			},
+		},
+		jobSpec:    jobSpec,
+		registry:   "registry.ci.openshift.org",
+		mirrorFunc: api.DefaultMirrorFunc,
+	}
+	actions, err := step.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var descriptions []string
+	for _, action := range actions {
+		descriptions = append(descriptions, action.Description)
+	}
+	expected := []string{
+		"Mirror <pipeline:bar> to registry.ci.openshift.org/roger/fred:bar",
+		"Mirror <pipeline:foo> to registry.ci.openshift.org/roger/fred:foo",
+		"Run the pod that mirrors the images",
+	}
+	testhelper.Diff(t, "descriptions", descriptions, expected)
+	if actions[len(actions)-1].Object == nil {
+		t.Error("expected the promotion pod to be described")
+	}
+}
+
+func TestPromotionStepPromotedTags(t *testing.T) {
+	step := &promotionStep{
+		configuration: &api.ReleaseBuildConfiguration{
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+				{To: api.PipelineImageStreamTagReference("foo")},
+			},
+			PromotionConfiguration: &api.PromotionConfiguration{
+				Namespace:    "roger",
+				Tag:          "latest",
+				TagTemplates: []string{"{{.Branch}}-{{.Date}}"},
+			},
+		},
+		jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowapi.Refs{BaseSHA: "sha", BaseRef: "release/4.14"}}},
+	}
+	tags, _, err := step.promotedTags("20230101")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "tags", tags, map[string][]api.ImageStreamTagReference{
+		"foo": {
+			{Namespace: "roger", Name: "foo", Tag: "latest"},
+			{Namespace: "roger", Name: "foo", Tag: "release-4.14-20230101"},
+		},
+	})
+
+	step.configuration.PromotionConfiguration.TagTemplates = []string{"{{.Branch}}"}
+	step.jobSpec.Refs.BaseRef = ""
+	_, _, err = step.promotedTags("20230101")
+	testhelper.Diff(t, "error", err, errors.New(`could not determine the tags to promote to: tag template "{{.Branch}}" rendered an invalid tag ""`), testhelper.EquateErrorMessage)
+}
+
+func TestPromotionStepPlanMirror(t *testing.T) {
+	step := &promotionStep{lookupDigest: func(_ context.Context, reference string) (string, error) {
+		switch reference {
+		case "quay.io/org/same:latest":
+			return "sha256:new", nil
+		case "quay.io/org/other:latest":
+			return "sha256:old", nil
+		case "quay.io/org/broken:latest":
+			return "", errors.New("unauthorized")
+		}
+		return "", nil
+	}}
+	var plan []string
+	for _, target := range []string{"quay.io/org/new:latest", "quay.io/org/same:latest", "quay.io/org/other:latest", "quay.io/org/broken:latest"} {
+		plan = append(plan, step.planMirror(context.Background(), "registry/ci-op/pipeline@sha256:new", target))
+	}
+	testhelper.Diff(t, "plan", plan, []string{
+		"Create quay.io/org/new:latest pointing to sha256:new",
+		"Keep quay.io/org/same:latest, which already points to sha256:new",
+		"Overwrite quay.io/org/other:latest, which points to sha256:old, with sha256:new",
+		"Mirror registry/ci-op/pipeline@sha256:new to quay.io/org/broken:latest, which could not be looked up: unauthorized",
+	})
+}
@@ -0,0 +1,346 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// PromotionTransactionAnnotation is set on the image streams we promote to
+// while the promotion is in progress. It records the tags we intend to set
+// as well as the images they pointed to before, so that a promotion that
+// does not complete can be rolled back, even by a later execution.
+const PromotionTransactionAnnotation = "ci.openshift.io/promotion-transaction"
+
+// promotionTransactionDeadline is how long a promotion recorded by another
+// execution is considered to be in progress. Only once it expires is the
+// promotion considered abandoned and rolled back.
+var promotionTransactionDeadline = time.Hour
+
+// promotionRecord is the content of the transaction annotation.
+type promotionRecord struct {
+	// Job identifies the execution that started the promotion.
+	Job string `json:"job"`
+	// Started is when the promotion started.
+	Started meta.Time `json:"started"`
+	// Created is set when the promotion created the image stream, which is
+	// then deleted when the promotion is rolled back.
+	Created bool `json:"created,omitempty"`
+	// Tags maps the tags in the image stream to their states.
+	Tags map[string]promotedTagRecord `json:"tags"`
+}
+
+type promotedTagRecord struct {
+	// Intended is the image the tag is promoted to.
+	Intended string `json:"intended"`
+	// Previous is the image the tag pointed to before the promotion, empty
+	// if the tag did not exist.
+	Previous string `json:"previous,omitempty"`
+}
+
+// promotionTransaction tracks a promotion to image streams in the cluster
+// that hosts the central registry.
+type promotionTransaction struct {
+	client ctrlruntimeclient.Client
+	job    string
+	// records holds the state of every image stream, by namespace/name
+	records map[ctrlruntimeclient.ObjectKey]*promotionRecord
+	now     func() time.Time
+}
+
+// newPromotionTransaction determines the images every promoted tag should
+// point to once the promotion succeeds.
+func newPromotionTransaction(client ctrlruntimeclient.Client, job string, tags map[string][]api.ImageStreamTagReference, pipeline *imagev1.ImageStream) *promotionTransaction {
+	t := &promotionTransaction{client: client, job: job, records: map[ctrlruntimeclient.ObjectKey]*promotionRecord{}, now: time.Now}
+	for src, dsts := range tags {
+		image := findImage(pipeline, src)
+		if image == "" {
+			continue
+		}
+		for _, dst := range dsts {
+			key := ctrlruntimeclient.ObjectKey{Namespace: dst.Namespace, Name: dst.Name}
+			if t.records[key] == nil {
+				t.records[key] = &promotionRecord{Job: job, Tags: map[string]promotedTagRecord{}}
+			}
+			t.records[key].Tags[dst.Tag] = promotedTagRecord{Intended: image}
+		}
+	}
+	return t
+}
+
+// findImage returns the name of the image a tag in the stream resolves to.
+func findImage(is *imagev1.ImageStream, tag string) string {
+	for _, t := range is.Status.Tags {
+		if t.Tag == tag && len(t.Items) > 0 {
+			return t.Items[0].Image
+		}
+	}
+	return ""
+}
+
+func (t *promotionTransaction) keys() []ctrlruntimeclient.ObjectKey {
+	var keys []ctrlruntimeclient.ObjectKey
+	for key := range t.records {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// begin rolls back any abandoned promotion to the same image streams, records
+// the current state of every tag and then records the transaction on the image
+// streams. Image streams that do not exist yet are created, so that the record
+// can be stored and the streams deleted on rollback. A promotion by another
+// execution that started less than promotionTransactionDeadline ago may still
+// be in progress, so it is not rolled back and the promotion fails instead.
+func (t *promotionTransaction) begin(ctx context.Context) error {
+	now := t.now()
+	started := meta.NewTime(now)
+	for _, key := range t.keys() {
+		record := t.records[key]
+		record.Started = started
+		is := &imagev1.ImageStream{}
+		if err := t.client.Get(ctx, key, is); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("could not get image stream %s: %w", key, err)
+			}
+			// every tag is new
+			record.Created = true
+			if err := t.create(ctx, key, record); err != nil {
+				return err
+			}
+			continue
+		}
+		if raw, ok := is.Annotations[PromotionTransactionAnnotation]; ok {
+			var stale promotionRecord
+			if err := json.Unmarshal([]byte(raw), &stale); err != nil {
+				return fmt.Errorf("could not parse promotion record on image stream %s: %w", key, err)
+			}
+			if deadline := stale.Started.Add(promotionTransactionDeadline); stale.Job != t.job && now.Before(deadline) {
+				return fmt.Errorf("promotion to %s by %s started at %s may still be in progress, it will be considered abandoned after %s", key, stale.Job, stale.Started.UTC().Format(time.RFC3339), deadline.UTC().Format(time.RFC3339))
+			}
+			logrus.Warnf("Promotion to %s by %s did not complete, rolling it back.", key, stale.Job)
+			if err := t.restore(ctx, key, &stale); err != nil {
+				return fmt.Errorf("could not roll back incomplete promotion to %s: %w", key, err)
+			}
+			if stale.Created {
+				record.Created = true
+				if err := t.create(ctx, key, record); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := t.client.Get(ctx, key, is); err != nil {
+				return fmt.Errorf("could not get image stream %s: %w", key, err)
+			}
+		}
+		for tag, state := range record.Tags {
+			state.Previous = findImage(is, tag)
+			record.Tags[tag] = state
+		}
+		if err := t.annotate(ctx, key, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create creates the image stream with the record of the transaction.
+func (t *promotionTransaction) create(ctx context.Context, key ctrlruntimeclient.ObjectKey, record *promotionRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not marshal promotion record: %w", err)
+	}
+	is := &imagev1.ImageStream{ObjectMeta: meta.ObjectMeta{
+		Namespace:   key.Namespace,
+		Name:        key.Name,
+		Annotations: map[string]string{PromotionTransactionAnnotation: string(raw)},
+	}}
+	if err := t.client.Create(ctx, is); err != nil {
+		return fmt.Errorf("could not create image stream %s: %w", key, err)
+	}
+	return nil
+}
+
+// plan describes how every tag would change, without changing anything.
+func (t *promotionTransaction) plan(ctx context.Context) ([]string, error) {
+	var plan []string
+	for _, key := range t.keys() {
+		is := &imagev1.ImageStream{}
+		if err := t.client.Get(ctx, key, is); err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("could not get image stream %s: %w", key, err)
+		}
+		for _, tag := range sortedTags(t.records[key]) {
+			intended := t.records[key].Tags[tag].Intended
+			switch previous := findImage(is, tag); previous {
+			case "":
+				plan = append(plan, fmt.Sprintf("Create %s:%s pointing to %s", key, tag, intended))
+			case intended:
+				plan = append(plan, fmt.Sprintf("Keep %s:%s, which already points to %s", key, tag, intended))
+			default:
+				plan = append(plan, fmt.Sprintf("Overwrite %s:%s, which points to %s, with %s", key, tag, previous, intended))
+			}
+		}
+	}
+	return plan, nil
+}
+
+// verify ensures every tag points to the promoted image.
+func (t *promotionTransaction) verify(ctx context.Context) error {
+	var errs []error
+	for _, key := range t.keys() {
+		is := &imagev1.ImageStream{}
+		if err := t.client.Get(ctx, key, is); err != nil {
+			return fmt.Errorf("could not get image stream %s: %w", key, err)
+		}
+		for _, tag := range sortedTags(t.records[key]) {
+			intended := t.records[key].Tags[tag].Intended
+			if actual := findImage(is, tag); actual != intended {
+				errs = append(errs, fmt.Errorf("%s:%s points to %q instead of %q", key, tag, actual, intended))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// rollback restores every tag to the image it pointed to before.
+func (t *promotionTransaction) rollback(ctx context.Context) error {
+	var errs []error
+	for _, key := range t.keys() {
+		if err := t.restore(ctx, key, t.records[key]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// commit removes the record of the transaction.
+func (t *promotionTransaction) commit(ctx context.Context) error {
+	var errs []error
+	for _, key := range t.keys() {
+		if err := t.annotate(ctx, key, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// restore points the tags in the record back to their previous images and
+// removes the record. Image streams created by the promotion are deleted.
+func (t *promotionTransaction) restore(ctx context.Context, key ctrlruntimeclient.ObjectKey, record *promotionRecord) error {
+	if record.Created {
+		is := &imagev1.ImageStream{ObjectMeta: meta.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+		if err := t.client.Delete(ctx, is); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("could not delete image stream %s: %w", key, err)
+		}
+		logrus.Infof("Deleted %s, which the promotion created.", key)
+		return nil
+	}
+	var errs []error
+	for _, tag := range sortedTags(record) {
+		previous := record.Tags[tag].Previous
+		ist := &imagev1.ImageStreamTag{}
+		name := fmt.Sprintf("%s:%s", key.Name, tag)
+		if previous == "" {
+			ist.ObjectMeta = meta.ObjectMeta{Namespace: key.Namespace, Name: name}
+			if err := t.client.Delete(ctx, ist); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("could not delete %s/%s: %w", key.Namespace, name, err))
+			}
+			continue
+		}
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := t.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: key.Namespace, Name: name}, ist); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				ist = &imagev1.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: key.Namespace, Name: name}}
+				ist.Tag = previousTagReference(key.Name, previous)
+				return t.client.Create(ctx, ist)
+			}
+			if ist.Image.Name == previous {
+				return nil
+			}
+			ist.Tag = previousTagReference(key.Name, previous)
+			return t.client.Update(ctx, ist)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("could not restore %s/%s to %s: %w", key.Namespace, name, previous, err))
+		}
+	}
+	if len(errs) > 0 {
+		// keep the record so that the rollback can be retried
+		return utilerrors.NewAggregate(errs)
+	}
+	logrus.Infof("Restored the tags in %s.", key)
+	return t.annotate(ctx, key, nil)
+}
+
+func previousTagReference(stream, image string) *imagev1.TagReference {
+	return &imagev1.TagReference{
+		From: &coreapi.ObjectReference{
+			Kind: "ImageStreamImage",
+			Name: fmt.Sprintf("%s@%s", stream, image),
+		},
+		ImportPolicy: imagev1.TagImportPolicy{
+			ImportMode: imagev1.ImportModePreserveOriginal,
+		},
+	}
+}
+
+// annotate records the transaction on the image stream, or removes the
+// record if it is nil.
+func (t *promotionTransaction) annotate(ctx context.Context, key ctrlruntimeclient.ObjectKey, record *promotionRecord) error {
+	var raw []byte
+	if record != nil {
+		var err error
+		if raw, err = json.Marshal(record); err != nil {
+			return fmt.Errorf("could not marshal promotion record: %w", err)
+		}
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		is := &imagev1.ImageStream{}
+		if err := t.client.Get(ctx, key, is); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("could not get image stream %s: %w", key, err)
+		}
+		if record == nil {
+			if _, ok := is.Annotations[PromotionTransactionAnnotation]; !ok {
+				return nil
+			}
+			delete(is.Annotations, PromotionTransactionAnnotation)
+		} else {
+			if is.Annotations == nil {
+				is.Annotations = map[string]string{}
+			}
+			is.Annotations[PromotionTransactionAnnotation] = string(raw)
+		}
+		return t.client.Update(ctx, is)
+	})
+}
+
+func sortedTags(record *promotionRecord) []string {
+	var tags []string
+	for tag := range record.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,223 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imageapi "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestPromotionTransaction(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := imageapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	stream := func(annotation string, tags map[string]string) *imageapi.ImageStream {
+		is := &imageapi.ImageStream{ObjectMeta: meta.ObjectMeta{Namespace: "ocp", Name: "4.14"}}
+		if annotation != "" {
+			is.Annotations = map[string]string{PromotionTransactionAnnotation: annotation}
+		}
+		var names []string
+		for tag := range tags {
+			names = append(names, tag)
+		}
+		sort.Strings(names)
+		for _, tag := range names {
+			is.Status.Tags = append(is.Status.Tags, imageapi.NamedTagEventList{Tag: tag, Items: []imageapi.TagEvent{{Image: tags[tag]}}})
+		}
+		return is
+	}
+	pipeline := &imageapi.ImageStream{Status: imageapi.ImageStreamStatus{Tags: []imageapi.NamedTagEventList{
+		{Tag: "a", Items: []imageapi.TagEvent{{Image: "sha256:new-a"}}},
+		{Tag: "b", Items: []imageapi.TagEvent{{Image: "sha256:new-b"}}},
+	}}}
+	tags := map[string][]api.ImageStreamTagReference{
+		"a": {{Namespace: "ocp", Name: "4.14", Tag: "a"}},
+		"b": {{Namespace: "ocp", Name: "4.14", Tag: "b"}},
+	}
+	key := ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.14"}
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	newTransaction := func(client ctrlruntimeclient.Client) *promotionTransaction {
+		transaction := newPromotionTransaction(client, "job/1", tags, pipeline)
+		transaction.now = func() time.Time { return now }
+		return transaction
+	}
+
+	t.Run("begin records the previous state", func(t *testing.T) {
+		client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(stream("", map[string]string{"a": "sha256:old-a"})).Build()
+		transaction := newTransaction(client)
+		if err := transaction.begin(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		is := &imageapi.ImageStream{}
+		if err := client.Get(context.Background(), key, is); err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"job":"job/1","started":"2023-01-01T12:00:00Z","tags":{"a":{"intended":"sha256:new-a","previous":"sha256:old-a"},"b":{"intended":"sha256:new-b"}}}`
+		testhelper.Diff(t, "annotation", is.Annotations[PromotionTransactionAnnotation], expected)
+		if err := transaction.verify(context.Background()); err == nil {
+			t.Error("expected verification to fail before the tags are promoted")
+		}
+	})
+
+	t.Run("commit after a complete promotion removes the record", func(t *testing.T) {
+		client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(stream("", nil)).Build()
+		transaction := newTransaction(client)
+		if err := transaction.begin(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		is := &imageapi.ImageStream{}
+		if err := client.Get(context.Background(), key, is); err != nil {
+			t.Fatal(err)
+		}
+		is.Status = stream("", map[string]string{"a": "sha256:new-a", "b": "sha256:new-b"}).Status
+		if err := client.Update(context.Background(), is); err != nil {
+			t.Fatal(err)
+		}
+		if err := transaction.verify(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := transaction.commit(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := client.Get(context.Background(), key, is); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := is.Annotations[PromotionTransactionAnnotation]; ok {
+			t.Error("expected the promotion record to be removed")
+		}
+	})
+
+	t.Run("incomplete promotion is rolled back", func(t *testing.T) {
+		stale := `{"job":"job/0","tags":{"a":{"intended":"sha256:stale-a","previous":"sha256:old-a"},"b":{"intended":"sha256:stale-b"}}}`
+		client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+			stream(stale, map[string]string{"a": "sha256:stale-a", "b": "sha256:stale-b"}),
+			&imageapi.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: "ocp", Name: "4.14:a"}, Image: imageapi.Image{ObjectMeta: meta.ObjectMeta{Name: "sha256:stale-a"}}},
+			&imageapi.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: "ocp", Name: "4.14:b"}, Image: imageapi.Image{ObjectMeta: meta.ObjectMeta{Name: "sha256:stale-b"}}},
+		).Build()
+		record := &promotionRecord{}
+		transaction := &promotionTransaction{client: client}
+		if err := json.Unmarshal([]byte(stale), record); err != nil {
+			t.Fatal(err)
+		}
+		if err := transaction.restore(context.Background(), key, record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		a := &imageapi.ImageStreamTag{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.14:a"}, a); err != nil {
+			t.Fatal(err)
+		}
+		testhelper.Diff(t, "restored tag", a.Tag, previousTagReference("4.14", "sha256:old-a"))
+		b := &imageapi.ImageStreamTag{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.14:b"}, b); err == nil {
+			t.Error("expected the new tag to be removed")
+		}
+		is := &imageapi.ImageStream{}
+		if err := client.Get(context.Background(), key, is); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := is.Annotations[PromotionTransactionAnnotation]; ok {
+			t.Error("expected the promotion record to be removed")
+		}
+	})
+
+	t.Run("begin creates missing image streams and rollback deletes them", func(t *testing.T) {
+		client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).Build()
+		transaction := newTransaction(client)
+		if err := transaction.begin(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		is := &imageapi.ImageStream{}
+		if err := client.Get(context.Background(), key, is); err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"job":"job/1","started":"2023-01-01T12:00:00Z","created":true,"tags":{"a":{"intended":"sha256:new-a"},"b":{"intended":"sha256:new-b"}}}`
+		testhelper.Diff(t, "annotation", is.Annotations[PromotionTransactionAnnotation], expected)
+		if err := transaction.rollback(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := client.Get(context.Background(), key, is); !kerrors.IsNotFound(err) {
+			t.Errorf("expected the image stream to be deleted, got %v", err)
+		}
+	})
+
+	for _, tc := range []struct {
+		name        string
+		job         string
+		started     time.Time
+		expectedErr error
+	}{{
+		name:        "promotion by another execution in progress is not rolled back",
+		job:         "job/0",
+		started:     now.Add(-time.Minute),
+		expectedErr: errors.New("promotion to ocp/4.14 by job/0 started at 2023-01-01T11:59:00Z may still be in progress, it will be considered abandoned after 2023-01-01T12:59:00Z"),
+	}, {
+		name:    "abandoned promotion by another execution is rolled back",
+		job:     "job/0",
+		started: now.Add(-2 * time.Hour),
+	}, {
+		name:    "incomplete promotion by the same execution is rolled back",
+		job:     "job/1",
+		started: now.Add(-time.Minute),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := json.Marshal(promotionRecord{Job: tc.job, Started: meta.NewTime(tc.started), Tags: map[string]promotedTagRecord{
+				"a": {Intended: "sha256:stale-a", Previous: "sha256:old-a"},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+				stream(string(raw), map[string]string{"a": "sha256:stale-a"}),
+				&imageapi.ImageStreamTag{ObjectMeta: meta.ObjectMeta{Namespace: "ocp", Name: "4.14:a"}, Image: imageapi.Image{ObjectMeta: meta.ObjectMeta{Name: "sha256:stale-a"}}},
+			).Build()
+			err = newTransaction(client).begin(context.Background())
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			a := &imageapi.ImageStreamTag{}
+			if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.14:a"}, a); err != nil {
+				t.Fatal(err)
+			}
+			if restored := a.Tag != nil; restored != (tc.expectedErr == nil) {
+				t.Errorf("expected the tag to be restored: %t, got %v", tc.expectedErr == nil, a.Tag)
+			}
+		})
+	}
+
+	t.Run("plan describes the changes without making them", func(t *testing.T) {
+		existing := stream("", map[string]string{"a": "sha256:old-a"})
+		client := fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithObjects(existing.DeepCopy()).Build()
+		plan, err := newPromotionTransaction(client, "job/1", map[string][]api.ImageStreamTagReference{
+			"a": {{Namespace: "ocp", Name: "4.14", Tag: "a"}},
+			"b": {{Namespace: "ocp", Name: "4.14", Tag: "b"}, {Namespace: "ocp", Name: "4.15", Tag: "b"}},
+		}, pipeline).plan(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []string{
+			"Overwrite ocp/4.14:a, which points to sha256:old-a, with sha256:new-a",
+			"Create ocp/4.14:b pointing to sha256:new-b",
+			"Create ocp/4.15:b pointing to sha256:new-b",
+		}
+		testhelper.Diff(t, "plan", plan, expected)
+		is := &imageapi.ImageStream{}
+		if err := client.Get(context.Background(), key, is); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := is.Annotations[PromotionTransactionAnnotation]; ok {
+			t.Error("expected no promotion record")
+		}
+	})
+}
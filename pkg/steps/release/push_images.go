@@ -0,0 +1,242 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// PushImagesOptions configures pushing the images built by a job to a
+// repository outside of the CI registry.
+type PushImagesOptions struct {
+	// Repository is the repository the images are pushed to, for
+	// example quay.io/user/prefix. Every image is pushed as a tag
+	// named after the image.
+	Repository string
+	// Images are the images to push: `name` selects a pipeline image
+	// and `stable:name` an image in the stable stream. All images
+	// built by the configuration are pushed when this is empty.
+	Images []string
+	// Promoted restricts the push to the images the promotion configuration
+	// promotes, each tagged by the name it is promoted as.
+	Promoted bool
+}
+
+// pushImagesStep mirrors selected pipeline and stable images to a
+// user-specified repository.
+type pushImagesStep struct {
+	config         *api.ReleaseBuildConfiguration
+	options        PushImagesOptions
+	requiredImages sets.Set[string]
+	jobSpec        *api.JobSpec
+	client         kubernetes.PodClient
+}
+
+func (s *pushImagesStep) Inputs() (api.InputDefinition, error) {
+	return nil, nil
+}
+
+func (*pushImagesStep) Validate() error { return nil }
+
+func (s *pushImagesStep) Run(ctx context.Context) error {
+	return results.ForReason("pushing_images").ForError(s.run(ctx))
+}
+
+func (s *pushImagesStep) run(ctx context.Context) error {
+	images := s.options.Images
+	explicit := len(images) != 0
+	var sources map[string]string
+	switch {
+	case s.options.Promoted:
+		sources = promotedImages(s.config, s.requiredImages)
+		images = sets.List(sets.KeySet(sources))
+	case !explicit:
+		for _, image := range s.config.Images {
+			images = append(images, string(image.To))
+		}
+	}
+	if len(images) == 0 {
+		logrus.Info("No images to push, skipping...")
+		return nil
+	}
+
+	streams := map[string]*imagev1.ImageStream{}
+	for _, image := range images {
+		stream, _ := splitPushedImage(image)
+		if _, ok := streams[stream]; ok {
+			continue
+		}
+		is := &imagev1.ImageStream{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: stream}, is); err != nil {
+			return fmt.Errorf("could not resolve imagestream %s: %w", stream, err)
+		}
+		streams[stream] = is
+	}
+
+	imageMirror, err := pushImagesTargets(images, sources, streams, s.options.Repository, explicit)
+	if err != nil {
+		return err
+	}
+	if len(imageMirror) == 0 {
+		logrus.Info("No images to push, skipping...")
+		return nil
+	}
+	pod := getMirrorPod(imageMirror, s.jobSpec.Namespace(), s.jobSpec.NameForAttempt(api.PushImagesStepName), "push", api.PushImagesCredentialsSecret)
+	pod.Spec.Containers[0].Args = []string{pushImagesScript(imageMirror, pushAttempts)}
+	if _, err := steps.RunPod(ctx, s.client, pod); err != nil {
+		return fmt.Errorf("unable to run push pod: %w", err)
+	}
+
+	targets := make([]string, 0, len(imageMirror))
+	for target := range imageMirror {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	logrus.Infof("Pushed images to %s:\n%s", s.options.Repository, strings.Join(targets, "\n"))
+	return nil
+}
+
+// pushAttempts is how many times the images are mirrored before the push
+// fails, as external registries are prone to transient errors.
+const pushAttempts = 3
+
+// pushImagesScript mirrors the images, retrying failures, and then verifies
+// that every pushed tag resolves to the digest of the image it was pushed
+// from, so that a push that silently did not update a tag fails the step.
+func pushImagesScript(imageMirror map[string]string, attempts int) string {
+	targets := make([]string, 0, len(imageMirror))
+	for target := range imageMirror {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	var mappings []string
+	for _, target := range targets {
+		mappings = append(mappings, fmt.Sprintf("%s=%s", imageMirror[target], target))
+	}
+	config := filepath.Join(api.RegistryPushCredentialsCICentralSecretMountPath, coreapi.DockerConfigJsonKey)
+	script := []string{
+		"attempt=1",
+		fmt.Sprintf("until oc image mirror --keep-manifest-list --registry-config=%s --max-per-registry=20 %s; do", config, strings.Join(mappings, " ")),
+		fmt.Sprintf(`  if [ "${attempt}" -ge %d ]; then echo "Could not push the images after %d attempts."; exit 1; fi`, attempts, attempts),
+		`  attempt=$((attempt + 1)); echo "Pushing the images failed, retrying..."; sleep 30`,
+		"done",
+		"failed=0",
+	}
+	for _, target := range targets {
+		_, digest, ok := strings.Cut(imageMirror[target], "@")
+		if !ok {
+			continue
+		}
+		script = append(script, fmt.Sprintf(`oc image info --registry-config=%s --filter-by-os=linux/amd64 -o json %s | grep -q '"%s"' || { echo "%s does not resolve to %s"; failed=1; }`, config, target, digest, target, digest))
+	}
+	script = append(script, `exit "${failed}"`)
+	return strings.Join(script, "\n")
+}
+
+// splitPushedImage returns the image stream and tag of an image selected for pushing.
+func splitPushedImage(image string) (string, string) {
+	if stream, tag, ok := strings.Cut(image, ":"); ok && stream == api.StableImageStream {
+		return stream, tag
+	}
+	return api.PipelineImageStream, image
+}
+
+// promotedImages maps the names the images are promoted as to the pipeline
+// tags they are promoted from.
+func promotedImages(config *api.ReleaseBuildConfiguration, requiredImages sets.Set[string]) map[string]string {
+	ret := map[string]string{}
+	if config.PromotionConfiguration == nil {
+		return ret
+	}
+	for _, target := range api.PromotionTargets(config.PromotionConfiguration) {
+		tags, _ := toPromote(target, config.Images, requiredImages)
+		for dst, src := range tags {
+			ret[dst] = src
+		}
+	}
+	return ret
+}
+
+// pushImagesTargets maps the pullspecs in the repository to the images they are pushed from.
+// Images are pushed from the tag sources maps them to, if any, and from the tag of
+// their own name otherwise. Images missing from their stream are an error only when
+// they were selected explicitly.
+func pushImagesTargets(images []string, sources map[string]string, streams map[string]*imagev1.ImageStream, repository string, explicit bool) (map[string]string, error) {
+	imageMirror := map[string]string{}
+	var missing []string
+	for _, image := range images {
+		stream, tag := splitPushedImage(image)
+		is := streams[stream]
+		if is == nil {
+			missing = append(missing, image)
+			continue
+		}
+		source := tag
+		if tag, ok := sources[image]; ok {
+			source = tag
+		}
+		dockerImageReference := findDockerImageReference(is, source)
+		if dockerImageReference == "" {
+			missing = append(missing, image)
+			continue
+		}
+		target := tag
+		if stream == api.StableImageStream {
+			target = fmt.Sprintf("%s-%s", api.StableImageStream, tag)
+		}
+		imageMirror[fmt.Sprintf("%s:%s", repository, target)] = getPublicImageReference(dockerImageReference, is.Status.PublicDockerImageRepository)
+	}
+	if explicit && len(missing) != 0 {
+		return nil, fmt.Errorf("could not push images that were not built: %s", strings.Join(missing, ", "))
+	}
+	return imageMirror, nil
+}
+
+func (s *pushImagesStep) Requires() []api.StepLink {
+	return []api.StepLink{api.AllStepsLink()}
+}
+
+func (s *pushImagesStep) Creates() []api.StepLink {
+	return []api.StepLink{}
+}
+
+func (s *pushImagesStep) Provides() api.ParameterMap {
+	return nil
+}
+
+func (s *pushImagesStep) Name() string { return fmt.Sprintf("[%s]", api.PushImagesStepName) }
+
+func (s *pushImagesStep) Description() string {
+	return fmt.Sprintf("Push built images to %s", s.options.Repository)
+}
+
+func (s *pushImagesStep) Objects() []ctrlruntimeclient.Object {
+	return s.client.Objects()
+}
+
+// PushImagesStep pushes the selected pipeline and stable images to the repository in the options.
+// The required images are promoted even if optional, so they are pushed with the promoted images.
+func PushImagesStep(config *api.ReleaseBuildConfiguration, options PushImagesOptions, requiredImages sets.Set[string], jobSpec *api.JobSpec, client kubernetes.PodClient) api.Step {
+	return &pushImagesStep{
+		config:         config,
+		options:        options,
+		requiredImages: requiredImages,
+		jobSpec:        jobSpec,
+		client:         client,
+	}
+}
@@ -0,0 +1,112 @@
+package release
+
+import (
+	"errors"
+	"testing"
+
+	imageapi "github.com/openshift/api/image/v1"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestPushImagesTargets(t *testing.T) {
+	streams := map[string]*imageapi.ImageStream{
+		"pipeline": {
+			Status: imageapi.ImageStreamStatus{
+				PublicDockerImageRepository: "registry.build01.ci.openshift.org/ci-op-1/pipeline",
+				Tags: []imageapi.NamedTagEventList{
+					{Tag: "bin", Items: []imageapi.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-1/pipeline@sha256:bin"}}},
+					{Tag: "src", Items: []imageapi.TagEvent{{DockerImageReference: "image-registry.openshift-image-registry.svc:5000/ci-op-1/pipeline@sha256:src"}}},
+				},
+			},
+		},
+		"stable": {
+			Status: imageapi.ImageStreamStatus{
+				Tags: []imageapi.NamedTagEventList{
+					{Tag: "cli", Items: []imageapi.TagEvent{{DockerImageReference: "quay.io/openshift/ci@sha256:cli"}}},
+				},
+			},
+		},
+	}
+	var testCases = []struct {
+		name        string
+		images      []string
+		sources     map[string]string
+		explicit    bool
+		expected    map[string]string
+		expectedErr error
+	}{
+		{
+			name:     "pipeline and stable images",
+			images:   []string{"bin", "stable:cli"},
+			explicit: true,
+			expected: map[string]string{
+				"quay.io/user/prefix:bin":        "registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:bin",
+				"quay.io/user/prefix:stable-cli": "quay.io/openshift/ci@sha256:cli",
+			},
+		},
+		{
+			name:     "images that were not built are skipped by default",
+			images:   []string{"src", "unbuilt"},
+			expected: map[string]string{"quay.io/user/prefix:src": "registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:src"},
+		},
+		{
+			name:        "selected images that were not built are an error",
+			images:      []string{"src", "unbuilt", "stable:missing"},
+			explicit:    true,
+			expectedErr: errors.New("could not push images that were not built: unbuilt, stable:missing"),
+		},
+		{
+			name:    "images pushed from the tags they are promoted from",
+			images:  []string{"cli", "src"},
+			sources: map[string]string{"cli": "bin"},
+			expected: map[string]string{
+				"quay.io/user/prefix:cli": "registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:bin",
+				"quay.io/user/prefix:src": "registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:src",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := pushImagesTargets(tc.images, tc.sources, streams, "quay.io/user/prefix", tc.explicit)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			testhelper.Diff(t, "targets", actual, tc.expected)
+		})
+	}
+}
+
+func TestPromotedImages(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{
+		Images: []api.ProjectDirectoryImageBuildStepConfiguration{{To: "cli"}, {To: "tests"}, {To: "debug", Optional: true}, {To: "required", Optional: true}},
+		PromotionConfiguration: &api.PromotionConfiguration{
+			Namespace:        "ocp",
+			Name:             "4.14",
+			ExcludedImages:   []string{"tests"},
+			AdditionalImages: map[string]string{"artifacts": "bin"},
+		},
+	}
+	expected := map[string]string{"cli": "cli", "required": "required", "artifacts": "bin"}
+	testhelper.Diff(t, "images", promotedImages(config, sets.New("required")), expected)
+	testhelper.Diff(t, "images without promotion", promotedImages(&api.ReleaseBuildConfiguration{Images: config.Images}, nil), map[string]string{})
+}
+
+func TestPushImagesScript(t *testing.T) {
+	imageMirror := map[string]string{
+		"quay.io/user/prefix:src": "registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:src",
+		"quay.io/user/prefix:bin": "registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:bin",
+		"quay.io/user/prefix:tag": "quay.io/openshift/ci:tag",
+	}
+	expected := `attempt=1
+until oc image mirror --keep-manifest-list --registry-config=/etc/push-secret/.dockerconfigjson --max-per-registry=20 registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:bin=quay.io/user/prefix:bin registry.build01.ci.openshift.org/ci-op-1/pipeline@sha256:src=quay.io/user/prefix:src quay.io/openshift/ci:tag=quay.io/user/prefix:tag; do
+  if [ "${attempt}" -ge 3 ]; then echo "Could not push the images after 3 attempts."; exit 1; fi
+  attempt=$((attempt + 1)); echo "Pushing the images failed, retrying..."; sleep 30
+done
+failed=0
+oc image info --registry-config=/etc/push-secret/.dockerconfigjson --filter-by-os=linux/amd64 -o json quay.io/user/prefix:bin | grep -q '"sha256:bin"' || { echo "quay.io/user/prefix:bin does not resolve to sha256:bin"; failed=1; }
+oc image info --registry-config=/etc/push-secret/.dockerconfigjson --filter-by-os=linux/amd64 -o json quay.io/user/prefix:src | grep -q '"sha256:src"' || { echo "quay.io/user/prefix:src does not resolve to sha256:src"; failed=1; }
+exit "${failed}"`
+	testhelper.Diff(t, "script", pushImagesScript(imageMirror, 3), expected)
+}
@@ -0,0 +1,224 @@
+package release
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// registryServiceNamespace and registryServiceName identify the
+	// service of the integrated registry
+	registryServiceNamespace = "openshift-image-registry"
+	registryServiceName      = "image-registry"
+)
+
+// registryResolver determines the host of the registry that serves the
+// images in the test namespace.
+type registryResolver struct {
+	client ctrlruntimeclient.Reader
+	// override is used instead of discovering the registry, if set
+	override string
+	// lookupHost resolves a host name, net.LookupHost unless testing
+	lookupHost func(host string) ([]string, error)
+}
+
+// host tries, in order: the configured override, the external and the
+// internal repository of the pipeline image stream and finally the service
+// of the integrated registry, by its in-cluster DNS name or its IP. Only
+// the first two yield pull specs that work outside of the cluster, which
+// most steps do not need, so the others are used with a warning.
+func (r *registryResolver) host(ctx context.Context, namespace string) (string, error) {
+	if r.override != "" {
+		return r.override, nil
+	}
+	is := &imagev1.ImageStream{}
+	if err := r.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: api.PipelineImageStream}, is); err != nil {
+		logrus.WithError(err).Debugf("Could not get image stream %s to determine the registry.", api.PipelineImageStream)
+	} else {
+		if repository := is.Status.PublicDockerImageRepository; repository != "" {
+			return strings.SplitN(repository, "/", 2)[0], nil
+		}
+		if repository := is.Status.DockerImageRepository; repository != "" {
+			logrus.Warnf("The registry is not exposed outside of the cluster, images will only be pullable from within it.")
+			return strings.SplitN(repository, "/", 2)[0], nil
+		}
+	}
+	service := &coreapi.Service{}
+	if err := r.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: registryServiceNamespace, Name: registryServiceName}, service); err != nil {
+		return "", fmt.Errorf("could not determine the registry from image stream %s or service %s/%s: %w", api.PipelineImageStream, registryServiceNamespace, registryServiceName, err)
+	}
+	port := int32(5000)
+	if len(service.Spec.Ports) > 0 {
+		port = service.Spec.Ports[0].Port
+	}
+	dnsName := fmt.Sprintf("%s.%s.svc", registryServiceName, registryServiceNamespace)
+	lookupHost := r.lookupHost
+	if lookupHost == nil {
+		lookupHost = net.LookupHost
+	}
+	if _, err := lookupHost(dnsName); err == nil {
+		logrus.Warnf("Could not determine the registry from image stream %s, using the in-cluster service %s.", api.PipelineImageStream, dnsName)
+		return fmt.Sprintf("%s:%d", dnsName, port), nil
+	}
+	if ip := service.Spec.ClusterIP; ip != "" && ip != coreapi.ClusterIPNone {
+		logrus.Warnf("Could not determine the registry from image stream %s or resolve %s, using the service IP %s.", api.PipelineImageStream, dnsName, ip)
+		return net.JoinHostPort(ip, fmt.Sprint(port)), nil
+	}
+	return "", fmt.Errorf("could not determine the registry: image stream %s has no repository and service %s/%s has no IP", api.PipelineImageStream, registryServiceNamespace, registryServiceName)
+}
+
+// manifestMediaTypes are the manifests registries are asked for when looking
+// up the image a tag points to, so they do not convert them.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// challengeParameter matches the parameters of a WWW-Authenticate header.
+var challengeParameter = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// registryCredentials returns the user and password to authenticate to the
+// registry with, if any.
+type registryCredentials func(registry string) (string, string)
+
+// manifestDigest returns the digest of the manifest the reference, like
+// registry/namespace/name:tag, points to in its registry, or nothing when the
+// registry does not have it. The registry is authenticated to with the
+// credentials when it requires it.
+func manifestDigest(ctx context.Context, client *http.Client, reference string, credentials registryCredentials) (string, error) {
+	slash := strings.Index(reference, "/")
+	if slash == -1 {
+		return "", fmt.Errorf("%s does not name a registry", reference)
+	}
+	registry, repository := reference[:slash], reference[slash+1:]
+	tag := "latest"
+	if i := strings.LastIndex(repository, "@"); i != -1 {
+		repository, tag = repository[:i], repository[i+1:]
+	} else if i := strings.LastIndex(repository, ":"); i != -1 && !strings.Contains(repository[i:], "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+	address := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, tag)
+	resp, err := headManifest(ctx, client, address, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		user, password := "", ""
+		if credentials != nil {
+			user, password = credentials(registry)
+		}
+		authorization, err := registryAuthorization(ctx, client, resp.Header.Get("WWW-Authenticate"), user, password)
+		if err != nil {
+			return "", fmt.Errorf("could not authenticate to %s: %w", registry, err)
+		}
+		if resp, err = headManifest(ctx, client, address, authorization); err != nil {
+			return "", err
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		digest := resp.Header.Get("Docker-Content-Digest")
+		if digest == "" {
+			return "", fmt.Errorf("%s did not report the digest of %s", registry, reference)
+		}
+		return digest, nil
+	case http.StatusNotFound:
+		return "", nil
+	default:
+		return "", fmt.Errorf("could not look up %s: %s", reference, resp.Status)
+	}
+}
+
+func headManifest(ctx context.Context, client *http.Client, address, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// registryAuthorization answers the challenge of the registry: with the
+// credentials for basic authentication, or with a token requested with them
+// from the realm of the registry for bearer authentication.
+func registryAuthorization(ctx context.Context, client *http.Client, challenge, user, password string) (string, error) {
+	scheme, rawParameters, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if user == "" {
+			return "", errors.New("the registry requires credentials")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password)), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	parameters := map[string]string{}
+	for _, match := range challengeParameter.FindAllStringSubmatch(rawParameters, -1) {
+		parameters[match[1]] = match[2]
+	}
+	realm, err := url.Parse(parameters["realm"])
+	if err != nil || parameters["realm"] == "" {
+		return "", fmt.Errorf("invalid realm in authentication challenge %q", challenge)
+	}
+	query := realm.Query()
+	for _, parameter := range []string{"service", "scope"} {
+		if value, set := parameters[parameter]; set {
+			query.Set(parameter, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the token request was rejected with status %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("could not decode the token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
@@ -0,0 +1,147 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestRegistryResolverHost(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := coreapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	pipeline := func(public, internal string) *imagev1.ImageStream {
+		return &imagev1.ImageStream{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "pipeline"},
+			Status:     imagev1.ImageStreamStatus{PublicDockerImageRepository: public, DockerImageRepository: internal},
+		}
+	}
+	service := &coreapi.Service{
+		ObjectMeta: meta.ObjectMeta{Namespace: "openshift-image-registry", Name: "image-registry"},
+		Spec: coreapi.ServiceSpec{
+			ClusterIP: "172.30.0.10",
+			Ports:     []coreapi.ServicePort{{Port: 5000}},
+		},
+	}
+	resolves := func(string) ([]string, error) { return []string{"172.30.0.10"}, nil }
+	fails := func(string) ([]string, error) { return nil, errors.New("no such host") }
+	for _, tc := range []struct {
+		name        string
+		objects     []runtime.Object
+		override    string
+		lookupHost  func(string) ([]string, error)
+		expected    string
+		expectedErr bool
+	}{{
+		name:     "override wins",
+		objects:  []runtime.Object{pipeline("registry.ci.org/ns/pipeline", "")},
+		override: "registry.example.com",
+		expected: "registry.example.com",
+	}, {
+		name:     "external repository",
+		objects:  []runtime.Object{pipeline("registry.ci.org/ns/pipeline", "image-registry.openshift-image-registry.svc:5000/ns/pipeline")},
+		expected: "registry.ci.org",
+	}, {
+		name:     "internal repository without external exposure",
+		objects:  []runtime.Object{pipeline("", "image-registry.openshift-image-registry.svc:5000/ns/pipeline")},
+		expected: "image-registry.openshift-image-registry.svc:5000",
+	}, {
+		name:       "in-cluster DNS name of the service",
+		objects:    []runtime.Object{pipeline("", ""), service},
+		lookupHost: resolves,
+		expected:   "image-registry.openshift-image-registry.svc:5000",
+	}, {
+		name:       "service IP when the DNS name does not resolve",
+		objects:    []runtime.Object{service},
+		lookupHost: fails,
+		expected:   "172.30.0.10:5000",
+	}, {
+		name:        "nothing to discover",
+		objects:     []runtime.Object{pipeline("", "")},
+		lookupHost:  fails,
+		expected:    "",
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &registryResolver{
+				client:     fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(tc.objects...).Build(),
+				override:   tc.override,
+				lookupHost: tc.lookupHost,
+			}
+			host, err := r.host(context.Background(), "ns")
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error=%t, got %v", tc.expectedErr, err)
+			}
+			testhelper.Diff(t, "host", host, tc.expected)
+		})
+	}
+}
+
+func TestManifestDigest(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if scope := r.URL.Query().Get("scope"); scope != "repository:ns/name:pull,push" {
+				t.Errorf("unexpected scope %q", scope)
+			}
+			_, _ = w.Write([]byte(`{"token":"token"}`))
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:ns/name:pull,push"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method != http.MethodHead:
+			t.Errorf("expected a HEAD request, got %s", r.Method)
+		case r.URL.Path == "/v2/ns/name/manifests/present":
+			w.Header().Set("Docker-Content-Digest", "sha256:present")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+	credentials := func(host string) (string, string) {
+		if host != registry {
+			t.Errorf("expected the credentials of %s, got %s", registry, host)
+		}
+		return "user", "secret"
+	}
+
+	for _, tc := range []struct {
+		name        string
+		reference   string
+		credentials registryCredentials
+		expected    string
+		expectedErr error
+	}{
+		{name: "tag exists", reference: registry + "/ns/name:present", credentials: credentials, expected: "sha256:present"},
+		{name: "tag does not exist", reference: registry + "/ns/name:missing", credentials: credentials},
+		{name: "no credentials", reference: registry + "/ns/name:present", expectedErr: fmt.Errorf("could not authenticate to %s: the token request was rejected with status 401 Unauthorized", registry)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			digest, err := manifestDigest(context.Background(), server.Client(), tc.reference, tc.credentials)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			testhelper.Diff(t, "digest", digest, tc.expected)
+		})
+	}
+}
@@ -3,7 +3,7 @@ package release
 import (
 	"context"
 	"fmt"
-	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 
@@ -92,10 +92,14 @@ func (s *stableImagesTagStep) Objects() []ctrlruntimeclient.Object {
 // expected that builds will overwrite these tags at
 // a later point, selectively
 type releaseImagesTagStep struct {
-	config  api.ReleaseTagConfiguration
-	client  loggingclient.LoggingClient
-	params  *api.DeferredParameters
-	jobSpec *api.JobSpec
+	config   api.ReleaseTagConfiguration
+	client   loggingclient.LoggingClient
+	params   *api.DeferredParameters
+	jobSpec  *api.JobSpec
+	registry *registryResolver
+
+	lock   sync.Mutex
+	format string
 }
 
 func (s *releaseImagesTagStep) Inputs() (api.InputDefinition, error) {
@@ -158,33 +162,36 @@ func (s *releaseImagesTagStep) Creates() []api.StepLink {
 }
 
 func (s *releaseImagesTagStep) Provides() api.ParameterMap {
-	return api.ParameterMap{
-		utils.ImageFormatEnv: s.imageFormat,
-	}
+	return api.ParameterMapFor(s.Parameters())
 }
 
-func (s *releaseImagesTagStep) imageFormat() (string, error) {
-	spec, err := s.repositoryPullSpec()
-	if err != nil {
-		return "REGISTRY", err
-	}
-	registry := strings.SplitN(spec, "/", 2)[0]
-	format := fmt.Sprintf("%s/%s/%s:%s", registry, s.jobSpec.Namespace(), api.StableImageStream, api.ComponentFormatReplacement)
-	return format, nil
+func (s *releaseImagesTagStep) Parameters() []api.Parameter {
+	return []api.Parameter{utils.ImageParameter(utils.ImageFormatEnv, "Pull spec of the images of the job, with ${component} in place of the image name", s.imageFormat)}
 }
 
-func (s *releaseImagesTagStep) repositoryPullSpec() (string, error) {
-	is := &imagev1.ImageStream{}
-	if err := s.client.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PipelineImageStream}, is); err != nil {
-		return "", err
-	}
-	if len(is.Status.PublicDockerImageRepository) > 0 {
-		return is.Status.PublicDockerImageRepository, nil
+// ParameterReference describes the parameters the steps of this package may
+// provide, with placeholders in angle brackets for the parts that depend on
+// the configuration.
+func ParameterReference() []api.Parameter {
+	reference := (&releaseImagesTagStep{}).Parameters()
+	reference = append(reference, (&importReleaseStep{name: "<name>"}).Parameters()...)
+	return reference
+}
+
+// imageFormat is remembered once determined, so that the fallbacks warn
+// only once.
+func (s *releaseImagesTagStep) imageFormat() (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.format != "" {
+		return s.format, nil
 	}
-	if len(is.Status.DockerImageRepository) > 0 {
-		return is.Status.DockerImageRepository, nil
+	registry, err := s.registry.host(context.TODO(), s.jobSpec.Namespace())
+	if err != nil {
+		return "REGISTRY", err
 	}
-	return "", fmt.Errorf("no pull spec available for image stream %s", api.PipelineImageStream)
+	s.format = fmt.Sprintf("%s/%s/%s:%s", registry, s.jobSpec.Namespace(), api.StableImageStream, api.ComponentFormatReplacement)
+	return s.format, nil
 }
 
 func (s *releaseImagesTagStep) Name() string { return s.config.InputsName() }
@@ -197,11 +204,15 @@ func (s *releaseImagesTagStep) Objects() []ctrlruntimeclient.Object {
 	return s.client.Objects()
 }
 
-func ReleaseImagesTagStep(config api.ReleaseTagConfiguration, client loggingclient.LoggingClient, params *api.DeferredParameters, jobSpec *api.JobSpec) api.Step {
+// ReleaseImagesTagStep creates a step that tags the release images into the
+// test namespace. The registry override, if set, is used in IMAGE_FORMAT
+// instead of discovering the registry.
+func ReleaseImagesTagStep(config api.ReleaseTagConfiguration, client loggingclient.LoggingClient, params *api.DeferredParameters, jobSpec *api.JobSpec, registryOverride string) api.Step {
 	return &releaseImagesTagStep{
-		config:  config,
-		client:  client,
-		params:  params,
-		jobSpec: jobSpec,
+		config:   config,
+		client:   client,
+		params:   params,
+		jobSpec:  jobSpec,
+		registry: &registryResolver{client: client, override: registryOverride},
 	}
 }
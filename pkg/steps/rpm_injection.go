@@ -64,6 +64,26 @@ func (s *rpmImageInjectionStep) run(ctx context.Context) error {
 	))
 }
 
+// DryRun describes the build that injects the repository. The host of the
+// RPM server and the digest of the base image are only known when the step
+// runs, so placeholders are used instead.
+func (s *rpmImageInjectionStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	dockerfile := rpmInjectionDockerfile(s.config.From, DryRunPlaceholder("host of the RPM server"))
+	build := buildFromSource(
+		s.jobSpec, s.config.From, s.config.To,
+		buildapi.BuildSource{
+			Type:       buildapi.BuildSourceDockerfile,
+			Dockerfile: &dockerfile,
+		},
+		DryRunPlaceholder(fmt.Sprintf("digest of %s:%s", api.PipelineImageStream, s.config.From)),
+		"",
+		s.resources,
+		s.pullSecret,
+		nil,
+	)
+	return []api.DryRunAction{{Description: fmt.Sprintf("Create build %s to build %s:%s", build.Name, api.PipelineImageStream, s.config.To), Object: build}}, nil
+}
+
 func (s *rpmImageInjectionStep) Requires() []api.StepLink {
 	return []api.StepLink{api.InternalImageLink(s.config.From), api.RPMRepoLink()}
 }
@@ -0,0 +1,102 @@
+package steps
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	appsapi "k8s.io/api/apps/v1"
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// rpmRepoReapTimeout bounds how long deleting the server may hold up the
+// step that finished last, as observers are notified synchronously.
+const rpmRepoReapTimeout = time.Minute
+
+// RPMRepoReaper is a StepObserver that deletes the deployment, service and
+// route serving the RPMs once every step of the graph requiring the repository
+// finished, so that the server does not keep running for the rest of the job.
+// Steps that never start, for instance because a dependency failed, keep the
+// repository around until the namespace is deleted. Executions sharing the
+// namespace share the server as well, so it must only be used by an execution
+// holding the namespace.
+type RPMRepoReaper struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+
+	lock      sync.Mutex
+	consumers sets.Set[string]
+}
+
+// NewRPMRepoReaper creates an observer tracking the steps in the graph that
+// require the RPM repository. Nothing is deleted when a post step requires the
+// repository, as post steps run after the graph.
+func NewRPMRepoReaper(client ctrlruntimeclient.Client, jobSpec *api.JobSpec, nodes api.OrderedStepList, postSteps []api.Step) *RPMRepoReaper {
+	r := &RPMRepoReaper{client: client, namespace: jobSpec.Namespace(), consumers: sets.Set[string]{}}
+	for _, step := range postSteps {
+		if requiresRPMRepo(step) {
+			return r
+		}
+	}
+	for _, node := range nodes {
+		if requiresRPMRepo(node.Step) {
+			r.consumers.Insert(node.Step.Name())
+		}
+	}
+	return r
+}
+
+func requiresRPMRepo(step api.Step) bool {
+	return api.HasAnyLinks([]api.StepLink{api.RPMRepoLink()}, step.Requires())
+}
+
+func (r *RPMRepoReaper) StepStarted(api.Step) {}
+
+func (r *RPMRepoReaper) StepFinished(step api.Step, _ error) {
+	r.lock.Lock()
+	if !r.consumers.Has(step.Name()) {
+		r.lock.Unlock()
+		return
+	}
+	r.consumers.Delete(step.Name())
+	done := r.consumers.Len() == 0
+	r.lock.Unlock()
+	if done {
+		ctx, cancel := context.WithTimeout(CleanupCtx, rpmRepoReapTimeout)
+		defer cancel()
+		r.reap(ctx)
+	}
+}
+
+// reap deletes the objects serving the RPMs. Failures are not fatal to the
+// execution of the job, as the objects are deleted with the namespace.
+func (r *RPMRepoReaper) reap(ctx context.Context) {
+	objectMeta := meta.ObjectMeta{Namespace: r.namespace, Name: RPMRepoName}
+	var failed bool
+	for _, object := range []struct {
+		kind string
+		obj  ctrlruntimeclient.Object
+	}{
+		{kind: "route", obj: &routev1.Route{ObjectMeta: objectMeta}},
+		{kind: "service", obj: &coreapi.Service{ObjectMeta: objectMeta}},
+		{kind: "deployment", obj: &appsapi.Deployment{ObjectMeta: objectMeta}},
+	} {
+		if err := r.client.Delete(ctx, object.obj, ctrlruntimeclient.PropagationPolicy(meta.DeletePropagationBackground)); err != nil && !kerrors.IsNotFound(err) {
+			logrus.WithError(err).Warnf("Could not delete the %s serving the RPMs.", object.kind)
+			failed = true
+		}
+	}
+	if !failed {
+		logrus.Info("Deleted the RPM repository server, as no step requires it anymore.")
+	}
+}
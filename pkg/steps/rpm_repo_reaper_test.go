@@ -0,0 +1,78 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsapi "k8s.io/api/apps/v1"
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestRPMRepoReaper(t *testing.T) {
+	if err := routev1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	rpms := &fakeStep{name: "[serve:rpms]", creates: []api.StepLink{api.RPMRepoLink()}}
+	first := &fakeStep{name: "first", requires: []api.StepLink{api.RPMRepoLink()}}
+	second := &fakeStep{name: "second", requires: []api.StepLink{api.RPMRepoLink()}}
+	unit := &fakeStep{name: "unit"}
+	nodes, errs := api.BuildGraph([]api.Step{rpms, first, second, unit}).TopologicalSort()
+	if errs != nil {
+		t.Fatalf("failed to sort graph: %v", errs)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		postSteps []api.Step
+		finished  []api.Step
+		deleted   bool
+	}{{
+		name:     "consumers still running",
+		finished: []api.Step{rpms, first, unit},
+	}, {
+		name:     "all consumers finished",
+		finished: []api.Step{rpms, first, unit, second},
+		deleted:  true,
+	}, {
+		name:      "post step requires the repository",
+		postSteps: []api.Step{&fakeStep{name: "post", requires: []api.StepLink{api.RPMRepoLink()}}},
+		finished:  []api.Step{rpms, first, unit, second},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			objectMeta := meta.ObjectMeta{Namespace: "ns", Name: RPMRepoName}
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+				&appsapi.Deployment{ObjectMeta: objectMeta},
+				&coreapi.Service{ObjectMeta: objectMeta},
+				&routev1.Route{ObjectMeta: objectMeta},
+			).Build()
+			reaper := NewRPMRepoReaper(client, jobSpec, nodes, tc.postSteps)
+			for i, step := range tc.finished {
+				var err error
+				if i == 1 {
+					// failed consumers are done with the repository as well
+					err = errors.New("oops")
+				}
+				reaper.StepStarted(step)
+				reaper.StepFinished(step, err)
+			}
+			for _, obj := range []ctrlruntimeclient.Object{&appsapi.Deployment{}, &coreapi.Service{}, &routev1.Route{}} {
+				err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: RPMRepoName}, obj)
+				if deleted := kerrors.IsNotFound(err); deleted != tc.deleted {
+					t.Errorf("expected %T to be deleted: %t, got error %v", obj, tc.deleted, err)
+				}
+			}
+		})
+	}
+}
@@ -35,6 +35,11 @@ const (
 	TTLIgnoreLabel = "ci.openshift.io/ttl.ignore"
 )
 
+// rpmRepoMetadataPath is the path, relative to the root of the served
+// repository, that yum fetches first. The server is only considered ready
+// once this file can be served, as dependent steps will otherwise time out.
+var rpmRepoMetadataPath = []string{"repodata", "repomd.xml"}
+
 type rpmServerStep struct {
 	config  api.RPMServeStepConfiguration
 	client  loggingclient.LoggingClient
@@ -60,6 +65,26 @@ func (s *rpmServerStep) run(ctx context.Context) error {
 		return fmt.Errorf("could not find source ImageStreamTag for RPM repo deployment: %w", err)
 	}
 
+	deployment, service, route := s.objects(ist.Image.DockerImageReference)
+	if err := s.client.Create(ctx, deployment); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create RPM repo server deployment: %w", err)
+	}
+
+	if err := s.client.Create(ctx, service); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create RPM repo server service: %w", err)
+	}
+	if err := s.client.Create(ctx, route); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create RPM repo server route: %w", err)
+	}
+	if err := waitForDeployment(ctx, ctrlruntimeclient.NewNamespacedClient(s.client, s.jobSpec.Namespace()), deployment.Name); err != nil {
+		return fmt.Errorf("could not wait for RPM repo server to deploy: %w", err)
+	}
+	return waitForRouteReachable(ctx, s.client, s.jobSpec.Namespace(), route.Name, "http", rpmRepoMetadataPath...)
+}
+
+// objects returns the deployment, service and route that serve the RPMs
+// from the given image.
+func (s *rpmServerStep) objects(image string) (*appsapi.Deployment, *coreapi.Service, *routev1.Route) {
 	labelSet := labelsFor(s.jobSpec, map[string]string{AppLabel: RPMRepoName, TTLIgnoreLabel: "true"})
 	selectorSet := map[string]string{
 		AppLabel: RPMRepoName,
@@ -70,18 +95,20 @@ func (s *rpmServerStep) run(ctx context.Context) error {
 		Labels:    labelSet,
 	}
 
-	probe := &coreapi.Probe{
-		ProbeHandler: coreapi.ProbeHandler{
-			HTTPGet: &coreapi.HTTPGetAction{
-				Path:   "/",
-				Port:   intstr.FromInt(8080),
-				Scheme: coreapi.URISchemeHTTP,
+	probeFor := func(path string) *coreapi.Probe {
+		return &coreapi.Probe{
+			ProbeHandler: coreapi.ProbeHandler{
+				HTTPGet: &coreapi.HTTPGetAction{
+					Path:   path,
+					Port:   intstr.FromInt(8080),
+					Scheme: coreapi.URISchemeHTTP,
+				},
 			},
-		},
-		InitialDelaySeconds: 1,
-		PeriodSeconds:       10,
-		SuccessThreshold:    1,
-		TimeoutSeconds:      1,
+			InitialDelaySeconds: 1,
+			PeriodSeconds:       10,
+			SuccessThreshold:    1,
+			TimeoutSeconds:      1,
+		}
 	}
 	oneI64 := int64(1)
 	oneI32 := int32(1)
@@ -101,7 +128,7 @@ func (s *rpmServerStep) run(ctx context.Context) error {
 				Spec: coreapi.PodSpec{
 					Containers: []coreapi.Container{{
 						Name:            RPMRepoName,
-						Image:           ist.Image.DockerImageReference,
+						Image:           image,
 						ImagePullPolicy: coreapi.PullAlways,
 
 						// SimpleHTTPServer is too simple - it can't handle threading. Use a threaded implementation
@@ -178,8 +205,8 @@ fi
 							ContainerPort: 8080,
 							Protocol:      coreapi.ProtocolTCP,
 						}},
-						ReadinessProbe: probe,
-						LivenessProbe:  probe,
+						ReadinessProbe: probeFor("/" + path.Join(rpmRepoMetadataPath...)),
+						LivenessProbe:  probeFor("/"),
 						Resources: coreapi.ResourceRequirements{
 							Requests: coreapi.ResourceList{
 								coreapi.ResourceCPU:    resource.MustParse("50m"),
@@ -196,10 +223,6 @@ fi
 		deployment.OwnerReferences = append(deployment.OwnerReferences, *owner)
 	}
 
-	if err := s.client.Create(ctx, deployment); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("could not create RPM repo server deployment: %w", err)
-	}
-
 	service := &coreapi.Service{
 		ObjectMeta: commonMeta,
 		Spec: coreapi.ServiceSpec{
@@ -215,9 +238,6 @@ fi
 		service.OwnerReferences = append(service.OwnerReferences, *owner)
 	}
 
-	if err := s.client.Create(ctx, service); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("could not create RPM repo server service: %w", err)
-	}
 	route := &routev1.Route{
 		ObjectMeta: commonMeta,
 		Spec: routev1.RouteSpec{
@@ -233,13 +253,7 @@ fi
 		route.OwnerReferences = append(route.OwnerReferences, *owner)
 	}
 
-	if err := s.client.Create(ctx, route); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("could not create RPM repo server route: %w", err)
-	}
-	if err := waitForDeployment(ctx, ctrlruntimeclient.NewNamespacedClient(s.client, s.jobSpec.Namespace()), deployment.Name); err != nil {
-		return fmt.Errorf("could not wait for RPM repo server to deploy: %w", err)
-	}
-	return waitForRouteReachable(ctx, s.client, s.jobSpec.Namespace(), route.Name, "http")
+	return deployment, service, route
 }
 
 func waitForDeployment(ctx context.Context, client ctrlruntimeclient.Client, name string) error {
@@ -344,7 +358,7 @@ func waitForRouteReachable(ctx context.Context, client ctrlruntimeclient.Client,
 			}
 		}
 		resp.Body.Close()
-		if resp.StatusCode >= 400 {
+		if resp.StatusCode != http.StatusOK {
 			logrus.Infof("Waiting for route to become available: %d", resp.StatusCode)
 			select {
 			case <-done:
@@ -353,11 +367,21 @@ func waitForRouteReachable(ctx context.Context, client ctrlruntimeclient.Client,
 				continue
 			}
 		}
-		logrus.Infof("RPMs being served at %s", u)
+		LoggerFor(ctx).Infof("RPMs being served at %s", u)
 		return nil
 	}
 }
 
+// DryRun describes the objects that serve the RPMs.
+func (s *rpmServerStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	deployment, service, route := s.objects(DryRunPlaceholder(fmt.Sprintf("%s:%s", api.PipelineImageStream, s.config.From)))
+	return []api.DryRunAction{
+		{Description: fmt.Sprintf("Create deployment %s to serve the RPMs in %s", deployment.Name, s.config.From), Object: deployment},
+		{Description: fmt.Sprintf("Create service %s", service.Name), Object: service},
+		{Description: fmt.Sprintf("Create route %s and wait for the repository to be reachable", route.Name), Object: route},
+	}, nil
+}
+
 func (s *rpmServerStep) Requires() []api.StepLink {
 	return []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceRPMs)}
 }
@@ -375,6 +399,10 @@ func (s *rpmServerStep) rpmRepoURL() (string, error) {
 }
 
 func (s *rpmServerStep) Provides() api.ParameterMap {
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *rpmServerStep) Parameters() []api.Parameter {
 	var refs []*v1.Refs
 	if s.jobSpec.Refs != nil {
 		refs = append(refs, s.jobSpec.Refs)
@@ -385,10 +413,14 @@ func (s *rpmServerStep) Provides() api.ParameterMap {
 	if len(refs) == 0 {
 		return nil
 	}
-	ret := make(api.ParameterMap)
+	var ret []api.Parameter
 	for _, ref := range refs {
-		rpmByOrgAndRepo := strings.Replace(fmt.Sprintf("RPM_REPO_%s_%s", strings.ToUpper(ref.Org), strings.ToUpper(ref.Repo)), "-", "_", -1)
-		ret[rpmByOrgAndRepo] = s.rpmRepoURL
+		ret = append(ret, api.Parameter{
+			Name:        strings.Replace(fmt.Sprintf("RPM_REPO_%s_%s", strings.ToUpper(ref.Org), strings.ToUpper(ref.Repo)), "-", "_", -1),
+			Description: fmt.Sprintf("URL of the repository serving the RPMs built from %s/%s", ref.Org, ref.Repo),
+			Value:       s.rpmRepoURL,
+			Link:        api.RPMRepoLink(),
+		})
 	}
 	return ret
 }
@@ -408,6 +440,10 @@ func admittedHostForRoute(client ctrlruntimeclient.Client, namespace, name strin
 	if err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
 		route := &routev1.Route{}
 		if err := client.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, route); err != nil {
+			if kerrors.IsNotFound(err) {
+				// the route may be created by a step that did not start yet
+				return false, nil
+			}
 			return false, fmt.Errorf("could not get route %s: %w", name, err)
 		}
 		if host, ok := admittedRoute(route); ok {
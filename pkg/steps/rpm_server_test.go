@@ -1,7 +1,12 @@
 package steps
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -98,3 +103,58 @@ func TestRPMServerStepProvides(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitForRouteReachable(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		handler     http.HandlerFunc
+		expectedErr bool
+	}{{
+		name: "repository metadata served",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/repodata/repomd.xml" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	}, {
+		name: "only index served",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+		expectedErr: true,
+	}, {
+		name: "non-200 response is not ready",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+			host := strings.TrimPrefix(server.URL, "http://")
+			client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(
+				&routev1.Route{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: RPMRepoName},
+					Status: routev1.RouteStatus{
+						Ingress: []routev1.RouteIngress{{
+							Host: host,
+							Conditions: []routev1.RouteIngressCondition{{
+								Type:   routev1.RouteAdmitted,
+								Status: corev1.ConditionTrue,
+							}},
+						}},
+					},
+				},
+			).Build()
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			err := waitForRouteReachable(ctx, client, "ns", RPMRepoName, "http", rpmRepoMetadataPath...)
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/steps/labelingclient"
 )
 
 type message struct {
@@ -19,7 +20,25 @@ type message struct {
 	stepDetails     api.CIOperatorStepDetails
 }
 
-func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
+// Run executes the graph. Every step starts as soon as all the steps that
+// create what it requires have succeeded, so independent steps, like the
+// builds of images and the tests that do not need them, run at the same time.
+// As their logs interleave, steps report their progress with LoggerFor, which
+// tags the lines with the name of the step. The observers are notified of the progress of the
+// steps, and of what they created if they implement LinkObserver.
+func Run(ctx context.Context, graph api.StepGraph, observers ...StepObserver) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
+	return RunWithConcurrency(ctx, graph, 0, observers...)
+}
+
+// RunWithConcurrency executes the graph like Run, but when maxConcurrency is
+// positive, at most that many steps run at once. Steps that are ready to run
+// wait for one of the running steps to finish. Steps waiting for gates or
+// leases only count once they passed the gates and acquired the leases.
+func RunWithConcurrency(ctx context.Context, graph api.StepGraph, maxConcurrency int, observers ...StepObserver) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
+	var slots chan struct{}
+	if maxConcurrency > 0 {
+		slots = make(chan struct{}, maxConcurrency)
+	}
 	var seen []api.StepLink
 	executionResults := make(chan message)
 	done := make(chan bool)
@@ -34,7 +53,7 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 
 	start := time.Now()
 	for _, root := range graph {
-		go runStep(ctx, root, executionResults)
+		go runStep(ctx, root, executionResults, observers, slots)
 	}
 
 	suites := &junit.TestSuites{
@@ -50,7 +69,11 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 	for {
 		select {
 		case <-ctxDone:
-			executionErrors = append(executionErrors, results.ForReason("interrupted").ForError(errors.New("execution cancelled")))
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				executionErrors = append(executionErrors, results.ForReason("timed_out").ForError(errors.New("execution timed out")))
+			} else {
+				executionErrors = append(executionErrors, results.ForReason("interrupted").ForError(errors.New("execution cancelled")))
+			}
 			interrupted = true
 			ctxDone = nil
 		case out := <-executionResults:
@@ -61,6 +84,7 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 				executionErrors = append(executionErrors, results.ForReason("step_failed").WithError(out.err).Errorf("step %s failed: %v", out.node.Step.Name(), out.err))
 			} else {
 				seen = append(seen, out.node.Step.Creates()...)
+				notifyLinksCreated(observers, out.node.Step)
 				if !interrupted {
 					for _, child := range out.node.Children {
 						// we can trigger a child if all of it's pre-requisites
@@ -70,7 +94,7 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 						// when the last of its parents finishes.
 						if api.HasAllLinks(child.Step.Requires(), seen) {
 							wg.Add(1)
-							go runStep(ctx, child, executionResults)
+							go runStep(ctx, child, executionResults, observers, slots)
 						}
 					}
 				}
@@ -116,14 +140,84 @@ type SubStepReporter interface {
 	SubSteps() []api.CIOperatorStepDetailInfo
 }
 
-func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
+// ConditionalPostStep is implemented by post-steps that run for a given
+// outcome of the execution of the graph. Post-steps that do not implement
+// it only run when the graph succeeded.
+type ConditionalPostStep interface {
+	RunIf() api.RunCondition
+}
+
+// PostStepShouldRun determines whether a post-step runs after the graph
+// failed or succeeded.
+func PostStepShouldRun(step api.Step, failed bool) bool {
+	condition := api.RunIfSuccess
+	if conditional, ok := step.(ConditionalPostStep); ok && conditional.RunIf() != "" {
+		condition = conditional.RunIf()
+	}
+	return condition.ShouldRun(failed)
+}
+
+// AttemptNameReporter allows steps to report the names they gave the objects
+// they created for this attempt.
+type AttemptNameReporter interface {
+	AttemptNames() map[string]string
+}
+
+// TimeoutReporter allows steps to bound how long they may run. A step running
+// for longer is cancelled, which deletes its pods and builds, and fails with a
+// timeout. Steps that do not depend on it continue to run.
+type TimeoutReporter interface {
+	Timeout() time.Duration
+}
+
+// RunDependentProvider is implemented by steps providing parameters whose
+// values are only known once the step ran, like the names of leased resources
+// or the outputs of multi-stage steps. Such steps are never skipped.
+type RunDependentProvider interface {
+	ProvidesFromRun() bool
+}
+
+func providesFromRun(step api.Step) bool {
+	provider, ok := step.(RunDependentProvider)
+	return ok && provider.ProvidesFromRun()
+}
+
+func runStep(ctx context.Context, node *api.StepNode, out chan<- message, observers []StepObserver, slots chan struct{}) {
+	ctx = labelingclient.WithStep(ctx, node.Step.Name())
+	if slots != nil && defersSlot(node.Step) {
+		slot := &deferredSlot{slots: slots}
+		ctx = context.WithValue(ctx, deferredSlotKey{}, slot)
+		defer func() {
+			if slot.held {
+				<-slots
+			}
+		}()
+	} else if slots != nil {
+		if err := acquireSlot(ctx, slots); err != nil {
+			out <- message{
+				node: node,
+				err:  err,
+				stepDetails: api.CIOperatorStepDetails{
+					CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: node.Step.Name(), Description: node.Step.Description()},
+				},
+			}
+			return
+		}
+		defer func() { <-slots }()
+	}
+	for _, observer := range observers {
+		observer.StepStarted(node.Step)
+	}
 	start := time.Now()
-	err := node.Step.Run(ctx)
+	err := runWithTimeout(ctx, node.Step)
+	duration := time.Since(start)
+	for _, observer := range observers {
+		observer.StepFinished(node.Step, err)
+	}
 	var additionalTests []*junit.TestCase
 	if reporter, ok := node.Step.(SubtestReporter); ok {
 		additionalTests = reporter.SubTests()
 	}
-	duration := time.Since(start)
 	failed := err != nil
 	finishedAt := start.Add(duration)
 
@@ -132,6 +226,11 @@ func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 		subSteps = x.SubSteps()
 	}
 
+	var attemptNames map[string]string
+	if x, ok := node.Step.(AttemptNameReporter); ok {
+		attemptNames = x.AttemptNames()
+	}
+
 	out <- message{
 		node:            node,
 		duration:        duration,
@@ -139,15 +238,97 @@ func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 		additionalTests: additionalTests,
 		stepDetails: api.CIOperatorStepDetails{
 			CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
-				StepName:    node.Step.Name(),
-				Description: node.Step.Description(),
-				StartedAt:   &start,
-				FinishedAt:  &finishedAt,
-				Duration:    &duration,
-				Manifests:   node.Step.Objects(),
-				Failed:      &failed,
+				StepName:     node.Step.Name(),
+				Description:  node.Step.Description(),
+				StartedAt:    &start,
+				FinishedAt:   &finishedAt,
+				Duration:     &duration,
+				Manifests:    node.Step.Objects(),
+				Failed:       &failed,
+				AttemptNames: attemptNames,
 			},
 			Substeps: subSteps,
 		},
 	}
 }
+
+// notifyLinksCreated notifies the observers that care about what the step
+// created, before the steps that require it are started.
+func notifyLinksCreated(observers []StepObserver, step api.Step) {
+	links := step.Creates()
+	if len(links) == 0 {
+		return
+	}
+	for _, observer := range observers {
+		if linkObserver, ok := observer.(LinkObserver); ok {
+			linkObserver.LinksCreated(step, links)
+		}
+	}
+}
+
+// acquireSlot waits for one of the slots of the running steps to be free.
+func acquireSlot(ctx context.Context, slots chan struct{}) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+	LoggerFor(ctx).Debugf("Waiting for one of the %d running steps to finish.", cap(slots))
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return results.ForReason("interrupted").WithError(ctx.Err()).Errorf("the execution was cancelled before the step started: %v", ctx.Err())
+	}
+}
+
+// slotDeferrer is implemented by steps that wait, like for gates or leases,
+// before running the step they wrap. They take the slot of the running steps
+// with acquireDeferredSlot once done waiting, instead of holding it while
+// they wait.
+type slotDeferrer interface {
+	defersSlot() bool
+}
+
+func defersSlot(step api.Step) bool {
+	deferrer, ok := step.(slotDeferrer)
+	return ok && deferrer.defersSlot()
+}
+
+type deferredSlotKey struct{}
+
+// deferredSlot is the slot of the running steps a step defers taking.
+type deferredSlot struct {
+	slots chan struct{}
+	held  bool
+}
+
+// acquireDeferredSlot takes the slot the step running with the context
+// deferred. It does nothing when the steps that run at once are not bounded.
+func acquireDeferredSlot(ctx context.Context) error {
+	slot, ok := ctx.Value(deferredSlotKey{}).(*deferredSlot)
+	if !ok || slot.held {
+		return nil
+	}
+	if err := acquireSlot(ctx, slot.slots); err != nil {
+		return err
+	}
+	slot.held = true
+	return nil
+}
+
+// runWithTimeout runs the step, cancelling it when it exceeds its timeout.
+func runWithTimeout(ctx context.Context, step api.Step) error {
+	reporter, ok := step.(TimeoutReporter)
+	if !ok || reporter.Timeout() <= 0 {
+		return step.Run(ctx)
+	}
+	timeout := reporter.Timeout()
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := step.Run(stepCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return results.ForReason("step_timed_out").WithError(err).Errorf("step %s timed out after %s: %v", step.Name(), timeout, err)
+	}
+	return err
+}
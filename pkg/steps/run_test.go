@@ -8,6 +8,7 @@ import (
 	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -15,6 +16,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
 type fakeStep struct {
@@ -337,3 +339,230 @@ func TestStepsRun(t *testing.T) {
 		})
 	}
 }
+
+// slowStep blocks until it is cancelled, bounded by its timeout.
+type slowStep struct {
+	fakeStep
+	timeout time.Duration
+}
+
+func (s *slowStep) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *slowStep) Timeout() time.Duration { return s.timeout }
+
+func TestStepsRunTimeout(t *testing.T) {
+	slow := &slowStep{fakeStep: fakeStep{name: "slow", creates: []api.StepLink{api.InternalImageLink("slow")}}, timeout: 10 * time.Millisecond}
+	other := &fakeStep{name: "other", creates: []api.StepLink{api.InternalImageLink("other")}}
+	child := &fakeStep{name: "child", requires: []api.StepLink{api.InternalImageLink("slow")}}
+	suites, _, errs := Run(context.Background(), api.BuildGraph([]api.Step{slow, other, child}))
+
+	expected := []error{results.ForReason("step_failed").WithError(results.ForReason("step_timed_out").ForError(context.DeadlineExceeded)).Errorf("step slow failed: step slow timed out after 10ms: context deadline exceeded")}
+	testhelper.Diff(t, "errors", errs, expected, testhelper.EquateErrorMessage)
+	if reasons := results.Reasons(errs...); len(reasons) != 1 || reasons[0] != "step_failed:step_timed_out" {
+		t.Errorf("unexpected reasons: %v", reasons)
+	}
+	if other.numRuns != 1 {
+		t.Errorf("the independent step ran %d times", other.numRuns)
+	}
+	if child.numRuns != 0 {
+		t.Errorf("the step depending on the timed out step ran %d times", child.numRuns)
+	}
+	if suites.Suites[0].NumFailed != 1 {
+		t.Errorf("expected the timed out step to fail in JUnit, got %d failures", suites.Suites[0].NumFailed)
+	}
+}
+
+func TestStepsRunGlobalTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	slow := &slowStep{fakeStep: fakeStep{name: "slow"}}
+	_, _, errs := Run(ctx, api.BuildGraph([]api.Step{slow}))
+	var reasons []string
+	for _, err := range errs {
+		reasons = append(reasons, results.Reasons(err)...)
+	}
+	sort.Strings(reasons)
+	testhelper.Diff(t, "reasons", reasons, []string{"step_failed", "timed_out"})
+}
+
+// concurrentStep records how many steps run at the same time as it does.
+type concurrentStep struct {
+	fakeStep
+	running, maxRunning *int
+	lock                *sync.Mutex
+}
+
+func (s *concurrentStep) Run(ctx context.Context) error {
+	s.lock.Lock()
+	*s.running++
+	if *s.running > *s.maxRunning {
+		*s.maxRunning = *s.running
+	}
+	s.lock.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	s.lock.Lock()
+	*s.running--
+	s.lock.Unlock()
+	return s.fakeStep.Run(ctx)
+}
+
+func TestStepsRunWithConcurrency(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		maxConcurrency int
+		expectedMax    int
+	}{{
+		name:        "unlimited",
+		expectedMax: 4,
+	}, {
+		name:           "limited",
+		maxConcurrency: 2,
+		expectedMax:    2,
+	}, {
+		name:           "serial",
+		maxConcurrency: 1,
+		expectedMax:    1,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var running, maxRunning int
+			lock := &sync.Mutex{}
+			step := func(name string, requires ...api.StepLink) *concurrentStep {
+				return &concurrentStep{
+					fakeStep:   fakeStep{name: name, requires: requires, creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReference(name))}},
+					running:    &running,
+					maxRunning: &maxRunning,
+					lock:       lock,
+				}
+			}
+			// four independent image builds and a test that needs two of them
+			steps := []*concurrentStep{step("a"), step("b"), step("c"), step("d"), step("test", api.InternalImageLink("a"), api.InternalImageLink("b"))}
+			var graph []api.Step
+			for _, s := range steps {
+				graph = append(graph, s)
+			}
+			_, _, errs := RunWithConcurrency(context.Background(), api.BuildGraph(graph), tc.maxConcurrency)
+			if len(errs) != 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			for _, s := range steps {
+				if s.numRuns != 1 {
+					t.Errorf("step %s ran %d times", s.name, s.numRuns)
+				}
+			}
+			testhelper.Diff(t, "maximum concurrent steps", maxRunning, tc.expectedMax)
+		})
+	}
+}
+
+func TestStepsRunWithConcurrencyCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// whichever step starts first blocks the other one until the timeout
+	first := &slowStep{fakeStep: fakeStep{name: "first"}}
+	second := &slowStep{fakeStep: fakeStep{name: "second"}}
+	_, _, errs := RunWithConcurrency(ctx, api.BuildGraph([]api.Step{first, second}), 1)
+	var reasons []string
+	for _, err := range errs {
+		reasons = append(reasons, results.Reasons(err)...)
+	}
+	sort.Strings(reasons)
+	testhelper.Diff(t, "reasons", reasons, []string{"step_failed", "step_failed:interrupted", "timed_out"})
+	var cancelled int
+	for _, err := range errs {
+		if errors.Is(err, context.DeadlineExceeded) {
+			cancelled++
+		}
+	}
+	testhelper.Diff(t, "errors caused by the cancellation", cancelled, 2)
+}
+
+// waitingStep waits for another step to run before it takes its slot, like
+// the steps waiting for gates or leases.
+type waitingStep struct {
+	fakeStep
+	waitFor <-chan struct{}
+}
+
+func (s *waitingStep) defersSlot() bool { return true }
+
+func (s *waitingStep) Run(ctx context.Context) error {
+	select {
+	case <-s.waitFor:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := acquireDeferredSlot(ctx); err != nil {
+		return err
+	}
+	return s.fakeStep.Run(ctx)
+}
+
+// signallingStep closes its channel when it runs.
+type signallingStep struct {
+	fakeStep
+	ran chan struct{}
+}
+
+func (s *signallingStep) Run(ctx context.Context) error {
+	close(s.ran)
+	return s.fakeStep.Run(ctx)
+}
+
+func TestStepsRunWithConcurrencyDeferredSlot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ran := make(chan struct{})
+	// the waiting step would hold the only slot until the timeout if it took it before waiting
+	waiting := &waitingStep{fakeStep: fakeStep{name: "waiting"}, waitFor: ran}
+	signalling := &signallingStep{fakeStep: fakeStep{name: "signalling"}, ran: ran}
+	_, _, errs := RunWithConcurrency(ctx, api.BuildGraph([]api.Step{waiting, signalling}), 1)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	testhelper.Diff(t, "runs", []int{waiting.numRuns, signalling.numRuns}, []int{1, 1})
+}
+
+type conditionalStep struct {
+	fakeStep
+	runIf api.RunCondition
+}
+
+func (s *conditionalStep) RunIf() api.RunCondition { return s.runIf }
+
+func TestPostStepShouldRun(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		step      api.Step
+		onSuccess bool
+		onFailure bool
+	}{{
+		name:      "unconditional step runs on success",
+		step:      &fakeStep{name: "promote"},
+		onSuccess: true,
+	}, {
+		name:      "step without a condition runs on success",
+		step:      &conditionalStep{fakeStep: fakeStep{name: "promote"}},
+		onSuccess: true,
+	}, {
+		name:      "step runs on failure",
+		step:      &conditionalStep{fakeStep: fakeStep{name: "incident"}, runIf: api.RunIfFailure},
+		onFailure: true,
+	}, {
+		name:      "step runs always",
+		step:      &conditionalStep{fakeStep: fakeStep{name: "notify"}, runIf: api.RunIfAlways},
+		onSuccess: true,
+		onFailure: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := PostStepShouldRun(tc.step, false); actual != tc.onSuccess {
+				t.Errorf("expected to run on success: %v, got %v", tc.onSuccess, actual)
+			}
+			if actual := PostStepShouldRun(tc.step, true); actual != tc.onFailure {
+				t.Errorf("expected to run on failure: %v, got %v", tc.onFailure, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package scopeclient
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// IsClusterScoped asks the mapper, which discovers the resources the cluster
+// serves, whether objects of the kind are cluster-scoped.
+func IsClusterScoped(mapper meta.RESTMapper, gvk schema.GroupVersionKind) (bool, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, fmt.Errorf("could not determine whether %s is namespaced: %w", gvk.Kind, err)
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameRoot, nil
+}
+
+// ValidateObjectNamespaced ensures the object is namespaced, unless its kind
+// is allowed.
+func ValidateObjectNamespaced(mapper meta.RESTMapper, gvk schema.GroupVersionKind, name string, allowed sets.Set[string]) error {
+	if allowed.Has(gvk.Kind) {
+		return nil
+	}
+	clusterScoped, err := IsClusterScoped(mapper, gvk)
+	if err != nil {
+		return err
+	}
+	if clusterScoped {
+		return fmt.Errorf("%s %s is cluster-scoped, add the kind to 'allowed_cluster_scoped_kinds' to create it", gvk.Kind, name)
+	}
+	return nil
+}
+
+// Wrap wraps the upstream client, refusing to create cluster-scoped objects
+// through it unless their kind is allowed. The scope of every kind is looked
+// up with the RESTMapper of the upstream client, and the objects of template
+// instances are checked as well, since they are created on our behalf.
+func Wrap(upstream ctrlruntimeclient.WithWatch, allowed []string) ctrlruntimeclient.WithWatch {
+	return &client{WithWatch: upstream, allowed: sets.New[string](allowed...)}
+}
+
+type client struct {
+	ctrlruntimeclient.WithWatch
+	allowed sets.Set[string]
+}
+
+func (c *client) Create(ctx context.Context, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	if err := c.admit(obj); err != nil {
+		return results.ForReason("creating_cluster_scoped_object").ForError(err)
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+func (c *client) admit(obj ctrlruntimeclient.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return fmt.Errorf("could not determine the kind of %s: %w", obj.GetName(), err)
+	}
+	if err := ValidateObjectNamespaced(c.RESTMapper(), gvk, obj.GetName(), c.allowed); err != nil {
+		return err
+	}
+	instance, ok := obj.(*templateapi.TemplateInstance)
+	if !ok {
+		return nil
+	}
+	for i, raw := range instance.Spec.Template.Objects {
+		var object metav1.PartialObjectMetadata
+		if err := yaml.Unmarshal(raw.Raw, &object); err != nil {
+			return fmt.Errorf("could not decode object %d of template instance %s: %w", i, obj.GetName(), err)
+		}
+		if err := ValidateObjectNamespaced(c.RESTMapper(), object.GroupVersionKind(), object.Name, c.allowed); err != nil {
+			return fmt.Errorf("template instance %s: %w", obj.GetName(), err)
+		}
+	}
+	return nil
+}
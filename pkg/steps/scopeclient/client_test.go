@@ -0,0 +1,85 @@
+package scopeclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	rbacapi "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestCreate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{coreapi.AddToScheme, rbacapi.AddToScheme, templateapi.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "template.openshift.io", Version: "v1", Kind: "TemplateInstance"}, meta.RESTScopeNamespace)
+
+	instance := func(object string) *templateapi.TemplateInstance {
+		return &templateapi.TemplateInstance{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "instance"},
+			Spec: templateapi.TemplateInstanceSpec{Template: templateapi.Template{
+				Objects: []runtime.RawExtension{{Raw: []byte(object)}},
+			}},
+		}
+	}
+	var testCases = []struct {
+		name        string
+		allowed     []string
+		obj         ctrlruntimeclient.Object
+		expectedErr error
+	}{
+		{
+			name: "namespaced object",
+			obj:  &coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}},
+		},
+		{
+			name:        "cluster-scoped object",
+			obj:         &rbacapi.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "admin"}},
+			expectedErr: errors.New("ClusterRole admin is cluster-scoped, add the kind to 'allowed_cluster_scoped_kinds' to create it"),
+		},
+		{
+			name:    "allowed cluster-scoped object",
+			allowed: []string{"ClusterRole"},
+			obj:     &rbacapi.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "admin"}},
+		},
+		{
+			name: "namespaced object of a template instance",
+			obj:  instance(`{"kind":"Role","apiVersion":"rbac.authorization.k8s.io/v1","metadata":{"name":"role"}}`),
+		},
+		{
+			name:        "cluster-scoped object of a template instance",
+			obj:         instance(`{"kind":"ClusterRole","apiVersion":"rbac.authorization.k8s.io/v1","metadata":{"name":"admin"}}`),
+			expectedErr: errors.New("template instance instance: ClusterRole admin is cluster-scoped, add the kind to 'allowed_cluster_scoped_kinds' to create it"),
+		},
+		{
+			name:        "unknown kind",
+			obj:         instance(`{"kind":"Widget","apiVersion":"example.com/v1","metadata":{"name":"widget"}}`),
+			expectedErr: errors.New(`template instance instance: could not determine whether Widget is namespaced: no matches for kind "Widget" in version "example.com/v1"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := Wrap(fakectrlruntimeclient.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).Build(), tc.allowed)
+			err := client.Create(context.Background(), tc.obj)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+		})
+	}
+}
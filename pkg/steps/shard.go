@@ -0,0 +1,421 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+const (
+	shardIndexEnv = "SHARD_INDEX"
+	shardTotalEnv = "SHARD_TOTAL"
+	// shardTestsEnv holds the newline-separated tests assigned to the shard
+	// when the test has timings from previous runs.
+	shardTestsEnv = "SHARD_TESTS"
+	// shardExcludedTestsEnv holds the newline-separated tests assigned to the
+	// other shards. It is given to the shard with the least expected duration,
+	// which runs all tests but these so that tests without timings are run.
+	shardExcludedTestsEnv = "SHARD_EXCLUDED_TESTS"
+	// shardTimingsFileEnv is where a shard may write the JSON timings of the
+	// tests it ran, mapping the name of each test to its duration in seconds.
+	shardTimingsFileEnv = "SHARD_TIMINGS_FILE"
+	// shardTimingsFile is the name of the file the timings are written to in
+	// the artifacts of the shard, which are gathered into the artifacts of the
+	// pod when it completes.
+	shardTimingsFile = "shard-timings.json"
+	// shardArtifactsPath is where the artifacts gathered from a shard are
+	// mounted in its test container.
+	shardArtifactsPath = "/tmp/artifacts"
+
+	// ShardTimingsArtifactDir is the directory in the artifacts holding the
+	// timings of the tests of every sharded test, in <test>.json.
+	ShardTimingsArtifactDir = "shard-timings"
+)
+
+// TestTimings maps the names of tests to their duration in seconds.
+type TestTimings map[string]float64
+
+// partitionTests assigns the tests to the shards so that the expected
+// duration of all shards is balanced, by assigning the longest tests first
+// to the shard with the least expected duration. It also returns the shard
+// with the least expected duration once all tests are assigned.
+func partitionTests(timings TestTimings, shards int) ([][]string, int) {
+	tests := make([]string, 0, len(timings))
+	for test := range timings {
+		tests = append(tests, test)
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if timings[tests[i]] != timings[tests[j]] {
+			return timings[tests[i]] > timings[tests[j]]
+		}
+		return tests[i] < tests[j]
+	})
+	partition := make([][]string, shards)
+	durations := make([]float64, shards)
+	for _, test := range tests {
+		shortest := shortestShard(durations)
+		partition[shortest] = append(partition[shortest], test)
+		durations[shortest] += timings[test]
+	}
+	return partition, shortestShard(durations)
+}
+
+func shortestShard(durations []float64) int {
+	shortest := 0
+	for i := range durations {
+		if durations[i] < durations[shortest] {
+			shortest = i
+		}
+	}
+	return shortest
+}
+
+// loadTestTimings loads the timings for the test from the directory, if any.
+func loadTestTimings(dir, test string) (TestTimings, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, test+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var timings TestTimings
+	if err := json.Unmarshal(raw, &timings); err != nil {
+		return nil, fmt.Errorf("could not parse the timings for %s: %w", test, err)
+	}
+	return timings, nil
+}
+
+// shardedTestStep runs a container test split into shards, one pod per
+// shard, in parallel. The sub-tests of all shards are reported together
+// and the test fails when any of the shards fails. When timings of the
+// tests are known from previous runs, the tests are partitioned between
+// the shards by their expected duration and the timings are regenerated
+// from what the shards report.
+type shardedTestStep struct {
+	as         string
+	shards     []*podStep
+	timingsDir string
+	client     kubernetes.PodClient
+	timeout    time.Duration
+}
+
+func (s *shardedTestStep) Inputs() (api.InputDefinition, error) {
+	return nil, nil
+}
+
+func (*shardedTestStep) Validate() error { return nil }
+
+func (s *shardedTestStep) Timeout() time.Duration { return s.timeout }
+
+func (s *shardedTestStep) Run(ctx context.Context) error {
+	return results.ForReason("running_shards").ForError(s.run(ctx))
+}
+
+func (s *shardedTestStep) run(ctx context.Context) error {
+	timings, err := s.assignTests()
+	if err != nil {
+		return err
+	}
+	errs := make([]error, len(s.shards))
+	var wg sync.WaitGroup
+	for i, shard := range s.shards {
+		wg.Add(1)
+		go func(i int, shard *podStep) {
+			defer wg.Done()
+			if err := shard.Run(ctx); err != nil {
+				errs[i] = fmt.Errorf("shard %d/%d failed: %w", shard.shard.index+1, shard.shard.total, err)
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+	if err := s.saveTimings(timings); err != nil {
+		logrus.WithError(err).Warnf("Could not save the timings of test %s.", s.as)
+	}
+	if err := s.mergeJUnit(); err != nil {
+		logrus.WithError(err).Warnf("Could not merge the JUnit results of the shards of test %s.", s.as)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// assignTests partitions the tests between the shards by their timings. Tests
+// without timings are left to the shard with the least expected duration.
+func (s *shardedTestStep) assignTests() (TestTimings, error) {
+	timings, err := loadTestTimings(s.timingsDir, s.as)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the timings of test %s: %w", s.as, err)
+	}
+	if len(timings) == 0 {
+		return nil, nil
+	}
+	partition, shortest := partitionTests(timings, len(s.shards))
+	for i, tests := range partition {
+		s.shards[i].shard.tests = tests
+		if i != shortest {
+			s.shards[shortest].shard.excluded = append(s.shards[shortest].shard.excluded, tests...)
+		}
+	}
+	sort.Strings(s.shards[shortest].shard.excluded)
+	return timings, nil
+}
+
+// saveTimings records the timings the shards reported in their artifacts,
+// keeping the previous timings of tests that were not reported.
+func (s *shardedTestStep) saveTimings(previous TestTimings) error {
+	artifactDir, set := api.Artifacts()
+	if !set {
+		return nil
+	}
+	timings := TestTimings{}
+	for test, duration := range previous {
+		timings[test] = duration
+	}
+	for _, shard := range s.shards {
+		reported, err := reportedTimings(shard.shard.timingsFile)
+		if err != nil {
+			return fmt.Errorf("could not read the timings of shard %d: %w", shard.shard.index+1, err)
+		}
+		for test, duration := range reported {
+			timings[test] = duration
+		}
+	}
+	if len(timings) == 0 {
+		return nil
+	}
+	raw, err := json.MarshalIndent(timings, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(artifactDir, ShardTimingsArtifactDir)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, s.as+".json"), raw, 0644)
+}
+
+// reportedTimings returns the timings a shard wrote to its timings file,
+// if any.
+func reportedTimings(file string) (TestTimings, error) {
+	if file == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var timings TestTimings
+	if err := json.Unmarshal(raw, &timings); err != nil {
+		return nil, err
+	}
+	return timings, nil
+}
+
+// isJUnitFile tells whether a file gathered from the artifacts of a shard
+// holds JUnit results.
+func isJUnitFile(name string) bool {
+	return strings.HasPrefix(name, "junit") && strings.HasSuffix(name, ".xml")
+}
+
+// mergeJUnit merges the JUnit results the shards gathered to their artifacts
+// into one suite for the test, saved in junit_<test>.xml in the artifacts.
+// The files of the shards are removed once merged, so that their results
+// are not reported twice.
+func (s *shardedTestStep) mergeJUnit() error {
+	artifactDir, set := api.Artifacts()
+	if !set {
+		return nil
+	}
+	merged := &junit.TestSuite{Name: s.as}
+	var files []string
+	for _, shard := range s.shards {
+		if shard.shard.artifactDir == "" {
+			continue
+		}
+		err := filepath.WalkDir(shard.shard.artifactDir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || !isJUnitFile(entry.Name()) {
+				return err
+			}
+			suites, err := readJUnitSuites(path)
+			if err != nil {
+				return fmt.Errorf("could not read %s: %w", path, err)
+			}
+			for _, suite := range suites {
+				mergeSuite(merged, suite)
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("could not gather the JUnit results of shard %d: %w", shard.shard.index+1, err)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	raw, err := xml.MarshalIndent(&junit.TestSuites{Suites: []*junit.TestSuite{merged}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal the JUnit results: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(artifactDir, fmt.Sprintf("junit_%s.xml", s.as)), raw, 0644); err != nil {
+		return err
+	}
+	var errs []error
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// readJUnitSuites reads the suites in a JUnit file, which may hold a single
+// suite or a collection of them.
+func readJUnitSuites(path string) ([]*junit.TestSuite, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	suites := &junit.TestSuites{}
+	if err := xml.Unmarshal(raw, suites); err == nil {
+		return suites.Suites, nil
+	}
+	suite := &junit.TestSuite{}
+	if err := xml.Unmarshal(raw, suite); err != nil {
+		return nil, err
+	}
+	return []*junit.TestSuite{suite}, nil
+}
+
+// mergeSuite adds the test cases of the suite and of its children to the
+// merged suite. The totals are counted from the test cases, as those of a
+// suite may or may not include its children.
+func mergeSuite(merged, suite *junit.TestSuite) {
+	for _, test := range suite.TestCases {
+		merged.NumTests++
+		if test.SkipMessage != nil {
+			merged.NumSkipped++
+		}
+		if test.FailureOutput != nil {
+			merged.NumFailed++
+		}
+		merged.Duration += test.Duration
+		merged.TestCases = append(merged.TestCases, test)
+	}
+	for _, child := range suite.Children {
+		mergeSuite(merged, child)
+	}
+}
+
+// addShardTimingsArtifact gives the test container of a shard a file to write
+// its timings to, which is gathered into the artifacts once the pod
+// completes. The termination message is too small to hold them.
+func addShardTimingsArtifact(pod *coreapi.Pod) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, coreapi.Volume{Name: "artifacts", VolumeSource: coreapi.VolumeSource{EmptyDir: &coreapi.EmptyDirVolumeSource{}}})
+	container := &pod.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, coreapi.VolumeMount{Name: "artifacts", MountPath: shardArtifactsPath})
+	container.Env = append(container.Env, coreapi.EnvVar{Name: shardTimingsFileEnv, Value: filepath.Join(shardArtifactsPath, shardTimingsFile)})
+	addArtifactsToPod(pod)
+}
+
+// AttemptNames reports the names of the pods of all shards for this attempt.
+func (s *shardedTestStep) AttemptNames() map[string]string {
+	var ret map[string]string
+	for _, shard := range s.shards {
+		for name, attemptName := range shard.AttemptNames() {
+			if ret == nil {
+				ret = map[string]string{}
+			}
+			ret[name] = attemptName
+		}
+	}
+	return ret
+}
+
+// SubTests merges the sub-tests of all shards.
+func (s *shardedTestStep) SubTests() []*junit.TestCase {
+	var subTests []*junit.TestCase
+	for _, shard := range s.shards {
+		subTests = append(subTests, shard.SubTests()...)
+	}
+	return subTests
+}
+
+// DryRun describes the pods of all shards.
+func (s *shardedTestStep) DryRun(ctx context.Context) ([]api.DryRunAction, error) {
+	if _, err := s.assignTests(); err != nil {
+		return nil, err
+	}
+	var actions []api.DryRunAction
+	for _, shard := range s.shards {
+		shardActions, err := shard.DryRun(ctx)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, shardActions...)
+	}
+	return actions, nil
+}
+
+func (s *shardedTestStep) Requires() []api.StepLink {
+	return s.shards[0].Requires()
+}
+
+func (s *shardedTestStep) Creates() []api.StepLink {
+	return []api.StepLink{}
+}
+
+func (s *shardedTestStep) Provides() api.ParameterMap {
+	return nil
+}
+
+func (s *shardedTestStep) Name() string { return s.as }
+
+func (s *shardedTestStep) Description() string {
+	return fmt.Sprintf("Run test %s in %d shards", s.as, len(s.shards))
+}
+
+func (s *shardedTestStep) Objects() []ctrlruntimeclient.Object {
+	return s.client.Objects()
+}
+
+// ShardedTestStep runs the container test in config.Shards pods in parallel,
+// or in a single pod when it is not sharded. Timings of the tests from
+// previous runs are loaded from <test>.json in timingsDir, when set.
+func ShardedTestStep(config api.TestStepConfiguration, resources api.ResourceConfiguration, client kubernetes.PodClient, jobSpec *api.JobSpec, params api.Parameters, nodeName, timingsDir string) api.Step {
+	if config.Shards <= 1 {
+		return TestStep(config, resources, client, jobSpec, params, nodeName)
+	}
+	step := &shardedTestStep{as: config.As, timingsDir: timingsDir, client: client, timeout: config.TestTimeout()}
+	for i := 0; i < config.Shards; i++ {
+		shardStep := TestStep(config, resources, client, jobSpec, params, nodeName).(*podStep)
+		shardStep.shard = &shard{index: i, total: config.Shards}
+		step.shards = append(step.shards, shardStep)
+	}
+	return step
+}
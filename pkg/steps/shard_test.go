@@ -0,0 +1,297 @@
+package steps
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	fakerest "k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/tools/remotecommand"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+// shardFailingClient fails the pods of the shards with the given suffix and
+// records the timings each shard reports for the tests assigned to it.
+type shardFailingClient struct {
+	ctrlruntimeclient.WithWatch
+	failing string
+
+	lock    sync.Mutex
+	timings map[string]string
+}
+
+func (c *shardFailingClient) Create(ctx context.Context, o ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
+	if pod, ok := o.(*corev1.Pod); ok {
+		var timings []string
+		for _, env := range pod.Spec.Containers[0].Env {
+			if env.Name == shardTestsEnv {
+				for _, test := range strings.Split(env.Value, "\n") {
+					timings = append(timings, fmt.Sprintf("%q: 10", test))
+				}
+			}
+		}
+		c.lock.Lock()
+		c.timings[pod.Name] = "{" + strings.Join(timings, ",") + "}"
+		c.lock.Unlock()
+		failing := strings.HasSuffix(pod.Name, c.failing)
+		pod.Status.Phase = corev1.PodSucceeded
+		if failing {
+			pod.Status.Phase = corev1.PodFailed
+		}
+		for _, container := range pod.Spec.Containers {
+			terminated := &corev1.ContainerStateTerminated{}
+			if failing && container.Name == pod.Spec.Containers[0].Name {
+				terminated.ExitCode = 1
+			}
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{Name: container.Name, State: corev1.ContainerState{Terminated: terminated}})
+		}
+	}
+	return c.WithWatch.Create(ctx, o, opts...)
+}
+
+// shardPodClient serves the timings recorded for a shard from its artifacts container.
+type shardPodClient struct {
+	kubernetes.PodClient
+	client *shardFailingClient
+}
+
+func (c *shardPodClient) Exec(_, pod string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	c.client.lock.Lock()
+	defer c.client.lock.Unlock()
+	return &shardTimingsExecutor{command: opts.Command, timings: c.client.timings[pod]}, nil
+}
+
+type shardTimingsExecutor struct {
+	command []string
+	timings string
+}
+
+func (e *shardTimingsExecutor) Stream(opts remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), opts)
+}
+
+func (e *shardTimingsExecutor) StreamWithContext(_ context.Context, opts remotecommand.StreamOptions) error {
+	if e.command[0] != "tar" {
+		return nil
+	}
+	gz := gzip.NewWriter(opts.Stdout)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: shardTimingsFile, Mode: 0644, Size: int64(len(e.timings))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write([]byte(e.timings)); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// List honors the name field selector used to wait for pods, which the fake client ignores.
+func (c *shardFailingClient) List(ctx context.Context, list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) error {
+	if err := c.WithWatch.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	options := (&ctrlruntimeclient.ListOptions{}).ApplyOptions(opts)
+	pods, ok := list.(*corev1.PodList)
+	if !ok || options.Raw == nil || options.Raw.FieldSelector == "" {
+		return nil
+	}
+	selector, err := fields.ParseSelector(options.Raw.FieldSelector)
+	if err != nil {
+		return err
+	}
+	var items []corev1.Pod
+	for _, pod := range pods.Items {
+		if selector.Matches(fields.Set{"metadata.name": pod.Name}) {
+			items = append(items, pod)
+		}
+	}
+	pods.Items = items
+	return nil
+}
+
+func TestShardedTestStep(t *testing.T) {
+	config := api.TestStepConfiguration{
+		As:                         "e2e",
+		Commands:                   "make e2e",
+		Shards:                     3,
+		ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src", Clone: utilpointer.Bool(false)},
+	}
+	template, _ := preparePodStep("ns")
+	jobSpec := template.jobSpec
+
+	if step := ShardedTestStep(api.TestStepConfiguration{As: "unit", ContainerTestConfiguration: config.ContainerTestConfiguration}, nil, nil, jobSpec, nil, "", ""); step.Name() != "unit" {
+		t.Errorf("expected an unsharded test to run in a single pod step, got %s", step.Name())
+	} else if _, ok := step.(*podStep); !ok {
+		t.Errorf("expected an unsharded test to run in a single pod step, got %T", step)
+	}
+
+	timingsDir, artifactDir := t.TempDir(), t.TempDir()
+	t.Setenv("ARTIFACTS", artifactDir)
+	if err := os.WriteFile(filepath.Join(timingsDir, "e2e.json"), []byte(`{"a": 30, "b": 20, "c": 20, "d": 10}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logs := &fakerest.RESTClient{Client: fakerest.CreateHTTPClient(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("logs\n"))}, nil
+	})}
+	fakeClient := &shardFailingClient{WithWatch: fakectrlruntimeclient.NewClientBuilder().Build(), failing: "-shard-1", timings: map[string]string{}}
+	client := &shardPodClient{PodClient: kubernetes.NewPodClient(loggingclient.New(fakeClient), nil, logs, 0, nil), client: fakeClient}
+	step := ShardedTestStep(config, nil, client, jobSpec, nil, "", timingsDir)
+	if requires := step.Requires(); len(requires) != 1 || !requires[0].SatisfiedBy(api.InternalImageLink("src")) {
+		t.Errorf("expected the shards to require the src image, got %v", requires)
+	}
+
+	err := step.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "shard 2/3 failed") {
+		t.Fatalf("expected the second shard to fail, got %v", err)
+	}
+
+	expectedTests := []string{"a", "b\nd", "c"}
+	expectedExcluded := []string{"", "", "a\nb\nd"}
+	for i, index := range []string{"0", "1", "2"} {
+		pod := &corev1.Pod{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "e2e-shard-" + index}, pod); err != nil {
+			t.Fatalf("shard %d: could not get pod: %v", i, err)
+		}
+		env := map[string]string{}
+		for _, e := range pod.Spec.Containers[0].Env {
+			env[e.Name] = e.Value
+		}
+		testhelper.Diff(t, "shard env", map[string]string{shardIndexEnv: env[shardIndexEnv], shardTotalEnv: env[shardTotalEnv], shardTestsEnv: env[shardTestsEnv], shardExcludedTestsEnv: env[shardExcludedTestsEnv], shardTimingsFileEnv: env[shardTimingsFileEnv]}, map[string]string{shardIndexEnv: index, shardTotalEnv: "3", shardTestsEnv: expectedTests[i], shardExcludedTestsEnv: expectedExcluded[i], shardTimingsFileEnv: "/tmp/artifacts/shard-timings.json"})
+	}
+
+	raw, err := os.ReadFile(filepath.Join(artifactDir, ShardTimingsArtifactDir, "e2e.json"))
+	if err != nil {
+		t.Fatalf("could not read the regenerated timings: %v", err)
+	}
+	var timings TestTimings
+	if err := json.Unmarshal(raw, &timings); err != nil {
+		t.Fatalf("could not parse the regenerated timings: %v", err)
+	}
+	testhelper.Diff(t, "timings", timings, TestTimings{"a": 10, "b": 10, "c": 10, "d": 10})
+
+	for _, index := range []string{"0", "1", "2"} {
+		if _, err := os.Stat(filepath.Join(artifactDir, "e2e-shard-"+index, "test.log")); err != nil {
+			t.Errorf("expected the logs of shard %s to be streamed to the artifacts: %v", index, err)
+		}
+		if _, err := os.Stat(filepath.Join(artifactDir, "e2e-shard-"+index, shardTimingsFile)); err != nil {
+			t.Errorf("expected the timings of shard %s to be gathered to the artifacts: %v", index, err)
+		}
+	}
+
+	if subTests := step.(SubtestReporter).SubTests(); len(subTests) != 3 {
+		t.Errorf("expected the sub-tests of all three shards, got %d", len(subTests))
+	}
+}
+
+func TestMergeJUnit(t *testing.T) {
+	artifactDir := t.TempDir()
+	t.Setenv("ARTIFACTS", artifactDir)
+	files := map[string]string{
+		"e2e-shard-0/junit_e2e.xml":       `<testsuites><testsuite name="e2e" tests="2" failures="1"><testcase name="a" time="2"><failure message="failed"></failure></testcase><testcase name="b" time="1"></testcase></testsuite></testsuites>`,
+		"e2e-shard-1/junit/junit_e2e.xml": `<testsuite name="e2e" tests="2" skipped="1"><testcase name="c" time="3"></testcase><testsuite name="nested"><testcase name="d"><skipped message="skipped"></skipped></testcase></testsuite></testsuite>`,
+		"e2e-shard-1/test.log":            "logs",
+	}
+	for name, content := range files {
+		path := filepath.Join(artifactDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	step := &shardedTestStep{as: "e2e"}
+	for i, dir := range []string{"e2e-shard-0", "e2e-shard-1", "e2e-shard-2"} {
+		step.shards = append(step.shards, &podStep{shard: &shard{index: i, total: 3, artifactDir: filepath.Join(artifactDir, dir)}})
+	}
+	if err := step.mergeJUnit(); err != nil {
+		t.Fatalf("failed to merge the JUnit results: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(artifactDir, "junit_e2e.xml"))
+	if err != nil {
+		t.Fatalf("could not read the merged results: %v", err)
+	}
+	suites := &junit.TestSuites{}
+	if err := xml.Unmarshal(raw, suites); err != nil {
+		t.Fatalf("could not parse the merged results: %v", err)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("expected one merged suite, got %d", len(suites.Suites))
+	}
+	suite := suites.Suites[0]
+	var names []string
+	for _, test := range suite.TestCases {
+		names = append(names, test.Name)
+	}
+	testhelper.Diff(t, "merged suite", []interface{}{suite.Name, suite.NumTests, suite.NumFailed, suite.NumSkipped, suite.Duration, names}, []interface{}{"e2e", uint(4), uint(1), uint(1), float64(6), []string{"a", "b", "c", "d"}})
+
+	for _, name := range []string{"e2e-shard-0/junit_e2e.xml", "e2e-shard-1/junit/junit_e2e.xml"} {
+		if _, err := os.Stat(filepath.Join(artifactDir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed once merged, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(artifactDir, "e2e-shard-1", "test.log")); err != nil {
+		t.Errorf("expected the other artifacts of the shards to be kept: %v", err)
+	}
+}
+
+func TestPartitionTests(t *testing.T) {
+	var testCases = []struct {
+		name             string
+		timings          TestTimings
+		shards           int
+		expected         [][]string
+		expectedShortest int
+	}{
+		{
+			name:     "no timings",
+			shards:   2,
+			expected: [][]string{nil, nil},
+		},
+		{
+			name:             "longest tests are spread first",
+			timings:          TestTimings{"a": 50, "b": 40, "c": 30, "d": 20, "e": 10},
+			shards:           2,
+			expected:         [][]string{{"a", "d", "e"}, {"b", "c"}},
+			expectedShortest: 1,
+		},
+		{
+			name:             "more shards than tests",
+			timings:          TestTimings{"a": 1},
+			shards:           3,
+			expected:         [][]string{{"a"}, nil, nil},
+			expectedShortest: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			partition, shortest := partitionTests(tc.timings, tc.shards)
+			testhelper.Diff(t, "partition", partition, tc.expected)
+			testhelper.Diff(t, "shortest shard", shortest, tc.expectedShortest)
+		})
+	}
+}
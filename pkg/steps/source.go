@@ -2,6 +2,7 @@ package steps
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -31,6 +32,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/metrics"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
@@ -73,6 +75,22 @@ func (c *CloneAuthConfig) getCloneURI(org, repo string) string {
 
 var (
 	JobSpecAnnotation = fmt.Sprintf("%s/%s", CiAnnotationPrefix, "job-spec")
+	// CacheFromAnnotation records the promoted image a build used as its cache
+	CacheFromAnnotation = fmt.Sprintf("%s/%s", CiAnnotationPrefix, "cache-from")
+	// BuildTimeoutAnnotation holds how long a single attempt of a build may take
+	BuildTimeoutAnnotation = fmt.Sprintf("%s/%s", CiAnnotationPrefix, "build-timeout")
+	// BuildNoOutputTimeoutAnnotation holds how long a build may run without
+	// writing to its log before it is considered stalled
+	BuildNoOutputTimeoutAnnotation = fmt.Sprintf("%s/%s", CiAnnotationPrefix, "build-no-output-timeout")
+)
+
+// defaultBuildNoOutputTimeout is how long a build may run without writing
+// to its log if its configuration does not say otherwise.
+const defaultBuildNoOutputTimeout = 20 * time.Minute
+
+var (
+	errBuildTimedOut = errors.New("build timed out")
+	errBuildStalled  = errors.New("build stalled")
 )
 
 func sourceDockerfile(fromTag api.PipelineImageStreamTagReference, workingDir string, cloneAuthConfig *CloneAuthConfig) string {
@@ -133,6 +151,22 @@ func labelsFor(spec *api.JobSpec, base map[string]string) map[string]string {
 	return utils.SanitizeLabels(base)
 }
 
+// StandardLabels returns the labels that every object created for the job
+// carries, so that CI resources can be selected by job, inputs and attempt.
+func StandardLabels(spec *api.JobSpec) map[string]string {
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		JobNameLabel:   spec.Job,
+		InputHashLabel: spec.InputHash(),
+		AttemptLabel:   spec.Attempt(),
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return utils.SanitizeLabels(labels)
+}
+
 type sourceStep struct {
 	config          api.SourceStepConfiguration
 	resources       api.ResourceConfiguration
@@ -159,11 +193,18 @@ func (s *sourceStep) run(ctx context.Context) error {
 		return fmt.Errorf("could not resolve clonerefs source: %w", err)
 	}
 
-	fromDigest, err := resolvePipelineImageStreamTagReference(ctx, s.client, s.config.From, s.jobSpec)
+	rootDigest, err := resolvePipelineImageStreamTagReference(ctx, s.client, s.config.From, s.jobSpec)
 	if err != nil {
 		return err
 	}
-	return handleBuilds(ctx, s.client, s.podClient, *createBuild(s.config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, fromDigest))
+	config, fromDigest, cached := s.useSourceCache(ctx, rootDigest)
+	if err := handleBuilds(ctx, s.client, s.podClient, *createBuild(config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, fromDigest)); err != nil {
+		return err
+	}
+	if !cached {
+		s.cacheSource(ctx, rootDigest)
+	}
+	return nil
 }
 
 func createBuild(config api.SourceStepConfiguration, jobSpec *api.JobSpec, clonerefsRef corev1.ObjectReference, resources api.ResourceConfiguration, cloneAuthConfig *CloneAuthConfig, pullSecret *corev1.Secret, fromDigest string) *buildapi.Build {
@@ -380,6 +421,23 @@ func handleFailedBuild(ctx context.Context, client BuildClient, ns, name string,
 	}
 
 	logrus.Infof("Build %s previously failed from an infrastructure error (%s), retrying...", name, b.Status.Reason)
+	return deleteBuildAndWait(ctx, client, b)
+}
+
+// deleteBuild removes the build so that it can be created again.
+func deleteBuild(ctx context.Context, client BuildClient, ns, name string) error {
+	b := &buildapi.Build{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: ns, Name: name}, b); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("could not get build %s: %w", name, err)
+	}
+	return deleteBuildAndWait(ctx, client, b)
+}
+
+func deleteBuildAndWait(ctx context.Context, client BuildClient, b *buildapi.Build) error {
+	ns, name := b.Namespace, b.Name
 	zero := int64(0)
 	foreground := metav1.DeletePropagationForeground
 	opts := metav1.DeleteOptions{
@@ -455,6 +513,7 @@ func constructMultiArchBuilds(build buildapi.Build, nodeArchitectures []string)
 func handleBuild(ctx context.Context, client BuildClient, podClient kubernetes.PodClient, build buildapi.Build) error {
 	const attempts = 5
 	ns, name := build.Namespace, build.Name
+	timeouts := buildTimeoutsFor(&build)
 	var errs []error
 	if err := wait.ExponentialBackoff(wait.Backoff{Duration: time.Minute, Factor: 1.5, Steps: attempts}, func() (bool, error) {
 		var attempt buildapi.Build
@@ -466,8 +525,19 @@ func handleBuild(ctx context.Context, client BuildClient, podClient kubernetes.P
 		} else {
 			return false, fmt.Errorf("could not create build %s: %w", name, err)
 		}
-		if err := waitForBuildOrTimeout(ctx, client, podClient, ns, name); err != nil {
+		// the build controller names the pod of a build after it
+		stopStreaming := util.StreamPodLogs(ctx, podClient, podClient.Censor(), ns, fmt.Sprintf("%s-build", name), name)
+		err := waitForBuildOrTimeout(ctx, client, podClient, ns, name, timeouts)
+		stopStreaming()
+		if err != nil {
 			errs = append(errs, err)
+			switch {
+			case errors.Is(err, errBuildTimedOut):
+				return false, err
+			case errors.Is(err, errBuildStalled):
+				logrus.Infof("Build %s stalled, retrying...", name)
+				return false, deleteBuild(ctx, client, ns, name)
+			}
 			return false, handleFailedBuild(ctx, client, ns, name, err)
 		}
 		if err := gatherSuccessfulBuildLog(client, ns, name); err != nil {
@@ -542,13 +612,177 @@ func hintsAtInfraReason(logSnippet string) bool {
 		strings.Contains(logSnippet, "connection reset by peer")
 }
 
+// buildTimeouts limit how long a build may run.
+type buildTimeouts struct {
+	// timeout is the maximum duration of an attempt, zero if unlimited
+	timeout time.Duration
+	// noOutput is the maximum duration without log output
+	noOutput time.Duration
+}
+
+// setBuildTimeouts records the configured timeouts on the build.
+func setBuildTimeouts(build *buildapi.Build, timeout, noOutput *prowv1.Duration) {
+	if timeout != nil {
+		build.Annotations[BuildTimeoutAnnotation] = timeout.Duration.String()
+	}
+	if noOutput != nil {
+		build.Annotations[BuildNoOutputTimeoutAnnotation] = noOutput.Duration.String()
+	}
+}
+
+func buildTimeoutsFor(build *buildapi.Build) buildTimeouts {
+	timeouts := buildTimeouts{noOutput: defaultBuildNoOutputTimeout}
+	for annotation, into := range map[string]*time.Duration{
+		BuildTimeoutAnnotation:         &timeouts.timeout,
+		BuildNoOutputTimeoutAnnotation: &timeouts.noOutput,
+	} {
+		raw, set := build.Annotations[annotation]
+		if !set {
+			continue
+		}
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			logrus.WithError(err).Warnf("Ignoring invalid %s annotation on build %s.", annotation, build.Name)
+			continue
+		}
+		*into = duration
+	}
+	return timeouts
+}
+
+// waitForBuildOrTimeout waits for the build like waitForBuild, but cancels
+// the build once it exceeds its timeout or stops writing to its log.
 func waitForBuildOrTimeout(
 	ctx context.Context,
 	buildClient BuildClient,
 	podClient kubernetes.PodClient,
 	namespace, name string,
+	timeouts buildTimeouts,
 ) error {
-	return waitForBuild(ctx, buildClient, podClient, namespace, name)
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	aborted := make(chan error, 1)
+	abort := func(err error) {
+		select {
+		case aborted <- err:
+			cancel()
+		default:
+		}
+	}
+	if timeouts.timeout > 0 {
+		timer := time.AfterFunc(timeouts.timeout, func() {
+			abort(results.ForReason("build_timed_out").WithError(errBuildTimedOut).Errorf("the build %s did not complete within %s", name, timeouts.timeout))
+		})
+		defer timer.Stop()
+	}
+	if timeouts.noOutput > 0 {
+		go detectStalledBuild(waitCtx, buildClient, namespace, name, timeouts.noOutput, func() {
+			abort(results.ForReason("build_stalled").WithError(errBuildStalled).Errorf("the build %s wrote nothing to its log for %s", name, timeouts.noOutput))
+		})
+	}
+	err := waitForBuild(waitCtx, buildClient, podClient, namespace, name)
+	select {
+	case abortErr := <-aborted:
+		logrus.Info(abortErr.Error())
+		cancelBuild(ctx, buildClient, namespace, name)
+		return abortErr
+	default:
+		return err
+	}
+}
+
+// buildLogRetryInterval is how often following the log of a build is retried,
+// as it is not available until the pod of the build starts.
+var buildLogRetryInterval = 10 * time.Second
+
+// followBuildLog follows the log of the build, retrying until it succeeds or
+// the context is done. Stalls cannot be detected while the log cannot be
+// followed, which is reported once it takes longer than the timeout.
+func followBuildLog(ctx context.Context, client BuildClient, namespace, name string, timeout time.Duration) (io.ReadCloser, bool) {
+	start := time.Now()
+	warned := false
+	for {
+		logs, err := client.Logs(namespace, name, &buildapi.BuildLogOptions{Follow: true})
+		if err == nil {
+			return logs, true
+		}
+		if !warned && time.Since(start) >= timeout {
+			logrus.WithError(err).Warnf("Could not follow the log of build %s for %s, not detecting stalls until it can be followed.", name, timeout)
+			warned = true
+		} else {
+			logrus.WithError(err).Debugf("Could not follow the log of build %s, retrying.", name)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(buildLogRetryInterval):
+		}
+	}
+}
+
+// detectStalledBuild follows the log of the build and calls stalled once the
+// build has written nothing to it for the timeout. The log ends when the
+// build does.
+func detectStalledBuild(ctx context.Context, client BuildClient, namespace, name string, timeout time.Duration, stalled func()) {
+	logs, ok := followBuildLog(ctx, client, namespace, name, timeout)
+	if !ok {
+		return
+	}
+	// closing the log also stops the reader below
+	defer logs.Close()
+	output := make(chan struct{})
+	go func() {
+		defer close(output)
+		buf := make([]byte, 4096)
+		for {
+			n, err := logs.Read(buf)
+			if n > 0 {
+				select {
+				case output <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, open := <-output:
+			if !open {
+				return
+			}
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+		case <-timer.C:
+			stalled()
+			return
+		}
+	}
+}
+
+// cancelBuild asks the build controller to stop the build.
+func cancelBuild(ctx context.Context, client BuildClient, namespace, name string) {
+	build := &buildapi.Build{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, build); err != nil {
+		logrus.WithError(err).Warnf("Could not get build %s to cancel it.", name)
+		return
+	}
+	if isBuildPhaseTerminated(build.Status.Phase) {
+		return
+	}
+	build.Status.Cancelled = true
+	if err := client.Update(ctx, build); err != nil {
+		logrus.WithError(err).Warnf("Could not cancel build %s.", name)
+	}
 }
 
 // waitForBuild watches a build until it either succeeds or fails
@@ -617,9 +851,11 @@ func waitForBuild(
 					eg.Go(pendingCheck)
 				}
 			case buildapi.BuildPhaseComplete:
+				observeBuildWait(build)
 				logrus.Infof("Build %s succeeded after %s", build.Name, buildDuration(build).Truncate(time.Second))
 				return true, nil
 			case buildapi.BuildPhaseFailed, buildapi.BuildPhaseCancelled, buildapi.BuildPhaseError:
+				observeBuildWait(build)
 				logrus.Infof("Build %s failed, printing logs:", build.Name)
 				printBuildLogs(buildClient, build.Namespace, build.Name)
 				return true, util.AppendLogToError(fmt.Errorf("the build %s failed after %s with reason %s: %s", build.Name, buildDuration(build).Truncate(time.Second), build.Status.Reason, build.Status.Message), build.Status.LogSnippet)
@@ -646,6 +882,13 @@ func checkPending(
 	return nil
 }
 
+// observeBuildWait records how long the build waited to start, if it did.
+func observeBuildWait(build *buildapi.Build) {
+	if start := build.Status.StartTimestamp; start != nil {
+		metrics.BuildWait.Observe(start.Sub(build.CreationTimestamp.Time).Seconds())
+	}
+}
+
 func buildDuration(build *buildapi.Build) time.Duration {
 	start := build.Status.StartTimestamp
 	if start == nil {
@@ -664,9 +907,13 @@ func printBuildLogs(buildClient BuildClient, namespace, name string) {
 		NoWait: true,
 	}); err == nil {
 		defer s.Close()
-		if _, err := io.Copy(os.Stdout, s); err != nil {
+		logs, err := io.ReadAll(s)
+		if err != nil {
 			logrus.WithError(err).Warn("Unable to copy log output from failed build.")
 		}
+		if _, err := io.WriteString(os.Stdout, util.ConsoleOutput(name, string(logs))); err != nil {
+			logrus.WithError(err).Warn("Unable to print log output from failed build.")
+		}
 	} else {
 		logrus.WithError(err).Warn("Unable to retrieve logs from failed build")
 	}
@@ -697,6 +944,17 @@ func ResourcesFor(req api.ResourceRequirements) (corev1.ResourceRequirements, er
 	return apireq, nil
 }
 
+// DryRun describes the build that clones the source code.
+func (s *sourceStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	clonerefsRef := corev1.ObjectReference{Kind: "DockerImage", Name: DryRunPlaceholder(s.config.ClonerefsImage.ISTagName())}
+	fromDigest := DryRunPlaceholder(fmt.Sprintf("digest of %s:%s", api.PipelineImageStream, s.config.From))
+	build := createBuild(s.config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, fromDigest)
+	return []api.DryRunAction{{
+		Description: fmt.Sprintf("Create build %s to clone the source code", build.Name),
+		Object:      build,
+	}}, nil
+}
+
 func (s *sourceStep) Requires() []api.StepLink {
 	return []api.StepLink{api.InternalImageLink(s.config.From)}
 }
@@ -706,9 +964,11 @@ func (s *sourceStep) Creates() []api.StepLink {
 }
 
 func (s *sourceStep) Provides() api.ParameterMap {
-	return api.ParameterMap{
-		utils.PipelineImageEnvFor(s.config.To): utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, string(s.config.To)),
-	}
+	return api.ParameterMapFor(s.Parameters())
+}
+
+func (s *sourceStep) Parameters() []api.Parameter {
+	return []api.Parameter{utils.PipelineImageParameter(s.client, s.jobSpec.Namespace, s.config.To)}
 }
 
 func (s *sourceStep) Name() string { return s.config.TargetName() }
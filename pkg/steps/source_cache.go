@@ -0,0 +1,174 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// SourceCacheRootAnnotation is set on the tags of the source cache to the
+// digest of the image the source code was cloned on top of, since a cached
+// clone is only reused on top of the same image.
+const SourceCacheRootAnnotation = "ci.openshift.io/source-cache-root"
+
+// sourceCacheTags is how many base revisions of a repository the source
+// cache keeps. Jobs test the latest base revisions, so clones of older ones
+// are seldom reused and only take up registry storage.
+const sourceCacheTags = 5
+
+// sourceCacheFor returns the tag in the base namespace caching the clone of
+// the base revision of the job, in an image stream per repository. Jobs that
+// clone more than one repository are not cached.
+func sourceCacheFor(jobSpec *api.JobSpec) (api.ImageStreamTagReference, bool) {
+	refs := jobSpec.Refs
+	if refs == nil || len(jobSpec.ExtraRefs) != 0 || refs.BaseSHA == "" || jobSpec.BaseNamespace == "" {
+		return api.ImageStreamTagReference{}, false
+	}
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(fmt.Sprintf("%s-%s-source", refs.Org, refs.Repo)))
+	return api.ImageStreamTagReference{Namespace: jobSpec.BaseNamespace, Name: name, Tag: refs.BaseSHA}, true
+}
+
+// sourceCacheTag is the pipeline tag the cached clone is tagged as to build
+// the source code on top of it.
+func sourceCacheTag(to api.PipelineImageStreamTagReference) api.PipelineImageStreamTagReference {
+	return api.PipelineImageStreamTagReference(fmt.Sprintf("%s-cache", to))
+}
+
+// resolveSourceCache returns the image caching the clone if it was cloned on
+// top of the given image, or an empty string otherwise.
+func resolveSourceCache(ctx context.Context, client ctrlruntimeclient.Reader, cache api.ImageStreamTagReference, rootDigest string) (string, error) {
+	ist := &imagev1.ImageStreamTag{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: cache.Namespace, Name: fmt.Sprintf("%s:%s", cache.Name, cache.Tag)}, ist); err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("could not get source cache %s: %w", cache.ISTagName(), err)
+	}
+	if ist.Tag == nil || ist.Tag.Annotations[SourceCacheRootAnnotation] != rootDigest {
+		return "", nil
+	}
+	return ist.Image.Name, nil
+}
+
+// updateSourceCache tags the image with the clone of the base revision into
+// the source cache.
+func updateSourceCache(ctx context.Context, client ctrlruntimeclient.Client, jobSpec *api.JobSpec, cache api.ImageStreamTagReference, image, rootDigest string) error {
+	is := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: cache.Namespace, Name: cache.Name}}
+	if err := client.Create(ctx, is); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create imagestream %s/%s: %w", cache.Namespace, cache.Name, err)
+	}
+	ist := &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s:%s", cache.Name, cache.Tag),
+			Namespace: cache.Namespace,
+		},
+		Tag: &imagev1.TagReference{
+			Annotations: map[string]string{SourceCacheRootAnnotation: rootDigest},
+			ReferencePolicy: imagev1.TagReferencePolicy{
+				Type: imagev1.LocalTagReferencePolicy,
+			},
+			From: &coreapi.ObjectReference{
+				Kind:      "ImageStreamImage",
+				Name:      fmt.Sprintf("%s@%s", api.PipelineImageStream, image),
+				Namespace: jobSpec.Namespace(),
+			},
+		},
+	}
+	// another job of the same revision cached the same clone
+	if err := client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create imagestreamtag %s: %w", cache.ISTagName(), err)
+	}
+	return nil
+}
+
+// pruneSourceCache deletes the tags of the source cache beyond the given
+// number of the most recently cached base revisions. Tags the image stream
+// does not have an image for yet are kept, as they were just created.
+func pruneSourceCache(ctx context.Context, client ctrlruntimeclient.Client, cache api.ImageStreamTagReference, keep int) error {
+	is := &imagev1.ImageStream{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: cache.Namespace, Name: cache.Name}, is); err != nil {
+		return fmt.Errorf("could not get imagestream %s/%s: %w", cache.Namespace, cache.Name, err)
+	}
+	var tags []imagev1.NamedTagEventList
+	for _, tag := range is.Status.Tags {
+		if len(tag.Items) != 0 {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[j].Items[0].Created.Before(&tags[i].Items[0].Created)
+	})
+	for i := keep; i < len(tags); i++ {
+		ist := &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: cache.Namespace, Name: fmt.Sprintf("%s:%s", cache.Name, tags[i].Tag)}}
+		// another job may prune the same tags
+		if err := client.Delete(ctx, ist); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("could not delete imagestreamtag %s/%s: %w", ist.Namespace, ist.Name, err)
+		}
+	}
+	return nil
+}
+
+// useSourceCache tags the cached clone into the pipeline when there is one
+// for the job and returns the configuration to build the source code on top
+// of it. Failures are not fatal, the source code is cloned from scratch.
+func (s *sourceStep) useSourceCache(ctx context.Context, rootDigest string) (api.SourceStepConfiguration, string, bool) {
+	logger := LoggerFor(ctx)
+	cache, ok := sourceCacheFor(s.jobSpec)
+	if !s.config.Cache || !ok {
+		return s.config, rootDigest, false
+	}
+	image, err := resolveSourceCache(ctx, s.client, cache, rootDigest)
+	if err != nil {
+		logger.WithError(err).Warn("Could not resolve the source cache.")
+		return s.config, rootDigest, false
+	}
+	if image == "" {
+		return s.config, rootDigest, false
+	}
+	config := s.config
+	config.From = sourceCacheTag(s.config.To)
+	if err := tagPromotedImage(ctx, s.client, s.jobSpec, cache, image, config.From); err != nil {
+		logger.WithError(err).Warn("Could not use the source cache.")
+		return s.config, rootDigest, false
+	}
+	logger.Infof("Cloning the source code on top of the source cache %s.", cache.ISTagName())
+	return config, image, true
+}
+
+// cacheSource caches the clone of the base revision for later jobs, unless
+// the job tests pull requests on top of it.
+func (s *sourceStep) cacheSource(ctx context.Context, rootDigest string) {
+	cache, ok := sourceCacheFor(s.jobSpec)
+	if !s.config.Cache || !ok || len(s.jobSpec.Refs.Pulls) != 0 {
+		return
+	}
+	logger := LoggerFor(ctx)
+	image, err := resolvePipelineImageStreamTagReference(ctx, s.client, s.config.To, s.jobSpec)
+	if err != nil {
+		logger.WithError(err).Warn("Could not update the source cache.")
+		return
+	}
+	if err := updateSourceCache(ctx, s.client, s.jobSpec, cache, image, rootDigest); err != nil {
+		logger.WithError(err).Warn("Could not update the source cache.")
+		return
+	}
+	logger.Infof("Cached the source code as %s.", cache.ISTagName())
+	if err := pruneSourceCache(ctx, s.client, cache, sourceCacheTags); err != nil {
+		logger.WithError(err).Warn("Could not prune the source cache.")
+	}
+}
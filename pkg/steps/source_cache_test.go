@@ -0,0 +1,129 @@
+package steps
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestSourceCacheFor(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		jobSpec   api.JobSpec
+		expected  api.ImageStreamTagReference
+		cacheable bool
+	}{{
+		name: "repository is cached by base revision",
+		jobSpec: api.JobSpec{
+			JobSpec:       downwardapi.JobSpec{Refs: &prowv1.Refs{Org: "Org", Repo: "my_repo", BaseSHA: "base", Pulls: []prowv1.Pull{{Number: 1}}}},
+			BaseNamespace: "stable",
+		},
+		expected:  api.ImageStreamTagReference{Namespace: "stable", Name: "org-my-repo-source", Tag: "base"},
+		cacheable: true,
+	}, {
+		name: "more than one repository is not cached",
+		jobSpec: api.JobSpec{
+			JobSpec:       downwardapi.JobSpec{Refs: &prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"}, ExtraRefs: []prowv1.Refs{{Org: "org", Repo: "other"}}},
+			BaseNamespace: "stable",
+		},
+	}, {
+		name:    "no base namespace",
+		jobSpec: api.JobSpec{JobSpec: downwardapi.JobSpec{Refs: &prowv1.Refs{Org: "org", Repo: "repo", BaseSHA: "base"}}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, cacheable := sourceCacheFor(&tc.jobSpec)
+			if cacheable != tc.cacheable {
+				t.Fatalf("expected cacheable to be %t, got %t", tc.cacheable, cacheable)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected cache: %s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdateAndResolveSourceCache(t *testing.T) {
+	ctx := context.Background()
+	client := fakectrlruntimeclient.NewClientBuilder().Build()
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ci-op-1234")
+	cache := api.ImageStreamTagReference{Namespace: "stable", Name: "org-repo-source", Tag: "base"}
+
+	if image, err := resolveSourceCache(ctx, client, cache, "sha256:root"); err != nil || image != "" {
+		t.Fatalf("expected no cache, got %q and error %v", image, err)
+	}
+	if err := updateSourceCache(ctx, client, jobSpec, cache, "sha256:src", "sha256:root"); err != nil {
+		t.Fatalf("could not update cache: %v", err)
+	}
+	ist := &imagev1.ImageStreamTag{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: "stable", Name: "org-repo-source:base"}, ist); err != nil {
+		t.Fatalf("could not get cache: %v", err)
+	}
+	if actual, expected := ist.Tag.From.Name, "pipeline@sha256:src"; actual != expected {
+		t.Errorf("expected the cache to tag %s, got %s", expected, actual)
+	}
+	// the image is resolved by the registry in a cluster
+	ist.Image = imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: "sha256:src"}}
+	if err := client.Update(ctx, ist); err != nil {
+		t.Fatalf("could not update cache: %v", err)
+	}
+
+	if image, err := resolveSourceCache(ctx, client, cache, "sha256:root"); err != nil || image != "sha256:src" {
+		t.Errorf("expected the cached image, got %q and error %v", image, err)
+	}
+	if image, err := resolveSourceCache(ctx, client, cache, "sha256:other"); err != nil || image != "" {
+		t.Errorf("expected no cache for another root, got %q and error %v", image, err)
+	}
+}
+
+func TestPruneSourceCache(t *testing.T) {
+	ctx := context.Background()
+	cache := api.ImageStreamTagReference{Namespace: "stable", Name: "org-repo-source", Tag: "newest"}
+	created := func(hours int) []imagev1.TagEvent {
+		return []imagev1.TagEvent{{Created: metav1.NewTime(time.Date(2023, 1, 1, hours, 0, 0, 0, time.UTC))}}
+	}
+	is := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "stable", Name: "org-repo-source"},
+		Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{
+			{Tag: "oldest", Items: created(1)},
+			{Tag: "newest", Items: created(4)},
+			{Tag: "older", Items: created(2)},
+			{Tag: "newer", Items: created(3)},
+			{Tag: "pending"},
+		}},
+	}
+	objects := []ctrlruntimeclient.Object{is}
+	for _, tag := range []string{"oldest", "older", "newer", "newest", "pending"} {
+		objects = append(objects, &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: "stable", Name: "org-repo-source:" + tag}})
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(objects...).Build()
+
+	if err := pruneSourceCache(ctx, client, cache, 2); err != nil {
+		t.Fatalf("could not prune the cache: %v", err)
+	}
+	tags := &imagev1.ImageStreamTagList{}
+	if err := client.List(ctx, tags, ctrlruntimeclient.InNamespace("stable")); err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, tag := range tags.Items {
+		actual = append(actual, tag.Name)
+	}
+	sort.Strings(actual)
+	if diff := cmp.Diff([]string{"org-repo-source:newer", "org-repo-source:newest", "org-repo-source:pending"}, actual); diff != "" {
+		t.Errorf("unexpected tags after pruning: %s", diff)
+	}
+}
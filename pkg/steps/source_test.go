@@ -599,6 +599,96 @@ Found 0 events for Pod some-build-build:`),
 	}
 }
 
+func TestBuildTimeoutsFor(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		expected    buildTimeouts
+	}{{
+		name:     "defaults",
+		expected: buildTimeouts{noOutput: defaultBuildNoOutputTimeout},
+	}, {
+		name: "configured",
+		annotations: map[string]string{
+			BuildTimeoutAnnotation:         "1h0m0s",
+			BuildNoOutputTimeoutAnnotation: "5m0s",
+		},
+		expected: buildTimeouts{timeout: time.Hour, noOutput: 5 * time.Minute},
+	}, {
+		name:        "invalid values are ignored",
+		annotations: map[string]string{BuildTimeoutAnnotation: "forever"},
+		expected:    buildTimeouts{noOutput: defaultBuildNoOutputTimeout},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			build := &buildapi.Build{ObjectMeta: meta.ObjectMeta{Name: "build", Annotations: tc.annotations}}
+			if diff := cmp.Diff(tc.expected, buildTimeoutsFor(build), cmp.AllowUnexported(buildTimeouts{})); diff != "" {
+				t.Errorf("unexpected timeouts (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type pipeBuildClient struct {
+	BuildClient
+	logs io.ReadCloser
+	// failures is how many times following the log fails first
+	failures int
+}
+
+func (c *pipeBuildClient) Logs(string, string, *buildapi.BuildLogOptions) (io.ReadCloser, error) {
+	if c.failures > 0 {
+		c.failures--
+		return nil, errors.New("container is waiting to start")
+	}
+	return c.logs, nil
+}
+
+func TestDetectStalledBuild(t *testing.T) {
+	retryInterval := buildLogRetryInterval
+	buildLogRetryInterval = time.Millisecond
+	defer func() { buildLogRetryInterval = retryInterval }()
+	for _, tc := range []struct {
+		name     string
+		failures int
+		write    func(w *io.PipeWriter)
+		expected bool
+	}{{
+		name: "build writes output until it completes",
+		write: func(w *io.PipeWriter) {
+			for i := 0; i < 5; i++ {
+				time.Sleep(20 * time.Millisecond)
+				_, _ = w.Write([]byte("output\n"))
+			}
+			_ = w.Close()
+		},
+	}, {
+		name: "build stops writing output",
+		write: func(w *io.PipeWriter) {
+			_, _ = w.Write([]byte("output\n"))
+		},
+		expected: true,
+	}, {
+		name:     "log is followed once the build starts",
+		failures: 3,
+		write: func(w *io.PipeWriter) {
+			_, _ = w.Write([]byte("output\n"))
+		},
+		expected: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, w := io.Pipe()
+			go tc.write(w)
+			stalled := false
+			detectStalledBuild(context.Background(), &pipeBuildClient{logs: r, failures: tc.failures}, "ns", "build", 50*time.Millisecond, func() {
+				stalled = true
+			})
+			if stalled != tc.expected {
+				t.Errorf("expected stalled=%t, got %t", tc.expected, stalled)
+			}
+		})
+	}
+}
+
 func TestCheckPending(t *testing.T) {
 	now := meta.Time{Time: time.Now()}
 	for _, tc := range []struct {
@@ -0,0 +1,256 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// StatusConfigMapName is the name of the ConfigMap in the test namespace
+	// that holds the current state of every step in the execution graph.
+	StatusConfigMapName = "ci-operator-status"
+	// StatusConfigMapKey is the key under which the serialized status is stored.
+	StatusConfigMapKey = "status.json"
+)
+
+// StepPhase is the lifecycle phase of a step in the execution graph.
+type StepPhase string
+
+const (
+	StepPhasePending   StepPhase = "Pending"
+	StepPhaseRunning   StepPhase = "Running"
+	StepPhaseSucceeded StepPhase = "Succeeded"
+	StepPhaseFailed    StepPhase = "Failed"
+)
+
+// StepCondition describes the state of a single step.
+type StepCondition struct {
+	Name               string     `json:"name"`
+	Phase              StepPhase  `json:"phase"`
+	Message            string     `json:"message,omitempty"`
+	StartedAt          *meta.Time `json:"startedAt,omitempty"`
+	FinishedAt         *meta.Time `json:"finishedAt,omitempty"`
+	LastTransitionTime meta.Time  `json:"lastTransitionTime"`
+}
+
+// ExecutionStatus is the content of the status ConfigMap.
+type ExecutionStatus struct {
+	// InputHash and Revisions identify the inputs the execution ran with.
+	InputHash  string          `json:"inputHash,omitempty"`
+	Revisions  []string        `json:"revisions,omitempty"`
+	Conditions []StepCondition `json:"conditions"`
+}
+
+// Revisions identifies the revisions of every repository the job tests, as
+// org/repo@sha for the base and org/repo#number@sha for each pull request.
+func Revisions(jobSpec *api.JobSpec) []string {
+	var revisions []string
+	refs := jobSpec.ExtraRefs
+	if jobSpec.Refs != nil {
+		refs = append([]prowapi.Refs{*jobSpec.Refs}, refs...)
+	}
+	for _, ref := range refs {
+		revisions = append(revisions, fmt.Sprintf("%s/%s@%s", ref.Org, ref.Repo, ref.BaseSHA))
+		for _, pull := range ref.Pulls {
+			revisions = append(revisions, fmt.Sprintf("%s/%s#%d@%s", ref.Org, ref.Repo, pull.Number, pull.SHA))
+		}
+	}
+	return revisions
+}
+
+// ReadExecutionStatus reads the status an earlier execution recorded in the
+// namespace.
+func ReadExecutionStatus(ctx context.Context, client ctrlruntimeclient.Reader, namespace string) (*ExecutionStatus, error) {
+	cm := &coreapi.ConfigMap{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: StatusConfigMapName}, cm); err != nil {
+		return nil, fmt.Errorf("could not get the %s ConfigMap: %w", StatusConfigMapName, err)
+	}
+	raw, ok := cm.Data[StatusConfigMapKey]
+	if !ok {
+		return nil, fmt.Errorf("the %s ConfigMap has no %s", StatusConfigMapName, StatusConfigMapKey)
+	}
+	var status ExecutionStatus
+	if err := json.Unmarshal([]byte(raw), &status); err != nil {
+		return nil, fmt.Errorf("could not parse the status: %w", err)
+	}
+	return &status, nil
+}
+
+// Unsucceeded lists the steps with the given names that are not recorded as
+// succeeded, along with their phase.
+func (s ExecutionStatus) Unsucceeded(names sets.Set[string]) []string {
+	phases := map[string]StepPhase{}
+	for _, condition := range s.Conditions {
+		phases[condition.Name] = condition.Phase
+	}
+	var ret []string
+	for _, name := range sets.List(names) {
+		switch phase, recorded := phases[name]; {
+		case !recorded:
+			ret = append(ret, fmt.Sprintf("%s (not recorded)", name))
+		case phase != StepPhaseSucceeded:
+			ret = append(ret, fmt.Sprintf("%s (%s)", name, phase))
+		}
+	}
+	return ret
+}
+
+// StepObserver is notified as steps in the execution graph change state.
+// Implementations must be safe for concurrent use, as steps run in parallel.
+type StepObserver interface {
+	StepStarted(step api.Step)
+	StepFinished(step api.Step, err error)
+}
+
+// StatusReporter is a StepObserver that mirrors the lifecycle of every step
+// into the status ConfigMap in the test namespace, so that the progress of a
+// job can be watched from within the cluster.
+type StatusReporter struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	labels    map[string]string
+	now       func() time.Time
+
+	lock   sync.Mutex
+	status ExecutionStatus
+}
+
+// NewStatusReporter creates a reporter that tracks every step in the graph,
+// in the order given.
+func NewStatusReporter(client ctrlruntimeclient.Client, jobSpec *api.JobSpec, nodes api.OrderedStepList) *StatusReporter {
+	r := &StatusReporter{
+		client:    client,
+		namespace: jobSpec.Namespace(),
+		labels:    labelsFor(jobSpec, nil),
+		now:       time.Now,
+		status:    ExecutionStatus{InputHash: jobSpec.InputHash(), Revisions: Revisions(jobSpec)},
+	}
+	for _, node := range nodes {
+		r.status.Conditions = append(r.status.Conditions, StepCondition{
+			Name:  node.Step.Name(),
+			Phase: StepPhasePending,
+		})
+	}
+	return r
+}
+
+// Initialize records every step as pending.
+func (r *StatusReporter) Initialize(ctx context.Context) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := meta.NewTime(r.now())
+	for i := range r.status.Conditions {
+		r.status.Conditions[i].LastTransitionTime = now
+	}
+	r.sync(ctx)
+}
+
+// Resume continues from the status recorded by an earlier execution, so
+// that the steps that do not run again keep their recorded state.
+func (r *StatusReporter) Resume(status ExecutionStatus) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, recorded := range status.Conditions {
+		replaced := false
+		for i := range r.status.Conditions {
+			if r.status.Conditions[i].Name == recorded.Name {
+				r.status.Conditions[i] = recorded
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			r.status.Conditions = append(r.status.Conditions, recorded)
+		}
+	}
+}
+
+func (r *StatusReporter) StepStarted(step api.Step) {
+	r.transition(step.Name(), func(condition *StepCondition, now meta.Time) {
+		condition.Phase = StepPhaseRunning
+		condition.StartedAt = &now
+	})
+}
+
+func (r *StatusReporter) StepFinished(step api.Step, err error) {
+	r.transition(step.Name(), func(condition *StepCondition, now meta.Time) {
+		condition.FinishedAt = &now
+		if err != nil {
+			condition.Phase = StepPhaseFailed
+			condition.Message = err.Error()
+		} else {
+			condition.Phase = StepPhaseSucceeded
+		}
+	})
+}
+
+func (r *StatusReporter) transition(name string, mutate func(*StepCondition, meta.Time)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := meta.NewTime(r.now())
+	idx := -1
+	for i := range r.status.Conditions {
+		if r.status.Conditions[i].Name == name {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		r.status.Conditions = append(r.status.Conditions, StepCondition{Name: name})
+		idx = len(r.status.Conditions) - 1
+	}
+	condition := &r.status.Conditions[idx]
+	mutate(condition, now)
+	condition.LastTransitionTime = now
+	r.sync(context.TODO())
+}
+
+// sync writes the current status to the cluster. Failures are not fatal to
+// the execution of the job, so they are only logged. The caller must hold
+// the lock, which ensures updates are applied in order.
+func (r *StatusReporter) sync(ctx context.Context) {
+	if err := r.write(ctx); err != nil {
+		logrus.WithError(err).Warnf("Failed to update %s ConfigMap.", StatusConfigMapName)
+	}
+}
+
+func (r *StatusReporter) write(ctx context.Context) error {
+	raw, err := json.Marshal(r.status)
+	if err != nil {
+		return fmt.Errorf("could not marshal status: %w", err)
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &coreapi.ConfigMap{}
+		key := ctrlruntimeclient.ObjectKey{Namespace: r.namespace, Name: StatusConfigMapName}
+		if err := r.client.Get(ctx, key, cm); err != nil {
+			if !kerrors.IsNotFound(err) {
+				return fmt.Errorf("could not get ConfigMap: %w", err)
+			}
+			cm = &coreapi.ConfigMap{
+				ObjectMeta: meta.ObjectMeta{Namespace: r.namespace, Name: StatusConfigMapName, Labels: r.labels},
+				Data:       map[string]string{StatusConfigMapKey: string(raw)},
+			}
+			return r.client.Create(ctx, cm)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[StatusConfigMapKey] = string(raw)
+		return r.client.Update(ctx, cm)
+	})
+}
@@ -0,0 +1,132 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestStatusReporter(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	src := &fakeStep{name: "src", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}
+	bin := &fakeStep{name: "bin", requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}
+	unit := &fakeStep{name: "unit", requires: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}
+	nodes, errs := api.BuildGraph([]api.Step{src, bin, unit}).TopologicalSort()
+	if errs != nil {
+		t.Fatalf("failed to sort graph: %v", errs)
+	}
+
+	client := fakectrlruntimeclient.NewClientBuilder().Build()
+	reporter := NewStatusReporter(client, jobSpec, nodes)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+
+	status := func() ExecutionStatus {
+		cm := &coreapi.ConfigMap{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: StatusConfigMapName}, cm); err != nil {
+			t.Fatalf("failed to get status ConfigMap: %v", err)
+		}
+		var ret ExecutionStatus
+		if err := json.Unmarshal([]byte(cm.Data[StatusConfigMapKey]), &ret); err != nil {
+			t.Fatalf("failed to unmarshal status: %v", err)
+		}
+		return ret
+	}
+
+	reporter.Initialize(context.Background())
+	epoch := meta.NewTime(now)
+	now = now.Add(time.Minute)
+	testhelper.Diff(t, "initial status", status(), ExecutionStatus{Conditions: []StepCondition{
+		{Name: "src", Phase: StepPhasePending, LastTransitionTime: epoch},
+		{Name: "bin", Phase: StepPhasePending, LastTransitionTime: epoch},
+		{Name: "unit", Phase: StepPhasePending, LastTransitionTime: epoch},
+	}})
+
+	reporter.StepStarted(src)
+	started := meta.NewTime(now)
+	now = now.Add(time.Minute)
+	reporter.StepFinished(src, nil)
+	finished := meta.NewTime(now)
+	reporter.StepStarted(unit)
+	reporter.StepFinished(unit, errors.New("oops"))
+	reporter.StepStarted(bin)
+
+	testhelper.Diff(t, "final status", status(), ExecutionStatus{Conditions: []StepCondition{
+		{Name: "src", Phase: StepPhaseSucceeded, StartedAt: &started, FinishedAt: &finished, LastTransitionTime: finished},
+		{Name: "bin", Phase: StepPhaseRunning, StartedAt: &finished, LastTransitionTime: finished},
+		{Name: "unit", Phase: StepPhaseFailed, Message: "oops", StartedAt: &finished, FinishedAt: &finished, LastTransitionTime: finished},
+	}})
+}
+
+func TestReadExecutionStatus(t *testing.T) {
+	recorded := ExecutionStatus{Conditions: []StepCondition{
+		{Name: "src", Phase: StepPhaseSucceeded},
+		{Name: "bin", Phase: StepPhaseSucceeded},
+		{Name: "unit", Phase: StepPhaseFailed, Message: "oops"},
+	}}
+	raw, err := json.Marshal(recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		&coreapi.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: StatusConfigMapName}, Data: map[string]string{StatusConfigMapKey: string(raw)}},
+	).Build()
+
+	if _, err := ReadExecutionStatus(context.Background(), client, "other"); err == nil {
+		t.Error("expected an error for a namespace without a status")
+	}
+	status, err := ReadExecutionStatus(context.Background(), client, "ns")
+	if err != nil {
+		t.Fatalf("failed to read the status: %v", err)
+	}
+	testhelper.Diff(t, "status", status, &recorded)
+	testhelper.Diff(t, "unsucceeded steps", status.Unsucceeded(sets.New[string]("src", "unit", "e2e")), []string{"e2e (not recorded)", "unit (Failed)"})
+}
+
+func TestStatusReporterResume(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.SetNamespace("ns")
+	src := &fakeStep{name: "src"}
+	nodes, errs := api.BuildGraph([]api.Step{src}).TopologicalSort()
+	if errs != nil {
+		t.Fatalf("failed to sort graph: %v", errs)
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().Build()
+	reporter := NewStatusReporter(client, jobSpec, nodes)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+	recorded := meta.NewTime(now.Add(-time.Hour))
+	reporter.Resume(ExecutionStatus{Conditions: []StepCondition{
+		{Name: "src", Phase: StepPhaseSucceeded, LastTransitionTime: recorded},
+		{Name: "unit", Phase: StepPhaseSucceeded, LastTransitionTime: recorded},
+	}})
+	promotion := &fakeStep{name: "[promotion]"}
+	reporter.StepStarted(promotion)
+
+	cm := &coreapi.ConfigMap{}
+	if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: StatusConfigMapName}, cm); err != nil {
+		t.Fatalf("failed to get status ConfigMap: %v", err)
+	}
+	var status ExecutionStatus
+	if err := json.Unmarshal([]byte(cm.Data[StatusConfigMapKey]), &status); err != nil {
+		t.Fatalf("failed to unmarshal status: %v", err)
+	}
+	started := meta.NewTime(now)
+	testhelper.Diff(t, "status", status, ExecutionStatus{Conditions: []StepCondition{
+		{Name: "src", Phase: StepPhaseSucceeded, LastTransitionTime: recorded},
+		{Name: "unit", Phase: StepPhaseSucceeded, LastTransitionTime: recorded},
+		{Name: "[promotion]", Phase: StepPhaseRunning, StartedAt: &started, LastTransitionTime: started},
+	}})
+}
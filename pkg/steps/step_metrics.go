@@ -0,0 +1,41 @@
+package steps
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/metrics"
+)
+
+// StepMetrics is a StepObserver that records the duration and the result of
+// every step in the Prometheus metrics of ci-operator.
+type StepMetrics struct {
+	now func() time.Time
+
+	lock    sync.Mutex
+	started map[string]time.Time
+}
+
+// NewStepMetrics creates an observer recording the metrics of the steps.
+func NewStepMetrics() *StepMetrics {
+	return &StepMetrics{now: time.Now, started: map[string]time.Time{}}
+}
+
+func (m *StepMetrics) StepStarted(step api.Step) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.started[step.Name()] = m.now()
+}
+
+func (m *StepMetrics) StepFinished(step api.Step, err error) {
+	m.lock.Lock()
+	start, ok := m.started[step.Name()]
+	delete(m.started, step.Name())
+	m.lock.Unlock()
+	result := metrics.Result(err)
+	metrics.StepsTotal.WithLabelValues(step.Name(), result).Inc()
+	if ok {
+		metrics.StepDuration.WithLabelValues(step.Name(), result).Observe(m.now().Sub(start).Seconds())
+	}
+}
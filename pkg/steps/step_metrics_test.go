@@ -0,0 +1,43 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/openshift/ci-tools/pkg/metrics"
+)
+
+func TestStepMetrics(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := NewStepMetrics()
+	m.now = func() time.Time { return now }
+
+	step := &fakeStep{name: "step-metrics-test"}
+	m.StepStarted(step)
+	now = now.Add(time.Minute)
+	m.StepFinished(step, nil)
+	m.StepStarted(step)
+	now = now.Add(time.Second)
+	m.StepFinished(step, errors.New("oops"))
+
+	for result, expected := range map[string]float64{"success": 60, "failure": 1} {
+		counter := &dto.Metric{}
+		if err := metrics.StepsTotal.WithLabelValues(step.name, result).Write(counter); err != nil {
+			t.Fatalf("could not read the counter: %v", err)
+		}
+		if counter.Counter.GetValue() != 1 {
+			t.Errorf("expected one %s, got %v", result, counter.Counter.GetValue())
+		}
+		histogram := &dto.Metric{}
+		if err := metrics.StepDuration.WithLabelValues(step.name, result).(prometheus.Metric).Write(histogram); err != nil {
+			t.Fatalf("could not read the histogram: %v", err)
+		}
+		if actual := histogram.Histogram.GetSampleSum(); actual != expected {
+			t.Errorf("expected the %s to last %vs, got %vs", result, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// Teardown stops what the steps of the job left running in the namespace
+// when the execution is interrupted: builds that have not finished are
+// cancelled and pods that run once and have not terminated are deleted.
+// Only objects labelled for this job and attempt are touched, so other jobs
+// that share the namespace are not affected.
+func Teardown(ctx context.Context, client ctrlruntimeclient.Client, jobSpec *api.JobSpec) error {
+	namespace := jobSpec.Namespace()
+	selector := ctrlruntimeclient.MatchingLabels(StandardLabels(jobSpec))
+	var errs []error
+
+	builds := &buildapi.BuildList{}
+	if err := client.List(ctx, builds, ctrlruntimeclient.InNamespace(namespace), selector); err != nil {
+		errs = append(errs, fmt.Errorf("could not list builds: %w", err))
+	}
+	for i := range builds.Items {
+		build := &builds.Items[i]
+		if isBuildPhaseTerminated(build.Status.Phase) {
+			continue
+		}
+		logrus.Infof("cleanup: Cancelling build %s", build.Name)
+		build.Status.Cancelled = true
+		if err := client.Update(ctx, build); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not cancel build %s: %w", build.Name, err))
+		}
+	}
+
+	pods := &coreapi.PodList{}
+	if err := client.List(ctx, pods, ctrlruntimeclient.InNamespace(namespace), selector); err != nil {
+		errs = append(errs, fmt.Errorf("could not list pods: %w", err))
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.RestartPolicy != coreapi.RestartPolicyNever || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Status.Phase == coreapi.PodSucceeded || pod.Status.Phase == coreapi.PodFailed {
+			continue
+		}
+		logrus.Infof("cleanup: Deleting pod %s", pod.Name)
+		if err := client.Delete(ctx, pod); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not delete pod %s: %w", pod.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
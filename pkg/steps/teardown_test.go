@@ -0,0 +1,66 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestTeardown(t *testing.T) {
+	jobSpec := &api.JobSpec{}
+	jobSpec.Job = "job"
+	jobSpec.SetNamespace("ns")
+	labels := map[string]string{JobNameLabel: "job"}
+	build := func(name string, phase buildapi.BuildPhase, labels map[string]string) *buildapi.Build {
+		return &buildapi.Build{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: name, Labels: labels},
+			Status:     buildapi.BuildStatus{Phase: phase},
+		}
+	}
+	pod := func(name string, restart coreapi.RestartPolicy, phase coreapi.PodPhase, labels map[string]string) *coreapi.Pod {
+		return &coreapi.Pod{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: name, Labels: labels},
+			Spec:       coreapi.PodSpec{RestartPolicy: restart},
+			Status:     coreapi.PodStatus{Phase: phase},
+		}
+	}
+	client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects([]runtime.Object{
+		build("running", buildapi.BuildPhaseRunning, labels),
+		build("complete", buildapi.BuildPhaseComplete, labels),
+		build("other-job", buildapi.BuildPhaseRunning, map[string]string{JobNameLabel: "other"}),
+		pod("test", coreapi.RestartPolicyNever, coreapi.PodRunning, labels),
+		pod("finished", coreapi.RestartPolicyNever, coreapi.PodSucceeded, labels),
+		pod("server", coreapi.RestartPolicyAlways, coreapi.PodRunning, labels),
+		pod("other-job", coreapi.RestartPolicyNever, coreapi.PodRunning, map[string]string{JobNameLabel: "other"}),
+	}...).Build()
+
+	if err := Teardown(context.Background(), client, jobSpec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, cancelled := range map[string]bool{"running": true, "complete": false, "other-job": false} {
+		b := &buildapi.Build{}
+		if err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: name}, b); err != nil {
+			t.Fatalf("could not get build %s: %v", name, err)
+		}
+		if b.Status.Cancelled != cancelled {
+			t.Errorf("build %s: expected cancelled to be %t, got %t", name, cancelled, b.Status.Cancelled)
+		}
+	}
+	for name, deleted := range map[string]bool{"test": true, "finished": false, "server": false, "other-job": false} {
+		err := client.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: name}, &coreapi.Pod{})
+		if kerrors.IsNotFound(err) != deleted {
+			t.Errorf("pod %s: expected deleted to be %t, got error %v", name, deleted, err)
+		}
+	}
+}
@@ -13,7 +13,9 @@ import (
 	coreapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -26,6 +28,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
 	"github.com/openshift/ci-tools/pkg/util"
@@ -48,6 +51,11 @@ const (
 	RefsVariantLabel        = "ci.openshift.io/refs.variant"
 	JobNameLabel            = "ci.openshift.io/job"
 	MultiStageStepNameLabel = "ci.openshift.io/step"
+	InputHashLabel          = "ci.openshift.io/hash"
+	AttemptLabel            = "ci.openshift.io/attempt"
+	// TemplateLabel identifies the objects created by a template, so those
+	// left behind by an earlier attempt can be cleaned up before it runs again.
+	TemplateLabel = "ci.openshift.io/template"
 
 	TestContainerName = "test"
 )
@@ -59,6 +67,7 @@ type templateExecutionStep struct {
 	podClient kubernetes.PodClient
 	client    TemplateClient
 	jobSpec   *api.JobSpec
+	censor    *secrets.DynamicCensor
 
 	subTests []*junit.TestCase
 }
@@ -74,7 +83,7 @@ func (s *templateExecutionStep) Run(ctx context.Context) error {
 }
 
 func (s *templateExecutionStep) run(ctx context.Context) error {
-	logrus.Infof("Executing template %s", s.template.Name)
+	LoggerFor(ctx).Infof("Executing template %s", s.template.Name)
 
 	if len(s.template.Objects) == 0 {
 		return fmt.Errorf("template %s has no objects", s.template.Name)
@@ -106,31 +115,35 @@ func (s *templateExecutionStep) run(ctx context.Context) error {
 		}
 	}
 
-	operateOnTemplatePods(s.template, s.resources)
-	injectLabelsToTemplate(s.jobSpec, s.template)
-
-	// TODO: enforce single namespace behavior
-	instance := &templateapi.TemplateInstance{
-		ObjectMeta: meta.ObjectMeta{
-			Namespace: s.jobSpec.Namespace(),
-			Name:      s.template.Name,
-		},
-		Spec: templateapi.TemplateInstanceSpec{
-			Template: *s.template,
-		},
+	if err := s.saveParameters(); err != nil {
+		logrus.WithError(err).Warnf("Could not save the parameters of template %s.", s.template.Name)
 	}
-	if owner := s.jobSpec.Owner(); owner != nil {
-		instance.OwnerReferences = append(instance.OwnerReferences, *owner)
+
+	if hasServiceAccounts(s.template) {
+		secret := &coreapi.Secret{}
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PullRateLimitBypassSecret}, secret); err == nil {
+			injectPullSecretToServiceAccounts(s.template, api.PullRateLimitBypassSecret)
+		} else if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("could not check for the pull secret: %w", err)
+		}
 	}
 
+	instance := templateInstanceFor(s.jobSpec, s.template, s.resources)
+
+	name := instance.Name
 	go func() {
 		<-ctx.Done()
-		logrus.Infof("cleanup: Deleting template %s", s.template.Name)
-		if err := s.client.Delete(CleanupCtx, &templateapi.TemplateInstance{ObjectMeta: meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: s.template.Name}}, ctrlruntimeclient.PropagationPolicy(meta.DeletePropagationForeground)); err != nil && !kerrors.IsNotFound(err) {
+		logrus.Infof("cleanup: Deleting template %s", name)
+		if err := s.client.Delete(CleanupCtx, &templateapi.TemplateInstance{ObjectMeta: meta.ObjectMeta{Namespace: s.jobSpec.Namespace(), Name: name}}, ctrlruntimeclient.PropagationPolicy(meta.DeletePropagationForeground)); err != nil && !kerrors.IsNotFound(err) {
 			logrus.WithError(err).Error("Could not delete template instance.")
 		}
 	}()
 
+	logrus.Debugf("Deleting objects left behind by earlier template instances")
+	if err := deleteStaleTemplateObjects(ctx, s.client, s.jobSpec.Namespace(), s.template); err != nil {
+		return fmt.Errorf("could not delete objects left behind by template %s: %w", s.template.Name, err)
+	}
+
 	logrus.Debugf("Creating or restarting template instance")
 	_, err := createOrRestartTemplateInstance(ctx, s.client, instance)
 	if err != nil {
@@ -138,7 +151,7 @@ func (s *templateExecutionStep) run(ctx context.Context) error {
 	}
 
 	logrus.Debugf("Waiting for template instance to be ready")
-	instance, err = waitForTemplateInstanceReady(ctrlruntimeclient.NewNamespacedClient(s.client, s.jobSpec.Namespace()), s.template.Name)
+	instance, err = waitForTemplateInstanceReady(ctrlruntimeclient.NewNamespacedClient(s.client, s.jobSpec.Namespace()), name)
 	if err != nil {
 		return fmt.Errorf("could not wait for template instance to be ready: %w", err)
 	}
@@ -146,7 +159,7 @@ func (s *templateExecutionStep) run(ctx context.Context) error {
 	// now that the pods have been resolved by the template, add them to the artifact map
 	var notifier util.ContainerNotifier = util.NopNotifier
 	if artifactDir, artifactsRequested := api.Artifacts(); artifactsRequested {
-		artifacts := NewArtifactWorker(s.podClient, filepath.Join(artifactDir, s.template.Name), s.jobSpec.Namespace())
+		artifacts := NewArtifactWorker(s.podClient, filepath.Join(artifactDir, s.jobSpec.AttemptArtifactDir(s.template.Name)), s.jobSpec.Namespace())
 		for _, ref := range instance.Status.Objects {
 			switch {
 			case ref.Ref.Kind == "Pod" && ref.Ref.APIVersion == "v1":
@@ -188,6 +201,73 @@ func (s *templateExecutionStep) run(ctx context.Context) error {
 	return nil
 }
 
+// templateParameter is the value a parameter of a template was given, as
+// recorded in the artifacts.
+type templateParameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// redactedParameterValue replaces the values of parameters that contain a
+// secret in the artifacts.
+const redactedParameterValue = "<from secret>"
+
+// RedactParameterValue replaces the value of a parameter as a whole when it
+// contains a secret, as censoring only the secret in it may still leave
+// parts of it that are derived from the secret.
+func RedactParameterValue(censor *secrets.DynamicCensor, value string) string {
+	censored := []byte(value)
+	censor.Censor(&censored)
+	if string(censored) != value {
+		return redactedParameterValue
+	}
+	return value
+}
+
+// saveParameters records the parameters the template is executed with in
+// templates/<name>/parameters.json in the artifacts, so their values can be
+// inspected without running again.
+func (s *templateExecutionStep) saveParameters() error {
+	if _, set := api.Artifacts(); !set || s.censor == nil {
+		return nil
+	}
+	parameters := make([]templateParameter, 0, len(s.template.Parameters))
+	for _, p := range s.template.Parameters {
+		parameters = append(parameters, templateParameter{Name: p.Name, Value: RedactParameterValue(s.censor, p.Value)})
+	}
+	data, err := json.MarshalIndent(parameters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal parameters: %w", err)
+	}
+	return api.SaveArtifact(s.censor, s.jobSpec.AttemptArtifactDir(filepath.Join("templates", s.template.Name, "parameters.json")), data)
+}
+
+// templateInstanceFor prepares the template for execution in the namespace
+// of the job and wraps it in a template instance.
+func templateInstanceFor(jobSpec *api.JobSpec, template *templateapi.Template, resources api.ResourceConfiguration) *templateapi.TemplateInstance {
+	operateOnTemplatePods(template, resources)
+	injectLabelsToTemplate(jobSpec, template)
+	if template.ObjectLabels == nil {
+		template.ObjectLabels = make(map[string]string)
+	}
+	template.ObjectLabels[TemplateLabel] = template.Name
+
+	// TODO: enforce single namespace behavior
+	instance := &templateapi.TemplateInstance{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: jobSpec.Namespace(),
+			Name:      jobSpec.NameForAttempt(template.Name),
+		},
+		Spec: templateapi.TemplateInstanceSpec{
+			Template: *template,
+		},
+	}
+	if owner := jobSpec.Owner(); owner != nil {
+		instance.OwnerReferences = append(instance.OwnerReferences, *owner)
+	}
+	return instance
+}
+
 func injectLabelsToTemplate(jobSpec *api.JobSpec, template *templateapi.Template) {
 	if refs := jobSpec.JobSpec.Refs; refs != nil {
 		if template.ObjectLabels == nil {
@@ -246,6 +326,24 @@ func operateOnTemplatePods(template *templateapi.Template, resources api.Resourc
 	}
 }
 
+// DryRun describes the template instance the step would create. Parameters
+// are resolved when the step runs, so they keep their default values.
+func (s *templateExecutionStep) DryRun(context.Context) ([]api.DryRunAction, error) {
+	instance := templateInstanceFor(s.jobSpec, s.template.DeepCopy(), s.resources)
+	return []api.DryRunAction{{
+		Description: fmt.Sprintf("Create template instance %s and wait for its pods to complete", instance.Name),
+		Object:      instance,
+	}}, nil
+}
+
+// AttemptNames reports the name of the template instance for this attempt.
+func (s *templateExecutionStep) AttemptNames() map[string]string {
+	if name := s.jobSpec.NameForAttempt(s.template.Name); name != s.template.Name {
+		return map[string]string{s.template.Name: name}
+	}
+	return nil
+}
+
 func (s *templateExecutionStep) SubTests() []*junit.TestCase {
 	return s.subTests
 }
@@ -278,7 +376,7 @@ func (s *templateExecutionStep) Objects() []ctrlruntimeclient.Object {
 	return s.client.Objects()
 }
 
-func TemplateExecutionStep(template *templateapi.Template, params api.Parameters, podClient kubernetes.PodClient, templateClient TemplateClient, jobSpec *api.JobSpec, resources api.ResourceConfiguration) api.Step {
+func TemplateExecutionStep(template *templateapi.Template, params api.Parameters, podClient kubernetes.PodClient, templateClient TemplateClient, jobSpec *api.JobSpec, resources api.ResourceConfiguration, censor *secrets.DynamicCensor) api.Step {
 	return &templateExecutionStep{
 		template:  template,
 		resources: resources,
@@ -286,6 +384,7 @@ func TemplateExecutionStep(template *templateapi.Template, params api.Parameters
 		podClient: podClient,
 		client:    templateClient,
 		jobSpec:   jobSpec,
+		censor:    censor,
 	}
 }
 
@@ -348,6 +447,58 @@ func createOrRestartTemplateInstance(ctx context.Context, client ctrlruntimeclie
 	return instance, nil
 }
 
+// deleteStaleTemplateObjects deletes the objects an earlier instance of the
+// template left behind, for example when the instance was deleted without
+// its dependents, as they would keep the template from being instantiated
+// again. Only objects of the kinds the template creates are considered.
+func deleteStaleTemplateObjects(ctx context.Context, client ctrlruntimeclient.Client, namespace string, template *templateapi.Template) error {
+	kinds := map[schema.GroupVersionKind]struct{}{}
+	for _, object := range template.Objects {
+		var typeMeta meta.TypeMeta
+		if object.Object != nil {
+			gvk := object.Object.GetObjectKind().GroupVersionKind()
+			typeMeta.APIVersion, typeMeta.Kind = gvk.ToAPIVersionAndKind()
+		} else if err := json.Unmarshal(object.Raw, &typeMeta); err != nil {
+			return fmt.Errorf("could not determine the kind of a template object: %w", err)
+		}
+		if typeMeta.Kind == "" {
+			continue
+		}
+		kinds[schema.FromAPIVersionAndKind(typeMeta.APIVersion, typeMeta.Kind+"List")] = struct{}{}
+	}
+	selector := ctrlruntimeclient.MatchingLabels{TemplateLabel: template.Name}
+	listStale := func(kind schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(kind)
+		if err := client.List(ctx, list, ctrlruntimeclient.InNamespace(namespace), selector); err != nil {
+			return nil, fmt.Errorf("could not list %s: %w", kind.Kind, err)
+		}
+		return list.Items, nil
+	}
+	for kind := range kinds {
+		stale, err := listStale(kind)
+		if err != nil {
+			return err
+		}
+		for i := range stale {
+			logrus.Infof("Deleting %s %s left behind by template %s", stale[i].GetKind(), stale[i].GetName(), template.Name)
+			if err := client.Delete(ctx, &stale[i], ctrlruntimeclient.PropagationPolicy(meta.DeletePropagationForeground)); err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("could not delete %s %s: %w", stale[i].GetKind(), stale[i].GetName(), err)
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+		if err := wait.PollImmediate(2*time.Second, 5*time.Minute, func() (bool, error) {
+			remaining, err := listStale(kind)
+			return len(remaining) == 0, err
+		}); err != nil {
+			return fmt.Errorf("could not wait for stale %s to be deleted: %w", kind.Kind, err)
+		}
+	}
+	return nil
+}
+
 func waitForCompletedTemplateInstanceDeletion(ctx context.Context, client ctrlruntimeclient.Client, namespace, name string) error {
 	instance := &templateapi.TemplateInstance{}
 	err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, instance)
@@ -417,6 +568,77 @@ func templateInstanceReady(instance *templateapi.TemplateInstance) (ready bool,
 	return false, nil
 }
 
+// AddPullSecret references the secret from the service account for pulling
+// images and for builds, and reports whether the service account changed.
+func AddPullSecret(sa *coreapi.ServiceAccount, name string) bool {
+	for _, ref := range sa.ImagePullSecrets {
+		if ref.Name == name {
+			return false
+		}
+	}
+	sa.ImagePullSecrets = append(sa.ImagePullSecrets, coreapi.LocalObjectReference{Name: name})
+	sa.Secrets = append(sa.Secrets, coreapi.ObjectReference{Name: name})
+	return true
+}
+
+func hasServiceAccounts(template *templateapi.Template) bool {
+	for _, object := range template.Objects {
+		if getServiceAccountFromObject(object) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// injectPullSecretToServiceAccounts lets the service accounts the template
+// creates pull with the secret.
+func injectPullSecretToServiceAccounts(template *templateapi.Template, name string) {
+	for index, object := range template.Objects {
+		if sa := getServiceAccountFromObject(object); sa != nil && AddPullSecret(sa, name) {
+			template.Objects[index].Raw = []byte(runtime.EncodeOrDie(corev1Codec, sa))
+			template.Objects[index].Object = sa.DeepCopyObject()
+		}
+	}
+}
+
+// SetTemplateRuntimeClass makes the pods the template creates run with the
+// RuntimeClass, replacing the one they set themselves.
+func SetTemplateRuntimeClass(template *templateapi.Template, runtimeClass string) {
+	for index, object := range template.Objects {
+		if pod := getPodFromObject(object); pod != nil {
+			SetPodRuntimeClass(&pod.Spec, runtimeClass)
+			template.Objects[index].Raw = []byte(runtime.EncodeOrDie(corev1Codec, pod))
+			template.Objects[index].Object = pod.DeepCopyObject()
+		}
+	}
+}
+
+// TemplateImages returns the images the pods of the template run, with the
+// references to the parameters of the template that they may contain.
+func TemplateImages(template *templateapi.Template) []string {
+	var images []string
+	for _, object := range template.Objects {
+		pod := getPodFromObject(object)
+		if pod == nil {
+			continue
+		}
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			if container.Image != "" {
+				images = append(images, container.Image)
+			}
+		}
+	}
+	return images
+}
+
+func getServiceAccountFromObject(object runtime.RawExtension) *coreapi.ServiceAccount {
+	requiredObj, _ := runtime.Decode(codecFactory.UniversalDecoder(coreapi.SchemeGroupVersion), object.Raw)
+	if sa, ok := requiredObj.(*coreapi.ServiceAccount); ok {
+		return sa
+	}
+	return nil
+}
+
 func getPodFromObject(object runtime.RawExtension) *coreapi.Pod {
 	// We don't care for errors, because we accept that this func() will check also a non-pod objects.
 	requiredObj, _ := runtime.Decode(codecFactory.UniversalDecoder(coreapi.SchemeGroupVersion), object.Raw)
@@ -1,6 +1,10 @@
 package steps
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	coreapi "k8s.io/api/core/v1"
@@ -9,10 +13,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	templateapi "github.com/openshift/api/template/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
@@ -368,3 +374,99 @@ func TestInjectLabelsToTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteStaleTemplateObjects(t *testing.T) {
+	stale := &coreapi.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "e2e", Labels: map[string]string{TemplateLabel: "e2e-template"}}}
+	other := &coreapi.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "unrelated", Labels: map[string]string{TemplateLabel: "other"}}}
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(stale, other).Build()
+	template := &templateapi.Template{
+		ObjectMeta: meta.ObjectMeta{Name: "e2e-template"},
+		Objects:    []runtime.RawExtension{{Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"e2e"}}`)}},
+	}
+	if err := deleteStaleTemplateObjects(context.Background(), client, "ns", template); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var pods coreapi.PodList
+	if err := client.List(context.Background(), &pods); err != nil {
+		t.Fatalf("could not list pods: %v", err)
+	}
+	var remaining []string
+	for _, pod := range pods.Items {
+		remaining = append(remaining, pod.Name)
+	}
+	testhelper.Diff(t, "remaining pods", remaining, []string{"unrelated"})
+}
+
+func TestInjectPullSecretToServiceAccounts(t *testing.T) {
+	sa := &coreapi.ServiceAccount{
+		TypeMeta:   meta.TypeMeta{Kind: "ServiceAccount", APIVersion: "v1"},
+		ObjectMeta: meta.ObjectMeta{Name: "installer"},
+	}
+	template := &templateapi.Template{
+		Objects: []runtime.RawExtension{
+			{Raw: []byte(runtime.EncodeOrDie(corev1Codec, sa))},
+			{Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"e2e"}}`)},
+		},
+	}
+	if !hasServiceAccounts(template) {
+		t.Fatal("expected the template to have service accounts")
+	}
+	injectPullSecretToServiceAccounts(template, api.PullRateLimitBypassSecret)
+	injectPullSecretToServiceAccounts(template, api.PullRateLimitBypassSecret)
+	actual := getServiceAccountFromObject(template.Objects[0])
+	testhelper.Diff(t, "image pull secrets", actual.ImagePullSecrets, []coreapi.LocalObjectReference{{Name: api.PullRateLimitBypassSecret}})
+	testhelper.Diff(t, "secrets", actual.Secrets, []coreapi.ObjectReference{{Name: api.PullRateLimitBypassSecret}})
+	if getServiceAccountFromObject(template.Objects[1]) != nil {
+		t.Error("expected the pod to be left alone")
+	}
+}
+
+func TestSetTemplateRuntimeClass(t *testing.T) {
+	template := &templateapi.Template{
+		Objects: []runtime.RawExtension{
+			{Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"e2e"}}`)},
+			{Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"setup"},"spec":{"runtimeClassName":"runc"}}`)},
+		},
+	}
+	SetTemplateRuntimeClass(template, "gvisor")
+	var actual []string
+	for _, object := range template.Objects {
+		actual = append(actual, *getPodFromObject(object).Spec.RuntimeClassName)
+	}
+	testhelper.Diff(t, "runtime classes", actual, []string{"gvisor", "gvisor"})
+}
+
+func TestSaveTemplateParameters(t *testing.T) {
+	artifactDir := t.TempDir()
+	t.Setenv("ARTIFACTS", artifactDir)
+	censor := secrets.NewDynamicCensor()
+	censor.AddSecrets("hunter2")
+	step := &templateExecutionStep{
+		template: &templateapi.Template{
+			ObjectMeta: meta.ObjectMeta{Name: "e2e"},
+			Parameters: []templateapi.Parameter{
+				{Name: "JOB_NAME", Value: "pull-ci-org-repo-e2e"},
+				{Name: "PASSWORD", Value: "hunter2"},
+				{Name: "UNSET"},
+			},
+		},
+		jobSpec: &api.JobSpec{},
+		censor:  &censor,
+	}
+	if err := step.saveParameters(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(artifactDir, "templates", "e2e", "parameters.json"))
+	if err != nil {
+		t.Fatalf("could not read parameters: %v", err)
+	}
+	var actual []templateParameter
+	if err := json.Unmarshal(raw, &actual); err != nil {
+		t.Fatalf("could not unmarshal parameters: %v", err)
+	}
+	testhelper.Diff(t, "parameters", actual, []templateParameter{
+		{Name: "JOB_NAME", Value: "pull-ci-org-repo-e2e"},
+		{Name: "PASSWORD", Value: redactedParameterValue},
+		{Name: "UNSET"},
+	})
+}
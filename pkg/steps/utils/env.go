@@ -12,6 +12,7 @@ const (
 	initialEnvPrefix  = "INITIAL_"
 	imageEnvPrefix    = "IMAGE_"
 	releaseEnvPrefix  = "RELEASE_"
+	rpmRepoEnvPrefix  = "RPM_REPO_"
 
 	// ImageFormatEnv is the environment we use to hold the base pull spec
 	ImageFormatEnv = "IMAGE_FORMAT"
@@ -67,6 +68,8 @@ func LinkForEnv(envVar string) (api.StepLink, bool) {
 		return api.ReleaseImagesLink(api.InitialReleaseName), true
 	case IsReleaseImageEnv(envVar):
 		return api.ReleasePayloadImageLink(ReleaseNameFrom(envVar)), true
+	case IsRPMRepoEnv(envVar):
+		return api.RPMRepoLink(), true
 	default:
 		return nil, false
 	}
@@ -147,6 +150,12 @@ func IsReleaseImageEnv(envVar string) bool {
 	return strings.HasPrefix(envVar, knownPrefixes[api.ReleaseImageStream])
 }
 
+// IsRPMRepoEnv determines if an env var holds the URL of
+// the repository serving the RPMs built by the job
+func IsRPMRepoEnv(envVar string) bool {
+	return strings.HasPrefix(envVar, rpmRepoEnvPrefix)
+}
+
 // ReleaseNameFrom determines the name of the release payload
 // that the pull spec points to.
 func ReleaseNameFrom(envVar string) string {
@@ -154,3 +163,10 @@ func ReleaseNameFrom(envVar string) string {
 	name, _ := imageFromEnv(api.ReleaseImageStream, envVar)
 	return name
 }
+
+// ImageParameter describes a parameter holding the pull spec of an image,
+// linked to what provides the image as determined by LinkForEnv.
+func ImageParameter(envVar, description string, value func() (string, error)) api.Parameter {
+	link, _ := LinkForEnv(envVar)
+	return api.Parameter{Name: envVar, Description: description, Value: value, Link: link}
+}
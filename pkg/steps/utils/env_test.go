@@ -94,6 +94,11 @@ func TestLinkForEnv(t *testing.T) {
 			output: api.ReleasePayloadImageLink("foobar"),
 			valid:  true,
 		},
+		{
+			input:  "RPM_REPO_ORG_REPO",
+			output: api.RPMRepoLink(),
+			valid:  true,
+		},
 	}
 
 	for _, testCase := range testCases {
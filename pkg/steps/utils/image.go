@@ -8,8 +8,22 @@ import (
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
 )
 
+// PipelineImageParameter describes the parameter holding the pull spec of a
+// tag of the pipeline image stream in the test namespace.
+func PipelineImageParameter(client ctrlruntimeclient.Client, namespace func() string, tag api.PipelineImageStreamTagReference) api.Parameter {
+	return ImageParameter(PipelineImageEnvFor(tag), fmt.Sprintf("Pull spec of the %s image built by the job", tag), ImageDigestFor(client, namespace, api.PipelineImageStream, string(tag)))
+}
+
+// ReleaseImageParameter describes the parameter holding the pull spec of a
+// release payload in the test namespace.
+func ReleaseImageParameter(client ctrlruntimeclient.Client, namespace func() string, name string) api.Parameter {
+	return ImageParameter(ReleaseImageEnv(name), fmt.Sprintf("Pull spec of the %s release payload", name), ImageDigestFor(client, namespace, api.ReleaseImageStream, name))
+}
+
 func ImageDigestFor(client ctrlruntimeclient.Client, namespace func() string, name, tag string) func() (string, error) {
 	return func() (string, error) {
 		is := &imagev1.ImageStream{}
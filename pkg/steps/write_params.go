@@ -2,6 +2,7 @@ package steps
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
@@ -11,14 +12,36 @@ import (
 	"github.com/sirupsen/logrus"
 
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/results"
 )
 
+// ParametersFormat is the format of the file the parameters are written to.
+type ParametersFormat string
+
+const (
+	// ParametersFormatEnv writes one KEY=value line per parameter, quoted so
+	// that the file can be sourced by a POSIX shell.
+	ParametersFormatEnv ParametersFormat = "env"
+	// ParametersFormatJSON writes a JSON object.
+	ParametersFormatJSON ParametersFormat = "json"
+	// ParametersFormatYAML writes a YAML mapping.
+	ParametersFormatYAML ParametersFormat = "yaml"
+	// ParametersFormatExport writes the lines of ParametersFormatEnv as
+	// export statements, so that sourcing the file exports the parameters
+	// to the processes the shell starts.
+	ParametersFormatExport ParametersFormat = "export"
+)
+
+// ParametersFormats are the supported formats.
+var ParametersFormats = []ParametersFormat{ParametersFormatEnv, ParametersFormatJSON, ParametersFormatYAML, ParametersFormatExport}
+
 type writeParametersStep struct {
 	params    *api.DeferredParameters
 	paramFile string
+	format    ParametersFormat
 }
 
 var safeEnv = regexp.MustCompile(`^[a-zA-Z0-9_\-\.]*$`)
@@ -35,24 +58,44 @@ func (s *writeParametersStep) Run(_ context.Context) error {
 
 func (s *writeParametersStep) run() error {
 	logrus.Infof("Writing parameters to %s", s.paramFile)
-	var params []string
-
 	values, err := s.params.Map()
 	if err != nil {
 		return fmt.Errorf("failed to resolve parameters: %w", err)
 	}
+	var raw []byte
+	switch s.format {
+	case ParametersFormatJSON:
+		raw, err = json.MarshalIndent(values, "", "  ")
+		raw = append(raw, '\n')
+	case ParametersFormatYAML:
+		raw, err = yaml.Marshal(values)
+	case ParametersFormatExport:
+		raw = []byte(envFile(values, "export "))
+	default:
+		raw = []byte(envFile(values, ""))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal parameters: %w", err)
+	}
+	return os.WriteFile(s.paramFile, raw, 0640)
+}
+
+// envFile formats the parameters as lines of KEY=value, sorted by key and
+// starting with prefix. Values that are not safe are enclosed in single
+// quotes, so they are used verbatim, including backslashes, carriage returns
+// and line breaks. The lines always end with a line feed.
+func envFile(values map[string]string, prefix string) string {
+	var params []string
 	for k, v := range values {
 		if safeEnv.MatchString(v) {
-			params = append(params, fmt.Sprintf("%s=%s", k, v))
+			params = append(params, fmt.Sprintf("%s%s=%s", prefix, k, v))
 			continue
 		}
-		params = append(params, fmt.Sprintf("%s='%s'", k, strings.Replace(strings.Replace(v, "\\", "\\\\", -1), "'", "\\'", -1)))
+		params = append(params, fmt.Sprintf("%s%s='%s'", prefix, k, strings.ReplaceAll(v, "'", `'\''`)))
 	}
-
 	sort.Strings(params)
-
 	params = append(params, "")
-	return os.WriteFile(s.paramFile, []byte(strings.Join(params, "\n")), 0640)
+	return strings.Join(params, "\n")
 }
 
 func (s *writeParametersStep) Requires() []api.StepLink {
@@ -75,9 +118,10 @@ func (s *writeParametersStep) Objects() []ctrlruntimeclient.Object {
 	return nil
 }
 
-func WriteParametersStep(params *api.DeferredParameters, paramFile string) api.Step {
+func WriteParametersStep(params *api.DeferredParameters, paramFile string, format ParametersFormat) api.Step {
 	return &writeParametersStep{
 		params:    params,
 		paramFile: paramFile,
+		format:    format,
 	}
 }
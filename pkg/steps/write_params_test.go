@@ -1,12 +1,15 @@
 package steps
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/util/diff"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
 func TestWriteParamsStep(t *testing.T) {
@@ -19,7 +22,7 @@ func TestWriteParamsStep(t *testing.T) {
 	}
 	defer os.Remove(paramFile.Name())
 
-	wps := WriteParametersStep(params, paramFile.Name())
+	wps := WriteParametersStep(params, paramFile.Name(), ParametersFormatEnv)
 
 	specification := stepExpectation{
 		name:     "parameters/write",
@@ -59,3 +62,52 @@ func TestWriteParamsStep(t *testing.T) {
 		t.Errorf("Params were not written out as expected:\n%s", diff.StringDiff(expectedWrittenParams, writtenParams))
 	}
 }
+
+func TestWriteParamsFormats(t *testing.T) {
+	params := api.NewDeferredParameters(nil)
+	params.Add("SAFE", func() (string, error) { return "v1.2_3", nil })
+	params.Add("QUOTE", func() (string, error) { return `it's a \path`, nil })
+	params.Add("LINES", func() (string, error) { return "first\r\nsecond", nil })
+	testCases := []struct {
+		format   ParametersFormat
+		expected string
+	}{
+		{
+			format:   ParametersFormatEnv,
+			expected: "LINES='first\r\nsecond'\nQUOTE='it'\\''s a \\path'\nSAFE=v1.2_3\n",
+		},
+		{
+			format: ParametersFormatJSON,
+			expected: `{
+  "LINES": "first\r\nsecond",
+  "QUOTE": "it's a \\path",
+  "SAFE": "v1.2_3"
+}
+`,
+		},
+		{
+			format: ParametersFormatYAML,
+			expected: `LINES: "first\r\nsecond"
+QUOTE: it's a \path
+SAFE: v1.2_3
+`,
+		},
+		{
+			format:   ParametersFormatExport,
+			expected: "export LINES='first\r\nsecond'\nexport QUOTE='it'\\''s a \\path'\nexport SAFE=v1.2_3\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			paramFile := filepath.Join(t.TempDir(), "params")
+			if err := WriteParametersStep(params, paramFile, tc.format).Run(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			written, err := os.ReadFile(paramFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testhelper.Diff(t, "parameters", string(written), tc.expected)
+		})
+	}
+}
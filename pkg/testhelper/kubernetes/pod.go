@@ -17,6 +17,7 @@ import (
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/util"
 )
@@ -116,6 +117,10 @@ func (*FakePodClient) GetLogs(string, string, *coreapi.PodLogOptions) *rest.Requ
 	return rest.NewRequestWithClient(nil, "", rest.ClientContentConfig{}, nil)
 }
 
+func (*FakePodClient) Censor() *secrets.DynamicCensor {
+	return nil
+}
+
 func (f *FakePodClient) WithNewLoggingClient() kubernetes.PodClient {
 	return f
 }
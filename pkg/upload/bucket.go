@@ -0,0 +1,103 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"gocloud.dev/gcerrors"
+	"google.golang.org/api/googleapi"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	pkgio "k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/io/providers"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	"k8s.io/test-infra/prow/pod-utils/gcs"
+)
+
+// Bucket uploads files to a location in any object storage the opener
+// supports, like gs://bucket/path or s3://bucket/path.
+type Bucket struct {
+	opener   pkgio.Opener
+	location string
+}
+
+// NewBucket creates a destination for a location like s3://bucket/path.
+func NewBucket(opener pkgio.Opener, location string) (*Bucket, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid location %q: %w", location, err)
+	}
+	if (u.Scheme != providers.GS && u.Scheme != providers.S3) || u.Host == "" {
+		return nil, fmt.Errorf("invalid location %q: expected gs://bucket/path or s3://bucket/path", location)
+	}
+	return &Bucket{opener: opener, location: strings.TrimSuffix(location, "/")}, nil
+}
+
+func (b *Bucket) Upload(ctx context.Context, name, localPath string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	object := b.location + "/" + name
+	_, options := gcs.WriterOptionsFromFileName(name)
+	writer, err := b.opener.Writer(ctx, object, options)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(writer, file); err != nil {
+		_ = writer.Close()
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return object, nil
+}
+
+func (b *Bucket) Overloaded(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code == http.StatusServiceUnavailable
+	}
+	return gcerrors.Code(err) == gcerrors.ResourceExhausted
+}
+
+// JobPath is the path the artifacts of the job are stored under in the
+// bucket of a Prow deployment, like logs/<job>/<build ID> for periodic
+// jobs. Org and repo are encoded with the path strategy of the job, as
+// org_repo when it has none.
+func JobPath(spec *downwardapi.JobSpec) (string, error) {
+	switch spec.Type {
+	case prowapi.PeriodicJob, prowapi.PostsubmitJob, prowapi.BatchJob:
+	case prowapi.PresubmitJob:
+		if spec.Refs == nil || len(spec.Refs.Pulls) == 0 {
+			return "", errors.New("the refs of a presubmit job must include a pull request")
+		}
+	default:
+		return "", fmt.Errorf("unknown job type %q", spec.Type)
+	}
+	if spec.Job == "" || spec.BuildID == "" {
+		return "", errors.New("the name and the build ID of the job are required")
+	}
+	builder := gcs.NewExplicitRepoPathBuilder()
+	if spec.DecorationConfig != nil && spec.DecorationConfig.GCSConfiguration != nil {
+		config := spec.DecorationConfig.GCSConfiguration
+		switch config.PathStrategy {
+		case prowapi.PathStrategyExplicit:
+			builder = gcs.NewExplicitRepoPathBuilder()
+		case prowapi.PathStrategySingle:
+			builder = gcs.NewSingleDefaultRepoPathBuilder(config.DefaultOrg, config.DefaultRepo)
+		default:
+			builder = gcs.NewLegacyRepoPathBuilder(config.DefaultOrg, config.DefaultRepo)
+		}
+	}
+	return path.Join(gcs.PathForSpec(spec, builder), "artifacts"), nil
+}
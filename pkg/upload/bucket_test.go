@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	pkgio "k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+// objectWriter stores what was written once it is closed.
+type objectWriter struct {
+	bytes.Buffer
+	close func(string)
+}
+
+func (w *objectWriter) Close() error {
+	w.close(w.String())
+	return nil
+}
+
+type fakeOpener struct {
+	pkgio.Opener
+	written map[string]string
+}
+
+func (o *fakeOpener) Writer(_ context.Context, path string, _ ...pkgio.WriterOptions) (io.WriteCloser, error) {
+	return &objectWriter{close: func(content string) { o.written[path] = content }}, nil
+}
+
+func TestBucket(t *testing.T) {
+	if _, err := NewBucket(&fakeOpener{}, "https://example.com/bucket"); err == nil {
+		t.Error("expected an error for a location that is not in object storage")
+	}
+	opener := &fakeOpener{written: map[string]string{}}
+	bucket, err := NewBucket(opener, "s3://bucket/logs/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "junit.xml")
+	if err := os.WriteFile(file, []byte("<testsuites/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	url, err := bucket.Upload(context.Background(), "junit/junit.xml", file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.Diff(t, "url", url, "s3://bucket/logs/junit/junit.xml")
+	testhelper.Diff(t, "written", opener.written, map[string]string{"s3://bucket/logs/junit/junit.xml": "<testsuites/>"})
+}
+
+func TestJobPath(t *testing.T) {
+	refs := &prowapi.Refs{Org: "openshift", Repo: "ci-tools", Pulls: []prowapi.Pull{{Number: 42}}}
+	for _, tc := range []struct {
+		name        string
+		spec        downwardapi.JobSpec
+		expected    string
+		expectedErr bool
+	}{{
+		name:     "periodic",
+		spec:     downwardapi.JobSpec{Type: prowapi.PeriodicJob, Job: "periodic-job", BuildID: "1"},
+		expected: "logs/periodic-job/1/artifacts",
+	}, {
+		name:     "presubmit",
+		spec:     downwardapi.JobSpec{Type: prowapi.PresubmitJob, Job: "pull-job", BuildID: "2", Refs: refs},
+		expected: "pr-logs/pull/openshift_ci-tools/42/pull-job/2/artifacts",
+	}, {
+		name: "presubmit of the default repository",
+		spec: downwardapi.JobSpec{Type: prowapi.PresubmitJob, Job: "pull-job", BuildID: "2", Refs: refs, DecorationConfig: &prowapi.DecorationConfig{
+			GCSConfiguration: &prowapi.GCSConfiguration{PathStrategy: prowapi.PathStrategySingle, DefaultOrg: "openshift", DefaultRepo: "ci-tools"},
+		}},
+		expected: "pr-logs/pull/42/pull-job/2/artifacts",
+	}, {
+		name:        "presubmit without a pull request",
+		spec:        downwardapi.JobSpec{Type: prowapi.PresubmitJob, Job: "pull-job", BuildID: "2"},
+		expectedErr: true,
+	}, {
+		name:        "no build ID",
+		spec:        downwardapi.JobSpec{Type: prowapi.PeriodicJob, Job: "periodic-job"},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := JobPath(&tc.spec)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			testhelper.Diff(t, "path", actual, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+)
+
+// GCS uploads files to a location in a Google Cloud Storage bucket.
+type GCS struct {
+	bucket string
+	prefix string
+	handle *storage.BucketHandle
+}
+
+// NewGCS creates a destination for a location like gs://bucket/path.
+func NewGCS(client *storage.Client, location string) (*GCS, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid location %q: %w", location, err)
+	}
+	if u.Scheme != "gs" || u.Host == "" {
+		return nil, fmt.Errorf("invalid location %q: expected gs://bucket/path", location)
+	}
+	return &GCS{
+		bucket: u.Host,
+		prefix: strings.Trim(u.Path, "/"),
+		handle: client.Bucket(u.Host),
+	}, nil
+}
+
+func (g *GCS) Upload(ctx context.Context, name, localPath string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	object := path.Join(g.prefix, name)
+	writer := g.handle.Object(object).NewWriter(ctx)
+	if _, err := io.Copy(writer, file); err != nil {
+		_ = writer.Close()
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("gs://%s/%s", g.bucket, object), nil
+}
+
+func (g *GCS) Overloaded(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusTooManyRequests || apiErr.Code == http.StatusServiceUnavailable
+}
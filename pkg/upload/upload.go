@@ -0,0 +1,216 @@
+// Package upload uploads directories of artifacts with a bounded number of
+// concurrent uploads, retrying failed uploads and slowing down every upload
+// when the destination signals that it is overloaded.
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ManifestName is the name of the manifest that is uploaded last and lists
+// every uploaded object.
+const ManifestName = "upload-manifest.json"
+
+// Destination stores uploaded files.
+type Destination interface {
+	// Upload stores the content of the local file under the name and returns
+	// the URL of the stored object.
+	Upload(ctx context.Context, name, path string) (string, error)
+	// Overloaded determines whether the error signals that the destination
+	// cannot handle more requests right now.
+	Overloaded(err error) bool
+}
+
+// Options control how files are uploaded.
+type Options struct {
+	// Concurrency is the maximum number of concurrent uploads.
+	Concurrency int
+	// Retries is the number of times a failed upload is retried.
+	Retries int
+	// Backoff is the delay before the first retry, doubled for every
+	// following one.
+	Backoff time.Duration
+}
+
+// Object is an uploaded file.
+type Object struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Manifest lists the uploaded files.
+type Manifest struct {
+	Objects []Object `json:"objects"`
+	// Failed lists the files that could not be uploaded.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// throttle delays every upload once the destination is overloaded.
+type throttle struct {
+	lock  sync.Mutex
+	until time.Time
+}
+
+func (t *throttle) pause(d time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if until := time.Now().Add(d); until.After(t.until) {
+		t.until = until
+	}
+}
+
+func (t *throttle) wait(ctx context.Context) error {
+	t.lock.Lock()
+	delay := time.Until(t.until)
+	t.lock.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// Directory uploads every file in the directory, named by its path relative
+// to the directory, followed by the manifest. Files are handed to a fixed
+// number of workers, so the directory is only read as fast as the uploads
+// complete.
+func Directory(ctx context.Context, destination Destination, dir string, options Options) (*Manifest, error) {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	files := make(chan string)
+	var walkErr error
+	go func() {
+		defer close(files)
+		walkErr = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// links, like the one to the artifacts of the final attempt,
+			// point at content that is uploaded on its own
+			if entry.IsDir() || entry.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+			select {
+			case files <- path:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	manifest := &Manifest{Objects: []Object{}}
+	var lock sync.Mutex
+	var errs []error
+	t := &throttle{}
+	wg := sync.WaitGroup{}
+	wg.Add(options.Concurrency)
+	for i := 0; i < options.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range files {
+				name, err := filepath.Rel(dir, path)
+				if err != nil {
+					continue
+				}
+				name = filepath.ToSlash(name)
+				url, err := uploadWithRetries(ctx, destination, t, name, path, options)
+				lock.Lock()
+				if err != nil {
+					errs = append(errs, err)
+					manifest.Failed = append(manifest.Failed, name)
+				} else {
+					manifest.Objects = append(manifest.Objects, Object{Name: name, URL: url})
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if walkErr != nil {
+		errs = append(errs, fmt.Errorf("could not list files in %s: %w", dir, walkErr))
+	}
+	sort.Slice(manifest.Objects, func(i, j int) bool {
+		return manifest.Objects[i].Name < manifest.Objects[j].Name
+	})
+	sort.Strings(manifest.Failed)
+
+	if err := uploadManifest(ctx, destination, t, manifest, options); err != nil {
+		errs = append(errs, err)
+	}
+	return manifest, utilerrors.NewAggregate(errs)
+}
+
+func uploadWithRetries(ctx context.Context, destination Destination, t *throttle, name, path string, options Options) (string, error) {
+	backoff := options.Backoff
+	var url string
+	var err error
+	for attempt := 0; attempt <= options.Retries; attempt++ {
+		if attempt > 0 {
+			logrus.WithError(err).Debugf("Retrying upload of %s in %s.", name, backoff)
+			if destination.Overloaded(err) {
+				// slow down every worker, not only this one
+				t.pause(backoff)
+			}
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(wait.Jitter(backoff, 0.1)):
+			}
+			backoff *= 2
+		}
+		if err = t.wait(ctx); err != nil {
+			return "", err
+		}
+		if url, err = destination.Upload(ctx, name, path); err == nil {
+			return url, nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+	}
+	return "", fmt.Errorf("could not upload %s: %w", name, err)
+}
+
+func uploadManifest(ctx context.Context, destination Destination, t *throttle, manifest *Manifest, options Options) error {
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal upload manifest: %w", err)
+	}
+	file, err := os.CreateTemp("", "upload-manifest-*.json")
+	if err != nil {
+		return fmt.Errorf("could not write upload manifest: %w", err)
+	}
+	defer func() {
+		if err := os.Remove(file.Name()); err != nil {
+			logrus.WithError(err).Debug("Could not remove temporary upload manifest.")
+		}
+	}()
+	if _, err := file.Write(raw); err != nil {
+		return fmt.Errorf("could not write upload manifest: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("could not write upload manifest: %w", err)
+	}
+	_, err = uploadWithRetries(ctx, destination, t, ManifestName, file.Name(), options)
+	return err
+}
@@ -0,0 +1,85 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+var errOverloaded = errors.New("overloaded")
+
+type fakeDestination struct {
+	lock sync.Mutex
+	// failures is the number of times uploads of a name fail
+	failures map[string]int
+	// permanent fails every upload of these names
+	permanent map[string]bool
+	running   int
+	maxActive int
+	uploaded  []string
+}
+
+func (d *fakeDestination) Upload(_ context.Context, name, _ string) (string, error) {
+	d.lock.Lock()
+	d.running++
+	if d.running > d.maxActive {
+		d.maxActive = d.running
+	}
+	d.lock.Unlock()
+	time.Sleep(time.Millisecond)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.running--
+	if d.permanent[name] {
+		return "", errors.New("forbidden")
+	}
+	if d.failures[name] > 0 {
+		d.failures[name]--
+		return "", errOverloaded
+	}
+	d.uploaded = append(d.uploaded, name)
+	return "gs://bucket/" + name, nil
+}
+
+func (d *fakeDestination) Overloaded(err error) bool {
+	return errors.Is(err, errOverloaded)
+}
+
+func TestDirectory(t *testing.T) {
+	dir := t.TempDir()
+	var expected []Object
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("dir/file-%02d", i)
+		if err := os.MkdirAll(filepath.Join(dir, "dir"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if i != 7 {
+			expected = append(expected, Object{Name: name, URL: "gs://bucket/" + name})
+		}
+	}
+	destination := &fakeDestination{
+		failures:  map[string]int{"dir/file-03": 2, ManifestName: 1},
+		permanent: map[string]bool{"dir/file-07": true},
+	}
+	manifest, err := Directory(context.Background(), destination, dir, Options{Concurrency: 4, Retries: 2, Backoff: time.Millisecond})
+	if err == nil {
+		t.Error("expected an error for the file that could not be uploaded")
+	}
+	testhelper.Diff(t, "manifest", manifest, &Manifest{Objects: expected, Failed: []string{"dir/file-07"}})
+	if destination.maxActive > 4 {
+		t.Errorf("expected at most 4 concurrent uploads, got %d", destination.maxActive)
+	}
+	if last := destination.uploaded[len(destination.uploaded)-1]; last != ManifestName {
+		t.Errorf("expected the manifest to be uploaded last, got %s", last)
+	}
+}
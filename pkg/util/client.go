@@ -3,40 +3,90 @@ package util
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// LoadClusterConfig loads the configuration from $KUBECONFIG or, without it,
+// the in-cluster configuration. Clients created from a configuration loaded by
+// this package survive the rotation of their credentials: the token of the
+// service account and exec credential plugins are refreshed by client-go, and
+// tokens in the kubeconfig are reloaded when they are rejected.
 func LoadClusterConfig() (*rest.Config, error) {
 	if env := os.Getenv(clientcmd.RecommendedConfigPathEnvVar); env != "" {
-		credentials, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
-		if err != nil {
-			return nil, fmt.Errorf("could not load credentials from config: %w", err)
-		}
+		load := func() (*rest.Config, error) {
+			credentials, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+			if err != nil {
+				return nil, fmt.Errorf("could not load credentials from config: %w", err)
+			}
 
-		clusterConfig, err := clientcmd.NewDefaultClientConfig(*credentials, &clientcmd.ConfigOverrides{}).ClientConfig()
-		if err != nil {
-			return nil, fmt.Errorf("could not load client configuration: %w", err)
+			clusterConfig, err := clientcmd.NewDefaultClientConfig(*credentials, &clientcmd.ConfigOverrides{}).ClientConfig()
+			if err != nil {
+				return nil, fmt.Errorf("could not load client configuration: %w", err)
+			}
+			return clusterConfig, nil
 		}
-		return clusterConfig, nil
+		return loadReloadable(load)
 	}
 
 	// otherwise, prefer in-cluster config
 	return rest.InClusterConfig()
 }
 
+// loadReloadable loads the configuration, loading it again to reload the
+// token when it is rejected.
+func loadReloadable(load func() (*rest.Config, error)) (*rest.Config, error) {
+	config, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return withTokenReload(config, load), nil
+}
+
 // LoadKubeConfig loads a kubeconfig from the file and uses the default context
 func LoadKubeConfig(path string) (*rest.Config, error) {
-	loader := clientcmd.NewDefaultClientConfigLoadingRules()
-	loader.ExplicitPath = path
-	cfg, err := loader.Load()
-	if err != nil {
-		return nil, fmt.Errorf("could not load kubeconfig: %w", err)
+	return loadReloadable(func() (*rest.Config, error) {
+		loader := clientcmd.NewDefaultClientConfigLoadingRules()
+		loader.ExplicitPath = path
+		cfg, err := loader.Load()
+		if err != nil {
+			return nil, fmt.Errorf("could not load kubeconfig: %w", err)
+		}
+		clusterConfig, err := clientcmd.NewDefaultClientConfig(*cfg, &clientcmd.ConfigOverrides{}).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("could not load client configuration: %w", err)
+		}
+		return clusterConfig, nil
+	})
+}
+
+// LoadClusterConfigFor loads the configuration from the kubeconfig files, a
+// list separated like $PATH, using the context if one is given. Without
+// either, it behaves like LoadClusterConfig.
+func LoadClusterConfigFor(kubeconfig, context string) (*rest.Config, error) {
+	if kubeconfig == "" && context == "" {
+		return LoadClusterConfig()
 	}
-	clusterConfig, err := clientcmd.NewDefaultClientConfig(*cfg, &clientcmd.ConfigOverrides{}).ClientConfig()
-	if err != nil {
-		return nil, fmt.Errorf("could not load client configuration: %w", err)
+	loader := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		paths := filepath.SplitList(kubeconfig)
+		// unlike with $KUBECONFIG, files that were asked for explicitly
+		// must exist, so that the cluster is chosen deterministically
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("could not load kubeconfig: %w", err)
+			}
+		}
+		loader.Precedence = paths
 	}
-	return clusterConfig, nil
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	return loadReloadable(func() (*rest.Config, error) {
+		clusterConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, overrides).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("could not load client configuration: %w", err)
+		}
+		return clusterConfig, nil
+	})
 }
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const kubeconfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: NAME
+  cluster:
+    server: https://NAME.example.com:6443
+users:
+- name: NAME
+  user:
+    token: token
+contexts:
+- name: NAME
+  context:
+    cluster: NAME
+    user: NAME
+current-context: NAME
+`
+
+func TestLoadClusterConfigFor(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"first", "second"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.ReplaceAll(kubeconfigTemplate, "NAME", name)), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	kubeconfig := strings.Join(paths, string(os.PathListSeparator))
+	for _, tc := range []struct {
+		name        string
+		kubeconfig  string
+		context     string
+		expected    string
+		expectedErr bool
+	}{{
+		name:       "current context of the first file",
+		kubeconfig: kubeconfig,
+		expected:   "https://first.example.com:6443",
+	}, {
+		name:       "explicit context from the second file",
+		kubeconfig: kubeconfig,
+		context:    "second",
+		expected:   "https://second.example.com:6443",
+	}, {
+		name:        "unknown context",
+		kubeconfig:  kubeconfig,
+		context:     "third",
+		expectedErr: true,
+	}, {
+		name:        "missing file",
+		kubeconfig:  kubeconfig + string(os.PathListSeparator) + filepath.Join(dir, "missing"),
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := LoadClusterConfigFor(tc.kubeconfig, tc.context)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error=%t, got %v", tc.expectedErr, err)
+			}
+			if err == nil && config.Host != tc.expected {
+				t.Errorf("expected host %s, got %s", tc.expected, config.Host)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UpsertImmutableConfigMap is the UpsertImmutableSecret of ConfigMaps.
+// Updating an existing ConfigMap happens by re-creating it.
+func UpsertImmutableConfigMap(ctx context.Context, client ctrlruntimeclient.Client, configMap *coreapi.ConfigMap) (created bool, err error) {
+	configMap.Immutable = utilpointer.Bool(true)
+	err = client.Create(ctx, configMap.DeepCopy())
+	if err == nil {
+		return true, nil
+	}
+	if !kerrors.IsAlreadyExists(err) {
+		return false, err
+	}
+	existing := &coreapi.ConfigMap{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Name: configMap.Name, Namespace: configMap.Namespace}, existing); err != nil {
+		return false, err
+	}
+	if equality.Semantic.DeepEqual(configMap.Data, existing.Data) && equality.Semantic.DeepEqual(configMap.BinaryData, existing.BinaryData) {
+		return false, nil
+	}
+	if err := client.Delete(ctx, existing); err != nil {
+		return false, fmt.Errorf("delete failed: %w", err)
+	}
+
+	// Recreate counts as "Update"
+	return false, client.Create(ctx, configMap)
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpsertImmutableConfigMap(t *testing.T) {
+	configMap := func(data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "manifests"},
+			Data:       map[string]string{"tests.txt": data},
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		client   ctrlruntimeclient.Client
+		expected bool
+	}{{
+		name:     "the ConfigMap is created",
+		client:   fakeclient.NewClientBuilder().Build(),
+		expected: true,
+	}, {
+		name:   "the ConfigMap is updated",
+		client: fakeclient.NewClientBuilder().WithRuntimeObjects(configMap("old")).Build(),
+	}, {
+		name:   "the ConfigMap is unchanged",
+		client: fakeclient.NewClientBuilder().WithRuntimeObjects(configMap("new")).Build(),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			created, err := UpsertImmutableConfigMap(context.TODO(), tc.client, configMap("new"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created != tc.expected {
+				t.Errorf("expected created to be %v, got %v", tc.expected, created)
+			}
+			actual := &corev1.ConfigMap{}
+			if err := tc.client.Get(context.TODO(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "manifests"}, actual); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(map[string]string{"tests.txt": "new"}, actual.Data); diff != "" {
+				t.Errorf("unexpected data: %s", diff)
+			}
+		})
+	}
+}
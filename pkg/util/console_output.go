@@ -0,0 +1,109 @@
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/secretutil"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// ConsoleOutputOptions control how the output of failed steps, like the logs
+// of their containers, is printed on the console.
+type ConsoleOutputOptions struct {
+	// StripANSI removes the escape sequences for colors and cursor movements.
+	StripANSI bool
+	// CollapseRepeatedLines prints consecutive identical lines once, followed
+	// by the number of repetitions.
+	CollapseRepeatedLines bool
+	// MaxBytes caps the output of a step, keeping its end where failures are
+	// usually reported. The full output is saved to the artifacts. Zero means
+	// no limit.
+	MaxBytes int
+	// Censor censors the full output saved to the artifacts.
+	Censor secretutil.Censorer
+}
+
+var (
+	consoleOutputLock    sync.RWMutex
+	consoleOutputOptions ConsoleOutputOptions
+)
+
+// SetConsoleOutputOptions configures how the output of steps is printed.
+func SetConsoleOutputOptions(options ConsoleOutputOptions) {
+	consoleOutputLock.Lock()
+	defer consoleOutputLock.Unlock()
+	consoleOutputOptions = options
+}
+
+// ansiEscape matches the CSI sequences (colors, cursor movements) and the
+// OSC sequences (titles, hyperlinks) of terminals.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// ConsoleOutput formats the output of a step for the console. The name
+// identifies the output, and the artifact the full output is saved to when
+// it is truncated.
+func ConsoleOutput(name, output string) string {
+	consoleOutputLock.RLock()
+	options := consoleOutputOptions
+	consoleOutputLock.RUnlock()
+	return options.format(name, output)
+}
+
+func (o ConsoleOutputOptions) format(name, output string) string {
+	full := output
+	if o.StripANSI {
+		output = ansiEscape.ReplaceAllString(output, "")
+	}
+	if o.CollapseRepeatedLines {
+		output = collapseRepeatedLines(output)
+	}
+	if o.MaxBytes <= 0 || len(output) <= o.MaxBytes {
+		return output
+	}
+	artifact := filepath.Join("console-output", fmt.Sprintf("%s.log", name))
+	censor := o.Censor
+	if censor == nil {
+		censor = &noOpCensor{}
+	}
+	if err := api.SaveArtifact(censor, artifact, []byte(full)); err != nil {
+		logrus.WithError(err).Warnf("Could not save the full output of %s.", name)
+	}
+	truncated := output[len(output)-o.MaxBytes:]
+	// start at a line, unless the kept output is a single line
+	if i := strings.IndexByte(truncated, '\n'); i != -1 && i < len(truncated)-1 {
+		truncated = truncated[i+1:]
+	}
+	return fmt.Sprintf("... %d bytes truncated, the full output is saved to the artifacts in %s ...\n%s", len(output)-len(truncated), artifact, truncated)
+}
+
+// collapseRepeatedLines replaces runs of identical lines with the line and the
+// number of times it repeats.
+func collapseRepeatedLines(output string) string {
+	lines := strings.Split(output, "\n")
+	var collapsed []string
+	for i := 0; i < len(lines); {
+		j := i + 1
+		for j < len(lines) && lines[j] == lines[i] {
+			j++
+		}
+		collapsed = append(collapsed, lines[i])
+		if repeated := j - i - 1; repeated > 0 && lines[i] != "" {
+			collapsed = append(collapsed, fmt.Sprintf("... repeated %d more times", repeated))
+		} else if repeated > 0 {
+			collapsed = append(collapsed, lines[i+1:j]...)
+		}
+		i = j
+	}
+	return strings.Join(collapsed, "\n")
+}
+
+type noOpCensor struct{}
+
+func (noOpCensor) Censor(*[]byte) {}
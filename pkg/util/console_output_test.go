@@ -0,0 +1,75 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestConsoleOutputFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		options  ConsoleOutputOptions
+		output   string
+		expected string
+	}{
+		{
+			name:     "output is kept by default",
+			output:   "\x1b[31mFAIL\x1b[0m\nok\nok\n",
+			expected: "\x1b[31mFAIL\x1b[0m\nok\nok\n",
+		},
+		{
+			name:     "ANSI sequences are stripped",
+			options:  ConsoleOutputOptions{StripANSI: true},
+			output:   "\x1b[1;31mFAIL\x1b[0m \x1b]8;;https://example.com\x07link\x1b]8;;\x07\x1b[2K\n",
+			expected: "FAIL link\n",
+		},
+		{
+			name:     "repeated lines are collapsed",
+			options:  ConsoleOutputOptions{CollapseRepeatedLines: true},
+			output:   "waiting\nwaiting\nwaiting\ndone\n\n\nexit\n",
+			expected: "waiting\n... repeated 2 more times\ndone\n\n\nexit\n",
+		},
+		{
+			name:     "stripping reveals repeated lines",
+			options:  ConsoleOutputOptions{StripANSI: true, CollapseRepeatedLines: true},
+			output:   "\x1b[33mwaiting\x1b[0m\nwaiting\n",
+			expected: "waiting\n... repeated 1 more times\n",
+		},
+		{
+			name:     "output is truncated to its last lines",
+			options:  ConsoleOutputOptions{MaxBytes: 12},
+			output:   "first line\nsecond line\nthird\n",
+			expected: "... 23 bytes truncated, the full output is saved to the artifacts in console-output/step.log ...\nthird\n",
+		},
+		{
+			name:     "output under the limit is not truncated",
+			options:  ConsoleOutputOptions{MaxBytes: 100},
+			output:   "first line\nsecond line\n",
+			expected: "first line\nsecond line\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "output", tc.options.format("step", tc.output), tc.expected)
+		})
+	}
+}
+
+func TestConsoleOutputSavesFullOutput(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ARTIFACTS", dir)
+	output := "\x1b[31m" + strings.Repeat("line\n", 10) + "\x1b[0m"
+	SetConsoleOutputOptions(ConsoleOutputOptions{StripANSI: true, MaxBytes: 10})
+	defer SetConsoleOutputOptions(ConsoleOutputOptions{})
+	if formatted := ConsoleOutput("pod-test", output); !strings.HasSuffix(formatted, "\nline\n") {
+		t.Errorf("unexpected output: %q", formatted)
+	}
+	saved, err := os.ReadFile(filepath.Join(dir, "console-output", "pod-test.log"))
+	if err != nil {
+		t.Fatalf("could not read the saved output: %v", err)
+	}
+	testhelper.Diff(t, "saved output", string(saved), output)
+}
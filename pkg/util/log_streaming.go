@@ -0,0 +1,176 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/secrets"
+)
+
+var (
+	// logStreamingPollInterval is how often the pod is checked for containers
+	// that started.
+	logStreamingPollInterval = 2 * time.Second
+	// logStreamingDrainTimeout is how long the logs of containers still
+	// running are followed once streaming is stopped.
+	logStreamingDrainTimeout = 30 * time.Second
+)
+
+// podLogStreamer follows the logs of the containers of a pod into files, as
+// the containers start.
+type podLogStreamer struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	name      string
+	dir       string
+	censor    *secrets.DynamicCensor
+	openLogs  func(ctx context.Context, container string) (io.ReadCloser, error)
+
+	streams sync.WaitGroup
+	started map[string]bool
+}
+
+// StreamPodLogs follows the logs of every container of the pod into
+// <artifact-dir>/<step>/<container>.log while the pod runs, so they are
+// available even when the step fails. Every line is censored before it is
+// written. It does nothing when no artifact directory is set. The returned
+// function stops looking for new containers and waits for the logs of the
+// started ones to be written.
+func StreamPodLogs(ctx context.Context, podClient kubernetes.PodClient, censor *secrets.DynamicCensor, namespace, name, step string) func() {
+	artifactDir, set := api.Artifacts()
+	if !set {
+		return func() {}
+	}
+	s := &podLogStreamer{
+		client:    podClient,
+		namespace: namespace,
+		name:      name,
+		dir:       filepath.Join(artifactDir, step),
+		censor:    censor,
+		openLogs: func(ctx context.Context, container string) (io.ReadCloser, error) {
+			return podClient.GetLogs(namespace, name, &corev1.PodLogOptions{Container: container, Follow: true}).Stream(ctx)
+		},
+	}
+	return s.start(ctx)
+}
+
+func (s *podLogStreamer) start(ctx context.Context) func() {
+	s.started = map[string]bool{}
+	streamCtx, cancelStreams := context.WithCancel(ctx)
+	pollCtx, cancelPoll := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(logStreamingPollInterval)
+		defer ticker.Stop()
+		for {
+			if finished := s.poll(streamCtx); finished {
+				return
+			}
+			select {
+			case <-pollCtx.Done():
+				// a last look, for containers that ran since the last poll
+				s.poll(streamCtx)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancelPoll()
+		<-done
+		drained := make(chan struct{})
+		go func() {
+			s.streams.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-time.After(logStreamingDrainTimeout):
+			cancelStreams()
+			<-drained
+		}
+		cancelStreams()
+	}
+}
+
+// poll starts streaming the logs of the containers that started since the
+// last poll, and determines whether the pod has no container left to start.
+func (s *podLogStreamer) poll(ctx context.Context) bool {
+	pod := &corev1.Pod{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.namespace, Name: s.name}, pod); err != nil {
+		if !kerrors.IsNotFound(err) {
+			logrus.WithError(err).Debugf("Failed to get pod %s to stream its logs.", s.name)
+		}
+		return false
+	}
+	statuses := getContainerStatuses(pod)
+	for _, status := range statuses {
+		if s.started[status.Name] || (status.State.Running == nil && status.State.Terminated == nil) {
+			continue
+		}
+		s.started[status.Name] = true
+		s.streams.Add(1)
+		go func(container string) {
+			defer s.streams.Done()
+			if err := s.stream(ctx, container); err != nil {
+				logrus.WithError(err).Warnf("Failed to stream the logs of container %s of pod %s.", container, s.name)
+			}
+		}(status.Name)
+	}
+	finished := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+	return finished && len(s.started) == len(statuses)
+}
+
+func (s *podLogStreamer) stream(ctx context.Context, container string) error {
+	if err := os.MkdirAll(s.dir, 0750); err != nil {
+		return fmt.Errorf("unable to create directory %s: %w", s.dir, err)
+	}
+	file, err := os.Create(filepath.Join(s.dir, fmt.Sprintf("%s.log", container)))
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	defer file.Close()
+	logs, err := s.openLogs(ctx, container)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve the logs: %w", err)
+	}
+	defer logs.Close()
+	reader := bufio.NewReader(logs)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if s.censor != nil {
+			s.censor.Censor(&line)
+		}
+		if _, err := file.Write(line); err != nil {
+			return fmt.Errorf("unable to write the logs: %w", err)
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("unable to copy the logs: %w", readErr)
+		}
+	}
+}
+
+func getContainerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	var statuses []corev1.ContainerStatus
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	return statuses
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/ci-tools/pkg/secrets"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestPodLogStreamer(t *testing.T) {
+	logStreamingPollInterval = time.Millisecond
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodFailed,
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "init", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "test", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+				{Name: "sidecar", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	censor := secrets.NewDynamicCensor()
+	censor.AddSecrets("hunter2")
+	dir := t.TempDir()
+	s := &podLogStreamer{
+		client:    fakectrlruntimeclient.NewClientBuilder().WithObjects(pod).Build(),
+		namespace: "ns",
+		name:      "pod",
+		dir:       filepath.Join(dir, "step"),
+		censor:    &censor,
+		openLogs: func(_ context.Context, container string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("logs of " + container + "\nusing hunter2\n")), nil
+		},
+	}
+	s.start(context.Background())()
+
+	actual := map[string]string{}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		t.Fatalf("could not read the logs: %v", err)
+	}
+	for _, entry := range entries {
+		raw, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual[entry.Name()] = string(raw)
+	}
+	expected := map[string]string{
+		"init.log":    "logs of init\nusing XXXXXXX\n",
+		"test.log":    "logs of test\nusing XXXXXXX\n",
+		"sidecar.log": "logs of sidecar\nusing XXXXXXX\n",
+	}
+	testhelper.Diff(t, "logs", actual, expected)
+}
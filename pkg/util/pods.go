@@ -25,6 +25,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/metrics"
 	"github.com/openshift/ci-tools/pkg/results"
 )
 
@@ -59,6 +60,7 @@ func CreateOrRestartPod(ctx context.Context, podClient ctrlruntimeclient.Client,
 		if err != nil {
 			if kerrors.IsForbidden(err) {
 				logrus.WithError(err).Warnf("Unable to create pod %s, may be temporary.", name)
+				metrics.PodRetries.WithLabelValues("forbidden").Inc()
 				return false, nil
 			}
 			if !kerrors.IsAlreadyExists(err) {
@@ -95,6 +97,7 @@ func waitForCompletedPodDeletion(ctx context.Context, podClient ctrlruntimeclien
 	if err != nil {
 		return fmt.Errorf("could not delete completed pod: %w", err)
 	}
+	metrics.PodRetries.WithLabelValues("restarted").Inc()
 
 	return WaitForPodDeletion(ctx, podClient, namespace, name, uid)
 }
@@ -121,6 +124,8 @@ func WaitForPodCompletion(ctx context.Context, podClient kubernetes.PodClient, n
 	if notifier == nil {
 		notifier = NopNotifier
 	}
+	stopStreaming := StreamPodLogs(ctx, podClient, podClient.Censor(), namespace, name, name)
+	defer stopStreaming()
 	ctxDone := ctx.Done()
 	notifierDone := notifier.Done(name)
 	completed := make(map[string]time.Time)
@@ -506,7 +511,7 @@ func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod,
 				logrus.WithError(err).Warnf("Unable to close log output from failed pod container %s.", status.Name)
 			}
 			logrus.Infof("Logs for container %s in pod %s:", status.Name, pod.Name)
-			logrus.Info(logs.String())
+			logrus.Info(ConsoleOutput(fmt.Sprintf("%s-%s", pod.Name, status.Name), logs.String()))
 		} else {
 			logrus.WithError(err).Warnf("error: Unable to retrieve logs from failed pod container %s.", status.Name)
 		}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RetryStatistics describes the attempts made for an operation.
+type RetryStatistics struct {
+	Operation string `json:"operation"`
+	// Attempts is the number of times the operation was attempted.
+	Attempts int `json:"attempts"`
+	// Failures is the number of attempts that ended with an error.
+	Failures int `json:"failures"`
+	// Exhausted is the number of times the operation was given up on.
+	Exhausted int `json:"exhausted,omitempty"`
+	// Waited is the total time spent waiting between attempts.
+	Waited time.Duration `json:"waited"`
+}
+
+// Retrier retries operations with an exponential backoff, recording
+// statistics of the attempts for every operation.
+type Retrier struct {
+	lock       sync.Mutex
+	statistics map[string]*RetryStatistics
+}
+
+// NewRetrier creates a Retrier.
+func NewRetrier() *Retrier {
+	return &Retrier{statistics: map[string]*RetryStatistics{}}
+}
+
+// Retry calls fn until it succeeds, it returns an error that is not
+// retriable, the steps of the backoff are exhausted or the context is done,
+// returning the last error. The backoff should have jitter, so concurrent
+// callers do not retry in lockstep.
+func (r *Retrier) Retry(ctx context.Context, operation string, backoff wait.Backoff, retriable func(error) bool, fn func(context.Context) error) error {
+	for {
+		err := fn(ctx)
+		r.record(operation, err, 0)
+		if err == nil || !retriable(err) {
+			return err
+		}
+		if backoff.Steps <= 1 {
+			r.exhausted(operation)
+			return err
+		}
+		delay := backoff.Step()
+		logrus.WithError(err).Debugf("Retrying %s in %s.", operation, delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		r.record(operation, nil, delay)
+	}
+}
+
+func (r *Retrier) record(operation string, err error, waited time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	stats, ok := r.statistics[operation]
+	if !ok {
+		stats = &RetryStatistics{Operation: operation}
+		r.statistics[operation] = stats
+	}
+	if waited > 0 {
+		stats.Waited += waited
+		return
+	}
+	stats.Attempts++
+	if err != nil {
+		stats.Failures++
+	}
+}
+
+func (r *Retrier) exhausted(operation string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.statistics[operation].Exhausted++
+}
+
+// Statistics returns the statistics of all operations, sorted by name.
+func (r *Retrier) Statistics() []RetryStatistics {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var statistics []RetryStatistics
+	for _, stats := range r.statistics {
+		statistics = append(statistics, *stats)
+	}
+	sort.Slice(statistics, func(i, j int) bool {
+		return statistics[i].Operation < statistics[j].Operation
+	})
+	return statistics
+}
+
+// IsTransientError determines whether an error from the API server is
+// likely to go away when the request is retried. Conflicts are not, as the
+// object needs to be read again before the request can succeed.
+func IsTransientError(err error) bool {
+	return kerrors.IsServerTimeout(err) ||
+		kerrors.IsTimeout(err) ||
+		kerrors.IsTooManyRequests(err) ||
+		kerrors.IsInternalError(err) ||
+		kerrors.IsServiceUnavailable(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsProbableEOF(err)
+}
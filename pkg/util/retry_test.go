@@ -0,0 +1,86 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestRetry(t *testing.T) {
+	backoff := wait.Backoff{Duration: time.Millisecond, Factor: 2, Jitter: 0.1, Steps: 3}
+	transient := kerrors.NewServiceUnavailable("unavailable")
+	permanent := kerrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "pod", errors.New("forbidden"))
+	conflict := kerrors.NewConflict(schema.GroupResource{Resource: "pods"}, "pod", errors.New("modified"))
+	var testCases = []struct {
+		name        string
+		errs        []error
+		expectedErr error
+		expected    RetryStatistics
+	}{
+		{
+			name:     "success",
+			errs:     []error{nil},
+			expected: RetryStatistics{Operation: "op", Attempts: 1},
+		},
+		{
+			name:     "success after transient errors",
+			errs:     []error{transient, transient, nil},
+			expected: RetryStatistics{Operation: "op", Attempts: 3, Failures: 2},
+		},
+		{
+			name:        "permanent error",
+			errs:        []error{transient, permanent},
+			expectedErr: permanent,
+			expected:    RetryStatistics{Operation: "op", Attempts: 2, Failures: 2},
+		},
+		{
+			name:        "conflicts are not retried",
+			errs:        []error{conflict},
+			expectedErr: conflict,
+			expected:    RetryStatistics{Operation: "op", Attempts: 1, Failures: 1},
+		},
+		{
+			name:        "exhausted",
+			errs:        []error{transient, transient, transient},
+			expectedErr: transient,
+			expected:    RetryStatistics{Operation: "op", Attempts: 3, Failures: 3, Exhausted: 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			retrier := NewRetrier()
+			var attempt int
+			err := retrier.Retry(context.Background(), "op", backoff, IsTransientError, func(context.Context) error {
+				err := tc.errs[attempt]
+				attempt++
+				return err
+			})
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+			statistics := retrier.Statistics()
+			if len(statistics) != 1 {
+				t.Fatalf("expected statistics for one operation, got %v", statistics)
+			}
+			if tc.expected.Failures != 0 && tc.expected.Exhausted == 0 && tc.expectedErr == nil && statistics[0].Waited == 0 {
+				t.Error("expected time to be spent waiting between attempts")
+			}
+			statistics[0].Waited = 0
+			testhelper.Diff(t, "statistics", statistics[0], tc.expected)
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	backoff := wait.Backoff{Duration: time.Hour, Steps: 2}
+	transient := kerrors.NewTooManyRequests("slow down", 1)
+	err := NewRetrier().Retry(ctx, "op", backoff, IsTransientError, func(context.Context) error { return transient })
+	testhelper.Diff(t, "error", err, transient, testhelper.EquateErrorMessage)
+}
@@ -3,8 +3,10 @@ package util
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -43,6 +45,55 @@ func SecretFromDir(path string) (*coreapi.Secret, error) {
 	return ret, nil
 }
 
+// SecretFromDirTree creates a secret with the contents of the files in a
+// directory and in the directories nested in it. Files in nested directories
+// are keyed by their path relative to the directory, with the separators
+// replaced by dots, so that dir/sub/file becomes sub.file. Entries whose names
+// start with "..", like the ..data directory of a mounted secret that holds
+// the same files again, are skipped.
+func SecretFromDirTree(root string) (*coreapi.Secret, error) {
+	ret := &coreapi.Secret{
+		Type: coreapi.SecretTypeOpaque,
+		Data: make(map[string][]byte),
+	}
+	sources := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), "..") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		// if the file is a broken symlink or a symlink to a dir, skip it
+		if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		key := strings.ReplaceAll(filepath.ToSlash(rel), "/", ".")
+		if other, ok := sources[key]; ok {
+			return fmt.Errorf("files %s and %s would both be stored as key %s", other, rel, key)
+		}
+		sources[key] = rel
+		if ret.Data[key], err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("could not read file %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read dir %s: %w", root, err)
+	}
+	return ret, nil
+}
+
 // UpsertImmutableSecret adds new values to an existing secret.
 // New values are added, existing values are overwritten. The secret will be
 // created if it doesn't already exist. Updating an existing secret happens by re-creating it.
@@ -3,6 +3,8 @@ package util
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -153,3 +155,38 @@ func TestUpsertImmutableSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretFromDirTree(t *testing.T) {
+	// the layout of a mounted secret, whose files link to the ..data directory
+	root := t.TempDir()
+	for _, dir := range []string{"..2023_01_01", "..2023_01_01/sub"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range map[string]string{"..2023_01_01/token": "secret", "..2023_01_01/sub/file": "nested"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{"..data": "..2023_01_01", "token": "..data/token", "sub": "..data/sub"} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "dir", "file"), []byte("in a directory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := SecretFromDirTree(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]byte{"token": []byte("secret"), "dir.file": []byte("in a directory")}
+	if diff := cmp.Diff(expected, secret.Data); diff != "" {
+		t.Errorf("unexpected data (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/client-go/rest"
+)
+
+// reloadableToken is the bearer token of a kubeconfig that may be rotated
+// while a long-running process uses it. Tokens read from a file and exec
+// credential plugins are refreshed by client-go itself, but tokens written
+// into the kubeconfig are only read once when the configuration is loaded.
+type reloadableToken struct {
+	load func() (*rest.Config, error)
+
+	lock sync.RWMutex
+	// token is the token loaded after a rotation, empty until one happens.
+	token string
+}
+
+// withTokenReload makes the clients created from the configuration retry
+// requests rejected as unauthorized with the token loaded again by load, and
+// use that token from then on.
+func withTokenReload(config *rest.Config, load func() (*rest.Config, error)) *rest.Config {
+	if config.BearerToken == "" || config.BearerTokenFile != "" {
+		return config
+	}
+	token := &reloadableToken{load: load}
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &tokenReloadingRoundTripper{token: token, delegate: rt}
+	})
+	return config
+}
+
+func (t *reloadableToken) current() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.token
+}
+
+// reload loads the token again, returning it when it differs from the one
+// that was rejected.
+func (t *reloadableToken) reload(rejected string) (string, error) {
+	config, err := t.load()
+	if err != nil {
+		return "", err
+	}
+	if config.BearerToken == "" || bearer(config.BearerToken) == rejected {
+		return "", nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.token = config.BearerToken
+	return t.token, nil
+}
+
+type tokenReloadingRoundTripper struct {
+	token    *reloadableToken
+	delegate http.RoundTripper
+}
+
+func (rt *tokenReloadingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if token := rt.token.current(); token != "" {
+		req = withBearer(req, token)
+	}
+	resp, err := rt.delegate.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	token, err := rt.token.reload(req.Header.Get("Authorization"))
+	if err != nil {
+		logrus.WithError(err).Warn("Could not reload the kubeconfig after the credentials were rejected.")
+		return resp, nil
+	}
+	if token == "" {
+		return resp, nil
+	}
+	retry, err := rewind(req)
+	if err != nil {
+		logrus.WithError(err).Debug("Could not retry the request with the reloaded token.")
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	logrus.Info("The credentials were rejected, continuing with the token reloaded from the kubeconfig.")
+	return rt.delegate.RoundTrip(withBearer(retry, token))
+}
+
+// WrappedRoundTripper lets client-go unwrap the round tripper.
+func (rt *tokenReloadingRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
+
+func bearer(token string) string {
+	return fmt.Sprintf("Bearer %s", token)
+}
+
+func withBearer(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", bearer(token))
+	return req
+}
+
+// rewind returns a copy of the request that can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("the body of the request cannot be read again")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry.Body = body
+	return retry, nil
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestTokenReload(t *testing.T) {
+	var rejected int
+	var accepted []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer rotated" {
+			rejected++
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		accepted = append(accepted, string(body))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	write := func(token string) {
+		// tokens are only sent to servers over TLS
+		kubeconfig := strings.ReplaceAll(kubeconfigTemplate, "server: https://NAME.example.com:6443", "server: "+server.URL+"\n    insecure-skip-tls-verify: true")
+		kubeconfig = strings.ReplaceAll(kubeconfig, "token: token", "token: "+token)
+		if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("expired")
+	config, err := LoadClusterConfigFor(path, "")
+	if err != nil {
+		t.Fatalf("could not load the configuration: %v", err)
+	}
+	client, err := rest.HTTPClientFor(config)
+	if err != nil {
+		t.Fatalf("could not create the client: %v", err)
+	}
+	post := func(body string) int {
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post("first"); code != http.StatusUnauthorized {
+		t.Errorf("expected the request with the expired token to be rejected, got %d", code)
+	}
+	write("rotated")
+	if code := post("second"); code != http.StatusOK {
+		t.Errorf("expected the request to be retried with the rotated token, got %d", code)
+	}
+	if code := post("third"); code != http.StatusOK {
+		t.Errorf("expected the rotated token to be used, got %d", code)
+	}
+	if rejected != 2 {
+		t.Errorf("expected 2 rejected requests, got %d", rejected)
+	}
+	if expected := []string{"second", "third"}; strings.Join(accepted, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected the bodies %v to be accepted, got %v", expected, accepted)
+	}
+}
+
+func TestTokenReloadIgnoresTokenFiles(t *testing.T) {
+	config := &rest.Config{BearerTokenFile: "/var/run/secrets/token"}
+	if withTokenReload(config, nil).WrapTransport != nil {
+		t.Error("expected tokens read from files to be left to client-go")
+	}
+}
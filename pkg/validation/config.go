@@ -3,9 +3,12 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 
+	"github.com/docker/distribution/reference"
+
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -127,7 +130,30 @@ func IsValidConfiguration(config *api.ReleaseBuildConfiguration, org, repo strin
 	return v.validateConfiguration(NewConfigContext(), config, org, repo, false)
 }
 
+// ResolvedConfigurationErrors returns every error IsValidResolvedConfiguration
+// would report, one for each invalid value.
+func ResolvedConfigurationErrors(config *api.ReleaseBuildConfiguration) []error {
+	config.Default()
+	v := newSingleUseValidator()
+	return v.configurationErrors(NewConfigContext(), config, "", "", true)
+}
+
 func (v *Validator) validateConfiguration(ctx *configContext, config *api.ReleaseBuildConfiguration, org, repo string, resolved bool) error {
+	var lines []string
+	for _, err := range v.configurationErrors(ctx, config, org, repo, resolved) {
+		lines = append(lines, err.Error())
+	}
+	switch len(lines) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("invalid configuration: %s", lines[0])
+	default:
+		return fmt.Errorf("configuration has %d errors:\n\n  * %s\n", len(lines), strings.Join(lines, "\n  * "))
+	}
+}
+
+func (v *Validator) configurationErrors(ctx *configContext, config *api.ReleaseBuildConfiguration, org, repo string, resolved bool) []error {
 	var validationErrors []error
 	if config.BinaryBuildCommands != "" {
 		ctx.pipelineImages[api.PipelineImageStreamTagReferenceBinaries] = "binary_build_commands"
@@ -168,21 +194,13 @@ func (v *Validator) validateConfiguration(ctx *configContext, config *api.Releas
 	// this validation brings together a large amount of data from separate
 	// parts of the configuration, so it's written as a standalone method
 	validationErrors = append(validationErrors, validateTestStepDependencies(config)...)
-	var lines []string
+	var ret []error
 	for _, err := range validationErrors {
-		if err == nil {
-			continue
+		if err != nil {
+			ret = append(ret, err)
 		}
-		lines = append(lines, err.Error())
-	}
-	switch len(lines) {
-	case 0:
-		return nil
-	case 1:
-		return fmt.Errorf("invalid configuration: %s", lines[0])
-	default:
-		return fmt.Errorf("configuration has %d errors:\n\n  * %s\n", len(lines), strings.Join(lines, "\n  * "))
 	}
+	return ret
 }
 
 func (v *Validator) ValidateTestStepConfiguration(ctx *configContext, config *api.ReleaseBuildConfiguration, resolved bool) []error {
@@ -230,6 +248,9 @@ func validateBuildRootImageConfiguration(ctx *configContext, input *api.BuildRoo
 
 func validateBuildRootImageStreamTag(ctx *configContext, buildRoot api.ImageStreamTagReference) []error {
 	var validationErrors []error
+	if buildRoot.PullSpec != "" {
+		validationErrors = append(validationErrors, ctx.AddField("pull_spec").errorf("only supported for base_images"))
+	}
 	if len(buildRoot.Namespace) == 0 {
 		validationErrors = append(validationErrors, ctx.AddField("namespace").errorf("value required but not provided"))
 	}
@@ -255,6 +276,25 @@ func ValidateImages(ctx *configContext, images []api.ProjectDirectoryImageBuildS
 		if image.DockerfileLiteral != nil && (image.ContextDir != "" || image.DockerfilePath != "") {
 			validationErrors = append(validationErrors, ctxN.errorf("dockerfile_literal is mutually exclusive with context_dir and dockerfile_path"))
 		}
+		for i, include := range image.ContextIncludes {
+			if clean := path.Clean(include); include == "" || path.IsAbs(include) || clean == ".." || strings.HasPrefix(clean, "../") {
+				validationErrors = append(validationErrors, ctxN.AddField("context_includes").addIndex(i).errorf("must be a path inside of context_dir, got %q", include))
+			}
+		}
+		for i, exclude := range image.ContextExcludes {
+			if _, err := path.Match(strings.TrimPrefix(exclude, "!"), ""); exclude == "" || err != nil {
+				validationErrors = append(validationErrors, ctxN.AddField("context_excludes").addIndex(i).errorf("must be a valid .dockerignore pattern, got %q", exclude))
+			}
+		}
+		if image.Timeout != nil && image.Timeout.Duration <= 0 {
+			validationErrors = append(validationErrors, ctxN.AddField("timeout").errorf("must be positive, got %s", image.Timeout.Duration))
+		}
+		if image.NoOutputTimeout != nil && image.NoOutputTimeout.Duration <= 0 {
+			validationErrors = append(validationErrors, ctxN.AddField("no_output_timeout").errorf("must be positive, got %s", image.NoOutputTimeout.Duration))
+		}
+		if image.RunTimeout != nil && image.RunTimeout.Duration <= 0 {
+			validationErrors = append(validationErrors, ctxN.AddField("run_timeout").errorf("must be positive, got %s", image.RunTimeout.Duration))
+		}
 	}
 	return validationErrors
 }
@@ -335,7 +375,7 @@ func ValidateOperatorSubstitution(ctx *configContext, sub api.PullSpecSubstituti
 }
 
 func ValidateBaseImages(ctx *configContext, images map[string]api.ImageStreamTagReference) []error {
-	ret := validateImageStreamTagReferenceMap("base_images", images)
+	ret := validateImageStreamTagReferenceMap("base_images", images, true)
 	for name := range images {
 		if err := ctx.addKey(name).addPipelineImage(api.PipelineImageStreamTagReference(name)); err != nil {
 			ret = append(ret, err)
@@ -345,7 +385,7 @@ func ValidateBaseImages(ctx *configContext, images map[string]api.ImageStreamTag
 }
 
 func validateBaseRPMImages(ctx *configContext, images map[string]api.ImageStreamTagReference) []error {
-	ret := validateImageStreamTagReferenceMap("base_rpm_images", images)
+	ret := validateImageStreamTagReferenceMap("base_rpm_images", images, false)
 	for name := range images {
 		ctxN := ctx.addKey(name)
 		if err := ctxN.addPipelineImage(api.PipelineImageStreamTagReference(fmt.Sprintf("%s-without-rpms", name))); err != nil {
@@ -358,9 +398,25 @@ func validateBaseRPMImages(ctx *configContext, images map[string]api.ImageStream
 	return ret
 }
 
-func validateImageStreamTagReference(fieldRoot string, input api.ImageStreamTagReference) []error {
+// validateImageStreamTagReference validates the reference, which may only be
+// an external pull spec when allowPullSpec is set, as only base images are
+// imported from pull specs.
+func validateImageStreamTagReference(fieldRoot string, input api.ImageStreamTagReference, allowPullSpec bool) []error {
 	var validationErrors []error
 
+	if input.PullSpec != "" {
+		if !allowPullSpec {
+			return append(validationErrors, fmt.Errorf("%s.pull_spec: only supported for base_images", fieldRoot))
+		}
+		if input.Namespace != "" || input.Name != "" || input.Tag != "" {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.pull_spec: mutually exclusive with namespace, name and tag", fieldRoot))
+		}
+		if _, err := reference.ParseNormalizedNamed(input.PullSpec); err != nil {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.pull_spec: invalid pull spec %q: %w", fieldRoot, input.PullSpec, err))
+		}
+		return validationErrors
+	}
+
 	if len(input.Tag) == 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s.tag: value required but not provided", fieldRoot))
 	}
@@ -368,7 +424,7 @@ func validateImageStreamTagReference(fieldRoot string, input api.ImageStreamTagR
 	return validationErrors
 }
 
-func validateImageStreamTagReferenceMap(fieldRoot string, input map[string]api.ImageStreamTagReference) []error {
+func validateImageStreamTagReferenceMap(fieldRoot string, input map[string]api.ImageStreamTagReference, allowPullSpec bool) []error {
 	var validationErrors []error
 	for k, v := range input {
 		if k == "root" {
@@ -383,7 +439,7 @@ func validateImageStreamTagReferenceMap(fieldRoot string, input map[string]api.I
 		if strings.HasPrefix(k, string(api.PipelineImageStreamTagReferenceIndexImage)) {
 			validationErrors = append(validationErrors, fmt.Errorf("%s.%s: cannot begin with %s", fieldRoot, k, api.PipelineImageStreamTagReferenceIndexImage))
 		}
-		validationErrors = append(validationErrors, validateImageStreamTagReference(fmt.Sprintf("%s.%s", fieldRoot, k), v)...)
+		validationErrors = append(validationErrors, validateImageStreamTagReference(fmt.Sprintf("%s.%s", fieldRoot, k), v, allowPullSpec)...)
 	}
 	return validationErrors
 }
@@ -422,6 +478,12 @@ func validatePromotionConfiguration(fieldRoot string, input api.PromotionConfigu
 			validationErrors = append(validationErrors, fmt.Errorf("%s: both name and tag defined", thisFieldRoot(i)))
 		}
 
+		for j, tagTemplate := range target.TagTemplates {
+			if _, err := api.RenderPromotionTagTemplate(tagTemplate, api.PromotionTagTemplateData{Commit: "0123456789abcdef", Branch: "main", Date: "20060102"}); err != nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.tag_templates[%d]: %w", thisFieldRoot(i), j, err))
+			}
+		}
+
 		if promotesOfficialImages && imageTargets {
 			if _, ok := releases["latest"]; !ok && releaseTagConfiguration == nil {
 				validationErrors = append(validationErrors, fmt.Errorf("importing the release stream is required to ensure the promoted images to the namespace %s can be integrated properly. Although it can be achieved by tag_specification or releases[\"latest\"], adding an e2e test is strongly suggested", target.Namespace))
@@ -441,9 +503,32 @@ func validatePromotionConfiguration(fieldRoot string, input api.PromotionConfigu
 			}
 		}
 	}
+	if err := validateRunIf(input.RunIf); err != nil {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.run_if: %w", fieldRoot, err))
+	}
+	if input.RegistryMirror != "" {
+		if err := ValidatePushRepository(input.RegistryMirror); err != nil {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.registry_mirror: %w", fieldRoot, err))
+		}
+	}
 	return validationErrors
 }
 
+// ValidatePushRepository ensures the repository images are pushed to has
+// no tag or digest, as every image is pushed as its own tag.
+func ValidatePushRepository(repository string) error {
+	if strings.Contains(repository, "@") {
+		return errors.New("the repository must not have a digest")
+	}
+	if strings.Contains(repository[strings.LastIndex(repository, "/")+1:], ":") {
+		return errors.New("the repository must not have a tag")
+	}
+	if !strings.Contains(repository, "/") {
+		return errors.New("the repository must include the registry, like quay.io/user/prefix")
+	}
+	return nil
+}
+
 func validateReleaseTagConfiguration(fieldRoot string, input api.ReleaseTagConfiguration) []error {
 	var validationErrors []error
 
@@ -480,10 +565,42 @@ func validateReleaseBuildConfiguration(input *api.ReleaseBuildConfiguration, org
 		}
 	}
 
+	if input.CloneCredentials != nil {
+		validationErrors = append(validationErrors, validateCloneCredentials("clone_credentials", *input.CloneCredentials)...)
+	}
+
+	for i, kind := range input.AllowedClusterScopedKinds {
+		if kind == "" {
+			validationErrors = append(validationErrors, fmt.Errorf("allowed_cluster_scoped_kinds[%d]: must not be empty", i))
+		}
+	}
+
+	for i, resource := range input.AdditionalResources {
+		if (resource.Manifest == "") == (resource.File == "") {
+			validationErrors = append(validationErrors, fmt.Errorf("additional_resources[%d]: exactly one of 'manifest' or 'file' must be set", i))
+		}
+	}
+
 	validationErrors = append(validationErrors, validateResources("resources", input.Resources)...)
 	return validationErrors
 }
 
+func validateCloneCredentials(fieldRoot string, input api.CloneCredentials) []error {
+	var validationErrors []error
+	if input.Namespace == "" {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.namespace: must be set", fieldRoot))
+	}
+	if input.Name == "" {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.name: must be set", fieldRoot))
+	}
+	switch input.Type {
+	case api.CloneCredentialsSSH, api.CloneCredentialsOAuth:
+	default:
+		validationErrors = append(validationErrors, fmt.Errorf("%s.type: must be one of %q or %q, not %q", fieldRoot, api.CloneCredentialsSSH, api.CloneCredentialsOAuth, input.Type))
+	}
+	return validationErrors
+}
+
 func validateResources(fieldRoot string, resources api.ResourceConfiguration) []error {
 	var validationErrors []error
 	if len(resources) == 0 {
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/utils/diff"
 	utilpointer "k8s.io/utils/pointer"
 
@@ -92,6 +94,7 @@ func TestValidateImageStreamTagReferenceMap(t *testing.T) {
 	for _, tc := range []struct {
 		id            string
 		baseImages    map[string]api.ImageStreamTagReference
+		noPullSpec    bool
 		expectedValid bool
 	}{
 		{
@@ -108,6 +111,35 @@ func TestValidateImageStreamTagReferenceMap(t *testing.T) {
 			},
 			expectedValid: false,
 		},
+		{
+			id: "external pull spec",
+			baseImages: map[string]api.ImageStreamTagReference{
+				"test": {PullSpec: "quay.io/org/repo:tag"}, "test2": {PullSpec: "quay.io/org/repo@sha256:8f6b9d9d7d4b0c7a2f0e2c1b1d6a3f6e6c7b0a9d8e7f6a5b4c3d2e1f0a9b8c7d"},
+			},
+			expectedValid: true,
+		},
+		{
+			id: "external pull spec where it is not supported",
+			baseImages: map[string]api.ImageStreamTagReference{
+				"test": {PullSpec: "quay.io/org/repo:tag"},
+			},
+			noPullSpec:    true,
+			expectedValid: false,
+		},
+		{
+			id: "external pull spec with a tag",
+			baseImages: map[string]api.ImageStreamTagReference{
+				"test": {PullSpec: "quay.io/org/repo:tag", Tag: "tag"},
+			},
+			expectedValid: false,
+		},
+		{
+			id: "invalid external pull spec",
+			baseImages: map[string]api.ImageStreamTagReference{
+				"test": {PullSpec: "quay.io/Org/repo:tag"},
+			},
+			expectedValid: false,
+		},
 		{
 			id: "cannot be bundle source",
 			baseImages: map[string]api.ImageStreamTagReference{
@@ -117,7 +149,7 @@ func TestValidateImageStreamTagReferenceMap(t *testing.T) {
 		},
 	} {
 		t.Run(tc.id, func(t *testing.T) {
-			if errs := validateImageStreamTagReferenceMap("base_images", tc.baseImages); len(errs) > 0 && tc.expectedValid {
+			if errs := validateImageStreamTagReferenceMap("base_images", tc.baseImages, !tc.noPullSpec); len(errs) > 0 && tc.expectedValid {
 				t.Errorf("expected to be valid, got: %v", errs)
 			} else if !tc.expectedValid && len(errs) == 0 {
 				t.Error("expected to be invalid, but returned valid")
@@ -361,6 +393,28 @@ func TestValidatePromotion(t *testing.T) {
 			input:                  api.PromotionConfiguration{Namespace: "foo", Tag: "bar"},
 			promotesOfficialImages: true,
 		},
+		{
+			name:  "valid tag templates",
+			input: api.PromotionConfiguration{Namespace: "foo", Tag: "latest", TagTemplates: []string{"sha-{{.Commit}}", "{{.Branch}}-{{.Date}}"}},
+		},
+		{
+			name:  "invalid tag templates",
+			input: api.PromotionConfiguration{Namespace: "foo", Tag: "latest", TagTemplates: []string{"{{.Commit", "{{.Author}}", "sha:{{.Commit}}"}},
+			expected: []error{
+				errors.New(`promotion.tag_templates[0]: could not parse tag template "{{.Commit": template: tag:1: unclosed action`),
+				errors.New(`promotion.tag_templates[1]: could not render tag template "{{.Author}}": template: tag:1:2: executing "tag" at <.Author>: can't evaluate field Author in type api.PromotionTagTemplateData`),
+				errors.New(`promotion.tag_templates[2]: tag template "sha:{{.Commit}}" rendered an invalid tag "sha:0123456789abcdef"`),
+			},
+		},
+		{
+			name:  "valid registry mirror",
+			input: api.PromotionConfiguration{Namespace: "foo", Tag: "latest", RegistryMirror: "quay.io/org/prefix"},
+		},
+		{
+			name:     "registry mirror with a tag",
+			input:    api.PromotionConfiguration{Namespace: "foo", Tag: "latest", RegistryMirror: "quay.io/org/prefix:latest"},
+			expected: []error{errors.New("promotion.registry_mirror: the repository must not have a tag")},
+		},
 	}
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
@@ -441,6 +495,34 @@ func TestValidateImages(t *testing.T) {
 				errors.New("images[0]: dockerfile_literal is mutually exclusive with context_dir and dockerfile_path"),
 			},
 		},
+		{
+			name: "context filters must stay inside of the context",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				To:              "amsterdam",
+				ContextIncludes: []string{"cmd", "../other", "/abs"},
+				ContextExcludes: []string{"**/*.md", "!README.md", "[", ""},
+			}},
+			output: []error{
+				errors.New(`images[0].context_includes[1]: must be a path inside of context_dir, got "../other"`),
+				errors.New(`images[0].context_includes[2]: must be a path inside of context_dir, got "/abs"`),
+				errors.New(`images[0].context_excludes[2]: must be a valid .dockerignore pattern, got "["`),
+				errors.New(`images[0].context_excludes[3]: must be a valid .dockerignore pattern, got ""`),
+			},
+		},
+		{
+			name: "build timeouts must be positive",
+			input: []api.ProjectDirectoryImageBuildStepConfiguration{{
+				To:              "amsterdam",
+				Timeout:         &prowv1.Duration{Duration: -time.Minute},
+				NoOutputTimeout: &prowv1.Duration{},
+				RunTimeout:      &prowv1.Duration{},
+			}},
+			output: []error{
+				errors.New("images[0].timeout: must be positive, got -1m0s"),
+				errors.New("images[0].no_output_timeout: must be positive, got 0s"),
+				errors.New("images[0].run_timeout: must be positive, got 0s"),
+			},
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -1247,3 +1329,44 @@ func TestValidateReleaseBuildConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCloneCredentials(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		input       api.CloneCredentials
+		expectedErr []error
+	}{{
+		name:  "valid",
+		input: api.CloneCredentials{Namespace: "ci", Name: "github", Type: api.CloneCredentialsSSH},
+	}, {
+		name:  "missing fields and invalid type",
+		input: api.CloneCredentials{Type: "password"},
+		expectedErr: []error{
+			errors.New("clone_credentials.namespace: must be set"),
+			errors.New("clone_credentials.name: must be set"),
+			errors.New(`clone_credentials.type: must be one of "ssh" or "oauth", not "password"`),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "errors", validateCloneCredentials("clone_credentials", tc.input), tc.expectedErr, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
+func TestValidatePushRepository(t *testing.T) {
+	var testCases = []struct {
+		repository  string
+		expectedErr error
+	}{
+		{repository: "quay.io/user/prefix"},
+		{repository: "localhost:5000/user/prefix"},
+		{repository: "quay.io/user/prefix:tag", expectedErr: errors.New("the repository must not have a tag")},
+		{repository: "quay.io/user/prefix@sha256:abc", expectedErr: errors.New("the repository must not have a digest")},
+		{repository: "prefix", expectedErr: errors.New("the repository must include the registry, like quay.io/user/prefix")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.repository, func(t *testing.T) {
+			testhelper.Diff(t, "error", ValidatePushRepository(tc.repository), tc.expectedErr, testhelper.EquateErrorMessage)
+		})
+	}
+}
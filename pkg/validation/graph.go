@@ -20,6 +20,7 @@ type pipelineImageSet map[api.PipelineImageStreamTagReference]sets.Empty
 func IsValidGraphConfiguration(rawSteps []api.StepConfiguration) error {
 	var ret []error
 	var containerTests, multiStageTests []*api.TestStepConfiguration
+	var imageBuilds []*api.ProjectDirectoryImageBuildStepConfiguration
 	names := sets.New[string]()
 	pipelineImages := pipelineImageSet{
 		// `src` can only be validated at runtime
@@ -52,6 +53,7 @@ func IsValidGraphConfiguration(rawSteps []api.StepConfiguration) error {
 		} else if c := s.ProjectDirectoryImageBuildStepConfiguration; c != nil {
 			addName(c.TargetName())
 			pipelineImages[c.To] = sets.Empty{}
+			imageBuilds = append(imageBuilds, c)
 		} else if c := s.RPMImageInjectionStepConfiguration; c != nil {
 			addName(c.TargetName())
 			pipelineImages[c.To] = sets.Empty{}
@@ -77,6 +79,9 @@ func IsValidGraphConfiguration(rawSteps []api.StepConfiguration) error {
 			pipelineImages[api.PipelineImageStreamTagReferenceRoot] = sets.Empty{}
 		}
 	}
+	for _, c := range imageBuilds {
+		ret = append(ret, validateImageBuild(pipelineImages, c)...)
+	}
 	for _, t := range containerTests {
 		ret = append(ret, validateContainerTest(pipelineImages, t)...)
 	}
@@ -86,6 +91,25 @@ func IsValidGraphConfiguration(rawSteps []api.StepConfiguration) error {
 	return utilerrors.NewAggregate(ret)
 }
 
+func validateImageBuild(
+	pipelineImages pipelineImageSet,
+	c *api.ProjectDirectoryImageBuildStepConfiguration,
+) (ret []error) {
+	if c.From == "" {
+		return
+	}
+	if _, ok := pipelineImages[c.From]; !ok {
+		msg := fmt.Sprintf("images[%s].from: unknown image %q", c.To, c.From)
+		if s := pipelineImageToConfigField[c.From]; s != "" {
+			msg = fmt.Sprintf("%s (configuration is missing `%s`)", msg, s)
+		} else {
+			msg = fmt.Sprintf("%s (it must be one of base_images or another image built by the configuration)", msg)
+		}
+		ret = append(ret, errors.New(msg))
+	}
+	return
+}
+
 func validateContainerTest(
 	pipelineImages pipelineImageSet,
 	s *api.TestStepConfiguration,
@@ -318,6 +318,53 @@ func TestIsValidGraph_ContainerTestFrom(t *testing.T) {
 	}
 }
 
+func TestIsValidGraph_ImageFrom(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   api.ReleaseBuildConfiguration
+		expected error
+	}{{
+		name: "from base image",
+		config: api.ReleaseBuildConfiguration{
+			InputConfiguration: api.InputConfiguration{
+				BaseImages: map[string]api.ImageStreamTagReference{"base": {}},
+			},
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{{From: "base", To: "image"}},
+		},
+	}, {
+		name: "from another image",
+		config: api.ReleaseBuildConfiguration{
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{
+				{From: "other", To: "image"},
+				{From: "src", To: "other"},
+			},
+		},
+	}, {
+		name: "without from",
+		config: api.ReleaseBuildConfiguration{
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{{To: "image"}},
+		},
+	}, {
+		name: "missing base image",
+		config: api.ReleaseBuildConfiguration{
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{{From: "base", To: "image"}},
+		},
+		expected: utilerrors.NewAggregate([]error{errors.New(`images[image].from: unknown image "base" (it must be one of base_images or another image built by the configuration)`)}),
+	}, {
+		name: "missing `bin` image",
+		config: api.ReleaseBuildConfiguration{
+			Images: []api.ProjectDirectoryImageBuildStepConfiguration{{From: "bin", To: "image"}},
+		},
+		expected: utilerrors.NewAggregate([]error{errors.New("images[image].from: unknown image \"bin\" (configuration is missing `binary_build_commands`)")}),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			graphConf := defaults.FromConfigStatic(&tc.config)
+			err := IsValidGraphConfiguration(graphConf.Steps)
+			testhelper.Diff(t, "error", err, tc.expected, testhelper.EquateErrorMessage)
+		})
+	}
+}
+
 func TestIsValidGraph_MultiStageTestFrom(t *testing.T) {
 	tests := func(from string) []api.TestStepConfiguration {
 		return []api.TestStepConfiguration{{
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/steps/scopeclient"
+)
+
+// ValidateTemplateObjectsNamespaced ensures the templates do not create
+// cluster-scoped objects, other than those of the allowed kinds.
+func ValidateTemplateObjectsNamespaced(mapper meta.RESTMapper, templates []*templateapi.Template, allowed []string) error {
+	allowedKinds := sets.New[string](allowed...)
+	var validationErrors []error
+	for _, template := range templates {
+		for i, raw := range template.Objects {
+			var object metav1.PartialObjectMetadata
+			if err := yaml.Unmarshal(raw.Raw, &object); err != nil {
+				validationErrors = append(validationErrors, fmt.Errorf("template %s: objects[%d]: could not determine the kind: %w", template.Name, i, err))
+				continue
+			}
+			if err := scopeclient.ValidateObjectNamespaced(mapper, object.GroupVersionKind(), object.Name, allowedKinds); err != nil {
+				validationErrors = append(validationErrors, fmt.Errorf("template %s: objects[%d]: %w", template.Name, i, err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(validationErrors)
+}
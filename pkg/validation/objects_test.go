@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	templateapi "github.com/openshift/api/template/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateTemplateObjectsNamespaced(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	template := &templateapi.Template{
+		ObjectMeta: metav1.ObjectMeta{Name: "e2e"},
+		Objects: []runtime.RawExtension{
+			{Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"test"}}`)},
+			{Raw: []byte(`{"kind":"ClusterRole","apiVersion":"rbac.authorization.k8s.io/v1","metadata":{"name":"admin"}}`)},
+			{Raw: []byte(`{"kind":"CustomResourceDefinition","apiVersion":"apiextensions.k8s.io/v1","metadata":{"name":"crd"}}`)},
+		},
+	}
+	var testCases = []struct {
+		name        string
+		allowed     []string
+		expectedErr error
+	}{
+		{
+			name:        "cluster-scoped objects are rejected",
+			expectedErr: errors.New("[template e2e: objects[1]: ClusterRole admin is cluster-scoped, add the kind to 'allowed_cluster_scoped_kinds' to create it, template e2e: objects[2]: CustomResourceDefinition crd is cluster-scoped, add the kind to 'allowed_cluster_scoped_kinds' to create it]"),
+		},
+		{
+			name:        "allowed kinds are accepted",
+			allowed:     []string{"ClusterRole"},
+			expectedErr: errors.New("template e2e: objects[2]: CustomResourceDefinition crd is cluster-scoped, add the kind to 'allowed_cluster_scoped_kinds' to create it"),
+		},
+		{
+			name:    "all kinds allowed",
+			allowed: []string{"ClusterRole", "CustomResourceDefinition"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTemplateObjectsNamespaced(mapper, []*templateapi.Template{template}, tc.allowed)
+			testhelper.Diff(t, "error", err, tc.expectedErr, testhelper.EquateErrorMessage)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -208,6 +210,34 @@ func (v *Validator) validateTestStepConfiguration(
 		if test.Timeout != nil && test.Timeout.Duration > maxJobTimeout {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: job timeout is limited to %s", fieldRootN, maxJobTimeout))
 		}
+		if test.RunTimeout != nil {
+			if test.ContainerTestConfiguration == nil && test.MultiStageTestConfiguration == nil && test.MultiStageTestConfigurationLiteral == nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.run_timeout: can be only used with container-based and multi-stage tests", fieldRootN))
+			}
+			if test.RunTimeout.Duration <= 0 {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.run_timeout: must be positive, got %s", fieldRootN, test.RunTimeout.Duration))
+			}
+		}
+
+		if test.Shards < 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.shards: must not be negative", fieldRootN))
+		} else if test.Shards > 1 && test.ContainerTestConfiguration == nil {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.shards: can be only used with container-based tests", fieldRootN))
+		}
+
+		if len(test.Gates) != 0 {
+			if test.ContainerTestConfiguration == nil && test.MultiStageTestConfiguration == nil && test.MultiStageTestConfigurationLiteral == nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.gates: can be only used with container-based and multi-stage tests", fieldRootN))
+			}
+			validationErrors = append(validationErrors, validateGates(fieldRootN+".gates", test.Gates)...)
+		}
+
+		if test.DedicatedNamespace != nil {
+			if test.ContainerTestConfiguration == nil && test.MultiStageTestConfiguration == nil && test.MultiStageTestConfigurationLiteral == nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.dedicated_namespace: can be only used with container-based and multi-stage tests", fieldRootN))
+			}
+			validationErrors = append(validationErrors, validateDedicatedNamespace(fieldRootN+".dedicated_namespace", test.As, *test.DedicatedNamespace)...)
+		}
 
 		// Validate Secret/Secrets
 		if test.Secret != nil && test.Secrets != nil {
@@ -491,6 +521,25 @@ func (v *Validator) validateTestConfigurationType(
 		if testConfig.From == "" {
 			validationErrors = append(validationErrors, fmt.Errorf("%s: 'from' is required", fieldRoot))
 		}
+		if expose := testConfig.Expose; expose != nil {
+			if expose.Port < 1 || expose.Port > 65535 {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.expose.port: %d is not a valid port", fieldRoot, expose.Port))
+			}
+			if test.Shards > 1 {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.expose: cannot be used with shards", fieldRoot))
+			}
+			if errs := validation.IsDNS1035Label(test.As); len(errs) != 0 {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.expose: the name of the test must be a valid service name: %s", fieldRoot, strings.Join(errs, ", ")))
+			}
+		}
+		validationErrors = append(validationErrors, validateHostAliases(fieldRoot+".host_aliases", testConfig.HostAliases)...)
+		validationErrors = append(validationErrors, validateRuntimeClass(fieldRoot+".runtime_class", testConfig.RuntimeClass)...)
+		if testConfig.ClusterProfile != "" {
+			validationErrors = append(validationErrors, v.validateClusterProfile(fieldRoot, testConfig.ClusterProfile)...)
+			if test.ClusterClaim != nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s: cannot set both cluster_profile and cluster_claim", fieldRoot))
+			}
+		}
 	}
 	var needsReleaseRpms bool
 	if testConfig := test.OpenshiftAnsibleClusterTestConfiguration; testConfig != nil {
@@ -511,6 +560,15 @@ func (v *Validator) validateTestConfigurationType(
 	if testConfig := test.OpenshiftInstallerClusterTestConfiguration; testConfig != nil {
 		typeCount++
 		validationErrors = append(validationErrors, v.validateClusterProfile(fieldRoot, testConfig.ClusterProfile)...)
+		if from := testConfig.FromRelease; from != "" {
+			if !testConfig.Upgrade {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.openshift_installer.from_release: can only be set for upgrade tests", fieldRoot))
+			} else if from == api.LatestReleaseName {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.openshift_installer.from_release: cannot upgrade from the release under test", fieldRoot))
+			} else if !releases.Has(from) && !(release != nil && from == api.InitialReleaseName) {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.openshift_installer.from_release: unknown release %q", fieldRoot, from))
+			}
+		}
 	}
 	if testConfig := test.OpenshiftInstallerUPIClusterTestConfiguration; testConfig != nil {
 		typeCount++
@@ -539,6 +597,7 @@ func (v *Validator) validateTestConfigurationType(
 		}
 		context := newContext(fieldPath(fieldRoot), testConfig.Environment, releases, inputImagesSeen)
 		validationErrors = append(validationErrors, validateLeases(context.addField("leases"), testConfig.Leases)...)
+		validationErrors = append(validationErrors, validateSharedVolume(fieldRoot+".steps.shared_volume", testConfig.SharedVolume)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("pre"), testStagePre, testConfig.Pre, claimRelease)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("test"), testStageTest, testConfig.Test, claimRelease)...)
 		validationErrors = append(validationErrors, v.validateTestSteps(context.addField("post"), testStagePost, testConfig.Post, claimRelease)...)
@@ -551,6 +610,7 @@ func (v *Validator) validateTestConfigurationType(
 			validationErrors = append(validationErrors, v.validateClusterProfile(fieldRoot, testConfig.ClusterProfile)...)
 		}
 		validationErrors = append(validationErrors, validateLeases(context.addField("leases"), testConfig.Leases)...)
+		validationErrors = append(validationErrors, validateSharedVolume(fieldRoot+".steps.shared_volume", testConfig.SharedVolume)...)
 		for i, s := range testConfig.Pre {
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("pre").addIndex(i), testStagePre, s, claimRelease)...)
 		}
@@ -561,6 +621,10 @@ func (v *Validator) validateTestConfigurationType(
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("post").addIndex(i), testStagePost, s, claimRelease)...)
 		}
 	}
+	if testConfig := test.ManifestTestConfiguration; testConfig != nil {
+		typeCount++
+		validationErrors = append(validationErrors, validateManifestTestConfiguration(fieldRoot+".manifests", testConfig)...)
+	}
 	if typeCount == 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s has no type, you may want to specify 'container' for a container based test", fieldRoot))
 	} else if typeCount == 1 {
@@ -654,11 +718,21 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 	}
 	ret = append(ret, validateDependencies(string(context.field), step.Dependencies)...)
 	ret = append(ret, validateLeases(context.addField("leases"), step.Leases)...)
+	ret = append(ret, validateSidecars(context.addField("sidecars"), step.Sidecars, claimRelease)...)
+	ret = append(ret, validateOutputs(context.addField("outputs"), step.Outputs)...)
+	ret = append(ret, validateHostAliases(string(context.field)+".host_aliases", step.HostAliases)...)
+	ret = append(ret, validateRuntimeClass(string(context.field)+".runtime_class", step.RuntimeClass)...)
 	switch stage {
 	case testStagePre, testStageTest:
 		if step.OptionalOnSuccess != nil {
 			ret = append(ret, context.errorf("`optional_on_success` is only allowed for Post steps"))
 		}
+		if step.RunIf != "" {
+			ret = append(ret, context.errorf("`run_if` is only allowed for Post steps"))
+		}
+	}
+	if err := validateRunIf(step.RunIf); err != nil {
+		ret = append(ret, context.addField("run_if").errorf("%v", err))
 	}
 	return ret
 }
@@ -677,6 +751,9 @@ func validateFromAndFromImage(
 		ret = append(ret, context.errorf("`from` and `from_image` cannot be set together"))
 	} else if fromImage != nil {
 		imgCtx := context.addField("from_image")
+		if fromImage.PullSpec != "" {
+			ret = append(ret, imgCtx.errorf("`pull_spec` is only supported for base_images"))
+		}
 		if fromImage.Namespace == "" {
 			ret = append(ret, imgCtx.errorf("`namespace` is required"))
 		}
@@ -816,6 +893,63 @@ func validateDependencies(fieldRoot string, dependencies []api.StepDependency) [
 	return errs
 }
 
+func validateGates(fieldRoot string, gates []api.StepGate) []error {
+	var errs []error
+	for i, gate := range gates {
+		fieldRootI := fmt.Sprintf("%s[%d]", fieldRoot, i)
+		var checks int
+		if gate.HTTP != nil {
+			checks++
+			if u, err := url.Parse(gate.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("%s.http.url: %q is not a valid http(s) URL", fieldRootI, gate.HTTP.URL))
+			}
+		}
+		if ist := gate.ImageStreamTag; ist != nil {
+			checks++
+			if ist.Name == "" || ist.Tag == "" {
+				errs = append(errs, fmt.Errorf("%s.image_stream_tag: name and tag must be set", fieldRootI))
+			}
+		}
+		if r := gate.Resource; r != nil {
+			checks++
+			if r.APIVersion == "" || r.Kind == "" || r.Name == "" || r.Condition == "" {
+				errs = append(errs, fmt.Errorf("%s.resource: api_version, kind, name and condition must be set", fieldRootI))
+			}
+			if r.ClusterScoped && r.Namespace != "" {
+				errs = append(errs, fmt.Errorf("%s.resource.namespace: cannot be set for cluster-scoped resources", fieldRootI))
+			}
+		}
+		if checks != 1 {
+			errs = append(errs, fmt.Errorf("%s: exactly one of http, image_stream_tag and resource must be set", fieldRootI))
+		}
+		if gate.Timeout != nil && gate.Timeout.Duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s.timeout: must be positive", fieldRootI))
+		}
+		if gate.Interval != nil && gate.Interval.Duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s.interval: must be positive", fieldRootI))
+		}
+	}
+	return errs
+}
+
+// defaultNamespaceLength is the length of the namespaces ci-operator names by
+// default, ci-op- followed by eight characters of the input hash.
+const defaultNamespaceLength = len("ci-op-") + 8
+
+func validateDedicatedNamespace(fieldRoot, test string, namespace api.DedicatedNamespace) []error {
+	var errs []error
+	// the dedicated namespace is named <namespace>-<test>
+	if length := defaultNamespaceLength + 1 + len(test); length > validation.DNS1123LabelMaxLength {
+		errs = append(errs, fmt.Errorf("%s: the name of the test is too long, the dedicated namespace would be named with %d characters, more than %d", fieldRoot, length, validation.DNS1123LabelMaxLength))
+	}
+	for _, name := range sets.List(sets.KeySet(namespace.Quota)) {
+		if _, err := resource.ParseQuantity(namespace.Quota[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s.quota.%s: invalid quantity %q: %v", fieldRoot, name, namespace.Quota[name], err))
+		}
+	}
+	return errs
+}
+
 func validateDNSConfig(fieldRoot string, dnsConfig []api.StepDNSConfig) (ret []error) {
 	var errs []error
 	for i, dnsconfig := range dnsConfig {
@@ -827,6 +961,97 @@ func validateDNSConfig(fieldRoot string, dnsConfig []api.StepDNSConfig) (ret []e
 	return errs
 }
 
+func validateHostAliases(fieldRoot string, aliases []api.StepHostAlias) (ret []error) {
+	for i, alias := range aliases {
+		if net.ParseIP(alias.IP) == nil {
+			ret = append(ret, fmt.Errorf("%s[%d].ip: %q is not a valid IP address", fieldRoot, i, alias.IP))
+		}
+		if len(alias.Hostnames) == 0 {
+			ret = append(ret, fmt.Errorf("%s[%d].hostnames: at least one hostname is required", fieldRoot, i))
+		}
+		for j, hostname := range alias.Hostnames {
+			if errs := validation.IsDNS1123Subdomain(hostname); len(errs) != 0 {
+				ret = append(ret, fmt.Errorf("%s[%d].hostnames[%d]: %q is not a valid hostname: %s", fieldRoot, i, j, hostname, strings.Join(errs, ", ")))
+			}
+		}
+	}
+	return ret
+}
+
+func validateRuntimeClass(fieldRoot, runtimeClass string) (ret []error) {
+	if runtimeClass == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(runtimeClass); len(errs) != 0 {
+		ret = append(ret, fmt.Errorf("%s: %q is not a valid RuntimeClass name: %s", fieldRoot, runtimeClass, strings.Join(errs, ", ")))
+	}
+	return ret
+}
+
+// reservedContainerNames are the containers of the pod of a step that
+// sidecars cannot be named after.
+var reservedContainerNames = sets.New[string]("test", "sidecar", "artifacts", "vpn-client", "place-entrypoint", "cp-entrypoint-wrapper", "inject-cli")
+
+func validateSidecars(context *context, sidecars []api.Sidecar, claimRelease *api.ClaimRelease) (ret []error) {
+	names := sets.New[string]()
+	for i, sidecar := range sidecars {
+		contextI := context.addIndex(i)
+		if sidecar.Name == "" {
+			ret = append(ret, contextI.errorf("`name` is required"))
+		} else if errs := validation.IsDNS1123Label(sidecar.Name); len(errs) != 0 {
+			ret = append(ret, contextI.addField("name").errorf("'%s' is not a valid container name: %s", sidecar.Name, strings.Join(errs, ", ")))
+		} else if reservedContainerNames.Has(sidecar.Name) {
+			ret = append(ret, contextI.addField("name").errorf("'%s' is reserved", sidecar.Name))
+		} else if names.Has(sidecar.Name) {
+			ret = append(ret, contextI.addField("name").errorf("duplicated name %q", sidecar.Name))
+		} else {
+			names.Insert(sidecar.Name)
+		}
+		if sidecar.From == "" {
+			ret = append(ret, contextI.errorf("`from` is required"))
+		} else {
+			ret = append(ret, validateFromAndFromImage(contextI, sidecar.From, nil, nil, claimRelease)...)
+		}
+		if sidecar.Commands == "" {
+			ret = append(ret, contextI.errorf("`commands` is required"))
+		}
+		for j, port := range sidecar.Ports {
+			if port < 1 || port > 65535 {
+				ret = append(ret, contextI.addField("ports").addIndex(j).errorf("%d is not a valid port", port))
+			}
+		}
+		if len(sidecar.Resources.Requests) != 0 || len(sidecar.Resources.Limits) != 0 {
+			ret = append(ret, validateResourceRequirements(string(contextI.field)+".resources", sidecar.Resources)...)
+		}
+	}
+	return
+}
+
+func validateRunIf(runIf api.RunCondition) error {
+	if runIf == "" {
+		return nil
+	}
+	for _, valid := range api.ValidRunConditions {
+		if runIf == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value %q, expected one of %v", runIf, api.ValidRunConditions)
+}
+
+func validateOutputs(context *context, outputs []string) (ret []error) {
+	seen := sets.New[string]()
+	for i, name := range outputs {
+		if errs := validation.IsEnvVarName(name); len(errs) != 0 {
+			ret = append(ret, context.addIndex(i).errorf("%q is not a valid environment variable name: %s", name, strings.Join(errs, ", ")))
+		} else if seen.Has(name) {
+			ret = append(ret, context.addIndex(i).errorf("duplicated output %q", name))
+		}
+		seen.Insert(name)
+	}
+	return
+}
+
 func validateLeases(context *context, leases []api.StepLease) (ret []error) {
 	for i, l := range leases {
 		if l.ResourceType == "" {
@@ -844,3 +1069,44 @@ func validateLeases(context *context, leases []api.StepLease) (ret []error) {
 	}
 	return
 }
+
+// validateManifestTestConfiguration validates the manifests of a test and the
+// object it waits for.
+func validateManifestTestConfiguration(fieldRoot string, config *api.ManifestTestConfiguration) []error {
+	var validationErrors []error
+	if len(config.Objects) == 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.objects: at least one object is required", fieldRoot))
+	}
+	for i, object := range config.Objects {
+		if (object.Manifest == "") == (object.File == "") {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.objects[%d]: exactly one of 'manifest' or 'file' must be set", fieldRoot, i))
+		}
+	}
+	if kind := config.WaitFor.Kind; kind != "Pod" && kind != "Job" {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.wait_for.kind: must be Pod or Job, got %q", fieldRoot, kind))
+	}
+	if config.WaitFor.Name == "" {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.wait_for.name: must be set", fieldRoot))
+	}
+	return validationErrors
+}
+
+// validateSharedVolume validates the size and storage class of the shared
+// volume of a multi-stage test, if it has one.
+func validateSharedVolume(fieldRoot string, volume *api.SharedVolume) []error {
+	if volume == nil {
+		return nil
+	}
+	var validationErrors []error
+	if size, err := resource.ParseQuantity(volume.Size); err != nil {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.size: must be a Kubernetes quantity: %w", fieldRoot, err))
+	} else if size.Sign() <= 0 {
+		validationErrors = append(validationErrors, fmt.Errorf("%s.size: must be positive, got %s", fieldRoot, volume.Size))
+	}
+	if class := volume.StorageClass; class != "" {
+		if errs := validation.IsDNS1123Subdomain(class); len(errs) != 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("%s.storage_class: %q is not a valid StorageClass name: %s", fieldRoot, class, strings.Join(errs, ", ")))
+		}
+	}
+	return validationErrors
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -198,6 +199,39 @@ func TestValidateTests(t *testing.T) {
 			},
 			expectedError: errors.New("tests[0]: invalid cluster profile \"\""),
 		},
+		{
+			id: "container test with a cluster profile",
+			tests: []api.TestStepConfiguration{
+				{
+					As:                         "test",
+					Commands:                   "commands",
+					ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored", ClusterProfile: api.ClusterProfileGCP},
+				},
+			},
+		},
+		{
+			id: "container test with an invalid cluster profile",
+			tests: []api.TestStepConfiguration{
+				{
+					As:                         "test",
+					Commands:                   "commands",
+					ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored", ClusterProfile: "moon"},
+				},
+			},
+			expectedError: errors.New("tests[0]: invalid cluster profile \"moon\""),
+		},
+		{
+			id: "container test with a cluster profile and a claim",
+			tests: []api.TestStepConfiguration{
+				{
+					As:                         "test",
+					Commands:                   "commands",
+					ClusterClaim:               &api.ClusterClaim{Version: "4.9", Cloud: "gcp", Owner: "ME"},
+					ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored", ClusterProfile: api.ClusterProfileGCP},
+				},
+			},
+			expectedError: errors.New("tests[0]: cannot set both cluster_profile and cluster_claim"),
+		},
 		{
 			id: "release missing",
 			tests: []api.TestStepConfiguration{
@@ -681,6 +715,29 @@ func TestValidateTests(t *testing.T) {
 			},
 			expectedError: errors.New("tests[0].as: 49 characters long, maximum length is 42 for tests with claims"),
 		},
+		{
+			id: "run timeout",
+			tests: []api.TestStepConfiguration{
+				{
+					As:                         "unit",
+					Commands:                   "commands",
+					ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+					RunTimeout:                 &prowv1.Duration{Duration: time.Hour},
+				},
+			},
+		},
+		{
+			id: "non-positive run timeout",
+			tests: []api.TestStepConfiguration{
+				{
+					As:                         "unit",
+					Commands:                   "commands",
+					ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "ignored"},
+					RunTimeout:                 &prowv1.Duration{},
+				},
+			},
+			expectedError: errors.New("tests[0].run_timeout: must be positive, got 0s"),
+		},
 	} {
 		t.Run(tc.id, func(t *testing.T) {
 			v := newSingleUseValidator()
@@ -976,6 +1033,21 @@ func TestValidateTestSteps(t *testing.T) {
 		errs: []error{
 			errors.New("test[0]: `optional_on_success` is only allowed for Post steps"),
 		},
+	}, {
+		name: "Test step with run condition",
+
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "as",
+				From:      "from",
+				Commands:  "commands",
+				Resources: resources,
+				RunIf:     "sometimes"},
+		}},
+		errs: []error{
+			errors.New("test[0]: `run_if` is only allowed for Post steps"),
+			errors.New("test[0].run_if: invalid value \"sometimes\", expected one of [success failure always]"),
+		},
 	}, {
 		name: "Multiple errors",
 		steps: []api.TestStep{{
@@ -1299,6 +1371,94 @@ func TestValidateDependencies(t *testing.T) {
 	}
 }
 
+func TestValidateGates(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		input  []api.StepGate
+		output []error
+	}{
+		{
+			name: "valid gates",
+			input: []api.StepGate{
+				{HTTP: &api.HTTPGate{URL: "https://example.com/healthz"}, Timeout: &prowv1.Duration{Duration: time.Hour}},
+				{ImageStreamTag: &api.ImageStreamTagGate{Namespace: "ocp", Name: "4.14", Tag: "cli"}},
+				{Resource: &api.ResourceGate{APIVersion: "apps/v1", Kind: "Deployment", Name: "registry", Condition: "Available"}},
+			},
+		},
+		{
+			name: "invalid gates",
+			input: []api.StepGate{
+				{},
+				{HTTP: &api.HTTPGate{URL: "example.com"}, ImageStreamTag: &api.ImageStreamTagGate{Name: "4.14"}},
+				{Resource: &api.ResourceGate{APIVersion: "v1", Kind: "Node", Name: "node", Condition: "Ready", Namespace: "ns", ClusterScoped: true}, Interval: &prowv1.Duration{}},
+			},
+			output: []error{
+				errors.New("root.gates[0]: exactly one of http, image_stream_tag and resource must be set"),
+				errors.New("root.gates[1].http.url: \"example.com\" is not a valid http(s) URL"),
+				errors.New("root.gates[1].image_stream_tag: name and tag must be set"),
+				errors.New("root.gates[1]: exactly one of http, image_stream_tag and resource must be set"),
+				errors.New("root.gates[2].resource.namespace: cannot be set for cluster-scoped resources"),
+				errors.New("root.gates[2].interval: must be positive"),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual, expected := validateGates("root.gates", testCase.input), testCase.output; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: got incorrect errors: %s", testCase.name, cmp.Diff(actual, expected, cmp.Comparer(func(x, y error) bool {
+					return x.Error() == y.Error()
+				})))
+			}
+		})
+	}
+}
+
+func TestValidateDedicatedNamespace(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		test   string
+		input  api.DedicatedNamespace
+		output []error
+	}{
+		{
+			name: "no quota",
+		},
+		{
+			name:  "valid quota",
+			input: api.DedicatedNamespace{Quota: api.ResourceList{"requests.cpu": "10", "pods": "20"}},
+		},
+		{
+			name:  "invalid quota",
+			input: api.DedicatedNamespace{Quota: api.ResourceList{"requests.cpu": "many", "pods": "20"}},
+			output: []error{
+				errors.New("root.dedicated_namespace.quota.requests.cpu: invalid quantity \"many\": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'"),
+			},
+		},
+		{
+			name: "longest test name",
+			test: strings.Repeat("a", 48),
+		},
+		{
+			name: "test name too long",
+			test: strings.Repeat("a", 49),
+			output: []error{
+				errors.New("root.dedicated_namespace: the name of the test is too long, the dedicated namespace would be named with 64 characters, more than 63"),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual, expected := validateDedicatedNamespace("root.dedicated_namespace", testCase.test, testCase.input), testCase.output; !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: got incorrect errors: %s", testCase.name, cmp.Diff(actual, expected, cmp.Comparer(func(x, y error) bool {
+					return x.Error() == y.Error()
+				})))
+			}
+		})
+	}
+}
+
 func TestValidateDNSConfig(t *testing.T) {
 	var testCases = []struct {
 		name   string
@@ -1337,6 +1497,111 @@ func TestValidateDNSConfig(t *testing.T) {
 	}
 }
 
+func TestValidateHostAliases(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		aliases []api.StepHostAlias
+		output  []error
+	}{{
+		name:    "valid aliases",
+		aliases: []api.StepHostAlias{{IP: "10.0.0.1", Hostnames: []string{"registry.internal", "mirror.internal"}}, {IP: "fd00::1", Hostnames: []string{"ipv6.internal"}}},
+	}, {
+		name:    "invalid aliases",
+		aliases: []api.StepHostAlias{{IP: "registry.internal"}, {IP: "10.0.0.1", Hostnames: []string{"Not_Valid"}}},
+		output: []error{
+			errors.New(`root[0].ip: "registry.internal" is not a valid IP address`),
+			errors.New("root[0].hostnames: at least one hostname is required"),
+			errors.New(`root[1].hostnames[0]: "Not_Valid" is not a valid hostname: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateHostAliases("root", tc.aliases)
+			if diff := cmp.Diff(err, tc.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateRuntimeClass(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		runtimeClass string
+		output       []error
+	}{{
+		name: "no runtime class",
+	}, {
+		name:         "valid runtime class",
+		runtimeClass: "gvisor",
+	}, {
+		name:         "invalid runtime class",
+		runtimeClass: "Kata_Qemu",
+		output: []error{
+			errors.New(`root: "Kata_Qemu" is not a valid RuntimeClass name: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRuntimeClass("root", tc.runtimeClass)
+			if diff := cmp.Diff(err, tc.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateManifestTestConfiguration(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config api.ManifestTestConfiguration
+		output []error
+	}{{
+		name: "valid pod",
+		config: api.ManifestTestConfiguration{
+			Objects: []api.AdditionalResource{{Manifest: "kind: Pod"}, {File: "manifests/config.yaml"}},
+			WaitFor: api.ManifestWaitFor{Kind: "Pod", Name: "test"},
+		},
+	}, {
+		name: "valid job",
+		config: api.ManifestTestConfiguration{
+			Objects: []api.AdditionalResource{{Manifest: "kind: Job"}},
+			WaitFor: api.ManifestWaitFor{Kind: "Job", Name: "test"},
+		},
+	}, {
+		name: "no objects",
+		config: api.ManifestTestConfiguration{
+			WaitFor: api.ManifestWaitFor{Kind: "Pod", Name: "test"},
+		},
+		output: []error{errors.New("root.objects: at least one object is required")},
+	}, {
+		name: "object with both manifest and file",
+		config: api.ManifestTestConfiguration{
+			Objects: []api.AdditionalResource{{Manifest: "kind: Pod", File: "pod.yaml"}, {}},
+			WaitFor: api.ManifestWaitFor{Kind: "Pod", Name: "test"},
+		},
+		output: []error{
+			errors.New("root.objects[0]: exactly one of 'manifest' or 'file' must be set"),
+			errors.New("root.objects[1]: exactly one of 'manifest' or 'file' must be set"),
+		},
+	}, {
+		name: "unsupported wait target",
+		config: api.ManifestTestConfiguration{
+			Objects: []api.AdditionalResource{{Manifest: "kind: Deployment"}},
+			WaitFor: api.ManifestWaitFor{Kind: "Deployment"},
+		},
+		output: []error{
+			errors.New(`root.wait_for.kind: must be Pod or Job, got "Deployment"`),
+			errors.New("root.wait_for.name: must be set"),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateManifestTestConfiguration("root", &tc.config)
+			if diff := cmp.Diff(err, tc.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateLeases(t *testing.T) {
 	for _, tc := range []struct {
 		name string
@@ -1413,10 +1678,103 @@ func TestValidateLeases(t *testing.T) {
 	}
 }
 
+func TestValidateSidecars(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		sidecars []api.Sidecar
+		err      []error
+	}{{
+		name: "valid sidecars",
+		sidecars: []api.Sidecar{
+			{Name: "database", From: "src", Commands: "postgres", Ports: []int32{5432}},
+			{Name: "cache", From: "src", Commands: "redis-server", Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "100m"}}},
+		},
+	}, {
+		name:     "missing fields",
+		sidecars: []api.Sidecar{{}},
+		err: []error{
+			errors.New("tests[0].steps.test[0].sidecars[0]: `name` is required"),
+			errors.New("tests[0].steps.test[0].sidecars[0]: `from` is required"),
+			errors.New("tests[0].steps.test[0].sidecars[0]: `commands` is required"),
+		},
+	}, {
+		name: "invalid names and ports",
+		sidecars: []api.Sidecar{
+			{Name: "Database", From: "src", Commands: "postgres"},
+			{Name: "test", From: "src", Commands: "postgres"},
+			{Name: "cache", From: "src", Commands: "redis-server", Ports: []int32{0}},
+			{Name: "cache", From: "src", Commands: "redis-server"},
+		},
+		err: []error{
+			errors.New("tests[0].steps.test[0].sidecars[0].name: 'Database' is not a valid container name: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')"),
+			errors.New("tests[0].steps.test[0].sidecars[1].name: 'test' is reserved"),
+			errors.New("tests[0].steps.test[0].sidecars[2].ports[0]: 0 is not a valid port"),
+			errors.New("tests[0].steps.test[0].sidecars[3].name: duplicated name \"cache\""),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := api.TestStepConfiguration{
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{
+						As:        "as",
+						From:      "src",
+						Commands:  "commands",
+						Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "1"}},
+						Sidecars:  tc.sidecars,
+					}},
+				},
+			}
+			v := NewValidator()
+			err := v.validateTestConfigurationType("tests[0]", test, nil, nil, make(testInputImages), true)
+			if diff := diff.ObjectReflectDiff(tc.err, err); diff != "<no diffs>" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateOutputs(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		outputs []string
+		err     []error
+	}{{
+		name:    "valid outputs",
+		outputs: []string{"CLUSTER_NAME", "API_URL"},
+	}, {
+		name:    "invalid and duplicated names",
+		outputs: []string{"API_URL", "1ST", "API_URL"},
+		err: []error{
+			errors.New("tests[0].steps.test[0].outputs[1]: \"1ST\" is not a valid environment variable name: a valid environment variable name must consist of alphabetic characters, digits, '_', '-', or '.', and must not start with a digit (e.g. 'my.env-name',  or 'MY_ENV.NAME',  or 'MyEnvName1', regex used for validation is '[-._a-zA-Z][-._a-zA-Z0-9]*')"),
+			errors.New("tests[0].steps.test[0].outputs[2]: duplicated output \"API_URL\""),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := api.TestStepConfiguration{
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{
+						As:        "as",
+						From:      "src",
+						Commands:  "commands",
+						Resources: api.ResourceRequirements{Requests: api.ResourceList{"cpu": "1"}},
+						Outputs:   tc.outputs,
+					}},
+				},
+			}
+			v := NewValidator()
+			err := v.validateTestConfigurationType("tests[0]", test, nil, nil, make(testInputImages), true)
+			if diff := diff.ObjectReflectDiff(tc.err, err); diff != "<no diffs>" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
 func TestValidateTestConfigurationType(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
 		test     api.TestStepConfiguration
+		releases sets.Set[string]
 		expected []error
 	}{
 		{
@@ -1599,13 +1957,108 @@ func TestValidateTestConfigurationType(t *testing.T) {
 				errors.New("test.cluster_claim.labels contains an invalid key in claim's label: cloud"),
 			},
 		},
+		{
+			name: "upgrade from a configured release",
+			test: api.TestStepConfiguration{
+				OpenshiftInstallerClusterTestConfiguration: &api.OpenshiftInstallerClusterTestConfiguration{
+					ClusterTestConfiguration: api.ClusterTestConfiguration{ClusterProfile: api.ClusterProfileAWS},
+					Upgrade:                  true,
+					FromRelease:              "previous",
+				},
+			},
+			releases: sets.New[string]("previous"),
+		},
+		{
+			name: "from_release on a test that does not upgrade -> error",
+			test: api.TestStepConfiguration{
+				OpenshiftInstallerClusterTestConfiguration: &api.OpenshiftInstallerClusterTestConfiguration{
+					ClusterTestConfiguration: api.ClusterTestConfiguration{ClusterProfile: api.ClusterProfileAWS},
+					FromRelease:              "previous",
+				},
+			},
+			releases: sets.New[string]("previous"),
+			expected: []error{errors.New("test.openshift_installer.from_release: can only be set for upgrade tests")},
+		},
+		{
+			name: "upgrade from the release under test -> error",
+			test: api.TestStepConfiguration{
+				OpenshiftInstallerClusterTestConfiguration: &api.OpenshiftInstallerClusterTestConfiguration{
+					ClusterTestConfiguration: api.ClusterTestConfiguration{ClusterProfile: api.ClusterProfileAWS},
+					Upgrade:                  true,
+					FromRelease:              api.LatestReleaseName,
+				},
+			},
+			expected: []error{errors.New("test.openshift_installer.from_release: cannot upgrade from the release under test")},
+		},
+		{
+			name: "upgrade from an unknown release -> error",
+			test: api.TestStepConfiguration{
+				OpenshiftInstallerClusterTestConfiguration: &api.OpenshiftInstallerClusterTestConfiguration{
+					ClusterTestConfiguration: api.ClusterTestConfiguration{ClusterProfile: api.ClusterProfileAWS},
+					Upgrade:                  true,
+					FromRelease:              "previous",
+				},
+			},
+			expected: []error{errors.New(`test.openshift_installer.from_release: unknown release "previous"`)},
+		},
+		{
+			name: "exposed container test",
+			test: api.TestStepConfiguration{
+				As:                         "server",
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src", Expose: &api.TestExposure{Port: 8080, Route: true}},
+			},
+		},
+		{
+			name: "invalid exposure -> error",
+			test: api.TestStepConfiguration{
+				As:                         "1-server",
+				Shards:                     2,
+				ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src", Expose: &api.TestExposure{Port: 70000}},
+			},
+			expected: []error{
+				errors.New("test.expose.port: 70000 is not a valid port"),
+				errors.New("test.expose: cannot be used with shards"),
+				errors.New("test.expose: the name of the test must be a valid service name: a DNS-1035 label must consist of lower case alphanumeric characters or '-', start with an alphabetic character, and end with an alphanumeric character (e.g. 'my-name',  or 'abc-123', regex used for validation is '[a-z]([-a-z0-9]*[a-z0-9])?')"),
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			v := NewValidator()
-			actual := v.validateTestConfigurationType("test", tc.test, nil, nil, make(testInputImages), false)
+			actual := v.validateTestConfigurationType("test", tc.test, nil, tc.releases, make(testInputImages), false)
 			if diff := cmp.Diff(tc.expected, actual, testhelper.EquateErrorMessage); diff != "" {
 				t.Errorf("expected differs from actual: %s", diff)
 			}
 		})
 	}
 }
+
+func TestValidateSharedVolume(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		volume *api.SharedVolume
+		output []error
+	}{{
+		name: "no shared volume",
+	}, {
+		name:   "valid shared volume",
+		volume: &api.SharedVolume{Size: "10Gi", StorageClass: "gp3-csi"},
+	}, {
+		name:   "invalid size",
+		volume: &api.SharedVolume{Size: "a lot"},
+		output: []error{errors.New("root.size: must be a Kubernetes quantity: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'")},
+	}, {
+		name:   "empty volume with invalid storage class",
+		volume: &api.SharedVolume{Size: "0", StorageClass: "Fast_SSD"},
+		output: []error{
+			errors.New("root.size: must be positive, got 0"),
+			errors.New(`root.storage_class: "Fast_SSD" is not a valid StorageClass name: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`),
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSharedVolume("root", tc.volume)
+			if diff := cmp.Diff(err, tc.output, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("actualError does not match expectedError, diff: %s", diff)
+			}
+		})
+	}
+}
@@ -235,6 +235,13 @@ const templateDefinitions = `
       <td>This step's failure will not cause whole job to fail if the step is run in <span style="font-family:monospace">post</span> phase.</td>
     </tr>
   {{ end }}
+  {{ if .RunIf }}
+    <tr>
+      <td>Run if</td>
+      <td>{{ .RunIf }}</td>
+      <td>The outcome of the steps in <span style="font-family:monospace">pre</span> and <span style="font-family:monospace">test</span> phases this step runs for in <span style="font-family:monospace">post</span> phase.</td>
+    </tr>
+  {{ end }}
   {{ if .Cli }}
     <tr>
       <td>Inject <span style="font-family:monospace">oc</span> CLI<sup>[<a href="https://docs.ci.openshift.org/docs/architecture/step-registry/#sharing-data-between-steps">?</a>]</sup></td>
@@ -1316,6 +1323,7 @@ func referenceHandler(agent agents.RegistryAgent, w http.ResponseWriter, req *ht
 				Resources:         refs[name].Resources,
 				OptionalOnSuccess: refs[name].OptionalOnSuccess,
 				BestEffort:        refs[name].BestEffort,
+				RunIf:             refs[name].RunIf,
 				Cli:               refs[name].Cli,
 			},
 			Documentation: docs[name],
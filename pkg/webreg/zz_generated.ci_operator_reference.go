@@ -1,6 +1,21 @@
 package webreg
 
-const ciOperatorReferenceYaml = "# The list of base images describe\n" +
+const ciOperatorReferenceYaml = "# AdditionalResources are objects created in the test namespace when it\n" +
+	"# is set up, before any test runs, like a ConfigMap or an instance of a\n" +
+	"# custom resource the tests need. They are not named resources, as that\n" +
+	"# configures the resources of the steps.\n" +
+	"additional_resources:\n" +
+	"    - # File is the path of a file holding the object, relative to the\n" +
+	"      # directory ci-operator runs in, which is usually the source under test.\n" +
+	"      file: ' '\n" +
+	"      # Manifest is the object, as YAML or JSON.\n" +
+	"      manifest: ' '\n" +
+	"# AllowedClusterScopedKinds lists the kinds of cluster-scoped objects,\n" +
+	"# like ClusterRole, that templates in this configuration may create\n" +
+	"# when ci-operator forbids cluster-scoped objects.\n" +
+	"allowed_cluster_scoped_kinds:\n" +
+	"    - \"\"\n" +
+	"# The list of base images describe\n" +
 	"# which images are going to be necessary outside\n" +
 	"# of the pipeline. The key will be the alias that other\n" +
 	"# steps use to refer to this image.\n" +
@@ -10,6 +25,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        as: ' '\n" +
 	"        name: ' '\n" +
 	"        namespace: ' '\n" +
+	"        # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"        # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"        # only supported for base images, and excludes the other fields.\n" +
+	"        pull_spec: ' '\n" +
 	"        tag: ' '\n" +
 	"# BaseRPMImages is a list of the images and their aliases that will\n" +
 	"# have RPM repositories injected into them for downstream\n" +
@@ -20,6 +39,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        as: ' '\n" +
 	"        name: ' '\n" +
 	"        namespace: ' '\n" +
+	"        # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"        # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"        # only supported for base images, and excludes the other fields.\n" +
+	"        pull_spec: ' '\n" +
 	"        tag: ' '\n" +
 	"# BinaryBuildCommands will create a \"bin\" image based on \"src\" that\n" +
 	"# contains the output of this command. This allows reuse of binary artifacts\n" +
@@ -36,6 +59,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        as: ' '\n" +
 	"        name: ' '\n" +
 	"        namespace: ' '\n" +
+	"        # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"        # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"        # only supported for base images, and excludes the other fields.\n" +
+	"        pull_spec: ' '\n" +
 	"        tag: ' '\n" +
 	"    project_image:\n" +
 	"        # BuildArgs contains build arguments that will be resolved in the Dockerfile.\n" +
@@ -83,6 +110,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# Go. If specified the location of the repository we are\n" +
 	"# cloning from is ignored.\n" +
 	"canonical_go_repository: \"\"\n" +
+	"# CloneCredentials references a secret on the build cluster that\n" +
+	"# is used to clone private repositories.\n" +
+	"clone_credentials:\n" +
+	"    # Name is the name of the secret.\n" +
+	"    name: ' '\n" +
+	"    # Namespace is the namespace of the secret.\n" +
+	"    namespace: ' '\n" +
+	"    # Type is the kind of credentials the secret holds, `ssh` or `oauth`.\n" +
+	"    type: ' '\n" +
 	"# Images describes the images that are built\n" +
 	"# baseImage the project as part of the release\n" +
 	"# process. The name of each image is its \"to\" value\n" +
@@ -95,9 +131,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"          name: ' '\n" +
 	"          # Value of the build arg.\n" +
 	"          value: ' '\n" +
+	"      # CacheFromPromoted enables the layer cache for the build, using\n" +
+	"      # the image previously promoted for this component as the cache\n" +
+	"      # source. Has no effect if the image is not promoted or if it has\n" +
+	"      # not been promoted yet.\n" +
+	"      cache_from_promoted: true\n" +
 	"      # ContextDir is the directory in the project\n" +
 	"      # from which this build should be run.\n" +
 	"      context_dir: ' '\n" +
+	"      # ContextExcludes are .dockerignore patterns for files in the build\n" +
+	"      # context that the build does not use. They are added after the\n" +
+	"      # patterns of any .dockerignore in the context_dir.\n" +
+	"      context_excludes:\n" +
+	"        - \"\"\n" +
+	"      # ContextIncludes limits the build context to these paths, relative\n" +
+	"      # to the context_dir, instead of copying the whole directory. The\n" +
+	"      # Dockerfile is always included.\n" +
+	"      context_includes:\n" +
+	"        - \"\"\n" +
 	"      # DockerfileLiteral can be used to provide an inline Dockerfile.\n" +
 	"      # Mutually exclusive with DockerfilePath.\n" +
 	"      dockerfile_literal: \"\"\n" +
@@ -125,11 +176,35 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  destination_dir: ' '\n" +
 	"                  # SourcePath is a file or directory in the source image to copy from.\n" +
 	"                  source_path: ' '\n" +
+	"      # NoOutputTimeout is how long the build may run without writing to its\n" +
+	"      # log before it is considered stalled, cancelled and retried. Defaults\n" +
+	"      # to 20 minutes.\n" +
+	"      no_output_timeout: 0s\n" +
 	"      # Optional means the build step is not built, published, or\n" +
 	"      # promoted unless explicitly targeted. Use for builds which\n" +
 	"      # are invoked only when testing certain parts of the repo.\n" +
 	"      optional: true\n" +
+	"      # ReusePromoted skips the build if the image previously promoted for\n" +
+	"      # this component was built from the same source revisions, build\n" +
+	"      # configuration and pipeline images, and uses the promoted image\n" +
+	"      # instead. Images the Dockerfile pulls from outside of the pipeline\n" +
+	"      # are not considered, so do not use it for builds that depend on them.\n" +
+	"      reuse_promoted: true\n" +
+	"      # RunTimeout bounds how long ci-operator lets the build step run,\n" +
+	"      # including the retries of the build. A step running for longer is\n" +
+	"      # cancelled and fails with a timeout.\n" +
+	"      run_timeout: 0s\n" +
+	"      # Timeout is how long a single attempt of the build may take before\n" +
+	"      # it is cancelled and the step fails.\n" +
+	"      timeout: 0s\n" +
 	"      to: ' '\n" +
+	"# ExpectedMetadata names the repository and branch this configuration\n" +
+	"# is for. When it is set, ci-operator fails before running anything\n" +
+	"# if the refs of the job are for another repository or branch.\n" +
+	"metadata:\n" +
+	"    branch: ' '\n" +
+	"    org: ' '\n" +
+	"    repo: ' '\n" +
 	"# Operator describes the operator bundle(s) that is built by the project\n" +
 	"operator:\n" +
 	"    # Bundles define a dockerfile and build context to build a bundle\n" +
@@ -192,11 +267,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"    # Namespace identifies the namespace to which the built\n" +
 	"    # artifacts will be published to.\n" +
 	"    namespace: ' '\n" +
+	"    # RegistryMirror is a repository outside of the CI registry, like\n" +
+	"    # quay.io/org/prefix, the promoted images are also pushed to, tagged\n" +
+	"    # by the name they are promoted as. The push is retried on failure and\n" +
+	"    # every tag is verified to resolve to the digest of the promoted image.\n" +
+	"    # It needs the credentials given to ci-operator with\n" +
+	"    # --push-images-secret-dir.\n" +
+	"    registry_mirror: ' '\n" +
 	"    # RegistryOverride is an override for the registry domain to\n" +
 	"    # which we will mirror images. This is an advanced option and\n" +
 	"    # should *not* be used in common test workflows. The CI chat\n" +
 	"    # bot uses this option to facilitate image sharing.\n" +
 	"    registry_override: ' '\n" +
+	"    # RunIf determines the outcome of the run the promotion happens\n" +
+	"    # for. Images are only promoted when all steps succeeded by default.\n" +
+	"    run_if: ' '\n" +
 	"    # Tag is the ImageStreamTag tagged in for each\n" +
 	"    # build image's ImageStream.\n" +
 	"    tag: ' '\n" +
@@ -205,6 +290,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"    # this will cause both a floating tag and commit-specific tags\n" +
 	"    # to be promoted.\n" +
 	"    tag_by_commit: true\n" +
+	"    # TagTemplates are Go templates for additional tags each image\n" +
+	"    # is promoted as, like `sha-{{.Commit}}`. The templates can use\n" +
+	"    # the `.Commit` and `.Branch` that were built and the `.Date`\n" +
+	"    # of the promotion in the YYYYMMDD format.\n" +
+	"    tag_templates:\n" +
+	"        - \"\"\n" +
 	"    # Targets configure a set of images to be pushed to\n" +
 	"    # a registry.\n" +
 	"    to:\n" +
@@ -242,6 +333,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"          # this will cause both a floating tag and commit-specific tags\n" +
 	"          # to be promoted.\n" +
 	"          tag_by_commit: true\n" +
+	"          # TagTemplates are Go templates for additional tags each image\n" +
+	"          # is promoted as, like `sha-{{.Commit}}`. The templates can use\n" +
+	"          # the `.Commit` and `.Branch` that were built and the `.Date`\n" +
+	"          # of the promotion in the YYYYMMDD format.\n" +
+	"          tag_templates:\n" +
+	"            - \"\"\n" +
 	"# RawSteps are literal Steps that should be\n" +
 	"# included in the final pipeline.\n" +
 	"raw_steps:\n" +
@@ -269,6 +366,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            as: ' '\n" +
 	"            name: ' '\n" +
 	"            namespace: ' '\n" +
+	"            # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"            # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"            # only supported for base images, and excludes the other fields.\n" +
+	"            pull_spec: ' '\n" +
 	"            tag: ' '\n" +
 	"        to: ' '\n" +
 	"      output_image_tag_step:\n" +
@@ -282,6 +383,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            as: ' '\n" +
 	"            name: ' '\n" +
 	"            namespace: ' '\n" +
+	"            # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"            # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"            # only supported for base images, and excludes the other fields.\n" +
+	"            pull_spec: ' '\n" +
 	"            tag: ' '\n" +
 	"      pipeline_image_cache_step:\n" +
 	"        # Commands are the shell commands to run in\n" +
@@ -335,9 +440,24 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              name: ' '\n" +
 	"              # Value of the build arg.\n" +
 	"              value: ' '\n" +
+	"        # CacheFromPromoted enables the layer cache for the build, using\n" +
+	"        # the image previously promoted for this component as the cache\n" +
+	"        # source. Has no effect if the image is not promoted or if it has\n" +
+	"        # not been promoted yet.\n" +
+	"        cache_from_promoted: true\n" +
 	"        # ContextDir is the directory in the project\n" +
 	"        # from which this build should be run.\n" +
 	"        context_dir: ' '\n" +
+	"        # ContextExcludes are .dockerignore patterns for files in the build\n" +
+	"        # context that the build does not use. They are added after the\n" +
+	"        # patterns of any .dockerignore in the context_dir.\n" +
+	"        context_excludes:\n" +
+	"            - \"\"\n" +
+	"        # ContextIncludes limits the build context to these paths, relative\n" +
+	"        # to the context_dir, instead of copying the whole directory. The\n" +
+	"        # Dockerfile is always included.\n" +
+	"        context_includes:\n" +
+	"            - \"\"\n" +
 	"        # DockerfileLiteral can be used to provide an inline Dockerfile.\n" +
 	"        # Mutually exclusive with DockerfilePath.\n" +
 	"        dockerfile_literal: \"\"\n" +
@@ -365,10 +485,27 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                      destination_dir: ' '\n" +
 	"                      # SourcePath is a file or directory in the source image to copy from.\n" +
 	"                      source_path: ' '\n" +
+	"        # NoOutputTimeout is how long the build may run without writing to its\n" +
+	"        # log before it is considered stalled, cancelled and retried. Defaults\n" +
+	"        # to 20 minutes.\n" +
+	"        no_output_timeout: 0s\n" +
 	"        # Optional means the build step is not built, published, or\n" +
 	"        # promoted unless explicitly targeted. Use for builds which\n" +
 	"        # are invoked only when testing certain parts of the repo.\n" +
 	"        optional: true\n" +
+	"        # ReusePromoted skips the build if the image previously promoted for\n" +
+	"        # this component was built from the same source revisions, build\n" +
+	"        # configuration and pipeline images, and uses the promoted image\n" +
+	"        # instead. Images the Dockerfile pulls from outside of the pipeline\n" +
+	"        # are not considered, so do not use it for builds that depend on them.\n" +
+	"        reuse_promoted: true\n" +
+	"        # RunTimeout bounds how long ci-operator lets the build step run,\n" +
+	"        # including the retries of the build. A step running for longer is\n" +
+	"        # cancelled and fails with a timeout.\n" +
+	"        run_timeout: 0s\n" +
+	"        # Timeout is how long a single attempt of the build may take before\n" +
+	"        # it is cancelled and the step fails.\n" +
+	"        timeout: 0s\n" +
 	"        to: ' '\n" +
 	"      release_images_tag_step:\n" +
 	"        # IncludeBuiltImages determines if the release we assemble will include\n" +
@@ -426,12 +563,19 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"      rpm_serve_step:\n" +
 	"        from: ' '\n" +
 	"      source_step:\n" +
+	"        # Cache clones the source code on top of the clone of the same base\n" +
+	"        # revision cached by an earlier job, see SourceCache.\n" +
+	"        cache: false\n" +
 	"        # ClonerefsImage is the image where we get the clonerefs tool\n" +
 	"        clonerefs_image:\n" +
 	"            # As is an optional string to use as the intermediate name for this reference.\n" +
 	"            as: ' '\n" +
 	"            name: ' '\n" +
 	"            namespace: ' '\n" +
+	"            # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"            # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"            # only supported for base images, and excludes the other fields.\n" +
+	"            pull_spec: ' '\n" +
 	"            tag: ' '\n" +
 	"        # ClonerefsPath is the path in the above image where the\n" +
 	"        # clonerefs tool is placed\n" +
@@ -476,19 +620,94 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # If the step should clone the source code prior to running the command.\n" +
 	"            # Defaults to `true` for `base_images`, `false` otherwise.\n" +
 	"            clone: false\n" +
+	"            # ClusterProfile mounts the credentials of the profile into the test's\n" +
+	"            # Pod at $CLUSTER_PROFILE_DIR and sets $CLUSTER_TYPE for it, and leases\n" +
+	"            # a resource of the profile into $LEASED_RESOURCE, like for the steps of\n" +
+	"            # multi-stage tests.\n" +
+	"            cluster_profile: ' '\n" +
+	"            # DnsConfig for the test's Pod.\n" +
+	"            dnsConfig:\n" +
+	"                # Nameservers is a list of IP addresses that will be used as DNS servers for the Pod\n" +
+	"                nameservers:\n" +
+	"                    - \"\"\n" +
+	"                # Searches is a list of DNS search domains for host-name lookup\n" +
+	"                searches:\n" +
+	"                    - \"\"\n" +
+	"            # Expose makes a port of the test reachable by other tests with a\n" +
+	"            # Service, and optionally a Route, for as long as the test runs.\n" +
+	"            expose:\n" +
+	"                # Port is the port the test listens on.\n" +
+	"                port: 0\n" +
+	"                # Route also exposes the port outside of the cluster with a Route, whose\n" +
+	"                # host is used in the URL.\n" +
+	"                route: true\n" +
 	"            # From is the image stream tag in the pipeline to run this\n" +
 	"            # command in.\n" +
 	"            from: ' '\n" +
+	"            # HostAliases are entries added to the /etc/hosts file of the test's Pod.\n" +
+	"            host_aliases:\n" +
+	"                - # Hostnames are the names resolving to the IP.\n" +
+	"                  hostnames:\n" +
+	"                    - \"\"\n" +
+	"                  # IP is the address the hostnames resolve to.\n" +
+	"                  ip: ' '\n" +
 	"            # MemoryBackedVolume mounts a volume of the specified size into\n" +
 	"            # the container at /tmp/volume.\n" +
 	"            memory_backed_volume:\n" +
 	"                # Size is the requested size of the volume as a Kubernetes\n" +
 	"                # quantity, i.e. \"1Gi\" or \"500M\"\n" +
 	"                size: ' '\n" +
+	"            # RuntimeClass is the name of the RuntimeClass the test's Pod runs with,\n" +
+	"            # like a sandboxed runtime for untrusted code.\n" +
+	"            runtime_class: ' '\n" +
 	"        # Cron is how often the test is expected to run outside\n" +
 	"        # of pull request workflows. Setting this field will\n" +
 	"        # create a periodic job instead of a presubmit\n" +
 	"        cron: \"\"\n" +
+	"        # DedicatedNamespace runs a container or multi-stage test in a\n" +
+	"        # namespace of its own, named after the namespace of the job and the\n" +
+	"        # test, so that heavyweight tests do not share quotas and cleanup with\n" +
+	"        # the rest of the job. The images of the job are pulled from its\n" +
+	"        # namespace.\n" +
+	"        dedicated_namespace:\n" +
+	"            # Quota are the hard limits of the ResourceQuota of the namespace, by\n" +
+	"            # the name of the resource, like requests.cpu or pods. The namespace\n" +
+	"            # has no quota if not set.\n" +
+	"            quota:\n" +
+	"                \"\": \"\"\n" +
+	"        # Gates are conditions on external systems, like an artifact being\n" +
+	"        # published upstream, that must hold before the test starts. They\n" +
+	"        # are checked in order, each until it holds or times out.\n" +
+	"        gates:\n" +
+	"            - # HTTP waits for a URL to answer with a successful status. Only hosts\n" +
+	"              # ci-operator is configured to allow may be requested.\n" +
+	"              http:\n" +
+	"                url: ' '\n" +
+	"              # ImageStreamTag waits for a tag to exist.\n" +
+	"              image_stream_tag:\n" +
+	"                name: ' '\n" +
+	"                # Namespace of the image stream, the test namespace by default.\n" +
+	"                namespace: ' '\n" +
+	"                tag: ' '\n" +
+	"              # Interval is how long to wait before checking the gate again, 10\n" +
+	"              # seconds by default. It doubles after every check, up to 5 minutes.\n" +
+	"              interval: 0s\n" +
+	"              # Resource waits for a condition of a resource in the cluster to be True.\n" +
+	"              resource:\n" +
+	"                api_version: ' '\n" +
+	"                # ClusterScoped must be set for resources that are not namespaced, in\n" +
+	"                # which case Namespace must be empty. Cluster-scoped resources must be\n" +
+	"                # allowed by ci-operator.\n" +
+	"                cluster_scoped: true\n" +
+	"                # Condition is the type of the condition, like Available.\n" +
+	"                condition: ' '\n" +
+	"                kind: ' '\n" +
+	"                name: ' '\n" +
+	"                # Namespace of the resource, the test namespace by default. Other\n" +
+	"                # namespaces must be allowed by ci-operator.\n" +
+	"                namespace: ' '\n" +
+	"              # Timeout bounds how long to wait for the gate, 30 minutes by default.\n" +
+	"              timeout: 0s\n" +
 	"        # Interval is how frequently the test should be run based\n" +
 	"        # on the last time the test ran. Setting this field will\n" +
 	"        # create a periodic job instead of a presubmit\n" +
@@ -540,6 +759,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    as: ' '\n" +
 	"                    name: ' '\n" +
 	"                    namespace: ' '\n" +
+	"                    # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                    # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                    # only supported for base images, and excludes the other fields.\n" +
+	"                    pull_spec: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"                  # SIGKILL when aborting this observer.\n" +
@@ -612,10 +835,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    as: ' '\n" +
 	"                    name: ' '\n" +
 	"                    namespace: ' '\n" +
+	"                    # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                    # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                    # only supported for base images, and excludes the other fields.\n" +
+	"                    pull_spec: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"                  # SIGKILL when aborting a Step.\n" +
 	"                  grace_period: 0s\n" +
+	"                  # HostAliases are entries added to the /etc/hosts file of the step's Pod.\n" +
+	"                  host_aliases:\n" +
+	"                    - # Hostnames are the names resolving to the IP.\n" +
+	"                      hostnames:\n" +
+	"                        - \"\"\n" +
+	"                      # IP is the address the hostnames resolve to.\n" +
+	"                      ip: ' '\n" +
 	"                  # Leases lists resources that should be acquired for the test.\n" +
 	"                  leases:\n" +
 	"                    - # Env is the environment variable that will contain the resource name.\n" +
@@ -634,6 +868,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Outputs are the names of values the step computes for the steps after\n" +
+	"                  # it, like the URL of a cluster it provisioned. The step writes them as\n" +
+	"                  # NAME=value lines to ${SHARED_DIR}/outputs.env. Once it succeeds, they\n" +
+	"                  # are stored in a secret, set as <STEP>_<NAME> environment variables of\n" +
+	"                  # the following steps of the test from it and provided as\n" +
+	"                  # <TEST>_<STEP>_<NAME> parameters of the test.\n" +
+	"                  outputs:\n" +
+	"                    - \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -647,6 +889,43 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIf determines the outcome of the `pre` and `test` steps this\n" +
+	"                  # step runs for: `success`, `failure` or `always`, the default. This\n" +
+	"                  # option is applicable to `post` steps.\n" +
+	"                  run_if: ' '\n" +
+	"                  # RuntimeClass is the name of the RuntimeClass the step's Pod runs with,\n" +
+	"                  # like a sandboxed runtime for untrusted code.\n" +
+	"                  runtime_class: ' '\n" +
+	"                  # Sidecars are containers run next to the test container of the step, in\n" +
+	"                  # the same pod, e.g. a database the test reaches on localhost. The step\n" +
+	"                  # succeeds or fails with the test container, and the sidecars are stopped\n" +
+	"                  # once it exits.\n" +
+	"                  sidecars:\n" +
+	"                    - # Commands is the command(s) run in the container with /bin/sh.\n" +
+	"                      commands: ' '\n" +
+	"                      # Environment are the environment variables of the container.\n" +
+	"                      env:\n" +
+	"                        \"\": \"\"\n" +
+	"                      # From is the image the container runs, resolved like the image of the step.\n" +
+	"                      from: ' '\n" +
+	"                      # Name is the name of the container, unique in the step.\n" +
+	"                      name: ' '\n" +
+	"                      # Ports are the ports the container listens on.\n" +
+	"                      ports:\n" +
+	"                        - 0\n" +
+	"                      # Readiness is a command run in the container until it succeeds; the\n" +
+	"                      # commands of the step only start once it does.\n" +
+	"                      readiness: ' '\n" +
+	"                      # Resources defines the resource requirements of the container.\n" +
+	"                      resources:\n" +
+	"                        # Limits are resource limits applied to an individual step in the job.\n" +
+	"                        # These are directly used in creating the Pods that execute the Job.\n" +
+	"                        limits:\n" +
+	"                            \"\": \"\"\n" +
+	"                        # Requests are resource requests applied to an individual step in the job.\n" +
+	"                        # These are directly used in creating the Pods that execute the Job.\n" +
+	"                        requests:\n" +
+	"                            \"\": \"\"\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
@@ -702,10 +981,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    as: ' '\n" +
 	"                    name: ' '\n" +
 	"                    namespace: ' '\n" +
+	"                    # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                    # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                    # only supported for base images, and excludes the other fields.\n" +
+	"                    pull_spec: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"                  # SIGKILL when aborting a Step.\n" +
 	"                  grace_period: 0s\n" +
+	"                  # HostAliases are entries added to the /etc/hosts file of the step's Pod.\n" +
+	"                  host_aliases:\n" +
+	"                    - # Hostnames are the names resolving to the IP.\n" +
+	"                      hostnames:\n" +
+	"                        - \"\"\n" +
+	"                      # IP is the address the hostnames resolve to.\n" +
+	"                      ip: ' '\n" +
 	"                  # Leases lists resources that should be acquired for the test.\n" +
 	"                  leases:\n" +
 	"                    - # Env is the environment variable that will contain the resource name.\n" +
@@ -724,6 +1014,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Outputs are the names of values the step computes for the steps after\n" +
+	"                  # it, like the URL of a cluster it provisioned. The step writes them as\n" +
+	"                  # NAME=value lines to ${SHARED_DIR}/outputs.env. Once it succeeds, they\n" +
+	"                  # are stored in a secret, set as <STEP>_<NAME> environment variables of\n" +
+	"                  # the following steps of the test from it and provided as\n" +
+	"                  # <TEST>_<STEP>_<NAME> parameters of the test.\n" +
+	"                  outputs:\n" +
+	"                    - \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -737,8 +1035,54 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIf determines the outcome of the `pre` and `test` steps this\n" +
+	"                  # step runs for: `success`, `failure` or `always`, the default. This\n" +
+	"                  # option is applicable to `post` steps.\n" +
+	"                  run_if: ' '\n" +
+	"                  # RuntimeClass is the name of the RuntimeClass the step's Pod runs with,\n" +
+	"                  # like a sandboxed runtime for untrusted code.\n" +
+	"                  runtime_class: ' '\n" +
+	"                  # Sidecars are containers run next to the test container of the step, in\n" +
+	"                  # the same pod, e.g. a database the test reaches on localhost. The step\n" +
+	"                  # succeeds or fails with the test container, and the sidecars are stopped\n" +
+	"                  # once it exits.\n" +
+	"                  sidecars:\n" +
+	"                    - # Commands is the command(s) run in the container with /bin/sh.\n" +
+	"                      commands: ' '\n" +
+	"                      # Environment are the environment variables of the container.\n" +
+	"                      env:\n" +
+	"                        \"\": \"\"\n" +
+	"                      # From is the image the container runs, resolved like the image of the step.\n" +
+	"                      from: ' '\n" +
+	"                      # Name is the name of the container, unique in the step.\n" +
+	"                      name: ' '\n" +
+	"                      # Ports are the ports the container listens on.\n" +
+	"                      ports:\n" +
+	"                        - 0\n" +
+	"                      # Readiness is a command run in the container until it succeeds; the\n" +
+	"                      # commands of the step only start once it does.\n" +
+	"                      readiness: ' '\n" +
+	"                      # Resources defines the resource requirements of the container.\n" +
+	"                      resources:\n" +
+	"                        # Limits are resource limits applied to an individual step in the job.\n" +
+	"                        # These are directly used in creating the Pods that execute the Job.\n" +
+	"                        limits:\n" +
+	"                            \"\": \"\"\n" +
+	"                        # Requests are resource requests applied to an individual step in the job.\n" +
+	"                        # These are directly used in creating the Pods that execute the Job.\n" +
+	"                        requests:\n" +
+	"                            \"\": \"\"\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"            # SharedVolume is a volume mounted into every step of the test, for data\n" +
+	"            # the steps pass on that does not fit into SHARED_DIR.\n" +
+	"            shared_volume:\n" +
+	"                # Size is the requested size of the volume as a Kubernetes quantity,\n" +
+	"                # like 10Gi.\n" +
+	"                size: ' '\n" +
+	"                # StorageClass is the storage class of the volume, the default storage\n" +
+	"                # class of the cluster if not set.\n" +
+	"                storage_class: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                - # As is the name of the LiteralTestStep.\n" +
@@ -792,10 +1136,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    as: ' '\n" +
 	"                    name: ' '\n" +
 	"                    namespace: ' '\n" +
+	"                    # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                    # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                    # only supported for base images, and excludes the other fields.\n" +
+	"                    pull_spec: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"                  # SIGKILL when aborting a Step.\n" +
 	"                  grace_period: 0s\n" +
+	"                  # HostAliases are entries added to the /etc/hosts file of the step's Pod.\n" +
+	"                  host_aliases:\n" +
+	"                    - # Hostnames are the names resolving to the IP.\n" +
+	"                      hostnames:\n" +
+	"                        - \"\"\n" +
+	"                      # IP is the address the hostnames resolve to.\n" +
+	"                      ip: ' '\n" +
 	"                  # Leases lists resources that should be acquired for the test.\n" +
 	"                  leases:\n" +
 	"                    - # Env is the environment variable that will contain the resource name.\n" +
@@ -814,6 +1169,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Outputs are the names of values the step computes for the steps after\n" +
+	"                  # it, like the URL of a cluster it provisioned. The step writes them as\n" +
+	"                  # NAME=value lines to ${SHARED_DIR}/outputs.env. Once it succeeds, they\n" +
+	"                  # are stored in a secret, set as <STEP>_<NAME> environment variables of\n" +
+	"                  # the following steps of the test from it and provided as\n" +
+	"                  # <TEST>_<STEP>_<NAME> parameters of the test.\n" +
+	"                  outputs:\n" +
+	"                    - \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -827,10 +1190,64 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
+	"                  # RunIf determines the outcome of the `pre` and `test` steps this\n" +
+	"                  # step runs for: `success`, `failure` or `always`, the default. This\n" +
+	"                  # option is applicable to `post` steps.\n" +
+	"                  run_if: ' '\n" +
+	"                  # RuntimeClass is the name of the RuntimeClass the step's Pod runs with,\n" +
+	"                  # like a sandboxed runtime for untrusted code.\n" +
+	"                  runtime_class: ' '\n" +
+	"                  # Sidecars are containers run next to the test container of the step, in\n" +
+	"                  # the same pod, e.g. a database the test reaches on localhost. The step\n" +
+	"                  # succeeds or fails with the test container, and the sidecars are stopped\n" +
+	"                  # once it exits.\n" +
+	"                  sidecars:\n" +
+	"                    - # Commands is the command(s) run in the container with /bin/sh.\n" +
+	"                      commands: ' '\n" +
+	"                      # Environment are the environment variables of the container.\n" +
+	"                      env:\n" +
+	"                        \"\": \"\"\n" +
+	"                      # From is the image the container runs, resolved like the image of the step.\n" +
+	"                      from: ' '\n" +
+	"                      # Name is the name of the container, unique in the step.\n" +
+	"                      name: ' '\n" +
+	"                      # Ports are the ports the container listens on.\n" +
+	"                      ports:\n" +
+	"                        - 0\n" +
+	"                      # Readiness is a command run in the container until it succeeds; the\n" +
+	"                      # commands of the step only start once it does.\n" +
+	"                      readiness: ' '\n" +
+	"                      # Resources defines the resource requirements of the container.\n" +
+	"                      resources:\n" +
+	"                        # Limits are resource limits applied to an individual step in the job.\n" +
+	"                        # These are directly used in creating the Pods that execute the Job.\n" +
+	"                        limits:\n" +
+	"                            \"\": \"\"\n" +
+	"                        # Requests are resource requests applied to an individual step in the job.\n" +
+	"                        # These are directly used in creating the Pods that execute the Job.\n" +
+	"                        requests:\n" +
+	"                            \"\": \"\"\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
 	"            # Override job timeout\n" +
 	"            timeout: 0s\n" +
+	"        manifests:\n" +
+	"            # Objects are the manifests applied to the test namespace, in order.\n" +
+	"            # They must not set their namespace.\n" +
+	"            objects:\n" +
+	"                - # File is the path of a file holding the object, relative to the\n" +
+	"                  # directory ci-operator runs in, which is usually the source under test.\n" +
+	"                  file: ' '\n" +
+	"                  # Manifest is the object, as YAML or JSON.\n" +
+	"                  manifest: ' '\n" +
+	"            # WaitFor is the Pod or Job of the manifests the test waits for. The\n" +
+	"            # logs of the Pod, or of every Pod of the Job, are gathered, along with\n" +
+	"            # the artifacts of those with an `artifacts` volume.\n" +
+	"            wait_for:\n" +
+	"                # Kind is the kind of the object, either Pod or Job.\n" +
+	"                kind: ' '\n" +
+	"                # Name is the name of the object.\n" +
+	"                name: ' '\n" +
 	"        # MinimumInterval to wait between two runs of the job. Consecutive\n" +
 	"        # jobs are run at `minimum_interval` + `duration of previous job`\n" +
 	"        # apart. Setting this field will create a periodic job instead of a\n" +
@@ -844,6 +1261,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            cluster_profile: ' '\n" +
 	"        openshift_installer:\n" +
 	"            cluster_profile: ' '\n" +
+	"            # FromRelease is the name of the release that is installed before\n" +
+	"            # upgrading to the release built by the job. Defaults to `initial`\n" +
+	"            # and may only be set for upgrade tests.\n" +
+	"            from_release: ' '\n" +
 	"            # If upgrade is true, RELEASE_IMAGE_INITIAL will be used as\n" +
 	"            # the initial payload and the installer image from that\n" +
 	"            # will be upgraded. The `run-upgrade-tests` function will be\n" +
@@ -871,6 +1292,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        release_controller: true\n" +
 	"        # RunIfChanged is a regex that will result in the test only running if something that matches it was changed.\n" +
 	"        run_if_changed: ' '\n" +
+	"        # RunTimeout bounds how long ci-operator lets a container or multi-stage\n" +
+	"        # test run, not counting the time spent acquiring its leases, claiming\n" +
+	"        # its cluster or waiting for its gates. Tests running for longer are\n" +
+	"        # cancelled, which still runs their post steps and gathers their\n" +
+	"        # artifacts.\n" +
+	"        run_timeout: 0s\n" +
 	"        # Secret is an optional secret object which\n" +
 	"        # will be mounted inside the test container.\n" +
 	"        # You cannot set the Secret and Secrets attributes\n" +
@@ -977,8 +1404,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    as: ' '\n" +
 	"                    name: ' '\n" +
 	"                    namespace: ' '\n" +
+	"                    pull_spec: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  grace_period: 0s\n" +
+	"                  host_aliases:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - hostnames:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      ip: ' '\n" +
 	"                  leases:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - env: ' '\n" +
@@ -988,6 +1422,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  outputs:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1000,6 +1437,28 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
 	"                  run_as_script: false\n" +
+	"                  run_if: ' '\n" +
+	"                  runtime_class: ' '\n" +
+	"                  sidecars:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - commands: ' '\n" +
+	"                      env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                      from: ' '\n" +
+	"                      name: ' '\n" +
+	"                      ports:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                      readiness: ' '\n" +
+	"                      resources:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        limits:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                        requests:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
 	"                  timeout: 0s\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
@@ -1040,8 +1499,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    as: ' '\n" +
 	"                    name: ' '\n" +
 	"                    namespace: ' '\n" +
+	"                    pull_spec: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  grace_period: 0s\n" +
+	"                  host_aliases:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - hostnames:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      ip: ' '\n" +
 	"                  leases:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - env: ' '\n" +
@@ -1051,6 +1517,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  outputs:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1063,7 +1532,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
 	"                  run_as_script: false\n" +
+	"                  run_if: ' '\n" +
+	"                  runtime_class: ' '\n" +
+	"                  sidecars:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - commands: ' '\n" +
+	"                      env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                      from: ' '\n" +
+	"                      name: ' '\n" +
+	"                      ports:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                      readiness: ' '\n" +
+	"                      resources:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        limits:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                        requests:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
 	"                  timeout: 0s\n" +
+	"            # SharedVolume is a volume mounted into every step of the test, for data\n" +
+	"            # the steps pass on that does not fit into SHARED_DIR.\n" +
+	"            shared_volume:\n" +
+	"                # Size is the requested size of the volume as a Kubernetes quantity,\n" +
+	"                # like 10Gi.\n" +
+	"                size: ' '\n" +
+	"                # StorageClass is the storage class of the volume, the default storage\n" +
+	"                # class of the cluster if not set.\n" +
+	"                storage_class: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1103,8 +1603,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    as: ' '\n" +
 	"                    name: ' '\n" +
 	"                    namespace: ' '\n" +
+	"                    pull_spec: ' '\n" +
 	"                    tag: ' '\n" +
 	"                  grace_period: 0s\n" +
+	"                  host_aliases:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - hostnames:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      ip: ' '\n" +
 	"                  leases:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - env: ' '\n" +
@@ -1114,6 +1621,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  outputs:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1126,6 +1636,28 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
 	"                  run_as_script: false\n" +
+	"                  run_if: ' '\n" +
+	"                  runtime_class: ' '\n" +
+	"                  sidecars:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - commands: ' '\n" +
+	"                      env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                      from: ' '\n" +
+	"                      name: ' '\n" +
+	"                      ports:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                      readiness: ' '\n" +
+	"                      resources:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        limits:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                        requests:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
 	"                  timeout: 0s\n" +
 	"            # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"            # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
@@ -1195,6 +1727,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"# unset, this will default under the repository root to\n" +
 	"# _output/local/releases/rpms/.\n" +
 	"rpm_build_location: ' '\n" +
+	"# SourceCache caches the clone of the base revision of the repository\n" +
+	"# in an image stream of the base namespace of ci-operator, shared by\n" +
+	"# the jobs of the repository. Jobs that do not test pull requests add\n" +
+	"# the clone to the cache and jobs of the same base revision clone their\n" +
+	"# changes on top of it, as long as the build root image is the same.\n" +
+	"# The clones of the five most recently cached base revisions are kept.\n" +
+	"source_cache: false\n" +
 	"# ReleaseTagConfiguration determines how the\n" +
 	"# full release is assembled.\n" +
 	"tag_specification:\n" +
@@ -1253,19 +1792,94 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # If the step should clone the source code prior to running the command.\n" +
 	"        # Defaults to `true` for `base_images`, `false` otherwise.\n" +
 	"        clone: false\n" +
+	"        # ClusterProfile mounts the credentials of the profile into the test's\n" +
+	"        # Pod at $CLUSTER_PROFILE_DIR and sets $CLUSTER_TYPE for it, and leases\n" +
+	"        # a resource of the profile into $LEASED_RESOURCE, like for the steps of\n" +
+	"        # multi-stage tests.\n" +
+	"        cluster_profile: ' '\n" +
+	"        # DnsConfig for the test's Pod.\n" +
+	"        dnsConfig:\n" +
+	"            # Nameservers is a list of IP addresses that will be used as DNS servers for the Pod\n" +
+	"            nameservers:\n" +
+	"                - \"\"\n" +
+	"            # Searches is a list of DNS search domains for host-name lookup\n" +
+	"            searches:\n" +
+	"                - \"\"\n" +
+	"        # Expose makes a port of the test reachable by other tests with a\n" +
+	"        # Service, and optionally a Route, for as long as the test runs.\n" +
+	"        expose:\n" +
+	"            # Port is the port the test listens on.\n" +
+	"            port: 0\n" +
+	"            # Route also exposes the port outside of the cluster with a Route, whose\n" +
+	"            # host is used in the URL.\n" +
+	"            route: true\n" +
 	"        # From is the image stream tag in the pipeline to run this\n" +
 	"        # command in.\n" +
 	"        from: ' '\n" +
+	"        # HostAliases are entries added to the /etc/hosts file of the test's Pod.\n" +
+	"        host_aliases:\n" +
+	"            - # Hostnames are the names resolving to the IP.\n" +
+	"              hostnames:\n" +
+	"                - \"\"\n" +
+	"              # IP is the address the hostnames resolve to.\n" +
+	"              ip: ' '\n" +
 	"        # MemoryBackedVolume mounts a volume of the specified size into\n" +
 	"        # the container at /tmp/volume.\n" +
 	"        memory_backed_volume:\n" +
 	"            # Size is the requested size of the volume as a Kubernetes\n" +
 	"            # quantity, i.e. \"1Gi\" or \"500M\"\n" +
 	"            size: ' '\n" +
+	"        # RuntimeClass is the name of the RuntimeClass the test's Pod runs with,\n" +
+	"        # like a sandboxed runtime for untrusted code.\n" +
+	"        runtime_class: ' '\n" +
 	"      # Cron is how often the test is expected to run outside\n" +
 	"      # of pull request workflows. Setting this field will\n" +
 	"      # create a periodic job instead of a presubmit\n" +
 	"      cron: \"\"\n" +
+	"      # DedicatedNamespace runs a container or multi-stage test in a\n" +
+	"      # namespace of its own, named after the namespace of the job and the\n" +
+	"      # test, so that heavyweight tests do not share quotas and cleanup with\n" +
+	"      # the rest of the job. The images of the job are pulled from its\n" +
+	"      # namespace.\n" +
+	"      dedicated_namespace:\n" +
+	"          # Quota are the hard limits of the ResourceQuota of the namespace, by\n" +
+	"          # the name of the resource, like requests.cpu or pods. The namespace\n" +
+	"          # has no quota if not set.\n" +
+	"          quota:\n" +
+	"              \"\": \"\"\n" +
+	"      # Gates are conditions on external systems, like an artifact being\n" +
+	"      # published upstream, that must hold before the test starts. They\n" +
+	"      # are checked in order, each until it holds or times out.\n" +
+	"      gates:\n" +
+	"        - # HTTP waits for a URL to answer with a successful status. Only hosts\n" +
+	"          # ci-operator is configured to allow may be requested.\n" +
+	"          http:\n" +
+	"            url: ' '\n" +
+	"          # ImageStreamTag waits for a tag to exist.\n" +
+	"          image_stream_tag:\n" +
+	"            name: ' '\n" +
+	"            # Namespace of the image stream, the test namespace by default.\n" +
+	"            namespace: ' '\n" +
+	"            tag: ' '\n" +
+	"          # Interval is how long to wait before checking the gate again, 10\n" +
+	"          # seconds by default. It doubles after every check, up to 5 minutes.\n" +
+	"          interval: 0s\n" +
+	"          # Resource waits for a condition of a resource in the cluster to be True.\n" +
+	"          resource:\n" +
+	"            api_version: ' '\n" +
+	"            # ClusterScoped must be set for resources that are not namespaced, in\n" +
+	"            # which case Namespace must be empty. Cluster-scoped resources must be\n" +
+	"            # allowed by ci-operator.\n" +
+	"            cluster_scoped: true\n" +
+	"            # Condition is the type of the condition, like Available.\n" +
+	"            condition: ' '\n" +
+	"            kind: ' '\n" +
+	"            name: ' '\n" +
+	"            # Namespace of the resource, the test namespace by default. Other\n" +
+	"            # namespaces must be allowed by ci-operator.\n" +
+	"            namespace: ' '\n" +
+	"          # Timeout bounds how long to wait for the gate, 30 minutes by default.\n" +
+	"          timeout: 0s\n" +
 	"      # Interval is how frequently the test should be run based\n" +
 	"      # on the last time the test ran. Setting this field will\n" +
 	"      # create a periodic job instead of a presubmit\n" +
@@ -1317,6 +1931,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                as: ' '\n" +
 	"                name: ' '\n" +
 	"                namespace: ' '\n" +
+	"                # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                # only supported for base images, and excludes the other fields.\n" +
+	"                pull_spec: ' '\n" +
 	"                tag: ' '\n" +
 	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"              # SIGKILL when aborting this observer.\n" +
@@ -1389,10 +2007,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                as: ' '\n" +
 	"                name: ' '\n" +
 	"                namespace: ' '\n" +
+	"                # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                # only supported for base images, and excludes the other fields.\n" +
+	"                pull_spec: ' '\n" +
 	"                tag: ' '\n" +
 	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"              # SIGKILL when aborting a Step.\n" +
 	"              grace_period: 0s\n" +
+	"              # HostAliases are entries added to the /etc/hosts file of the step's Pod.\n" +
+	"              host_aliases:\n" +
+	"                - # Hostnames are the names resolving to the IP.\n" +
+	"                  hostnames:\n" +
+	"                    - \"\"\n" +
+	"                  # IP is the address the hostnames resolve to.\n" +
+	"                  ip: ' '\n" +
 	"              # Leases lists resources that should be acquired for the test.\n" +
 	"              leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
@@ -1411,6 +2040,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # Outputs are the names of values the step computes for the steps after\n" +
+	"              # it, like the URL of a cluster it provisioned. The step writes them as\n" +
+	"              # NAME=value lines to ${SHARED_DIR}/outputs.env. Once it succeeds, they\n" +
+	"              # are stored in a secret, set as <STEP>_<NAME> environment variables of\n" +
+	"              # the following steps of the test from it and provided as\n" +
+	"              # <TEST>_<STEP>_<NAME> parameters of the test.\n" +
+	"              outputs:\n" +
+	"                - \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1424,6 +2061,43 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
+	"              # RunIf determines the outcome of the `pre` and `test` steps this\n" +
+	"              # step runs for: `success`, `failure` or `always`, the default. This\n" +
+	"              # option is applicable to `post` steps.\n" +
+	"              run_if: ' '\n" +
+	"              # RuntimeClass is the name of the RuntimeClass the step's Pod runs with,\n" +
+	"              # like a sandboxed runtime for untrusted code.\n" +
+	"              runtime_class: ' '\n" +
+	"              # Sidecars are containers run next to the test container of the step, in\n" +
+	"              # the same pod, e.g. a database the test reaches on localhost. The step\n" +
+	"              # succeeds or fails with the test container, and the sidecars are stopped\n" +
+	"              # once it exits.\n" +
+	"              sidecars:\n" +
+	"                - # Commands is the command(s) run in the container with /bin/sh.\n" +
+	"                  commands: ' '\n" +
+	"                  # Environment are the environment variables of the container.\n" +
+	"                  env:\n" +
+	"                    \"\": \"\"\n" +
+	"                  # From is the image the container runs, resolved like the image of the step.\n" +
+	"                  from: ' '\n" +
+	"                  # Name is the name of the container, unique in the step.\n" +
+	"                  name: ' '\n" +
+	"                  # Ports are the ports the container listens on.\n" +
+	"                  ports:\n" +
+	"                    - 0\n" +
+	"                  # Readiness is a command run in the container until it succeeds; the\n" +
+	"                  # commands of the step only start once it does.\n" +
+	"                  readiness: ' '\n" +
+	"                  # Resources defines the resource requirements of the container.\n" +
+	"                  resources:\n" +
+	"                    # Limits are resource limits applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    limits:\n" +
+	"                        \"\": \"\"\n" +
+	"                    # Requests are resource requests applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    requests:\n" +
+	"                        \"\": \"\"\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
@@ -1479,10 +2153,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                as: ' '\n" +
 	"                name: ' '\n" +
 	"                namespace: ' '\n" +
+	"                # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                # only supported for base images, and excludes the other fields.\n" +
+	"                pull_spec: ' '\n" +
 	"                tag: ' '\n" +
 	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"              # SIGKILL when aborting a Step.\n" +
 	"              grace_period: 0s\n" +
+	"              # HostAliases are entries added to the /etc/hosts file of the step's Pod.\n" +
+	"              host_aliases:\n" +
+	"                - # Hostnames are the names resolving to the IP.\n" +
+	"                  hostnames:\n" +
+	"                    - \"\"\n" +
+	"                  # IP is the address the hostnames resolve to.\n" +
+	"                  ip: ' '\n" +
 	"              # Leases lists resources that should be acquired for the test.\n" +
 	"              leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
@@ -1501,6 +2186,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # Outputs are the names of values the step computes for the steps after\n" +
+	"              # it, like the URL of a cluster it provisioned. The step writes them as\n" +
+	"              # NAME=value lines to ${SHARED_DIR}/outputs.env. Once it succeeds, they\n" +
+	"              # are stored in a secret, set as <STEP>_<NAME> environment variables of\n" +
+	"              # the following steps of the test from it and provided as\n" +
+	"              # <TEST>_<STEP>_<NAME> parameters of the test.\n" +
+	"              outputs:\n" +
+	"                - \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1514,8 +2207,54 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
+	"              # RunIf determines the outcome of the `pre` and `test` steps this\n" +
+	"              # step runs for: `success`, `failure` or `always`, the default. This\n" +
+	"              # option is applicable to `post` steps.\n" +
+	"              run_if: ' '\n" +
+	"              # RuntimeClass is the name of the RuntimeClass the step's Pod runs with,\n" +
+	"              # like a sandboxed runtime for untrusted code.\n" +
+	"              runtime_class: ' '\n" +
+	"              # Sidecars are containers run next to the test container of the step, in\n" +
+	"              # the same pod, e.g. a database the test reaches on localhost. The step\n" +
+	"              # succeeds or fails with the test container, and the sidecars are stopped\n" +
+	"              # once it exits.\n" +
+	"              sidecars:\n" +
+	"                - # Commands is the command(s) run in the container with /bin/sh.\n" +
+	"                  commands: ' '\n" +
+	"                  # Environment are the environment variables of the container.\n" +
+	"                  env:\n" +
+	"                    \"\": \"\"\n" +
+	"                  # From is the image the container runs, resolved like the image of the step.\n" +
+	"                  from: ' '\n" +
+	"                  # Name is the name of the container, unique in the step.\n" +
+	"                  name: ' '\n" +
+	"                  # Ports are the ports the container listens on.\n" +
+	"                  ports:\n" +
+	"                    - 0\n" +
+	"                  # Readiness is a command run in the container until it succeeds; the\n" +
+	"                  # commands of the step only start once it does.\n" +
+	"                  readiness: ' '\n" +
+	"                  # Resources defines the resource requirements of the container.\n" +
+	"                  resources:\n" +
+	"                    # Limits are resource limits applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    limits:\n" +
+	"                        \"\": \"\"\n" +
+	"                    # Requests are resource requests applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    requests:\n" +
+	"                        \"\": \"\"\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"        # SharedVolume is a volume mounted into every step of the test, for data\n" +
+	"        # the steps pass on that does not fit into SHARED_DIR.\n" +
+	"        shared_volume:\n" +
+	"            # Size is the requested size of the volume as a Kubernetes quantity,\n" +
+	"            # like 10Gi.\n" +
+	"            size: ' '\n" +
+	"            # StorageClass is the storage class of the volume, the default storage\n" +
+	"            # class of the cluster if not set.\n" +
+	"            storage_class: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            - # As is the name of the LiteralTestStep.\n" +
@@ -1569,10 +2308,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                as: ' '\n" +
 	"                name: ' '\n" +
 	"                namespace: ' '\n" +
+	"                # PullSpec is the pull spec of an image in an external registry, e.g.\n" +
+	"                # quay.io/org/repo:tag, to import instead of an image stream tag. It is\n" +
+	"                # only supported for base images, and excludes the other fields.\n" +
+	"                pull_spec: ' '\n" +
 	"                tag: ' '\n" +
 	"              # GracePeriod is how long the we will wait after sending SIGINT to send\n" +
 	"              # SIGKILL when aborting a Step.\n" +
 	"              grace_period: 0s\n" +
+	"              # HostAliases are entries added to the /etc/hosts file of the step's Pod.\n" +
+	"              host_aliases:\n" +
+	"                - # Hostnames are the names resolving to the IP.\n" +
+	"                  hostnames:\n" +
+	"                    - \"\"\n" +
+	"                  # IP is the address the hostnames resolve to.\n" +
+	"                  ip: ' '\n" +
 	"              # Leases lists resources that should be acquired for the test.\n" +
 	"              leases:\n" +
 	"                - # Env is the environment variable that will contain the resource name.\n" +
@@ -1591,6 +2341,14 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # Outputs are the names of values the step computes for the steps after\n" +
+	"              # it, like the URL of a cluster it provisioned. The step writes them as\n" +
+	"              # NAME=value lines to ${SHARED_DIR}/outputs.env. Once it succeeds, they\n" +
+	"              # are stored in a secret, set as <STEP>_<NAME> environment variables of\n" +
+	"              # the following steps of the test from it and provided as\n" +
+	"              # <TEST>_<STEP>_<NAME> parameters of the test.\n" +
+	"              outputs:\n" +
+	"                - \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1604,10 +2362,64 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
+	"              # RunIf determines the outcome of the `pre` and `test` steps this\n" +
+	"              # step runs for: `success`, `failure` or `always`, the default. This\n" +
+	"              # option is applicable to `post` steps.\n" +
+	"              run_if: ' '\n" +
+	"              # RuntimeClass is the name of the RuntimeClass the step's Pod runs with,\n" +
+	"              # like a sandboxed runtime for untrusted code.\n" +
+	"              runtime_class: ' '\n" +
+	"              # Sidecars are containers run next to the test container of the step, in\n" +
+	"              # the same pod, e.g. a database the test reaches on localhost. The step\n" +
+	"              # succeeds or fails with the test container, and the sidecars are stopped\n" +
+	"              # once it exits.\n" +
+	"              sidecars:\n" +
+	"                - # Commands is the command(s) run in the container with /bin/sh.\n" +
+	"                  commands: ' '\n" +
+	"                  # Environment are the environment variables of the container.\n" +
+	"                  env:\n" +
+	"                    \"\": \"\"\n" +
+	"                  # From is the image the container runs, resolved like the image of the step.\n" +
+	"                  from: ' '\n" +
+	"                  # Name is the name of the container, unique in the step.\n" +
+	"                  name: ' '\n" +
+	"                  # Ports are the ports the container listens on.\n" +
+	"                  ports:\n" +
+	"                    - 0\n" +
+	"                  # Readiness is a command run in the container until it succeeds; the\n" +
+	"                  # commands of the step only start once it does.\n" +
+	"                  readiness: ' '\n" +
+	"                  # Resources defines the resource requirements of the container.\n" +
+	"                  resources:\n" +
+	"                    # Limits are resource limits applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    limits:\n" +
+	"                        \"\": \"\"\n" +
+	"                    # Requests are resource requests applied to an individual step in the job.\n" +
+	"                    # These are directly used in creating the Pods that execute the Job.\n" +
+	"                    requests:\n" +
+	"                        \"\": \"\"\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
 	"        # Override job timeout\n" +
 	"        timeout: 0s\n" +
+	"      manifests:\n" +
+	"        # Objects are the manifests applied to the test namespace, in order.\n" +
+	"        # They must not set their namespace.\n" +
+	"        objects:\n" +
+	"            - # File is the path of a file holding the object, relative to the\n" +
+	"              # directory ci-operator runs in, which is usually the source under test.\n" +
+	"              file: ' '\n" +
+	"              # Manifest is the object, as YAML or JSON.\n" +
+	"              manifest: ' '\n" +
+	"        # WaitFor is the Pod or Job of the manifests the test waits for. The\n" +
+	"        # logs of the Pod, or of every Pod of the Job, are gathered, along with\n" +
+	"        # the artifacts of those with an `artifacts` volume.\n" +
+	"        wait_for:\n" +
+	"            # Kind is the kind of the object, either Pod or Job.\n" +
+	"            kind: ' '\n" +
+	"            # Name is the name of the object.\n" +
+	"            name: ' '\n" +
 	"      # MinimumInterval to wait between two runs of the job. Consecutive\n" +
 	"      # jobs are run at `minimum_interval` + `duration of previous job`\n" +
 	"      # apart. Setting this field will create a periodic job instead of a\n" +
@@ -1621,6 +2433,10 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        cluster_profile: ' '\n" +
 	"      openshift_installer:\n" +
 	"        cluster_profile: ' '\n" +
+	"        # FromRelease is the name of the release that is installed before\n" +
+	"        # upgrading to the release built by the job. Defaults to `initial`\n" +
+	"        # and may only be set for upgrade tests.\n" +
+	"        from_release: ' '\n" +
 	"        # If upgrade is true, RELEASE_IMAGE_INITIAL will be used as\n" +
 	"        # the initial payload and the installer image from that\n" +
 	"        # will be upgraded. The `run-upgrade-tests` function will be\n" +
@@ -1648,6 +2464,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"      release_controller: true\n" +
 	"      # RunIfChanged is a regex that will result in the test only running if something that matches it was changed.\n" +
 	"      run_if_changed: ' '\n" +
+	"      # RunTimeout bounds how long ci-operator lets a container or multi-stage\n" +
+	"      # test run, not counting the time spent acquiring its leases, claiming\n" +
+	"      # its cluster or waiting for its gates. Tests running for longer are\n" +
+	"      # cancelled, which still runs their post steps and gathers their\n" +
+	"      # artifacts.\n" +
+	"      run_timeout: 0s\n" +
 	"      # Secret is an optional secret object which\n" +
 	"      # will be mounted inside the test container.\n" +
 	"      # You cannot set the Secret and Secrets attributes\n" +
@@ -1754,8 +2576,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                as: ' '\n" +
 	"                name: ' '\n" +
 	"                namespace: ' '\n" +
+	"                pull_spec: ' '\n" +
 	"                tag: ' '\n" +
 	"              grace_period: 0s\n" +
+	"              host_aliases:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - hostnames:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  ip: ' '\n" +
 	"              leases:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - env: ' '\n" +
@@ -1765,6 +2594,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              outputs:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1777,6 +2609,28 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
 	"              run_as_script: false\n" +
+	"              run_if: ' '\n" +
+	"              runtime_class: ' '\n" +
+	"              sidecars:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - commands: ' '\n" +
+	"                  env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
+	"                  from: ' '\n" +
+	"                  name: ' '\n" +
+	"                  ports:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                  readiness: ' '\n" +
+	"                  resources:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    limits:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                    requests:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
 	"              timeout: 0s\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
@@ -1817,8 +2671,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                as: ' '\n" +
 	"                name: ' '\n" +
 	"                namespace: ' '\n" +
+	"                pull_spec: ' '\n" +
 	"                tag: ' '\n" +
 	"              grace_period: 0s\n" +
+	"              host_aliases:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - hostnames:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  ip: ' '\n" +
 	"              leases:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - env: ' '\n" +
@@ -1828,6 +2689,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              outputs:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1840,7 +2704,38 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
 	"              run_as_script: false\n" +
+	"              run_if: ' '\n" +
+	"              runtime_class: ' '\n" +
+	"              sidecars:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - commands: ' '\n" +
+	"                  env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
+	"                  from: ' '\n" +
+	"                  name: ' '\n" +
+	"                  ports:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                  readiness: ' '\n" +
+	"                  resources:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    limits:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                    requests:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
 	"              timeout: 0s\n" +
+	"        # SharedVolume is a volume mounted into every step of the test, for data\n" +
+	"        # the steps pass on that does not fit into SHARED_DIR.\n" +
+	"        shared_volume:\n" +
+	"            # Size is the requested size of the volume as a Kubernetes quantity,\n" +
+	"            # like 10Gi.\n" +
+	"            size: ' '\n" +
+	"            # StorageClass is the storage class of the volume, the default storage\n" +
+	"            # class of the cluster if not set.\n" +
+	"            storage_class: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
@@ -1880,8 +2775,15 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                as: ' '\n" +
 	"                name: ' '\n" +
 	"                namespace: ' '\n" +
+	"                pull_spec: ' '\n" +
 	"                tag: ' '\n" +
 	"              grace_period: 0s\n" +
+	"              host_aliases:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - hostnames:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  ip: ' '\n" +
 	"              leases:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - env: ' '\n" +
@@ -1891,6 +2793,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              outputs:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - \"\"\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -1903,6 +2808,28 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
 	"              run_as_script: false\n" +
+	"              run_if: ' '\n" +
+	"              runtime_class: ' '\n" +
+	"              sidecars:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - commands: ' '\n" +
+	"                  env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    \"\": \"\"\n" +
+	"                  from: ' '\n" +
+	"                  name: ' '\n" +
+	"                  ports:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                  readiness: ' '\n" +
+	"                  resources:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    limits:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                    requests:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
 	"              timeout: 0s\n" +
 	"        # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"        # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +